@@ -0,0 +1,264 @@
+//go:build e2e
+// +build e2e
+
+// Package e2e contains end-to-end tests for NTM robot mode commands.
+// fake_agent_test.go drives quota fetching, auto-respawn and proactive
+// handoffs against `ntm dev fake-agent`, a scripted stand-in for the real
+// agent CLIs, so the full loops run in tmux without network access.
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ntmcontext "github.com/Dicklesworthstone/ntm/internal/context"
+	"github.com/Dicklesworthstone/ntm/internal/quota"
+	"github.com/Dicklesworthstone/ntm/internal/swarm"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// fakeAgentPane is a tmux pane running a shell in which fake agents are started.
+type fakeAgentPane struct {
+	t      *testing.T
+	logger *TestLogger
+	target string
+	ntm    string
+}
+
+func newFakeAgentPane(t *testing.T, logger *TestLogger, name string) *fakeAgentPane {
+	t.Helper()
+	// The tmux server may predate TestMain's PATH, so use the binary's full path.
+	ntmBin, err := exec.LookPath("ntm")
+	if err != nil {
+		t.Fatalf("[E2E-FAKE] ntm not on PATH: %v", err)
+	}
+	session := fmt.Sprintf("e2e_fake_%s_%d", name, time.Now().UnixNano())
+	logger.Log("[E2E-FAKE] Creating test session: %s", session)
+	if err := exec.Command(tmux.BinaryPath(), "new-session", "-d", "-s", session, "-x", "160", "-y", "50").Run(); err != nil {
+		t.Fatalf("[E2E-FAKE] Failed to create session: %v", err)
+	}
+	t.Cleanup(func() {
+		logger.Log("[E2E-FAKE] Cleanup: killing session %s", session)
+		exec.Command(tmux.BinaryPath(), "kill-session", "-t", session).Run()
+	})
+	return &fakeAgentPane{t: t, logger: logger, target: session, ntm: ntmBin}
+}
+
+// start launches the fake agent and waits for its prompt.
+func (p *fakeAgentPane) start(agentType, prompt string, args ...string) {
+	p.t.Helper()
+	command := strings.Join(append([]string{p.ntm, "dev", "fake-agent", "--agent", agentType}, args...), " ")
+	p.logger.Log("[E2E-FAKE] Starting: %s", command)
+	if err := exec.Command(tmux.BinaryPath(), "send-keys", "-t", p.target, command, "Enter").Run(); err != nil {
+		p.t.Fatalf("[E2E-FAKE] Failed to start fake agent: %v", err)
+	}
+	// Pane captures drop trailing spaces, so match the bare prompt glyph.
+	p.waitFor(strings.TrimSpace(prompt), 10*time.Second)
+}
+
+// send types a line into the pane.
+func (p *fakeAgentPane) send(line string) {
+	p.t.Helper()
+	if err := exec.Command(tmux.BinaryPath(), "send-keys", "-t", p.target, line, "Enter").Run(); err != nil {
+		p.t.Fatalf("[E2E-FAKE] Failed to send %q: %v", line, err)
+	}
+}
+
+func (p *fakeAgentPane) capture() string {
+	out, _ := tmux.CapturePaneOutput(p.target, 200)
+	return out
+}
+
+// waitFor polls the pane until it shows text.
+func (p *fakeAgentPane) waitFor(text string, timeout time.Duration) string {
+	p.t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		out := p.capture()
+		if strings.Contains(out, text) {
+			return out
+		}
+		if time.Now().After(deadline) {
+			p.t.Fatalf("[E2E-FAKE] Timed out waiting for %q in pane:\n%s", text, out)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func writeFakeScenario(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scenario.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("[E2E-FAKE] Failed to write scenario: %v", err)
+	}
+	return path
+}
+
+// =============================================================================
+// Quota fetching from the agents' usage and status screens
+// =============================================================================
+
+func TestE2E_FakeAgentQuotaFetch(t *testing.T) {
+	CommonE2EPrerequisites(t)
+
+	logger := NewTestLogger(t, "fake_agent_quota")
+	defer logger.Close()
+
+	scenario := writeFakeScenario(t, `
+[usage]
+session = 42
+weekly = 71
+resets = "in 3 hours"
+
+[account]
+email = "e2e@example.com"
+`)
+
+	tests := []struct {
+		agentType string
+		prompt    string
+		provider  quota.Provider
+	}{
+		{"cc", "> ", quota.ProviderClaude},
+		{"cod", "› ", quota.ProviderCodex},
+		{"gmi", "gemini> ", quota.ProviderGemini},
+	}
+	for _, tt := range tests {
+		t.Run(tt.agentType, func(t *testing.T) {
+			pane := newFakeAgentPane(t, logger, "quota_"+tt.agentType)
+			pane.start(tt.agentType, tt.prompt, "--scenario", scenario)
+
+			fetcher := &quota.PTYFetcher{CommandTimeout: 10 * time.Second}
+			info, err := fetcher.FetchQuota(context.Background(), pane.target, tt.provider)
+			if err != nil {
+				t.Fatalf("[E2E-FAKE] FetchQuota: %v", err)
+			}
+			logger.Log("[E2E-FAKE] %s quota: session=%.0f weekly=%.0f account=%q error=%q",
+				tt.agentType, info.SessionUsage, info.WeeklyUsage, info.AccountID, info.Error)
+
+			if info.Error != "" {
+				t.Fatalf("[E2E-FAKE] FetchQuota error: %s\n%s", info.Error, pane.capture())
+			}
+			if info.SessionUsage != 42 || info.WeeklyUsage != 71 {
+				t.Errorf("[E2E-FAKE] usage = %.0f/%.0f, want 42/71", info.SessionUsage, info.WeeklyUsage)
+			}
+		})
+	}
+}
+
+// =============================================================================
+// Auto-respawn after a rate limit
+// =============================================================================
+
+func TestE2E_FakeAgentAutoRespawn(t *testing.T) {
+	CommonE2EPrerequisites(t)
+
+	logger := NewTestLogger(t, "fake_agent_respawn")
+	defer logger.Close()
+
+	dir := t.TempDir()
+	scenario := writeFakeScenario(t, `
+[[steps]]
+on = "start"
+event = "rate_limit"
+
+[[steps]]
+match = "RESUME"
+reply = "Resumed after respawn"
+`)
+	args := []string{"dev", "fake-agent", "--agent", "cc", "--scenario", scenario, "--state", filepath.Join(dir, "state.json")}
+
+	pane := newFakeAgentPane(t, logger, "respawn")
+	pane.start("cc", "You've hit your limit", args[4:]...)
+
+	detector := swarm.NewLimitDetector()
+	event, err := detector.CheckPane(pane.target, "cc")
+	if err != nil {
+		t.Fatalf("[E2E-FAKE] CheckPane: %v", err)
+	}
+	if event == nil {
+		t.Fatalf("[E2E-FAKE] Rate limit not detected:\n%s", pane.capture())
+	}
+	logger.Log("[E2E-FAKE] Limit detected with pattern %q", event.Pattern)
+
+	cfg := swarm.DefaultAutoRespawnerConfig()
+	cfg.MarchingOrders = map[string]string{"default": "RESUME the task"}
+	respawner := swarm.NewAutoRespawner().
+		WithConfig(cfg).
+		WithPromptInjector(swarm.NewPromptInjector()).
+		WithCommandBuilder(swarm.NewLaunchCommandBuilder().
+			WithFullPaths(true).
+			WithAgentPath("cc", pane.ntm).
+			WithAgentArgs("cc", args))
+
+	result := respawner.Respawn(*event)
+	logger.Log("[E2E-FAKE] Respawn result: success=%v error=%q duration=%s", result.Success, result.Error, result.Duration)
+	if !result.Success {
+		t.Fatalf("[E2E-FAKE] Respawn failed: %s\n%s", result.Error, pane.capture())
+	}
+
+	// The respawned agent continues with the scenario's next step.
+	pane.waitFor("Resumed after respawn", 15*time.Second)
+	logger.Log("[E2E-FAKE] SUCCESS: agent respawned and resumed")
+}
+
+// =============================================================================
+// Proactive handoff from the agent's transcript
+// =============================================================================
+
+func TestE2E_FakeAgentHandoffTrigger(t *testing.T) {
+	CommonE2EPrerequisites(t)
+
+	logger := NewTestLogger(t, "fake_agent_handoff")
+	defer logger.Close()
+
+	projectDir := t.TempDir()
+	transcript := filepath.Join(t.TempDir(), "session.jsonl")
+	scenario := writeFakeScenario(t, `
+[context]
+window = 200000
+tokens = 20000
+
+[[steps]]
+reply = "Done: refactored the parser\nNext: update the tests"
+tokens = 140000
+`)
+
+	pane := newFakeAgentPane(t, logger, "handoff")
+	pane.start("cc", "> ", "--scenario", scenario, "--transcript", transcript)
+
+	monitor := ntmcontext.NewContextMonitor(ntmcontext.DefaultMonitorConfig())
+	monitor.RegisterAgentWithTranscript("fake-cc", pane.target, "claude-sonnet-4-5", "cc", pane.target, transcript)
+	trigger := ntmcontext.NewHandoffTrigger(ntmcontext.HandoffTriggerConfig{ProjectDir: projectDir}, monitor, nil)
+
+	pane.send("refactor everything")
+	pane.waitFor("refactored the parser", 10*time.Second)
+
+	estimate := monitor.GetEstimate("fake-cc")
+	if estimate == nil {
+		t.Fatalf("[E2E-FAKE] No context estimate from transcript %s", transcript)
+	}
+	logger.Log("[E2E-FAKE] Context estimate: %d/%d tokens (%.1f%%) via %s",
+		estimate.TokensUsed, estimate.ContextLimit, estimate.UsagePercent, estimate.Method)
+	if estimate.UsagePercent < 75 {
+		t.Fatalf("[E2E-FAKE] usage = %.1f%%, want >= 75%%", estimate.UsagePercent)
+	}
+
+	paths, err := trigger.Check()
+	if err != nil {
+		t.Fatalf("[E2E-FAKE] Check: %v", err)
+	}
+	if len(paths) != 1 {
+		t.Fatalf("[E2E-FAKE] handoffs = %v, want one", paths)
+	}
+	if _, err := os.Stat(paths[0]); err != nil {
+		t.Errorf("[E2E-FAKE] handoff not written: %v", err)
+	}
+	logger.Log("[E2E-FAKE] SUCCESS: handoff written to %s", paths[0])
+}
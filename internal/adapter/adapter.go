@@ -0,0 +1,262 @@
+// Package adapter describes how ntm drives each agent CLI.
+//
+// Everything agent-specific about launching an agent, typing prompts into
+// it, interrupting or quitting it, reading its state, querying its quota,
+// compacting its context and switching its model lives behind the
+// AgentAdapter interface. Built-in adapters are registered by the files in
+// this package, one per agent; agent plugins register a Base built from
+// their definition. Agent types without an adapter get a generic one that
+// uses Ctrl+C and the shared output patterns.
+package adapter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/agent"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// ErrUnsupported is returned by operations an agent does not support.
+var ErrUnsupported = errors.New("not supported by this agent")
+
+// AgentAdapter is the agent-specific behaviour ntm relies on.
+type AgentAdapter interface {
+	// Type is the agent type this adapter handles, as used in pane titles.
+	Type() agent.AgentType
+
+	// Launch returns the binary and default arguments used when ntm builds
+	// the agent's command line itself.
+	Launch() Launch
+
+	// SendPrompt types prompt into the pane and submits it.
+	SendPrompt(paneID, prompt string) error
+
+	// Interrupt stops the agent's current turn without quitting it.
+	Interrupt(paneID string) error
+
+	// Exit quits the agent in the target pane and returns the name of the
+	// exit method used, e.g. "double_ctrl_c".
+	Exit(target string) (string, error)
+
+	// ParseState derives the agent's state from captured pane output.
+	ParseState(output string) (*agent.AgentState, error)
+
+	// Quota returns the commands that print the agent's usage and quota.
+	Quota() Quota
+
+	// Compaction returns the commands that shrink the agent's context.
+	Compaction() Compaction
+
+	// SwitchModel switches the running agent to model. It returns
+	// ErrUnsupported if the agent cannot switch models in place.
+	SwitchModel(paneID, model string) error
+}
+
+// Launch is how an agent's command line is built.
+type Launch struct {
+	Binary string   // Executable name, e.g. "claude"
+	Args   []string // Default arguments
+}
+
+// Quota holds an agent's quota commands. Provider names the quota parser
+// used for their output; an empty Provider means the agent has none.
+type Quota struct {
+	Provider      string
+	UsageCommand  string // e.g. "/usage"
+	StatusCommand string // e.g. "/status"
+}
+
+// Compaction holds an agent's context management commands. Empty commands
+// are unsupported.
+type Compaction struct {
+	CompactCommand string // e.g. "/compact"
+	ClearCommand   string // e.g. "/clear"
+}
+
+// Delays between typing a prompt and each of the two Enter presses that
+// submit it. Agent TUIs can swallow an Enter that arrives while they are
+// still rendering pasted text, so a second one follows.
+var (
+	PromptFirstEnterDelay  = 1 * time.Second
+	PromptSecondEnterDelay = 500 * time.Millisecond
+)
+
+// exitKeyDelay separates the keys of an exit sequence. Claude Code needs
+// its two Ctrl+Cs about 100ms apart to treat them as a quit.
+const exitKeyDelay = 100 * time.Millisecond
+
+// Base is a declarative AgentAdapter. Built-in adapters embed it and
+// override what they do differently; plugins use it as is.
+type Base struct {
+	AgentType agent.AgentType
+	Binary    string
+	Args      []string
+
+	// ExitKeys are sent in order to quit the agent. Each entry is passed to
+	// tmux send-keys, so key names such as "C-c", "Escape" and "Enter" are
+	// pressed and other text is typed. Empty means a single Ctrl+C.
+	ExitKeys []string
+	// ExitMethod names the exit for restart reports (default "exit_sequence").
+	ExitMethod string
+
+	QuotaCommands      Quota
+	CompactionCommands Compaction
+
+	// ModelCommand switches models when {model} is replaced with the model
+	// name, e.g. "/model {model}". Empty means unsupported.
+	ModelCommand string
+}
+
+// Type implements AgentAdapter.
+func (b *Base) Type() agent.AgentType { return b.AgentType }
+
+// Launch implements AgentAdapter.
+func (b *Base) Launch() Launch {
+	binary := b.Binary
+	if binary == "" {
+		binary = string(b.AgentType)
+	}
+	return Launch{Binary: binary, Args: append([]string(nil), b.Args...)}
+}
+
+// SendPrompt types prompt with the agent-aware send method, which pastes
+// multi-line text through a buffer where the agent needs it, then presses
+// Enter twice.
+func (b *Base) SendPrompt(paneID, prompt string) error {
+	if err := tmux.SendKeysForAgent(paneID, prompt, false, b.AgentType); err != nil {
+		return err
+	}
+	time.Sleep(PromptFirstEnterDelay)
+	if err := tmux.SendKeys(paneID, "", true); err != nil {
+		return err
+	}
+	time.Sleep(PromptSecondEnterDelay)
+	return tmux.SendKeys(paneID, "", true)
+}
+
+// Interrupt implements AgentAdapter by sending Ctrl+C.
+func (b *Base) Interrupt(paneID string) error {
+	return tmux.SendInterrupt(paneID)
+}
+
+// Exit sends ExitKeys, or Ctrl+C if there are none.
+func (b *Base) Exit(target string) (string, error) {
+	if len(b.ExitKeys) == 0 {
+		if err := sendKey(target, "C-c"); err != nil {
+			return "ctrl_c_fallback", fmt.Errorf("ctrl-c failed: %w", err)
+		}
+		return "ctrl_c_fallback", nil
+	}
+	method := b.ExitMethod
+	if method == "" {
+		method = "exit_sequence"
+	}
+	return method, sendKeySequence(target, b.ExitKeys)
+}
+
+// ParseState implements AgentAdapter with the shared output parser.
+func (b *Base) ParseState(output string) (*agent.AgentState, error) {
+	return agent.NewParser().ParseWithHint(output, b.AgentType)
+}
+
+// Quota implements AgentAdapter.
+func (b *Base) Quota() Quota { return b.QuotaCommands }
+
+// Compaction implements AgentAdapter.
+func (b *Base) Compaction() Compaction { return b.CompactionCommands }
+
+// SwitchModel types ModelCommand with the model name filled in.
+func (b *Base) SwitchModel(paneID, model string) error {
+	if b.ModelCommand == "" {
+		return fmt.Errorf("switch %s model: %w", b.AgentType, ErrUnsupported)
+	}
+	if model == "" || strings.ContainsAny(model, "\r\n") {
+		return fmt.Errorf("invalid model name %q", model)
+	}
+	return tmux.SendKeys(paneID, strings.ReplaceAll(b.ModelCommand, "{model}", model), true)
+}
+
+// sendKey presses a named key, or types text that is not a key name.
+func sendKey(target, key string) error {
+	return tmux.DefaultClient.RunSilent("send-keys", "-t", target, key)
+}
+
+// sendLiteral types text without interpreting key names.
+func sendLiteral(target, text string) error {
+	return tmux.DefaultClient.RunSilent("send-keys", "-t", target, "-l", text)
+}
+
+func sendKeySequence(target string, keys []string) error {
+	for i, key := range keys {
+		if i > 0 {
+			time.Sleep(exitKeyDelay)
+		}
+		if err := sendKey(target, key); err != nil {
+			return fmt.Errorf("exit sequence key %q failed: %w", key, err)
+		}
+	}
+	return nil
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[agent.AgentType]AgentAdapter)
+)
+
+// Register makes a available for its agent type, replacing any adapter
+// already registered for it.
+func Register(a AgentAdapter) error {
+	if a == nil || a.Type() == "" {
+		return fmt.Errorf("adapter has no agent type")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[a.Type()] = a
+	return nil
+}
+
+// Unregister removes the adapter for t.
+func Unregister(t agent.AgentType) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, t)
+}
+
+// Lookup returns the adapter registered for t.
+func Lookup(t agent.AgentType) (AgentAdapter, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	a, ok := registry[t]
+	return a, ok
+}
+
+// Get returns the adapter for t, or a generic one if none is registered.
+func Get(t agent.AgentType) AgentAdapter {
+	if a, ok := Lookup(t); ok {
+		return a
+	}
+	return &Base{AgentType: t}
+}
+
+// All returns the registered adapters sorted by agent type.
+func All() []AgentAdapter {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	all := make([]AgentAdapter, 0, len(registry))
+	for _, a := range registry {
+		all = append(all, a)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Type() < all[j].Type() })
+	return all
+}
+
+func mustRegister(a AgentAdapter) {
+	if err := Register(a); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,78 @@
+package adapter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/agent"
+)
+
+func TestBuiltinAdapters(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		agentType agent.AgentType
+		binary    string
+		provider  string
+		compact   string
+		clear     string
+		switches  bool
+	}{
+		{agent.AgentTypeClaudeCode, "claude", "claude", "/compact", "/clear", true},
+		{agent.AgentTypeCodex, "codex", "codex", "", "", false},
+		{agent.AgentTypeGemini, "gemini", "gemini", "", "/clear", false},
+		{agent.AgentTypeOllama, "ollama", "", "", "", true},
+		{agent.AgentTypeAider, "aider", "", "", "", true},
+	}
+	for _, tt := range tests {
+		a, ok := Lookup(tt.agentType)
+		if !ok {
+			t.Errorf("no adapter registered for %s", tt.agentType)
+			continue
+		}
+		if a.Type() != tt.agentType || a.Launch().Binary != tt.binary {
+			t.Errorf("%s: type %s, binary %q", tt.agentType, a.Type(), a.Launch().Binary)
+		}
+		if got := a.Quota().Provider; got != tt.provider {
+			t.Errorf("%s: quota provider = %q, want %q", tt.agentType, got, tt.provider)
+		}
+		if c := a.Compaction(); c.CompactCommand != tt.compact || c.ClearCommand != tt.clear {
+			t.Errorf("%s: compaction = %+v", tt.agentType, c)
+		}
+		if err := a.SwitchModel("", ""); errors.Is(err, ErrUnsupported) == tt.switches {
+			t.Errorf("%s: SwitchModel error = %v, want supported=%v", tt.agentType, err, tt.switches)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+	const goose agent.AgentType = "adapter-test-goose"
+
+	generic := Get(goose)
+	if _, ok := Lookup(goose); ok || generic.Type() != goose || generic.Launch().Binary != string(goose) {
+		t.Fatalf("unregistered adapter = %+v", generic)
+	}
+
+	if err := Register(&Base{AgentType: goose, Binary: "goose", ExitKeys: []string{"/exit", "Enter"}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Unregister(goose) })
+	if a := Get(goose); a.Launch().Binary != "goose" {
+		t.Errorf("registered binary = %q, want goose", a.Launch().Binary)
+	}
+
+	if err := Register(&Base{}); err == nil {
+		t.Error("Register accepted an adapter without an agent type")
+	}
+}
+
+func TestBaseParseState(t *testing.T) {
+	t.Parallel()
+	state, err := Get(agent.AgentTypeClaudeCode).ParseState("Error: rate limit exceeded, please wait")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Type != agent.AgentTypeClaudeCode {
+		t.Errorf("Type = %s, want cc", state.Type)
+	}
+}
@@ -0,0 +1,14 @@
+package adapter
+
+import "github.com/Dicklesworthstone/ntm/internal/agent"
+
+// Aider quits with /exit and switches models with /model. It manages its
+// own chat history, so ntm does not compact it.
+var aiderAdapter = &Base{
+	AgentType:    agent.AgentTypeAider,
+	Binary:       "aider",
+	ExitKeys:     []string{"/exit", "Enter"},
+	ModelCommand: "/model {model}",
+}
+
+func init() { mustRegister(aiderAdapter) }
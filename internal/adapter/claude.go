@@ -0,0 +1,25 @@
+package adapter
+
+import "github.com/Dicklesworthstone/ntm/internal/agent"
+
+// Claude Code quits on two Ctrl+Cs sent about 100ms apart, has /compact and
+// /clear, and switches models with /model.
+var claudeAdapter = &Base{
+	AgentType:  agent.AgentTypeClaudeCode,
+	Binary:     "claude",
+	Args:       []string{"--dangerously-skip-permissions"},
+	ExitKeys:   []string{"C-c", "C-c"},
+	ExitMethod: "double_ctrl_c",
+	QuotaCommands: Quota{
+		Provider:      "claude",
+		UsageCommand:  "/usage",
+		StatusCommand: "/status",
+	},
+	CompactionCommands: Compaction{
+		CompactCommand: "/compact",
+		ClearCommand:   "/clear",
+	},
+	ModelCommand: "/model {model}",
+}
+
+func init() { mustRegister(claudeAdapter) }
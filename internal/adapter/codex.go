@@ -0,0 +1,32 @@
+package adapter
+
+import (
+	"fmt"
+
+	"github.com/Dicklesworthstone/ntm/internal/agent"
+)
+
+// codexAdapter drives the Codex CLI. It quits with /exit and has no
+// compaction command; its /model command opens a picker, so models cannot
+// be switched in place.
+type codexAdapter struct{ Base }
+
+func (a *codexAdapter) Exit(target string) (string, error) {
+	if err := sendLiteral(target, "/exit\n"); err != nil {
+		return "exit_command", fmt.Errorf("exit command failed: %w", err)
+	}
+	return "exit_command", nil
+}
+
+func init() {
+	mustRegister(&codexAdapter{Base{
+		AgentType: agent.AgentTypeCodex,
+		Binary:    "codex",
+		Args:      []string{"--quiet", "--auto-approve"},
+		QuotaCommands: Quota{
+			Provider:      "codex",
+			UsageCommand:  "/usage",
+			StatusCommand: "/status",
+		},
+	}})
+}
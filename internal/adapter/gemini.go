@@ -0,0 +1,39 @@
+package adapter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/agent"
+)
+
+// geminiAdapter drives the Gemini CLI. It leaves shell mode with Escape
+// before quitting with /exit, reports quota through /auth status, and can
+// clear but not compact its history. Its /model command opens a picker.
+type geminiAdapter struct{ Base }
+
+func (a *geminiAdapter) Exit(target string) (string, error) {
+	const method = "escape_then_exit"
+	if err := sendKey(target, "Escape"); err != nil {
+		return method, fmt.Errorf("escape failed: %w", err)
+	}
+	time.Sleep(exitKeyDelay)
+	if err := sendLiteral(target, "/exit\n"); err != nil {
+		return method, fmt.Errorf("exit failed: %w", err)
+	}
+	return method, nil
+}
+
+func init() {
+	mustRegister(&geminiAdapter{Base{
+		AgentType: agent.AgentTypeGemini,
+		Binary:    "gemini",
+		Args:      []string{"--non-interactive"},
+		QuotaCommands: Quota{
+			Provider:      "gemini",
+			UsageCommand:  "/auth status",
+			StatusCommand: "/auth status",
+		},
+		CompactionCommands: Compaction{ClearCommand: "/clear"},
+	}})
+}
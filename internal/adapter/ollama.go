@@ -0,0 +1,30 @@
+package adapter
+
+import (
+	"strings"
+
+	"github.com/Dicklesworthstone/ntm/internal/agent"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// ollamaAdapter drives `ollama run`. Its REPL submits on every newline, so
+// multi-line prompts are wrapped in triple quotes; it quits with /bye and
+// loads another model with /load.
+type ollamaAdapter struct{ Base }
+
+func (a *ollamaAdapter) SendPrompt(paneID, prompt string) error {
+	if strings.Contains(prompt, "\n") {
+		return tmux.SendBuffer(paneID, `"""`+prompt+`"""`, true)
+	}
+	return tmux.SendKeys(paneID, prompt, true)
+}
+
+func init() {
+	mustRegister(&ollamaAdapter{Base{
+		AgentType:    agent.AgentTypeOllama,
+		Binary:       "ollama",
+		Args:         []string{"run", "codellama:latest"},
+		ExitKeys:     []string{"/bye", "Enter"},
+		ModelCommand: "/load {model}",
+	}})
+}
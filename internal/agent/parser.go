@@ -180,6 +180,12 @@ func (p *parserImpl) extractMetrics(output string, state *AgentState) {
 	
 	case AgentTypeCursor, AgentTypeWindsurf, AgentTypeAider:
 		// No specific metrics yet for these agents
+
+	default:
+		// Plugin agents may declare how they report a compacted context
+		if ps, ok := RegisteredPatternSet(state.Type); ok && ps.MatchesCompaction(getLastNLines(output, 50)) {
+			state.IsContextLow = true
+		}
 	}
 }
 
@@ -226,6 +232,9 @@ func (p *parserImpl) detectStateFlags(output string, state *AgentState) {
 // We scan recent output (last 50 lines) to avoid stale errors triggering state.
 func (p *parserImpl) detectRateLimit(output string, agentType AgentType) bool {
 	recentOutput := getLastNLines(output, 50)
+	if ps, ok := RegisteredPatternSet(agentType); ok {
+		return ps.MatchesRateLimit(recentOutput)
+	}
 
 	switch agentType {
 	case AgentTypeClaudeCode:
@@ -256,6 +265,9 @@ func (p *parserImpl) detectRateLimit(output string, agentType AgentType) bool {
 func (p *parserImpl) detectWorking(output string, agentType AgentType) bool {
 	// Check recent output - recent activity is more relevant
 	recentOutput := getLastNLines(output, 20)
+	if ps, ok := RegisteredPatternSet(agentType); ok {
+		return ps.MatchesWorking(recentOutput)
+	}
 
 	switch agentType {
 	case AgentTypeClaudeCode:
@@ -286,6 +298,9 @@ func (p *parserImpl) detectWorking(output string, agentType AgentType) bool {
 func (p *parserImpl) detectIdle(output string, agentType AgentType) bool {
 	// Check last lines for prompt indicators
 	lastLines := getLastNLines(output, 5)
+	if ps, ok := RegisteredPatternSet(agentType); ok {
+		return ps.MatchesIdle(lastLines)
+	}
 
 	switch agentType {
 	case AgentTypeClaudeCode:
@@ -316,6 +331,9 @@ func (p *parserImpl) detectIdle(output string, agentType AgentType) bool {
 func (p *parserImpl) detectError(output string, agentType AgentType) bool {
 	// Check recent output for error patterns
 	recentOutput := getLastNLines(output, 10)
+	if ps, ok := RegisteredPatternSet(agentType); ok {
+		return ps.MatchesError(recentOutput)
+	}
 
 	switch agentType {
 	case AgentTypeClaudeCode:
@@ -339,6 +357,9 @@ func (p *parserImpl) detectError(output string, agentType AgentType) bool {
 func (p *parserImpl) collectLimitIndicators(output string, agentType AgentType) []string {
 	// Focus on recent output to match detection logic
 	recentOutput := getLastNLines(output, 50)
+	if ps, ok := RegisteredPatternSet(agentType); ok {
+		return ps.rateLimitIndicators(recentOutput)
+	}
 
 	switch agentType {
 	case AgentTypeClaudeCode:
@@ -369,6 +390,9 @@ func (p *parserImpl) collectLimitIndicators(output string, agentType AgentType)
 func (p *parserImpl) collectWorkIndicators(output string, agentType AgentType) []string {
 	// Focus on recent output
 	recentOutput := getLastNLines(output, 20)
+	if ps, ok := RegisteredPatternSet(agentType); ok {
+		return ps.workIndicators(recentOutput)
+	}
 
 	switch agentType {
 	case AgentTypeClaudeCode:
@@ -398,6 +398,14 @@ type PatternSet struct {
 	TokenPattern      *regexp.Regexp // Token usage extraction
 	MemoryPattern     *regexp.Regexp // Memory usage (Gemini)
 	HeaderPattern     *regexp.Regexp
+
+	// Regular expressions for agent types registered at runtime (plugins).
+	// They are checked alongside the substring patterns above.
+	RateLimitRegexes  []*regexp.Regexp
+	WorkingRegexes    []*regexp.Regexp
+	ErrorRegexes      []*regexp.Regexp
+	CompactionRegexes []*regexp.Regexp // Context was compacted or reset
+	ContextLimit      int              // Context window in tokens (0 = unknown)
 }
 
 // GetPatternSet returns the pattern set for the given agent type.
@@ -456,6 +464,9 @@ func GetPatternSet(agentType AgentType) *PatternSet {
 			HeaderPattern:     aiderHeaderPattern,
 		}
 	default:
+		if ps, ok := RegisteredPatternSet(agentType); ok {
+			return ps
+		}
 		return &PatternSet{} // Empty pattern set for unknown types
 	}
 }
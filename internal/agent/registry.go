@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// Agent types defined by plugins are registered here so that parsing and
+// status detection treat them like the built-in agents.
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[AgentType]*PatternSet)
+)
+
+// RegisterPatternSet registers the patterns for a plugin-defined agent type,
+// replacing any earlier registration. Built-in types cannot be overridden.
+func RegisterPatternSet(agentType AgentType, ps *PatternSet) error {
+	if agentType == "" || agentType == AgentTypeUnknown || agentType.IsValid() {
+		return fmt.Errorf("cannot register patterns for built-in agent type %q", agentType)
+	}
+	if ps == nil {
+		ps = &PatternSet{}
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[agentType] = ps
+	return nil
+}
+
+// UnregisterPatternSet removes a registered agent type.
+func UnregisterPatternSet(agentType AgentType) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, agentType)
+}
+
+// RegisteredPatternSet returns the patterns registered for agentType.
+func RegisteredPatternSet(agentType AgentType) (*PatternSet, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	ps, ok := registry[agentType]
+	return ps, ok
+}
+
+// IsRegistered reports whether agentType was registered by a plugin.
+func IsRegistered(agentType AgentType) bool {
+	_, ok := RegisteredPatternSet(agentType)
+	return ok
+}
+
+// MatchesRateLimit reports whether text shows the agent hit a usage limit.
+func (ps *PatternSet) MatchesRateLimit(text string) bool {
+	return matchAny(text, ps.RateLimitPatterns) || matchAnyRegex(text, ps.RateLimitRegexes)
+}
+
+// MatchesWorking reports whether text shows the agent producing output.
+func (ps *PatternSet) MatchesWorking(text string) bool {
+	return matchAny(text, ps.WorkingPatterns) || matchAnyRegex(text, ps.WorkingRegexes)
+}
+
+// MatchesIdle reports whether text shows the agent waiting for input.
+func (ps *PatternSet) MatchesIdle(text string) bool {
+	return matchAnyRegex(text, ps.IdlePatterns)
+}
+
+// MatchesError reports whether text shows the agent in an error state.
+func (ps *PatternSet) MatchesError(text string) bool {
+	return matchAny(text, ps.ErrorPatterns) || matchAnyRegex(text, ps.ErrorRegexes)
+}
+
+// MatchesCompaction reports whether text shows the agent's context was
+// compacted or reset.
+func (ps *PatternSet) MatchesCompaction(text string) bool {
+	return matchAnyRegex(text, ps.CompactionRegexes)
+}
+
+func (ps *PatternSet) rateLimitIndicators(text string) []string {
+	return append(collectMatches(text, ps.RateLimitPatterns), collectRegexMatches(text, ps.RateLimitRegexes)...)
+}
+
+func (ps *PatternSet) workIndicators(text string) []string {
+	return append(collectMatches(text, ps.WorkingPatterns), collectRegexMatches(text, ps.WorkingRegexes)...)
+}
+
+// collectRegexMatches returns the text matched by each regex that matches.
+func collectRegexMatches(text string, patterns []*regexp.Regexp) []string {
+	var matches []string
+	for _, p := range patterns {
+		if m := p.FindString(text); m != "" {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRegisterPatternSet(t *testing.T) {
+	const goose AgentType = "goose-registry-test"
+	ps := &PatternSet{
+		IdlePatterns:      []*regexp.Regexp{regexp.MustCompile(`(?m)^goose>\s*$`)},
+		WorkingRegexes:    []*regexp.Regexp{regexp.MustCompile(`Thinking`)},
+		RateLimitRegexes:  []*regexp.Regexp{regexp.MustCompile(`(?i)quota exceeded`)},
+		ErrorRegexes:      []*regexp.Regexp{regexp.MustCompile(`^Error:`)},
+		CompactionRegexes: []*regexp.Regexp{regexp.MustCompile(`context compacted`)},
+	}
+	if err := RegisterPatternSet(goose, ps); err != nil {
+		t.Fatalf("RegisterPatternSet: %v", err)
+	}
+	t.Cleanup(func() { UnregisterPatternSet(goose) })
+
+	if err := RegisterPatternSet(AgentTypeClaudeCode, ps); err == nil {
+		t.Error("registering a built-in type should fail")
+	}
+	if !IsRegistered(goose) || GetPatternSet(goose) != ps {
+		t.Fatal("registered pattern set not returned")
+	}
+
+	parser := NewParser()
+	tests := []struct {
+		name   string
+		output string
+		check  func(*AgentState) bool
+	}{
+		{"idle", "done\ngoose> ", func(s *AgentState) bool { return s.IsIdle && !s.IsWorking }},
+		{"working", "Thinking about the diff", func(s *AgentState) bool { return s.IsWorking && len(s.WorkIndicators) == 1 }},
+		{"rate limited", "Quota exceeded, retry later", func(s *AgentState) bool {
+			return s.IsRateLimited && len(s.LimitIndicators) == 1
+		}},
+		{"error", "Error: boom", func(s *AgentState) bool { return s.IsInError }},
+		{"compaction", "note: context compacted\ngoose> ", func(s *AgentState) bool { return s.IsContextLow }},
+	}
+	for _, tt := range tests {
+		state, err := parser.ParseWithHint(tt.output, goose)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !tt.check(state) {
+			t.Errorf("%s: unexpected state %+v", tt.name, state)
+		}
+	}
+}
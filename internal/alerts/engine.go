@@ -0,0 +1,317 @@
+package alerts
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// errorEventTypes and rateLimitEventTypes are the event types counted by the
+// error_count and rate_limit_count metrics.
+var (
+	errorEventTypes     = []string{"agent.error", "agent.crashed", "agent_error", "error"}
+	rateLimitEventTypes = []string{"agent.rate_limit"}
+)
+
+// Sample is a point-in-time reading of an agent's metrics. A sample with an
+// empty Pane holds session-wide metrics (such as file_conflicts), which
+// pane scopes inherit when they lack their own value.
+type Sample struct {
+	Time    time.Time
+	Session string
+	Pane    string
+	Agent   string
+	Metrics map[string]float64
+}
+
+// RuleEvent is an event considered by rule matchers and event counts.
+// Events are counted in the scope of their pane, or of the session when
+// they name no pane.
+type RuleEvent struct {
+	Time    time.Time
+	Type    string
+	Session string
+	Pane    string
+	Agent   string
+	Message string
+}
+
+// Firing is a rule alert that started or stopped firing.
+type Firing struct {
+	Rule     *CompiledRule `json:"-"`
+	RuleName string        `json:"rule"`
+	Key      string        `json:"dedup_key"`
+	Alert    Alert         `json:"alert"`
+	Resolved bool          `json:"resolved"`
+}
+
+type ruleScope struct{ session, pane string }
+
+type ruleState struct {
+	pendingSince time.Time
+	firing       *Alert
+}
+
+// Engine evaluates compiled rules over metric samples and events. It is not
+// safe for concurrent use. Time comes from the caller, so the same engine
+// drives live evaluation and replays of recorded history.
+type Engine struct {
+	rules   []*CompiledRule
+	horizon time.Duration
+	samples map[ruleScope][]Sample
+	agents  map[ruleScope]string
+	events  []RuleEvent
+	states  map[string]*ruleState
+}
+
+// NewEngine creates an engine for rules.
+func NewEngine(rules []*CompiledRule) *Engine {
+	e := &Engine{
+		rules:   rules,
+		horizon: defaultRuleWindow,
+		samples: make(map[ruleScope][]Sample),
+		agents:  make(map[ruleScope]string),
+		states:  make(map[string]*ruleState),
+	}
+	for _, r := range rules {
+		e.horizon = max(e.horizon, r.window)
+	}
+	return e
+}
+
+// Rules returns the engine's rules.
+func (e *Engine) Rules() []*CompiledRule { return e.rules }
+
+// Observe records a metrics sample.
+func (e *Engine) Observe(s Sample) {
+	sc := ruleScope{s.Session, s.Pane}
+	e.samples[sc] = append(e.samples[sc], s)
+	if s.Agent != "" {
+		e.agents[sc] = s.Agent
+	}
+	e.prune(s.Time)
+}
+
+// ObserveEvent records an event.
+func (e *Engine) ObserveEvent(ev RuleEvent) {
+	e.events = append(e.events, ev)
+	if ev.Agent != "" && ev.Pane != "" {
+		e.agents[ruleScope{ev.Session, ev.Pane}] = ev.Agent
+	}
+	e.prune(ev.Time)
+}
+
+// prune drops samples and events older than the longest rule window, keeping
+// the latest sample of each scope.
+func (e *Engine) prune(now time.Time) {
+	cutoff := now.Add(-e.horizon)
+	for sc, list := range e.samples {
+		i := 0
+		for i < len(list)-1 && list[i].Time.Before(cutoff) {
+			i++
+		}
+		e.samples[sc] = list[i:]
+	}
+	i := 0
+	for i < len(e.events) && e.events[i].Time.Before(cutoff) {
+		i++
+	}
+	e.events = e.events[i:]
+}
+
+// Evaluate checks every rule at now and returns the alerts that started or
+// stopped firing. A rule fires once its condition has held for its for
+// duration and stays firing, without repeating, until the condition clears.
+func (e *Engine) Evaluate(now time.Time) []Firing {
+	var out []Firing
+	for _, r := range e.rules {
+		type match struct {
+			sc   ruleScope
+			vars map[string]interface{}
+		}
+		matched := make(map[string]match)
+		for _, sc := range e.scopes(r, now) {
+			metrics := e.metrics(r, sc, now)
+			if !r.expr.eval(func(name string) (float64, bool) {
+				v, ok := metrics[name]
+				return v, ok
+			}) {
+				continue
+			}
+			vars := map[string]interface{}{
+				"rule":    r.Name,
+				"expr":    r.Expr,
+				"session": sc.session,
+				"pane":    sc.pane,
+				"agent":   e.agents[sc],
+			}
+			for k, v := range metrics {
+				vars[k] = v
+			}
+			key := render(r.dedup, vars)
+			if _, ok := matched[key]; !ok {
+				matched[key] = match{sc, vars}
+			}
+		}
+
+		keys := make([]string, 0, len(matched))
+		for key := range matched {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			m := matched[key]
+			id := r.Name + "\x00" + key
+			st := e.states[id]
+			if st == nil {
+				st = &ruleState{pendingSince: now}
+				e.states[id] = st
+			}
+			if st.firing != nil || now.Sub(st.pendingSince) < r.forDur {
+				continue
+			}
+			metrics := make(map[string]interface{}, len(m.vars))
+			for k, v := range m.vars {
+				if containsString(RuleMetrics, k) {
+					metrics[k] = v
+				}
+			}
+			st.firing = &Alert{
+				ID:         generateAlertID(AlertRuleFired, r.Name, key),
+				Type:       AlertRuleFired,
+				Severity:   r.Severity,
+				Source:     "rule:" + r.Name,
+				Message:    render(r.message, m.vars),
+				Session:    m.sc.session,
+				Pane:       m.sc.pane,
+				Context:    map[string]interface{}{"rule": r.Name, "expr": r.Expr, "dedup_key": key, "metrics": metrics},
+				CreatedAt:  now,
+				LastSeenAt: now,
+				Count:      1,
+			}
+			out = append(out, Firing{Rule: r, RuleName: r.Name, Key: key, Alert: *st.firing})
+		}
+
+		prefix := r.Name + "\x00"
+		var cleared []string
+		for id, st := range e.states {
+			if strings.HasPrefix(id, prefix) {
+				if _, ok := matched[strings.TrimPrefix(id, prefix)]; !ok {
+					cleared = append(cleared, id)
+					if st.firing != nil {
+						resolved := *st.firing
+						resolved.ResolvedAt = &now
+						resolved.LastSeenAt = now
+						out = append(out, Firing{Rule: r, RuleName: r.Name, Key: strings.TrimPrefix(id, prefix), Alert: resolved, Resolved: true})
+					}
+				}
+			}
+		}
+		for _, id := range cleared {
+			delete(e.states, id)
+		}
+	}
+	return out
+}
+
+// Firing returns the alerts currently firing.
+func (e *Engine) Firing() []Alert {
+	var active []Alert
+	for _, st := range e.states {
+		if st.firing != nil {
+			active = append(active, *st.firing)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].CreatedAt.Before(active[j].CreatedAt) })
+	return active
+}
+
+// scopes returns the scopes r is evaluated in at now: those with a sample
+// inside r's window and those with events in it.
+func (e *Engine) scopes(r *CompiledRule, now time.Time) []ruleScope {
+	cutoff := now.Add(-r.window)
+	seen := make(map[ruleScope]bool)
+	var scopes []ruleScope
+	add := func(sc ruleScope) {
+		if !seen[sc] {
+			seen[sc] = true
+			scopes = append(scopes, sc)
+		}
+	}
+	for sc, list := range e.samples {
+		if last := list[len(list)-1]; !last.Time.Before(cutoff) && !last.Time.After(now) {
+			add(sc)
+		}
+	}
+	for _, ev := range e.events {
+		if !ev.Time.Before(cutoff) && !ev.Time.After(now) {
+			add(ruleScope{ev.Session, ev.Pane})
+		}
+	}
+	sort.Slice(scopes, func(i, j int) bool {
+		if scopes[i].session != scopes[j].session {
+			return scopes[i].session < scopes[j].session
+		}
+		return scopes[i].pane < scopes[j].pane
+	})
+	return scopes
+}
+
+// metrics computes the metric values of sc for r at now.
+func (e *Engine) metrics(r *CompiledRule, sc ruleScope, now time.Time) map[string]float64 {
+	cutoff := now.Add(-r.window)
+	values := make(map[string]float64)
+
+	inherit := func(sc ruleScope) {
+		list := e.samples[sc]
+		var first, last *Sample
+		for i := range list {
+			if list[i].Time.Before(cutoff) || list[i].Time.After(now) {
+				continue
+			}
+			if first == nil {
+				first = &list[i]
+			}
+			last = &list[i]
+		}
+		if last == nil {
+			return
+		}
+		for k, v := range last.Metrics {
+			if _, ok := values[k]; !ok {
+				values[k] = v
+			}
+		}
+		if _, ok := values[MetricCostPerHour]; !ok && last.Time.After(first.Time) {
+			c0, ok0 := first.Metrics[MetricCostUSD]
+			c1, ok1 := last.Metrics[MetricCostUSD]
+			if ok0 && ok1 {
+				values[MetricCostPerHour] = (c1 - c0) / last.Time.Sub(first.Time).Hours()
+			}
+		}
+	}
+	inherit(sc)
+	if sc.pane != "" {
+		inherit(ruleScope{sc.session, ""})
+	}
+
+	var errs, limits, matched float64
+	for _, ev := range e.events {
+		if ev.Session != sc.session || ev.Pane != sc.pane || ev.Time.Before(cutoff) || ev.Time.After(now) {
+			continue
+		}
+		if containsString(errorEventTypes, ev.Type) {
+			errs++
+		}
+		if containsString(rateLimitEventTypes, ev.Type) {
+			limits++
+		}
+		if r.matches(ev) {
+			matched++
+		}
+	}
+	values[MetricErrorCount] = errs
+	values[MetricRateLimitCount] = limits
+	values[MetricEvents] = matched
+	return values
+}
@@ -0,0 +1,65 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/notify"
+)
+
+var ruleWebhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// Route delivers a rule firing to the rule's notify channels and webhooks.
+// Notify channels only hear about alerts that start firing; webhooks also
+// receive resolutions. n may be nil when no channels are configured.
+func Route(f Firing, n *notify.Notifier) error {
+	if f.Rule == nil {
+		return nil
+	}
+	var errs []error
+	if !f.Resolved && len(f.Rule.Notify) > 0 && n != nil {
+		event := notify.Event{
+			Type:      notify.EventAlertRule,
+			Timestamp: f.Alert.CreatedAt,
+			Session:   f.Alert.Session,
+			Pane:      f.Alert.Pane,
+			Message:   f.Alert.Message,
+			Details: map[string]string{
+				"rule":      f.RuleName,
+				"severity":  string(f.Alert.Severity),
+				"dedup_key": f.Key,
+			},
+		}
+		if err := n.NotifyChannels(event, f.Rule.Notify); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(f.Rule.Webhooks) > 0 {
+		body, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+		for _, hook := range f.Rule.Webhooks {
+			if err := postRuleWebhook(hook, body); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func postRuleWebhook(url string, body []byte) error {
+	resp, err := ruleWebhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", url, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s: status %d", url, resp.StatusCode)
+	}
+	return nil
+}
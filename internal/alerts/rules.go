@@ -0,0 +1,478 @@
+package alerts
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// Metrics available to rule expressions.
+const (
+	// MetricContextPct is the agent's context window usage (0-100)
+	MetricContextPct = "context_pct"
+	// MetricIdleMinutes is how long the agent has had no output
+	MetricIdleMinutes = "idle_minutes"
+	// MetricCostUSD is the agent's cumulative spend
+	MetricCostUSD = "cost_usd"
+	// MetricCostPerHour is the agent's spend rate over the rule window
+	MetricCostPerHour = "cost_per_hour"
+	// MetricErrorCount is the number of agent error events in the rule window
+	MetricErrorCount = "error_count"
+	// MetricRateLimitCount is the number of rate limit events in the rule window
+	MetricRateLimitCount = "rate_limit_count"
+	// MetricFileConflicts is the number of active file reservation conflicts
+	MetricFileConflicts = "file_conflicts"
+	// MetricEvents is the number of events matching the rule's matchers in the rule window
+	MetricEvents = "events"
+)
+
+// RuleMetrics lists the metric names rule expressions may reference.
+var RuleMetrics = []string{
+	MetricContextPct, MetricIdleMinutes, MetricCostUSD, MetricCostPerHour,
+	MetricErrorCount, MetricRateLimitCount, MetricFileConflicts, MetricEvents,
+}
+
+// AlertRuleFired indicates a user-defined alert rule matched
+const AlertRuleFired AlertType = "rule"
+
+const (
+	defaultRuleWindow   = 10 * time.Minute
+	defaultRuleDedupKey = "{{.rule}}/{{.session}}/{{.pane}}"
+	defaultRuleMessage  = "{{.rule}}: {{.expr}}"
+)
+
+// ruleChannels are the notify channels a rule may route to.
+var ruleChannels = []string{"desktop", "webhook", "shell", "log", "filebox"}
+
+// Rule is a user-defined alert rule, configured as [[alerts.rules]].
+type Rule struct {
+	// Name identifies the rule; it must be unique
+	Name string `toml:"name" json:"name"`
+	// Expr is a condition over metrics, e.g. "context_pct > 85 && idle_minutes >= 10".
+	// Defaults to "events > 0" when only event matchers are given.
+	Expr string `toml:"expr" json:"expr,omitempty"`
+	// Events selects the events counted by the events metric
+	Events []EventMatcher `toml:"events" json:"events,omitempty"`
+	// Window is how far back event counts and cost rates look (default 10m)
+	Window string `toml:"window" json:"window,omitempty"`
+	// For is how long the condition must hold before the rule fires
+	For string `toml:"for" json:"for,omitempty"`
+	// Severity of the fired alert (default warning)
+	Severity Severity `toml:"severity" json:"severity,omitempty"`
+	// DedupKey is a template naming the alert a match belongs to; matches that
+	// render the same key share one alert (default "{{.rule}}/{{.session}}/{{.pane}}")
+	DedupKey string `toml:"dedup_key" json:"dedup_key,omitempty"`
+	// Message is a template for the alert message
+	Message string `toml:"message" json:"message,omitempty"`
+	// Notify lists notify channels the alert is sent to (desktop, webhook, shell, log, filebox)
+	Notify []string `toml:"notify" json:"notify,omitempty"`
+	// Webhooks lists URLs the alert is POSTed to as JSON
+	Webhooks []string `toml:"webhooks" json:"webhooks,omitempty"`
+}
+
+// EventMatcher selects events by type, session, agent and message. Empty
+// fields match anything.
+type EventMatcher struct {
+	// Type is a glob over the event type, e.g. "agent.*"
+	Type string `toml:"type" json:"type,omitempty"`
+	// Session is a glob over the session name
+	Session string `toml:"session" json:"session,omitempty"`
+	// Agent is a glob over the agent type
+	Agent string `toml:"agent" json:"agent,omitempty"`
+	// Message is a regular expression over the event message
+	Message string `toml:"message" json:"message,omitempty"`
+}
+
+// CompiledRule is a validated rule ready for evaluation.
+type CompiledRule struct {
+	Rule
+	window   time.Duration
+	forDur   time.Duration
+	expr     ruleExpr
+	matchers []eventMatcher
+	dedup    *template.Template
+	message  *template.Template
+}
+
+type eventMatcher struct {
+	EventMatcher
+	message *regexp.Regexp
+}
+
+// CompileRules validates rules and prepares them for evaluation.
+func CompileRules(rules []Rule) ([]*CompiledRule, error) {
+	seen := make(map[string]bool, len(rules))
+	compiled := make([]*CompiledRule, 0, len(rules))
+	for i, r := range rules {
+		c, err := CompileRule(r)
+		if err != nil {
+			if r.Name == "" {
+				return nil, fmt.Errorf("rule %d: %w", i+1, err)
+			}
+			return nil, fmt.Errorf("rule %q: %w", r.Name, err)
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("rule %q: duplicate name", r.Name)
+		}
+		seen[r.Name] = true
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// CompileRule validates a single rule and prepares it for evaluation.
+func CompileRule(r Rule) (*CompiledRule, error) {
+	if strings.TrimSpace(r.Name) == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	c := &CompiledRule{Rule: r, window: defaultRuleWindow}
+
+	if c.Expr == "" {
+		if len(r.Events) == 0 {
+			return nil, fmt.Errorf("expr or events is required")
+		}
+		c.Expr = MetricEvents + " > 0"
+	}
+	expr, err := parseRuleExpr(c.Expr)
+	if err != nil {
+		return nil, fmt.Errorf("expr: %w", err)
+	}
+	c.expr = expr
+	if expr.uses(MetricEvents) && len(r.Events) == 0 {
+		return nil, fmt.Errorf("expr: %s requires event matchers", MetricEvents)
+	}
+
+	for i, m := range r.Events {
+		for _, glob := range []string{m.Type, m.Session, m.Agent} {
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("events[%d]: invalid pattern %q", i, glob)
+			}
+		}
+		em := eventMatcher{EventMatcher: m}
+		if m.Message != "" {
+			if em.message, err = regexp.Compile(m.Message); err != nil {
+				return nil, fmt.Errorf("events[%d].message: %w", i, err)
+			}
+		}
+		c.matchers = append(c.matchers, em)
+	}
+
+	if r.Window != "" {
+		if c.window, err = time.ParseDuration(r.Window); err != nil || c.window <= 0 {
+			return nil, fmt.Errorf("window: invalid duration %q", r.Window)
+		}
+	}
+	if r.For != "" {
+		if c.forDur, err = time.ParseDuration(r.For); err != nil || c.forDur < 0 {
+			return nil, fmt.Errorf("for: invalid duration %q", r.For)
+		}
+	}
+
+	switch c.Severity {
+	case "":
+		c.Severity = SeverityWarning
+	case SeverityInfo, SeverityWarning, SeverityError, SeverityCritical:
+	default:
+		return nil, fmt.Errorf("severity: must be info, warning, error or critical, got %q", r.Severity)
+	}
+
+	if c.DedupKey == "" {
+		c.DedupKey = defaultRuleDedupKey
+	}
+	if c.dedup, err = template.New("dedup_key").Option("missingkey=zero").Parse(c.DedupKey); err != nil {
+		return nil, fmt.Errorf("dedup_key: %w", err)
+	}
+	if c.Message == "" {
+		c.Message = defaultRuleMessage
+	}
+	if c.message, err = template.New("message").Option("missingkey=zero").Parse(c.Message); err != nil {
+		return nil, fmt.Errorf("message: %w", err)
+	}
+
+	for _, ch := range r.Notify {
+		if !containsString(ruleChannels, ch) {
+			return nil, fmt.Errorf("notify: unknown channel %q (want one of %s)", ch, strings.Join(ruleChannels, ", "))
+		}
+	}
+	for _, hook := range r.Webhooks {
+		u, err := url.Parse(hook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("webhooks: invalid URL %q", hook)
+		}
+	}
+	return c, nil
+}
+
+// WindowDuration returns how far back the rule counts events.
+func (c *CompiledRule) WindowDuration() time.Duration { return c.window }
+
+// ForDuration returns how long the condition must hold before firing.
+func (c *CompiledRule) ForDuration() time.Duration { return c.forDur }
+
+// matches reports whether ev is selected by any of the rule's matchers.
+func (c *CompiledRule) matches(ev RuleEvent) bool {
+	for _, m := range c.matchers {
+		if globMatch(m.Type, ev.Type) && globMatch(m.Session, ev.Session) && globMatch(m.Agent, ev.Agent) &&
+			(m.message == nil || m.message.MatchString(ev.Message)) {
+			return true
+		}
+	}
+	return false
+}
+
+// render executes tmpl over vars, returning the raw template text on error.
+func render(tmpl *template.Template, vars map[string]interface{}) string {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return tmpl.Root.String()
+	}
+	return b.String()
+}
+
+func globMatch(pattern, s string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, s)
+	return ok
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleExpr is a parsed rule expression. Comparisons against a metric that has
+// no value in the evaluated scope are false.
+type ruleExpr interface {
+	eval(lookup func(string) (float64, bool)) bool
+	uses(metric string) bool
+}
+
+type (
+	andExpr struct{ l, r ruleExpr }
+	orExpr  struct{ l, r ruleExpr }
+	notExpr struct{ x ruleExpr }
+	cmpExpr struct {
+		op   string
+		l, r operand
+	}
+	operand struct {
+		metric string
+		value  float64
+	}
+)
+
+func (e andExpr) eval(f func(string) (float64, bool)) bool { return e.l.eval(f) && e.r.eval(f) }
+func (e orExpr) eval(f func(string) (float64, bool)) bool  { return e.l.eval(f) || e.r.eval(f) }
+func (e notExpr) eval(f func(string) (float64, bool)) bool { return !e.x.eval(f) }
+func (e andExpr) uses(m string) bool                       { return e.l.uses(m) || e.r.uses(m) }
+func (e orExpr) uses(m string) bool                        { return e.l.uses(m) || e.r.uses(m) }
+func (e notExpr) uses(m string) bool                       { return e.x.uses(m) }
+func (e cmpExpr) uses(m string) bool                       { return e.l.metric == m || e.r.metric == m }
+
+func (e cmpExpr) eval(f func(string) (float64, bool)) bool {
+	l, ok := e.l.resolve(f)
+	if !ok {
+		return false
+	}
+	r, ok := e.r.resolve(f)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case "==":
+		return l == r
+	default:
+		return l != r
+	}
+}
+
+func (o operand) resolve(f func(string) (float64, bool)) (float64, bool) {
+	if o.metric == "" {
+		return o.value, true
+	}
+	return f(o.metric)
+}
+
+// parseRuleExpr parses an expression of metric comparisons joined with
+// && (and), || (or), ! (not) and parentheses.
+func parseRuleExpr(s string) (ruleExpr, error) {
+	toks, err := lexRuleExpr(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	return e, nil
+}
+
+type exprParser struct {
+	toks []string
+	pos  int
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *exprParser) or() (ruleExpr, error) {
+	l, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for t := p.peek(); t == "||" || t == "or"; t = p.peek() {
+		p.next()
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l = orExpr{l, r}
+	}
+	return l, nil
+}
+
+func (p *exprParser) and() (ruleExpr, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for t := p.peek(); t == "&&" || t == "and"; t = p.peek() {
+		p.next()
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = andExpr{l, r}
+	}
+	return l, nil
+}
+
+func (p *exprParser) unary() (ruleExpr, error) {
+	switch p.peek() {
+	case "!", "not":
+		p.next()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{x}, nil
+	case "(":
+		p.next()
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return x, nil
+	}
+
+	l, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	op := p.next()
+	switch op {
+	case ">", ">=", "<", "<=", "==", "!=":
+	case "":
+		return nil, fmt.Errorf("expected comparison after %q", p.toks[p.pos-2])
+	default:
+		return nil, fmt.Errorf("expected comparison, got %q", op)
+	}
+	r, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	return cmpExpr{op: op, l: l, r: r}, nil
+}
+
+func (p *exprParser) operand() (operand, error) {
+	t := p.next()
+	if t == "" {
+		return operand{}, fmt.Errorf("unexpected end of expression")
+	}
+	if v, err := strconv.ParseFloat(t, 64); err == nil {
+		return operand{value: v}, nil
+	}
+	if !containsString(RuleMetrics, t) {
+		return operand{}, fmt.Errorf("unknown metric %q (want one of %s)", t, strings.Join(RuleMetrics, ", "))
+	}
+	return operand{metric: t}, nil
+}
+
+// lexRuleExpr splits an expression into identifiers, numbers, operators and
+// parentheses.
+func lexRuleExpr(s string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')':
+			toks = append(toks, string(c))
+			i++
+		case strings.ContainsRune("<>=!&|", c):
+			j := i + 1
+			if j < len(s) && strings.ContainsRune("=&|", rune(s[j])) {
+				j++
+			}
+			op := s[i:j]
+			switch op {
+			case ">", ">=", "<", "<=", "==", "!=", "!", "&&", "||":
+			default:
+				return nil, fmt.Errorf("invalid operator %q", op)
+			}
+			toks = append(toks, op)
+			i = j
+		case c == '_' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c):
+			j := i
+			for j < len(s) && (s[j] == '_' || s[j] == '.' || unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return toks, nil
+}
@@ -0,0 +1,235 @@
+package alerts
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRuleExpr(t *testing.T) {
+	metrics := map[string]float64{"context_pct": 90, "idle_minutes": 4, "error_count": 0}
+	lookup := func(name string) (float64, bool) {
+		v, ok := metrics[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"context_pct > 85", true},
+		{"context_pct > 85 && idle_minutes >= 10", false},
+		{"context_pct > 85 and (idle_minutes >= 10 or error_count == 0)", true},
+		{"!(context_pct <= 50)", true},
+		{"not context_pct != 90", true},
+		{"85 < context_pct", true},
+		{"cost_per_hour > 0", false},   // no value: false
+		{"!(cost_per_hour > 0)", true}, // ...so its negation holds
+	}
+	for _, tt := range tests {
+		e, err := parseRuleExpr(tt.expr)
+		if err != nil {
+			t.Errorf("parseRuleExpr(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got := e.eval(lookup); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "context_pct", "context_pct >", "tokens > 5", "context_pct = 5", "(context_pct > 1", "context_pct > 1 &&", "a ~ b"} {
+		if _, err := parseRuleExpr(bad); err == nil {
+			t.Errorf("parseRuleExpr(%q) = nil error", bad)
+		}
+	}
+}
+
+func TestCompileRules(t *testing.T) {
+	rules, err := CompileRules([]Rule{
+		{Name: "errors", Events: []EventMatcher{{Type: "agent.*"}}},
+		{Name: "context", Expr: "context_pct > 85", For: "5m", Window: "1h", Severity: SeverityCritical},
+	})
+	if err != nil {
+		t.Fatalf("CompileRules() error = %v", err)
+	}
+	if rules[0].Expr != "events > 0" || rules[0].Severity != SeverityWarning || rules[0].WindowDuration() != 10*time.Minute {
+		t.Errorf("defaults not applied: %+v", rules[0].Rule)
+	}
+	if rules[1].ForDuration() != 5*time.Minute || rules[1].WindowDuration() != time.Hour {
+		t.Errorf("durations = %v, %v", rules[1].ForDuration(), rules[1].WindowDuration())
+	}
+
+	bad := []Rule{
+		{Expr: "context_pct > 1"},
+		{Name: "x"},
+		{Name: "x", Expr: "events > 1"},
+		{Name: "x", Expr: "context_pct > 1", For: "soon"},
+		{Name: "x", Expr: "context_pct > 1", Window: "0s"},
+		{Name: "x", Expr: "context_pct > 1", Severity: "urgent"},
+		{Name: "x", Expr: "context_pct > 1", Notify: []string{"pager"}},
+		{Name: "x", Expr: "context_pct > 1", Webhooks: []string{"ftp://example.com"}},
+		{Name: "x", Expr: "context_pct > 1", DedupKey: "{{.session"},
+		{Name: "x", Events: []EventMatcher{{Message: "("}}},
+		{Name: "x", Events: []EventMatcher{{Type: "["}}},
+	}
+	for _, r := range bad {
+		if _, err := CompileRules([]Rule{r}); err == nil {
+			t.Errorf("CompileRules(%+v) = nil error", r)
+		}
+	}
+	if _, err := CompileRules([]Rule{{Name: "x", Expr: "error_count > 1"}, {Name: "x", Expr: "error_count > 2"}}); err == nil {
+		t.Error("duplicate rule names accepted")
+	}
+}
+
+func TestEngine_ForAndResolve(t *testing.T) {
+	rules, err := CompileRules([]Rule{{
+		Name:    "context-high",
+		Expr:    "context_pct > 85",
+		For:     "2m",
+		Message: "{{.agent}} at {{printf \"%.0f\" .context_pct}}%",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine(rules)
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	sample := func(at time.Duration, pct float64) []Firing {
+		now := t0.Add(at)
+		e.Observe(Sample{Time: now, Session: "s", Pane: "cc_1", Agent: "cc", Metrics: map[string]float64{MetricContextPct: pct}})
+		return e.Evaluate(now)
+	}
+
+	if got := sample(0, 90); len(got) != 0 {
+		t.Fatalf("fired before for elapsed: %+v", got)
+	}
+	if got := sample(time.Minute, 91); len(got) != 0 {
+		t.Fatalf("fired before for elapsed: %+v", got)
+	}
+	got := sample(2*time.Minute, 92)
+	if len(got) != 1 || got[0].Resolved || got[0].Alert.Message != "cc at 92%" || got[0].Key != "context-high/s/cc_1" {
+		t.Fatalf("firing = %+v", got)
+	}
+	if got := sample(3*time.Minute, 95); len(got) != 0 {
+		t.Fatalf("repeated firing: %+v", got)
+	}
+	if len(e.Firing()) != 1 {
+		t.Errorf("Firing() = %v", e.Firing())
+	}
+	got = sample(4*time.Minute, 40)
+	if len(got) != 1 || !got[0].Resolved || got[0].Alert.ResolvedAt == nil {
+		t.Fatalf("resolution = %+v", got)
+	}
+
+	// The for duration restarts after the condition clears.
+	if got := sample(5*time.Minute, 90); len(got) != 0 {
+		t.Fatalf("fired without waiting again: %+v", got)
+	}
+}
+
+func TestEngine_EventsAndDedup(t *testing.T) {
+	rules, err := CompileRules([]Rule{
+		{
+			Name:     "rate-limited",
+			Expr:     "rate_limit_count >= 2",
+			Window:   "10m",
+			DedupKey: "{{.session}}",
+		},
+		{
+			Name:   "auth-errors",
+			Events: []EventMatcher{{Type: "agent.error", Agent: "co*", Message: "(?i)auth"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine(rules)
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, pane := range []string{"cc_1", "cc_1", "cc_2", "cc_2"} {
+		e.ObserveEvent(RuleEvent{Time: t0.Add(time.Duration(i) * time.Second), Type: "agent.rate_limit", Session: "s", Pane: pane, Agent: "cc"})
+	}
+	e.ObserveEvent(RuleEvent{Time: t0, Type: "agent.error", Session: "s", Pane: "cod_1", Agent: "cod", Message: "Auth token expired"})
+	e.ObserveEvent(RuleEvent{Time: t0, Type: "agent.error", Session: "s", Pane: "cc_1", Agent: "cc", Message: "auth failed"})
+
+	got := e.Evaluate(t0.Add(time.Minute))
+	var rateLimited, auth []Firing
+	for _, f := range got {
+		switch f.RuleName {
+		case "rate-limited":
+			rateLimited = append(rateLimited, f)
+		case "auth-errors":
+			auth = append(auth, f)
+		}
+	}
+	// Both panes hit the limit twice, but share the session dedup key.
+	if len(rateLimited) != 1 || rateLimited[0].Key != "s" {
+		t.Errorf("rate-limited firings = %+v", rateLimited)
+	}
+	if len(auth) != 1 || auth[0].Alert.Pane != "cod_1" {
+		t.Errorf("auth-errors firings = %+v", auth)
+	}
+
+	// Once the events leave the window, both alerts resolve.
+	got = e.Evaluate(t0.Add(15 * time.Minute))
+	if len(got) != 2 || !got[0].Resolved || !got[1].Resolved {
+		t.Errorf("after window = %+v", got)
+	}
+}
+
+func TestEngine_CostPerHourAndSessionMetrics(t *testing.T) {
+	rules, err := CompileRules([]Rule{{
+		Name:   "burn",
+		Expr:   "cost_per_hour > 5 && file_conflicts > 0",
+		Window: "30m",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine(rules)
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	e.Observe(Sample{Time: t0, Session: "s", Pane: "cc_1", Metrics: map[string]float64{MetricCostUSD: 1}})
+	e.Observe(Sample{Time: t0, Session: "s", Metrics: map[string]float64{MetricFileConflicts: 1}})
+	e.Observe(Sample{Time: t0.Add(20 * time.Minute), Session: "s", Pane: "cc_1", Metrics: map[string]float64{MetricCostUSD: 4}})
+	e.Observe(Sample{Time: t0.Add(20 * time.Minute), Session: "s", Metrics: map[string]float64{MetricFileConflicts: 1}})
+
+	// $3 over 20 minutes is $9/hour; file_conflicts comes from the session sample.
+	got := e.Evaluate(t0.Add(20 * time.Minute))
+	if len(got) != 1 || got[0].Alert.Pane != "cc_1" {
+		t.Fatalf("firings = %+v", got)
+	}
+	metrics := got[0].Alert.Context["metrics"].(map[string]interface{})
+	if rate := metrics[MetricCostPerHour].(float64); rate < 8.99 || rate > 9.01 {
+		t.Errorf("cost_per_hour = %v, want 9", rate)
+	}
+}
+
+func TestRoute_Webhook(t *testing.T) {
+	var got Firing
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	rules, err := CompileRules([]Rule{{Name: "errors", Expr: "error_count > 0", Webhooks: []string{srv.URL}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine(rules)
+	now := time.Now()
+	e.ObserveEvent(RuleEvent{Time: now, Type: "agent.crashed", Session: "s", Pane: "cc_1"})
+	firings := e.Evaluate(now)
+	if len(firings) != 1 {
+		t.Fatalf("firings = %+v", firings)
+	}
+	if err := Route(firings[0], nil); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if got.RuleName != "errors" || got.Alert.Session != "s" || !strings.HasPrefix(got.Alert.Source, "rule:") {
+		t.Errorf("webhook body = %+v", got)
+	}
+}
@@ -51,6 +51,9 @@ const (
 	AlertQuotaWarning AlertType = "quota_warning"
 	// AlertQuotaCritical indicates API usage at or exceeding quota
 	AlertQuotaCritical AlertType = "quota_critical"
+
+	// AlertApprovalPending indicates a pipeline is paused at an approval gate
+	AlertApprovalPending AlertType = "approval_pending"
 )
 
 // Severity indicates the urgency of an alert
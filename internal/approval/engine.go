@@ -389,6 +389,27 @@ func (e *Engine) WaitForApproval(ctx context.Context, id string, timeout time.Du
 	}
 }
 
+// Current returns the newest approval for action on resource that is still
+// pending or approved and has not expired, or nil if there is none. Callers
+// use it to honour a standing approval instead of requesting a new one.
+func (e *Engine) Current(ctx context.Context, action, resource string) (*state.Approval, error) {
+	approvals, err := e.store.ListApprovalsFor(action, resource)
+	if err != nil {
+		return nil, fmt.Errorf("list approvals: %w", err)
+	}
+	now := time.Now()
+	for i := range approvals {
+		a := &approvals[i]
+		if now.After(a.ExpiresAt) {
+			continue
+		}
+		if a.Status == state.ApprovalPending || a.Status == state.ApprovalApproved {
+			return a, nil
+		}
+	}
+	return nil, nil
+}
+
 // ListPending returns all pending approval requests.
 func (e *Engine) ListPending(ctx context.Context) ([]state.Approval, error) {
 	approvals, err := e.store.ListPendingApprovals()
@@ -146,6 +146,42 @@ func TestDeny(t *testing.T) {
 	}
 }
 
+func TestCurrent(t *testing.T) {
+	store := setupTestStore(t)
+	engine := New(store, nil, nil, DefaultConfig())
+	ctx := context.Background()
+
+	if got, err := engine.Current(ctx, "budget_override", "session:proj"); err != nil || got != nil {
+		t.Fatalf("Current with no approvals = %v, %v", got, err)
+	}
+
+	denied, _ := engine.Request(ctx, RequestParams{Action: "budget_override", Resource: "session:proj", RequestedBy: "a"})
+	_ = engine.Deny(ctx, denied.ID, "b", "no")
+	if got, _ := engine.Current(ctx, "budget_override", "session:proj"); got != nil {
+		t.Errorf("denied approval returned: %+v", got)
+	}
+
+	pending, _ := engine.Request(ctx, RequestParams{Action: "budget_override", Resource: "session:proj", RequestedBy: "a"})
+	if _, err := engine.Request(ctx, RequestParams{Action: "budget_override", Resource: "session:other", RequestedBy: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := engine.Current(ctx, "budget_override", "session:proj")
+	if err != nil || got == nil || got.ID != pending.ID || got.Status != state.ApprovalPending {
+		t.Fatalf("Current = %+v, %v; want pending %s", got, err, pending.ID)
+	}
+
+	_ = engine.Approve(ctx, pending.ID, "b")
+	if got, _ := engine.Current(ctx, "budget_override", "session:proj"); got == nil || got.Status != state.ApprovalApproved {
+		t.Errorf("Current after approve = %+v", got)
+	}
+
+	expired, _ := engine.Request(ctx, RequestParams{Action: "budget_override", Resource: "daily:2026-01-01", RequestedBy: "a", ExpiresIn: time.Millisecond})
+	time.Sleep(5 * time.Millisecond)
+	if got, _ := engine.Current(ctx, "budget_override", "daily:2026-01-01"); got != nil {
+		t.Errorf("expired approval %s returned", expired.ID)
+	}
+}
+
 func TestSLBEnforcement(t *testing.T) {
 	store := setupTestStore(t)
 	engine := New(store, nil, nil, DefaultConfig())
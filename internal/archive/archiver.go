@@ -42,6 +42,11 @@ type ArchiveRecord struct {
 	Sequence  int       `json:"sequence"` // Monotonic sequence number per pane
 }
 
+// Indexer receives each record the archiver writes, e.g. a full-text index.
+type Indexer interface {
+	IndexRecord(record *ArchiveRecord) error
+}
+
 // PaneState tracks the state of a single pane for incremental capture.
 type PaneState struct {
 	LastHash    uint64 // Hash of last captured content for deduplication
@@ -63,6 +68,7 @@ type Archiver struct {
 	encoder         *json.Encoder
 	started         time.Time
 	totalRecords    int
+	index           Indexer              // Optional search index
 	onRecord        func(*ArchiveRecord) // Optional callback for testing
 }
 
@@ -72,6 +78,7 @@ type ArchiverOptions struct {
 	OutputDir       string
 	Interval        time.Duration
 	LinesPerCapture int
+	Index           Indexer              // Index fed with every record written
 	OnRecord        func(*ArchiveRecord) // Callback when record is written
 }
 
@@ -123,6 +130,7 @@ func NewArchiver(opts ArchiverOptions) (*Archiver, error) {
 		file:            f,
 		encoder:         json.NewEncoder(f),
 		started:         time.Now(),
+		index:           opts.Index,
 		onRecord:        opts.OnRecord,
 	}, nil
 }
@@ -259,6 +267,13 @@ func (a *Archiver) writeRecord(record *ArchiveRecord) error {
 	}
 	a.totalRecords++
 
+	// The JSONL archive is the source of truth; indexing is best-effort
+	if a.index != nil {
+		if err := a.index.IndexRecord(record); err != nil {
+			slog.Warn("archive index error", "session", a.sessionName, "pane", record.Pane, "error", err)
+		}
+	}
+
 	// Call optional callback
 	if a.onRecord != nil {
 		a.onRecord(record)
@@ -0,0 +1,180 @@
+// Package budget enforces session, project and daily spend limits.
+//
+// Spend is read from the agents' own transcripts, so it is cumulative per
+// pane. A ledger under ~/.ntm/budget records the last value seen for each
+// pane and attributes the growth since then to the pane's project and to
+// the current day, which is what project and daily limits are checked
+// against. Session limits are checked against the live panes' totals.
+//
+// Crossing a soft limit publishes an AlertEvent once. Crossing a hard limit
+// makes Enforce fail until a budget_override approval for that scope is
+// granted with `ntm approve`.
+package budget
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/cost"
+)
+
+// Budget scopes.
+const (
+	ScopeSession = "session"
+	ScopeProject = "project"
+	ScopeDaily   = "daily"
+)
+
+// OverrideAction is the approval action that lifts a hard limit.
+const OverrideAction = "budget_override"
+
+// AlertType is the AlertEvent type raised for budget limits.
+const AlertType = "budget"
+
+// Level is how far spend has gone against a scope's limits.
+type Level string
+
+const (
+	LevelOK   Level = "ok"
+	LevelSoft Level = "soft"
+	LevelHard Level = "hard"
+)
+
+func (l Level) rank() int {
+	switch l {
+	case LevelSoft:
+		return 1
+	case LevelHard:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Spend is money and tokens spent.
+type Spend struct {
+	USD    float64 `json:"usd"`
+	Tokens int64   `json:"tokens"`
+}
+
+// Add returns s plus o.
+func (s Spend) Add(o Spend) Spend {
+	return Spend{USD: s.USD + o.USD, Tokens: s.Tokens + o.Tokens}
+}
+
+// SpendOf returns the spend of an agent's usage: its marginal cost, which is
+// zero under a subscription plan, and all of its tokens.
+func SpendOf(a *cost.AgentCost) Spend {
+	return Spend{
+		USD:    a.Cost(),
+		Tokens: int64(a.InputTokens + a.OutputTokens + a.CacheReadTokens + a.CacheWriteTokens),
+	}
+}
+
+// ScopeStatus is the spend of one scope against its limits.
+type ScopeStatus struct {
+	Scope  string              `json:"scope"`
+	Key    string              `json:"key"` // Session name, project directory or date
+	Spent  Spend               `json:"spent"`
+	Limits config.BudgetLimits `json:"limits"`
+	Level  Level               `json:"level"`
+	// Limit names the limit reached: soft_usd, hard_usd, soft_tokens or hard_tokens.
+	Limit string `json:"limit,omitempty"`
+	// Override is the approval ID that lifts a reached hard limit.
+	Override string `json:"override,omitempty"`
+}
+
+// Evaluate compares spent against limits. Hard limits are checked first,
+// then USD before tokens.
+func Evaluate(scope, key string, spent Spend, limits config.BudgetLimits) ScopeStatus {
+	s := ScopeStatus{Scope: scope, Key: key, Spent: spent, Limits: limits, Level: LevelOK}
+	switch {
+	case limits.HardUSD > 0 && spent.USD >= limits.HardUSD:
+		s.Level, s.Limit = LevelHard, "hard_usd"
+	case limits.HardTokens > 0 && spent.Tokens >= limits.HardTokens:
+		s.Level, s.Limit = LevelHard, "hard_tokens"
+	case limits.SoftUSD > 0 && spent.USD >= limits.SoftUSD:
+		s.Level, s.Limit = LevelSoft, "soft_usd"
+	case limits.SoftTokens > 0 && spent.Tokens >= limits.SoftTokens:
+		s.Level, s.Limit = LevelSoft, "soft_tokens"
+	}
+	return s
+}
+
+// Resource is the approval resource for overriding this scope's limit.
+// Daily keys are dates, so a daily override lapses with the day.
+func (s ScopeStatus) Resource() string {
+	return s.Scope + ":" + s.Key
+}
+
+// Blocked reports whether a hard limit is reached without an override.
+func (s ScopeStatus) Blocked() bool {
+	return s.Level == LevelHard && s.Override == ""
+}
+
+// Describe summarizes the reached limit, e.g.
+// `session "proj" has spent $12.40 of its $10.00 hard limit`.
+func (s ScopeStatus) Describe() string {
+	name := s.Scope
+	switch s.Scope {
+	case ScopeSession, ScopeProject:
+		name = fmt.Sprintf("%s %q", s.Scope, s.Key)
+	case ScopeDaily:
+		name = fmt.Sprintf("daily spend (%s)", s.Key)
+	}
+
+	kind, _, _ := strings.Cut(s.Limit, "_")
+	switch s.Limit {
+	case "hard_usd", "soft_usd":
+		limit := s.Limits.HardUSD
+		if kind == "soft" {
+			limit = s.Limits.SoftUSD
+		}
+		return fmt.Sprintf("%s has spent $%.2f of its $%.2f %s limit", name, s.Spent.USD, limit, kind)
+	case "hard_tokens", "soft_tokens":
+		limit := s.Limits.HardTokens
+		if kind == "soft" {
+			limit = s.Limits.SoftTokens
+		}
+		return fmt.Sprintf("%s has used %d of its %d-token %s limit", name, s.Spent.Tokens, limit, kind)
+	}
+	return fmt.Sprintf("%s is within budget ($%.2f, %d tokens)", name, s.Spent.USD, s.Spent.Tokens)
+}
+
+// Status is the budget state of a session: its own scope and the project
+// and daily scopes it counts toward. Only scopes with limits are included.
+type Status struct {
+	Session string        `json:"session"`
+	Level   Level         `json:"level"`
+	Scopes  []ScopeStatus `json:"scopes"`
+}
+
+func (s *Status) add(sc ScopeStatus) {
+	s.Scopes = append(s.Scopes, sc)
+	if sc.Level.rank() > s.Level.rank() {
+		s.Level = sc.Level
+	}
+}
+
+// Scope returns the status of the named scope, if it has limits.
+func (s *Status) Scope(scope string) (ScopeStatus, bool) {
+	for _, sc := range s.Scopes {
+		if sc.Scope == scope {
+			return sc, true
+		}
+	}
+	return ScopeStatus{}, false
+}
+
+// ExceededError is returned when an action is blocked by a hard limit.
+type ExceededError struct {
+	Scope      ScopeStatus
+	Action     string // What was blocked: "send", "spawn", "pipeline step", ...
+	ApprovalID string // Override request awaiting approval
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("budget exceeded: %s; %s blocked. To override, run: ntm approve %s",
+		e.Scope.Describe(), e.Action, e.ApprovalID)
+}
@@ -0,0 +1,170 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/approval"
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+	limits := config.BudgetLimits{SoftUSD: 5, HardUSD: 10, SoftTokens: 1000, HardTokens: 2000}
+	tests := []struct {
+		spent Spend
+		level Level
+		limit string
+	}{
+		{Spend{USD: 1, Tokens: 10}, LevelOK, ""},
+		{Spend{USD: 5, Tokens: 10}, LevelSoft, "soft_usd"},
+		{Spend{USD: 1, Tokens: 1500}, LevelSoft, "soft_tokens"},
+		{Spend{USD: 7, Tokens: 2000}, LevelHard, "hard_tokens"},
+		{Spend{USD: 12, Tokens: 10}, LevelHard, "hard_usd"},
+	}
+	for _, tt := range tests {
+		got := Evaluate(ScopeSession, "proj", tt.spent, limits)
+		if got.Level != tt.level || got.Limit != tt.limit {
+			t.Errorf("Evaluate(%+v) = %s/%s, want %s/%s", tt.spent, got.Level, got.Limit, tt.level, tt.limit)
+		}
+	}
+
+	got := Evaluate(ScopeSession, "proj", Spend{USD: 12.4}, limits)
+	if want := `session "proj" has spent $12.40 of its $10.00 hard limit`; got.Describe() != want {
+		t.Errorf("Describe() = %q, want %q", got.Describe(), want)
+	}
+}
+
+func newTestTracker(t *testing.T, cfg config.BudgetConfig, now *time.Time) (*Tracker, *[]events.AlertEvent) {
+	t.Helper()
+	var alerts []events.AlertEvent
+	return &Tracker{
+		Config: cfg,
+		Dir:    t.TempDir(),
+		Now:    func() time.Time { return *now },
+		Publish: func(e events.BusEvent) {
+			alerts = append(alerts, e.(events.AlertEvent))
+		},
+	}, &alerts
+}
+
+func TestTrackerObserve(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local)
+	cfg := config.BudgetConfig{
+		Session: config.BudgetLimits{SoftUSD: 2, HardUSD: 4},
+		Project: config.BudgetLimits{HardUSD: 100},
+		Daily:   config.BudgetLimits{SoftTokens: 5000},
+	}
+	tr, alerts := newTestTracker(t, cfg, &now)
+
+	status, err := tr.Observe("proj", "/work/proj", map[string]Spend{"%1": {USD: 1, Tokens: 1000}, "%2": {USD: 0.5, Tokens: 500}})
+	if err != nil {
+		t.Fatalf("Observe: %v", err)
+	}
+	if status.Level != LevelOK || len(status.Scopes) != 3 {
+		t.Fatalf("status = %+v", status)
+	}
+
+	// Only growth since the last observation counts toward project and day.
+	status, _ = tr.Observe("proj", "/work/proj", map[string]Spend{"%1": {USD: 2, Tokens: 4000}, "%2": {USD: 0.5, Tokens: 500}})
+	session, _ := status.Scope(ScopeSession)
+	project, _ := status.Scope(ScopeProject)
+	daily, _ := status.Scope(ScopeDaily)
+	if session.Spent.USD != 2.5 || project.Spent.USD != 2.5 || daily.Spent.Tokens != 4500 {
+		t.Errorf("spent = session %+v project %+v daily %+v", session.Spent, project.Spent, daily.Spent)
+	}
+	if session.Level != LevelSoft || len(*alerts) != 1 || (*alerts)[0].Severity != "warning" {
+		t.Fatalf("session = %+v, alerts = %+v", session, *alerts)
+	}
+
+	// A second session in the same project adds to the project and day, and
+	// an alert already raised is not repeated.
+	status, _ = tr.Observe("other", "/work/proj", map[string]Spend{"%9": {USD: 0.25, Tokens: 1000}})
+	daily, _ = status.Scope(ScopeDaily)
+	project, _ = status.Scope(ScopeProject)
+	if project.Spent.USD != 2.75 || daily.Level != LevelSoft {
+		t.Errorf("project = %+v, daily = %+v", project, daily)
+	}
+	tr.Observe("proj", "/work/proj", map[string]Spend{"%1": {USD: 2, Tokens: 4000}})
+	if len(*alerts) != 2 {
+		t.Errorf("alerts = %d, want 2 (session soft, daily soft)", len(*alerts))
+	}
+
+	// A new day starts from zero.
+	now = now.Add(24 * time.Hour)
+	status, _ = tr.Observe("proj", "/work/proj", map[string]Spend{"%1": {USD: 5, Tokens: 4100}})
+	daily, _ = status.Scope(ScopeDaily)
+	session, _ = status.Scope(ScopeSession)
+	if daily.Spent.Tokens != 100 || daily.Key != "2026-03-02" || session.Level != LevelHard {
+		t.Errorf("next day: daily = %+v, session = %+v", daily, session)
+	}
+	if last := (*alerts)[len(*alerts)-1]; last.Severity != "critical" || !strings.Contains(last.Message, "hard limit") {
+		t.Errorf("hard alert = %+v", last)
+	}
+}
+
+func TestEnforce(t *testing.T) {
+	t.Parallel()
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	engine := approval.New(store, nil, nil, approval.DefaultConfig())
+	ctx := context.Background()
+
+	blocked := func() *Status {
+		s := &Status{Session: "proj"}
+		s.add(Evaluate(ScopeSession, "proj", Spend{USD: 11}, config.BudgetLimits{HardUSD: 10}))
+		return s
+	}
+
+	var exceeded *ExceededError
+	err = Enforce(ctx, engine, blocked(), "send")
+	if !errors.As(err, &exceeded) || exceeded.ApprovalID == "" {
+		t.Fatalf("Enforce = %v, want ExceededError", err)
+	}
+	if !strings.Contains(err.Error(), "ntm approve "+exceeded.ApprovalID) {
+		t.Errorf("error lacks approve hint: %v", err)
+	}
+
+	// The pending request is reused rather than duplicated.
+	err = Enforce(ctx, engine, blocked(), "send")
+	var again *ExceededError
+	if !errors.As(err, &again) || again.ApprovalID != exceeded.ApprovalID {
+		t.Errorf("second Enforce = %v, want same approval %s", err, exceeded.ApprovalID)
+	}
+
+	if err := engine.Approve(ctx, exceeded.ApprovalID, "lead"); err != nil {
+		t.Fatal(err)
+	}
+	status := blocked()
+	if err := Enforce(ctx, engine, status, "send"); err != nil {
+		t.Fatalf("Enforce after approval = %v", err)
+	}
+	if status.Scopes[0].Override != exceeded.ApprovalID || status.Scopes[0].Blocked() {
+		t.Errorf("override not recorded: %+v", status.Scopes[0])
+	}
+}
+
+func TestCheckHonorsContext(t *testing.T) {
+	t.Parallel()
+	cfg := config.Default()
+	cfg.Budget = config.BudgetConfig{Session: config.BudgetLimits{HardUSD: 1}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := Check(ctx, cfg, "proj", "pipeline step"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Check with canceled context = %v, want context.Canceled", err)
+	}
+}
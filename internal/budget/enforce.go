@@ -0,0 +1,147 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/Dicklesworthstone/ntm/internal/approval"
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	ctxmon "github.com/Dicklesworthstone/ntm/internal/context"
+	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// SpendFromUsage prices transcript usage, keyed by pane ID, for the agent
+// panes that have it. Panes without a model in their transcript are priced
+// with the configured model for their agent type and variant.
+func SpendFromUsage(cfg *config.Config, panes []tmux.Pane, usage map[string]*ctxmon.TranscriptUsage) map[string]Spend {
+	spend := make(map[string]Spend)
+	for _, p := range panes {
+		u := usage[p.ID]
+		if u == nil {
+			continue
+		}
+		var model string
+		if cfg != nil {
+			model = cfg.Models.GetModelName(string(p.Type), p.Variant)
+		}
+		spend[p.ID] = SpendOf(u.AgentCost(model))
+	}
+	return spend
+}
+
+// ReadSpend reads the cumulative spend of each agent pane from its
+// transcript in projectDir.
+func ReadSpend(cfg *config.Config, transcripts *ctxmon.TranscriptEstimator, panes []tmux.Pane, projectDir string) map[string]Spend {
+	usage := make(map[string]*ctxmon.TranscriptUsage)
+	for _, p := range panes {
+		switch p.Type {
+		case tmux.AgentClaude, tmux.AgentCodex, tmux.AgentGemini:
+		default:
+			continue
+		}
+		if u := transcripts.PaneUsage(p.ID, string(p.Type), projectDir); u != nil {
+			usage[p.ID] = u
+		}
+	}
+	return SpendFromUsage(cfg, panes, usage)
+}
+
+// sharedTranscripts is the process's transcript estimator. Its tailers keep
+// their read offsets, so repeated checks only parse what was appended since.
+var sharedTranscripts = sync.OnceValue(func() *ctxmon.TranscriptEstimator {
+	return ctxmon.NewTranscriptEstimator(ctxmon.DefaultTranscriptLocator())
+})
+
+// Observe reads session's current spend, records it and returns its budget
+// status, or nil if no budget is configured. A session that does not exist
+// yet has no spend of its own but still counts toward its project and day.
+func Observe(cfg *config.Config, session string) (*Status, error) {
+	return ObserveContext(context.Background(), cfg, session)
+}
+
+// ObserveContext is Observe bounded by ctx.
+func ObserveContext(ctx context.Context, cfg *config.Config, session string) (*Status, error) {
+	if cfg == nil || !cfg.Budget.Enabled() {
+		return nil, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	projectDir := cfg.GetProjectDir(session)
+	var spend map[string]Spend
+	if panes, err := tmux.GetPanesContext(ctx, session); err == nil {
+		spend = ReadSpend(cfg, sharedTranscripts(), panes, projectDir)
+	}
+	return NewTracker(cfg.Budget).Observe(session, projectDir, spend)
+}
+
+// Check observes session's spend and, if a hard limit is reached, enforces
+// it for action using the default approval store. It returns an
+// *ExceededError when action must not proceed.
+func Check(ctx context.Context, cfg *config.Config, session, action string) (*Status, error) {
+	status, err := ObserveContext(ctx, cfg, session)
+	if err != nil || status == nil || status.Level != LevelHard {
+		return status, err
+	}
+
+	store, err := state.Open("")
+	if err != nil {
+		return status, fmt.Errorf("open state store: %w", err)
+	}
+	defer store.Close()
+	if err := store.Migrate(); err != nil {
+		return status, fmt.Errorf("apply migrations: %w", err)
+	}
+	engine := approval.New(store, nil, events.DefaultBus, approval.DefaultConfig())
+	return status, Enforce(ctx, engine, status, action)
+}
+
+// Enforce blocks action while a hard limit in status is reached without an
+// approved override. The first such scope's pending override request is
+// reused, or a new one is filed, and returned in an *ExceededError. Scopes
+// with an approved override are marked with its ID.
+func Enforce(ctx context.Context, engine *approval.Engine, status *Status, action string) error {
+	for i := range status.Scopes {
+		sc := &status.Scopes[i]
+		if sc.Level != LevelHard {
+			continue
+		}
+
+		appr, err := engine.Current(ctx, OverrideAction, sc.Resource())
+		if err != nil {
+			return fmt.Errorf("check budget override: %w", err)
+		}
+		if appr != nil && appr.Status == state.ApprovalApproved {
+			sc.Override = appr.ID
+			continue
+		}
+		if appr == nil {
+			appr, err = engine.Request(ctx, approval.RequestParams{
+				Action:        OverrideAction,
+				Resource:      sc.Resource(),
+				Reason:        sc.Describe(),
+				RequestedBy:   requester(),
+				CorrelationID: status.Session,
+			})
+			if err != nil {
+				return fmt.Errorf("request budget override: %w", err)
+			}
+		}
+		return &ExceededError{Scope: *sc, Action: action, ApprovalID: appr.ID}
+	}
+	return nil
+}
+
+func requester() string {
+	if user := os.Getenv("NTM_USER"); user != "" {
+		return user
+	}
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return "ntm"
+}
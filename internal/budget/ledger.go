@@ -0,0 +1,216 @@
+package budget
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/util"
+)
+
+const (
+	ledgerFile     = "ledger.json"
+	ledgerLockFile = "ledger.lock"
+
+	// dayRetention is how many days of daily totals are kept.
+	dayRetention = 31
+	// paneRetention is how long a pane that is no longer seen is remembered.
+	paneRetention = 7 * 24 * time.Hour
+)
+
+// ledger is the on-disk spend record shared by all ntm processes.
+type ledger struct {
+	Panes    map[string]paneEntry `json:"panes"`    // "session/paneID" -> last cumulative spend
+	Projects map[string]Spend     `json:"projects"` // Project dir -> spend
+	Days     map[string]Spend     `json:"days"`     // YYYY-MM-DD -> spend
+	Alerted  map[string]string    `json:"alerted"`  // Alert key -> day raised
+}
+
+type paneEntry struct {
+	Session string    `json:"session"`
+	Project string    `json:"project,omitempty"`
+	Spend   Spend     `json:"spend"`
+	Seen    time.Time `json:"seen"`
+}
+
+// Tracker records spend in the ledger and evaluates budgets.
+type Tracker struct {
+	Config config.BudgetConfig
+	// Dir holds the ledger (default ~/.ntm/budget).
+	Dir string
+	// Now returns the current time (default time.Now).
+	Now func() time.Time
+	// Publish receives soft and hard limit alerts (default events.Publish).
+	Publish func(events.BusEvent)
+}
+
+// NewTracker creates a tracker for cfg using the default ledger directory.
+func NewTracker(cfg config.BudgetConfig) *Tracker {
+	dir := ""
+	if ntmDir, err := util.NTMDir(); err == nil {
+		dir = filepath.Join(ntmDir, "budget")
+	}
+	return &Tracker{Config: cfg, Dir: dir, Now: time.Now, Publish: events.Publish}
+}
+
+// Observe records the cumulative spend of session's panes, keyed by pane
+// ID, and returns the session's budget status. Growth since a pane was last
+// observed is added to project (if non-empty) and to today's total. An
+// alert is published the first time each limit is reached.
+func (t *Tracker) Observe(session, project string, panes map[string]Spend) (*Status, error) {
+	if t.Dir == "" {
+		return nil, fmt.Errorf("budget ledger directory unavailable")
+	}
+	now := t.Now()
+	today := now.Format("2006-01-02")
+
+	unlock, err := t.lock()
+	if err != nil {
+		return nil, fmt.Errorf("lock budget ledger: %w", err)
+	}
+	defer unlock()
+
+	l, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var sessionSpent Spend
+	for paneID, spent := range panes {
+		sessionSpent = sessionSpent.Add(spent)
+
+		key := session + "/" + paneID
+		prev, ok := l.Panes[key]
+		delta := spent
+		if ok && spent.USD >= prev.Spend.USD && spent.Tokens >= prev.Spend.Tokens {
+			delta = Spend{USD: spent.USD - prev.Spend.USD, Tokens: spent.Tokens - prev.Spend.Tokens}
+		} // Otherwise the pane restarted with a new transcript; count it afresh
+
+		l.Panes[key] = paneEntry{Session: session, Project: project, Spend: spent, Seen: now}
+		if project != "" {
+			l.Projects[project] = l.Projects[project].Add(delta)
+		}
+		l.Days[today] = l.Days[today].Add(delta)
+	}
+
+	status := &Status{Session: session, Level: LevelOK}
+	if !t.Config.Session.IsZero() {
+		status.add(Evaluate(ScopeSession, session, sessionSpent, t.Config.Session))
+	}
+	if !t.Config.Project.IsZero() && project != "" {
+		status.add(Evaluate(ScopeProject, project, l.Projects[project], t.Config.Project))
+	}
+	if !t.Config.Daily.IsZero() {
+		status.add(Evaluate(ScopeDaily, today, l.Days[today], t.Config.Daily))
+	}
+
+	for _, sc := range status.Scopes {
+		if sc.Level == LevelOK {
+			continue
+		}
+		key := alertKey(sc)
+		if _, done := l.Alerted[key]; done {
+			continue
+		}
+		l.Alerted[key] = today
+		if t.Publish != nil {
+			severity := "warning"
+			if sc.Level == LevelHard {
+				severity = "critical"
+			}
+			t.Publish(events.NewAlertEvent(session, key, AlertType, severity, "Budget "+string(sc.Level)+" limit reached: "+sc.Describe()))
+		}
+	}
+
+	l.prune(now)
+	if err := t.save(l); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// alertKey identifies a limit crossing. It includes the limit value so
+// raising a limit re-arms its alert.
+func alertKey(sc ScopeStatus) string {
+	var limit string
+	switch sc.Limit {
+	case "soft_usd":
+		limit = fmt.Sprintf("%g", sc.Limits.SoftUSD)
+	case "hard_usd":
+		limit = fmt.Sprintf("%g", sc.Limits.HardUSD)
+	case "soft_tokens":
+		limit = fmt.Sprintf("%d", sc.Limits.SoftTokens)
+	case "hard_tokens":
+		limit = fmt.Sprintf("%d", sc.Limits.HardTokens)
+	}
+	return strings.Join([]string{"budget", sc.Scope, sc.Key, sc.Limit, limit}, ":")
+}
+
+// prune drops old days, panes not seen recently, and alerts raised on a
+// dropped day.
+func (l *ledger) prune(now time.Time) {
+	if len(l.Days) > dayRetention {
+		days := make([]string, 0, len(l.Days))
+		for day := range l.Days {
+			days = append(days, day)
+		}
+		sort.Strings(days)
+		for _, day := range days[:len(days)-dayRetention] {
+			delete(l.Days, day)
+		}
+	}
+	cutoff := now.AddDate(0, 0, -dayRetention).Format("2006-01-02")
+	for key, day := range l.Alerted {
+		if day < cutoff {
+			delete(l.Alerted, key)
+		}
+	}
+	for key, p := range l.Panes {
+		if now.Sub(p.Seen) > paneRetention {
+			delete(l.Panes, key)
+		}
+	}
+}
+
+func (t *Tracker) load() (*ledger, error) {
+	l := &ledger{}
+	data, err := os.ReadFile(filepath.Join(t.Dir, ledgerFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read budget ledger: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, l); err != nil {
+			return nil, fmt.Errorf("parse budget ledger: %w", err)
+		}
+	}
+	if l.Panes == nil {
+		l.Panes = make(map[string]paneEntry)
+	}
+	if l.Projects == nil {
+		l.Projects = make(map[string]Spend)
+	}
+	if l.Days == nil {
+		l.Days = make(map[string]Spend)
+	}
+	if l.Alerted == nil {
+		l.Alerted = make(map[string]string)
+	}
+	return l, nil
+}
+
+func (t *Tracker) save(l *ledger) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal budget ledger: %w", err)
+	}
+	if err := util.AtomicWriteFile(filepath.Join(t.Dir, ledgerFile), data, 0600); err != nil {
+		return fmt.Errorf("write budget ledger: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+//go:build unix
+
+package budget
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+var ledgerMu sync.Mutex
+
+// lock acquires both the in-process mutex and an exclusive flock on the
+// ledger's lock file, so concurrent ntm processes don't count the same
+// growth twice. Returns an unlock function to release both.
+func (t *Tracker) lock() (func(), error) {
+	ledgerMu.Lock()
+
+	if err := os.MkdirAll(t.Dir, 0700); err != nil {
+		ledgerMu.Unlock()
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(t.Dir, ledgerLockFile), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		ledgerMu.Unlock()
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		ledgerMu.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+		ledgerMu.Unlock()
+	}, nil
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package budget
+
+import (
+	"os"
+	"sync"
+)
+
+var ledgerMu sync.Mutex
+
+// lock acquires the in-process mutex only on Windows.
+// File locking is not supported on Windows in this implementation.
+// Returns an unlock function to release the lock.
+func (t *Tracker) lock() (func(), error) {
+	ledgerMu.Lock()
+	if err := os.MkdirAll(t.Dir, 0700); err != nil {
+		ledgerMu.Unlock()
+		return nil, err
+	}
+	return func() {
+		ledgerMu.Unlock()
+	}, nil
+}
@@ -232,8 +232,8 @@ func TestStorage_SaveScrollback(t *testing.T) {
 		t.Fatalf("SaveScrollback() failed: %v", err)
 	}
 
-	if relativePath != "panes/pane__0.txt" {
-		t.Errorf("relativePath = %q, want %q", relativePath, "panes/pane__0.txt")
+	if relativePath != "panes/pane__0.chunks.json" {
+		t.Errorf("relativePath = %q, want %q", relativePath, "panes/pane__0.chunks.json")
 	}
 
 	// Load scrollback
@@ -0,0 +1,542 @@
+package checkpoint
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Dicklesworthstone/ntm/internal/util"
+)
+
+// Content-addressed scrollback storage.
+//
+// Pane scrollback is split into chunks at line boundaries chosen from line
+// content, so a scrollback that has grown or scrolled since the previous
+// checkpoint still produces mostly the same chunks. Each chunk is stored
+// once under its SHA256 in BaseDir/.chunks/objects and shared by every pane
+// and checkpoint that contains it. Chunks are gzipped unless the checkpoint
+// that first stored them had compression turned off; readers accept both. A checkpoint pane stores only
+// a small manifest listing its chunks. Reference counts are kept in
+// .chunks/refs.json; a chunk is removed when its last checkpoint is
+// deleted, and `ntm checkpoint gc` rebuilds the counts from the manifests.
+
+const (
+	// ChunksDir is the chunk store directory under the checkpoint base directory.
+	ChunksDir = ".chunks"
+	// ChunkObjectsDir holds chunk objects, fanned out by hash prefix.
+	ChunkObjectsDir = "objects"
+	// ChunkRefsFile holds chunk reference counts.
+	ChunkRefsFile = "refs.json"
+	// ChunkManifestExt is the extension of pane scrollback manifests.
+	ChunkManifestExt = ".chunks.json"
+
+	chunkLockFile = "lock"
+
+	minChunkSize = 2 << 10
+	maxChunkSize = 64 << 10
+	// chunkBoundaryMask ends a chunk after roughly one line in 32.
+	chunkBoundaryMask = 0x1f
+)
+
+// chunkMu serializes chunk store updates within the process; the lock file
+// serializes them across processes.
+var chunkMu sync.Mutex
+
+// ScrollbackManifest lists the chunks that make up a pane's scrollback.
+type ScrollbackManifest struct {
+	// Version is the manifest format version
+	Version int `json:"version"`
+	// Size is the total scrollback size in bytes
+	Size int64 `json:"size"`
+	// Chunks are chunk hashes in content order
+	Chunks []string `json:"chunks"`
+}
+
+// ChunkStore stores deduplicated scrollback chunks.
+type ChunkStore struct {
+	// Dir is the chunk store root
+	Dir string
+}
+
+// Chunks returns the chunk store shared by all checkpoints in this storage.
+func (s *Storage) Chunks() *ChunkStore {
+	return &ChunkStore{Dir: filepath.Join(s.BaseDir, ChunksDir)}
+}
+
+// splitChunks splits data into content-defined chunks. Boundaries fall after
+// lines whose hash matches chunkBoundaryMask, once a chunk reaches
+// minChunkSize; no chunk exceeds maxChunkSize.
+func splitChunks(data []byte) [][]byte {
+	var chunks [][]byte
+	start := 0
+	for start < len(data) {
+		end := start
+		for end < len(data) {
+			lineEnd := len(data)
+			if nl := bytes.IndexByte(data[end:], '\n'); nl >= 0 {
+				lineEnd = end + nl + 1
+			}
+			if lineEnd-start > maxChunkSize {
+				// Cut before an overlong line, or inside one that alone
+				// exceeds the limit.
+				if end == start {
+					end = start + maxChunkSize
+				}
+				break
+			}
+			line := data[end:lineEnd]
+			end = lineEnd
+			if end-start >= minChunkSize && lineHash(line)&chunkBoundaryMask == 0 {
+				break
+			}
+		}
+		chunks = append(chunks, data[start:end])
+		start = end
+	}
+	return chunks
+}
+
+func lineHash(line []byte) uint32 {
+	h := fnv.New32a()
+	h.Write(line)
+	return h.Sum32()
+}
+
+// chunkHash returns the content address of a chunk.
+func chunkHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// validChunkHash reports whether h looks like a chunk address.
+func validChunkHash(h string) bool {
+	if len(h) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(h)
+	return err == nil
+}
+
+// ObjectPath returns the file path of a chunk.
+func (cs *ChunkStore) ObjectPath(hash string) string {
+	return filepath.Join(cs.Dir, ChunkObjectsDir, hash[:2], hash)
+}
+
+// Has reports whether a chunk is stored.
+func (cs *ChunkStore) Has(hash string) bool {
+	return validChunkHash(hash) && fileExists(cs.ObjectPath(hash))
+}
+
+// Get reads a chunk and checks it against its hash.
+func (cs *ChunkStore) Get(hash string) ([]byte, error) {
+	if !validChunkHash(hash) {
+		return nil, fmt.Errorf("invalid chunk hash %q", hash)
+	}
+	data, err := os.ReadFile(cs.ObjectPath(hash))
+	if err != nil {
+		return nil, fmt.Errorf("reading chunk %s: %w", hash[:12], err)
+	}
+	if isGzip(data) {
+		if data, err = gzipDecompress(data); err != nil {
+			return nil, fmt.Errorf("decompressing chunk %s: %w", hash[:12], err)
+		}
+	}
+	if got := chunkHash(data); got != hash {
+		return nil, fmt.Errorf("chunk %s is corrupt (content hashes to %s)", hash[:12], got[:12])
+	}
+	return data, nil
+}
+
+// Verify checks that a chunk exists and matches its hash.
+func (cs *ChunkStore) Verify(hash string) error {
+	_, err := cs.Get(hash)
+	return err
+}
+
+// isGzip reports whether a stored chunk starts with the gzip magic number.
+// put always compresses chunks that begin with it, so plain chunks are
+// never mistaken for gzipped ones.
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// put writes a chunk, gzipped if compress is set, unless it is already
+// stored. Callers hold the lock.
+func (cs *ChunkStore) put(data []byte, compress bool) (string, error) {
+	hash := chunkHash(data)
+	path := cs.ObjectPath(hash)
+	if fileExists(path) {
+		return hash, nil
+	}
+	stored := data
+	if compress || isGzip(data) {
+		compressed, err := gzipCompress(data)
+		if err != nil {
+			return "", fmt.Errorf("compressing chunk: %w", err)
+		}
+		stored = compressed
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("creating chunk directory: %w", err)
+	}
+	if err := util.AtomicWriteFile(path, stored, 0600); err != nil {
+		return "", fmt.Errorf("writing chunk: %w", err)
+	}
+	return hash, nil
+}
+
+// list returns the hashes of all stored chunks.
+func (cs *ChunkStore) list() ([]string, error) {
+	var hashes []string
+	root := filepath.Join(cs.Dir, ChunkObjectsDir)
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() && validChunkHash(d.Name()) {
+			hashes = append(hashes, d.Name())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing chunks: %w", err)
+	}
+	sort.Strings(hashes)
+	return hashes, nil
+}
+
+func (cs *ChunkStore) loadRefs() (map[string]int, error) {
+	refs := make(map[string]int)
+	data, err := os.ReadFile(filepath.Join(cs.Dir, ChunkRefsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return refs, nil
+		}
+		return nil, fmt.Errorf("reading chunk refs: %w", err)
+	}
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, fmt.Errorf("parsing chunk refs: %w", err)
+	}
+	return refs, nil
+}
+
+func (cs *ChunkStore) saveRefs(refs map[string]int) error {
+	if err := os.MkdirAll(cs.Dir, 0755); err != nil {
+		return fmt.Errorf("creating chunk store: %w", err)
+	}
+	return writeJSON(filepath.Join(cs.Dir, ChunkRefsFile), refs)
+}
+
+// RefCount returns the number of references to a chunk.
+func (cs *ChunkStore) RefCount(hash string) (int, error) {
+	refs, err := cs.loadRefs()
+	if err != nil {
+		return 0, err
+	}
+	return refs[hash], nil
+}
+
+// release drops one reference per listed chunk and removes chunks that are
+// no longer referenced. Callers hold the lock.
+func (cs *ChunkStore) release(refs map[string]int, hashes []string) {
+	for _, h := range hashes {
+		switch n := refs[h]; {
+		case n > 1:
+			refs[h]--
+		case n == 1:
+			delete(refs, h)
+			if validChunkHash(h) {
+				_ = os.Remove(cs.ObjectPath(h))
+			}
+		default:
+			// Unknown count; leave the chunk for gc to decide.
+		}
+	}
+}
+
+// scrollbackManifestName returns the manifest file name for a pane.
+func scrollbackManifestName(paneID string) string {
+	return fmt.Sprintf("pane_%s%s", sanitizeName(paneID), ChunkManifestExt)
+}
+
+// IsChunkedScrollback reports whether a pane's scrollback file is a chunk manifest.
+func IsChunkedScrollback(relPath string) bool {
+	return strings.HasSuffix(relPath, ChunkManifestExt)
+}
+
+// readScrollbackManifest reads a pane manifest.
+func readScrollbackManifest(path string) (*ScrollbackManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m ScrollbackManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing scrollback manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// saveChunkedScrollback stores content in the chunk store, gzipping new
+// chunks if compress is set, and writes the pane manifest. A manifest
+// already at that path has its chunks released.
+func (s *Storage) saveChunkedScrollback(sessionName, checkpointID, paneID, content string, compress bool) (string, error) {
+	panesDir := s.PanesDirPath(sessionName, checkpointID)
+	if err := os.MkdirAll(panesDir, 0755); err != nil {
+		return "", fmt.Errorf("creating panes directory: %w", err)
+	}
+	filename := scrollbackManifestName(paneID)
+	manifestPath := filepath.Join(panesDir, filename)
+
+	cs := s.Chunks()
+	unlock, err := cs.lock()
+	if err != nil {
+		return "", fmt.Errorf("locking chunk store: %w", err)
+	}
+	defer unlock()
+
+	refs, err := cs.loadRefs()
+	if err != nil {
+		return "", err
+	}
+
+	manifest := &ScrollbackManifest{Version: 1, Size: int64(len(content)), Chunks: []string{}}
+	for _, chunk := range splitChunks([]byte(content)) {
+		hash, err := cs.put(chunk, compress)
+		if err != nil {
+			return "", fmt.Errorf("saving scrollback: %w", err)
+		}
+		manifest.Chunks = append(manifest.Chunks, hash)
+		refs[hash]++
+	}
+
+	if old, err := readScrollbackManifest(manifestPath); err == nil {
+		cs.release(refs, old.Chunks)
+	}
+
+	// Manifest and refs are written under the lock, so gc never sees
+	// referenced chunks without the manifest that references them.
+	if err := writeJSON(manifestPath, manifest); err != nil {
+		return "", fmt.Errorf("saving scrollback manifest: %w", err)
+	}
+	if err := cs.saveRefs(refs); err != nil {
+		return "", fmt.Errorf("saving chunk refs: %w", err)
+	}
+
+	return filepath.Join(PanesDir, filename), nil
+}
+
+// loadChunkedScrollback reassembles scrollback from a pane manifest.
+func (s *Storage) loadChunkedScrollback(manifestPath string) (string, error) {
+	manifest, err := readScrollbackManifest(manifestPath)
+	if err != nil {
+		return "", fmt.Errorf("reading scrollback: %w", err)
+	}
+	cs := s.Chunks()
+	var buf bytes.Buffer
+	buf.Grow(int(manifest.Size))
+	for _, hash := range manifest.Chunks {
+		data, err := cs.Get(hash)
+		if err != nil {
+			return "", fmt.Errorf("reading scrollback: %w", err)
+		}
+		buf.Write(data)
+	}
+	return buf.String(), nil
+}
+
+// checkpointChunks returns the chunks referenced by a checkpoint's pane
+// manifests, one entry per reference.
+func (s *Storage) checkpointChunks(sessionName, checkpointID string) []string {
+	entries, err := os.ReadDir(s.PanesDirPath(sessionName, checkpointID))
+	if err != nil {
+		return nil
+	}
+	var hashes []string
+	for _, e := range entries {
+		if e.IsDir() || !IsChunkedScrollback(e.Name()) {
+			continue
+		}
+		m, err := readScrollbackManifest(filepath.Join(s.PanesDirPath(sessionName, checkpointID), e.Name()))
+		if err != nil {
+			continue
+		}
+		hashes = append(hashes, m.Chunks...)
+	}
+	return hashes
+}
+
+// deleteCheckpointDir removes a checkpoint and releases its chunks.
+func (s *Storage) deleteCheckpointDir(sessionName, checkpointID string) error {
+	hashes := s.checkpointChunks(sessionName, checkpointID)
+	if len(hashes) == 0 {
+		return os.RemoveAll(s.CheckpointDir(sessionName, checkpointID))
+	}
+
+	cs := s.Chunks()
+	unlock, err := cs.lock()
+	if err != nil {
+		return fmt.Errorf("locking chunk store: %w", err)
+	}
+	defer unlock()
+
+	refs, err := cs.loadRefs()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(s.CheckpointDir(sessionName, checkpointID)); err != nil {
+		return err
+	}
+	cs.release(refs, hashes)
+	return cs.saveRefs(refs)
+}
+
+// GCOptions configures chunk garbage collection.
+type GCOptions struct {
+	// DryRun reports what would be removed without removing it
+	DryRun bool
+}
+
+// GCResult summarizes a chunk garbage collection.
+type GCResult struct {
+	// Manifests is the number of pane manifests scanned
+	Manifests int `json:"manifests"`
+	// Chunks is the number of chunks stored before collection
+	Chunks int `json:"chunks"`
+	// Referenced is the number of distinct chunks still referenced
+	Referenced int `json:"referenced"`
+	// Removed is the number of unreferenced chunks removed (or that would be)
+	Removed int `json:"removed"`
+	// BytesFreed is the on-disk size of removed chunks
+	BytesFreed int64 `json:"bytes_freed"`
+	// BytesKept is the on-disk size of the chunks that remain
+	BytesKept int64 `json:"bytes_kept"`
+	// RefsRepaired is the number of chunks whose stored count was wrong
+	RefsRepaired int `json:"refs_repaired"`
+	// Missing lists referenced chunks that are not stored
+	Missing []string `json:"missing,omitempty"`
+	// DryRun is true if nothing was changed
+	DryRun bool `json:"dry_run"`
+}
+
+// GC recounts chunk references from every checkpoint's pane manifests,
+// removes chunks nothing references and repairs the stored counts.
+func (s *Storage) GC(opts GCOptions) (*GCResult, error) {
+	cs := s.Chunks()
+	unlock, err := cs.lock()
+	if err != nil {
+		return nil, fmt.Errorf("locking chunk store: %w", err)
+	}
+	defer unlock()
+
+	result := &GCResult{DryRun: opts.DryRun}
+
+	// Mark
+	live := make(map[string]int)
+	sessions, err := os.ReadDir(s.BaseDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading checkpoints directory: %w", err)
+	}
+	for _, sess := range sessions {
+		if !sess.IsDir() || strings.HasPrefix(sess.Name(), ".") {
+			continue
+		}
+		cps, err := os.ReadDir(filepath.Join(s.BaseDir, sess.Name()))
+		if err != nil {
+			continue
+		}
+		for _, cp := range cps {
+			if !cp.IsDir() {
+				continue
+			}
+			panesDir := s.PanesDirPath(sess.Name(), cp.Name())
+			panes, err := os.ReadDir(panesDir)
+			if err != nil {
+				continue
+			}
+			for _, p := range panes {
+				if p.IsDir() || !IsChunkedScrollback(p.Name()) {
+					continue
+				}
+				m, err := readScrollbackManifest(filepath.Join(panesDir, p.Name()))
+				if err != nil {
+					// An unreadable manifest may still reference chunks;
+					// refuse to sweep rather than risk deleting them.
+					return nil, fmt.Errorf("reading %s: %w", filepath.Join(sess.Name(), cp.Name(), PanesDir, p.Name()), err)
+				}
+				result.Manifests++
+				for _, h := range m.Chunks {
+					live[h]++
+				}
+			}
+		}
+	}
+
+	// Sweep
+	stored, err := cs.list()
+	if err != nil {
+		return nil, err
+	}
+	result.Chunks = len(stored)
+	storedSet := make(map[string]bool, len(stored))
+	for _, h := range stored {
+		storedSet[h] = true
+		var size int64
+		if info, err := os.Stat(cs.ObjectPath(h)); err == nil {
+			size = info.Size()
+		}
+		if live[h] > 0 {
+			result.BytesKept += size
+			continue
+		}
+		result.Removed++
+		result.BytesFreed += size
+		if !opts.DryRun {
+			if err := os.Remove(cs.ObjectPath(h)); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("removing chunk %s: %w", h[:12], err)
+			}
+		}
+	}
+	for h := range live {
+		if !storedSet[h] {
+			result.Missing = append(result.Missing, h)
+		}
+	}
+	sort.Strings(result.Missing)
+	result.Referenced = len(live) - len(result.Missing)
+
+	// Repair counts
+	refs, err := cs.loadRefs()
+	if err != nil {
+		refs = make(map[string]int)
+	}
+	for h, n := range live {
+		if refs[h] != n {
+			result.RefsRepaired++
+		}
+	}
+	for h := range refs {
+		if live[h] == 0 {
+			result.RefsRepaired++
+		}
+	}
+	if !opts.DryRun && result.RefsRepaired > 0 {
+		if err := cs.saveRefs(live); err != nil {
+			return nil, fmt.Errorf("saving chunk refs: %w", err)
+		}
+	}
+
+	return result, nil
+}
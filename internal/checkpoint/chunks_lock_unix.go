@@ -0,0 +1,38 @@
+//go:build unix
+
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lock acquires both the in-process mutex and an exclusive flock on the
+// chunk store's lock file, so saves, deletes and gc from several ntm
+// processes serialize. Returns an unlock function to release both.
+func (cs *ChunkStore) lock() (func(), error) {
+	chunkMu.Lock()
+
+	if err := os.MkdirAll(cs.Dir, 0755); err != nil {
+		chunkMu.Unlock()
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(cs.Dir, chunkLockFile), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		chunkMu.Unlock()
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		chunkMu.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+		chunkMu.Unlock()
+	}, nil
+}
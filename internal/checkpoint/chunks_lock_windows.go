@@ -0,0 +1,13 @@
+//go:build windows
+
+package checkpoint
+
+// lock acquires the in-process mutex only on Windows.
+// File locking is not supported on Windows in this implementation.
+// Returns an unlock function to release the lock.
+func (cs *ChunkStore) lock() (func(), error) {
+	chunkMu.Lock()
+	return func() {
+		chunkMu.Unlock()
+	}, nil
+}
@@ -0,0 +1,268 @@
+package checkpoint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrollbackLines returns n distinct terminal-like lines starting at from.
+func scrollbackLines(from, n int) string {
+	var b strings.Builder
+	for i := from; i < from+n; i++ {
+		fmt.Fprintf(&b, "[%05d] agent output line with some payload %d\n", i, i*7919)
+	}
+	return b.String()
+}
+
+// saveChunkedCheckpoint saves a checkpoint whose panes hold the given scrollback.
+func saveChunkedCheckpoint(t *testing.T, storage *Storage, id string, panes ...string) *Checkpoint {
+	t.Helper()
+	cp := &Checkpoint{
+		Version:     CurrentVersion,
+		ID:          id,
+		Name:        id,
+		SessionName: "proj",
+		WorkingDir:  "/tmp",
+		CreatedAt:   time.Now(),
+		PaneCount:   len(panes),
+	}
+	if err := storage.Save(cp); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	for i, content := range panes {
+		paneID := fmt.Sprintf("%%%d", i)
+		rel, err := storage.SaveScrollback(cp.SessionName, cp.ID, paneID, content)
+		if err != nil {
+			t.Fatalf("SaveScrollback() error: %v", err)
+		}
+		cp.Session.Panes = append(cp.Session.Panes, PaneState{
+			Index: i, ID: paneID, Width: 80, Height: 24, ScrollbackFile: rel,
+		})
+	}
+	if err := storage.Save(cp); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	return cp
+}
+
+func storedChunks(t *testing.T, storage *Storage) []string {
+	t.Helper()
+	hashes, err := storage.Chunks().list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hashes
+}
+
+func TestSplitChunks_ReassemblesAndResyncs(t *testing.T) {
+	t.Parallel()
+	data := []byte(scrollbackLines(0, 4000))
+	chunks := splitChunks(data)
+	if len(chunks) < 10 {
+		t.Fatalf("got %d chunks for %d bytes", len(chunks), len(data))
+	}
+	var joined []byte
+	for _, c := range chunks {
+		if len(c) > maxChunkSize {
+			t.Errorf("chunk of %d bytes exceeds max", len(c))
+		}
+		joined = append(joined, c...)
+	}
+	if string(joined) != string(data) {
+		t.Fatal("chunks do not reassemble to the input")
+	}
+
+	// Scrolling 100 lines off the top changes only the first chunk or two.
+	shifted := splitChunks([]byte(scrollbackLines(100, 3900)))
+	before := make(map[string]bool)
+	for _, c := range chunks {
+		before[chunkHash(c)] = true
+	}
+	reused := 0
+	for _, c := range shifted {
+		if before[chunkHash(c)] {
+			reused++
+		}
+	}
+	if reused < len(shifted)-2 {
+		t.Errorf("reused %d of %d chunks after scrolling", reused, len(shifted))
+	}
+
+	if got := splitChunks([]byte(strings.Repeat("x", maxChunkSize*2+5))); len(got) != 3 {
+		t.Errorf("overlong line split into %d chunks, want 3", len(got))
+	}
+}
+
+func TestChunkStore_DedupAndRefcounts(t *testing.T) {
+	t.Parallel()
+	storage := NewStorageWithDir(t.TempDir())
+
+	base := scrollbackLines(0, 3000)
+	grown := base + scrollbackLines(3000, 200)
+	cp1 := saveChunkedCheckpoint(t, storage, "cp1", base, base)
+	afterFirst := len(storedChunks(t, storage))
+	cp2 := saveChunkedCheckpoint(t, storage, "cp2", grown)
+	afterSecond := len(storedChunks(t, storage))
+
+	// The 200 new lines (about 11 KB) span a few chunks; the rest is shared.
+	if added := afterSecond - afterFirst; added > 6 || added*5 > afterFirst {
+		t.Errorf("second checkpoint added %d chunks to %d for 200 new lines", added, afterFirst)
+	}
+
+	hash := chunkHash(splitChunks([]byte(base))[0])
+	if n, _ := storage.Chunks().RefCount(hash); n != 3 {
+		t.Errorf("first chunk refcount = %d, want 3 (two panes and one later checkpoint)", n)
+	}
+
+	// Deleting one checkpoint keeps chunks the other still uses.
+	if err := storage.Delete(cp1.SessionName, cp1.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err := storage.LoadScrollback(cp2.SessionName, cp2.ID, "%0")
+	if err != nil || got != grown {
+		t.Fatalf("LoadScrollback() after deleting cp1: err=%v, equal=%v", err, got == grown)
+	}
+	if err := storage.Delete(cp2.SessionName, cp2.ID); err != nil {
+		t.Fatal(err)
+	}
+	if left := storedChunks(t, storage); len(left) != 0 {
+		t.Errorf("%d chunks left after deleting every checkpoint", len(left))
+	}
+}
+
+func TestChunkStore_Uncompressed(t *testing.T) {
+	t.Parallel()
+	storage := NewStorageWithDir(t.TempDir())
+	content := scrollbackLines(0, 300)
+
+	if _, err := storage.saveChunkedScrollback("proj", "plain", "%0", content, false); err != nil {
+		t.Fatal(err)
+	}
+	first := splitChunks([]byte(content))[0]
+	raw, err := os.ReadFile(storage.Chunks().ObjectPath(chunkHash(first)))
+	if err != nil || string(raw) != string(first) {
+		t.Fatalf("uncompressed chunk stored as %d bytes (err %v), want the %d plain bytes", len(raw), err, len(first))
+	}
+
+	// A compressed checkpoint reuses the plain chunks and adds gzipped ones.
+	grown := content + scrollbackLines(300, 100)
+	if _, err := storage.saveChunkedScrollback("proj", "gz", "%0", grown, true); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]string{"plain": content, "gz": grown} {
+		if got, err := storage.LoadScrollback("proj", id, "%0"); err != nil || got != want {
+			t.Errorf("LoadScrollback(%s): err=%v, equal=%v", id, err, got == want)
+		}
+	}
+
+	// A plain chunk that looks gzipped is compressed so reads stay unambiguous.
+	magic := []byte("\x1f\x8bnot really gzip\n")
+	hash, err := storage.Chunks().put(magic, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := storage.Chunks().Get(hash); err != nil || string(got) != string(magic) {
+		t.Errorf("Get(magic chunk) = %q, %v", got, err)
+	}
+}
+
+func TestStorage_GC(t *testing.T) {
+	t.Parallel()
+	storage := NewStorageWithDir(t.TempDir())
+	cp := saveChunkedCheckpoint(t, storage, "cp1", scrollbackLines(0, 500))
+
+	cs := storage.Chunks()
+	unlock, err := cs.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orphan, err := cs.put([]byte("left behind by a crashed save\n"), true)
+	unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(cs.Dir, ChunkRefsFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	dry, err := storage.GC(GCOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("GC(dry run) error: %v", err)
+	}
+	if dry.Removed != 1 || !cs.Has(orphan) {
+		t.Errorf("dry run removed=%d, orphan present=%v", dry.Removed, cs.Has(orphan))
+	}
+
+	res, err := storage.GC(GCOptions{})
+	if err != nil {
+		t.Fatalf("GC() error: %v", err)
+	}
+	if res.Removed != 1 || cs.Has(orphan) || res.Manifests != 1 || res.RefsRepaired == 0 {
+		t.Errorf("GC() = %+v", res)
+	}
+	if _, err := storage.LoadScrollback(cp.SessionName, cp.ID, "%0"); err != nil {
+		t.Errorf("live scrollback lost by gc: %v", err)
+	}
+	if n, _ := cs.RefCount(chunkHash(splitChunks([]byte(scrollbackLines(0, 500)))[0])); n != 1 {
+		t.Errorf("rebuilt refcount = %d, want 1", n)
+	}
+}
+
+func TestVerify_CoversChunks(t *testing.T) {
+	t.Parallel()
+	storage := NewStorageWithDir(t.TempDir())
+	cp := saveChunkedCheckpoint(t, storage, "cp1", scrollbackLines(0, 500))
+
+	if res := cp.Verify(storage); !res.Valid || res.Details["chunks_verified"] == "0" {
+		t.Fatalf("Verify() on intact checkpoint = %+v", res)
+	}
+
+	manifest, err := readScrollbackManifest(filepath.Join(storage.CheckpointDir(cp.SessionName, cp.ID), cp.Session.Panes[0].ScrollbackFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := storage.Chunks()
+	corrupt, _ := gzipCompress([]byte("tampered"))
+	if err := os.WriteFile(cs.ObjectPath(manifest.Chunks[0]), corrupt, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(cs.ObjectPath(manifest.Chunks[1])); err != nil {
+		t.Fatal(err)
+	}
+
+	res := cp.Verify(storage)
+	if res.Valid || res.ChecksumsValid || res.FilesPresent {
+		t.Errorf("Verify() after damage: valid=%v checksums=%v files=%v", res.Valid, res.ChecksumsValid, res.FilesPresent)
+	}
+}
+
+func TestExport_ChunkedScrollbackRoundTrip(t *testing.T) {
+	t.Parallel()
+	storage := NewStorageWithDir(t.TempDir())
+	content := scrollbackLines(0, 800)
+	cp := saveChunkedCheckpoint(t, storage, "cp1", content)
+
+	archive := filepath.Join(t.TempDir(), "cp.tar.gz")
+	opts := DefaultExportOptions()
+	opts.RedactSecrets = false
+	if _, err := storage.Export(cp.SessionName, cp.ID, archive, opts); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	other := NewStorageWithDir(t.TempDir())
+	imported, err := other.Import(archive, DefaultImportOptions())
+	if err != nil {
+		t.Fatalf("Import() error: %v", err)
+	}
+	if imported.Session.Panes[0].ScrollbackFile != "panes/pane__0.txt" {
+		t.Errorf("imported scrollback file = %q", imported.Session.Panes[0].ScrollbackFile)
+	}
+	got, err := other.LoadScrollback(imported.SessionName, imported.ID, "%0")
+	if err != nil || got != content {
+		t.Errorf("imported scrollback: err=%v, equal=%v", err, got == content)
+	}
+}
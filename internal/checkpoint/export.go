@@ -143,11 +143,26 @@ func (s *Storage) Export(sessionName, checkpointID string, destPath string, opts
 	var files []string
 	files = append(files, MetadataFile)
 
+	// Chunked scrollback is exported as plain text, so archives stay
+	// self-contained
+	inline := make(map[string][]byte)
+	exportPanes := cp.Session.Panes
 	if opts.IncludeScrollback {
-		for _, pane := range cp.Session.Panes {
-			if pane.ScrollbackFile != "" {
-				files = append(files, pane.ScrollbackFile)
+		exportPanes = make([]PaneState, len(cp.Session.Panes))
+		copy(exportPanes, cp.Session.Panes)
+		for i, pane := range exportPanes {
+			if pane.ScrollbackFile == "" {
+				continue
 			}
+			if IsChunkedScrollback(pane.ScrollbackFile) {
+				content, err := s.LoadScrollback(sessionName, checkpointID, pane.ID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to load scrollback for pane %s: %w", pane.ID, err)
+				}
+				exportPanes[i].ScrollbackFile = strings.TrimSuffix(pane.ScrollbackFile, ChunkManifestExt) + ".txt"
+				inline[exportPanes[i].ScrollbackFile] = []byte(content)
+			}
+			files = append(files, exportPanes[i].ScrollbackFile)
 		}
 	}
 
@@ -171,13 +186,18 @@ func (s *Storage) Export(sessionName, checkpointID string, destPath string, opts
 	if opts.RewritePaths {
 		cpData = rewriteCheckpointPaths(cp)
 	}
+	if len(inline) > 0 {
+		withPanes := *cpData
+		withPanes.Session.Panes = exportPanes
+		cpData = &withPanes
+	}
 
 	// Create the archive
 	switch opts.Format {
 	case FormatTarGz:
-		err = s.exportTarGz(destPath, cpDir, cpData, files, opts, manifest)
+		err = s.exportTarGz(destPath, cpDir, cpData, files, inline, opts, manifest)
 	case FormatZip:
-		err = s.exportZip(destPath, cpDir, cpData, files, opts, manifest)
+		err = s.exportZip(destPath, cpDir, cpData, files, inline, opts, manifest)
 	default:
 		return nil, fmt.Errorf("unsupported export format: %s", opts.Format)
 	}
@@ -189,7 +209,7 @@ func (s *Storage) Export(sessionName, checkpointID string, destPath string, opts
 	return manifest, nil
 }
 
-func (s *Storage) exportTarGz(destPath, cpDir string, cp *Checkpoint, files []string, opts ExportOptions, manifest *ExportManifest) error {
+func (s *Storage) exportTarGz(destPath, cpDir string, cp *Checkpoint, files []string, inline map[string][]byte, opts ExportOptions, manifest *ExportManifest) error {
 	f, err := os.Create(destPath)
 	if err != nil {
 		return fmt.Errorf("failed to create export file: %w", err)
@@ -226,8 +246,7 @@ func (s *Storage) exportTarGz(destPath, cpDir string, cp *Checkpoint, files []st
 			continue
 		}
 
-		srcPath := filepath.Join(cpDir, file)
-		data, err := os.ReadFile(srcPath)
+		data, err := readExportFile(cpDir, file, inline)
 		if err != nil {
 			continue
 		}
@@ -262,7 +281,7 @@ func (s *Storage) exportTarGz(destPath, cpDir string, cp *Checkpoint, files []st
 	return nil
 }
 
-func (s *Storage) exportZip(destPath, cpDir string, cp *Checkpoint, files []string, opts ExportOptions, manifest *ExportManifest) error {
+func (s *Storage) exportZip(destPath, cpDir string, cp *Checkpoint, files []string, inline map[string][]byte, opts ExportOptions, manifest *ExportManifest) error {
 	f, err := os.Create(destPath)
 	if err != nil {
 		return fmt.Errorf("failed to create export file: %w", err)
@@ -296,8 +315,7 @@ func (s *Storage) exportZip(destPath, cpDir string, cp *Checkpoint, files []stri
 			continue
 		}
 
-		srcPath := filepath.Join(cpDir, file)
-		data, err := os.ReadFile(srcPath)
+		data, err := readExportFile(cpDir, file, inline)
 		if err != nil {
 			continue
 		}
@@ -613,6 +631,15 @@ func (s *Storage) importZip(archivePath string, opts ImportOptions) (*Checkpoint
 
 // Helper functions
 
+// readExportFile returns an archive file's content, preferring content
+// materialized in memory over the file on disk.
+func readExportFile(cpDir, file string, inline map[string][]byte) ([]byte, error) {
+	if data, ok := inline[file]; ok {
+		return data, nil
+	}
+	return os.ReadFile(filepath.Join(cpDir, file))
+}
+
 func writeTarEntry(tw *tar.Writer, name string, data []byte) error {
 	header := &tar.Header{
 		Name:    name,
@@ -70,8 +70,9 @@ func (c *Checkpoint) Verify(storage *Storage) *IntegrityResult {
 	c.validateConsistency(result)
 
 	// Overall validity
-	result.Valid = result.SchemaValid && result.FilesPresent && result.ConsistencyValid
-	// Checksums are optional (only checked if manifest exists)
+	result.Valid = result.SchemaValid && result.FilesPresent && result.ChecksumsValid && result.ConsistencyValid
+	// File checksums are optional (only checked if manifest exists); chunk
+	// hashes are always verified
 
 	return result
 }
@@ -136,12 +137,15 @@ func (c *Checkpoint) checkFiles(storage *Storage, dir string, result *IntegrityR
 
 	// Check scrollback files for each pane
 	missingScrollback := 0
+	chunksVerified := 0
 	for _, pane := range c.Session.Panes {
 		if pane.ScrollbackFile != "" {
 			scrollPath := filepath.Join(dir, pane.ScrollbackFile)
 			if !fileExists(scrollPath) {
 				missingScrollback++
 				result.Errors = append(result.Errors, fmt.Sprintf("missing scrollback file for pane %s: %s", pane.ID, pane.ScrollbackFile))
+			} else if IsChunkedScrollback(pane.ScrollbackFile) {
+				chunksVerified += checkChunks(storage, pane, scrollPath, result)
 			}
 		}
 	}
@@ -149,6 +153,7 @@ func (c *Checkpoint) checkFiles(storage *Storage, dir string, result *IntegrityR
 	if missingScrollback > 0 {
 		result.FilesPresent = false
 	}
+	result.Details["chunks_verified"] = fmt.Sprintf("%d", chunksVerified)
 
 	// Check git patch if referenced
 	if c.Git.PatchFile != "" {
@@ -163,6 +168,40 @@ func (c *Checkpoint) checkFiles(storage *Storage, dir string, result *IntegrityR
 	result.Details["files_checked"] = fmt.Sprintf("%d", 2+len(c.Session.Panes))
 }
 
+// checkChunks verifies every chunk of a pane's scrollback manifest and
+// returns the number that passed.
+func checkChunks(storage *Storage, pane PaneState, manifestPath string, result *IntegrityResult) int {
+	manifest, err := readScrollbackManifest(manifestPath)
+	if err != nil {
+		result.ChecksumsValid = false
+		result.Errors = append(result.Errors, fmt.Sprintf("unreadable scrollback manifest for pane %s: %v", pane.ID, err))
+		return 0
+	}
+
+	cs := storage.Chunks()
+	verified := 0
+	var size int64
+	for _, hash := range manifest.Chunks {
+		data, err := cs.Get(hash)
+		switch {
+		case err == nil:
+			verified++
+			size += int64(len(data))
+		case errors.Is(err, os.ErrNotExist):
+			result.FilesPresent = false
+			result.Errors = append(result.Errors, fmt.Sprintf("missing scrollback chunk for pane %s: %s", pane.ID, hash))
+		default:
+			result.ChecksumsValid = false
+			result.Errors = append(result.Errors, fmt.Sprintf("bad scrollback chunk for pane %s: %v", pane.ID, err))
+		}
+	}
+	if verified == len(manifest.Chunks) && size != manifest.Size {
+		result.ChecksumsValid = false
+		result.Errors = append(result.Errors, fmt.Sprintf("scrollback for pane %s is %d bytes, manifest says %d", pane.ID, size, manifest.Size))
+	}
+	return verified
+}
+
 // validateConsistency checks internal consistency of the checkpoint data.
 func (c *Checkpoint) validateConsistency(result *IntegrityResult) {
 	// Check pane count matches
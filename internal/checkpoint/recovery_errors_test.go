@@ -224,7 +224,7 @@ func TestStorage_LoadScrollback_PermissionDenied(t *testing.T) {
 	}
 
 	// Make scrollback file unreadable
-	scrollbackPath := filepath.Join(storage.PanesDirPath(sessionName, checkpointID), "pane__0.txt")
+	scrollbackPath := filepath.Join(storage.PanesDirPath(sessionName, checkpointID), "pane__0.chunks.json")
 	if err := os.Chmod(scrollbackPath, 0000); err != nil {
 		t.Fatalf("Chmod failed: %v", err)
 	}
@@ -164,7 +164,12 @@ func (s *Storage) SaveCompressedScrollback(sessionName, checkpointID, paneID str
 
 // LoadCompressedScrollback reads and decompresses scrollback from a file.
 func (s *Storage) LoadCompressedScrollback(sessionName, checkpointID, paneID string) (string, error) {
-	// Try compressed file first
+	// Chunked scrollback is compressed per chunk
+	if fileExists(filepath.Join(s.PanesDirPath(sessionName, checkpointID), scrollbackManifestName(paneID))) {
+		return s.LoadScrollback(sessionName, checkpointID, paneID)
+	}
+
+	// Try compressed file next
 	filename := fmt.Sprintf("pane_%s.txt.gz", sanitizeName(paneID))
 	fullPath := filepath.Join(s.PanesDirPath(sessionName, checkpointID), filename)
 
@@ -202,16 +207,9 @@ func (c *Capturer) captureScrollbackEnhanced(cp *Checkpoint, config ScrollbackCo
 			continue
 		}
 
-		// Save scrollback
-		var relativePath string
-		var saveErr error
-
-		if config.Compress && len(capture.Compressed) > 0 {
-			relativePath, saveErr = c.storage.SaveCompressedScrollback(cp.SessionName, cp.ID, pane.ID, capture.Compressed)
-		} else {
-			relativePath, saveErr = c.storage.SaveScrollback(cp.SessionName, cp.ID, pane.ID, capture.Content)
-		}
-
+		// Save scrollback to the chunk store, which shares each chunk with
+		// other panes and checkpoints and compresses it if enabled
+		relativePath, saveErr := c.storage.saveChunkedScrollback(cp.SessionName, cp.ID, pane.ID, capture.Content, config.Compress)
 		if saveErr != nil {
 			slog.Warn("failed to save scrollback", "pane", pane.Index, "error", saveErr)
 			continue
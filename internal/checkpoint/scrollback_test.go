@@ -134,15 +134,13 @@ func TestStorage_LoadCompressedScrollback_FallbackToUncompressed(t *testing.T) {
 	paneID := "%0"
 	content := "Uncompressed scrollback content\n"
 
-	// Save uncompressed scrollback using the old method
-	relativePath, err := storage.SaveScrollback(sessionName, checkpointID, paneID, content)
-	if err != nil {
-		t.Fatalf("SaveScrollback failed: %v", err)
+	// Write uncompressed scrollback in the pre-chunk-store layout
+	panesDir := storage.PanesDirPath(sessionName, checkpointID)
+	if err := os.MkdirAll(panesDir, 0755); err != nil {
+		t.Fatal(err)
 	}
-
-	// Verify it's a .txt file
-	if !strings.HasSuffix(relativePath, ".txt") {
-		t.Errorf("Expected .txt suffix, got %s", relativePath)
+	if err := os.WriteFile(filepath.Join(panesDir, "pane__0.txt"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
 	}
 
 	// Load using compressed method (should fall back to uncompressed)
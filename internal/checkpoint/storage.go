@@ -217,7 +217,8 @@ func (s *Storage) ListAll() ([]*Checkpoint, error) {
 
 	var all []*Checkpoint
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		// Skip files and internal directories such as the chunk store
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
 			continue
 		}
 		sessionCheckpoints, err := s.List(entry.Name())
@@ -235,10 +236,9 @@ func (s *Storage) ListAll() ([]*Checkpoint, error) {
 	return all, nil
 }
 
-// Delete removes a checkpoint from disk.
+// Delete removes a checkpoint from disk and releases its scrollback chunks.
 func (s *Storage) Delete(sessionName, checkpointID string) error {
-	dir := s.CheckpointDir(sessionName, checkpointID)
-	return os.RemoveAll(dir)
+	return s.deleteCheckpointDir(sessionName, checkpointID)
 }
 
 // GetLatest returns the most recent checkpoint for a session.
@@ -253,29 +253,24 @@ func (s *Storage) GetLatest(sessionName string) (*Checkpoint, error) {
 	return checkpoints[0], nil
 }
 
-// SaveScrollback writes pane scrollback to a file.
+// SaveScrollback stores pane scrollback in the chunk store, compressed, and
+// returns the relative path of the pane's chunk manifest.
 func (s *Storage) SaveScrollback(sessionName, checkpointID string, paneID string, content string) (string, error) {
+	return s.saveChunkedScrollback(sessionName, checkpointID, paneID, content, true)
+}
+
+// LoadScrollback reads pane scrollback, from the chunk store or from a
+// plain-text file written by older versions.
+func (s *Storage) LoadScrollback(sessionName, checkpointID string, paneID string) (string, error) {
 	panesDir := s.PanesDirPath(sessionName, checkpointID)
-	if err := os.MkdirAll(panesDir, 0755); err != nil {
-		return "", fmt.Errorf("creating panes directory: %w", err)
+	manifestPath := filepath.Join(panesDir, scrollbackManifestName(paneID))
+	if fileExists(manifestPath) {
+		return s.loadChunkedScrollback(manifestPath)
 	}
 
-	// Use sanitized pane ID for filename to handle % and other chars
 	filename := fmt.Sprintf("pane_%s.txt", sanitizeName(paneID))
 	fullPath := filepath.Join(panesDir, filename)
 
-	if err := util.AtomicWriteFile(fullPath, []byte(content), 0600); err != nil {
-		return "", fmt.Errorf("saving scrollback: %w", err)
-	}
-
-	return filepath.Join(PanesDir, filename), nil
-}
-
-// LoadScrollback reads pane scrollback from a file.
-func (s *Storage) LoadScrollback(sessionName, checkpointID string, paneID string) (string, error) {
-	filename := fmt.Sprintf("pane_%s.txt", sanitizeName(paneID))
-	fullPath := filepath.Join(s.PanesDirPath(sessionName, checkpointID), filename)
-
 	data, err := os.ReadFile(fullPath)
 	if err != nil {
 		return "", fmt.Errorf("reading scrollback: %w", err)
@@ -232,6 +232,10 @@ func runAdd(opts AddOptions) error {
 		}
 	}
 
+	if err := checkBudget(session, "add"); err != nil {
+		return outputError(err)
+	}
+
 	// Initialize hook executor
 	hookExec, err := hooks.NewExecutorFromConfig()
 	if err != nil {
@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/agentmail"
+	"github.com/Dicklesworthstone/ntm/internal/alerts"
+	"github.com/Dicklesworthstone/ntm/internal/budget"
+	ctxmon "github.com/Dicklesworthstone/ntm/internal/context"
+	"github.com/Dicklesworthstone/ntm/internal/coordinator"
+	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/notify"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// alertRuleSampleInterval is how often the session monitor samples agent
+// metrics for alert rules.
+const alertRuleSampleInterval = 30 * time.Second
+
+// runAlertRules evaluates the configured alert rules for session until ctx
+// is done. Every interval it publishes a metrics_sample event; with the
+// durable event log enabled these are recorded, so 'ntm alerts rules test'
+// can replay them later. Events are read back from the durable log when it
+// is enabled (seeing those of other ntm processes) and from the in-process
+// bus otherwise.
+func runAlertRules(ctx context.Context, session, projectDir string) {
+	var rules []*alerts.CompiledRule
+	if cfg != nil {
+		compiled, err := alerts.CompileRules(cfg.Alerts.Rules)
+		if err != nil {
+			slog.Default().Warn("alert rules disabled", "error", err)
+		}
+		rules = compiled
+	}
+	eventLog := events.DefaultBus.Log()
+	if len(rules) == 0 && eventLog == nil {
+		return
+	}
+
+	incoming := make(chan events.BusEvent, 256)
+	forward := func(ev events.BusEvent) {
+		if ev.EventSession() != session {
+			return
+		}
+		select {
+		case incoming <- ev:
+		case <-ctx.Done():
+		}
+	}
+	if eventLog != nil {
+		next, err := eventLog.NextOffset()
+		if err == nil {
+			go eventLog.Follow(next, ctx.Done(), func(_ uint64, ev events.BusEvent) { forward(ev) })
+		}
+	} else {
+		unsubscribe := events.DefaultBus.SubscribeAll(forward)
+		defer unsubscribe()
+	}
+
+	var notifier *notify.Notifier
+	if cfg != nil {
+		notifier = notify.NewWithRedaction(cfg.Notifications, cfg.Redaction.ToRedactionLibConfig())
+	}
+	engine := alerts.NewEngine(rules)
+	transcripts := ctxmon.NewTranscriptEstimator(ctxmon.DefaultTranscriptLocator())
+	ticker := time.NewTicker(alertRuleSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sample, err := sampleSessionMetrics(ctx, session, projectDir, transcripts)
+			if err != nil {
+				slog.Default().Debug("metrics sample failed", "session", session, "error", err)
+				continue
+			}
+			events.DefaultBus.Publish(sample)
+		case ev := <-incoming:
+			feedAlertRules(engine, ev)
+			for _, f := range engine.Evaluate(ev.EventTimestamp()) {
+				if !f.Resolved {
+					slog.Default().Info("alert rule fired", "rule", f.RuleName, "key", f.Key, "message", f.Alert.Message)
+					events.DefaultBus.Publish(events.NewAlertEvent(session, f.Alert.ID, string(f.Alert.Type), string(f.Alert.Severity), f.Alert.Message))
+				}
+				if err := alerts.Route(f, notifier); err != nil {
+					slog.Default().Warn("alert rule delivery failed", "rule", f.RuleName, "error", err)
+				}
+			}
+		}
+	}
+}
+
+// feedAlertRules records ev in engine, as samples if it is a metrics_sample
+// event.
+func feedAlertRules(engine *alerts.Engine, ev events.BusEvent) {
+	if ev.EventType() == "metrics_sample" {
+		var sample events.MetricsSampleEvent
+		switch e := ev.(type) {
+		case events.MetricsSampleEvent:
+			sample = e
+		case *events.MetricsSampleEvent:
+			sample = *e
+		case events.RecordedEvent:
+			if err := json.Unmarshal(e.Payload, &sample); err != nil {
+				return
+			}
+		default:
+			return
+		}
+		for _, s := range alertSamples(sample) {
+			engine.Observe(s)
+		}
+		return
+	}
+
+	// Events carry pane, agent and message under these names (see
+	// events.WebhookEvent and events.AgentErrorEvent); decode them generically
+	// so replayed records and typed events are handled alike.
+	var fields struct {
+		Pane    string `json:"pane"`
+		Agent   string `json:"agent"`
+		AgentID string `json:"agent_id"`
+		Message string `json:"message"`
+	}
+	if data, err := json.Marshal(ev); err == nil {
+		_ = json.Unmarshal(data, &fields)
+	}
+	if fields.Pane == "" {
+		fields.Pane = fields.AgentID
+	}
+	engine.ObserveEvent(alerts.RuleEvent{
+		Time:    ev.EventTimestamp(),
+		Type:    ev.EventType(),
+		Session: ev.EventSession(),
+		Pane:    fields.Pane,
+		Agent:   fields.Agent,
+		Message: fields.Message,
+	})
+}
+
+// alertSamples converts a metrics_sample event into engine samples: one per
+// agent and one with the session-wide metrics.
+func alertSamples(e events.MetricsSampleEvent) []alerts.Sample {
+	samples := make([]alerts.Sample, 0, len(e.Agents)+1)
+	for _, a := range e.Agents {
+		samples = append(samples, alerts.Sample{
+			Time:    e.Timestamp,
+			Session: e.Session,
+			Pane:    a.Pane,
+			Agent:   a.Agent,
+			Metrics: map[string]float64{
+				alerts.MetricContextPct:  a.ContextPct,
+				alerts.MetricIdleMinutes: a.IdleMinutes,
+				alerts.MetricCostUSD:     a.CostUSD,
+			},
+		})
+	}
+	session := alerts.Sample{Time: e.Timestamp, Session: e.Session, Metrics: map[string]float64{}}
+	if e.FileConflicts != nil {
+		session.Metrics[alerts.MetricFileConflicts] = float64(*e.FileConflicts)
+	}
+	return append(samples, session)
+}
+
+// sampleSessionMetrics reads the metrics alert rules evaluate for the agent
+// panes of session: idle time from tmux, context usage and spend from the
+// agents' transcripts, and file reservation conflicts from Agent Mail.
+func sampleSessionMetrics(ctx context.Context, session, projectDir string, transcripts *ctxmon.TranscriptEstimator) (events.MetricsSampleEvent, error) {
+	activity, err := tmux.GetPanesWithActivityContext(ctx, session)
+	if err != nil {
+		return events.MetricsSampleEvent{}, err
+	}
+
+	var (
+		panes  []tmux.Pane
+		agents []events.AgentMetrics
+	)
+	usage := make(map[string]*ctxmon.TranscriptUsage)
+	for _, pa := range activity {
+		p := pa.Pane
+		switch p.Type {
+		case tmux.AgentClaude, tmux.AgentCodex, tmux.AgentGemini:
+		default:
+			continue
+		}
+		panes = append(panes, p)
+		m := events.AgentMetrics{
+			Pane:        p.ID,
+			Agent:       string(p.Type),
+			IdleMinutes: time.Since(pa.LastActivity).Minutes(),
+		}
+		if u := transcripts.PaneUsage(p.ID, string(p.Type), projectDir); u != nil {
+			usage[p.ID] = u
+			var model string
+			if cfg != nil {
+				model = cfg.Models.GetModelName(string(p.Type), p.Variant)
+			}
+			if limit := u.ContextLimit(model); limit > 0 {
+				m.ContextPct = float64(u.ContextTokens) / float64(limit) * 100
+			}
+		}
+		agents = append(agents, m)
+	}
+	spend := budget.SpendFromUsage(cfg, panes, usage)
+	for i := range agents {
+		agents[i].CostUSD = spend[agents[i].Pane].USD
+	}
+
+	var conflicts *int
+	mail := agentmail.NewClient(agentmail.WithProjectKey(projectDir))
+	if mail.IsAvailable() {
+		cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		found, err := coordinator.NewConflictDetector(mail, projectDir).DetectConflicts(cctx)
+		cancel()
+		if err == nil {
+			n := len(found)
+			conflicts = &n
+		}
+	}
+	return events.NewMetricsSampleEvent(session, agents, conflicts), nil
+}
@@ -0,0 +1,308 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/alerts"
+	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/util"
+)
+
+func newAlertsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alerts",
+		Short: "Manage user-defined alert rules",
+		Long: `Manage the alert rules configured as [[alerts.rules]] in config.toml.
+
+A rule fires when its expression over agent metrics holds for its 'for'
+duration. The session monitor evaluates rules every 30 seconds and routes
+fired alerts to notify channels and webhooks.
+
+Examples:
+  ntm alerts rules list             # Show configured rules
+  ntm alerts rules test --since=24h # Replay recorded history through the rules`,
+	}
+
+	rules := &cobra.Command{
+		Use:   "rules",
+		Short: "List and test alert rules",
+	}
+	rules.AddCommand(newAlertsRulesListCmd(), newAlertsRulesTestCmd())
+	cmd.AddCommand(rules)
+	return cmd
+}
+
+func newAlertsRulesListCmd() *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Show the configured alert rules",
+		Example: `  ntm alerts rules list
+  ntm alerts rules list --file=rules.toml --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rules, err := loadAlertRules(file, nil)
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				list := make([]alerts.Rule, len(rules))
+				for i, r := range rules {
+					list[i] = r.Rule
+				}
+				return output.PrintJSON(map[string]interface{}{"rules": list, "count": len(list)})
+			}
+			if len(rules) == 0 {
+				fmt.Println("No alert rules configured. Add one to ~/.config/ntm/config.toml:")
+				fmt.Println()
+				fmt.Println("  [[alerts.rules]]")
+				fmt.Println("  name = \"context-high\"")
+				fmt.Println("  expr = \"context_pct > 85 && idle_minutes >= 10\"")
+				fmt.Println("  for = \"5m\"")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "NAME\tEXPR\tFOR\tWINDOW\tSEVERITY\tROUTES")
+			for _, r := range rules {
+				routes := append(append([]string{}, r.Notify...), r.Webhooks...)
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Name, r.Expr, r.ForDuration(), r.WindowDuration(), r.Severity, strings.Join(routes, ","))
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "Read rules from this TOML file instead of config.toml")
+	return cmd
+}
+
+func newAlertsRulesTestCmd() *cobra.Command {
+	var (
+		file    string
+		since   time.Duration
+		session string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test [rule...]",
+		Short: "Evaluate alert rules against recorded history",
+		Long: `Replay the durable event log through alert rules and report when each
+would have fired and resolved. Nothing is sent to notify channels or webhooks.
+
+History comes from the durable event log ([events] durable = true), which
+records the metrics_sample events the session monitor publishes every 30
+seconds alongside agent errors, rate limits and other events.
+
+With rule names as arguments only those rules are evaluated. --file tests
+rules from a TOML file ([[rules]] or [[alerts.rules]] tables) before adding
+them to config.toml.`,
+		Example: `  ntm alerts rules test
+  ntm alerts rules test context-high --since=2h --session=myproject
+  ntm alerts rules test --file=draft-rules.toml --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rules, err := loadAlertRules(file, args)
+			if err != nil {
+				return err
+			}
+			if len(rules) == 0 {
+				return fmt.Errorf("no alert rules to test; configure [[alerts.rules]] or pass --file")
+			}
+			eventLog, closeLog, err := openAlertHistory()
+			if err != nil {
+				return err
+			}
+			defer closeLog()
+
+			result, err := replayAlertRules(eventLog, rules, time.Now().Add(-since), session)
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(result)
+			}
+			printAlertRulesTest(result)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "Read rules from this TOML file instead of config.toml")
+	cmd.Flags().DurationVar(&since, "since", 24*time.Hour, "How much history to replay")
+	cmd.Flags().StringVarP(&session, "session", "s", "", "Only replay this session's events")
+	return cmd
+}
+
+// loadAlertRules compiles the rules in file, or the configured rules when
+// file is empty, keeping only the named ones when names are given.
+func loadAlertRules(file string, names []string) ([]*alerts.CompiledRule, error) {
+	var list []alerts.Rule
+	if file != "" {
+		var doc struct {
+			Rules  []alerts.Rule `toml:"rules"`
+			Alerts struct {
+				Rules []alerts.Rule `toml:"rules"`
+			} `toml:"alerts"`
+		}
+		if _, err := toml.DecodeFile(util.ExpandPath(file), &doc); err != nil {
+			return nil, fmt.Errorf("reading rules: %w", err)
+		}
+		list = append(doc.Rules, doc.Alerts.Rules...)
+	} else if cfg != nil {
+		list = cfg.Alerts.Rules
+	}
+
+	rules, err := alerts.CompileRules(list)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return rules, nil
+	}
+	var selected []*alerts.CompiledRule
+	for _, name := range names {
+		found := false
+		for _, r := range rules {
+			if r.Name == name {
+				selected = append(selected, r)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+	return selected, nil
+}
+
+// openAlertHistory returns the durable event log, opening it read-side when
+// this process did not attach it to the bus.
+func openAlertHistory() (*events.EventLog, func(), error) {
+	if l := events.DefaultBus.Log(); l != nil {
+		return l, func() {}, nil
+	}
+	var dir string
+	if cfg != nil {
+		dir = util.ExpandPath(cfg.Events.Dir)
+	}
+	if dir == "" {
+		dir, _ = events.DefaultEventLogDir()
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, nil, fmt.Errorf("no recorded event history in %s; enable it with [events] durable = true", dir)
+	}
+	l, err := events.OpenEventLog(dir, events.EventLogOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, func() { l.Close() }, nil
+}
+
+// AlertRulesTestResult is the outcome of replaying history through alert
+// rules.
+type AlertRulesTestResult struct {
+	Since       time.Time             `json:"since"`
+	Until       time.Time             `json:"until"`
+	Session     string                `json:"session,omitempty"`
+	Events      int                   `json:"events"`
+	Samples     int                   `json:"samples"`
+	Rules       []AlertRuleTestResult `json:"rules"`
+	Firings     []alerts.Firing       `json:"firings"`
+	StillFiring []alerts.Alert        `json:"still_firing"`
+}
+
+// AlertRuleTestResult counts one rule's firings in a replay.
+type AlertRuleTestResult struct {
+	Name     string `json:"name"`
+	Fired    int    `json:"fired"`
+	Resolved int    `json:"resolved"`
+}
+
+// replayAlertRules feeds the events recorded since the given time (only
+// session's, if set) through rules, evaluating them at each event's time.
+func replayAlertRules(eventLog *events.EventLog, rules []*alerts.CompiledRule, since time.Time, session string) (*AlertRulesTestResult, error) {
+	result := &AlertRulesTestResult{Since: since, Session: session, Firings: []alerts.Firing{}}
+	engine := alerts.NewEngine(rules)
+	counts := make(map[string]*AlertRuleTestResult, len(rules))
+	for _, r := range rules {
+		result.Rules = append(result.Rules, AlertRuleTestResult{Name: r.Name})
+	}
+	for i := range result.Rules {
+		counts[result.Rules[i].Name] = &result.Rules[i]
+	}
+
+	err := eventLog.Scan(0, func(ev events.RecordedEvent) bool {
+		if ev.Timestamp.Before(since) || (session != "" && ev.Session != session) {
+			return true
+		}
+		if ev.Type == "metrics_sample" {
+			result.Samples++
+		} else {
+			result.Events++
+		}
+		result.Until = ev.Timestamp
+		feedAlertRules(engine, ev)
+		for _, f := range engine.Evaluate(ev.Timestamp) {
+			result.Firings = append(result.Firings, f)
+			if f.Resolved {
+				counts[f.RuleName].Resolved++
+			} else {
+				counts[f.RuleName].Fired++
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.StillFiring = engine.Firing()
+	return result, nil
+}
+
+func printAlertRulesTest(r *AlertRulesTestResult) {
+	if r.Events+r.Samples == 0 {
+		fmt.Printf("No events recorded since %s.\n", r.Since.Local().Format("2006-01-02 15:04"))
+		return
+	}
+	fmt.Printf("Replayed %d events and %d metric samples from %s to %s\n\n", r.Events, r.Samples,
+		r.Since.Local().Format("2006-01-02 15:04"), r.Until.Local().Format("2006-01-02 15:04"))
+	if r.Samples == 0 {
+		fmt.Println("No metric samples recorded: only event counts were evaluated. Samples are")
+		fmt.Println("recorded by the session monitor while durable events are enabled.")
+		fmt.Println()
+	}
+
+	if len(r.Firings) == 0 {
+		fmt.Println("No rules fired.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "TIME\tRULE\tSEVERITY\tSTATE\tKEY\tMESSAGE")
+		for _, f := range r.Firings {
+			at, state := f.Alert.CreatedAt, "fired"
+			if f.Resolved {
+				at, state = *f.Alert.ResolvedAt, "resolved"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", at.Local().Format("01-02 15:04:05"), f.RuleName, f.Alert.Severity, state, f.Key, f.Alert.Message)
+		}
+		w.Flush()
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "RULE\tFIRED\tRESOLVED")
+	for _, rule := range r.Rules {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", rule.Name, rule.Fired, rule.Resolved)
+	}
+	w.Flush()
+	if len(r.StillFiring) > 0 {
+		fmt.Printf("\n%d alert(s) still firing at the end of the history.\n", len(r.StillFiring))
+	}
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/events"
+)
+
+func TestReplayAlertRules(t *testing.T) {
+	dir := t.TempDir()
+	rulesFile := filepath.Join(dir, "rules.toml")
+	if err := os.WriteFile(rulesFile, []byte(`
+[[rules]]
+name = "context-high"
+expr = "context_pct > 85"
+for = "1m"
+
+[[alerts.rules]]
+name = "crashes"
+events = [{ type = "agent.crashed" }]
+severity = "error"
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := loadAlertRules(rulesFile, nil)
+	if err != nil || len(rules) != 2 {
+		t.Fatalf("loadAlertRules() = %v, %v", rules, err)
+	}
+	if _, err := loadAlertRules(rulesFile, []string{"missing"}); err == nil {
+		t.Error("loadAlertRules() accepted an unknown rule name")
+	}
+
+	eventLog, err := events.OpenEventLog(filepath.Join(dir, "events"), events.EventLogOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer eventLog.Close()
+
+	t0 := time.Now().Add(-time.Hour).UTC()
+	record := func(ev events.BusEvent) {
+		t.Helper()
+		if _, err := eventLog.Append(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sample := func(at time.Duration, session string, pct float64) events.MetricsSampleEvent {
+		ev := events.NewMetricsSampleEvent(session, []events.AgentMetrics{{Pane: "%1", Agent: "cc", ContextPct: pct}}, nil)
+		ev.Timestamp = t0.Add(at)
+		return ev
+	}
+	record(sample(0, "proj", 90))
+	record(sample(0, "other", 99))
+	record(sample(30*time.Second, "proj", 92))
+	crash := events.NewWebhookEvent(events.WebhookAgentCrashed, "proj", "%1", "cc", "exited", nil)
+	crash.Timestamp = t0.Add(45 * time.Second)
+	record(crash)
+	record(sample(time.Minute, "proj", 95))
+	record(sample(2*time.Minute, "proj", 50))
+
+	result, err := replayAlertRules(eventLog, rules, t0.Add(-time.Second), "proj")
+	if err != nil {
+		t.Fatalf("replayAlertRules() error = %v", err)
+	}
+	if result.Samples != 4 || result.Events != 1 {
+		t.Errorf("replayed %d samples and %d events, want 4 and 1", result.Samples, result.Events)
+	}
+	var states []string
+	for _, f := range result.Firings {
+		state := "fired"
+		if f.Resolved {
+			state = "resolved"
+		}
+		states = append(states, f.RuleName+":"+state+":"+f.Alert.Pane)
+	}
+	want := []string{"crashes:fired:%1", "context-high:fired:%1", "context-high:resolved:%1"}
+	if len(states) != len(want) {
+		t.Fatalf("firings = %v, want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Errorf("firings = %v, want %v", states, want)
+			break
+		}
+	}
+	if result.Rules[0].Fired != 1 || result.Rules[0].Resolved != 1 || len(result.StillFiring) != 1 {
+		t.Errorf("rules = %+v, still firing = %v", result.Rules, result.StillFiring)
+	}
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Dicklesworthstone/ntm/internal/budget"
+)
+
+// checkBudget enforces the configured spend budgets before action runs
+// against session. Only a hard limit reached without an approved override
+// blocks; a failure to read spend is reported as a warning.
+func checkBudget(session, action string) error {
+	return checkBudgetContext(context.Background(), session, action)
+}
+
+// checkBudgetContext is checkBudget bounded by ctx.
+func checkBudgetContext(ctx context.Context, session, action string) error {
+	_, err := budget.Check(ctx, cfg, session, action)
+	var exceeded *budget.ExceededError
+	if errors.As(err, &exceeded) {
+		return err
+	}
+	if err != nil && !IsJSONOutput() {
+		fmt.Fprintf(os.Stderr, "Warning: budget check failed: %v\n", err)
+	}
+	return nil
+}
+
+// pipelineBudgetCheck adapts checkBudget for pipeline executors.
+func pipelineBudgetCheck(ctx context.Context, session string) error {
+	return checkBudgetContext(ctx, session, "pipeline step")
+}
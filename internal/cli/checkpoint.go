@@ -33,7 +33,8 @@ Examples:
   ntm checkpoint list                     # List all checkpoints
   ntm checkpoint list myproject           # List checkpoints for session
   ntm checkpoint show myproject <id>      # Show checkpoint details
-  ntm checkpoint delete myproject <id>    # Delete a checkpoint`,
+  ntm checkpoint delete myproject <id>    # Delete a checkpoint
+  ntm checkpoint gc                       # Reclaim unreferenced scrollback chunks`,
 	}
 
 	cmd.AddCommand(newCheckpointSaveCmd())
@@ -43,6 +44,7 @@ Examples:
 	cmd.AddCommand(newCheckpointVerifyCmd())
 	cmd.AddCommand(newCheckpointExportCmd())
 	cmd.AddCommand(newCheckpointImportCmd())
+	cmd.AddCommand(newCheckpointGCCmd())
 	// TODO: newCheckpointRestoreCmd() not yet implemented
 
 	return cmd
@@ -453,6 +455,7 @@ func newCheckpointVerifyCmd() *cobra.Command {
 Performs the following checks:
 - Schema validation (version, required fields)
 - File existence (metadata.json, session.json, scrollback files)
+- Scrollback chunk hashes (every chunk is read and checked)
 - Consistency checks (pane count, valid indices)
 
 Examples:
@@ -516,6 +519,13 @@ func verifySingleCheckpoint(storage *checkpoint.Storage, session, id string) err
 		fmt.Printf("  %s\u2717%s Missing files\n", colorize(t.Error), "\033[0m")
 	}
 
+	// Chunk checksums
+	if result.ChecksumsValid {
+		fmt.Printf("  %s\u2713%s Scrollback chunks intact (%s verified)\n", colorize(t.Success), "\033[0m", result.Details["chunks_verified"])
+	} else {
+		fmt.Printf("  %s\u2717%s Corrupt scrollback chunks\n", colorize(t.Error), "\033[0m")
+	}
+
 	// Consistency
 	if result.ConsistencyValid {
 		fmt.Printf("  %s\u2713%s Consistency checks passed\n", colorize(t.Success), "\033[0m")
@@ -611,6 +621,59 @@ func verifyAllCheckpoints(storage *checkpoint.Storage, session string) error {
 	return nil
 }
 
+func newCheckpointGCCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Reclaim unreferenced scrollback chunks",
+		Long: `Remove scrollback chunks that no checkpoint references.
+
+Scrollback is stored once per distinct chunk and shared across panes and
+checkpoints. Deleting a checkpoint releases its chunks; gc recounts every
+reference from the checkpoint manifests, removes chunks left behind by
+interrupted saves, and repairs the stored reference counts.
+
+Examples:
+  ntm checkpoint gc            # Reclaim space
+  ntm checkpoint gc --dry-run  # Show what would be removed`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			storage := checkpoint.NewStorage()
+			result, err := storage.GC(checkpoint.GCOptions{DryRun: dryRun})
+			if err != nil {
+				return fmt.Errorf("collecting chunks: %w", err)
+			}
+
+			if jsonOutput {
+				return json.NewEncoder(os.Stdout).Encode(result)
+			}
+
+			t := theme.Current()
+			verb := "Removed"
+			if dryRun {
+				verb = "Would remove"
+			}
+			fmt.Printf("%s\u2713%s %s %d of %d chunk(s), %s\n",
+				colorize(t.Success), "\033[0m", verb, result.Removed, result.Chunks, formatBytes(result.BytesFreed))
+			fmt.Printf("  Referenced: %d chunk(s) from %d pane manifest(s), %s\n",
+				result.Referenced, result.Manifests, formatBytes(result.BytesKept))
+			if result.RefsRepaired > 0 {
+				fmt.Printf("  Reference counts repaired: %d\n", result.RefsRepaired)
+			}
+			if len(result.Missing) > 0 {
+				fmt.Printf("%s! %d referenced chunk(s) are missing; run 'ntm checkpoint verify <session> --all'%s\n",
+					colorize(t.Warning), len(result.Missing), "\033[0m")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report without removing anything")
+
+	return cmd
+}
+
 func newCheckpointExportCmd() *cobra.Command {
 	var (
 		output        string
@@ -26,7 +26,7 @@ func TestNewCheckpointCmd(t *testing.T) {
 		names[sub.Use] = true
 	}
 
-	expected := []string{"save <session>", "list [session]", "show <session> <id>", "delete <session> <id>"} // restore not yet implemented
+	expected := []string{"save <session>", "list [session]", "show <session> <id>", "delete <session> <id>", "gc"} // restore not yet implemented
 	for _, exp := range expected {
 		if !names[exp] {
 			t.Errorf("missing subcommand %q", exp)
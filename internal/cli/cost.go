@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	ctxmon "github.com/Dicklesworthstone/ntm/internal/context"
+	"github.com/Dicklesworthstone/ntm/internal/cost"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+func newCostCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cost [session]",
+		Short: "Show token spend per agent, by pricing category",
+		Long: `Show what each agent in a session has spent, priced with the model
+pricing catalog and broken down into input, output, cache-read, cache-write
+and reasoning tokens.
+
+Token counts come from the agents' own transcripts (Claude Code, Codex CLI
+and Gemini CLI), so they include cache and reasoning usage exactly. Panes
+whose agent has not written a transcript are listed without a cost.
+
+Prices come from the built-in catalog, overridden by
+~/.config/ntm/pricing.toml and then <project>/.ntm/pricing.toml. Run
+'ntm cost pricing' to see the merged catalog.
+
+Examples:
+  ntm cost                 # Current session
+  ntm cost myproject
+  ntm cost myproject --json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var session string
+			if len(args) > 0 {
+				session = args[0]
+			}
+
+			res, err := ResolveSession(session, cmd.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			if res.Session == "" {
+				return nil
+			}
+			res.ExplainIfInferred(os.Stderr)
+
+			return runCost(res.Session)
+		},
+	}
+
+	cmd.AddCommand(newCostPricingCmd())
+	return cmd
+}
+
+// CostReport is the per-agent spend of a session.
+type CostReport struct {
+	Session   string             `json:"session"`
+	Catalog   CostCatalogInfo    `json:"catalog"`
+	Agents    []CostReportAgent  `json:"agents"`
+	Breakdown cost.CostBreakdown `json:"breakdown"`
+	TotalUSD  float64            `json:"total_usd"` // API-equivalent
+	BilledUSD float64            `json:"billed_usd"`
+}
+
+// CostReportAgent is one agent pane's usage and cost.
+type CostReportAgent struct {
+	Pane      string              `json:"pane"`
+	Type      string              `json:"type"`
+	Model     string              `json:"model"`
+	Plan      string              `json:"plan,omitempty"`
+	Usage     *cost.AgentCost     `json:"usage,omitempty"` // Nil without a transcript
+	Breakdown *cost.CostBreakdown `json:"breakdown,omitempty"`
+}
+
+// CostCatalogInfo identifies the pricing catalog a report was priced with.
+type CostCatalogInfo struct {
+	Version  int      `json:"version"`
+	Updated  string   `json:"updated,omitempty"`
+	Sources  []string `json:"sources"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+func costCatalogInfo(c *cost.Catalog) CostCatalogInfo {
+	return CostCatalogInfo{Version: c.Version, Updated: c.Updated, Sources: c.Sources, Warnings: c.Warnings}
+}
+
+// loadCostCatalog loads the pricing catalog for projectDir and installs it
+// as the default. Override files that fail to load are reported in the
+// catalog's warnings rather than as an error.
+func loadCostCatalog(projectDir string) *cost.Catalog {
+	loader := cost.NewCatalogLoader()
+	if projectDir != "" {
+		loader.ProjectDir = projectDir
+	}
+	catalog, _ := loader.Load()
+	cost.SetDefaultCatalog(catalog)
+	return catalog
+}
+
+func runCost(session string) error {
+	if err := tmux.EnsureInstalled(); err != nil {
+		return err
+	}
+	panes, err := tmux.GetPanes(session)
+	if err != nil {
+		return err
+	}
+
+	var projectDir string
+	if cfg != nil {
+		projectDir = cfg.GetProjectDir(session)
+	}
+	catalog := loadCostCatalog(projectDir)
+	transcripts := ctxmon.NewTranscriptEstimator(ctxmon.DefaultTranscriptLocator())
+
+	report := &CostReport{Session: session, Catalog: costCatalogInfo(catalog), Agents: []CostReportAgent{}}
+	for _, p := range panes {
+		switch p.Type {
+		case tmux.AgentClaude, tmux.AgentCodex, tmux.AgentGemini:
+		default:
+			continue
+		}
+		var model string
+		if cfg != nil {
+			model = cfg.Models.GetModelName(string(p.Type), p.Variant)
+		}
+
+		row := CostReportAgent{Pane: p.Title, Type: string(p.Type), Model: model}
+		if projectDir != "" {
+			if u := transcripts.PaneUsage(p.ID, string(p.Type), projectDir); u != nil {
+				row.Usage = u.AgentCost(model)
+				row.Model = row.Usage.Model
+				b := row.Usage.Breakdown()
+				row.Breakdown = &b
+				report.Breakdown.Add(b)
+			}
+		}
+		if row.Model != "" {
+			row.Plan = catalog.Lookup(row.Model).Plan
+		}
+		report.Agents = append(report.Agents, row)
+	}
+	report.TotalUSD = report.Breakdown.Total()
+	report.BilledUSD = report.Breakdown.Billed()
+
+	if IsJSONOutput() {
+		return output.PrintJSON(report)
+	}
+	return report.Text(os.Stdout)
+}
+
+// Text renders the report as a table.
+func (r *CostReport) Text(w io.Writer) error {
+	for _, warning := range r.Catalog.Warnings {
+		fmt.Fprintf(w, "warning: pricing override ignored: %s\n", warning)
+	}
+	if len(r.Agents) == 0 {
+		fmt.Fprintf(w, "No agent panes in session '%s'\n", r.Session)
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "PANE\tMODEL\tINPUT\tOUTPUT\tCACHE RD\tCACHE WR\tREASONING\tTOTAL\t")
+	for _, a := range r.Agents {
+		if a.Breakdown == nil {
+			fmt.Fprintf(tw, "%s\t%s\t-\t-\t-\t-\t-\tno transcript\t\n", a.Pane, a.Model)
+			continue
+		}
+		b := a.Breakdown
+		total := formatCostUSD(b.Billed())
+		if b.Covered > 0 {
+			total = "plan"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", a.Pane, a.Model,
+			formatCostCell(a.Usage.InputTokens, b.Input),
+			formatCostCell(a.Usage.OutputTokens-a.Usage.ReasoningTokens, b.Output),
+			formatCostCell(a.Usage.CacheReadTokens, b.CacheRead),
+			formatCostCell(a.Usage.CacheWriteTokens, b.CacheWrite),
+			formatCostCell(a.Usage.ReasoningTokens, b.Reasoning),
+			total)
+	}
+	b := r.Breakdown
+	fmt.Fprintf(tw, "TOTAL\t\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+		formatCostUSD(b.Input), formatCostUSD(b.Output), formatCostUSD(b.CacheRead),
+		formatCostUSD(b.CacheWrite), formatCostUSD(b.Reasoning), formatCostUSD(r.BilledUSD))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if b.LongContext > 0 {
+		fmt.Fprintf(w, "\nIncludes %s billed at long-context (>200K) prices.\n", formatCostUSD(b.LongContext))
+	}
+	if b.Covered > 0 {
+		fmt.Fprintf(w, "\nPlan-covered: %s at API prices (not included in total).\n", formatCostUSD(b.Covered))
+	}
+	return nil
+}
+
+func formatCostCell(tokens int, usd float64) string {
+	if tokens == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s %s", formatTokenCount(tokens), formatCostUSD(usd))
+}
+
+func formatCostUSD(usd float64) string {
+	if usd > 0 && usd < 0.01 {
+		return fmt.Sprintf("$%.4f", usd)
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+func newCostPricingCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pricing [model...]",
+		Short: "Show the model pricing catalog",
+		Long: `Show the merged model pricing catalog in USD per million tokens.
+
+The built-in catalog is overridden field by field by
+~/.config/ntm/pricing.toml and then .ntm/pricing.toml in the current
+project. With model arguments, shows the prices each model resolves to.
+
+Examples:
+  ntm cost pricing
+  ntm cost pricing claude-sonnet-4-5-20250929 gpt-5-codex`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			catalog := loadCostCatalog("")
+			return runCostPricing(os.Stdout, catalog, args)
+		},
+	}
+}
+
+// CostPricingModel is one model's prices, per million tokens.
+type CostPricingModel struct {
+	Model         string            `json:"model"`
+	Input         float64           `json:"input"`
+	Output        float64           `json:"output"`
+	CacheRead     float64           `json:"cache_read"`
+	CacheWrite    float64           `json:"cache_write"`
+	Reasoning     float64           `json:"reasoning"`
+	BatchDiscount float64           `json:"batch_discount,omitempty"`
+	LongContext   *CostPricingModel `json:"long_context,omitempty"`
+	Plan          string            `json:"plan,omitempty"`
+}
+
+func costPricingModel(model string, p cost.ModelPricing) *CostPricingModel {
+	m := &CostPricingModel{
+		Model:         model,
+		Input:         p.InputPer1K * 1000,
+		Output:        p.OutputPer1K * 1000,
+		CacheRead:     p.CacheReadPrice() * 1000,
+		CacheWrite:    p.CacheWritePrice() * 1000,
+		Reasoning:     p.ReasoningPrice() * 1000,
+		BatchDiscount: p.BatchDiscount,
+		Plan:          p.Plan,
+	}
+	if p.LongContext != nil {
+		m.LongContext = costPricingModel("", *p.LongContext)
+	}
+	return m
+}
+
+func runCostPricing(w io.Writer, catalog *cost.Catalog, models []string) error {
+	if len(models) == 0 {
+		models = catalog.Models()
+	}
+	rows := make([]*CostPricingModel, 0, len(models))
+	for _, model := range models {
+		rows = append(rows, costPricingModel(model, catalog.Lookup(model)))
+	}
+
+	if IsJSONOutput() {
+		return output.PrintJSON(map[string]interface{}{
+			"catalog": costCatalogInfo(catalog),
+			"models":  rows,
+			"plans":   catalog.Plans(),
+		})
+	}
+
+	fmt.Fprintf(w, "Pricing catalog v%d", catalog.Version)
+	if catalog.Updated != "" {
+		fmt.Fprintf(w, " (updated %s)", catalog.Updated)
+	}
+	fmt.Fprintf(w, " from %s\n", strings.Join(catalog.Sources, ", "))
+	for _, warning := range catalog.Warnings {
+		fmt.Fprintf(w, "warning: pricing override ignored: %s\n", warning)
+	}
+	fmt.Fprintln(w, "USD per million tokens")
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tINPUT\tOUTPUT\tCACHE RD\tCACHE WR\tREASONING\tBATCH\t>200K IN/OUT\tPLAN")
+	for _, m := range rows {
+		batch, long := "-", "-"
+		if m.BatchDiscount > 0 {
+			batch = fmt.Sprintf("-%.0f%%", m.BatchDiscount*100)
+		}
+		if m.LongContext != nil {
+			long = fmt.Sprintf("%.4g/%.4g", m.LongContext.Input, m.LongContext.Output)
+		}
+		plan := m.Plan
+		if plan == "" {
+			plan = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%.4g\t%.4g\t%.4g\t%.4g\t%.4g\t%s\t%s\t%s\n",
+			m.Model, m.Input, m.Output, m.CacheRead, m.CacheWrite, m.Reasoning, batch, long, plan)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Plans (set active = true in pricing.toml to enable):")
+	for _, p := range catalog.Plans() {
+		state := "inactive"
+		if p.Active {
+			state = "active"
+		}
+		fmt.Fprintf(w, "  %-20s %-8s $%.2f/mo  %s\n", p.ID, state, p.MonthlyUSD, strings.Join(p.Models, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/cost"
+)
+
+func TestCostReportText(t *testing.T) {
+	usage := &cost.AgentCost{InputTokens: 1000, OutputTokens: 2000, ReasoningTokens: 500, CacheReadTokens: 10000, Model: "claude-sonnet-4"}
+	b := usage.Breakdown()
+	r := &CostReport{
+		Session: "proj",
+		Agents: []CostReportAgent{
+			{Pane: "proj__cc_1", Model: "claude-sonnet-4", Usage: usage, Breakdown: &b},
+			{Pane: "proj__cod_1", Model: "gpt-5-codex"},
+		},
+		Breakdown: b,
+		BilledUSD: b.Billed(),
+	}
+
+	var buf bytes.Buffer
+	if err := r.Text(&buf); err != nil {
+		t.Fatalf("Text() error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"CACHE RD", "1.0K $0.0030", "1.5K $0.02", "10.0K $0.0030", "no transcript", "TOTAL"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunCostPricing(t *testing.T) {
+	var buf bytes.Buffer
+	if err := runCostPricing(&buf, cost.BuiltinCatalog(), []string{"claude-sonnet-4-5-20250929"}); err != nil {
+		t.Fatalf("runCostPricing() error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Pricing catalog v1", "claude-sonnet-4-5-20250929", "-50%", "6/22.5", "claude-max"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
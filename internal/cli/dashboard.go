@@ -39,6 +39,9 @@ If no session is specified:
 - Inside tmux: uses the current session
 - Outside tmux: shows a session selector
 
+A session on a remote host from the [hosts] config inventory is named
+session@host, and the session selector lists every host's sessions.
+
 Flags:
   --no-tui    Plain text output (no interactive UI)
   --json      JSON output (implies --no-tui)
@@ -52,10 +55,11 @@ Environment:
 
 Examples:
   ntm dashboard myproject
-  ntm dash                  # Auto-detect session
-  ntm dashboard --no-tui    # Plain text output for scripting
-  ntm dashboard --json      # JSON output for automation
-  CI=1 ntm dashboard        # Auto-detects plain mode in CI`,
+  ntm dashboard api@build-box  # Session on a remote host
+  ntm dash                     # Auto-detect session
+  ntm dashboard --no-tui       # Plain text output for scripting
+  ntm dashboard --json         # JSON output for automation
+  CI=1 ntm dashboard           # Auto-detects plain mode in CI`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var session string
@@ -78,6 +82,20 @@ Examples:
 				debug = true
 			}
 
+			// Sessions on other hosts are named session@host; with no session
+			// given, the picker offers every host's sessions.
+			if session == "" && !noTUI && !tmux.InTmux() && IsInteractive(cmd.OutOrStdout()) {
+				picked, err := pickSessionOnHosts()
+				if err != nil {
+					return err
+				}
+				session = picked
+			}
+			session, err := useHostSession(session)
+			if err != nil {
+				return err
+			}
+
 			if jsonOutput {
 				return runDashboardJSON(cmd.OutOrStdout(), cmd.ErrOrStderr(), session)
 			}
@@ -246,6 +264,20 @@ func runDashboardPlain(w io.Writer, errW io.Writer, session string) error {
 	return nil
 }
 
+// attachControlMode routes the default tmux client's commands, and with them
+// the dashboard's pane captures, over a control-mode connection attached to
+// session. If the connection cannot be made it warns and the client keeps
+// forking a tmux process per command. The returned func closes it.
+func attachControlMode(errW io.Writer, session string) func() {
+	ctx, cancel := context.WithTimeout(context.Background(), tmux.DefaultControlStartTimeout)
+	defer cancel()
+	if err := tmux.DefaultClient.AttachControlMode(ctx, session); err != nil {
+		fmt.Fprintf(errW, "Warning: tmux control mode unavailable, polling instead: %v\n", err)
+		return func() {}
+	}
+	return func() { _ = tmux.DefaultClient.Close() }
+}
+
 func runDashboard(w io.Writer, errW io.Writer, session string, debug bool) error {
 	if err := tmux.EnsureInstalled(); err != nil {
 		return err
@@ -285,6 +317,10 @@ func runDashboard(w io.Writer, errW io.Writer, session string, debug bool) error
 		fmt.Fprintf(errW, "Check your projects_base setting in config: ntm config show\n\n")
 	}
 
+	if cfg != nil && cfg.Tmux.ControlMode {
+		defer attachControlMode(errW, session)()
+	}
+
 	// Start FileReservationWatcher if enabled and Agent Mail is available
 	var reservationWatcher *watcher.FileReservationWatcher
 	if cfg != nil && cfg.FileReservation.Enabled && cfg.AgentMail.Enabled {
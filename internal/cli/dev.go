@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/fakeagent"
+)
+
+func newDevCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "dev",
+		Short:  "Tools for developing and testing ntm",
+		Hidden: true,
+	}
+	cmd.AddCommand(newDevFakeAgentCmd())
+	return cmd
+}
+
+func newDevFakeAgentCmd() *cobra.Command {
+	var (
+		agentType  string
+		scenario   string
+		model      string
+		statePath  string
+		transcript string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "fake-agent",
+		Short: "Run a scripted stand-in for an agent's TUI",
+		Long: `Simulate Claude, Codex or Gemini in the terminal without network access.
+
+The fake agent prints the real CLI's prompt glyph, working spinner, usage
+and status screens, rate-limit banner and compaction messages, and answers
+the slash commands ntm sends (usage, status, compact, clear, model, exit).
+Ctrl+C quits it the way it quits the real agent. What it replies and when
+it hits limits is scripted by a scenario file:
+
+  agent = "cc"
+  work = "1s"                  # default time spent on a prompt
+
+  [usage]
+  session = 42
+  weekly = 71
+  resets = "in 3 hours"
+
+  [context]
+  tokens = 20000               # context used at startup
+  per_turn = 5000              # added by each prompt
+  auto_compact = 90            # percent used that compacts (0 = never)
+
+  [[steps]]
+  match = "(?i)fix"            # wait for a matching prompt
+  output = ["Reading main.go"]
+  reply = "Fixed the bug."
+  tokens = 120000
+
+  [[steps]]
+  event = "rate_limit"         # rate_limit, compact, clear, crash, exit
+  usage = { session = 100 }
+
+Steps with on = "start" run as soon as the agent is ready. Use it in place
+of a real agent from a spawn profile or agent config, e.g.
+  ntm profile save fakes --cc 2 --command cc="ntm dev fake-agent --agent cc --scenario limits.toml"
+
+--state keeps the scenario position across restarts, so a respawned agent
+continues with the next step; --transcript writes token usage in the
+agent's native transcript format for context monitoring.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sc := &fakeagent.Scenario{}
+			if scenario != "" {
+				var err error
+				if sc, err = fakeagent.LoadScenario(config.ExpandHome(scenario)); err != nil {
+					return err
+				}
+			} else if err := sc.Validate(); err != nil {
+				return err
+			}
+			if agentType == "" {
+				agentType = sc.Agent
+			}
+			profile, err := fakeagent.ProfileFor(agentType)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGHUP)
+			defer stop()
+
+			// Ctrl+C is input to the agent, not a reason to stop the process.
+			sigint := make(chan os.Signal, 1)
+			signal.Notify(sigint, os.Interrupt)
+			defer signal.Stop(sigint)
+			interrupts := make(chan struct{})
+			go func() {
+				for range sigint {
+					select {
+					case interrupts <- struct{}{}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+
+			sim := &fakeagent.Simulator{
+				Profile:        profile,
+				Scenario:       sc,
+				In:             os.Stdin,
+				Out:            os.Stdout,
+				Interrupts:     interrupts,
+				Model:          model,
+				StatePath:      config.ExpandHome(statePath),
+				TranscriptPath: config.ExpandHome(transcript),
+			}
+			code, err := sim.Run(ctx)
+			if err != nil {
+				return err
+			}
+			if code != 0 {
+				os.Exit(code)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&agentType, "agent", "", fmt.Sprintf("Agent to simulate: %s (default: the scenario's agent, else cc)", strings.Join(fakeagent.SupportedTypes(), ", ")))
+	cmd.Flags().StringVar(&scenario, "scenario", "", "Scenario file scripting the session")
+	cmd.Flags().StringVar(&model, "model", "", "Model the agent reports (default: the scenario's, else the agent's usual model)")
+	cmd.Flags().StringVar(&statePath, "state", "", "File that keeps the scenario position across restarts")
+	cmd.Flags().StringVar(&transcript, "transcript", "", "Write token usage to this transcript file")
+	return cmd
+}
@@ -126,6 +126,7 @@ Primary usage:
 	cmd.AddCommand(newEnsembleSuggestCmd())
 	cmd.AddCommand(newEnsembleEstimateCmd())
 	cmd.AddCommand(newEnsembleSynthesizeCmd())
+	cmd.AddCommand(newEnsembleDeliberateCmd())
 	cmd.AddCommand(newEnsembleCacheCmd())
 	cmd.AddCommand(newEnsembleExportFindingsCmd())
 	cmd.AddCommand(newEnsembleProvenanceCmd())
@@ -863,6 +864,18 @@ func runEnsembleSynthesize(w io.Writer, session string, opts synthesizeOptions)
 
 	cacheEnabled := opts.UseCache && !opts.NoCache
 	collectedModes := make(map[string]bool)
+
+	// A deliberated session is synthesized from its final round.
+	if record, err := ensemble.LoadDeliberationRecord(state); err == nil {
+		for _, o := range record.FinalOutputs() {
+			if err := collector.Add(o); err != nil {
+				logger.Warn("deliberation output add failed", "mode_id", o.ModeID, "error", err)
+				continue
+			}
+			collectedModes[o.ModeID] = true
+		}
+		logger.Info("using final deliberation round", "session", session, "rounds", len(record.Rounds))
+	}
 	cacheFingerprints := make(map[string]ensemble.ModeOutputFingerprint)
 	var outputCache *ensemble.ModeOutputCache
 	var cacheContextHash string
@@ -900,6 +913,9 @@ func runEnsembleSynthesize(w io.Writer, session string, opts synthesizeOptions)
 
 					if cacheEnabled {
 						for _, assignment := range state.Assignments {
+							if collectedModes[assignment.ModeID] {
+								continue
+							}
 							mode := catalog.GetMode(assignment.ModeID)
 							if mode == nil {
 								logger.Warn("cache skip: mode not found", "mode_id", assignment.ModeID)
@@ -2173,23 +2189,19 @@ func runEnsembleProvenance(w io.Writer, session, findingID string, opts provenan
 	}
 	tracker := ensemble.NewProvenanceTracker(state.Question, modeIDs)
 
-	// Load outputs and record provenance
-	capture := ensemble.NewOutputCapture(tmux.DefaultClient)
-	captured, err := capture.CaptureAll(state)
-	if err != nil {
-		slog.Default().Warn("failed to capture outputs for provenance", "error", err)
-	}
-
-	outputs := make([]ensemble.ModeOutput, 0, len(captured))
-	for _, cap := range captured {
-		if cap.Parsed == nil {
-			continue
-		}
-		parsed := *cap.Parsed
-		if parsed.ModeID == "" {
-			parsed.ModeID = cap.ModeID
+	// Load outputs and record provenance. A deliberation contributes each
+	// finding's round-by-round history before its final round is merged.
+	var outputs []ensemble.ModeOutput
+	if record, err := ensemble.LoadDeliberationRecord(state); err == nil {
+		tracker = record.Provenance()
+		outputs = record.FinalOutputs()
+	} else {
+		capture := ensemble.NewOutputCapture(tmux.DefaultClient)
+		captured, err := capture.CaptureAll(state)
+		if err != nil {
+			slog.Default().Warn("failed to capture outputs for provenance", "error", err)
 		}
-		outputs = append(outputs, parsed)
+		outputs = parsedCaptures(captured)
 	}
 
 	if len(outputs) > 0 {
@@ -21,8 +21,10 @@ import (
 
 // compareOptions holds CLI flags for ensemble compare.
 type compareOptions struct {
-	Format  string
-	Verbose bool
+	Format    string
+	Verbose   bool
+	FromRound int
+	ToRound   int
 }
 
 // compareOutput is the JSON/YAML output structure.
@@ -41,10 +43,15 @@ func newEnsembleCompareCmd() *cobra.Command {
 	}
 
 	cmd := &cobra.Command{
-		Use:   "compare <run1> <run2>",
-		Short: "Compare two ensemble runs",
+		Use:   "compare <run1> [run2]",
+		Short: "Compare two ensemble runs or two deliberation rounds",
 		Long: `Compare two ensemble runs side-by-side to see mode, finding, and synthesis differences.
 
+With a single session that has deliberated ('ntm ensemble deliberate'), compare
+its first and final rounds (or --from-round/--to-round) and show how each
+agent's position moved: thesis changes, findings kept, added and withdrawn,
+confidence, agreement with the other agents, and conflicts resolved.
+
 The comparison uses stable finding IDs (hashes) for deterministic matching,
 so findings from the same mode with the same text will be correctly aligned
 even if order or other attributes differ.
@@ -54,6 +61,7 @@ Output sections:
   - Finding Changes: new, missing, changed, and unchanged findings
   - Conclusion Changes: thesis and synthesis differences
   - Contribution Changes: mode contribution score deltas and rank changes
+  - Position Movement: per-agent movement between deliberation rounds
 
 Formats:
   --format=text (default) - Human-readable report
@@ -61,15 +69,22 @@ Formats:
   --format=yaml           - YAML format`,
 		Example: `  ntm ensemble compare session1 session2
   ntm ensemble compare run-20240101 run-20240102 --format=json
-  ntm ensemble compare mysession-v1 mysession-v2 --verbose`,
-		Args: cobra.ExactArgs(2),
+  ntm ensemble compare mysession-v1 mysession-v2 --verbose
+  ntm ensemble compare mysession                      # deliberation: first vs final round
+  ntm ensemble compare mysession --from-round=2 --to-round=3`,
+		Args: cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				return runEnsembleCompareRounds(cmd.OutOrStdout(), args[0], opts)
+			}
 			return runEnsembleCompare(cmd.OutOrStdout(), args[0], args[1], opts)
 		},
 	}
 
 	cmd.Flags().StringVarP(&opts.Format, "format", "f", "text", "Output format: text, json, yaml")
 	cmd.Flags().BoolVarP(&opts.Verbose, "verbose", "v", false, "Show detailed diff including unchanged items")
+	cmd.Flags().IntVar(&opts.FromRound, "from-round", 1, "Deliberation round to compare from (single-session form)")
+	cmd.Flags().IntVar(&opts.ToRound, "to-round", 0, "Deliberation round to compare to (default: final round)")
 	cmd.ValidArgsFunction = completeSessionArgs
 	return cmd
 }
@@ -131,6 +146,50 @@ func runEnsembleCompare(w io.Writer, runAID, runBID string, opts compareOptions)
 	return writeCompareResult(w, result, opts, format)
 }
 
+// runEnsembleCompareRounds compares two rounds of a session's deliberation.
+func runEnsembleCompareRounds(w io.Writer, session string, opts compareOptions) error {
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	if format == "" {
+		format = "text"
+	}
+	if jsonOutput {
+		format = "json"
+	}
+
+	state, err := ensemble.LoadSession(session)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = fmt.Errorf("no ensemble running in session '%s'", session)
+		}
+		return writeCompareError(w, session, session, err, format)
+	}
+	record, err := ensemble.LoadDeliberationRecord(state)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, ensemble.ErrStaleDeliberation) {
+			err = fmt.Errorf("session '%s' has no deliberation to compare; run 'ntm ensemble deliberate %s' or pass two runs", session, session)
+		}
+		return writeCompareError(w, session, session, err, format)
+	}
+	to := opts.ToRound
+	if to <= 0 {
+		to = len(record.Rounds)
+	}
+
+	result, err := ensemble.CompareRounds(record, opts.FromRound, to)
+	if err != nil {
+		return writeCompareError(w, session, session, err, format)
+	}
+
+	slog.Info("deliberation rounds compared",
+		"session", session,
+		"from_round", opts.FromRound,
+		"to_round", to,
+		"summary", result.Summary,
+	)
+
+	return writeCompareResult(w, result, opts, format)
+}
+
 // loadCompareInput loads an ensemble session and constructs a CompareInput.
 func loadCompareInput(runID string) (*ensemble.CompareInput, error) {
 	// Try to load as session
@@ -153,26 +212,21 @@ func loadCompareInput(runID string) (*ensemble.CompareInput, error) {
 	}
 	sort.Strings(modeIDs)
 
-	// Capture outputs
-	capture := ensemble.NewOutputCapture(tmux.DefaultClient)
-	captured, err := capture.CaptureAll(state)
-	if err != nil {
-		slog.Warn("failed to capture outputs for comparison",
-			"session", runID,
-			"error", err,
-		)
-	}
-
-	outputs := make([]ensemble.ModeOutput, 0, len(captured))
-	for _, cap := range captured {
-		if cap.Parsed == nil {
-			continue
-		}
-		parsed := *cap.Parsed
-		if parsed.ModeID == "" {
-			parsed.ModeID = cap.ModeID
+	// Use the final deliberation round when the session deliberated;
+	// otherwise capture outputs from the panes.
+	var outputs []ensemble.ModeOutput
+	if record, err := ensemble.LoadDeliberationRecord(state); err == nil {
+		outputs = record.FinalOutputs()
+	} else {
+		capture := ensemble.NewOutputCapture(tmux.DefaultClient)
+		captured, err := capture.CaptureAll(state)
+		if err != nil {
+			slog.Warn("failed to capture outputs for comparison",
+				"session", runID,
+				"error", err,
+			)
 		}
-		outputs = append(outputs, parsed)
+		outputs = parsedCaptures(captured)
 	}
 
 	// Set up provenance tracker
@@ -26,8 +26,8 @@ func TestNewEnsembleCompareCmd(t *testing.T) {
 	if cmd == nil {
 		t.Fatal("newEnsembleCompareCmd returned nil")
 	}
-	if cmd.Use != "compare <run1> <run2>" {
-		t.Errorf("expected Use='compare <run1> <run2>', got %q", cmd.Use)
+	if cmd.Use != "compare <run1> [run2]" {
+		t.Errorf("expected Use='compare <run1> [run2]', got %q", cmd.Use)
 	}
 
 	// Check flags exist
@@ -43,6 +43,15 @@ func TestNewEnsembleCompareCmd(t *testing.T) {
 		t.Error("expected --verbose flag")
 	}
 
+	for name, def := range map[string]string{"from-round": "1", "to-round": "0"} {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			t.Errorf("expected --%s flag", name)
+		} else if flag.DefValue != def {
+			t.Errorf("expected --%s default=%q, got %q", name, def, flag.DefValue)
+		}
+	}
+
 	t.Log("TEST: TestNewEnsembleCompareCmd - assertion: command created with correct flags")
 }
 
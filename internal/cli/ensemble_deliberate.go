@@ -0,0 +1,342 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Dicklesworthstone/ntm/internal/ensemble"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/swarm"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// deliberationPollInterval is how often panes are captured while waiting
+// for a round's revisions.
+const deliberationPollInterval = 10 * time.Second
+
+type deliberateOptions struct {
+	Format       string
+	MaxRounds    int
+	Similarity   float64
+	MaxTokens    int
+	RoundTimeout time.Duration
+	Restart      bool
+}
+
+// deliberationOutput is the JSON/YAML output structure.
+type deliberationOutput struct {
+	GeneratedAt time.Time                    `json:"generated_at" yaml:"generated_at"`
+	Session     string                       `json:"session" yaml:"session"`
+	Record      *ensemble.DeliberationRecord `json:"record" yaml:"record"`
+}
+
+func newEnsembleDeliberateCmd() *cobra.Command {
+	defaults := ensemble.DefaultDeliberationConfig()
+	opts := deliberateOptions{
+		Format:     "text",
+		MaxRounds:  defaults.MaxRounds,
+		Similarity: defaults.Convergence.SimilarityThreshold,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "deliberate [session]",
+		Short: "Run critique rounds where ensemble agents revise their analyses",
+		Long: `Run an iterative deliberation over a finished ensemble.
+
+The agents' current outputs form round 1. Each following round sends every
+agent the other agents' latest outputs, anonymized, together with the
+conflicts the disagreement auditor identified, and asks for a revision or
+rebuttal. Rounds continue until the agents converge (no conflicts remain or
+their outputs are similar enough), positions stop moving, --max-rounds is
+reached or the token budget is spent.
+
+Every round is recorded. 'ntm ensemble compare <session>' shows how positions
+moved between rounds, 'ntm ensemble provenance' shows each finding's history
+across rounds, and 'ntm ensemble synthesize' merges the final round.
+
+Formats:
+  --format=text (default) - Round-by-round progress and summary
+  --format=json           - Machine-readable JSON
+  --format=yaml           - YAML format`,
+		Example: `  ntm ensemble deliberate mysession
+  ntm ensemble deliberate mysession --max-rounds=4 --similarity=0.6
+  ntm ensemble deliberate mysession --max-tokens=80000 --round-timeout=10m --json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			session := ""
+			if len(args) > 0 {
+				session = args[0]
+			} else {
+				session = tmux.GetCurrentSession()
+			}
+			if session == "" {
+				return fmt.Errorf("session required (not in tmux)")
+			}
+			return runEnsembleDeliberate(cmd.OutOrStdout(), session, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", "text", "Output format: text, json, yaml")
+	cmd.Flags().IntVar(&opts.MaxRounds, "max-rounds", opts.MaxRounds, "Maximum rounds, including the initial outputs")
+	cmd.Flags().Float64Var(&opts.Similarity, "similarity", opts.Similarity, "Output similarity (0-1) at which agents count as converged")
+	cmd.Flags().IntVar(&opts.MaxTokens, "max-tokens", 0, "Total token budget across rounds (default: the ensemble's budget)")
+	cmd.Flags().DurationVar(&opts.RoundTimeout, "round-timeout", 0, "How long to wait for revisions each round (default: the ensemble's per-mode timeout)")
+	cmd.Flags().BoolVar(&opts.Restart, "restart", false, "Start a new deliberation from where the previous one ended")
+	cmd.ValidArgsFunction = completeSessionArgs
+	return cmd
+}
+
+func runEnsembleDeliberate(w io.Writer, session string, opts deliberateOptions) error {
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	if format == "" {
+		format = "text"
+	}
+	if jsonOutput {
+		format = "json"
+	}
+
+	if err := tmux.EnsureInstalled(); err != nil {
+		return err
+	}
+	if !tmux.SessionExists(session) {
+		return fmt.Errorf("session '%s' not found", session)
+	}
+	state, err := ensemble.LoadSession(session)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no ensemble running in session '%s'", session)
+		}
+		return fmt.Errorf("load session: %w", err)
+	}
+
+	// A record left by an earlier ensemble in this tmux session is ignored
+	// and overwritten.
+	prev, err := ensemble.LoadDeliberationRecord(state)
+	if err == nil && !opts.Restart {
+		return fmt.Errorf("session '%s' already deliberated (%d rounds, stopped: %s); use --restart to start over",
+			session, len(prev.Rounds), prev.StopReason)
+	}
+
+	cfg := ensemble.DefaultDeliberationConfig()
+	cfg.MaxRounds = opts.MaxRounds
+	cfg.Convergence.SimilarityThreshold = opts.Similarity
+	_, budget := resolveEnsembleBudget(state)
+	if opts.MaxTokens > 0 {
+		budget.MaxTotalTokens = opts.MaxTokens
+	}
+	if cfg.MaxRounds > 0 {
+		budget.MaxTokensPerMode *= cfg.MaxRounds
+	}
+	cfg.Budget = budget
+	roundTimeout := opts.RoundTimeout
+	if roundTimeout <= 0 {
+		roundTimeout = budget.TimeoutPerMode
+	}
+
+	// A restart begins from the positions the previous deliberation ended
+	// with; otherwise round 1 is what the agents produced on their own.
+	capture := ensemble.NewOutputCapture(tmux.DefaultClient)
+	initial := prev.FinalOutputs()
+	if len(initial) == 0 {
+		captured, err := capture.CaptureAll(state)
+		if err != nil {
+			slog.Default().Warn("failed to capture some outputs for deliberation", "session", session, "error", err)
+		}
+		initial = parsedCaptures(captured)
+	}
+	if len(initial) < 2 {
+		return fmt.Errorf("deliberation needs at least 2 parsed agent outputs, found %d", len(initial))
+	}
+
+	modeIDs := make([]string, 0, len(state.Assignments))
+	for _, a := range state.Assignments {
+		modeIDs = append(modeIDs, a.ModeID)
+	}
+	d := ensemble.NewDeliberation(session, state.Question, modeIDs, cfg, slog.Default())
+	text := format == "text"
+
+	decision := d.AddRound(initial)
+	if text {
+		writeDeliberationRound(w, d.Record, decision)
+	}
+	if err := ensemble.SaveDeliberationRecord(d.Record); err != nil {
+		return err
+	}
+
+	injector := swarm.NewPromptInjector()
+	injector.TmuxClient = tmux.DefaultClient
+	for !decision.ShouldStop {
+		next := len(d.Record.Rounds) + 1
+		targets, err := ensemblePaneTargets(session)
+		if err != nil {
+			return err
+		}
+		var sent []string
+		for _, a := range state.Assignments {
+			prompt := d.Prompt(a.ModeID)
+			if _, ok := d.Record.Round(next - 1).Output(a.ModeID); !ok || prompt == "" {
+				continue
+			}
+			target := targets[a.PaneName]
+			if target == "" {
+				target = a.PaneName
+			}
+			if err := injector.InjectPrompt(target, a.AgentType, prompt); err != nil {
+				slog.Default().Warn("deliberation prompt injection failed", "mode_id", a.ModeID, "pane", a.PaneName, "error", err)
+				continue
+			}
+			sent = append(sent, a.ModeID)
+		}
+		if len(sent) == 0 {
+			return fmt.Errorf("round %d: could not send revision prompts to any agent", next)
+		}
+		if text {
+			fmt.Fprintf(w, "Round %d: waiting up to %s for %d agents...\n", next, roundTimeout, len(sent))
+		}
+
+		revised := awaitDeliberationRound(capture, state, ensemble.DeliberationMarker(next), sent, roundTimeout)
+		decision = d.AddRound(revised)
+		if text {
+			writeDeliberationRound(w, d.Record, decision)
+		}
+		if err := ensemble.SaveDeliberationRecord(d.Record); err != nil {
+			return err
+		}
+	}
+
+	payload := deliberationOutput{
+		GeneratedAt: output.Timestamp(),
+		Session:     session,
+		Record:      d.Record,
+	}
+	switch format {
+	case "json":
+		return output.WriteJSON(w, payload, true)
+	case "yaml", "yml":
+		return yaml.NewEncoder(w).Encode(payload)
+	default:
+		writeDeliberationSummary(w, d.Record)
+		return nil
+	}
+}
+
+// parsedCaptures returns the parsed outputs of captured panes.
+func parsedCaptures(captured []ensemble.CapturedOutput) []ensemble.ModeOutput {
+	outputs := make([]ensemble.ModeOutput, 0, len(captured))
+	for _, cap := range captured {
+		if cap.Parsed == nil {
+			continue
+		}
+		parsed := *cap.Parsed
+		if parsed.ModeID == "" {
+			parsed.ModeID = cap.ModeID
+		}
+		outputs = append(outputs, parsed)
+	}
+	return outputs
+}
+
+// ensemblePaneTargets maps pane titles and IDs to tmux targets.
+func ensemblePaneTargets(session string) (map[string]string, error) {
+	panes, err := tmux.GetPanes(session)
+	if err != nil {
+		return nil, fmt.Errorf("get panes: %w", err)
+	}
+	targets := make(map[string]string, len(panes)*2)
+	for _, pane := range panes {
+		target := pane.ID
+		if target == "" {
+			target = swarm.GetPaneTarget(session, pane.Index)
+		}
+		if pane.Title != "" {
+			targets[pane.Title] = target
+		}
+		if pane.ID != "" {
+			targets[pane.ID] = target
+		}
+	}
+	return targets, nil
+}
+
+// awaitDeliberationRound polls the panes until every mode in modeIDs has
+// printed a parsable revision after marker that stayed the same across two
+// captures, or until timeout. It returns the revisions collected.
+func awaitDeliberationRound(capture *ensemble.OutputCapture, state *ensemble.EnsembleSession, marker string, modeIDs []string, timeout time.Duration) []ensemble.ModeOutput {
+	deadline := time.Now().Add(timeout)
+	last := make(map[string]string, len(modeIDs))
+	stable := make(map[string]ensemble.ModeOutput, len(modeIDs))
+
+	for {
+		time.Sleep(deliberationPollInterval)
+		captured, err := capture.CaptureAfter(state, marker)
+		if err != nil {
+			slog.Default().Warn("deliberation capture failed", "error", err)
+		}
+		for _, cap := range captured {
+			if cap.Parsed == nil || !slices.Contains(modeIDs, cap.ModeID) {
+				continue
+			}
+			parsed := *cap.Parsed
+			parsed.ModeID = cap.ModeID
+			sig := parsed.Thesis + "\x00" + fmt.Sprint(parsed.TopFindings, parsed.Confidence)
+			if last[cap.ModeID] == sig {
+				stable[cap.ModeID] = parsed
+			} else {
+				delete(stable, cap.ModeID)
+			}
+			last[cap.ModeID] = sig
+		}
+		if len(stable) == len(modeIDs) || !time.Now().Before(deadline) {
+			break
+		}
+	}
+
+	outputs := make([]ensemble.ModeOutput, 0, len(stable))
+	for _, id := range modeIDs {
+		if o, ok := stable[id]; ok {
+			outputs = append(outputs, o)
+		}
+	}
+	return outputs
+}
+
+func writeDeliberationRound(w io.Writer, rec *ensemble.DeliberationRecord, decision ensemble.StopDecision) {
+	round := rec.Round(len(rec.Rounds))
+	fmt.Fprintf(w, "Round %d: %d outputs, %d conflicts, agreement %.2f, %d tokens",
+		round.Round, len(round.Outputs)-len(round.Missing), len(round.Conflicts), round.Similarity, round.Tokens)
+	if len(round.Missing) > 0 {
+		fmt.Fprintf(w, " (no revision from %s)", strings.Join(round.Missing, ", "))
+	}
+	fmt.Fprintln(w)
+	if decision.ShouldStop {
+		fmt.Fprintf(w, "Stopping: %s\n", decision.Reason)
+	}
+}
+
+func writeDeliberationSummary(w io.Writer, rec *ensemble.DeliberationRecord) {
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "ROUND\tOUTPUTS\tCONFLICTS\tAGREEMENT\tTOKENS")
+	for _, r := range rec.Rounds {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%.2f\t%d\n", r.Round, len(r.Outputs)-len(r.Missing), len(r.Conflicts), r.Similarity, r.Tokens)
+	}
+	tw.Flush()
+
+	state := "did not converge"
+	if rec.Converged {
+		state = "converged"
+	}
+	fmt.Fprintf(w, "\nDeliberation %s after %d rounds (%s), %d tokens.\n", state, len(rec.Rounds), rec.StopReason, rec.TokensSpent)
+	fmt.Fprintf(w, "\nSee how positions moved: ntm ensemble compare %s\n", rec.SessionName)
+	fmt.Fprintf(w, "Merge the final round:   ntm ensemble synthesize %s\n", rec.SessionName)
+}
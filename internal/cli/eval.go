@@ -0,0 +1,366 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/cost"
+	"github.com/Dicklesworthstone/ntm/internal/eval"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/persona"
+	"github.com/Dicklesworthstone/ntm/internal/scoring"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+func newEvalCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "eval",
+		Short: "Compare agents, models and personas on task suites",
+		Long: `Run offline evaluation suites and compare the results.
+
+A suite is a TOML file (or .ntm/eval/<name>.toml) listing candidates and
+tasks. Each candidate attempts each task in an isolated worktree of the
+task's repo snapshot; the task's verify script decides pass or fail.
+Time, tokens and cost are recorded per attempt, and every result is
+written to the effectiveness score store used for work assignment.
+
+Suite format:
+  name = "refactors"
+  timeout = "20m"
+
+  [[candidates]]
+  name = "claude-opus"
+  agent = "cc"            # cc, cod, gmi, or stub
+  model = "opus"
+  persona = "architect"   # optional
+
+  [[candidates]]
+  name = "baseline"
+  agent = "stub"          # runs command headless, prompt on stdin
+  command = "./fixtures/apply-fix.sh"
+
+  [[tasks]]
+  id = "extract-config"
+  type = "refactor"
+  repo = "fixtures/config-repo"   # git repo (ref optional) or plain dir
+  prompt = "Move config parsing into its own package."
+  verify = "go build ./... && go test ./..."
+
+Examples:
+  ntm eval run refactors
+  ntm eval run suites/bugs.toml --candidates=claude-opus,codex --repeat=3
+  ntm eval report
+  ntm eval report --suite=refactors --last=5`,
+	}
+
+	cmd.AddCommand(newEvalRunCmd(), newEvalReportCmd())
+	return cmd
+}
+
+func newEvalRunCmd() *cobra.Command {
+	var (
+		candidates []string
+		tasks      []string
+		repeat     int
+		keep       bool
+		workDir    string
+		noScores   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run <suite>",
+		Short: "Run an evaluation suite",
+		Long: `Run every candidate of a suite on every task and report the results.
+
+Interactive agents are launched in detached tmux sessions (ntm-eval-*)
+rooted at their workspace, using the configured agent commands; stub
+candidates run their command directly. Workspaces are removed afterwards
+unless --keep is given. The run record is saved for ntm eval report.`,
+		Example: `  ntm eval run refactors
+  ntm eval run suites/bugs.toml --tasks=nil-deref --repeat=3 --keep
+  ntm eval run refactors --candidates=baseline --no-scores --json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			path, err := eval.ResolveSuitePath(dir, args[0])
+			if err != nil {
+				return err
+			}
+			suite, err := eval.LoadSuite(path)
+			if err != nil {
+				return err
+			}
+			if err := suite.Filter(candidates, tasks); err != nil {
+				return err
+			}
+			if err := resolveEvalCandidates(suite, dir); err != nil {
+				return err
+			}
+
+			runner := &eval.Runner{
+				Suite:    suite,
+				NewAgent: evalAgentFactory(dir),
+				WorkDir:  workDir,
+				Keep:     keep,
+				Repeat:   repeat,
+			}
+			if !noScores {
+				runner.Tracker = scoring.DefaultTracker()
+			}
+			if !IsJSONOutput() {
+				fmt.Printf("Running suite %s: %d task(s) × %d candidate(s) × %d attempt(s)\n",
+					suite.Name, len(suite.Tasks), len(suite.Candidates), max(repeat, 1))
+				runner.OnResult = func(r eval.Result) {
+					printEvalResult(os.Stdout, r)
+				}
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			run, runErr := runner.Run(ctx)
+			if run == nil {
+				return runErr
+			}
+			if err := eval.SaveRun("", run); err != nil {
+				return err
+			}
+
+			runs := []*eval.Run{run}
+			report := eval.BuildReport(runs)
+			if IsJSONOutput() {
+				if err := output.PrintJSON(map[string]interface{}{"run": run, "report": report}); err != nil {
+					return err
+				}
+			} else {
+				fmt.Println()
+				if err := writeEvalReport(os.Stdout, report); err != nil {
+					return err
+				}
+				fmt.Printf("\nRun saved as %s (ntm eval report %s)\n", run.ID, run.ID)
+			}
+			if runErr != nil {
+				return fmt.Errorf("run interrupted after %d attempt(s): %w", len(run.Results), runErr)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&candidates, "candidates", nil, "Only run these candidates")
+	cmd.Flags().StringSliceVar(&tasks, "tasks", nil, "Only run these tasks")
+	cmd.Flags().IntVar(&repeat, "repeat", 1, "Attempts per candidate per task")
+	cmd.Flags().BoolVar(&keep, "keep", false, "Keep workspaces for inspection")
+	cmd.Flags().StringVar(&workDir, "work-dir", "", "Directory for workspaces (default: a temporary directory)")
+	cmd.Flags().BoolVar(&noScores, "no-scores", false, "Do not write results to the effectiveness score store")
+	return cmd
+}
+
+func newEvalReportCmd() *cobra.Command {
+	var (
+		suite string
+		last  int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "report [run-id...]",
+		Short: "Compare candidates across evaluation runs",
+		Long: `Show comparative tables for saved evaluation runs: a ranking of
+candidates by pass rate, score, time, tokens and cost, and a task-by-
+candidate matrix.
+
+Without run IDs the most recent run is reported, or the last --last runs
+(optionally of one --suite) aggregated together.`,
+		Example: `  ntm eval report
+  ntm eval report refactors-20260101-120000-a1b2c3
+  ntm eval report --suite=refactors --last=5 --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runs, err := eval.LoadRuns("", suite, args...)
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 && len(runs) > max(last, 1) {
+				runs = runs[:max(last, 1)]
+			}
+			if len(runs) == 0 {
+				if suite != "" {
+					return fmt.Errorf("no evaluation runs found for suite %q", suite)
+				}
+				return fmt.Errorf("no evaluation runs found; start one with: ntm eval run <suite>")
+			}
+
+			report := eval.BuildReport(runs)
+			if IsJSONOutput() {
+				return output.PrintJSON(report)
+			}
+			return writeEvalReport(os.Stdout, report)
+		},
+	}
+
+	cmd.Flags().StringVar(&suite, "suite", "", "Only include runs of this suite")
+	cmd.Flags().IntVar(&last, "last", 1, "Aggregate this many of the most recent runs")
+	return cmd
+}
+
+// resolveEvalCandidates fills in each candidate's full model name, taking
+// the persona's model when the candidate names none, so results are priced
+// and compared by the model that actually ran.
+func resolveEvalCandidates(suite *eval.Suite, dir string) error {
+	var registry *persona.Registry
+	for i, c := range suite.Candidates {
+		if c.AgentType() == eval.AgentStub {
+			continue
+		}
+		model := c.Model
+		if c.Persona != "" {
+			if registry == nil {
+				var err error
+				if registry, err = persona.LoadRegistry(dir); err != nil {
+					return fmt.Errorf("loading personas: %w", err)
+				}
+			}
+			p, ok := registry.Get(c.Persona)
+			if !ok {
+				return fmt.Errorf("candidate %s: unknown persona %q", c.Name, c.Persona)
+			}
+			if model == "" {
+				model = p.Model
+			}
+		}
+		suite.Candidates[i].Model = ResolveModel(AgentType(c.AgentType()), model)
+	}
+	return nil
+}
+
+// evalAgentFactory builds eval agents: stub candidates run their command
+// headless, the rest launch the configured agent command in tmux.
+func evalAgentFactory(dir string) func(eval.Candidate) (eval.Agent, error) {
+	return func(c eval.Candidate) (eval.Agent, error) {
+		if c.AgentType() == eval.AgentStub {
+			return &eval.CommandAgent{Command: c.Command}, nil
+		}
+		command, err := evalAgentCommand(c, dir)
+		if err != nil {
+			return nil, err
+		}
+		return &eval.TmuxAgent{Command: command, AgentType: c.AgentType()}, nil
+	}
+}
+
+// evalAgentCommand renders the launch command for a candidate.
+func evalAgentCommand(c eval.Candidate, dir string) (string, error) {
+	agents := config.Default().Agents
+	if cfg != nil {
+		agents = cfg.Agents
+	}
+	tmpl := c.Command
+	if tmpl == "" {
+		switch c.AgentType() {
+		case tmux.AgentClaude:
+			tmpl = agents.Claude
+		case tmux.AgentCodex:
+			tmpl = agents.Codex
+		case tmux.AgentGemini:
+			tmpl = agents.Gemini
+		}
+	}
+	if tmpl == "" {
+		return "", fmt.Errorf("no command configured for agent %q", c.Agent)
+	}
+
+	vars := config.AgentTemplateVars{
+		Model:       c.Model,
+		SessionName: "ntm-eval",
+		PaneIndex:   1,
+		AgentType:   string(c.AgentType()),
+		ProjectDir:  dir,
+		PersonaName: c.Persona,
+	}
+	if c.Persona != "" {
+		registry, err := persona.LoadRegistry(dir)
+		if err != nil {
+			return "", fmt.Errorf("loading personas: %w", err)
+		}
+		if p, ok := registry.Get(c.Persona); ok {
+			if vars.SystemPromptFile, err = persona.PrepareSystemPrompt(p, dir); err != nil {
+				return "", fmt.Errorf("preparing system prompt for %s: %w", p.Name, err)
+			}
+		}
+	}
+
+	command, err := config.GenerateAgentCommand(tmpl, vars)
+	if err != nil {
+		return "", fmt.Errorf("generating command for candidate %s: %w", c.Name, err)
+	}
+	return tmux.SanitizePaneCommand(command)
+}
+
+func printEvalResult(w io.Writer, r eval.Result) {
+	mark := "✓"
+	if !r.Passed {
+		mark = "✗"
+	}
+	line := fmt.Sprintf("  %s %-20s %-16s %6s  %6d tok  %s", mark, r.Task, r.Candidate,
+		formatDuration(r.Duration()), r.Tokens(), cost.FormatCost(r.CostUSD))
+	if r.Error != "" {
+		line += "  (" + r.Error + ")"
+	}
+	fmt.Fprintln(w, line)
+}
+
+func writeEvalReport(w io.Writer, report *eval.Report) error {
+	fmt.Fprintf(w, "Suite: %s   Runs: %d\n\n", strings.Join(report.Suites, ", "), len(report.Runs))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tCANDIDATE\tAGENT\tMODEL\tPASS\tSCORE\tAVG TIME\tAVG TOKENS\tCOST")
+	for i, c := range report.Candidates {
+		model := c.Model
+		if c.Persona != "" {
+			model += " (" + c.Persona + ")"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d/%d (%.0f%%)\t%.2f\t%s\t%d\t%s\n",
+			i+1, c.Candidate, c.Agent, model, c.Passed, c.Attempts, c.PassRate*100, c.AvgScore,
+			formatDuration(time.Duration(c.AvgDurationMs)*time.Millisecond), c.AvgTokens, cost.FormatCost(c.TotalCostUSD))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(report.Tasks) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		header := []string{"TASK", "TYPE"}
+		for _, c := range report.Candidates {
+			header = append(header, strings.ToUpper(c.Candidate))
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, row := range report.Tasks {
+			cells := []string{row.Task, row.TaskType}
+			for _, c := range report.Candidates {
+				cell, ok := row.Candidates[c.Candidate]
+				if !ok {
+					cells = append(cells, "-")
+					continue
+				}
+				cells = append(cells, fmt.Sprintf("%d/%d %s", cell.Passed, cell.Attempts, formatDuration(time.Duration(cell.AvgDurationMs)*time.Millisecond)))
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
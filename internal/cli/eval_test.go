@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/eval"
+)
+
+func TestEvalAgentCommand(t *testing.T) {
+	dir := t.TempDir()
+
+	got, err := evalAgentCommand(eval.Candidate{
+		Name:    "custom",
+		Agent:   "cc",
+		Model:   "claude-sonnet-4",
+		Command: "claude{{if .Model}} --model {{shellQuote .Model}}{{end}}",
+	}, dir)
+	if err != nil {
+		t.Fatalf("evalAgentCommand: %v", err)
+	}
+	if got != "claude --model 'claude-sonnet-4'" {
+		t.Errorf("command = %q", got)
+	}
+
+	got, err = evalAgentCommand(eval.Candidate{Name: "codex", Agent: "cod", Model: "gpt-5"}, dir)
+	if err != nil || !strings.Contains(got, "codex") || !strings.Contains(got, "gpt-5") {
+		t.Errorf("default codex command = %q, %v", got, err)
+	}
+
+	stub, err := evalAgentFactory(dir)(eval.Candidate{Name: "s", Agent: "stub", Command: "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := stub.(*eval.CommandAgent); !ok {
+		t.Errorf("stub candidate built %T, want *eval.CommandAgent", stub)
+	}
+}
+
+func TestWriteEvalReport(t *testing.T) {
+	report := eval.BuildReport([]*eval.Run{{
+		ID:    "refactors-1",
+		Suite: "refactors",
+		Results: []eval.Result{
+			{Task: "extract", TaskType: "refactor", Candidate: "codex", Agent: "cod", Model: "gpt-5", Passed: true, DurationMs: 90000, TimeoutMs: 600000, CostUSD: 0.25},
+			{Task: "extract", TaskType: "refactor", Candidate: "claude", Agent: "cc", Persona: "architect", Passed: false, DurationMs: 600000, TimeoutMs: 600000},
+		},
+	}})
+
+	var buf bytes.Buffer
+	if err := writeEvalReport(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Suite: refactors",
+		"1/1 (100%)",
+		"0/1 (0%)",
+		" (architect)",
+		"CODEX",
+		"extract",
+		"1/1 1m",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "codex") > strings.Index(out, "claude") {
+		t.Errorf("passing candidate should rank first:\n%s", out)
+	}
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/kernel"
+)
+
+func init() {
+	kernel.MustRegister(kernel.Command{
+		Name:        "events.schema",
+		Description: "List versioned JSON Schemas for event bus types",
+		Category:    "events",
+		Input: &kernel.SchemaRef{
+			Name: "EventsSchemaInput",
+			Ref:  "cli.EventsSchemaInput",
+		},
+		Output: &kernel.SchemaRef{
+			Name: "EventsSchemaResponse",
+			Ref:  "cli.EventsSchemaResponse",
+		},
+		Examples: []kernel.Example{
+			{
+				Name:        "list",
+				Description: "List every registered event type",
+				Command:     "ntm events schema",
+			},
+			{
+				Name:        "single",
+				Description: "Print the schema for one event type",
+				Command:     "ntm events schema agent_stall",
+			},
+		},
+		SafetyLevel: kernel.SafetySafe,
+		Idempotent:  true,
+	})
+	kernel.MustRegisterHandler("events.schema", handleEventsSchema)
+}
+
+// EventsSchemaInput selects which event schemas to return.
+type EventsSchemaInput struct {
+	Type string `json:"type,omitempty"`
+}
+
+// EventsSchemaResponse holds the registered event schemas.
+type EventsSchemaResponse struct {
+	Schemas []*events.EventSchema `json:"schemas"`
+}
+
+func handleEventsSchema(ctx context.Context, input any) (any, error) {
+	var params EventsSchemaInput
+	switch v := input.(type) {
+	case EventsSchemaInput:
+		params = v
+	case *EventsSchemaInput:
+		if v != nil {
+			params = *v
+		}
+	case map[string]any:
+		if t, ok := v["type"].(string); ok {
+			params.Type = t
+		}
+	}
+
+	if params.Type == "" {
+		return EventsSchemaResponse{Schemas: events.EventSchemas()}, nil
+	}
+	es, ok := events.LookupEventSchema(params.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q (run 'ntm events schema' to list types)", params.Type)
+	}
+	return EventsSchemaResponse{Schemas: []*events.EventSchema{es}}, nil
+}
+
+func newEventsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Inspect event bus types",
+		Long: `Inspect the event types published on the NTM event bus.
+
+Examples:
+  ntm events schema              # List event types and schema versions
+  ntm events schema agent_stall  # Print the JSON Schema for one type
+  ntm events schema --json       # All schemas as JSON`,
+	}
+
+	cmd.AddCommand(newEventsSchemaCmd())
+	return cmd
+}
+
+func newEventsSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema [TYPE]",
+		Short: "Show versioned JSON Schemas for event types",
+		Long: `Show the JSON Schema (draft 2020-12) for events published on the bus,
+in --robot-* output, over /events and on /ws frames.
+
+Schemas are generated from the Go event types. Each type carries a version
+that is bumped whenever its shape changes.
+
+Examples:
+  ntm events schema                # Table of event types
+  ntm events schema rotation_completed
+  ntm events schema --json         # Every schema as JSON`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var input EventsSchemaInput
+			if len(args) == 1 {
+				input.Type = args[0]
+			}
+
+			result, err := kernel.Run(cmd.Context(), "events.schema", input)
+			if err != nil {
+				return err
+			}
+			resp, _ := result.(EventsSchemaResponse)
+
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if jsonOutput {
+				return enc.Encode(resp)
+			}
+			if input.Type != "" {
+				return enc.Encode(resp.Schemas[0].Schema)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TYPE\tVERSION\tGO TYPE\tDESCRIPTION")
+			for _, es := range resp.Schemas {
+				fmt.Fprintf(w, "%s\tv%d\t%s\t%s\n", es.Type, es.Version, es.GoType, strings.TrimSpace(es.Description))
+			}
+			return w.Flush()
+		},
+	}
+}
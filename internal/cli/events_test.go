@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHandleEventsSchema(t *testing.T) {
+	all, err := handleEventsSchema(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("handleEventsSchema() error: %v", err)
+	}
+	if resp := all.(EventsSchemaResponse); len(resp.Schemas) < 10 {
+		t.Errorf("got %d schemas, want the full registry", len(resp.Schemas))
+	}
+
+	one, err := handleEventsSchema(context.Background(), map[string]any{"type": "rotation_completed"})
+	if err != nil {
+		t.Fatalf("handleEventsSchema(rotation_completed) error: %v", err)
+	}
+	resp := one.(EventsSchemaResponse)
+	if len(resp.Schemas) != 1 || resp.Schemas[0].GoType != "events.RotationCompletedEvent" {
+		t.Errorf("schemas = %+v", resp.Schemas)
+	}
+
+	if _, err := handleEventsSchema(context.Background(), EventsSchemaInput{Type: "no.such.event"}); err == nil {
+		t.Error("expected error for unknown event type")
+	}
+}
@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/palette"
+	"github.com/Dicklesworthstone/ntm/internal/startup"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// hostInventory returns the configured remote hosts, loading the config if
+// the command did not.
+func hostInventory() map[string]config.HostConfig {
+	if cfg != nil {
+		return cfg.Hosts
+	}
+	if loaded, err := startup.GetConfig(); err == nil && loaded != nil {
+		return loaded.Hosts
+	}
+	return nil
+}
+
+// hostClient returns a tmux client for the named host in the inventory.
+func hostClient(name string) (*tmux.Client, error) {
+	hosts := hostInventory()
+	h, ok := hosts[name]
+	if !ok {
+		if len(hosts) == 0 {
+			return nil, fmt.Errorf("unknown host %q: no [hosts] configured", name)
+		}
+		return nil, fmt.Errorf("unknown host %q (configured: %s)", name, strings.Join(slices.Sorted(maps.Keys(hosts)), ", "))
+	}
+	c := tmux.NewClient(h.Address)
+	c.Host = name
+	c.SSHOptions = h.SSHOptions()
+	return c, nil
+}
+
+// useHost points the default tmux client, and with it every session
+// command, at the named host.
+func useHost(name string) error {
+	c, err := hostClient(name)
+	if err != nil {
+		return err
+	}
+	tmux.DefaultClient = c
+	return nil
+}
+
+// currentHost returns the inventory entry of the host the default tmux
+// client runs on, or false when it is local or an ad hoc --ssh target.
+func currentHost() (config.HostConfig, bool) {
+	if tmux.DefaultClient.Host == "" {
+		return config.HostConfig{}, false
+	}
+	h, ok := hostInventory()[tmux.DefaultClient.Host]
+	return h, ok
+}
+
+// splitHostSession splits a "session@host" reference into its parts when
+// host is in the inventory. Other references are returned unchanged.
+func splitHostSession(ref string) (session, host string) {
+	i := strings.LastIndex(ref, "@")
+	if i <= 0 {
+		return ref, ""
+	}
+	if _, ok := hostInventory()[ref[i+1:]]; !ok {
+		return ref, ""
+	}
+	return ref[:i], ref[i+1:]
+}
+
+// useHostSession resolves a "session@host" reference by pointing the default
+// tmux client at host, and returns the bare session name.
+func useHostSession(ref string) (string, error) {
+	session, host := splitHostSession(ref)
+	if host == "" {
+		return ref, nil
+	}
+	if tmux.DefaultClient.Host != "" && tmux.DefaultClient.Host != host {
+		return "", fmt.Errorf("session %s is on host %s, not %s", ref, host, tmux.DefaultClient.Host)
+	}
+	return session, useHost(host)
+}
+
+// hostSessions is one tmux server's sessions in a cross-host listing.
+type hostSessions struct {
+	Host     string // inventory name, "" for the default client
+	Client   *tmux.Client
+	Sessions []tmux.Session
+	Err      error
+}
+
+// listSessionsOnHosts lists the sessions of the default tmux client and, when
+// it is local, of every configured host. Hosts are queried concurrently; an
+// unreachable host is reported in its entry's Err.
+func listSessionsOnHosts() []hostSessions {
+	results := []hostSessions{{Host: tmux.DefaultClient.Host, Client: tmux.DefaultClient}}
+	if tmux.DefaultClient.Remote == "" {
+		for _, name := range slices.Sorted(maps.Keys(hostInventory())) {
+			c, err := hostClient(name)
+			results = append(results, hostSessions{Host: name, Client: c, Err: err})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(r *hostSessions) {
+			defer wg.Done()
+			r.Sessions, r.Err = r.Client.ListSessions()
+		}(&results[i])
+	}
+	wg.Wait()
+	return results
+}
+
+// pickSessionOnHosts lets the user pick a session from the local tmux server
+// and every configured host, returning remote sessions as "session@host". It
+// returns "" when there is nothing to pick from beyond the local server, so
+// callers fall back to their usual session resolution.
+func pickSessionOnHosts() (string, error) {
+	if tmux.DefaultClient.Remote != "" || len(hostInventory()) == 0 {
+		return "", nil
+	}
+	var all []tmux.Session
+	remote := false
+	for _, r := range listSessionsOnHosts() {
+		for _, sess := range orderSessionsForSelection(r.Sessions) {
+			if r.Host != "" {
+				sess.Name += "@" + r.Host
+				remote = true
+			}
+			all = append(all, sess)
+		}
+	}
+	if !remote {
+		return "", nil
+	}
+	return palette.RunSessionSelector(all)
+}
+
+// HostStatus is one configured host in ntm hosts output.
+type HostStatus struct {
+	Name         string `json:"name"`
+	Address      string `json:"address"`
+	ProjectsBase string `json:"projects_base"`
+	Reachable    bool   `json:"reachable"`
+	Sessions     int    `json:"sessions"`
+	Error        string `json:"error,omitempty"`
+}
+
+func newHostsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "hosts",
+		Short: "List configured remote hosts and their sessions",
+		Long: `List the remote hosts configured in [hosts.<name>] tables and check that
+each is reachable over ssh.
+
+Any session command runs on a host with --host <name>:
+
+  ntm spawn api --cc=2 --host build-box
+  ntm status api --host build-box
+  ntm dashboard api@build-box
+
+'ntm list' and the dashboard's session picker show sessions on every host.
+Commands to the same host share one ssh connection (ControlMaster), kept open
+for 10 minutes after the last one.`,
+		Example: `  ntm hosts
+  ntm hosts --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHosts()
+		},
+	}
+}
+
+func runHosts() error {
+	hosts := hostInventory()
+	statuses := []HostStatus{}
+	for _, r := range listSessionsOnHosts() {
+		if r.Host == "" {
+			continue
+		}
+		h := hosts[r.Host]
+		s := HostStatus{
+			Name:         r.Host,
+			Address:      h.Address,
+			ProjectsBase: h.ProjectsDir(),
+			Reachable:    r.Err == nil,
+			Sessions:     len(r.Sessions),
+		}
+		if r.Err != nil {
+			s.Error = r.Err.Error()
+		}
+		statuses = append(statuses, s)
+	}
+
+	if IsJSONOutput() {
+		return output.PrintJSON(map[string]interface{}{"hosts": statuses, "count": len(statuses)})
+	}
+	if len(statuses) == 0 {
+		fmt.Println("No hosts configured. Add one to ~/.config/ntm/config.toml:")
+		fmt.Println()
+		fmt.Println("  [hosts.build-box]")
+		fmt.Println("  address = \"ubuntu@10.0.0.5\"")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "HOST\tADDRESS\tSTATUS\tSESSIONS")
+	for _, s := range statuses {
+		status := "ok"
+		if !s.Reachable {
+			status = "unreachable"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", s.Name, s.Address, status, s.Sessions)
+	}
+	w.Flush()
+	for _, s := range statuses {
+		if s.Error != "" {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", s.Name, strings.TrimSpace(s.Error))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// withHosts installs a host inventory and an ssh shim that answers every
+// remote tmux command with output, restoring the config and default tmux
+// client afterwards.
+func withHosts(t *testing.T, hosts map[string]config.HostConfig, output string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("ssh shim needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\nprintf '%s' " + tmux.ShellQuote(output) + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("NTM_SSH_CONTROL_DIR", "off")
+
+	oldCfg, oldClient := cfg, tmux.DefaultClient
+	cfg = config.Default()
+	cfg.Hosts = hosts
+	t.Cleanup(func() { cfg, tmux.DefaultClient = oldCfg, oldClient })
+}
+
+func TestUseHostSession(t *testing.T) {
+	withHosts(t, map[string]config.HostConfig{"build-box": {Address: "ubuntu@10.0.0.5", Port: 2222}}, "")
+
+	for _, ref := range []string{"api", "api@unknown"} {
+		if got, err := useHostSession(ref); err != nil || got != ref || tmux.DefaultClient.Remote != "" {
+			t.Errorf("useHostSession(%q) = %q, %v; remote %q", ref, got, err, tmux.DefaultClient.Remote)
+		}
+	}
+
+	got, err := useHostSession("api@build-box")
+	if err != nil || got != "api" {
+		t.Fatalf("useHostSession() = %q, %v", got, err)
+	}
+	c := tmux.DefaultClient
+	if c.Remote != "ubuntu@10.0.0.5" || c.Host != "build-box" || strings.Join(c.SSHOptions, " ") != "-p 2222" {
+		t.Errorf("default client = %+v", c)
+	}
+
+	if err := useHost("nope"); err == nil || !strings.Contains(err.Error(), "build-box") {
+		t.Errorf("useHost(unknown) error = %v", err)
+	}
+}
+
+func TestListSessionsOnHosts(t *testing.T) {
+	sep := tmux.FieldSeparator
+	withHosts(t, map[string]config.HostConfig{
+		"box-a": {Address: "a"},
+		"box-b": {Address: "b"},
+	}, "api"+sep+"1"+sep+"0"+sep+"today\n")
+
+	results := listSessionsOnHosts()
+	if len(results) != 3 || results[0].Host != "" {
+		t.Fatalf("listSessionsOnHosts() = %+v, want local then two hosts", results)
+	}
+	for i, host := range []string{"box-a", "box-b"} {
+		r := results[i+1]
+		if r.Host != host || r.Err != nil || len(r.Sessions) != 1 || r.Sessions[0].Name != "api" {
+			t.Errorf("results[%d] = %+v", i+1, r)
+		}
+	}
+
+	// A remote default client lists only its own host.
+	if err := useHost("box-b"); err != nil {
+		t.Fatal(err)
+	}
+	if results := listSessionsOnHosts(); len(results) != 1 || results[0].Host != "box-b" {
+		t.Errorf("listSessionsOnHosts() on a host = %+v", results)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/events"
 	"github.com/Dicklesworthstone/ntm/internal/plugins"
 	"github.com/Dicklesworthstone/ntm/internal/resilience"
+	"github.com/Dicklesworthstone/ntm/internal/search"
 	"github.com/Dicklesworthstone/ntm/internal/summary"
 	"github.com/Dicklesworthstone/ntm/internal/supervisor"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
@@ -121,6 +122,12 @@ func runMonitor(session string) error {
 
 	// Initialize archiver for background CASS capture
 	archiverOpts := archive.DefaultArchiverOptions(session)
+	if index, err := search.Open(""); err != nil {
+		fmt.Fprintf(os.Stderr, "Search index unavailable: %v\n", err)
+	} else {
+		archiverOpts.Index = index
+		defer index.Close()
+	}
 	archiver, err := archive.NewArchiver(archiverOpts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize archiver: %v\n", err)
@@ -134,6 +141,10 @@ func runMonitor(session string) error {
 		defer archiver.Close()
 	}
 
+	// Evaluate user-defined alert rules and record metric samples for
+	// 'ntm alerts rules test'.
+	go runAlertRules(ctx, session, manifest.ProjectDir)
+
 	// Wait for termination signal or session end
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
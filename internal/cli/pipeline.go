@@ -160,6 +160,7 @@ Examples:
 					Variables:    vars,
 					DryRun:       dryRun,
 					Background:   background,
+					BudgetCheck:  pipelineBudgetCheck,
 				}
 				exitCode := pipeline.PrintPipelineRun(opts)
 				if exitCode != 0 {
@@ -210,6 +211,7 @@ Examples:
 			execCfg.DryRun = dryRun
 			execCfg.ProjectDir = projectDir
 			execCfg.WorkflowFile = workflowPath
+			execCfg.BudgetCheck = pipelineBudgetCheck
 			executor := pipeline.NewExecutor(execCfg)
 
 			// Create progress channel
@@ -507,6 +509,7 @@ Examples:
 			execCfg.RunID = state.RunID
 			execCfg.ProjectDir = projectDir
 			execCfg.WorkflowFile = workflowFile
+			execCfg.BudgetCheck = pipelineBudgetCheck
 			executor := pipeline.NewExecutor(execCfg)
 
 			state.Session = session
@@ -814,6 +817,8 @@ func printProgressEvent(event pipeline.ProgressEvent) {
 		fmt.Printf("  ⊘ [%s] %s\n", event.StepID, event.Message)
 	case "step_retry":
 		fmt.Printf("  ↻ [%s] %s\n", event.StepID, event.Message)
+	case "step_approval":
+		fmt.Printf("  ⏸ [%s] %s\n", event.StepID, event.Message)
 	case "parallel_start":
 		fmt.Printf("  ⫘ [%s] %s\n", event.StepID, event.Message)
 	default:
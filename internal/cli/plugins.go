@@ -4,12 +4,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
+	"github.com/Dicklesworthstone/ntm/internal/adapter"
+	"github.com/Dicklesworthstone/ntm/internal/agent"
 	"github.com/Dicklesworthstone/ntm/internal/config"
 	"github.com/Dicklesworthstone/ntm/internal/plugins"
+	"github.com/Dicklesworthstone/ntm/internal/ratelimit"
+	"github.com/Dicklesworthstone/ntm/internal/status"
 )
 
 func newPluginsCmd() *cobra.Command {
@@ -72,3 +77,39 @@ func newPluginsListCmd() *cobra.Command {
 		},
 	}
 }
+
+var registerAgentPluginsOnce sync.Once
+
+// registerAgentPlugins loads the agent plugins once per process and registers
+// their output patterns, context limit and adapter, so their panes are
+// detected, rate-limited, prompted and restarted like built-in agents.
+func registerAgentPlugins() {
+	registerAgentPluginsOnce.Do(func() {
+		pluginsDir := filepath.Join(filepath.Dir(config.DefaultPath()), "agents")
+		loaded, _ := plugins.LoadAgentPlugins(pluginsDir)
+		for _, p := range loaded {
+			registerAgentPlugin(p)
+		}
+	})
+}
+
+func registerAgentPlugin(p plugins.AgentPlugin) {
+	ps, err := p.PatternSet()
+	if err != nil {
+		return // Already reported when loading
+	}
+	// Built-in agent types keep their own patterns
+	if err := agent.RegisterPatternSet(agent.AgentType(p.Name), ps); err != nil {
+		return
+	}
+	for _, pattern := range p.Patterns.Prompt {
+		_ = status.AddPromptPattern(p.Name, pattern, "Plugin "+p.Name+" prompt")
+	}
+	for _, pattern := range p.Patterns.Compaction {
+		_ = status.AddCompactionPattern(p.Name, pattern)
+	}
+	for _, pattern := range p.Patterns.RateLimit {
+		_ = ratelimit.AddAgentPattern(p.Name, pattern)
+	}
+	_ = adapter.Register(p.Adapter())
+}
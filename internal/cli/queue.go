@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/inbox"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/tui/theme"
+)
+
+// QueueListResult is the output of `ntm queue list`.
+type QueueListResult struct {
+	Session string               `json:"session,omitempty"`
+	Count   int                  `json:"count"`
+	Prompts []state.QueuedPrompt `json:"prompts"`
+}
+
+func newQueueCmd() *cobra.Command {
+	var (
+		pane int
+		all  bool
+	)
+
+	list := func(cmd *cobra.Command, args []string) error {
+		var session string
+		if len(args) > 0 {
+			session = args[0]
+		}
+		return runQueueList(session, pane, all)
+	}
+
+	cmd := &cobra.Command{
+		Use:   "queue [session]",
+		Short: "List and manage prompts queued for busy agents",
+		Long: `Manage the per-pane prompt queue.
+
+Prompts sent with 'ntm send --queue', pipeline steps with queue: true and
+bulk assignments wait here until their agent is idle, then are delivered one
+at a time. Delivery is done by 'ntm serve' or by 'ntm queue run'; a pipeline
+step waiting on its own prompt also delivers it.
+
+With no subcommand, lists the prompts still waiting.`,
+		Example: `  ntm queue                        # All queued prompts
+  ntm queue myproject --pane=2     # One pane's queue
+  ntm queue list myproject --all   # Include delivered, failed and cancelled
+  ntm queue move q-1a2b3c4d 1      # Deliver next
+  ntm queue cancel q-1a2b3c4d
+  ntm queue clear myproject
+  ntm queue run                    # Deliver queued prompts until interrupted`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: list,
+	}
+	cmd.PersistentFlags().IntVarP(&pane, "pane", "p", -1, "Only this pane index")
+	cmd.Flags().BoolVar(&all, "all", false, "Include delivered, failed and cancelled prompts")
+
+	listCmd := &cobra.Command{
+		Use:   "list [session]",
+		Short: "List queued prompts",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  list,
+	}
+	listCmd.Flags().BoolVar(&all, "all", false, "Include delivered, failed and cancelled prompts")
+
+	moveCmd := &cobra.Command{
+		Use:   "move <id> <position>",
+		Short: "Move a queued prompt within its pane's queue (1 = next)",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			position, err := strconv.Atoi(args[1])
+			if err != nil || position < 1 {
+				return fmt.Errorf("invalid position %q: must be a positive integer", args[1])
+			}
+			return runQueueMove(args[0], position)
+		},
+	}
+
+	cancelCmd := &cobra.Command{
+		Use:   "cancel <id>...",
+		Short: "Cancel queued prompts",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runQueueCancel(args)
+		},
+	}
+
+	clearCmd := &cobra.Command{
+		Use:   "clear <session>",
+		Short: "Cancel every queued prompt in a session, or in one pane with --pane",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runQueueClear(args[0], pane)
+		},
+	}
+
+	var interval, quiet time.Duration
+	runCmd := &cobra.Command{
+		Use:   "run",
+		Short: "Deliver queued prompts to idle agents until interrupted",
+		Long: `Run the queue dispatcher in the foreground.
+
+Every interval, each pane with queued prompts is checked; once its agent is
+idle and has printed nothing for the quiet period, the next prompt is
+delivered using the [send] delivery method. Not needed while 'ntm serve' is
+running, which dispatches the queue itself.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runQueueDispatcher(interval, quiet)
+		},
+	}
+	runCmd.Flags().DurationVar(&interval, "interval", inbox.DefaultInterval, "How often queued panes are checked")
+	runCmd.Flags().DurationVar(&quiet, "quiet", inbox.DefaultQuiet, "How long an agent must be idle before it is sent a prompt")
+
+	cmd.AddCommand(listCmd, moveCmd, cancelCmd, clearCmd, runCmd)
+	return cmd
+}
+
+// newQueueDispatcher returns a dispatcher for store that delivers prompts
+// the way ntm send is configured to.
+func newQueueDispatcher(store *state.Store) *inbox.Dispatcher {
+	d := inbox.NewDispatcher(store)
+	if cfg != nil {
+		d.Delivery = cfg.Send.Delivery
+		d.DeliveryAttempts = cfg.Send.DeliveryAttempts
+	}
+	return d
+}
+
+func runQueueList(session string, pane int, all bool) error {
+	store, err := inbox.OpenStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	prompts, err := store.ListQueuedPrompts(session, pane, all)
+	if err != nil {
+		return err
+	}
+	if prompts == nil {
+		prompts = []state.QueuedPrompt{}
+	}
+	result := QueueListResult{Session: session, Count: len(prompts), Prompts: prompts}
+	if IsJSONOutput() {
+		return output.PrintJSON(result)
+	}
+	return result.Text(os.Stdout)
+}
+
+// Text renders the queue grouped by pane.
+func (r QueueListResult) Text(w io.Writer) error {
+	t := theme.Current()
+	if len(r.Prompts) == 0 {
+		fmt.Fprintf(w, "%sNo queued prompts%s\n", colorize(t.Warning), "\033[0m")
+		return nil
+	}
+
+	lastPane := ""
+	for _, p := range r.Prompts {
+		if key := fmt.Sprintf("%s:%d", p.Session, p.Pane); key != lastPane {
+			if lastPane != "" {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s%s%s pane %d\n", colorize(t.Primary), p.Session, "\033[0m", p.Pane)
+			lastPane = key
+		}
+		position := "  "
+		if !p.Status.IsTerminal() {
+			position = fmt.Sprintf("%d.", p.Position)
+		}
+		detail := formatAge(p.CreatedAt)
+		if p.Source != "" {
+			detail += " • " + p.Source
+		}
+		if p.Error != "" {
+			detail += " • " + p.Error
+		}
+		fmt.Fprintf(w, "  %3s %s  %-10s %s\n", position, p.ID, p.Status, strings.Join(strings.Fields(truncatePrompt(p.Prompt, 60)), " "))
+		fmt.Fprintf(w, "      %s%s%s\n", "\033[2m", detail, "\033[0m")
+	}
+	return nil
+}
+
+func runQueueMove(id string, position int) error {
+	store, err := inbox.OpenStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if err := store.MoveQueuedPrompt(id, position); err != nil {
+		return err
+	}
+	p, err := store.GetQueuedPrompt(id)
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return output.PrintJSON(p)
+	}
+	fmt.Printf("Moved %s to position %d in %s pane %d\n", id, p.Position, p.Session, p.Pane)
+	return nil
+}
+
+func runQueueCancel(ids []string) error {
+	store, err := inbox.OpenStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var cancelled []string
+	for _, id := range ids {
+		if err := store.CancelQueuedPrompt(id); err != nil {
+			return err
+		}
+		cancelled = append(cancelled, id)
+	}
+	if IsJSONOutput() {
+		return output.PrintJSON(map[string]interface{}{"cancelled": cancelled})
+	}
+	for _, id := range cancelled {
+		fmt.Printf("Cancelled %s\n", id)
+	}
+	return nil
+}
+
+func runQueueClear(session string, pane int) error {
+	store, err := inbox.OpenStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	n, err := store.CancelQueuedPrompts(session, pane)
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return output.PrintJSON(map[string]interface{}{"session": session, "cancelled": n})
+	}
+	fmt.Printf("Cancelled %d queued prompt(s) in %s\n", n, session)
+	return nil
+}
+
+func runQueueDispatcher(interval, quiet time.Duration) error {
+	store, err := inbox.OpenStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	d := newQueueDispatcher(store)
+	d.Interval = interval
+	d.Quiet = quiet
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !IsJSONOutput() {
+		fmt.Println("Delivering queued prompts. Press Ctrl+C to stop")
+	}
+	if err := d.Run(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
@@ -68,15 +68,8 @@ func runQuota(session string) error {
 	}
 
 	for _, p := range panes {
-		var provider quota.Provider
-		switch p.Type {
-		case tmux.AgentClaude:
-			provider = quota.ProviderClaude
-		case tmux.AgentCodex:
-			provider = quota.ProviderCodex
-		case tmux.AgentGemini:
-			provider = quota.ProviderGemini
-		default:
+		provider := quota.ProviderFor(p.Type)
+		if provider == "" {
 			continue // Skip user/unknown panes
 		}
 
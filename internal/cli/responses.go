@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/robot"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/tui/theme"
+)
+
+func newResponsesCmd() *cobra.Command {
+	var (
+		session string
+		pane    int
+		wait    bool
+		timeout time.Duration
+		code    bool
+		limit   int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "responses [history-id]",
+		Short: "Show each agent's reply to a prompt sent with ntm send",
+		Long: `Show what each agent answered to a prompt sent with 'ntm send'.
+
+When a prompt is delivered, ntm notes where each agent pane's output stood.
+Once the agent is idle again, everything it printed since is captured, the
+agent's TUI chrome (input box, status bar, spinners, the echoed prompt) is
+stripped, and code blocks are extracted. Replies are captured by 'ntm serve'
+in the background, or by this command when it runs.
+
+The ID is the prompt history entry ID printed by 'ntm send --json' and shown
+by 'ntm history'. With no ID, the replies to the most recent prompt are shown.`,
+		Example: `  ntm responses                              # Replies to the last prompt sent
+  ntm responses 1735830245123-a1b2c3d4 --wait
+  ntm responses --session=myproject --limit=20
+  ntm responses 1735830245123-a1b2c3d4 --pane=2 --code`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := robot.ResponsesOptions{Session: session, Pane: pane, Limit: limit}
+			if len(args) > 0 {
+				opts.HistoryID = args[0]
+			}
+			if wait {
+				opts.Wait = timeout
+			}
+			return runResponses(opts, code)
+		},
+	}
+
+	cmd.Flags().StringVarP(&session, "session", "s", "", "List recent replies in a session instead of one prompt's")
+	cmd.Flags().IntVarP(&pane, "pane", "p", -1, "Only this pane index")
+	cmd.Flags().BoolVarP(&wait, "wait", "w", false, "Wait for agents that are still replying")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "How long --wait waits")
+	cmd.Flags().BoolVar(&code, "code", false, "Print only the extracted code blocks")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Max replies with --session")
+
+	return cmd
+}
+
+func runResponses(opts robot.ResponsesOptions, codeOnly bool) error {
+	store, err := state.Open("")
+	if err != nil {
+		return fmt.Errorf("open state store: %w", err)
+	}
+	defer store.Close()
+	if err := store.Migrate(); err != nil {
+		return fmt.Errorf("apply migrations: %w", err)
+	}
+	opts.Store = store
+
+	if opts.HistoryID == "" && opts.Session == "" {
+		latest, err := store.ListResponses(state.ResponseFilter{Pane: -1, Limit: 1})
+		if err != nil {
+			return err
+		}
+		if len(latest) == 0 {
+			return fmt.Errorf("no replies recorded yet; send a prompt with ntm send first")
+		}
+		opts.HistoryID = latest[0].HistoryID
+	}
+
+	result, err := robot.GetResponses(opts)
+	if err != nil {
+		return err
+	}
+	if IsJSONOutput() {
+		return output.PrintJSON(result)
+	}
+	if !result.Success {
+		return fmt.Errorf("%s", result.Error)
+	}
+
+	th := theme.Current()
+	paneStyle := lipgloss.NewStyle().Bold(true).Foreground(th.Primary)
+	dimStyle := lipgloss.NewStyle().Foreground(th.Subtext)
+	warnStyle := lipgloss.NewStyle().Foreground(th.Warning)
+
+	for i, r := range result.Responses {
+		if i > 0 {
+			fmt.Println()
+		}
+		header := fmt.Sprintf("=== %s pane %d (%s) ===", r.Session, r.Pane, r.AgentType)
+		fmt.Println(paneStyle.Render(header))
+		if opts.HistoryID == "" {
+			fmt.Println(dimStyle.Render("Prompt: " + truncatePrompt(strings.Join(strings.Fields(r.Prompt), " "), 70)))
+		}
+
+		switch r.Status {
+		case state.ResponsePending:
+			fmt.Println(warnStyle.Render("Still replying; rerun with --wait to wait for it"))
+			continue
+		case state.ResponseLost:
+			fmt.Println(warnStyle.Render("Not captured: " + r.Error))
+			continue
+		case state.ResponseTimeout:
+			fmt.Println(warnStyle.Render("Agent never went idle; showing what it printed"))
+		}
+
+		if codeOnly {
+			if len(r.CodeBlocks) == 0 {
+				fmt.Println(dimStyle.Render("(no code blocks)"))
+			}
+			for _, b := range r.CodeBlocks {
+				fmt.Printf("```%s\n%s\n```\n", b.Language, b.Content)
+			}
+			continue
+		}
+		if r.Text == "" {
+			fmt.Println(dimStyle.Render("(no output)"))
+			continue
+		}
+		fmt.Println(r.Text)
+	}
+
+	if result.Pending > 0 {
+		fmt.Println()
+		fmt.Println(dimStyle.Render(fmt.Sprintf("%d of %d agent(s) still replying", result.Pending, len(result.Responses))))
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -34,9 +35,10 @@ import (
 )
 
 var (
-	cfgFile string
-	cfg     *config.Config
-	sshHost string
+	cfgFile  string
+	cfg      *config.Config
+	sshHost  string
+	hostName string
 
 	// Global JSON output flag - inherited by all subcommands
 	jsonOutput bool
@@ -127,6 +129,9 @@ Shell Integration:
 			// Apply redaction flag overrides
 			applyRedactionFlagOverrides(cfg)
 
+			// Teach detection about agent types defined by plugins
+			registerAgentPlugins()
+
 			// Ensure persisted prompt history + event logs never store raw secrets/PII when redaction is enabled.
 			// (bd-3sl0s)
 			if cfg != nil {
@@ -173,6 +178,24 @@ Shell Integration:
 				}
 			}
 
+			// Persist bus events so serve, webhook bridges and dashboards can
+			// resume after a restart.
+			if cfg != nil && cfg.Events.Durable && events.DefaultBus.Log() == nil {
+				dir := util.ExpandPath(cfg.Events.Dir)
+				if dir == "" {
+					dir, _ = events.DefaultEventLogDir()
+				}
+				eventLog, err := events.OpenEventLog(dir, events.EventLogOptions{
+					SegmentBytes: int64(cfg.Events.SegmentSizeMB) << 20,
+					MaxSegments:  cfg.Events.MaxSegments,
+				})
+				if err != nil {
+					slog.Default().Debug("durable event log disabled", "dir", dir, "error", err)
+				} else {
+					events.DefaultBus.SetLog(eventLog)
+				}
+			}
+
 			// Run automatic temp file cleanup if enabled
 			MaybeRunStartupCleanup(
 				cfg.Cleanup.AutoCleanOnStartup,
@@ -180,6 +203,16 @@ Shell Integration:
 				cfg.Cleanup.Verbose,
 			)
 		}
+
+		// Run session commands on a configured remote host
+		if hostName != "" {
+			if sshHost != "" {
+				return fmt.Errorf("--host and --ssh cannot be combined")
+			}
+			if err := useHost(hostName); err != nil {
+				return err
+			}
+		}
 		startCommandAudit(cmd, args)
 		return nil
 	},
@@ -731,6 +764,44 @@ Shell Integration:
 			}
 			return
 		}
+		if robotResponses != "" {
+			var wait time.Duration
+			if robotResponsesWait != "" {
+				var err error
+				if wait, err = time.ParseDuration(robotResponsesWait); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: invalid --responses-wait: %v\n", err)
+					os.Exit(2)
+				}
+			}
+			opts := robot.ResponsesOptions{
+				HistoryID: robotResponses,
+				Pane:      -1,
+				Wait:      wait,
+			}
+			if err := robot.PrintResponses(opts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if robotMergeQueue != "" {
+			opts := mergeQueueOptions(robotMergeQueue)
+			opts.Status = robotMergeQueueStatus
+			if robotMergeQueueTarget != "" {
+				opts.Target = robotMergeQueueTarget
+			}
+			if robotMergeQueueVerify != "" {
+				opts.Verify = robotMergeQueueVerify
+			}
+			if robotMergeQueueAgents != "" {
+				opts.Agents = strings.Split(robotMergeQueueAgents, ",")
+			}
+			if err := robot.PrintMergeQueue(opts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
 		if robotActivity != "" {
 			// Parse pane filter (reuse --panes flag)
 			var paneFilter []string
@@ -820,6 +891,7 @@ Shell Integration:
 				Variables:    vars,
 				DryRun:       robotPipelineDryRun,
 				Background:   robotPipelineBG,
+				BudgetCheck:  pipelineBudgetCheck,
 			}
 			exitCode := pipeline.PrintPipelineRun(opts)
 			os.Exit(exitCode)
@@ -1143,9 +1215,14 @@ Shell Integration:
 				DelayMs:    robotSendDelay,
 				Enter:      enterOverride,
 				DryRun:     robotDryRunEffective,
+				Delivery:   robotSendVia,
 			}
 			if cfg != nil {
 				opts.Redaction = cfg.Redaction.ToRedactionLibConfig()
+				opts.DeliveryAttempts = cfg.Send.DeliveryAttempts
+				if opts.Delivery == "" {
+					opts.Delivery = cfg.Send.Delivery
+				}
 			}
 			if err := robot.PrintSend(opts); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -1320,6 +1397,7 @@ Shell Integration:
 				DryRun:             robotDryRunEffective,
 				SkipPanes:          skipPanes,
 				PromptTemplatePath: robotBulkAssignTemplate,
+				Queue:              robotBulkAssignQueue,
 			}
 			if err := robot.PrintBulkAssign(opts); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -2149,6 +2227,7 @@ var (
 	robotSendType    string // filter by agent type (e.g., "claude")
 	robotSendExclude string // comma-separated panes to exclude
 	robotSendDelay   int    // delay between sends in ms
+	robotSendVia     string // delivery method: keys or paste
 
 	// Robot-assign flags for work distribution
 	robotAssign         string // session name for work assignment
@@ -2162,6 +2241,7 @@ var (
 	robotBulkAssignStrategy string // assignment strategy: impact, ready, stale, balanced
 	robotBulkAssignSkip     string // comma-separated panes to skip
 	robotBulkAssignTemplate string // prompt template file path
+	robotBulkAssignQueue    bool   // queue prompts until each agent is idle
 
 	// Robot-health flag
 	robotHealth             string // session health or project health (empty = project)
@@ -2318,6 +2398,17 @@ var (
 	robotHistorySince string // time-based filter
 	robotHistoryStats bool   // show statistics instead of entries
 
+	// Robot-responses flags for captured agent replies
+	robotResponses     string // prompt history entry ID
+	robotResponsesWait string // how long to wait for agents still replying
+
+	// Robot-merge-queue flags for test-gated integration of agent branches
+	robotMergeQueue       string // session whose agent branches to merge
+	robotMergeQueueStatus bool   // report the last run instead of starting one
+	robotMergeQueueTarget string // integration branch
+	robotMergeQueueVerify string // verification command
+	robotMergeQueueAgents string // comma-separated agents in merge order
+
 	// Robot-activity flags for agent activity detection
 	robotActivity     string // session name for activity query
 	robotActivityType string // filter by agent type (claude, codex, gemini)
@@ -2555,6 +2646,7 @@ func init() {
 	// Global JSON output flag - applies to all commands
 	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Output in JSON format (machine-readable)")
 	rootCmd.PersistentFlags().StringVar(&sshHost, "ssh", "", "Remote host for SSH execution (e.g. user@host)")
+	rootCmd.PersistentFlags().StringVar(&hostName, "host", "", "Run on a host from the [hosts] config inventory (see 'ntm hosts')")
 
 	// Global no-color flag - disables colored output (respects NO_COLOR env var standard)
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output")
@@ -2671,6 +2763,7 @@ func init() {
 	rootCmd.Flags().StringVar(&robotSendType, "type", "", "Filter by agent type: claude|cc, codex|cod, gemini|gmi, cursor, windsurf, aider. Works with --robot-send, --robot-ack, --robot-interrupt")
 	rootCmd.Flags().StringVar(&robotSendExclude, "exclude", "", "Exclude pane indices (comma-separated). Optional with --robot-send. Example: --exclude=0,3")
 	rootCmd.Flags().IntVar(&robotSendDelay, "delay-ms", 0, "Delay between sends (ms). Optional with --robot-send. Example: --delay-ms=500 for 0.5s between panes")
+	rootCmd.Flags().StringVar(&robotSendVia, "delivery", "", "Prompt delivery: keys (send-keys) or paste (paste buffer, verified). Optional with --robot-send; default from [send] delivery")
 
 	// Robot-assign flags for work distribution
 	rootCmd.Flags().StringVar(&robotAssign, "robot-assign", "", "Get work distribution recommendations. Required: SESSION. Example: ntm --robot-assign=proj --strategy=speed")
@@ -2684,6 +2777,7 @@ func init() {
 	rootCmd.Flags().StringVar(&robotBulkAssignStrategy, "bulk-strategy", "impact", "Bulk assignment strategy: impact (default), ready, stale, balanced. Use with --from-bv")
 	rootCmd.Flags().StringVar(&robotBulkAssignSkip, "skip-panes", "", "Comma-separated pane indices to skip. Use with --robot-bulk-assign. Example: --skip-panes=0,3")
 	rootCmd.Flags().StringVar(&robotBulkAssignTemplate, "prompt-template", "", "Custom prompt template file. Use with --robot-bulk-assign")
+	rootCmd.Flags().BoolVar(&robotBulkAssignQueue, "bulk-queue", false, "Queue each prompt until its agent is idle instead of sending now. Use with --robot-bulk-assign")
 
 	// Robot-health flag for session/project health summary
 	rootCmd.Flags().StringVar(&robotHealth, "robot-health", "", "Get session or project health (JSON). SESSION for per-agent health, empty for project health. Example: ntm --robot-health=myproject")
@@ -2863,6 +2957,17 @@ func init() {
 	rootCmd.Flags().StringVar(&robotHistorySince, "history-since", "", "Show entries since time (1h, 30m, 2d, or ISO8601). Optional with --robot-history")
 	rootCmd.Flags().BoolVar(&robotHistoryStats, "history-stats", false, "Show statistics instead of entries. Optional with --robot-history")
 
+	// Robot-responses flags for captured agent replies
+	rootCmd.Flags().StringVar(&robotResponses, "robot-responses", "", "Get each agent's reply to a prompt sent with ntm send (JSON). Required: history entry ID. Example: ntm --robot-responses=1735830245123-a1b2c3d4")
+	rootCmd.Flags().StringVar(&robotResponsesWait, "responses-wait", "", "Wait up to this long for agents still replying. Optional with --robot-responses. Example: --responses-wait=5m")
+
+	// Robot-merge-queue flags for test-gated integration of agent branches
+	rootCmd.Flags().StringVar(&robotMergeQueue, "robot-merge-queue", "", "Rebase, verify and fast-forward the session's agent worktree branches onto the integration branch (JSON). Required: SESSION. Example: ntm --robot-merge-queue=myproject")
+	rootCmd.Flags().BoolVar(&robotMergeQueueStatus, "merge-queue-status", false, "Report the last merge queue run instead of starting one. Optional with --robot-merge-queue")
+	rootCmd.Flags().StringVar(&robotMergeQueueTarget, "merge-queue-target", "", "Integration branch (default merge_queue.target or ntm/SESSION/integration). Optional with --robot-merge-queue")
+	rootCmd.Flags().StringVar(&robotMergeQueueVerify, "merge-queue-verify", "", "Command that must pass before a branch is merged (default merge_queue.verify). Optional with --robot-merge-queue. Example: --merge-queue-verify='go test ./...'")
+	rootCmd.Flags().StringVar(&robotMergeQueueAgents, "merge-queue-agents", "", "Comma-separated agents to merge, in order (default all). Optional with --robot-merge-queue. Example: --merge-queue-agents=cc_1,cod_1")
+
 	// Robot-activity flags for agent activity detection
 	rootCmd.Flags().StringVar(&robotActivity, "robot-activity", "", "Get agent activity state (idle/busy/error). Required: SESSION. Example: ntm --robot-activity=myproject")
 	rootCmd.Flags().StringVar(&robotActivityType, "activity-type", "", "Filter by agent type: claude, codex, gemini. Optional with --robot-activity. Example: --activity-type=claude")
@@ -3306,11 +3411,15 @@ func init() {
 		// Agent management
 		newAddCmd(),
 		newSendCmd(),
+		newQueueCmd(),
+		newResponsesCmd(),
+		newHostsCmd(),
 		newPreflightCmd(),
 		newReplayCmd(),
 		newInterruptCmd(),
 		newRotateCmd(),
 		newQuotaCmd(),
+		newCostCmd(),
 		newPipelineCmd(),
 		newWaitCmd(),
 		newMailCmd(),
@@ -3374,6 +3483,10 @@ func init() {
 		newPolicyCmd(),
 		newKernelCmd(),
 		newOpenAPICmd(),
+		newEventsCmd(),
+		newAlertsCmd(),
+		newEvalCmd(),
+		newDevCmd(),
 		newGuardsCmd(),
 		newApproveCmd(),
 		newServeCmd(),
@@ -3707,6 +3820,7 @@ Examples:
 						"default_panes":      effectiveCfg.Tmux.DefaultPanes,
 						"palette_key":        effectiveCfg.Tmux.PaletteKey,
 						"pane_init_delay_ms": effectiveCfg.Tmux.PaneInitDelayMs,
+						"control_mode":       effectiveCfg.Tmux.ControlMode,
 					},
 					"checkpoints": map[string]interface{}{
 						"enabled":                  effectiveCfg.Checkpoints.Enabled,
@@ -149,15 +149,8 @@ func rotateAllLimited(session, targetAccount string, dryRun bool) error {
 		}
 
 		// Check quota
-		var provider quota.Provider
-		switch p.Type {
-		case tmux.AgentClaude:
-			provider = quota.ProviderClaude
-		case tmux.AgentCodex:
-			provider = quota.ProviderCodex
-		case tmux.AgentGemini:
-			provider = quota.ProviderGemini
-		default:
+		provider := quota.ProviderFor(p.Type)
+		if provider == "" {
 			continue
 		}
 
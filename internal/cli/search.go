@@ -0,0 +1,273 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/archive"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/search"
+	"github.com/Dicklesworthstone/ntm/internal/tui/theme"
+	"github.com/Dicklesworthstone/ntm/internal/util"
+)
+
+// ArchiveSearchOptions configures a search over the local archive index.
+type ArchiveSearchOptions struct {
+	Query   search.Query
+	Context int    // Neighbouring records shown around each hit
+	At      string // Jump to a timestamp instead of searching
+	Around  int64  // Jump to a hit ID instead of searching
+	NoSync  bool   // Skip ingesting new archive files before searching
+}
+
+// ArchiveSearchResult is the output of `ntm search --index`.
+type ArchiveSearchResult struct {
+	Query   string                    `json:"query,omitempty"`
+	Engine  search.Engine             `json:"engine"`
+	Count   int                       `json:"count"`
+	Hits    []search.Hit              `json:"hits,omitempty"`
+	Context map[int64][]search.Record `json:"context,omitempty"` // Keyed by hit ID
+	Records []search.Record           `json:"records,omitempty"` // --at / --around
+
+	jump   bool  // Records were requested rather than hits
+	target int64 // Record to mark in jump output
+}
+
+func newSearchCmd() *cobra.Command {
+	var (
+		session  string
+		agent    string
+		pane     string
+		since    string
+		until    string
+		limit    int
+		offset   int
+		contextN int
+		at       string
+		around   int64
+		noSync   bool
+		useIndex bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search archived agent output via CASS",
+		Long: `Search past agent sessions indexed by CASS (Coding Agent Session Search).
+
+Queries archived agent output across all sessions, with optional filtering
+by session name, agent type, and time range.
+
+This is a convenience wrapper around 'ntm cass search' with defaults
+tuned for quick lookups.
+
+With --index, searches the local full-text index of archived pane output
+instead. The index is fed by the output archiver ('ntm monitor') and catches
+up on archive files in ~/.ntm/archive before each search. Terms are AND-ed;
+"double quotes" match a phrase and a trailing * matches a prefix. Sessions
+in privacy mode are never indexed or returned. --at jumps to what a session
+printed at a given time, and --around shows the output surrounding a hit ID.`,
+		Example: `  ntm search 'rate limiting middleware'
+  ntm search 'authentication' --session=myproject
+  ntm search 'database migration' --agent=claude_code --since=7d
+  ntm search 'error handling' --limit=5 --json
+  ntm search --index 'nil pointer' --session=myproject --agent=cod --since=2d
+  ntm search --index '"connection refused"' -C 2
+  ntm search --index --around 4821 -C 5
+  ntm search --index --at 2026-03-01T14:05:00Z --session=myproject`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if useIndex && (at != "" || around > 0) {
+				return cobra.MaximumNArgs(0)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !useIndex {
+				for _, name := range []string{"pane", "until", "context", "at", "around", "no-sync"} {
+					if cmd.Flags().Changed(name) {
+						return fmt.Errorf("--%s requires --index", name)
+					}
+				}
+				return runCassSearch(args[0], agent, session, since, limit, offset)
+			}
+
+			var text string
+			if len(args) > 0 {
+				text = args[0]
+			}
+			opts := ArchiveSearchOptions{
+				Query: search.Query{
+					Text:    text,
+					Session: session,
+					Agent:   agent,
+					Pane:    pane,
+					Limit:   limit,
+					Offset:  offset,
+				},
+				Context: contextN,
+				At:      at,
+				Around:  around,
+				NoSync:  noSync,
+			}
+			if since != "" {
+				t, err := parseSearchTime(since)
+				if err != nil {
+					return fmt.Errorf("invalid --since: %w", err)
+				}
+				opts.Query.Since = t
+			}
+			if until != "" {
+				t, err := parseSearchTime(until)
+				if err != nil {
+					return fmt.Errorf("invalid --until: %w", err)
+				}
+				opts.Query.Until = t
+			}
+			return runArchiveSearch(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&session, "session", "s", "", "Filter by session/project workspace")
+	cmd.Flags().StringVarP(&agent, "agent", "a", "", "Filter by agent type (e.g. claude_code, or cc/cod/gmi with --index)")
+	cmd.Flags().StringVar(&since, "since", "", "Filter by time (e.g. 1h, 7d, 30d)")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Max results to return")
+	cmd.Flags().IntVar(&offset, "offset", 0, "Result offset for pagination")
+	cmd.Flags().BoolVar(&useIndex, "index", false, "Search the local full-text index of archived pane output instead of CASS")
+	cmd.Flags().StringVar(&pane, "pane", "", "Filter by pane name, e.g. cod_2 (--index)")
+	cmd.Flags().StringVar(&until, "until", "", "Only output before this time or age (--index)")
+	cmd.Flags().IntVarP(&contextN, "context", "C", 0, "Show N archived records before and after each hit (--index)")
+	cmd.Flags().StringVar(&at, "at", "", "Show output at a time (RFC3339 or age like 90m) instead of searching (--index)")
+	cmd.Flags().Int64Var(&around, "around", 0, "Show output around a hit ID instead of searching (--index)")
+	cmd.Flags().BoolVar(&noSync, "no-sync", false, "Don't index new archive files before searching (--index)")
+
+	return cmd
+}
+
+// parseSearchTime accepts an RFC3339 timestamp or an age such as "2d",
+// which is resolved relative to now.
+func parseSearchTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := parseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC3339 time or age like 2d: %q", s)
+	}
+	return time.Now().Add(-d), nil
+}
+
+func runArchiveSearch(opts ArchiveSearchOptions) error {
+	idx, err := search.Open("")
+	if err != nil {
+		return fmt.Errorf("open search index: %w", err)
+	}
+	defer idx.Close()
+
+	if !opts.NoSync {
+		if _, err := idx.IngestDir(util.ExpandPath(archive.DefaultOutputDir)); err != nil {
+			return fmt.Errorf("index archives: %w", err)
+		}
+	}
+
+	result := &ArchiveSearchResult{Query: opts.Query.Text, Engine: idx.Engine(), jump: opts.Around > 0 || opts.At != "", target: opts.Around}
+	switch {
+	case opts.Around > 0:
+		result.Records, err = idx.Around(opts.Around, max(opts.Context, 3), max(opts.Context, 3))
+		result.Count = len(result.Records)
+	case opts.At != "":
+		if opts.Query.Session == "" {
+			return fmt.Errorf("--at requires --session")
+		}
+		at, perr := parseSearchTime(opts.At)
+		if perr != nil {
+			return fmt.Errorf("invalid --at: %w", perr)
+		}
+		result.Records, err = idx.At(opts.Query.Session, opts.Query.Pane, at, max(opts.Context, 3), max(opts.Context, 3))
+		result.Count = len(result.Records)
+	default:
+		result.Hits, err = idx.Search(opts.Query)
+		result.Count = len(result.Hits)
+		if err == nil && opts.Context > 0 {
+			result.Context = make(map[int64][]search.Record, len(result.Hits))
+			for _, h := range result.Hits {
+				recs, cerr := idx.Around(h.ID, opts.Context, opts.Context)
+				if cerr != nil {
+					return cerr
+				}
+				result.Context[h.ID] = recs
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if IsJSONOutput() {
+		return output.PrintJSON(result)
+	}
+	return result.Text(os.Stdout)
+}
+
+// Text renders search results for the terminal.
+func (r *ArchiveSearchResult) Text(w io.Writer) error {
+	t := theme.Current()
+
+	if r.jump {
+		if len(r.Records) == 0 {
+			fmt.Fprintf(w, "%sNo archived output found%s\n", colorize(t.Warning), "\033[0m")
+			return nil
+		}
+		writeSearchRecords(w, r.Records, r.target, t)
+		return nil
+	}
+
+	if len(r.Hits) == 0 {
+		fmt.Fprintf(w, "%sNo matches found%s for '%s'\n", colorize(t.Warning), "\033[0m", r.Query)
+		return nil
+	}
+
+	fmt.Fprintf(w, "%sSearch Results (%d)%s\n", "\033[1m", len(r.Hits), "\033[0m")
+	fmt.Fprintf(w, "%s%s%s\n\n", "\033[2m", strings.Repeat("─", 60), "\033[0m")
+
+	for _, h := range r.Hits {
+		fmt.Fprintf(w, "  %s%s/%s%s (%s) • %s • %s • score %.2f • #%d\n",
+			colorize(t.Primary), h.Session, h.Pane, "\033[0m", h.Agent,
+			h.Timestamp.Local().Format("2006-01-02 15:04:05"), formatAge(h.Timestamp), h.Score, h.ID)
+		if ctx, ok := r.Context[h.ID]; ok {
+			writeSearchRecords(w, ctx, h.ID, t)
+		} else {
+			fmt.Fprintf(w, "    %s\n", renderHighlights(strings.Join(strings.Fields(h.Snippet), " "), t))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "%sUse 'ntm search --index --around <id>' to see surrounding output%s\n", "\033[2m", "\033[0m")
+	return nil
+}
+
+// writeSearchRecords prints archived records in order, marking the hit.
+func writeSearchRecords(w io.Writer, recs []search.Record, hitID int64, t theme.Theme) {
+	for _, rec := range recs {
+		marker := " "
+		color := colorize(t.Surface1)
+		if rec.ID == hitID {
+			marker = ">"
+			color = colorize(t.Blue)
+		}
+		fmt.Fprintf(w, "  %s%s %s %s/%s #%d%s\n", color, marker,
+			rec.Timestamp.Local().Format("15:04:05"), rec.Session, rec.Pane, rec.ID, "\033[0m")
+		for _, line := range strings.Split(strings.TrimRight(rec.Content, "\n"), "\n") {
+			fmt.Fprintf(w, "      %s\n", line)
+		}
+	}
+}
+
+// renderHighlights turns snippet highlight markers into terminal colors.
+func renderHighlights(snippet string, t theme.Theme) string {
+	snippet = strings.ReplaceAll(snippet, search.HighlightStart, "\033[1m"+colorize(t.Warning))
+	return strings.ReplaceAll(snippet, search.HighlightEnd, "\033[0m")
+}
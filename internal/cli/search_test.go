@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/search"
+)
+
+func TestParseSearchTime(t *testing.T) {
+	got, err := parseSearchTime("2026-03-01T14:05:00Z")
+	if err != nil || !got.Equal(time.Date(2026, 3, 1, 14, 5, 0, 0, time.UTC)) {
+		t.Errorf("parseSearchTime(RFC3339) = %v, %v", got, err)
+	}
+
+	got, err = parseSearchTime("2d")
+	if err != nil {
+		t.Fatalf("parseSearchTime(2d) error: %v", err)
+	}
+	if age := time.Since(got); age < 47*time.Hour || age > 49*time.Hour {
+		t.Errorf("parseSearchTime(2d) age = %v", age)
+	}
+
+	if _, err := parseSearchTime("yesterday"); err == nil {
+		t.Error("expected error for unparseable time")
+	}
+}
+
+func TestSearchCmdArchiveFlags(t *testing.T) {
+	cmd := newSearchCmd()
+	for _, name := range []string{"index", "pane", "until", "context", "at", "around", "no-sync"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("Flag %q not found", name)
+		}
+	}
+
+	// --around and --at replace the query argument, but only with --index.
+	cmd.Flags().Set("around", "12")
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("--around without --index or query accepted")
+	}
+	cmd.Flags().Set("index", "true")
+	if err := cmd.Args(cmd, []string{}); err != nil {
+		t.Errorf("--index --around without query rejected: %v", err)
+	}
+	if err := cmd.Args(cmd, []string{"q"}); err == nil {
+		t.Error("--index --around with query accepted")
+	}
+}
+
+func TestSearchCmdIndexOnlyFlagsRequireIndex(t *testing.T) {
+	cmd := newSearchCmd()
+	cmd.SetArgs([]string{"q", "--pane", "cc_1"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--pane requires --index") {
+		t.Errorf("Execute() error = %v, want --pane requires --index", err)
+	}
+}
+
+func TestArchiveSearchResultText(t *testing.T) {
+	ts := time.Date(2026, 3, 1, 14, 5, 0, 0, time.UTC)
+	r := &ArchiveSearchResult{
+		Query: "nil pointer",
+		Hits: []search.Hit{{
+			ID: 7, Session: "proj", Pane: "cod_2", Agent: "cod", Timestamp: ts,
+			Snippet: "panic: " + search.HighlightStart + "nil" + search.HighlightEnd + "\n  pointer",
+		}},
+	}
+	var buf bytes.Buffer
+	if err := r.Text(&buf); err != nil {
+		t.Fatalf("Text() error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "proj/cod_2") || !strings.Contains(out, "#7") {
+		t.Errorf("hit header missing: %q", out)
+	}
+	if strings.Contains(out, search.HighlightStart) || !strings.Contains(out, "nil\033[0m pointer") {
+		t.Errorf("snippet not rendered: %q", out)
+	}
+
+	r = &ArchiveSearchResult{jump: true, target: 8, Records: []search.Record{
+		{ID: 7, Session: "proj", Pane: "cod_2", Timestamp: ts, Content: "before"},
+		{ID: 8, Session: "proj", Pane: "cod_2", Timestamp: ts, Content: "line one\nline two\n"},
+	}}
+	buf.Reset()
+	r.Text(&buf)
+	out = buf.String()
+	if !strings.Contains(out, "> ") || !strings.Contains(out, "      line two\n") {
+		t.Errorf("jump output = %q", out)
+	}
+}
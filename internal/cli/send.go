@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/signal"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -20,19 +21,24 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/Dicklesworthstone/ntm/internal/adapter"
 	"github.com/Dicklesworthstone/ntm/internal/audit"
+	"github.com/Dicklesworthstone/ntm/internal/budget"
 	"github.com/Dicklesworthstone/ntm/internal/bv"
 	"github.com/Dicklesworthstone/ntm/internal/config"
 	"github.com/Dicklesworthstone/ntm/internal/cass"
 	"github.com/Dicklesworthstone/ntm/internal/checkpoint"
+	"github.com/Dicklesworthstone/ntm/internal/delivery"
 	"github.com/Dicklesworthstone/ntm/internal/events"
 	"github.com/Dicklesworthstone/ntm/internal/history"
 	"github.com/Dicklesworthstone/ntm/internal/hooks"
+	"github.com/Dicklesworthstone/ntm/internal/inbox"
 	"github.com/Dicklesworthstone/ntm/internal/integrations/dcg"
 	"github.com/Dicklesworthstone/ntm/internal/kernel"
 	"github.com/Dicklesworthstone/ntm/internal/output"
 	"github.com/Dicklesworthstone/ntm/internal/prompt"
 	"github.com/Dicklesworthstone/ntm/internal/redaction"
+	"github.com/Dicklesworthstone/ntm/internal/reply"
 	"github.com/Dicklesworthstone/ntm/internal/robot"
 	sessionPkg "github.com/Dicklesworthstone/ntm/internal/session"
 	"github.com/Dicklesworthstone/ntm/internal/state"
@@ -46,20 +52,23 @@ import (
 
 // SendResult is the JSON output for the send command.
 type SendResult struct {
-	Success       bool               `json:"success"`
-	Session       string             `json:"session"`
-	PromptPreview string             `json:"prompt_preview,omitempty"`
-	Redaction     *RedactionSummary  `json:"redaction,omitempty"`
-	Warnings      []string           `json:"warnings,omitempty"`
-	Blocked       bool               `json:"blocked,omitempty"`
-	ErrorCode     string             `json:"error_code,omitempty"`
-	Randomized    bool               `json:"randomized,omitempty"`
-	SeedUsed      int64              `json:"seed_used,omitempty"`
-	Targets       []int              `json:"targets"`
-	Delivered     int                `json:"delivered"`
-	Failed        int                `json:"failed"`
-	RoutedTo      *SendRoutingResult `json:"routed_to,omitempty"`
-	Error         string             `json:"error,omitempty"`
+	Success       bool                 `json:"success"`
+	Session       string               `json:"session"`
+	PromptPreview string               `json:"prompt_preview,omitempty"`
+	Redaction     *RedactionSummary    `json:"redaction,omitempty"`
+	Warnings      []string             `json:"warnings,omitempty"`
+	Blocked       bool                 `json:"blocked,omitempty"`
+	ErrorCode     string               `json:"error_code,omitempty"`
+	Randomized    bool                 `json:"randomized,omitempty"`
+	SeedUsed      int64                `json:"seed_used,omitempty"`
+	Targets       []int                `json:"targets"`
+	Delivered     int                  `json:"delivered"`
+	Failed        int                  `json:"failed"`
+	RoutedTo      *SendRoutingResult   `json:"routed_to,omitempty"`
+	Deliveries    []delivery.Result    `json:"deliveries,omitempty"`
+	Queued        []state.QueuedPrompt `json:"queued,omitempty"`
+	HistoryID     string               `json:"history_id,omitempty"` // For ntm responses
+	Error         string               `json:"error,omitempty"`
 }
 
 type SendDryRunEntry struct {
@@ -223,9 +232,11 @@ type SendOptions struct {
 	TemplateName   string
 	Tags           []string
 	DryRun         bool
-	Randomize      bool  // Randomize send order for individualized prompts
-	Seed           int64 // Deterministic seed (only used when Randomize=true)
-	PriorityOrder  bool  // Sort batch prompts by priority (P0 first)
+	Randomize      bool   // Randomize send order for individualized prompts
+	Seed           int64  // Deterministic seed (only used when Randomize=true)
+	PriorityOrder  bool   // Sort batch prompts by priority (P0 first)
+	Delivery       string // "keys" or "paste" (default from config)
+	Queue          bool   // Queue for delivery when each agent is idle
 
 	// Smart routing options
 	SmartRoute    bool   // Use smart routing to select best agent
@@ -465,6 +476,8 @@ func newSendCmd() *cobra.Command {
 	var templateVars []string
 	var tags []string
 	var dryRun bool
+	var deliveryMethod string
+	var queue bool
 	var cassCheck bool
 	var noCassCheck bool
 	var cassSimilarity float64
@@ -547,7 +560,8 @@ func newSendCmd() *cobra.Command {
 		  ntm send myproject -t code_review --file src/main.go  # Template with file
 		  ntm send myproject -t fix --var issue="null pointer" --file src/app.go  # Template with vars
 		  ntm send myproject --smart "fix auth bug"             # Auto-select best agent
-		  ntm send myproject --smart --route=affinity "auth"    # Use affinity strategy`,
+		  ntm send myproject --smart --route=affinity "auth"    # Use affinity strategy
+		  ntm send myproject --pane=2 --queue "next task"       # Deliver when pane 2 is idle`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Handle --project mode: broadcast to all matching sessions (bd-3cu02.14)
@@ -566,6 +580,9 @@ func newSendCmd() *cobra.Command {
 				return fmt.Errorf("session name required (or use --project)")
 			}
 			session := args[0]
+			if err := delivery.CheckMethod(deliveryMethod); err != nil {
+				return fmt.Errorf("--delivery: %w", err)
+			}
 
 			// Resolve base prompt: flag > file > config (bd-3ejl)
 			var cfgBasePrompt, cfgBasePromptFile string
@@ -620,6 +637,8 @@ func newSendCmd() *cobra.Command {
 					Randomize:       randomize,
 					Seed:            seed,
 					PriorityOrder:   priorityOrder,
+					Delivery:        deliveryMethod,
+					Queue:           queue,
 				}
 				return runSendBatch(batchOpts)
 			}
@@ -656,6 +675,8 @@ func newSendCmd() *cobra.Command {
 				DryRun:         dryRun,
 				Randomize:      randomize,
 				Seed:           seed,
+				Delivery:       deliveryMethod,
+				Queue:          queue,
 			}
 
 			// Handle template-based prompts
@@ -730,6 +751,8 @@ func newSendCmd() *cobra.Command {
 	cmd.Flags().IntVar(&cassCheckDays, "cass-check-days", 7, "Look back N days for duplicates")
 	cmd.Flags().BoolVar(&noHooks, "no-hooks", false, "Disable command hooks")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview what would be sent without sending")
+	cmd.Flags().StringVar(&deliveryMethod, "delivery", "", "How prompts reach agents: keys (send-keys) or paste (paste buffer, verified); default from [send] delivery")
+	cmd.Flags().BoolVar(&queue, "queue", false, "Queue the prompt and deliver it when each agent is idle (see 'ntm queue')")
 
 	// Randomization flags
 	cmd.Flags().BoolVar(&randomize, "randomize", false, "Randomize send order for individualized prompts (reduces thundering herd)")
@@ -1090,6 +1113,9 @@ func runSendInternal(opts SendOptions) (err error) {
 	delivered := 0
 	failed := 0
 	seedUsed := int64(0)
+	var deliveries []delivery.Result
+	historyID := history.NewID()
+	var replyMarks []reply.Mark
 
 	// Audit: send command start (redacted preview only)
 	_ = audit.LogEvent(session, audit.EventTypeSend, audit.ActorUser, "send", map[string]interface{}{
@@ -1131,14 +1157,17 @@ func runSendInternal(opts SendOptions) (err error) {
 			return
 		}
 		entry := history.NewEntry(session, intsToStrings(histTargets), prompt, history.SourceCLI)
+		entry.ID = historyID
 		entry.Template = templateName
 		entry.DurationMs = int(time.Since(start) / time.Millisecond)
+		entry.Delivery = deliveries
 		if histSuccess {
 			entry.SetSuccess()
 		} else {
 			entry.SetError(histErr)
 		}
 		_ = history.Append(entry)
+		recordReplies(historyID, session, prompt, replyMarks)
 
 		// Also persist to session-specific storage for restart resilience
 		promptEntry := sessionPkg.PromptEntry{
@@ -1155,8 +1184,11 @@ func runSendInternal(opts SendOptions) (err error) {
 		histErr = err
 		if jsonOutput {
 			code := ""
+			var exceeded *budget.ExceededError
 			if redactionBlocked {
 				code = "SENSITIVE_DATA_BLOCKED"
+			} else if errors.As(err, &exceeded) {
+				code = "BUDGET_EXCEEDED"
 			}
 			result := SendResult{
 				Success: false,
@@ -1185,6 +1217,12 @@ func runSendInternal(opts SendOptions) (err error) {
 		return outputError(redactionBlockedError{summary: *redactionSummary})
 	}
 
+	if !dryRun {
+		if err := checkBudget(session, "send"); err != nil {
+			return outputError(err)
+		}
+	}
+
 	// Smart routing: select best agent automatically.
 	// Explicit pane selection (--pane/--panes) wins over automatic routing.
 	if opts.SmartRoute && (opts.PanesSpecified || paneIndex >= 0) {
@@ -1509,10 +1547,40 @@ func runSendInternal(opts SendOptions) (err error) {
 		})
 	}
 
+	// Queued prompts are delivered later by the queue dispatcher
+	if opts.Queue {
+		if len(selectedPanes) == 0 {
+			return outputError(errors.New("no matching panes found"))
+		}
+		queued, err := enqueueSend(session, selectedPanes, prompt)
+		if err != nil {
+			return outputError(err)
+		}
+		histSuccess = true
+		if jsonOutput {
+			return json.NewEncoder(os.Stdout).Encode(SendResult{
+				Success:       true,
+				Session:       session,
+				PromptPreview: truncatePrompt(prompt, 50),
+				Redaction:     redactionSummary,
+				Warnings:      redactionWarnings,
+				Targets:       targetPanes,
+				RoutedTo:      opts.routingResult,
+				Queued:        queued,
+			})
+		}
+		for _, q := range queued {
+			fmt.Printf("Queued %s for pane %d (position %d)\n", q.ID, q.Pane, q.Position)
+		}
+		return nil
+	}
+
 	// If specific pane requested
 	if paneIndex >= 0 {
 		p := selectedPanes[0]
-		if err := sendPromptToPane(session, p, prompt); err != nil {
+		res := deliverPromptWithReply(session, p, prompt, opts.Delivery, &replyMarks)
+		deliveries = append(deliveries, res)
+		if err := deliveryError(res); err != nil {
 			failed++
 			histErr = err
 			if jsonOutput {
@@ -1528,6 +1596,7 @@ func runSendInternal(opts SendOptions) (err error) {
 					Delivered:     delivered,
 					Failed:        failed,
 					RoutedTo:      opts.routingResult,
+					Deliveries:    deliveries,
 					Error:         err.Error(),
 				}
 				return json.NewEncoder(os.Stdout).Encode(result)
@@ -1550,6 +1619,8 @@ func runSendInternal(opts SendOptions) (err error) {
 				Delivered:     delivered,
 				Failed:        failed,
 				RoutedTo:      opts.routingResult,
+				Deliveries:    deliveries,
+				HistoryID:     historyID,
 			}
 			return json.NewEncoder(os.Stdout).Encode(result)
 		}
@@ -1564,7 +1635,9 @@ func runSendInternal(opts SendOptions) (err error) {
 	}
 
 	for _, p := range selectedPanes {
-		if err := sendPromptToPane(session, p, prompt); err != nil {
+		res := deliverPromptWithReply(session, p, prompt, opts.Delivery, &replyMarks)
+		deliveries = append(deliveries, res)
+		if err := deliveryError(res); err != nil {
 			failed++
 			histErr = err
 			if !jsonOutput {
@@ -1624,6 +1697,7 @@ func runSendInternal(opts SendOptions) (err error) {
 			Delivered:     delivered,
 			Failed:        failed,
 			RoutedTo:      opts.routingResult,
+			HistoryID:     historyID,
 		}
 		if failed > 0 {
 			result.Error = fmt.Sprintf("%d pane(s) failed", failed)
@@ -1647,7 +1721,11 @@ func runSendInternal(opts SendOptions) (err error) {
 		}
 		// Show "What's next?" suggestions only on complete success
 		if failed == 0 {
-			output.SuccessFooter(output.SendSuggestions(session)...)
+			suggestions := output.SendSuggestions(session)
+			if len(replyMarks) > 0 {
+				suggestions = append(suggestions, output.Suggestion{Command: "ntm responses " + historyID + " --wait", Description: "Read the agents' replies"})
+			}
+			output.SuccessFooter(suggestions...)
 		}
 	}
 
@@ -2633,11 +2711,6 @@ func looksLikeShellCommand(line string) bool {
 	return false
 }
 
-const (
-	agentPromptFirstEnterDelay  = 1 * time.Second
-	agentPromptSecondEnterDelay = 500 * time.Millisecond
-)
-
 func sendPromptToPane(session string, p tmux.Pane, prompt string) error {
 	if p.Type == tmux.AgentUser {
 		if err := tmux.PasteKeys(p.ID, prompt, true); err != nil {
@@ -2652,26 +2725,105 @@ func sendPromptToPane(session string, p tmux.Pane, prompt string) error {
 	return nil
 }
 
+// enqueueSend adds prompt to the prompt queue of each pane.
+func enqueueSend(session string, panes []tmux.Pane, prompt string) ([]state.QueuedPrompt, error) {
+	store, err := inbox.OpenStore()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	queued := make([]state.QueuedPrompt, 0, len(panes))
+	for _, p := range panes {
+		q, err := inbox.Enqueue(store, session, p.Index, prompt, inbox.SourceSend)
+		if err != nil {
+			return queued, fmt.Errorf("queue for pane %d: %w", p.Index, err)
+		}
+		queued = append(queued, *q)
+	}
+	return queued, nil
+}
+
+// deliverPromptToPane sends prompt to p by the given delivery method, or
+// the configured one if method is empty, and reports the outcome. Paste
+// delivery waits for the agent to accept the prompt; user panes are always
+// typed into.
+func deliverPromptToPane(session string, p tmux.Pane, prompt, method string) delivery.Result {
+	var attempts int
+	if cfg != nil {
+		if method == "" {
+			method = cfg.Send.Delivery
+		}
+		attempts = cfg.Send.DeliveryAttempts
+	}
+	if err := delivery.CheckMethod(method); err != nil {
+		return delivery.Result{Pane: strconv.Itoa(p.Index), Method: method, Outcome: delivery.OutcomeFailed, Error: err.Error()}
+	}
+	if method != delivery.MethodPaste || p.Type == tmux.AgentUser {
+		res := delivery.Result{Pane: strconv.Itoa(p.Index), Method: delivery.MethodKeys, Outcome: delivery.OutcomeSent}
+		if err := sendPromptToPane(session, p, prompt); err != nil {
+			res.Outcome, res.Error = delivery.OutcomeFailed, err.Error()
+		}
+		return res
+	}
+
+	res := delivery.ToPane(context.Background(), p.ID, p.Type, prompt, delivery.Options{Attempts: attempts})
+	res.Pane = strconv.Itoa(p.Index)
+	if res.Outcome == delivery.OutcomeDelivered {
+		addTimelinePromptMarker(session, p, prompt)
+	}
+	return res
+}
+
+// deliverPromptWithReply delivers prompt like deliverPromptToPane and, when
+// it reaches an agent pane, adds a mark for capturing the agent's reply.
+func deliverPromptWithReply(session string, p tmux.Pane, prompt, method string, marks *[]reply.Mark) delivery.Result {
+	mark, markErr := reply.MarkPane(p)
+	res := deliverPromptToPane(session, p, prompt, method)
+	if res.OK() && markErr == nil && p.Type != tmux.AgentUser {
+		*marks = append(*marks, mark)
+	}
+	return res
+}
+
+// recordReplies stores pending responses for the panes a prompt reached, so
+// that ntm serve or ntm responses can capture the replies later.
+func recordReplies(historyID, session, prompt string, marks []reply.Mark) {
+	if len(marks) == 0 {
+		return
+	}
+	store, err := state.Open("")
+	if err != nil {
+		return
+	}
+	defer store.Close()
+	if err := store.Migrate(); err != nil {
+		return
+	}
+	_, _ = reply.Record(store, historyID, session, prompt, marks)
+}
+
+// deliveryError returns an error describing res if the prompt did not reach
+// its pane.
+func deliveryError(res delivery.Result) error {
+	if res.OK() {
+		return nil
+	}
+	if res.Outcome == delivery.OutcomeUndelivered {
+		return fmt.Errorf("prompt undelivered to pane %s: %s", res.Pane, res.Error)
+	}
+	return errors.New(res.Error)
+}
+
 func sendPromptWithDoubleEnter(paneID, prompt string) error {
 	// Default to AgentUnknown for backward compatibility
 	return sendPromptWithDoubleEnterForAgent(paneID, prompt, tmux.AgentUnknown)
 }
 
+// sendPromptWithDoubleEnterForAgent types prompt and submits it the way the
+// agent's adapter does, which for most agents is two Enter presses.
 func sendPromptWithDoubleEnterForAgent(paneID, prompt string, agentType tmux.AgentType) error {
-	// Use agent-aware send method which handles Gemini's multi-line quirks
-	// by using buffer-based paste instead of send-keys when content has newlines
-	if err := tmux.SendKeysForAgent(paneID, prompt, false, agentType); err != nil {
-		return err
-	}
-	time.Sleep(agentPromptFirstEnterDelay)
-	if err := tmux.SendKeys(paneID, "", true); err != nil {
-		return err
-	}
-	time.Sleep(agentPromptSecondEnterDelay)
-	if err := tmux.SendKeys(paneID, "", true); err != nil {
-		return err
-	}
-	return nil
+	return adapter.Get(agentType).SendPrompt(paneID, prompt)
 }
 
 func addTimelinePromptMarker(session string, p tmux.Pane, prompt string) {
@@ -3417,6 +3569,10 @@ func runSendBatch(opts SendOptions) error {
 		})
 	}
 
+	if err := checkBudget(opts.Session, "send"); err != nil {
+		return err
+	}
+
 	// Set up signal handling for graceful Ctrl+C
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -3526,7 +3682,17 @@ func runSendBatch(opts SendOptions) error {
 				sendErr = fmt.Errorf("pane %d not found", paneIdx)
 				continue
 			}
-			if err := sendPromptToPane(opts.Session, p, promptText); err != nil {
+			if opts.Queue {
+				_, err := enqueueSend(opts.Session, []tmux.Pane{p}, promptText)
+				if err != nil {
+					paneFailed++
+					sendErr = err
+				} else {
+					paneDelivered++
+				}
+				continue
+			}
+			if err := deliveryError(deliverPromptToPane(opts.Session, p, promptText, opts.Delivery)); err != nil {
 				paneFailed++
 				sendErr = err
 			} else {
@@ -11,9 +11,12 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/Dicklesworthstone/ntm/internal/config"
 	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/reply"
 	"github.com/Dicklesworthstone/ntm/internal/serve"
 	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
 func newServeCmd() *cobra.Command {
@@ -39,6 +42,10 @@ API Endpoints:
   GET /events                Server-Sent Events stream
   GET /health                Health check
 
+While running, the server also delivers prompts queued with 'ntm send --queue'
+to agents as they become idle (see 'ntm queue'), and captures each agent's
+reply to prompts sent with 'ntm send' (see 'ntm responses').
+
 Examples:
   ntm serve                              # Start on 127.0.0.1:7337
   ntm serve --port 8080                  # Start on custom port
@@ -104,13 +111,24 @@ func runServe(opts serveOptions) error {
 	if err != nil {
 		return err
 	}
+	var mergeQueue config.MergeQueueConfig
+	controlMode := false
+	if cfg != nil {
+		mergeQueue = cfg.MergeQueue
+		controlMode = cfg.Tmux.ControlMode
+	}
 	cfg := serve.Config{
-		Host:           opts.Host,
-		Port:           opts.Port,
-		PublicBaseURL:  opts.PublicBaseURL,
-		EventBus:       events.DefaultBus,
-		StateStore:     stateStore,
-		AllowedOrigins: opts.CORSAllowOrigins,
+		Host:                opts.Host,
+		Port:                opts.Port,
+		PublicBaseURL:       opts.PublicBaseURL,
+		EventBus:            events.DefaultBus,
+		StateStore:          stateStore,
+		AllowedOrigins:      opts.CORSAllowOrigins,
+		PipelineBudgetCheck: pipelineBudgetCheck,
+		PromptQueue:         newQueueDispatcher(stateStore),
+		ResponseCapture:     reply.NewCapturer(stateStore),
+		MergeQueue:          mergeQueue,
+		TmuxControlMode:     controlMode,
 		Auth: serve.AuthConfig{
 			Mode:   mode,
 			APIKey: opts.APIKey,
@@ -131,6 +149,7 @@ func runServe(opts serveOptions) error {
 	}
 	// Create server with default event bus
 	srv := serve.New(cfg)
+	defer tmux.DefaultClient.Close()
 
 	// Setup signal handling for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
 	"sort"
 	"strings"
 	"syscall"
@@ -47,6 +49,7 @@ type statusOptions struct {
 type SessionListInput struct {
 	Tags    []string `json:"tags,omitempty"`
 	Project string   `json:"project,omitempty"` // Filter by base project name (bd-3cu02.14)
+	Local   bool     `json:"local,omitempty"`   // Skip the configured remote hosts
 }
 
 // SessionStatusInput is the kernel input for sessions.status.
@@ -122,7 +125,7 @@ func init() {
 				opts = *value
 			}
 		}
-		return buildSessionListResponse(opts.Tags, opts.Project)
+		return buildSessionListResponse(opts.Tags, opts.Project, opts.Local)
 	})
 
 	kernel.MustRegister(kernel.Command{
@@ -270,7 +273,7 @@ Examples:
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				// No session specified, list sessions
-				return runList(nil)
+				return runList(SessionListInput{})
 			}
 			return runAttach(args[0])
 		},
@@ -282,6 +285,11 @@ Examples:
 }
 
 func runAttach(session string) error {
+	session, err := useHostSession(session)
+	if err != nil {
+		return err
+	}
+
 	if IsJSONOutput() {
 		result, err := kernel.Run(context.Background(), "sessions.attach", SessionAttachInput{Session: session})
 		if err != nil {
@@ -323,7 +331,7 @@ func runAttach(session string) error {
 
 	fmt.Printf("Session '%s' does not exist.\n\n", session)
 	fmt.Println("Available sessions:")
-	if err := runList(nil); err != nil {
+	if err := runList(SessionListInput{}); err != nil {
 		return err
 	}
 	fmt.Println()
@@ -338,24 +346,27 @@ func runAttach(session string) error {
 func newListCmd() *cobra.Command {
 	var tags []string
 	var project string
+	var local bool
 	cmd := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls", "l"},
 		Short:   "List all tmux sessions",
+		Long: `List tmux sessions with their agents.
+
+Sessions on every host in the [hosts] config inventory are listed too, with
+the host they run on. Pass --local to list only this machine's sessions, or
+--host to list one host's.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runList(tags, project)
+			return runList(SessionListInput{Tags: tags, Project: project, Local: local})
 		},
 	}
 	cmd.Flags().StringSliceVar(&tags, "tag", nil, "filter sessions by agent tag (shows session if any agent matches)")
 	cmd.Flags().StringVarP(&project, "project", "p", "", "filter by base project name (shows all labeled sessions for the project)")
+	cmd.Flags().BoolVar(&local, "local", false, "skip sessions on configured remote hosts")
 	return cmd
 }
 
-func runList(tags []string, project ...string) error {
-	input := SessionListInput{Tags: tags}
-	if len(project) > 0 {
-		input.Project = project[0]
-	}
+func runList(input SessionListInput) error {
 	result, err := kernel.Run(context.Background(), "sessions.list", input)
 	if err != nil {
 		if IsJSONOutput() {
@@ -374,6 +385,9 @@ func runList(tags []string, project ...string) error {
 	}
 
 	// Text output
+	for _, host := range slices.Sorted(maps.Keys(resp.HostErrors)) {
+		output.PrintWarningf("host %s unreachable: %s", host, resp.HostErrors[host])
+	}
 	if len(resp.Sessions) == 0 {
 		fmt.Println("No tmux sessions running")
 		return nil
@@ -383,21 +397,24 @@ func runList(tags []string, project ...string) error {
 	width, _, _ := term.GetSize(int(os.Stdout.Fd()))
 	isWide := width >= 100
 
-	// Check if any sessions have labels (bd-3cu02.6)
+	// Check if any sessions have labels (bd-3cu02.6) or run on remote hosts
 	hasLabels := false
+	hasHosts := false
 	for _, s := range resp.Sessions {
-		if s.Label != "" {
-			hasLabels = true
-			break
-		}
+		hasLabels = hasLabels || s.Label != ""
+		hasHosts = hasHosts || s.Host != ""
 	}
 
 	if isWide {
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		hostHeader := ""
+		if hasHosts {
+			hostHeader = "HOST\t"
+		}
 		if hasLabels {
-			fmt.Fprintln(w, "SESSION\tPROJECT\tLABEL\tWINDOWS\tSTATE\tAGENTS")
+			fmt.Fprintln(w, hostHeader+"SESSION\tPROJECT\tLABEL\tWINDOWS\tSTATE\tAGENTS")
 		} else {
-			fmt.Fprintln(w, "SESSION\tWINDOWS\tSTATE\tAGENTS")
+			fmt.Fprintln(w, hostHeader+"SESSION\tWINDOWS\tSTATE\tAGENTS")
 		}
 
 		for _, s := range resp.Sessions {
@@ -436,6 +453,13 @@ func runList(tags []string, project ...string) error {
 				}
 			}
 
+			if hasHosts {
+				host := s.Host
+				if host == "" {
+					host = "local"
+				}
+				fmt.Fprintf(w, "%s\t", host)
+			}
 			if hasLabels {
 				labelDisplay := "-"
 				if s.Label != "" {
@@ -454,7 +478,11 @@ func runList(tags []string, project ...string) error {
 			if s.Attached {
 				attached = " (attached)"
 			}
-			fmt.Printf("  %s: %d windows%s\n", s.Name, s.Windows, attached)
+			name := s.Name
+			if s.Host != "" {
+				name += "@" + s.Host
+			}
+			fmt.Printf("  %s: %d windows%s\n", name, s.Windows, attached)
 		}
 	}
 
@@ -475,20 +503,55 @@ func coerceSessionListResponse(result any) (output.ListResponse, error) {
 	}
 }
 
-func buildSessionListResponse(tags []string, project string) (output.ListResponse, error) {
+func buildSessionListResponse(tags []string, project string, local bool) (output.ListResponse, error) {
 	if err := tmux.EnsureInstalled(); err != nil {
 		return output.ListResponse{}, err
 	}
 
-	sessions, err := tmux.ListSessions()
-	if err != nil {
-		return output.ListResponse{}, err
+	var hosts []hostSessions
+	if local {
+		sessions, err := tmux.DefaultClient.ListSessions()
+		hosts = []hostSessions{{Host: tmux.DefaultClient.Host, Client: tmux.DefaultClient, Sessions: sessions, Err: err}}
+	} else {
+		hosts = listSessionsOnHosts()
 	}
 
+	resp := output.ListResponse{TimestampedResponse: output.NewTimestamped(), Sessions: []output.SessionListItem{}}
+	for i, h := range hosts {
+		var items []output.SessionListItem
+		var err error
+		if h.Err == nil {
+			items, err = buildHostSessionList(h.Client, h.Sessions, tags, project)
+		} else {
+			err = h.Err
+		}
+		if err != nil {
+			// The default client's failure is fatal; a remote host's is reported.
+			if i == 0 {
+				return output.ListResponse{}, err
+			}
+			if resp.HostErrors == nil {
+				resp.HostErrors = make(map[string]string)
+			}
+			resp.HostErrors[h.Host] = strings.TrimSpace(err.Error())
+			continue
+		}
+		for j := range items {
+			items[j].Host = h.Host
+		}
+		resp.Sessions = append(resp.Sessions, items...)
+	}
+	resp.Count = len(resp.Sessions)
+	return resp, nil
+}
+
+// buildHostSessionList builds the list items for the sessions of one tmux
+// server.
+func buildHostSessionList(client *tmux.Client, sessions []tmux.Session, tags []string, project string) ([]output.SessionListItem, error) {
 	// Optimization: fetch all panes once
-	allPanes, err := tmux.GetAllPanes()
+	allPanes, err := client.GetAllPanesContext(context.Background())
 	if err != nil {
-		return output.ListResponse{}, err
+		return nil, err
 	}
 
 	// Filter sessions by tag
@@ -587,11 +650,7 @@ func buildSessionListResponse(tags []string, project string) (output.ListRespons
 		return items[i].Label < items[j].Label
 	})
 
-	return output.ListResponse{
-		TimestampedResponse: output.NewTimestamped(),
-		Sessions:            items,
-		Count:               len(sessions),
-	}, nil
+	return items, nil
 }
 
 func coerceStatusResponse(result any) (output.StatusResponse, error) {
@@ -29,6 +29,9 @@ type SessionProfile struct {
 	InitFile  string `toml:"init_file,omitempty" json:"init_file,omitempty"`
 	Safety    *bool  `toml:"safety,omitempty" json:"safety,omitempty"`
 	Worktrees *bool  `toml:"worktrees,omitempty" json:"worktrees,omitempty"`
+	// Commands replaces the configured launch command per agent type,
+	// e.g. {"cc": "ntm dev fake-agent --agent cc"}.
+	Commands map[string]string `toml:"commands,omitempty" json:"commands,omitempty"`
 }
 
 var validProfileName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
@@ -145,6 +148,7 @@ func newSessionProfileSaveCmd() *cobra.Command {
 		aiderCount               int
 		userPane, safety, wt     bool
 		prompt, initFile         string
+		commands                 map[string]string
 	)
 
 	cmd := &cobra.Command{
@@ -182,6 +186,9 @@ func newSessionProfileSaveCmd() *cobra.Command {
 			if wt {
 				cfg.Worktrees = &wt
 			}
+			if len(commands) > 0 {
+				cfg.Commands = commands
+			}
 			if err := SaveSessionProfile(name, cfg); err != nil {
 				return err
 			}
@@ -201,6 +208,7 @@ func newSessionProfileSaveCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&wt, "worktrees", false, "Enable git worktree isolation")
 	cmd.Flags().StringVar(&prompt, "prompt", "", "Default prompt text")
 	cmd.Flags().StringVar(&initFile, "init-file", "", "Path to init prompt file")
+	cmd.Flags().StringToStringVar(&commands, "command", nil, "Launch command for an agent type, e.g. cc=\"ntm dev fake-agent --agent cc\" (repeatable)")
 
 	return cmd
 }
@@ -304,23 +312,35 @@ func printSessionProfileShow(name string) error {
 // ApplySessionProfileToSpawnOptions merges a loaded profile into SpawnOptions.
 // Explicit flags override profile values (non-zero values in opts win).
 func ApplySessionProfileToSpawnOptions(opts *SpawnOptions, profile *SessionProfile) {
+	// Agents are launched from opts.Agents, so profile counts add entries too.
+	addAgents := func(agentType AgentType, count int) {
+		for i := 1; i <= count; i++ {
+			opts.Agents = append(opts.Agents, FlatAgent{Type: agentType, Index: i})
+		}
+	}
 	if opts.CCCount == 0 && profile.CC > 0 {
 		opts.CCCount = profile.CC
+		addAgents(AgentTypeClaude, profile.CC)
 	}
 	if opts.CodCount == 0 && profile.Cod > 0 {
 		opts.CodCount = profile.Cod
+		addAgents(AgentTypeCodex, profile.Cod)
 	}
 	if opts.GmiCount == 0 && profile.Gmi > 0 {
 		opts.GmiCount = profile.Gmi
+		addAgents(AgentTypeGemini, profile.Gmi)
 	}
 	if opts.CursorCount == 0 && profile.Cursor > 0 {
 		opts.CursorCount = profile.Cursor
+		addAgents(AgentTypeCursor, profile.Cursor)
 	}
 	if opts.WindsurfCount == 0 && profile.Windsurf > 0 {
 		opts.WindsurfCount = profile.Windsurf
+		addAgents(AgentTypeWindsurf, profile.Windsurf)
 	}
 	if opts.AiderCount == 0 && profile.Aider > 0 {
 		opts.AiderCount = profile.Aider
+		addAgents(AgentTypeAider, profile.Aider)
 	}
 	if profile.UserPane != nil && *profile.UserPane {
 		opts.UserPane = true
@@ -340,4 +360,7 @@ func ApplySessionProfileToSpawnOptions(opts *SpawnOptions, profile *SessionProfi
 	if profile.Worktrees != nil && *profile.Worktrees {
 		opts.UseWorktrees = true
 	}
+	if len(opts.AgentCommands) == 0 && len(profile.Commands) > 0 {
+		opts.AgentCommands = profile.Commands
+	}
 }
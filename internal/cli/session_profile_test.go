@@ -27,6 +27,7 @@ func TestSessionProfileCRUD(t *testing.T) {
 			Prompt:   "do stuff",
 			InitFile: "~/init.md",
 			Safety:   &tr,
+			Commands: map[string]string{"cc": "ntm dev fake-agent --agent cc"},
 		}
 
 		if err := SaveSessionProfile("mytest", cfg); err != nil {
@@ -68,6 +69,9 @@ func TestSessionProfileCRUD(t *testing.T) {
 		if loaded.Safety == nil || !*loaded.Safety {
 			t.Error("Safety: want true")
 		}
+		if loaded.Commands["cc"] != "ntm dev fake-agent --agent cc" {
+			t.Errorf("Commands: got %v", loaded.Commands)
+		}
 	})
 
 	t.Run("valid names", func(t *testing.T) {
@@ -257,6 +261,9 @@ func TestApplySessionProfileToSpawnOptions(t *testing.T) {
 		if !opts.UseWorktrees {
 			t.Error("UseWorktrees: want true")
 		}
+		if len(opts.Agents) != 9 || opts.Agents[2] != (FlatAgent{Type: AgentTypeClaude, Index: 3}) {
+			t.Errorf("Agents: want 9 launchable agents, got %v", opts.Agents)
+		}
 	})
 
 	t.Run("explicit flags override profile", func(t *testing.T) {
@@ -287,6 +294,9 @@ func TestApplySessionProfileToSpawnOptions(t *testing.T) {
 		if opts.Prompt != "from flag" {
 			t.Errorf("Prompt: want %q, got %q", "from flag", opts.Prompt)
 		}
+		if len(opts.Agents) != 0 {
+			t.Errorf("Agents: profile should not add agents for explicit counts, got %v", opts.Agents)
+		}
 	})
 
 	t.Run("nil booleans do not set opts", func(t *testing.T) {
@@ -306,6 +316,23 @@ func TestApplySessionProfileToSpawnOptions(t *testing.T) {
 		}
 	})
 
+	t.Run("agent commands from profile", func(t *testing.T) {
+		t.Parallel()
+		profile := &SessionProfile{Commands: map[string]string{"cc": "ntm dev fake-agent --agent cc"}}
+		opts := &SpawnOptions{}
+		ApplySessionProfileToSpawnOptions(opts, profile)
+
+		if opts.AgentCommands["cc"] != "ntm dev fake-agent --agent cc" {
+			t.Errorf("AgentCommands: got %v", opts.AgentCommands)
+		}
+
+		opts = &SpawnOptions{AgentCommands: map[string]string{"cod": "codex --flag"}}
+		ApplySessionProfileToSpawnOptions(opts, profile)
+		if _, ok := opts.AgentCommands["cc"]; ok {
+			t.Errorf("AgentCommands: explicit commands should win, got %v", opts.AgentCommands)
+		}
+	})
+
 	t.Run("init file loads content", func(t *testing.T) {
 		t.Parallel()
 		tmpFile := filepath.Join(t.TempDir(), "init.md")
@@ -422,6 +422,9 @@ type SpawnOptions struct {
 
 	// Goal label for multi-session support (bd-1933u)
 	Label string
+
+	// AgentCommands overrides the configured launch command per agent type
+	AgentCommands map[string]string
 }
 
 // RecoveryContext holds all the information needed to help an agent recover
@@ -1108,7 +1111,17 @@ func spawnSessionLogic(opts SpawnOptions) (err error) {
 		totalAgents = len(opts.Agents)
 	}
 
+	if err := checkBudget(opts.Session, "spawn"); err != nil {
+		return outputError(err)
+	}
+
 	dir := cfg.GetProjectDir(opts.Session)
+	if host, ok := currentHost(); ok {
+		dir = host.ProjectDir(opts.Session)
+	}
+	if tmux.DefaultClient.Remote != "" && opts.UseWorktrees {
+		return outputError(fmt.Errorf("--worktrees is not supported on remote hosts"))
+	}
 	auditStart := time.Now()
 	auditSessionCreated := false
 	auditPanesAdded := 0
@@ -1208,7 +1221,14 @@ func spawnSessionLogic(opts SpawnOptions) (err error) {
 	}
 
 	// Check if directory exists
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
+	if tmux.DefaultClient.Remote != "" {
+		// Project directories on remote hosts are created without prompting.
+		remoteDir, err := tmux.DefaultClient.MakeDir(context.Background(), dir)
+		if err != nil {
+			return outputError(fmt.Errorf("creating directory on %s: %w", tmux.DefaultClient.Remote, err))
+		}
+		dir = remoteDir
+	} else if _, err := os.Stat(dir); os.IsNotExist(err) {
 		if IsJSONOutput() {
 			// Auto-create directory without prompting in JSON mode
 			if err := os.MkdirAll(dir, 0755); err != nil {
@@ -1514,6 +1534,9 @@ func spawnSessionLogic(opts SpawnOptions) (err error) {
 				continue
 			}
 		}
+		if tmpl := opts.AgentCommands[string(agent.Type)]; tmpl != "" {
+			agentCmdTemplate = tmpl
+		}
 
 		// Configure Claude hooks for DCG and RCH integrations
 		if agent.Type == AgentTypeClaude {
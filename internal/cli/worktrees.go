@@ -1,14 +1,18 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/robot"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 	"github.com/Dicklesworthstone/ntm/internal/worktrees"
 )
@@ -29,6 +33,7 @@ directory with its own branch.`,
 		newWorktreesMergeCmd(),
 		newWorktreesCleanCmd(),
 		newWorktreesRemoveCmd(),
+		newWorktreesQueueCmd(),
 	)
 
 	return cmd
@@ -97,7 +102,10 @@ func newWorktreesMergeCmd() *cobra.Command {
 		Long: `Merge changes from an agent's worktree branch back to the main branch.
 
 This will switch to the main branch and merge the agent's branch using
-a non-fast-forward merge to preserve the merge history.`,
+a non-fast-forward merge to preserve the merge history.
+
+To integrate agent branches without touching your checkout, with a
+verification command gating each one, use 'ntm worktrees queue'.`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			agentName := args[0]
@@ -257,3 +265,152 @@ in the worktree.`,
 		},
 	}
 }
+
+// mergeQueueOptions returns merge queue options for session with the
+// [merge_queue] config applied.
+func mergeQueueOptions(session string) robot.MergeQueueOptions {
+	opts := robot.MergeQueueOptions{Session: session}
+	if cfg != nil {
+		opts.Target = cfg.MergeQueue.Target
+		opts.Verify = cfg.MergeQueue.Verify
+		opts.Timeout = cfg.MergeQueue.VerifyTimeout()
+	}
+	return opts
+}
+
+func newWorktreesQueueCmd() *cobra.Command {
+	var (
+		sessionName string
+		target      string
+		verify      string
+		timeout     time.Duration
+		status      bool
+		noNotify    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "queue [agent-name...]",
+		Short: "Rebase, verify and merge agent branches one at a time",
+		Long: `Integrate agent worktree branches through a merge queue.
+
+Each ntm/<session>/<agent> branch is rebased onto the integration branch in
+a scratch worktree, the verification command runs there, and the
+integration branch is fast-forwarded only if it passes. Branches are taken
+in the order given, or alphabetically when none are named, each one
+rebased onto the result of the ones before it.
+
+On a conflict or failed verification the branch is skipped and its agent
+is sent the conflicting hunks or the command's output to fix.
+
+The integration branch defaults to ntm/<session>/integration, created from
+main or master. The queue never checks out a branch in your project
+directory and refuses a target that is checked out anywhere.
+
+Set defaults in the [merge_queue] config section:
+
+  [merge_queue]
+  verify = "go test ./..."`,
+		Example: `  ntm worktrees queue --verify "go test ./..."
+  ntm worktrees queue cc_1 cod_1
+  ntm worktrees queue --status`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get working directory: %w", err)
+			}
+
+			session := sessionName
+			if session == "" {
+				session = tmux.GetCurrentSession()
+				if session == "" {
+					session = filepath.Base(dir)
+				}
+			}
+
+			opts := mergeQueueOptions(session)
+			opts.ProjectDir = dir
+			opts.Agents = args
+			opts.Status = status
+			opts.NoNotify = noNotify
+			if target != "" {
+				opts.Target = target
+			}
+			if verify != "" {
+				opts.Verify = verify
+			}
+			if timeout > 0 {
+				opts.Timeout = timeout
+			}
+
+			if !status && !IsJSONOutput() {
+				fmt.Printf("Running merge queue for session %s...\n", session)
+			}
+			result, err := robot.GetMergeQueue(opts)
+			if err != nil {
+				return err
+			}
+			if IsJSONOutput() {
+				return output.PrintJSON(result)
+			}
+			if !result.Success {
+				return errors.New(result.Error)
+			}
+			printQueueReport(result.Report)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&sessionName, "session", "", "Session whose agent branches to merge (defaults to current session)")
+	cmd.Flags().StringVar(&target, "target", "", "Integration branch (default merge_queue.target or ntm/<session>/integration)")
+	cmd.Flags().StringVar(&verify, "verify", "", "Command that must pass before a branch is merged (default merge_queue.verify)")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Verification timeout (default 20m)")
+	cmd.Flags().BoolVar(&status, "status", false, "Show the last run instead of starting one")
+	cmd.Flags().BoolVar(&noNotify, "no-notify", false, "Don't message agents about conflicts and failures")
+	return cmd
+}
+
+// printQueueReport prints a merge queue report as a table with the details
+// of branches that were not merged.
+func printQueueReport(report *worktrees.QueueReport) {
+	if report == nil {
+		fmt.Println("The merge queue has not run for this session.")
+		return
+	}
+	fmt.Printf("Merge queue into %s", report.Target)
+	if report.Running {
+		fmt.Print(" (running)")
+	}
+	fmt.Println()
+	if report.Verify != "" {
+		fmt.Printf("Verify: %s\n", report.Verify)
+	}
+	fmt.Println()
+	if len(report.Entries) == 0 {
+		fmt.Println("No agent branches found.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "AGENT\tBRANCH\tSTATUS\tCOMMITS\tNOTIFIED")
+	for _, e := range report.Entries {
+		notified := ""
+		if e.Notified {
+			notified = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", e.Agent, e.Branch, e.Status, e.Commits, notified)
+	}
+	w.Flush()
+
+	for _, e := range report.Entries {
+		if e.Error == "" {
+			continue
+		}
+		fmt.Printf("\n%s: %s\n", e.Agent, e.Error)
+		for _, c := range e.Conflicts {
+			fmt.Printf("  %s\n", c.Path)
+		}
+		if e.Output != "" {
+			fmt.Println(e.Output)
+		}
+	}
+}
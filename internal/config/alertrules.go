@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Dicklesworthstone/ntm/internal/alerts"
+)
+
+func printAlertRules(w io.Writer, rules []alerts.Rule) {
+	if len(rules) == 0 {
+		fmt.Fprintln(w, "# User-defined alert rules; test them with: ntm alerts rules test")
+		fmt.Fprintln(w, "# [[alerts.rules]]")
+		fmt.Fprintln(w, "# name = \"context-high\"")
+		fmt.Fprintln(w, "# expr = \"context_pct > 85 && idle_minutes >= 10\"")
+		fmt.Fprintln(w, "# for = \"5m\"")
+		fmt.Fprintln(w, "# severity = \"warning\"")
+		fmt.Fprintln(w, "# notify = [\"desktop\"]")
+		fmt.Fprintln(w)
+		return
+	}
+	for _, r := range rules {
+		fmt.Fprintln(w, "[[alerts.rules]]")
+		fmt.Fprintf(w, "name = %q\n", r.Name)
+		if r.Expr != "" {
+			fmt.Fprintf(w, "expr = %q\n", r.Expr)
+		}
+		if len(r.Events) > 0 {
+			matchers := make([]string, 0, len(r.Events))
+			for _, m := range r.Events {
+				var fields []string
+				for _, f := range []struct{ key, value string }{
+					{"type", m.Type}, {"session", m.Session}, {"agent", m.Agent}, {"message", m.Message},
+				} {
+					if f.value != "" {
+						fields = append(fields, fmt.Sprintf("%s = %q", f.key, f.value))
+					}
+				}
+				matchers = append(matchers, "{ "+strings.Join(fields, ", ")+" }")
+			}
+			fmt.Fprintf(w, "events = [%s]\n", strings.Join(matchers, ", "))
+		}
+		for _, f := range []struct{ key, value string }{
+			{"window", r.Window}, {"for", r.For}, {"severity", string(r.Severity)},
+			{"dedup_key", r.DedupKey}, {"message", r.Message},
+		} {
+			if f.value != "" {
+				fmt.Fprintf(w, "%s = %q\n", f.key, f.value)
+			}
+		}
+		if len(r.Notify) > 0 {
+			fmt.Fprintf(w, "notify = %s\n", quotedList(r.Notify))
+		}
+		if len(r.Webhooks) > 0 {
+			fmt.Fprintf(w, "webhooks = %s\n", quotedList(r.Webhooks))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func quotedList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"io"
+)
+
+// BudgetConfig sets spend limits that are checked when prompts are sent,
+// agents are spawned and pipeline steps run. Crossing a soft limit raises an
+// alert; crossing a hard limit blocks the operation until an override is
+// approved. Zero leaves a limit unset, so budgets are off by default.
+type BudgetConfig struct {
+	// Session limits the spend of one session's agents.
+	Session BudgetLimits `toml:"session" json:"session"`
+	// Project limits the spend recorded for a project across all its sessions.
+	Project BudgetLimits `toml:"project" json:"project"`
+	// Daily limits the spend across all sessions in one local calendar day.
+	Daily BudgetLimits `toml:"daily" json:"daily"`
+}
+
+// BudgetLimits are the soft and hard limits for one budget scope. Tokens
+// count every token category, cache reads included.
+type BudgetLimits struct {
+	SoftUSD    float64 `toml:"soft_usd" json:"soft_usd,omitempty"`
+	HardUSD    float64 `toml:"hard_usd" json:"hard_usd,omitempty"`
+	SoftTokens int64   `toml:"soft_tokens" json:"soft_tokens,omitempty"`
+	HardTokens int64   `toml:"hard_tokens" json:"hard_tokens,omitempty"`
+}
+
+// IsZero reports whether no limit is set.
+func (l BudgetLimits) IsZero() bool {
+	return l == BudgetLimits{}
+}
+
+// Enabled reports whether any budget limit is set.
+func (c BudgetConfig) Enabled() bool {
+	return !c.Session.IsZero() || !c.Project.IsZero() || !c.Daily.IsZero()
+}
+
+// Tighten returns c with each limit lowered to the one in o where o sets a
+// lower value. Project configs are merged this way so a repository can
+// impose a stricter budget but never loosen the user's own.
+func (c BudgetConfig) Tighten(o BudgetConfig) BudgetConfig {
+	return BudgetConfig{
+		Session: c.Session.tighten(o.Session),
+		Project: c.Project.tighten(o.Project),
+		Daily:   c.Daily.tighten(o.Daily),
+	}
+}
+
+func (l BudgetLimits) tighten(o BudgetLimits) BudgetLimits {
+	return BudgetLimits{
+		SoftUSD:    lowerLimit(l.SoftUSD, o.SoftUSD),
+		HardUSD:    lowerLimit(l.HardUSD, o.HardUSD),
+		SoftTokens: lowerLimit(l.SoftTokens, o.SoftTokens),
+		HardTokens: lowerLimit(l.HardTokens, o.HardTokens),
+	}
+}
+
+// lowerLimit returns the lower of two limits, treating zero as unset.
+func lowerLimit[T int64 | float64](a, b T) T {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// ValidateBudgetConfig validates the budget configuration.
+func ValidateBudgetConfig(cfg *BudgetConfig) error {
+	if err := validateBudgetLimits(cfg.Session); err != nil {
+		return fmt.Errorf("session: %w", err)
+	}
+	if err := validateBudgetLimits(cfg.Project); err != nil {
+		return fmt.Errorf("project: %w", err)
+	}
+	if err := validateBudgetLimits(cfg.Daily); err != nil {
+		return fmt.Errorf("daily: %w", err)
+	}
+	return nil
+}
+
+func validateBudgetLimits(l BudgetLimits) error {
+	if l.SoftUSD < 0 || l.HardUSD < 0 || l.SoftTokens < 0 || l.HardTokens < 0 {
+		return fmt.Errorf("limits must be non-negative")
+	}
+	if l.SoftUSD > 0 && l.HardUSD > 0 && l.SoftUSD > l.HardUSD {
+		return fmt.Errorf("soft_usd %.2f exceeds hard_usd %.2f", l.SoftUSD, l.HardUSD)
+	}
+	if l.SoftTokens > 0 && l.HardTokens > 0 && l.SoftTokens > l.HardTokens {
+		return fmt.Errorf("soft_tokens %d exceeds hard_tokens %d", l.SoftTokens, l.HardTokens)
+	}
+	return nil
+}
+
+// printBudgetLimits writes one budget scope for Print, commented out when
+// no limit is set.
+func printBudgetLimits(w io.Writer, scope string, l BudgetLimits) {
+	if l.IsZero() {
+		fmt.Fprintf(w, "# [budget.%s]\n# soft_usd = 5.0\n# hard_usd = 10.0\n", scope)
+		return
+	}
+	fmt.Fprintf(w, "[budget.%s]\n", scope)
+	fmt.Fprintf(w, "soft_usd = %g\n", l.SoftUSD)
+	fmt.Fprintf(w, "hard_usd = %g\n", l.HardUSD)
+	fmt.Fprintf(w, "soft_tokens = %d\n", l.SoftTokens)
+	fmt.Fprintf(w, "hard_tokens = %d\n", l.HardTokens)
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/BurntSushi/toml"
 
+	"github.com/Dicklesworthstone/ntm/internal/alerts"
 	"github.com/Dicklesworthstone/ntm/internal/notify"
 	"github.com/Dicklesworthstone/ntm/internal/redaction"
 	"github.com/Dicklesworthstone/ntm/internal/util"
@@ -93,8 +94,12 @@ type Config struct {
 	Redaction          RedactionConfig       `toml:"redaction"`        // Secrets/PII redaction configuration
 	Privacy            PrivacyConfig         `toml:"privacy"`          // Privacy mode configuration
 	Encryption         EncryptionConfig      `toml:"encryption"`       // Encryption at rest for artifacts
+	Events             EventsConfig          `toml:"events"`           // Durable event bus log
 	Send               SendConfig            `toml:"send"`             // Send command defaults
 	Prompts            PromptsConfig         `toml:"prompts"`          // Per-agent-type default prompts
+	Budget             BudgetConfig          `toml:"budget"`           // Session, project and daily spend limits
+	Hosts              map[string]HostConfig `toml:"hosts"`            // Remote machines for --host, by name
+	MergeQueue         MergeQueueConfig      `toml:"merge_queue"`      // Test-gated integration of agent branches
 
 	// Runtime-only fields (populated by project config merging)
 	ProjectDefaults map[string]int `toml:"-"`
@@ -183,6 +188,10 @@ type AlertsConfig struct {
 	MailBacklogThreshold int     `toml:"mail_backlog_threshold"` // Unread messages before alerting
 	BeadStaleHours       int     `toml:"bead_stale_hours"`       // Hours before in-progress bead is stale
 	ResolvedPruneMinutes int     `toml:"resolved_prune_minutes"` // How long to keep resolved alerts
+
+	// Rules are user-defined alert rules ([[alerts.rules]]), evaluated by the
+	// session monitor and by ntm alerts rules test
+	Rules []alerts.Rule `toml:"rules"`
 }
 
 // DefaultAlertsConfig returns sensible alert defaults
@@ -837,6 +846,9 @@ type TmuxConfig struct {
 	DefaultPanes    int    `toml:"default_panes"`
 	PaletteKey      string `toml:"palette_key"`
 	PaneInitDelayMs int    `toml:"pane_init_delay_ms"` // Delay before sending keys to new panes
+	// ControlMode multiplexes the dashboard's and server's tmux commands over
+	// a persistent tmux -C connection instead of forking one process each.
+	ControlMode bool `toml:"control_mode"`
 	// ActivityIndicators control pane border activity coloring.
 	ActivityIndicators ActivityIndicatorConfig `toml:"activity_indicators"`
 }
@@ -1837,10 +1849,40 @@ func ValidateEncryptionConfig(cfg *EncryptionConfig) error {
 	return nil
 }
 
+// EventsConfig controls the durable event bus log, which lets ntm serve,
+// webhook bridges and dashboards replay events emitted while they were down.
+type EventsConfig struct {
+	// Durable appends every published bus event to an on-disk segment log.
+	// It is off by default: each append is a locked, synchronous write.
+	Durable bool `toml:"durable"`
+	// Dir overrides the log directory (default ~/.local/share/ntm/events).
+	Dir string `toml:"dir"`
+	// SegmentSizeMB is the size at which a log segment is rolled.
+	SegmentSizeMB int `toml:"segment_size_mb"`
+	// MaxSegments is how many segments are retained.
+	MaxSegments int `toml:"max_segments"`
+}
+
+// DefaultEventsConfig returns the default durable event log settings.
+func DefaultEventsConfig() EventsConfig {
+	return EventsConfig{
+		Durable:       false,
+		SegmentSizeMB: 8,
+		MaxSegments:   16,
+	}
+}
+
 // SendConfig holds defaults for the send command.
 type SendConfig struct {
 	BasePrompt     string `toml:"base_prompt"`      // Text prepended to all prompts
 	BasePromptFile string `toml:"base_prompt_file"` // File whose contents are prepended to all prompts
+	// Delivery is how prompts reach agent panes: "keys" types them with
+	// send-keys, "paste" pastes them from a tmux buffer and verifies the
+	// agent accepted them (default "keys").
+	Delivery string `toml:"delivery"`
+	// DeliveryAttempts is how many times paste delivery presses Enter before
+	// reporting a prompt undelivered (default 3).
+	DeliveryAttempts int `toml:"delivery_attempts"`
 }
 
 // PromptsConfig holds per-agent-type default prompts (bd-2ywo).
@@ -1971,6 +2013,7 @@ func Default() *Config {
 		Redaction:       DefaultRedactionConfig(),
 		Privacy:         DefaultPrivacyConfig(),
 		Encryption:      DefaultEncryptionConfig(),
+		Events:          DefaultEventsConfig(),
 		SpawnPacing:     DefaultSpawnPacingConfig(),
 	}
 
@@ -2462,6 +2505,7 @@ func Print(cfg *Config, w io.Writer) error {
 	fmt.Fprintf(w, "default_panes = %d\n", cfg.Tmux.DefaultPanes)
 	fmt.Fprintf(w, "palette_key = %q\n", cfg.Tmux.PaletteKey)
 	fmt.Fprintf(w, "pane_init_delay_ms = %d  # Delay before send-keys to new panes\n", cfg.Tmux.PaneInitDelayMs)
+	fmt.Fprintf(w, "control_mode = %t  # Reuse one tmux -C connection (falls back to polling)\n", cfg.Tmux.ControlMode)
 	fmt.Fprintln(w)
 
 	fmt.Fprintln(w, "[robot]")
@@ -2604,6 +2648,21 @@ func Print(cfg *Config, w io.Writer) error {
 	fmt.Fprintf(w, "require_explicit_persist = %t\n", cfg.Privacy.RequireExplicitPersist)
 	fmt.Fprintln(w)
 
+	fmt.Fprintln(w, "# Spend budgets: soft limits raise an alert, hard limits block send, spawn,")
+	fmt.Fprintln(w, "# add and pipeline steps until an override is approved (ntm approve).")
+	fmt.Fprintln(w, "# Scopes: [budget.session], [budget.project], [budget.daily]")
+	printBudgetLimits(w, "session", cfg.Budget.Session)
+	printBudgetLimits(w, "project", cfg.Budget.Project)
+	printBudgetLimits(w, "daily", cfg.Budget.Daily)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "# Remote hosts: select one with --host <name> (ssh connections are shared)")
+	printHosts(w, cfg)
+	fmt.Fprintln(w)
+
+	printMergeQueue(w, cfg.MergeQueue)
+	fmt.Fprintln(w)
+
 	// Write models configuration
 	fmt.Fprintln(w, "[models]")
 	fmt.Fprintln(w, "# Default models when no specifier given")
@@ -2646,6 +2705,7 @@ func Print(cfg *Config, w io.Writer) error {
 	fmt.Fprintf(w, "bead_stale_hours = %d       # Hours before in-progress bead is stale\n", cfg.Alerts.BeadStaleHours)
 	fmt.Fprintf(w, "resolved_prune_minutes = %d # How long to keep resolved alerts\n", cfg.Alerts.ResolvedPruneMinutes)
 	fmt.Fprintln(w)
+	printAlertRules(w, cfg.Alerts.Rules)
 
 	// Write checkpoints configuration
 	fmt.Fprintln(w, "[checkpoints]")
@@ -3128,6 +3188,8 @@ func GetValue(cfg *Config, path string) (interface{}, error) {
 			return cfg.Tmux.PaletteKey, nil
 		case "pane_init_delay_ms":
 			return cfg.Tmux.PaneInitDelayMs, nil
+		case "control_mode":
+			return cfg.Tmux.ControlMode, nil
 		}
 	case "agent_mail":
 		if len(parts) < 2 {
@@ -3414,6 +3476,7 @@ func Diff(cfg *Config) []ConfigDiff {
 	addDiff("tmux.default_panes", defaults.Tmux.DefaultPanes, cfg.Tmux.DefaultPanes)
 	addDiff("tmux.palette_key", defaults.Tmux.PaletteKey, cfg.Tmux.PaletteKey)
 	addDiff("tmux.pane_init_delay_ms", defaults.Tmux.PaneInitDelayMs, cfg.Tmux.PaneInitDelayMs)
+	addDiff("tmux.control_mode", defaults.Tmux.ControlMode, cfg.Tmux.ControlMode)
 
 	// Agent Mail
 	addDiff("agent_mail.enabled", defaults.AgentMail.Enabled, cfg.AgentMail.Enabled)
@@ -3572,6 +3635,28 @@ func Validate(cfg *Config) []error {
 		errs = append(errs, fmt.Errorf("spawn_pacing: %w", err))
 	}
 
+	// Validate budget limits
+	if err := ValidateBudgetConfig(&cfg.Budget); err != nil {
+		errs = append(errs, fmt.Errorf("budget: %w", err))
+	}
+
+	if err := ValidateHosts(cfg.Hosts); err != nil {
+		errs = append(errs, fmt.Errorf("hosts: %w", err))
+	}
+
+	if err := ValidateMergeQueueConfig(&cfg.MergeQueue); err != nil {
+		errs = append(errs, fmt.Errorf("merge_queue: %w", err))
+	}
+
+	switch cfg.Send.Delivery {
+	case "", "keys", "paste":
+	default:
+		errs = append(errs, fmt.Errorf("send.delivery: must be \"keys\" or \"paste\", got %q", cfg.Send.Delivery))
+	}
+	if cfg.Send.DeliveryAttempts < 0 {
+		errs = append(errs, fmt.Errorf("send.delivery_attempts: must be non-negative, got %d", cfg.Send.DeliveryAttempts))
+	}
+
 	// Validate projects_base if set
 	if cfg.ProjectsBase != "" {
 		expanded := ExpandHome(cfg.ProjectsBase)
@@ -3596,6 +3681,9 @@ func Validate(cfg *Config) []error {
 	if cfg.Alerts.DiskLowThresholdGB < 0 {
 		errs = append(errs, fmt.Errorf("alerts.disk_low_threshold_gb: must be non-negative, got %.1f", cfg.Alerts.DiskLowThresholdGB))
 	}
+	if _, err := alerts.CompileRules(cfg.Alerts.Rules); err != nil {
+		errs = append(errs, fmt.Errorf("alerts.rules: %w", err))
+	}
 
 	// Validate checkpoints
 	if cfg.Checkpoints.MaxAutoCheckpoints < 0 {
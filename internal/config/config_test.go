@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -4478,3 +4479,178 @@ func TestConfigReset_NoExistingFile(t *testing.T) {
 		t.Fatalf("config file should exist after reset: %v", err)
 	}
 }
+
+func TestValidateBudgetConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     BudgetConfig
+		wantErr string
+	}{
+		{"empty", BudgetConfig{}, ""},
+		{"valid", BudgetConfig{Session: BudgetLimits{SoftUSD: 5, HardUSD: 10}, Daily: BudgetLimits{HardTokens: 1000}}, ""},
+		{"soft only", BudgetConfig{Project: BudgetLimits{SoftUSD: 50}}, ""},
+		{"negative", BudgetConfig{Daily: BudgetLimits{HardUSD: -1}}, "daily: limits must be non-negative"},
+		{"soft above hard", BudgetConfig{Session: BudgetLimits{SoftTokens: 500, HardTokens: 100}}, "session: soft_tokens 500 exceeds hard_tokens 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBudgetConfig(&tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBudgetConfigTighten(t *testing.T) {
+	user := BudgetConfig{Session: BudgetLimits{SoftUSD: 5, HardUSD: 10}, Daily: BudgetLimits{HardTokens: 1000}}
+	project := BudgetConfig{Session: BudgetLimits{HardUSD: 20, HardTokens: 500}, Daily: BudgetLimits{HardTokens: 800}}
+
+	got := user.Tighten(project)
+	want := BudgetConfig{Session: BudgetLimits{SoftUSD: 5, HardUSD: 10, HardTokens: 500}, Daily: BudgetLimits{HardTokens: 800}}
+	if got != want {
+		t.Errorf("Tighten = %+v, want %+v", got, want)
+	}
+	if !got.Enabled() || (BudgetConfig{}).Enabled() {
+		t.Error("Enabled() mismatch")
+	}
+}
+
+func TestValidateSendDelivery(t *testing.T) {
+	cfg := Default()
+	cfg.Send.Delivery = "paste"
+	cfg.Send.DeliveryAttempts = 2
+	for _, err := range Validate(cfg) {
+		if strings.Contains(err.Error(), "send.") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	cfg.Send.Delivery = "carrier-pigeon"
+	cfg.Send.DeliveryAttempts = -1
+	var found int
+	for _, err := range Validate(cfg) {
+		if strings.Contains(err.Error(), "send.delivery") {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Errorf("got %d send.delivery errors, want 2", found)
+	}
+}
+
+func TestValidateHosts(t *testing.T) {
+	cfg := Default()
+	cfg.Hosts = map[string]HostConfig{"build-box": {Address: "ubuntu@10.0.0.5", Port: 2222}}
+	for _, err := range Validate(cfg) {
+		if strings.Contains(err.Error(), "hosts:") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if got := cfg.Hosts["build-box"].SSHOptions(); len(got) != 2 || got[1] != "2222" {
+		t.Errorf("SSHOptions() = %v", got)
+	}
+	if got := cfg.Hosts["build-box"].ProjectDir("api--fix"); got != "~/projects/api" {
+		t.Errorf("ProjectDir() = %q", got)
+	}
+
+	for _, hosts := range []map[string]HostConfig{
+		{"build-box": {}},
+		{"local": {Address: "x"}},
+		{"bad name": {Address: "x"}},
+	} {
+		cfg.Hosts = hosts
+		var found bool
+		for _, err := range Validate(cfg) {
+			found = found || strings.Contains(err.Error(), "hosts:")
+		}
+		if !found {
+			t.Errorf("Validate(%v) found no hosts error", hosts)
+		}
+	}
+}
+
+func TestValidateMergeQueueConfig(t *testing.T) {
+	valid := MergeQueueConfig{Target: "integration", Verify: "go test ./...", VerifyTimeoutSeconds: 600}
+	if err := ValidateMergeQueueConfig(&valid); err != nil {
+		t.Errorf("ValidateMergeQueueConfig(%+v) = %v", valid, err)
+	}
+	if got := valid.VerifyTimeout(); got != 10*time.Minute {
+		t.Errorf("VerifyTimeout() = %v", got)
+	}
+	for _, bad := range []MergeQueueConfig{
+		{VerifyTimeoutSeconds: -1},
+		{Target: "bad branch"},
+		{Target: "--force"},
+	} {
+		if err := ValidateMergeQueueConfig(&bad); err == nil {
+			t.Errorf("ValidateMergeQueueConfig(%+v) = nil, want error", bad)
+		}
+	}
+}
+
+func TestAlertRulesConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := `
+[alerts]
+enabled = true
+
+[[alerts.rules]]
+name = "context-high"
+expr = "context_pct > 85 && idle_minutes >= 10"
+for = "5m"
+severity = "critical"
+notify = ["desktop"]
+
+[[alerts.rules]]
+name = "auth-errors"
+events = [{ type = "agent.error", message = "(?i)auth" }]
+webhooks = ["https://example.com/hook"]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Alerts.Rules) != 2 || cfg.Alerts.Rules[0].For != "5m" || cfg.Alerts.Rules[1].Events[0].Message != "(?i)auth" {
+		t.Fatalf("rules = %+v", cfg.Alerts.Rules)
+	}
+	for _, err := range Validate(cfg) {
+		if strings.Contains(err.Error(), "alerts.rules") {
+			t.Errorf("Validate() = %v", err)
+		}
+	}
+
+	// Printed rules load back unchanged.
+	var buf bytes.Buffer
+	if err := Print(cfg, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load(printed) error = %v", err)
+	}
+	if !reflect.DeepEqual(reloaded.Alerts.Rules, cfg.Alerts.Rules) {
+		t.Errorf("reloaded rules = %+v, want %+v", reloaded.Alerts.Rules, cfg.Alerts.Rules)
+	}
+
+	cfg.Alerts.Rules[0].Expr = "context_pct >"
+	var found bool
+	for _, err := range Validate(cfg) {
+		found = found || strings.Contains(err.Error(), "alerts.rules")
+	}
+	if !found {
+		t.Error("Validate() accepted an invalid rule expression")
+	}
+}
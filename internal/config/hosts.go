@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultHostProjectsBase is where sessions on a remote host keep their
+// project directories when the host does not set projects_base.
+const DefaultHostProjectsBase = "~/projects"
+
+// HostConfig describes a machine ntm can run sessions on over ssh. Hosts are
+// keyed by name in [hosts.<name>] tables and selected with --host <name>.
+type HostConfig struct {
+	// Address is the ssh destination: "user@host" or an ~/.ssh/config alias.
+	Address string `toml:"address" json:"address"`
+	// Port overrides the ssh port.
+	Port int `toml:"port" json:"port,omitempty"`
+	// IdentityFile is the private key passed to ssh -i.
+	IdentityFile string `toml:"identity_file" json:"identity_file,omitempty"`
+	// ProjectsBase is the projects directory on the host; "~/" is relative to
+	// the remote home (default ~/projects).
+	ProjectsBase string `toml:"projects_base" json:"projects_base,omitempty"`
+}
+
+// SSHOptions returns the ssh arguments the host's port and identity file need.
+func (h HostConfig) SSHOptions() []string {
+	var opts []string
+	if h.Port != 0 {
+		opts = append(opts, "-p", strconv.Itoa(h.Port))
+	}
+	if h.IdentityFile != "" {
+		opts = append(opts, "-i", ExpandHome(h.IdentityFile))
+	}
+	return opts
+}
+
+// ProjectsDir returns the host's projects directory.
+func (h HostConfig) ProjectsDir() string {
+	if h.ProjectsBase == "" {
+		return DefaultHostProjectsBase
+	}
+	return strings.TrimSuffix(h.ProjectsBase, "/")
+}
+
+// ProjectDir returns the directory for session on the host.
+func (h HostConfig) ProjectDir(session string) string {
+	return h.ProjectsDir() + "/" + SessionBase(session)
+}
+
+var hostNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ValidateHosts validates the host inventory.
+func ValidateHosts(hosts map[string]HostConfig) error {
+	for name, h := range hosts {
+		if name == "local" || !hostNameRegex.MatchString(name) {
+			return fmt.Errorf("%q: invalid host name", name)
+		}
+		if h.Address == "" {
+			return fmt.Errorf("%s: address is required", name)
+		}
+		if h.Port < 0 || h.Port > 65535 {
+			return fmt.Errorf("%s: port %d out of range", name, h.Port)
+		}
+	}
+	return nil
+}
+
+// printHosts writes the host inventory for Print, with a commented example
+// when no hosts are configured.
+func printHosts(w io.Writer, c *Config) {
+	if len(c.Hosts) == 0 {
+		fmt.Fprintln(w, "# [hosts.build-box]")
+		fmt.Fprintln(w, "# address = \"ubuntu@10.0.0.5\"")
+		fmt.Fprintln(w, "# projects_base = \"~/projects\"")
+		return
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Hosts)) {
+		h := c.Hosts[name]
+		fmt.Fprintf(w, "[hosts.%s]\n", name)
+		fmt.Fprintf(w, "address = %q\n", h.Address)
+		if h.Port != 0 {
+			fmt.Fprintf(w, "port = %d\n", h.Port)
+		}
+		if h.IdentityFile != "" {
+			fmt.Fprintf(w, "identity_file = %q\n", h.IdentityFile)
+		}
+		if h.ProjectsBase != "" {
+			fmt.Fprintf(w, "projects_base = %q\n", h.ProjectsBase)
+		}
+	}
+}
@@ -89,6 +89,9 @@ func MergeConfig(global *Config, project *ProjectConfig, projectDir string) *Con
 		}
 	}
 
+	// Merge budgets. A project may tighten limits but never loosen them.
+	global.Budget = global.Budget.Tighten(project.Budget)
+
 	// Merge palette state (favorites/pins). Project entries come first.
 	global.PaletteState.Pinned = mergeStringListPreferFirst(project.PaletteState.Pinned, global.PaletteState.Pinned)
 	global.PaletteState.Favorites = mergeStringListPreferFirst(project.PaletteState.Favorites, global.PaletteState.Favorites)
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// MergeQueueConfig configures ntm worktrees queue, which integrates agent
+// worktree branches one at a time. It is only read from the user config: a
+// project config cannot set the verification command.
+type MergeQueueConfig struct {
+	// Target is the integration branch (default ntm/<session>/integration).
+	Target string `toml:"target" json:"target,omitempty"`
+	// Verify is a shell command that must pass on each rebased branch before
+	// it is merged, e.g. "go test ./...". Empty merges without verifying.
+	Verify string `toml:"verify" json:"verify,omitempty"`
+	// VerifyTimeoutSeconds bounds the verification command (default 1200).
+	VerifyTimeoutSeconds int `toml:"verify_timeout_seconds" json:"verify_timeout_seconds,omitempty"`
+}
+
+// VerifyTimeout returns the verification timeout, or zero for the default.
+func (c MergeQueueConfig) VerifyTimeout() time.Duration {
+	return time.Duration(c.VerifyTimeoutSeconds) * time.Second
+}
+
+// ValidateMergeQueueConfig validates the merge queue configuration.
+func ValidateMergeQueueConfig(cfg *MergeQueueConfig) error {
+	if cfg.VerifyTimeoutSeconds < 0 {
+		return fmt.Errorf("verify_timeout_seconds: must be non-negative, got %d", cfg.VerifyTimeoutSeconds)
+	}
+	if strings.ContainsAny(cfg.Target, " ~^:?*[\\") || strings.HasPrefix(cfg.Target, "-") {
+		return fmt.Errorf("target: invalid branch name %q", cfg.Target)
+	}
+	return nil
+}
+
+func printMergeQueue(w io.Writer, c MergeQueueConfig) {
+	fmt.Fprintln(w, "[merge_queue]")
+	fmt.Fprintln(w, "# Integration branch for ntm worktrees queue (default ntm/<session>/integration)")
+	if c.Target != "" {
+		fmt.Fprintf(w, "target = %q\n", c.Target)
+	} else {
+		fmt.Fprintln(w, "# target = \"integration\"")
+	}
+	fmt.Fprintln(w, "# Command that must pass on each rebased branch before it is merged")
+	if c.Verify != "" {
+		fmt.Fprintf(w, "verify = %q\n", c.Verify)
+	} else {
+		fmt.Fprintln(w, "# verify = \"go test ./...\"")
+	}
+	if c.VerifyTimeoutSeconds != 0 {
+		fmt.Fprintf(w, "verify_timeout_seconds = %d\n", c.VerifyTimeoutSeconds)
+	} else {
+		fmt.Fprintln(w, "# verify_timeout_seconds = 1200")
+	}
+}
@@ -35,6 +35,19 @@ type SpawnPacingConfig struct {
 	// When the queue exceeds this size, warnings are emitted.
 	BackpressureThreshold int `toml:"backpressure_threshold"`
 
+	// MaxQueueSize bounds the number of queued spawns (0 = unbounded). When
+	// the queue is full, a higher-priority spawn preempts the lowest-priority
+	// queued one; otherwise it is rejected.
+	MaxQueueSize int `toml:"max_queue_size"`
+
+	// ProjectWeights sets the fair-share weight of each project, keyed by
+	// project directory. Unlisted projects get weight 1.
+	ProjectWeights map[string]float64 `toml:"project_weights"`
+
+	// SessionWeights sets the fair-share weight of each session within its
+	// project. Unlisted sessions get weight 1.
+	SessionWeights map[string]float64 `toml:"session_weights"`
+
 	// AgentCaps contains per-agent-type concurrency caps and pacing.
 	AgentCaps AgentPacingConfig `toml:"agent_caps"`
 
@@ -186,6 +199,21 @@ func ValidateSpawnPacingConfig(cfg *SpawnPacingConfig) error {
 		return fmt.Errorf("backpressure_threshold must be at least 1, got %d", cfg.BackpressureThreshold)
 	}
 
+	// Validate queue cap and fair-share weights
+	if cfg.MaxQueueSize < 0 {
+		return fmt.Errorf("max_queue_size must be non-negative, got %d", cfg.MaxQueueSize)
+	}
+	for project, w := range cfg.ProjectWeights {
+		if w <= 0 {
+			return fmt.Errorf("project_weights[%q] must be positive, got %f", project, w)
+		}
+	}
+	for session, w := range cfg.SessionWeights {
+		if w <= 0 {
+			return fmt.Errorf("session_weights[%q] must be positive, got %f", session, w)
+		}
+	}
+
 	// Validate agent caps
 	if err := validateAgentPacingConfig(&cfg.AgentCaps); err != nil {
 		return fmt.Errorf("agent_caps: %w", err)
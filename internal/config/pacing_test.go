@@ -137,6 +137,24 @@ func TestValidateSpawnPacingConfig(t *testing.T) {
 			}(),
 			wantErr: true,
 		},
+		{
+			name: "negative max_queue_size",
+			cfg: func() SpawnPacingConfig {
+				cfg := DefaultSpawnPacingConfig()
+				cfg.MaxQueueSize = -1
+				return cfg
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "non-positive project weight",
+			cfg: func() SpawnPacingConfig {
+				cfg := DefaultSpawnPacingConfig()
+				cfg.ProjectWeights = map[string]float64{"/src/shop": 0}
+				return cfg
+			}(),
+			wantErr: true,
+		},
 		{
 			name: "negative claude_max_concurrent",
 			cfg: func() SpawnPacingConfig {
@@ -288,6 +306,13 @@ burst_size = 8
 default_retries = 4
 retry_delay_ms = 1500
 backpressure_threshold = 75
+max_queue_size = 40
+
+[spawn_pacing.project_weights]
+"/src/shop" = 2.0
+
+[spawn_pacing.session_weights]
+urgent = 3.0
 
 [spawn_pacing.agent_caps]
 claude_max_concurrent = 4
@@ -342,6 +367,12 @@ global_pause_duration_ms = 45000
 	if cfg.SpawnPacing.BackpressureThreshold != 75 {
 		t.Errorf("expected backpressure_threshold=75, got %d", cfg.SpawnPacing.BackpressureThreshold)
 	}
+	if cfg.SpawnPacing.MaxQueueSize != 40 {
+		t.Errorf("expected max_queue_size=40, got %d", cfg.SpawnPacing.MaxQueueSize)
+	}
+	if cfg.SpawnPacing.ProjectWeights["/src/shop"] != 2.0 || cfg.SpawnPacing.SessionWeights["urgent"] != 3.0 {
+		t.Errorf("expected weights to parse, got %v and %v", cfg.SpawnPacing.ProjectWeights, cfg.SpawnPacing.SessionWeights)
+	}
 
 	// Verify agent caps
 	if cfg.SpawnPacing.AgentCaps.ClaudeMaxConcurrent != 4 {
@@ -21,6 +21,7 @@ type ProjectConfig struct {
 	Templates    ProjectTemplates    `toml:"templates"`
 	Agents       AgentConfig         `toml:"agents"`
 	Integrations ProjectIntegrations `toml:"integrations"`
+	Budget       BudgetConfig        `toml:"budget"` // Can only lower the user's limits
 }
 
 // ProjectMeta holds basic project metadata.
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/adapter"
+	"github.com/Dicklesworthstone/ntm/internal/agent"
 )
 
 // CompactionMethod identifies the compaction strategy used.
@@ -86,42 +89,27 @@ type AgentCapabilities struct {
 	HistoryClearCommand    string // e.g., "/clear"
 }
 
-// GetAgentCapabilities returns the compaction capabilities for an agent type.
+// GetAgentCapabilities returns the compaction capabilities for an agent type,
+// as declared by its adapter. Provider names such as "claude" and "openai"
+// are accepted as aliases.
 func GetAgentCapabilities(agentType string) AgentCapabilities {
 	agentType = strings.ToLower(agentType)
 
 	switch agentType {
-	case "claude", "claude-code", "cc":
-		return AgentCapabilities{
-			SupportsBuiltinCompact: true,
-			SupportsHistoryClear:   true,
-			BuiltinCompactCommand:  "/compact",
-			HistoryClearCommand:    "/clear",
-		}
-	case "codex", "cod", "openai":
-		return AgentCapabilities{
-			SupportsBuiltinCompact: false, // Codex doesn't have /compact
-			SupportsHistoryClear:   false,
-			BuiltinCompactCommand:  "",
-			HistoryClearCommand:    "",
-		}
-	case "gemini", "gmi", "google":
-		return AgentCapabilities{
-			SupportsBuiltinCompact: false, // Gemini CLI doesn't have /compact
-			SupportsHistoryClear:   true,
-			BuiltinCompactCommand:  "",
-			HistoryClearCommand:    "/clear",
-		}
-	case "cursor", "windsurf", "aider":
-		// These IDE-based agents typically manage their own context or don't support explicit compaction commands yet
-		return AgentCapabilities{
-			SupportsBuiltinCompact: false,
-			SupportsHistoryClear:   false,
-			BuiltinCompactCommand:  "",
-			HistoryClearCommand:    "",
-		}
-	default:
-		return AgentCapabilities{}
+	case "claude", "claude-code":
+		agentType = "cc"
+	case "codex", "openai":
+		agentType = "cod"
+	case "gemini", "google":
+		agentType = "gmi"
+	}
+
+	c := adapter.Get(agent.AgentType(agentType)).Compaction()
+	return AgentCapabilities{
+		SupportsBuiltinCompact: c.CompactCommand != "",
+		SupportsHistoryClear:   c.ClearCommand != "",
+		BuiltinCompactCommand:  c.CompactCommand,
+		HistoryClearCommand:    c.ClearCommand,
 	}
 }
 
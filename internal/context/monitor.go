@@ -356,9 +356,10 @@ func (e *DurationActivityEstimator) Estimate(state *ContextState) (*ContextEstim
 
 // ContextMonitor manages context tracking for multiple agents.
 type ContextMonitor struct {
-	estimators []ContextEstimator
-	states     map[string]*ContextState // agentID -> state
-	mu         sync.RWMutex
+	estimators  []ContextEstimator
+	transcripts *TranscriptEstimator
+	states      map[string]*ContextState // agentID -> state
+	mu          sync.RWMutex
 
 	// Configuration
 	warningThreshold float64 // Default 70%
@@ -370,6 +371,10 @@ type MonitorConfig struct {
 	WarningThreshold float64 // Percentage at which to warn (default 70)
 	RotateThreshold  float64 // Percentage at which to rotate (default 75)
 	TokensPerMessage int     // For message count estimation (default 1500)
+
+	// TranscriptLocator finds agent transcripts for DiscoverTranscript.
+	// Nil uses the home directory; set it to point elsewhere in tests.
+	TranscriptLocator *TranscriptLocator
 }
 
 // DefaultMonitorConfig returns sensible defaults.
@@ -393,8 +398,16 @@ func NewContextMonitor(cfg MonitorConfig) *ContextMonitor {
 		cfg.TokensPerMessage = 1500
 	}
 
+	locator := cfg.TranscriptLocator
+	if locator == nil {
+		locator = DefaultTranscriptLocator()
+	}
+	transcripts := NewTranscriptEstimator(locator)
+
 	return &ContextMonitor{
+		transcripts: transcripts,
 		estimators: []ContextEstimator{
+			transcripts,
 			&RobotModeEstimator{},
 			&CumulativeTokenEstimator{CompactionDiscount: 0.7},
 			&MessageCountEstimator{TokensPerMessage: cfg.TokensPerMessage},
@@ -447,6 +460,7 @@ func (m *ContextMonitor) UnregisterAgent(agentID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	delete(m.states, agentID)
+	m.transcripts.Forget(agentID)
 }
 
 // GetState returns the current state for an agent.
@@ -621,6 +635,12 @@ func (m *ContextMonitor) ResetAgent(agentID string) {
 		state.cumulativeOutputTokens = 0
 		state.SessionStart = time.Now()
 		state.Estimate = nil
+
+		// The old transcript describes the rotated-away session.
+		if state.TranscriptPath != "" {
+			m.transcripts.Retire(agentID)
+			state.TranscriptPath = ""
+		}
 	}
 }
 
@@ -692,23 +712,96 @@ func (m *ContextMonitor) RegisterAgentWithTranscript(agentID, paneID, model, age
 	return state
 }
 
-// UpdateFromTranscript reads the agent's transcript file and estimates tokens from file size.
-// Uses ~4 bytes per token as approximation (conservative for safety).
-// Returns the estimated tokens, or 0 if the file doesn't exist or can't be read.
+// DiscoverTranscript finds the native transcript for an agent running in
+// workDir and records it as the agent's TranscriptPath. An already known
+// path is kept. Returns the path, or "" if no transcript was found.
+func (m *ContextMonitor) DiscoverTranscript(agentID, workDir string) string {
+	m.mu.RLock()
+	state, exists := m.states[agentID]
+	var agentType, current string
+	if exists {
+		agentType, current = state.AgentType, state.TranscriptPath
+	}
+	m.mu.RUnlock()
+
+	if !exists {
+		return ""
+	}
+	if current != "" {
+		return current
+	}
+
+	path := m.transcripts.Locate(agentID, agentType, workDir)
+	if path == "" {
+		return ""
+	}
+	m.mu.Lock()
+	if state, exists := m.states[agentID]; exists && state.TranscriptPath == "" {
+		state.TranscriptPath = path
+	}
+	m.mu.Unlock()
+	return path
+}
+
+// TranscriptUsage returns exact token usage from the agent's transcript, or
+// nil if the agent has no transcript or it reports no usage yet.
+func (m *ContextMonitor) TranscriptUsage(agentID string) *TranscriptUsage {
+	m.mu.RLock()
+	state, exists := m.states[agentID]
+	var path, agentType string
+	if exists {
+		path, agentType = state.TranscriptPath, state.AgentType
+	}
+	m.mu.RUnlock()
+
+	if path == "" {
+		return nil
+	}
+	usage, err := m.transcripts.Read(path, agentType)
+	if err != nil || usage == nil || usage.Turns == 0 {
+		return nil
+	}
+	return usage
+}
+
+// UpdateFromTranscript reads the agent's transcript file and updates its token counts.
+// Transcripts with usage blocks give exact counts; otherwise tokens are
+// estimated from file size at ~3.5 bytes per token (conservative for safety).
+// Returns the tokens in context, or 0 if the file doesn't exist or can't be read.
 func (m *ContextMonitor) UpdateFromTranscript(agentID string) (int64, error) {
 	m.mu.RLock()
 	state, exists := m.states[agentID]
+	var path, agentType string
+	if exists {
+		path, agentType = state.TranscriptPath, state.AgentType
+	}
 	m.mu.RUnlock()
 
 	if !exists {
 		return 0, nil
 	}
 
-	if state.TranscriptPath == "" {
+	if path == "" {
 		return 0, nil // No transcript path configured
 	}
 
-	info, err := os.Stat(state.TranscriptPath)
+	usage, err := m.transcripts.Read(path, agentType)
+	if err != nil {
+		return 0, err
+	}
+	if usage != nil && usage.Turns > 0 {
+		m.mu.Lock()
+		if state, exists := m.states[agentID]; exists {
+			state.MessageCount = usage.Turns
+			state.cumulativeInputTokens = usage.TotalInputTokens()
+			state.cumulativeOutputTokens = usage.OutputTokens
+			state.LastActivity = usage.UpdatedAt
+		}
+		m.mu.Unlock()
+		return usage.ContextTokens, nil
+	}
+
+	info, err := os.Stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return 0, nil // File doesn't exist yet, not an error
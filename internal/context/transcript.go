@@ -0,0 +1,756 @@
+// Package context provides context window monitoring for AI agent orchestration.
+// transcript.go reads exact token usage from the agents' own session transcripts.
+package context
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/cost"
+)
+
+// MethodTranscript marks estimates read from an agent's native transcript.
+const MethodTranscript EstimationMethod = "transcript"
+
+// TranscriptFormat identifies the transcript layout written by an agent CLI.
+type TranscriptFormat string
+
+const (
+	// TranscriptClaude is Claude Code's ~/.claude/projects/<project>/<session>.jsonl.
+	TranscriptClaude TranscriptFormat = "claude"
+	// TranscriptCodex is Codex's ~/.codex/sessions/YYYY/MM/DD/rollout-*.jsonl.
+	TranscriptCodex TranscriptFormat = "codex"
+	// TranscriptGemini is Gemini CLI's ~/.gemini/tmp/<project hash>/chats/session-*.json.
+	TranscriptGemini TranscriptFormat = "gemini"
+)
+
+// TranscriptFormatFor returns the transcript format for an agent type,
+// falling back to the path layout when the type is unknown.
+func TranscriptFormatFor(agentType, path string) TranscriptFormat {
+	switch agentType {
+	case "cc", "claude":
+		return TranscriptClaude
+	case "cod", "codex":
+		return TranscriptCodex
+	case "gmi", "gemini":
+		return TranscriptGemini
+	}
+	switch {
+	case strings.HasSuffix(path, ".json"):
+		return TranscriptGemini
+	case strings.HasPrefix(filepath.Base(path), "rollout-"):
+		return TranscriptCodex
+	default:
+		return TranscriptClaude
+	}
+}
+
+// TranscriptUsage is the token usage reported in an agent transcript.
+// Totals are cumulative over the session; ContextTokens is the size of the
+// most recent turn, which is what currently occupies the context window.
+type TranscriptUsage struct {
+	Path                string           `json:"path"`
+	Format              TranscriptFormat `json:"format"`
+	Model               string           `json:"model,omitempty"`
+	InputTokens         int64            `json:"input_tokens"`
+	OutputTokens        int64            `json:"output_tokens"`
+	CacheReadTokens     int64            `json:"cache_read_tokens"`
+	CacheCreationTokens int64            `json:"cache_creation_tokens"`
+	ReasoningTokens     int64            `json:"reasoning_tokens,omitempty"` // Included in OutputTokens
+	ContextTokens       int64            `json:"context_tokens"`
+	ContextWindow       int64            `json:"context_window,omitempty"` // Reported by the agent, 0 if unknown
+	Turns               int              `json:"turns"`
+	UpdatedAt           time.Time        `json:"updated_at"`
+
+	// LongContext is the part of the totals from turns whose prompt
+	// exceeded LongContextThreshold, which some models bill at higher rates.
+	LongContext TokenCounts `json:"long_context"`
+	// Batch is the part of the totals from other turns served by the batch
+	// tier, which providers bill at a discount.
+	Batch TokenCounts `json:"batch"`
+}
+
+// LongContextThreshold is the prompt size, in tokens, above which providers
+// bill a request at long-context rates.
+const LongContextThreshold = cost.LongContextThreshold
+
+// TokenCounts is a set of token counts by category.
+type TokenCounts struct {
+	InputTokens         int64 `json:"input_tokens"`
+	OutputTokens        int64 `json:"output_tokens"`
+	CacheReadTokens     int64 `json:"cache_read_tokens"`
+	CacheCreationTokens int64 `json:"cache_creation_tokens"`
+	ReasoningTokens     int64 `json:"reasoning_tokens"`
+}
+
+func (c *TokenCounts) add(o TokenCounts, sign int64) {
+	c.InputTokens += sign * o.InputTokens
+	c.OutputTokens += sign * o.OutputTokens
+	c.CacheReadTokens += sign * o.CacheReadTokens
+	c.CacheCreationTokens += sign * o.CacheCreationTokens
+	c.ReasoningTokens += sign * o.ReasoningTokens
+}
+
+// TotalInputTokens returns uncached input plus cache reads and writes.
+func (u *TranscriptUsage) TotalInputTokens() int64 {
+	return u.InputTokens + u.CacheReadTokens + u.CacheCreationTokens
+}
+
+// AgentCost converts the usage into a priced cost record. fallbackModel is
+// used when the transcript names no model.
+func (u *TranscriptUsage) AgentCost(fallbackModel string) *cost.AgentCost {
+	model := u.Model
+	if model == "" {
+		model = fallbackModel
+	}
+	a := &cost.AgentCost{
+		InputTokens:      int(u.InputTokens),
+		OutputTokens:     int(u.OutputTokens),
+		CacheReadTokens:  int(u.CacheReadTokens),
+		CacheWriteTokens: int(u.CacheCreationTokens),
+		ReasoningTokens:  int(u.ReasoningTokens),
+		Model:            model,
+		Exact:            true,
+		LastUpdated:      u.UpdatedAt,
+	}
+	if lc := u.LongContext; lc != (TokenCounts{}) {
+		a.LongContext = lc.usage()
+	}
+	if bt := u.Batch; bt != (TokenCounts{}) {
+		a.Batch = bt.usage()
+	}
+	return a
+}
+
+func (c TokenCounts) usage() *cost.Usage {
+	return &cost.Usage{
+		Input:      int(c.InputTokens),
+		Output:     int(c.OutputTokens),
+		CacheRead:  int(c.CacheReadTokens),
+		CacheWrite: int(c.CacheCreationTokens),
+		Reasoning:  int(c.ReasoningTokens),
+	}
+}
+
+// ContextLimit returns the reported context window, or the known limit for
+// the transcript's model (or fallbackModel if the transcript names none).
+func (u *TranscriptUsage) ContextLimit(fallbackModel string) int64 {
+	if u.ContextWindow > 0 {
+		return u.ContextWindow
+	}
+	model := u.Model
+	if model == "" {
+		model = fallbackModel
+	}
+	return GetContextLimit(model)
+}
+
+// TranscriptTailer incrementally reads one transcript file. JSONL
+// transcripts are read from the last complete line; Gemini's JSON session
+// files are re-parsed whenever they change.
+type TranscriptTailer struct {
+	path   string
+	format TranscriptFormat
+
+	offset  int64
+	size    int64
+	modTime time.Time
+	usage   TranscriptUsage
+
+	// Claude Code writes one line per content block, each repeating the
+	// message's usage, so the last message is tracked to avoid double counts.
+	lastMsgID    string
+	lastMsgUsage claudeUsage
+
+	lastCodexTotal codexTokenUsage
+}
+
+// NewTranscriptTailer creates a tailer for path.
+func NewTranscriptTailer(path string, format TranscriptFormat) *TranscriptTailer {
+	t := &TranscriptTailer{path: path, format: format}
+	t.reset()
+	return t
+}
+
+func (t *TranscriptTailer) reset() {
+	t.offset = 0
+	t.size = 0
+	t.modTime = time.Time{}
+	t.usage = TranscriptUsage{Path: t.path, Format: t.format}
+	t.lastMsgID = ""
+	t.lastMsgUsage = claudeUsage{}
+	t.lastCodexTotal = codexTokenUsage{}
+}
+
+// Poll reads anything appended since the last call and returns the current
+// usage. A missing file yields nil usage and no error.
+func (t *TranscriptTailer) Poll() (*TranscriptUsage, error) {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("stat transcript: %w", err)
+	}
+
+	if t.format == TranscriptGemini {
+		if info.Size() != t.size || !info.ModTime().Equal(t.modTime) {
+			if err := t.readGemini(); err != nil {
+				return nil, err
+			}
+			t.size, t.modTime = info.Size(), info.ModTime()
+		}
+		return t.snapshot(), nil
+	}
+
+	// A shorter file was rewritten; start over.
+	if info.Size() < t.offset {
+		t.reset()
+	}
+	if info.Size() > t.offset {
+		if err := t.readAppended(); err != nil {
+			return nil, err
+		}
+	}
+	return t.snapshot(), nil
+}
+
+func (t *TranscriptTailer) snapshot() *TranscriptUsage {
+	u := t.usage
+	return &u
+}
+
+// readAppended parses complete lines after the current offset. A trailing
+// partial line is left for the next poll.
+func (t *TranscriptTailer) readAppended() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return fmt.Errorf("open transcript: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(t.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seek transcript: %w", err)
+	}
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read transcript: %w", err)
+		}
+		t.offset += int64(len(line))
+		t.parseLine(bytes.TrimSpace(line))
+	}
+}
+
+func (t *TranscriptTailer) parseLine(line []byte) {
+	switch t.format {
+	case TranscriptCodex:
+		t.parseCodexLine(line)
+	default:
+		t.parseClaudeLine(line)
+	}
+}
+
+type claudeUsage struct {
+	InputTokens              int64  `json:"input_tokens"`
+	OutputTokens             int64  `json:"output_tokens"`
+	CacheReadInputTokens     int64  `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens int64  `json:"cache_creation_input_tokens"`
+	ServiceTier              string `json:"service_tier"`
+}
+
+func (u claudeUsage) counts() TokenCounts {
+	return TokenCounts{
+		InputTokens:         u.InputTokens,
+		OutputTokens:        u.OutputTokens,
+		CacheReadTokens:     u.CacheReadInputTokens,
+		CacheCreationTokens: u.CacheCreationInputTokens,
+	}
+}
+
+func (u claudeUsage) longContext() bool {
+	return u.InputTokens+u.CacheReadInputTokens+u.CacheCreationInputTokens > LongContextThreshold
+}
+
+// addClaude adds (sign 1) or removes (sign -1) a message's usage.
+func (t *TranscriptTailer) addClaude(u claudeUsage, sign int64) {
+	t.usage.InputTokens += sign * u.InputTokens
+	t.usage.OutputTokens += sign * u.OutputTokens
+	t.usage.CacheReadTokens += sign * u.CacheReadInputTokens
+	t.usage.CacheCreationTokens += sign * u.CacheCreationInputTokens
+	if u.longContext() {
+		t.usage.LongContext.add(u.counts(), sign)
+	} else if u.ServiceTier == "batch" {
+		t.usage.Batch.add(u.counts(), sign)
+	}
+}
+
+type claudeEntry struct {
+	Type        string    `json:"type"`
+	IsSidechain bool      `json:"isSidechain"`
+	Timestamp   time.Time `json:"timestamp"`
+	Message     struct {
+		ID    string       `json:"id"`
+		Model string       `json:"model"`
+		Usage *claudeUsage `json:"usage"`
+	} `json:"message"`
+}
+
+func (t *TranscriptTailer) parseClaudeLine(line []byte) {
+	if !bytes.Contains(line, []byte(`"usage"`)) {
+		return
+	}
+	var entry claudeEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return // Skip malformed lines
+	}
+	u := entry.Message.Usage
+	if entry.Type != "assistant" || u == nil {
+		return
+	}
+
+	// Repeated lines for the same message replace its earlier usage.
+	if entry.Message.ID != "" && entry.Message.ID == t.lastMsgID {
+		t.addClaude(t.lastMsgUsage, -1)
+	} else {
+		t.usage.Turns++
+	}
+	t.lastMsgID = entry.Message.ID
+	t.lastMsgUsage = *u
+	t.addClaude(*u, 1)
+
+	// Subagent (sidechain) turns cost tokens but do not fill the main context.
+	if !entry.IsSidechain {
+		t.usage.ContextTokens = u.InputTokens + u.CacheReadInputTokens + u.CacheCreationInputTokens + u.OutputTokens
+		if m := entry.Message.Model; m != "" && !strings.HasPrefix(m, "<") {
+			t.usage.Model = m
+		}
+	}
+	t.usage.UpdatedAt = entryTime(entry.Timestamp)
+}
+
+type codexTokenUsage struct {
+	InputTokens           int64 `json:"input_tokens"`
+	CachedInputTokens     int64 `json:"cached_input_tokens"`
+	OutputTokens          int64 `json:"output_tokens"`
+	ReasoningOutputTokens int64 `json:"reasoning_output_tokens"`
+}
+
+type codexEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Payload   struct {
+		Type  string `json:"type"`
+		Model string `json:"model"`
+		Cwd   string `json:"cwd"`
+		Info  *struct {
+			TotalTokenUsage    codexTokenUsage `json:"total_token_usage"`
+			LastTokenUsage     codexTokenUsage `json:"last_token_usage"`
+			ModelContextWindow int64           `json:"model_context_window"`
+		} `json:"info"`
+	} `json:"payload"`
+}
+
+func (t *TranscriptTailer) parseCodexLine(line []byte) {
+	if !bytes.Contains(line, []byte(`"token_count"`)) && !bytes.Contains(line, []byte(`"turn_context"`)) {
+		return
+	}
+	var entry codexEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return
+	}
+
+	if entry.Type == "turn_context" {
+		if entry.Payload.Model != "" {
+			t.usage.Model = entry.Payload.Model
+		}
+		return
+	}
+	info := entry.Payload.Info
+	if entry.Payload.Type != "token_count" || info == nil {
+		return
+	}
+
+	// Codex reports running totals; cached input is part of input_tokens
+	// and reasoning is part of output_tokens.
+	total := info.TotalTokenUsage
+	t.usage.InputTokens = total.InputTokens - total.CachedInputTokens
+	t.usage.CacheReadTokens = total.CachedInputTokens
+	t.usage.OutputTokens = total.OutputTokens
+	t.usage.ReasoningTokens = total.ReasoningOutputTokens
+
+	// The same totals are re-sent without a new request; count each
+	// request's long-context usage once.
+	last := info.LastTokenUsage
+	if total != t.lastCodexTotal && last.InputTokens > LongContextThreshold {
+		t.usage.LongContext.add(TokenCounts{
+			InputTokens:     last.InputTokens - last.CachedInputTokens,
+			OutputTokens:    last.OutputTokens,
+			CacheReadTokens: last.CachedInputTokens,
+			ReasoningTokens: last.ReasoningOutputTokens,
+		}, 1)
+	}
+	t.lastCodexTotal = total
+	t.usage.ContextTokens = info.LastTokenUsage.InputTokens + info.LastTokenUsage.OutputTokens
+	if info.ModelContextWindow > 0 {
+		t.usage.ContextWindow = info.ModelContextWindow
+	}
+	t.usage.Turns++
+	t.usage.UpdatedAt = entryTime(entry.Timestamp)
+}
+
+type geminiSession struct {
+	Messages []struct {
+		Type      string    `json:"type"`
+		Model     string    `json:"model"`
+		Timestamp time.Time `json:"timestamp"`
+		Tokens    *struct {
+			Input    int64 `json:"input"`
+			Output   int64 `json:"output"`
+			Cached   int64 `json:"cached"`
+			Thoughts int64 `json:"thoughts"`
+		} `json:"tokens"`
+	} `json:"messages"`
+}
+
+func (t *TranscriptTailer) readGemini() error {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read transcript: %w", err)
+	}
+	var session geminiSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		// Gemini rewrites the whole file; a torn read is retried next poll.
+		return nil
+	}
+
+	usage := TranscriptUsage{Path: t.path, Format: t.format}
+	for _, msg := range session.Messages {
+		if msg.Tokens == nil {
+			continue
+		}
+		tok := msg.Tokens
+		// Gemini's input count includes cached tokens.
+		usage.InputTokens += tok.Input - tok.Cached
+		usage.CacheReadTokens += tok.Cached
+		usage.OutputTokens += tok.Output + tok.Thoughts
+		usage.ReasoningTokens += tok.Thoughts
+		if tok.Input > LongContextThreshold {
+			usage.LongContext.add(TokenCounts{
+				InputTokens:     tok.Input - tok.Cached,
+				OutputTokens:    tok.Output + tok.Thoughts,
+				CacheReadTokens: tok.Cached,
+				ReasoningTokens: tok.Thoughts,
+			}, 1)
+		}
+		usage.ContextTokens = tok.Input + tok.Output
+		usage.Turns++
+		if msg.Model != "" {
+			usage.Model = msg.Model
+		}
+		usage.UpdatedAt = entryTime(msg.Timestamp)
+	}
+	t.usage = usage
+	return nil
+}
+
+func entryTime(ts time.Time) time.Time {
+	if ts.IsZero() {
+		return time.Now()
+	}
+	return ts
+}
+
+// TranscriptLocator finds the transcript files agent CLIs write for a
+// working directory.
+type TranscriptLocator struct {
+	Home   string        // Home directory holding .claude, .codex and .gemini
+	MaxAge time.Duration // Ignore transcripts not written within this window (default 12h)
+}
+
+// DefaultTranscriptLocator returns a locator rooted at the user's home directory.
+func DefaultTranscriptLocator() *TranscriptLocator {
+	home, _ := os.UserHomeDir()
+	return &TranscriptLocator{Home: home, MaxAge: 12 * time.Hour}
+}
+
+// claudeProjectDirRegex matches the characters Claude Code replaces when
+// naming a project directory after its working directory.
+var claudeProjectDirRegex = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// ClaudeProjectDir returns the directory holding Claude Code transcripts for workDir.
+func (l *TranscriptLocator) ClaudeProjectDir(workDir string) string {
+	return filepath.Join(l.Home, ".claude", "projects", claudeProjectDirRegex.ReplaceAllString(workDir, "-"))
+}
+
+// GeminiChatsDir returns the directory holding Gemini CLI sessions for workDir.
+func (l *TranscriptLocator) GeminiChatsDir(workDir string) string {
+	sum := sha256.Sum256([]byte(workDir))
+	return filepath.Join(l.Home, ".gemini", "tmp", hex.EncodeToString(sum[:]), "chats")
+}
+
+// transcriptFile is a candidate transcript and when it was last written.
+type transcriptFile struct {
+	path    string
+	modTime time.Time
+}
+
+// Candidates returns recent transcripts for agentType in workDir, newest first.
+func (l *TranscriptLocator) Candidates(agentType, workDir string) ([]transcriptFile, error) {
+	if l.Home == "" || workDir == "" {
+		return nil, nil
+	}
+	workDir = filepath.Clean(workDir)
+
+	var pattern string
+	format := TranscriptFormatFor(agentType, "")
+	switch format {
+	case TranscriptClaude:
+		pattern = filepath.Join(l.ClaudeProjectDir(workDir), "*.jsonl")
+	case TranscriptCodex:
+		pattern = filepath.Join(l.Home, ".codex", "sessions", "*", "*", "*", "rollout-*.jsonl")
+	case TranscriptGemini:
+		pattern = filepath.Join(l.GeminiChatsDir(workDir), "session-*.json")
+	}
+
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("glob transcripts: %w", err)
+	}
+
+	maxAge := l.MaxAge
+	if maxAge <= 0 {
+		maxAge = 12 * time.Hour
+	}
+	cutoff := time.Now().Add(-maxAge)
+
+	var files []transcriptFile
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil || info.ModTime().Before(cutoff) {
+			continue
+		}
+		// Codex keeps every project's sessions together; match on cwd.
+		if format == TranscriptCodex && codexSessionCwd(p) != workDir {
+			continue
+		}
+		files = append(files, transcriptFile{path: p, modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].modTime.Equal(files[j].modTime) {
+			return files[i].modTime.After(files[j].modTime)
+		}
+		return files[i].path > files[j].path
+	})
+	return files, nil
+}
+
+// codexSessionCwd reads the working directory from a Codex session's
+// leading session_meta line.
+func codexSessionCwd(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 16*1024)
+	line, err := r.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return ""
+	}
+	var entry codexEntry
+	if json.Unmarshal(line, &entry) != nil || entry.Type != "session_meta" || entry.Payload.Cwd == "" {
+		return ""
+	}
+	return filepath.Clean(entry.Payload.Cwd)
+}
+
+// transcriptSwitchIdle is how long a pane's transcript must go unwritten
+// before a newer unclaimed transcript is assumed to belong to it, as happens
+// when the agent is restarted or rotated.
+const transcriptSwitchIdle = 2 * time.Minute
+
+// TranscriptEstimator reads exact context usage from agent transcripts. It
+// also maps panes to transcript files, giving each pane the newest
+// transcript not already claimed by another pane.
+type TranscriptEstimator struct {
+	locator *TranscriptLocator
+
+	mu       sync.Mutex
+	tailers  map[string]*TranscriptTailer // path -> tailer
+	assigned map[string]string            // pane or agent key -> path
+	retired  map[string]bool              // paths of rotated-away sessions
+}
+
+// NewTranscriptEstimator creates an estimator that discovers transcripts
+// with locator. A nil locator disables discovery; explicit paths still work.
+func NewTranscriptEstimator(locator *TranscriptLocator) *TranscriptEstimator {
+	return &TranscriptEstimator{
+		locator:  locator,
+		tailers:  make(map[string]*TranscriptTailer),
+		assigned: make(map[string]string),
+		retired:  make(map[string]bool),
+	}
+}
+
+// Name returns the estimator name.
+func (e *TranscriptEstimator) Name() string { return "transcript" }
+
+// Confidence returns the base confidence for this strategy.
+func (e *TranscriptEstimator) Confidence() float64 { return 0.98 }
+
+// Estimate reports the context size of the agent's latest transcript turn.
+func (e *TranscriptEstimator) Estimate(state *ContextState) (*ContextEstimate, error) {
+	if state.TranscriptPath == "" {
+		return nil, nil
+	}
+	usage, err := e.Read(state.TranscriptPath, state.AgentType)
+	if err != nil || usage == nil || usage.Turns == 0 {
+		return nil, err
+	}
+
+	limit := usage.ContextLimit(state.Model)
+	model := usage.Model
+	if model == "" {
+		model = state.Model
+	}
+	return &ContextEstimate{
+		TokensUsed:   usage.ContextTokens,
+		ContextLimit: limit,
+		UsagePercent: float64(usage.ContextTokens) / float64(limit) * 100,
+		Confidence:   e.Confidence(),
+		Method:       MethodTranscript,
+		Model:        model,
+		UpdatedAt:    usage.UpdatedAt,
+	}, nil
+}
+
+// Read polls the transcript at path and returns its usage, or nil if the
+// file does not exist yet.
+func (e *TranscriptEstimator) Read(path, agentType string) (*TranscriptUsage, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	tailer, ok := e.tailers[path]
+	if !ok {
+		tailer = NewTranscriptTailer(path, TranscriptFormatFor(agentType, path))
+		e.tailers[path] = tailer
+	}
+	return tailer.Poll()
+}
+
+// Locate returns the transcript for key (a pane or agent ID), assigning the
+// newest unclaimed transcript for agentType in workDir on first use.
+// Returns "" if none has been written yet.
+func (e *TranscriptEstimator) Locate(key, agentType, workDir string) string {
+	if e.locator == nil {
+		return e.assignedPath(key)
+	}
+	candidates, err := e.locator.Candidates(agentType, workDir)
+	if err != nil {
+		return e.assignedPath(key)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	current := e.assigned[key]
+	var currentMod time.Time
+	claimed := make(map[string]bool, len(e.assigned))
+	for k, p := range e.assigned {
+		if k != key {
+			claimed[p] = true
+		}
+	}
+	for _, c := range candidates {
+		if c.path == current {
+			currentMod = c.modTime
+			break
+		}
+	}
+
+	for _, c := range candidates {
+		if claimed[c.path] || e.retired[c.path] || c.path == current {
+			continue
+		}
+		// Keep an active transcript; move on once it has gone quiet and a
+		// newer one has appeared.
+		if current != "" && !currentMod.IsZero() &&
+			(time.Since(currentMod) < transcriptSwitchIdle || !c.modTime.After(currentMod)) {
+			break
+		}
+		e.assigned[key] = c.path
+		return c.path
+	}
+	return current
+}
+
+func (e *TranscriptEstimator) assignedPath(key string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.assigned[key]
+}
+
+// PaneUsage locates and reads the transcript for a pane in one step.
+func (e *TranscriptEstimator) PaneUsage(key, agentType, workDir string) *TranscriptUsage {
+	path := e.Locate(key, agentType, workDir)
+	if path == "" {
+		return nil
+	}
+	usage, err := e.Read(path, agentType)
+	if err != nil || usage == nil || usage.Turns == 0 {
+		return nil
+	}
+	return usage
+}
+
+// Forget drops the transcript assignment for key, e.g. when a pane closes.
+func (e *TranscriptEstimator) Forget(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	path, ok := e.assigned[key]
+	if !ok {
+		return
+	}
+	delete(e.assigned, key)
+	for _, p := range e.assigned {
+		if p == path {
+			return
+		}
+	}
+	delete(e.tailers, path)
+}
+
+// Retire forgets key's transcript and never assigns that file again. Use it
+// after rotating an agent so the old session's usage is not picked back up
+// before the new agent writes its own transcript.
+func (e *TranscriptEstimator) Retire(key string) {
+	e.mu.Lock()
+	if path, ok := e.assigned[key]; ok {
+		e.retired[path] = true
+	}
+	e.mu.Unlock()
+	e.Forget(key)
+}
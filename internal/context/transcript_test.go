@@ -0,0 +1,267 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func claudeLine(id, model string, sidechain bool, input, cacheRead, cacheWrite, output int) string {
+	return fmt.Sprintf(`{"type":"assistant","isSidechain":%v,"timestamp":"2026-01-02T10:00:00Z","message":{"id":%q,"model":%q,"usage":{"input_tokens":%d,"cache_read_input_tokens":%d,"cache_creation_input_tokens":%d,"output_tokens":%d}}}`+"\n",
+		sidechain, id, model, input, cacheRead, cacheWrite, output)
+}
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTranscriptTailer_Claude(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	appendFile(t, path, `{"type":"user","message":{"role":"user","content":"hi"}}`+"\n"+
+		claudeLine("msg_1", "claude-opus-4-5-20251101", false, 10, 0, 5000, 200)+
+		// The same message repeated for a second content block
+		claudeLine("msg_1", "claude-opus-4-5-20251101", false, 10, 0, 5000, 250))
+
+	tailer := NewTranscriptTailer(path, TranscriptClaude)
+	u, err := tailer.Poll()
+	if err != nil {
+		t.Fatalf("Poll() error: %v", err)
+	}
+	if u.Turns != 1 || u.InputTokens != 10 || u.CacheCreationTokens != 5000 || u.OutputTokens != 250 {
+		t.Errorf("after first message: %+v", u)
+	}
+	if u.ContextTokens != 5260 || u.Model != "claude-opus-4-5-20251101" {
+		t.Errorf("context=%d model=%q", u.ContextTokens, u.Model)
+	}
+
+	// A subagent turn adds cost but not context; a partial line waits.
+	second := claudeLine("msg_3", "claude-opus-4-5-20251101", false, 5, 5000, 100, 80)
+	appendFile(t, path, claudeLine("msg_2", "claude-haiku-4-5", true, 300, 0, 0, 40)+second[:30])
+	u, _ = tailer.Poll()
+	if u.Turns != 2 || u.InputTokens != 310 || u.ContextTokens != 5260 || u.Model != "claude-opus-4-5-20251101" {
+		t.Errorf("after sidechain: %+v", u)
+	}
+
+	appendFile(t, path, second[30:])
+	u, _ = tailer.Poll()
+	if u.Turns != 3 || u.CacheReadTokens != 5000 || u.ContextTokens != 5185 {
+		t.Errorf("after completed line: %+v", u)
+	}
+	if got := u.TotalInputTokens(); got != 315+5000+5100 {
+		t.Errorf("TotalInputTokens() = %d", got)
+	}
+
+	// A rewritten (shorter) file is read from the start.
+	if err := os.WriteFile(path, []byte(claudeLine("msg_9", "", false, 1, 0, 0, 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	u, _ = tailer.Poll()
+	if u.Turns != 1 || u.ContextTokens != 2 {
+		t.Errorf("after rewrite: %+v", u)
+	}
+}
+
+func TestTranscriptTailer_CodexAndGemini(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	codex := filepath.Join(dir, "rollout-2026-01-02T10-00-00-abc.jsonl")
+	appendFile(t, codex, `{"type":"session_meta","payload":{"cwd":"/work/proj"}}
+{"type":"turn_context","payload":{"cwd":"/work/proj","model":"gpt-5-codex"}}
+{"type":"event_msg","payload":{"type":"token_count","info":null}}
+{"type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":9000,"cached_input_tokens":6000,"output_tokens":700},"last_token_usage":{"input_tokens":5000,"cached_input_tokens":4000,"output_tokens":300},"model_context_window":272000}}}
+`)
+	u, err := NewTranscriptTailer(codex, TranscriptFormatFor("", codex)).Poll()
+	if err != nil {
+		t.Fatalf("Poll() error: %v", err)
+	}
+	if u.Format != TranscriptCodex || u.InputTokens != 3000 || u.CacheReadTokens != 6000 || u.OutputTokens != 700 {
+		t.Errorf("codex totals: %+v", u)
+	}
+	if u.ContextTokens != 5300 || u.ContextLimit("") != 272000 || u.Model != "gpt-5-codex" {
+		t.Errorf("codex context=%d limit=%d model=%q", u.ContextTokens, u.ContextLimit(""), u.Model)
+	}
+
+	gemini := filepath.Join(dir, "session-1.json")
+	if err := os.WriteFile(gemini, []byte(`{"sessionId":"s","messages":[
+{"type":"user","content":"hi"},
+{"type":"gemini","model":"gemini-2.5-pro","tokens":{"input":1200,"output":100,"cached":1000,"thoughts":50}},
+{"type":"gemini","model":"gemini-2.5-pro","tokens":{"input":2000,"output":300,"cached":0,"thoughts":0}}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	u, err = NewTranscriptTailer(gemini, TranscriptGemini).Poll()
+	if err != nil {
+		t.Fatalf("Poll() error: %v", err)
+	}
+	if u.Turns != 2 || u.InputTokens != 2200 || u.CacheReadTokens != 1000 || u.OutputTokens != 450 || u.ContextTokens != 2300 {
+		t.Errorf("gemini usage: %+v", u)
+	}
+	if u.ReasoningTokens != 50 {
+		t.Errorf("gemini reasoning = %d, want 50", u.ReasoningTokens)
+	}
+}
+
+func TestTranscriptTailer_LongContextAndReasoning(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	claude := filepath.Join(dir, "claude.jsonl")
+	appendFile(t, claude, claudeLine("m1", "claude-sonnet-4-5", false, 10, 150000, 0, 100)+
+		claudeLine("m2", "claude-sonnet-4-5", false, 20, 190000, 20000, 300)+
+		claudeLine("m2", "claude-sonnet-4-5", false, 20, 190000, 20000, 400))
+	u, err := NewTranscriptTailer(claude, TranscriptClaude).Poll()
+	if err != nil {
+		t.Fatalf("Poll() error: %v", err)
+	}
+	want := TokenCounts{InputTokens: 20, OutputTokens: 400, CacheReadTokens: 190000, CacheCreationTokens: 20000}
+	if u.LongContext != want {
+		t.Errorf("claude long context = %+v, want %+v", u.LongContext, want)
+	}
+
+	// Batch-tier turns below the threshold are counted apart.
+	appendFile(t, claude, `{"type":"assistant","timestamp":"2026-01-02T10:01:00Z","message":{"id":"m3","model":"claude-sonnet-4-5","usage":{"input_tokens":500,"output_tokens":50,"service_tier":"batch"}}}`+"\n")
+	if u, err = NewTranscriptTailer(claude, TranscriptClaude).Poll(); err != nil {
+		t.Fatalf("Poll() error: %v", err)
+	}
+	if bt := (TokenCounts{InputTokens: 500, OutputTokens: 50}); u.Batch != bt || u.LongContext != want {
+		t.Errorf("claude batch = %+v, long context = %+v", u.Batch, u.LongContext)
+	}
+	if a := u.AgentCost(""); a.Batch == nil || a.Batch.Input != 500 {
+		t.Errorf("AgentCost batch = %+v", a.Batch)
+	}
+
+	codex := filepath.Join(dir, "rollout.jsonl")
+	tokenCount := func(totalIn, totalOut, lastIn, lastOut int) string {
+		return fmt.Sprintf(`{"type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":%d,"cached_input_tokens":0,"output_tokens":%d,"reasoning_output_tokens":%d},"last_token_usage":{"input_tokens":%d,"cached_input_tokens":0,"output_tokens":%d,"reasoning_output_tokens":%d}}}}`+"\n",
+			totalIn, totalOut, totalOut/2, lastIn, lastOut, lastOut/2)
+	}
+	appendFile(t, codex, tokenCount(1000, 100, 1000, 100)+
+		tokenCount(251000, 300, 250000, 200)+
+		tokenCount(251000, 300, 250000, 200)) // repeated totals, no new request
+	u, err = NewTranscriptTailer(codex, TranscriptCodex).Poll()
+	if err != nil {
+		t.Fatalf("Poll() error: %v", err)
+	}
+	if u.ReasoningTokens != 150 || u.OutputTokens != 300 {
+		t.Errorf("codex reasoning = %d of %d output", u.ReasoningTokens, u.OutputTokens)
+	}
+	want = TokenCounts{InputTokens: 250000, OutputTokens: 200, ReasoningTokens: 100}
+	if u.LongContext != want {
+		t.Errorf("codex long context = %+v, want %+v", u.LongContext, want)
+	}
+}
+
+func TestTranscriptEstimator_Locate(t *testing.T) {
+	t.Parallel()
+	home := t.TempDir()
+	loc := &TranscriptLocator{Home: home}
+	workDir := "/work/my.proj"
+
+	projDir := loc.ClaudeProjectDir(workDir)
+	if filepath.Base(projDir) != "-work-my-proj" {
+		t.Fatalf("ClaudeProjectDir() = %q", projDir)
+	}
+	if err := os.MkdirAll(projDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	write := func(name string, age time.Duration) string {
+		p := filepath.Join(projDir, name)
+		appendFile(t, p, claudeLine(name, "claude-sonnet-4", false, 1, 0, 0, 1))
+		if err := os.Chtimes(p, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	older := write("a.jsonl", 10*time.Minute)
+	newer := write("b.jsonl", time.Minute)
+	write("stale.jsonl", 48*time.Hour)
+
+	e := NewTranscriptEstimator(loc)
+	if got := e.Locate("%1", "cc", workDir); got != newer {
+		t.Errorf("first pane got %q, want newest", got)
+	}
+	if got := e.Locate("%2", "cc", workDir); got != older {
+		t.Errorf("second pane got %q, want the unclaimed one", got)
+	}
+	if got := e.Locate("%3", "cc", workDir); got != "" {
+		t.Errorf("third pane got %q, want none (stale transcripts ignored)", got)
+	}
+	if got := e.Locate("%1", "cc", workDir); got != newer {
+		t.Errorf("assignment not sticky: %q", got)
+	}
+
+	// After rotation the retired transcript is never reassigned, and the
+	// new session's transcript is picked up once written.
+	e.Retire("%1")
+	if got := e.Locate("%1", "cc", workDir); got != "" {
+		t.Errorf("rotated pane got %q before writing a new transcript", got)
+	}
+	fresh := write("c.jsonl", 0)
+	if got := e.Locate("%1", "cc", workDir); got != fresh {
+		t.Errorf("rotated pane got %q, want %q", got, fresh)
+	}
+	if u := e.PaneUsage("%1", "cc", workDir); u == nil || u.Turns != 1 {
+		t.Errorf("PaneUsage() = %+v", u)
+	}
+}
+
+func TestContextMonitor_TranscriptEstimate(t *testing.T) {
+	t.Parallel()
+	home := t.TempDir()
+	loc := &TranscriptLocator{Home: home}
+	workDir := "/work/proj"
+	if err := os.MkdirAll(loc.ClaudeProjectDir(workDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(loc.ClaudeProjectDir(workDir), "s.jsonl")
+	appendFile(t, path, claudeLine("m1", "claude-sonnet-4", false, 20, 140000, 0, 1000))
+
+	cfg := DefaultMonitorConfig()
+	cfg.TranscriptLocator = loc
+	monitor := NewContextMonitor(cfg)
+	monitor.RegisterAgent("agent-1", "%1", "claude-sonnet-4")
+	monitor.SetAgentType("agent-1", "cc")
+	// Heuristics alone would put this agent far lower.
+	monitor.RecordMessage("agent-1", 100, 100)
+
+	if got := monitor.DiscoverTranscript("agent-1", workDir); got != path {
+		t.Fatalf("DiscoverTranscript() = %q, want %q", got, path)
+	}
+	est := monitor.GetEstimate("agent-1")
+	if est == nil || est.Method != MethodTranscript || est.TokensUsed != 141020 {
+		t.Fatalf("GetEstimate() = %+v", est)
+	}
+	if rec := monitor.ShouldTriggerHandoff("agent-1", nil); !rec.ShouldWarn {
+		t.Errorf("70%% transcript usage did not warn: %+v", rec)
+	}
+
+	tokens, err := monitor.UpdateFromTranscript("agent-1")
+	if err != nil || tokens != 141020 {
+		t.Errorf("UpdateFromTranscript() = %d, %v", tokens, err)
+	}
+	if u := monitor.TranscriptUsage("agent-1"); u == nil || u.CacheReadTokens != 140000 {
+		t.Errorf("TranscriptUsage() = %+v", u)
+	}
+
+	// Rotation drops the old transcript instead of re-reading its usage.
+	monitor.ResetAgent("agent-1")
+	if est := monitor.GetEstimate("agent-1"); est != nil && est.Method == MethodTranscript {
+		t.Errorf("estimate after reset still from transcript: %+v", est)
+	}
+	if got := monitor.DiscoverTranscript("agent-1", workDir); strings.HasSuffix(got, "s.jsonl") {
+		t.Errorf("rotated agent rediscovered its old transcript")
+	}
+}
@@ -132,6 +132,8 @@ type CoordinatorEvent struct {
 	Details   map[string]any       `json:"details,omitempty"`
 }
 
+// busCoordinatorEvent is published on the event bus. Its JSON shape is
+// registered as events.AgentStateEvent for the agent.* state types.
 type busCoordinatorEvent struct {
 	events.BaseEvent
 	AgentID  string         `json:"agent_id,omitempty"`
@@ -269,6 +269,12 @@ func TestEmitEvent_PublishesToEventsBus(t *testing.T) {
 			if typed.NewType != string(robot.StateWaiting) {
 				t.Fatalf("NewType=%q, want %q", typed.NewType, string(robot.StateWaiting))
 			}
+			if typed.Details["pane_index"] != 2 {
+				t.Fatalf("Details[pane_index]=%v, want 2", typed.Details["pane_index"])
+			}
+			if err := events.ValidateEvent(typed); err != nil {
+				t.Fatalf("ValidateEvent() error: %v", err)
+			}
 			return
 		}
 
@@ -0,0 +1,361 @@
+package cost
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+// CatalogVersion is the newest pricing catalog schema this build understands.
+const CatalogVersion = 1
+
+// LongContextThreshold is the prompt size, in tokens, above which a request
+// is billed at a model's long-context prices.
+const LongContextThreshold = 200_000
+
+//go:embed pricing.toml
+var builtinCatalogTOML []byte
+
+// Plan is a subscription that covers usage of its models at no marginal cost.
+type Plan struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Models     []string `json:"models"` // Model names; a trailing * matches a prefix
+	MonthlyUSD float64  `json:"monthly_usd,omitempty"`
+	Active     bool     `json:"active"`
+}
+
+// Covers reports whether the plan applies to model.
+func (p Plan) Covers(model string) bool {
+	normalized := normalizeModelName(model)
+	for _, pattern := range p.Models {
+		pattern = strings.ToLower(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(normalized, prefix) {
+				return true
+			}
+		} else if normalized == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// Catalog is a set of model prices and subscription plans, merged from the
+// built-in catalog and optional user and project overrides.
+type Catalog struct {
+	Version  int      `json:"version"`
+	Updated  string   `json:"updated,omitempty"`
+	Sources  []string `json:"sources"`            // "builtin", then override files in precedence order
+	Warnings []string `json:"warnings,omitempty"` // Override files that could not be loaded
+
+	models map[string]ModelPricing
+	plans  map[string]Plan
+}
+
+// catalogFile is the TOML layout of a pricing catalog. Pointer fields tell
+// overrides that leave a value unset apart from ones that set it to zero.
+type catalogFile struct {
+	Version int                   `toml:"version"`
+	Updated string                `toml:"updated"`
+	Models  map[string]modelEntry `toml:"models"`
+	Plans   map[string]planEntry  `toml:"plans"`
+}
+
+type priceEntry struct {
+	Input      *float64 `toml:"input"`
+	Output     *float64 `toml:"output"`
+	CacheRead  *float64 `toml:"cache_read"`
+	CacheWrite *float64 `toml:"cache_write"`
+	Reasoning  *float64 `toml:"reasoning"`
+}
+
+type modelEntry struct {
+	priceEntry
+	BatchDiscount *float64    `toml:"batch_discount"`
+	LongContext   *priceEntry `toml:"long_context"`
+}
+
+type planEntry struct {
+	Name       string   `toml:"name"`
+	Models     []string `toml:"models"`
+	MonthlyUSD *float64 `toml:"monthly_usd"`
+	Active     *bool    `toml:"active"`
+}
+
+// BuiltinCatalog returns the catalog compiled into ntm.
+func BuiltinCatalog() *Catalog {
+	c := &Catalog{models: make(map[string]ModelPricing), plans: make(map[string]Plan)}
+	if err := c.merge(builtinCatalogTOML, "builtin"); err != nil {
+		panic(fmt.Sprintf("cost: invalid builtin pricing catalog: %v", err))
+	}
+	return c
+}
+
+// CatalogLoader loads the pricing catalog with user and project overrides.
+type CatalogLoader struct {
+	// UserConfigDir is the user config directory (default: ~/.config/ntm)
+	UserConfigDir string
+	// ProjectDir is the project directory (for .ntm/pricing.toml)
+	ProjectDir string
+}
+
+// NewCatalogLoader creates a loader with default paths.
+func NewCatalogLoader() *CatalogLoader {
+	projectDir, _ := os.Getwd()
+	return &CatalogLoader{
+		UserConfigDir: defaultUserConfigDir(),
+		ProjectDir:    projectDir,
+	}
+}
+
+func defaultUserConfigDir() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "ntm")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "ntm")
+}
+
+// Paths returns the override files consulted, lowest precedence first.
+func (l *CatalogLoader) Paths() []string {
+	var paths []string
+	if l.UserConfigDir != "" {
+		paths = append(paths, filepath.Join(l.UserConfigDir, "pricing.toml"))
+	}
+	if l.ProjectDir != "" {
+		paths = append(paths, filepath.Join(l.ProjectDir, ".ntm", "pricing.toml"))
+	}
+	return paths
+}
+
+// Load returns the built-in catalog with overrides applied in order:
+// builtin < user (~/.config/ntm/pricing.toml) < project (.ntm/pricing.toml).
+// Missing files are skipped. A file that fails to load is left out and
+// reported both in the returned error and in Catalog.Warnings, so the
+// catalog is always usable.
+func (l *CatalogLoader) Load() (*Catalog, error) {
+	c := BuiltinCatalog()
+	var errs []error
+	for _, path := range l.Paths() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("read %s: %w", path, err))
+			}
+			continue
+		}
+		if err := c.merge(data, path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	for _, err := range errs {
+		c.Warnings = append(c.Warnings, err.Error())
+	}
+	return c, errors.Join(errs...)
+}
+
+// merge applies a catalog file on top of c. Nothing is applied if the file
+// is invalid.
+func (c *Catalog) merge(data []byte, source string) error {
+	var f catalogFile
+	if _, err := toml.Decode(string(data), &f); err != nil {
+		return fmt.Errorf("parse pricing catalog: %w", err)
+	}
+	if f.Version > CatalogVersion {
+		return fmt.Errorf("pricing catalog version %d is newer than supported version %d", f.Version, CatalogVersion)
+	}
+	for name, entry := range f.Models {
+		if err := entry.validate(); err != nil {
+			return fmt.Errorf("model %q: %w", name, err)
+		}
+	}
+
+	for name, entry := range f.Models {
+		key := strings.ToLower(name)
+		c.models[key] = entry.apply(c.models[key])
+	}
+	for id, entry := range f.Plans {
+		p := c.plans[id]
+		p.ID = id
+		if entry.Name != "" {
+			p.Name = entry.Name
+		}
+		if entry.Models != nil {
+			p.Models = entry.Models
+		}
+		if entry.MonthlyUSD != nil {
+			p.MonthlyUSD = *entry.MonthlyUSD
+		}
+		if entry.Active != nil {
+			p.Active = *entry.Active
+		}
+		if p.Name == "" {
+			p.Name = id
+		}
+		c.plans[id] = p
+	}
+	if f.Version > c.Version {
+		c.Version = f.Version
+	}
+	if f.Updated != "" {
+		c.Updated = f.Updated
+	}
+	c.Sources = append(c.Sources, source)
+	return nil
+}
+
+func (e modelEntry) validate() error {
+	prices := []*float64{e.Input, e.Output, e.CacheRead, e.CacheWrite, e.Reasoning}
+	if e.LongContext != nil {
+		prices = append(prices, e.LongContext.Input, e.LongContext.Output,
+			e.LongContext.CacheRead, e.LongContext.CacheWrite, e.LongContext.Reasoning)
+	}
+	for _, p := range prices {
+		if p != nil && *p < 0 {
+			return fmt.Errorf("negative price %v", *p)
+		}
+	}
+	if e.BatchDiscount != nil && (*e.BatchDiscount < 0 || *e.BatchDiscount >= 1) {
+		return fmt.Errorf("batch_discount %v must be in [0, 1)", *e.BatchDiscount)
+	}
+	return nil
+}
+
+// apply overlays the entry's set fields onto base. Catalog files are per
+// million tokens; ModelPricing is per thousand.
+func (e modelEntry) apply(base ModelPricing) ModelPricing {
+	e.priceEntry.apply(&base)
+	if e.BatchDiscount != nil {
+		base.BatchDiscount = *e.BatchDiscount
+	}
+	if e.LongContext != nil {
+		var tier ModelPricing
+		if base.LongContext != nil {
+			tier = *base.LongContext
+		}
+		e.LongContext.apply(&tier)
+		base.LongContext = &tier
+	}
+	return base
+}
+
+func (e priceEntry) apply(p *ModelPricing) {
+	set := func(dst *float64, perMillion *float64) {
+		if perMillion != nil {
+			*dst = *perMillion / 1000
+		}
+	}
+	set(&p.InputPer1K, e.Input)
+	set(&p.OutputPer1K, e.Output)
+	set(&p.CacheReadPer1K, e.CacheRead)
+	set(&p.CacheWritePer1K, e.CacheWrite)
+	set(&p.ReasoningPer1K, e.Reasoning)
+}
+
+// Lookup returns the pricing for model, falling back to the "default"
+// entry. The covering plan's name is set if an active plan applies.
+func (c *Catalog) Lookup(model string) ModelPricing {
+	pricing := c.lookupModel(model)
+	if plan, ok := c.PlanFor(model); ok {
+		pricing.Plan = plan.Name
+	}
+	return pricing
+}
+
+func (c *Catalog) lookupModel(model string) ModelPricing {
+	if pricing, ok := c.models[model]; ok {
+		return pricing
+	}
+
+	normalized := normalizeModelName(model)
+	if pricing, ok := c.models[normalized]; ok {
+		return pricing
+	}
+
+	// Prefix match for variants (longest key first).
+	keys := make([]string, 0, len(c.models))
+	for key := range c.models {
+		if key == "default" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		if strings.HasPrefix(normalized, key) {
+			return c.models[key]
+		}
+	}
+
+	if pricing, ok := c.models["default"]; ok {
+		return pricing
+	}
+	return ModelPricing{}
+}
+
+// PlanFor returns the first active plan, by ID, covering model.
+func (c *Catalog) PlanFor(model string) (Plan, bool) {
+	for _, p := range c.Plans() {
+		if p.Active && p.Covers(model) {
+			return p, true
+		}
+	}
+	return Plan{}, false
+}
+
+// Models returns the priced model names, sorted.
+func (c *Catalog) Models() []string {
+	names := make([]string, 0, len(c.models))
+	for name := range c.models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Plans returns all plans, active or not, sorted by ID.
+func (c *Catalog) Plans() []Plan {
+	plans := make([]Plan, 0, len(c.plans))
+	for _, p := range c.plans {
+		plans = append(plans, p)
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].ID < plans[j].ID })
+	return plans
+}
+
+var (
+	defaultCatalogMu sync.Mutex
+	defaultCatalog   *Catalog
+)
+
+// DefaultCatalog returns the process-wide catalog, loading it with
+// NewCatalogLoader on first use.
+func DefaultCatalog() *Catalog {
+	defaultCatalogMu.Lock()
+	defer defaultCatalogMu.Unlock()
+	if defaultCatalog == nil {
+		defaultCatalog, _ = NewCatalogLoader().Load() // Load failures are kept in Warnings
+	}
+	return defaultCatalog
+}
+
+// SetDefaultCatalog replaces the process-wide catalog. Nil reloads it from
+// the default paths on next use.
+func SetDefaultCatalog(c *Catalog) {
+	defaultCatalogMu.Lock()
+	defer defaultCatalogMu.Unlock()
+	defaultCatalog = c
+}
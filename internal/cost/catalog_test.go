@@ -0,0 +1,166 @@
+package cost
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func writePricing(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pricing.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuiltinCatalog(t *testing.T) {
+	t.Parallel()
+	c := BuiltinCatalog()
+	if c.Version != CatalogVersion || c.Updated == "" || len(c.Sources) != 1 {
+		t.Errorf("catalog header = %d %q %v", c.Version, c.Updated, c.Sources)
+	}
+
+	p := c.Lookup("claude-sonnet-4-5-20250929")
+	if !approx(p.InputPer1K, 0.003) || p.LongContext == nil || !approx(p.LongContext.InputPer1K, 0.006) {
+		t.Errorf("claude-sonnet-4-5 = %+v", p)
+	}
+	if p := c.Lookup("gemini-2.5-pro"); !approx(p.CacheReadPrice(), 0.000125) || !approx(p.CacheWritePrice(), 0.00125) {
+		t.Errorf("gemini-2.5-pro cache prices = %v / %v", p.CacheReadPrice(), p.CacheWritePrice())
+	}
+	if p := c.Lookup("totally-unknown"); !approx(p.InputPer1K, 0.003) {
+		t.Errorf("default = %+v", p)
+	}
+	for _, plan := range c.Plans() {
+		if plan.Active {
+			t.Errorf("builtin plan %s is active", plan.ID)
+		}
+	}
+}
+
+func TestCatalogLoader_Overrides(t *testing.T) {
+	t.Parallel()
+	userDir := t.TempDir()
+	projectDir := t.TempDir()
+
+	writePricing(t, userDir, `
+[models.claude-sonnet-4]
+cache_read = 0.5          # field-level override keeps input/output
+
+[models.my-local-model]
+input = 0
+output = 0
+
+[plans.claude-max]
+active = true
+`)
+	writePricing(t, filepath.Join(projectDir, ".ntm"), `
+updated = "2026-02-01"
+
+[models.claude-sonnet-4]
+output = 20.0
+`)
+
+	c, err := (&CatalogLoader{UserConfigDir: userDir, ProjectDir: projectDir}).Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(c.Sources) != 3 || c.Updated != "2026-02-01" {
+		t.Errorf("sources = %v, updated = %q", c.Sources, c.Updated)
+	}
+
+	p := c.Lookup("claude-sonnet-4")
+	if !approx(p.InputPer1K, 0.003) || !approx(p.OutputPer1K, 0.02) || !approx(p.CacheReadPer1K, 0.0005) {
+		t.Errorf("merged claude-sonnet-4 = %+v", p)
+	}
+	if p.LongContext == nil {
+		t.Error("override dropped the long-context tier")
+	}
+	if p.Plan != "Claude Max" {
+		t.Errorf("plan = %q, want Claude Max", p.Plan)
+	}
+	if p := c.Lookup("my-local-model"); p.InputPer1K != 0 || p.Plan != "" {
+		t.Errorf("my-local-model = %+v", p)
+	}
+	if p := c.Lookup("gpt-5"); p.Plan != "" {
+		t.Errorf("gpt-5 covered by %q", p.Plan)
+	}
+}
+
+func TestCatalogLoader_InvalidOverrideIgnored(t *testing.T) {
+	t.Parallel()
+	userDir := t.TempDir()
+	projectDir := t.TempDir()
+	writePricing(t, userDir, "version = 99\n[models.gpt-5]\ninput = 100.0\n")
+	writePricing(t, filepath.Join(projectDir, ".ntm"), "[models.gpt-5]\nbatch_discount = 2\n")
+
+	c, err := (&CatalogLoader{UserConfigDir: userDir, ProjectDir: projectDir}).Load()
+	if err == nil || len(c.Warnings) != 2 {
+		t.Fatalf("Load() error = %v, warnings = %v", err, c.Warnings)
+	}
+	if !strings.Contains(c.Warnings[0], "newer than supported") {
+		t.Errorf("warning = %q", c.Warnings[0])
+	}
+	if p := c.Lookup("gpt-5"); !approx(p.InputPer1K, 0.00125) || p.BatchDiscount != 0.5 {
+		t.Errorf("invalid overrides applied: %+v", p)
+	}
+}
+
+func TestAgentCost_Breakdown(t *testing.T) {
+	c := BuiltinCatalog()
+	c.plans["gemini-code-assist"] = Plan{ID: "gemini-code-assist", Name: "Gemini Code Assist", Models: []string{"gemini-*"}, Active: true}
+	SetDefaultCatalog(c)
+	t.Cleanup(func() { SetDefaultCatalog(nil) })
+
+	// 1M output of which 400K reasoning; gpt-5 has no reasoning surcharge.
+	a := &AgentCost{Model: "gpt-5-codex", InputTokens: 100_000, OutputTokens: 1_000_000, ReasoningTokens: 400_000, CacheReadTokens: 1_000_000}
+	b := a.Breakdown()
+	if !approx(b.Input, 0.125) || !approx(b.Output, 6) || !approx(b.Reasoning, 4) || !approx(b.CacheRead, 0.125) {
+		t.Errorf("gpt-5-codex breakdown = %+v", b)
+	}
+	if !approx(a.Cost(), 10.25) {
+		t.Errorf("Cost() = %v", a.Cost())
+	}
+
+	// Half the input came from requests above the long-context threshold.
+	a = &AgentCost{Model: "claude-sonnet-4-5", InputTokens: 1_000_000, OutputTokens: 100_000,
+		LongContext: &Usage{Input: 500_000, Output: 50_000}}
+	b = a.Breakdown()
+	if !approx(b.Input, 1.5+3.0) || !approx(b.Output, 0.75+1.125) || !approx(b.LongContext, 3.0+1.125) {
+		t.Errorf("long-context breakdown = %+v", b)
+	}
+
+	// Half the usage came from batch requests at half price.
+	a = &AgentCost{Model: "claude-sonnet-4-5", InputTokens: 1_000_000, OutputTokens: 100_000,
+		Batch: &Usage{Input: 500_000, Output: 50_000}}
+	b = a.Breakdown()
+	if !approx(b.Input, 1.5+0.75) || !approx(b.Output, 0.75+0.375) || b.LongContext != 0 {
+		t.Errorf("batch breakdown = %+v", b)
+	}
+
+	// A plan covers the whole cost but the list price is still reported.
+	a = &AgentCost{Model: "gemini-2.5-flash", InputTokens: 1_000_000, OutputTokens: 1_000_000}
+	b = a.Breakdown()
+	if !approx(b.Total(), 2.8) || !approx(b.Covered, 2.8) || a.Cost() != 0 {
+		t.Errorf("plan breakdown = %+v, cost = %v", b, a.Cost())
+	}
+
+	s := &SessionCost{Agents: map[string]*AgentCost{"%1": a, "%2": {Model: "gpt-5", InputTokens: 1_000_000}}}
+	if sb := s.Breakdown(); !approx(sb.Billed(), 1.25) || !approx(s.TotalCost(), 1.25) {
+		t.Errorf("session breakdown = %+v", sb)
+	}
+}
+
+func TestModelPricing_Batch(t *testing.T) {
+	t.Parallel()
+	p := BuiltinCatalog().Lookup("claude-sonnet-4").Batch()
+	if !approx(p.InputPer1K, 0.0015) || !approx(p.CacheWritePrice(), 0.001875) || p.LongContext == nil || !approx(p.LongContext.OutputPer1K, 0.01125) {
+		t.Errorf("Batch() = %+v", p)
+	}
+}
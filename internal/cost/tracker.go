@@ -7,7 +7,6 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
-	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -15,66 +14,205 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/tokens"
 )
 
-// ModelPricing defines the cost per 1K tokens for input and output.
+// ModelPricing defines the cost per 1K tokens by category. Cache prices
+// default to a tenth of the input price for reads and a quarter more than
+// input for writes when unset; reasoning defaults to the output price.
 type ModelPricing struct {
-	InputPer1K  float64 `json:"input_per_1k"`
-	OutputPer1K float64 `json:"output_per_1k"`
-}
-
-// modelPricing contains pricing data for known models (USD per 1K tokens).
-// Updated as of May 2025.
-var modelPricing = map[string]ModelPricing{
-	// Claude models
-	"claude-opus":       {InputPer1K: 0.015, OutputPer1K: 0.075},
-	"claude-opus-4":     {InputPer1K: 0.015, OutputPer1K: 0.075},
-	"claude-opus-4-5":   {InputPer1K: 0.015, OutputPer1K: 0.075},
-	"claude-sonnet":     {InputPer1K: 0.003, OutputPer1K: 0.015},
-	"claude-sonnet-4":   {InputPer1K: 0.003, OutputPer1K: 0.015},
-	"claude-haiku":      {InputPer1K: 0.00025, OutputPer1K: 0.00125},
-	"claude-haiku-3-5":  {InputPer1K: 0.00025, OutputPer1K: 0.00125},
-	"claude-3-opus":     {InputPer1K: 0.015, OutputPer1K: 0.075},
-	"claude-3-sonnet":   {InputPer1K: 0.003, OutputPer1K: 0.015},
-	"claude-3-haiku":    {InputPer1K: 0.00025, OutputPer1K: 0.00125},
-	"claude-3-5-sonnet": {InputPer1K: 0.003, OutputPer1K: 0.015},
-	"claude-3-5-haiku":  {InputPer1K: 0.00025, OutputPer1K: 0.00125},
-
-	// OpenAI models
-	"gpt-4o":      {InputPer1K: 0.005, OutputPer1K: 0.015},
-	"gpt-4o-mini": {InputPer1K: 0.00015, OutputPer1K: 0.0006},
-	"gpt-4-turbo": {InputPer1K: 0.01, OutputPer1K: 0.03},
-	"gpt-4":       {InputPer1K: 0.03, OutputPer1K: 0.06},
-	"o1":          {InputPer1K: 0.015, OutputPer1K: 0.06},
-	"o1-mini":     {InputPer1K: 0.003, OutputPer1K: 0.012},
-	"o1-preview":  {InputPer1K: 0.015, OutputPer1K: 0.06},
-
-	// Google models
-	"gemini-pro":       {InputPer1K: 0.00025, OutputPer1K: 0.0005},
-	"gemini-pro-1.5":   {InputPer1K: 0.00025, OutputPer1K: 0.0005},
-	"gemini-ultra":     {InputPer1K: 0.00125, OutputPer1K: 0.00375},
-	"gemini-flash":     {InputPer1K: 0.000075, OutputPer1K: 0.0003},
-	"gemini-flash-1.5": {InputPer1K: 0.000075, OutputPer1K: 0.0003},
-	"gemini-2.0-flash": {InputPer1K: 0.000075, OutputPer1K: 0.0003},
-
-	// Default fallback
-	"default": {InputPer1K: 0.003, OutputPer1K: 0.015},
+	InputPer1K      float64 `json:"input_per_1k"`
+	OutputPer1K     float64 `json:"output_per_1k"`
+	CacheReadPer1K  float64 `json:"cache_read_per_1k,omitempty"`
+	CacheWritePer1K float64 `json:"cache_write_per_1k,omitempty"`
+	ReasoningPer1K  float64 `json:"reasoning_per_1k,omitempty"`
+	BatchDiscount   float64 `json:"batch_discount,omitempty"` // Fraction off for batch requests
+
+	// LongContext prices requests with more than LongContextThreshold
+	// prompt tokens. Nil means the model has a single tier.
+	LongContext *ModelPricing `json:"long_context,omitempty"`
+
+	// Plan names the active subscription covering this model, whose usage
+	// then has no marginal cost.
+	Plan string `json:"plan,omitempty"`
+}
+
+// CacheReadPrice returns the cost per 1K cached input tokens read.
+func (p ModelPricing) CacheReadPrice() float64 {
+	if p.CacheReadPer1K > 0 {
+		return p.CacheReadPer1K
+	}
+	return p.InputPer1K * 0.1
+}
+
+// CacheWritePrice returns the cost per 1K input tokens written to the cache.
+func (p ModelPricing) CacheWritePrice() float64 {
+	if p.CacheWritePer1K > 0 {
+		return p.CacheWritePer1K
+	}
+	return p.InputPer1K * 1.25
+}
+
+// ReasoningPrice returns the cost per 1K reasoning tokens.
+func (p ModelPricing) ReasoningPrice() float64 {
+	if p.ReasoningPer1K > 0 {
+		return p.ReasoningPer1K
+	}
+	return p.OutputPer1K
+}
+
+// Batch returns the pricing with the batch discount applied.
+func (p ModelPricing) Batch() ModelPricing {
+	if p.BatchDiscount <= 0 {
+		return p
+	}
+	b := p.scaled(1 - p.BatchDiscount)
+	b.BatchDiscount = 0
+	if p.LongContext != nil {
+		lc := p.LongContext.scaled(1 - p.BatchDiscount)
+		b.LongContext = &lc
+	}
+	return b
+}
+
+// scaled multiplies every price, resolving defaults first.
+func (p ModelPricing) scaled(f float64) ModelPricing {
+	s := p
+	s.InputPer1K = p.InputPer1K * f
+	s.OutputPer1K = p.OutputPer1K * f
+	s.CacheReadPer1K = p.CacheReadPrice() * f
+	s.CacheWritePer1K = p.CacheWritePrice() * f
+	s.ReasoningPer1K = p.ReasoningPrice() * f
+	return s
+}
+
+// Usage counts tokens by pricing category. Input excludes cached tokens,
+// which are counted as cache reads and writes, and Output includes
+// Reasoning.
+type Usage struct {
+	Input      int `json:"input"`
+	Output     int `json:"output"`
+	CacheRead  int `json:"cache_read,omitempty"`
+	CacheWrite int `json:"cache_write,omitempty"`
+	Reasoning  int `json:"reasoning,omitempty"`
+}
+
+func (u Usage) sub(o Usage) Usage {
+	return Usage{
+		Input:      max(u.Input-o.Input, 0),
+		Output:     max(u.Output-o.Output, 0),
+		CacheRead:  max(u.CacheRead-o.CacheRead, 0),
+		CacheWrite: max(u.CacheWrite-o.CacheWrite, 0),
+		Reasoning:  max(u.Reasoning-o.Reasoning, 0),
+	}
+}
+
+// Price returns the cost of u by category at these prices.
+func (p ModelPricing) Price(u Usage) CostBreakdown {
+	reasoning := min(u.Reasoning, u.Output)
+	return CostBreakdown{
+		Input:      float64(u.Input) / 1000 * p.InputPer1K,
+		Output:     float64(u.Output-reasoning) / 1000 * p.OutputPer1K,
+		CacheRead:  float64(u.CacheRead) / 1000 * p.CacheReadPrice(),
+		CacheWrite: float64(u.CacheWrite) / 1000 * p.CacheWritePrice(),
+		Reasoning:  float64(reasoning) / 1000 * p.ReasoningPrice(),
+	}
+}
+
+// CostBreakdown is a USD cost split by token category.
+type CostBreakdown struct {
+	Input      float64 `json:"input_usd"`
+	Output     float64 `json:"output_usd"`
+	CacheRead  float64 `json:"cache_read_usd"`
+	CacheWrite float64 `json:"cache_write_usd"`
+	Reasoning  float64 `json:"reasoning_usd"`
+
+	// LongContext is the part of the total billed at long-context prices.
+	LongContext float64 `json:"long_context_usd,omitempty"`
+	// Covered is the part of the total covered by subscription plans.
+	Covered float64 `json:"covered_usd,omitempty"`
+}
+
+// Total returns the API-equivalent cost across categories.
+func (b CostBreakdown) Total() float64 {
+	return b.Input + b.Output + b.CacheRead + b.CacheWrite + b.Reasoning
+}
+
+// Billed returns the marginal cost: the total less what plans cover.
+func (b CostBreakdown) Billed() float64 {
+	return b.Total() - b.Covered
+}
+
+// Add accumulates o into b.
+func (b *CostBreakdown) Add(o CostBreakdown) {
+	b.Input += o.Input
+	b.Output += o.Output
+	b.CacheRead += o.CacheRead
+	b.CacheWrite += o.CacheWrite
+	b.Reasoning += o.Reasoning
+	b.LongContext += o.LongContext
+	b.Covered += o.Covered
 }
 
 var modelDateSuffixRegex = regexp.MustCompile(`-\d{8}$`)
 
 // AgentCost tracks token usage for a single agent.
 type AgentCost struct {
-	InputTokens  int       `json:"input_tokens"`
-	OutputTokens int       `json:"output_tokens"`
-	Model        string    `json:"model"`
-	LastUpdated  time.Time `json:"last_updated"`
+	InputTokens      int       `json:"input_tokens"`
+	OutputTokens     int       `json:"output_tokens"`
+	CacheReadTokens  int       `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int       `json:"cache_write_tokens,omitempty"`
+	ReasoningTokens  int       `json:"reasoning_tokens,omitempty"` // Included in OutputTokens
+	Model            string    `json:"model"`
+	Exact            bool      `json:"exact,omitempty"` // Counts come from the agent's own transcript
+	LastUpdated      time.Time `json:"last_updated"`
+
+	// LongContext is the part of the usage from requests with more than
+	// LongContextThreshold prompt tokens.
+	LongContext *Usage `json:"long_context,omitempty"`
+	// Batch is the part of the usage from batch requests, apart from any
+	// already counted in LongContext.
+	Batch *Usage `json:"batch,omitempty"`
 }
 
-// Cost calculates the USD cost for this agent.
-func (a *AgentCost) Cost() float64 {
+// Usage returns the agent's token counts by category.
+func (a *AgentCost) Usage() Usage {
+	return Usage{
+		Input:      a.InputTokens,
+		Output:     a.OutputTokens,
+		CacheRead:  a.CacheReadTokens,
+		CacheWrite: a.CacheWriteTokens,
+		Reasoning:  a.ReasoningTokens,
+	}
+}
+
+// Breakdown prices the agent's usage by category. Long-context usage is
+// priced at the model's long-context tier when it has one, and batch usage
+// with the model's batch discount.
+func (a *AgentCost) Breakdown() CostBreakdown {
 	pricing := GetModelPricing(a.Model)
-	inputCost := float64(a.InputTokens) / 1000 * pricing.InputPer1K
-	outputCost := float64(a.OutputTokens) / 1000 * pricing.OutputPer1K
-	return inputCost + outputCost
+	usage := a.Usage()
+
+	var b CostBreakdown
+	if a.Batch != nil {
+		b.Add(pricing.Batch().Price(*a.Batch))
+		usage = usage.sub(*a.Batch)
+	}
+	if a.LongContext != nil && pricing.LongContext != nil {
+		long := pricing.LongContext.Price(*a.LongContext)
+		b.Add(pricing.Price(usage.sub(*a.LongContext)))
+		b.Add(long)
+		b.LongContext = long.Total()
+	} else {
+		b.Add(pricing.Price(usage))
+	}
+	if pricing.Plan != "" {
+		b.Covered = b.Total()
+	}
+	return b
+}
+
+// Cost calculates the marginal USD cost for this agent, which is zero when
+// a subscription plan covers its model.
+func (a *AgentCost) Cost() float64 {
+	return a.Breakdown().Billed()
 }
 
 // SessionCost tracks costs for all agents in a session.
@@ -92,6 +230,15 @@ func (s *SessionCost) TotalCost() float64 {
 	return total
 }
 
+// Breakdown returns the session cost by category.
+func (s *SessionCost) Breakdown() CostBreakdown {
+	var b CostBreakdown
+	for _, agent := range s.Agents {
+		b.Add(agent.Breakdown())
+	}
+	return b
+}
+
 // TotalTokens returns total input and output tokens for this session.
 func (s *SessionCost) TotalTokens() (input, output int) {
 	for _, agent := range s.Agents {
@@ -190,6 +337,7 @@ func (s *SessionCost) getOrCreateAgent(pane, model string) *AgentCost {
 }
 
 // RecordPrompt records input tokens from a prompt.
+// Agents with exact transcript usage ignore estimates.
 func (t *CostTracker) RecordPrompt(session, pane, model, prompt string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -197,6 +345,9 @@ func (t *CostTracker) RecordPrompt(session, pane, model, prompt string) {
 	tokens := EstimateTokens(prompt)
 	s := t.getOrCreateSession(session)
 	a := s.getOrCreateAgent(pane, model)
+	if a.Exact {
+		return
+	}
 	a.InputTokens += tokens
 	a.LastUpdated = time.Now()
 	if model != "" && a.Model == "" {
@@ -205,6 +356,7 @@ func (t *CostTracker) RecordPrompt(session, pane, model, prompt string) {
 }
 
 // RecordResponse records output tokens from a response.
+// Agents with exact transcript usage ignore estimates.
 func (t *CostTracker) RecordResponse(session, pane, model, response string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -212,6 +364,9 @@ func (t *CostTracker) RecordResponse(session, pane, model, response string) {
 	tokens := EstimateTokens(response)
 	s := t.getOrCreateSession(session)
 	a := s.getOrCreateAgent(pane, model)
+	if a.Exact {
+		return
+	}
 	a.OutputTokens += tokens
 	a.LastUpdated = time.Now()
 	if model != "" && a.Model == "" {
@@ -234,6 +389,31 @@ func (t *CostTracker) RecordTokens(session, pane, model string, inputTokens, out
 	}
 }
 
+// RecordUsage replaces an agent's counts with the cumulative totals read
+// from its transcript; longContext is the part of total from requests
+// above LongContextThreshold. Later estimates for the agent are ignored.
+func (t *CostTracker) RecordUsage(session, pane, model string, total, longContext Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := t.getOrCreateSession(session)
+	a := s.getOrCreateAgent(pane, model)
+	a.InputTokens = total.Input
+	a.OutputTokens = total.Output
+	a.CacheReadTokens = total.CacheRead
+	a.CacheWriteTokens = total.CacheWrite
+	a.ReasoningTokens = total.Reasoning
+	a.LongContext = nil
+	if longContext != (Usage{}) {
+		a.LongContext = &longContext
+	}
+	a.Exact = true
+	a.LastUpdated = time.Now()
+	if model != "" {
+		a.Model = model
+	}
+}
+
 // GetSessionCost returns the total USD cost for a session.
 func (t *CostTracker) GetSessionCost(session string) float64 {
 	t.mu.RLock()
@@ -305,39 +485,10 @@ func normalizeModelName(model string) string {
 	return model
 }
 
-// GetModelPricing returns the pricing for a model.
+// GetModelPricing returns the pricing for a model from the default catalog.
 // If the model is not found, returns default pricing.
 func GetModelPricing(model string) ModelPricing {
-	if pricing, ok := modelPricing[model]; ok {
-		return pricing
-	}
-
-	normalized := normalizeModelName(model)
-	if pricing, ok := modelPricing[normalized]; ok {
-		return pricing
-	}
-
-	// Prefix match for variants (longest key first).
-	keys := make([]string, 0, len(modelPricing))
-	for key := range modelPricing {
-		if key == "default" {
-			continue
-		}
-		keys = append(keys, key)
-	}
-	sort.Slice(keys, func(i, j int) bool {
-		return len(keys[i]) > len(keys[j])
-	})
-	for _, key := range keys {
-		if strings.HasPrefix(normalized, key) {
-			return modelPricing[key]
-		}
-	}
-
-	if pricing, ok := modelPricing["default"]; ok {
-		return pricing
-	}
-	return ModelPricing{}
+	return DefaultCatalog().Lookup(model)
 }
 
 // EstimateTokens estimates the token count for text.
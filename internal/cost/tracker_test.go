@@ -213,6 +213,29 @@ func TestCostTracker_RecordTokens(t *testing.T) {
 	}
 }
 
+func TestCostTracker_RecordUsage(t *testing.T) {
+	tracker := NewCostTracker("")
+	tracker.RecordPrompt("session1", "pane1", "", "an estimated prompt")
+
+	// Transcript totals replace estimates and are not added twice.
+	usage := Usage{Input: 1000, Output: 2000, CacheRead: 100000, CacheWrite: 10000}
+	tracker.RecordUsage("session1", "pane1", "claude-sonnet-4", usage, Usage{})
+	tracker.RecordUsage("session1", "pane1", "claude-sonnet-4", usage, Usage{})
+	tracker.RecordResponse("session1", "pane1", "", "ignored once exact usage is known")
+
+	agent := tracker.GetSession("session1").Agents["pane1"]
+	if !agent.Exact || agent.InputTokens != 1000 || agent.OutputTokens != 2000 || agent.CacheReadTokens != 100000 {
+		t.Fatalf("agent = %+v", agent)
+	}
+
+	// 1K input at $0.003, 2K output at $0.015, 100K cache reads at a tenth
+	// of input and 10K cache writes at 1.25x input.
+	want := 0.003 + 0.030 + 0.030 + 0.0375
+	if got := agent.Cost(); got < want-1e-9 || got > want+1e-9 {
+		t.Errorf("Cost() = %v, want %v", got, want)
+	}
+}
+
 func TestCostTracker_GetSessionCost(t *testing.T) {
 	tracker := NewCostTracker("")
 	tracker.RecordTokens("session1", "pane1", "claude-opus", 1000, 1000)
@@ -0,0 +1,290 @@
+// Package delivery pastes prompts into agent panes and verifies that the
+// agent received them.
+//
+// Typing a prompt with send-keys is slow for large prompts, and agent TUIs
+// can mangle it. Paste delivery loads the prompt into a tmux buffer and
+// pastes it with bracketed paste (paste-buffer -p), so the agent sees one
+// paste rather than thousands of keystrokes. Enter is then pressed and the
+// pane is watched with the status detector. A pane that goes from not
+// working to working and keeps changing, or that shows the prompt in its
+// transcript, has accepted it. If nothing happens,
+// Enter is pressed again, since an Enter that arrives while the TUI is
+// still rendering the paste is often swallowed. A prompt still not accepted
+// after the last attempt is reported undelivered.
+package delivery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Dicklesworthstone/ntm/internal/status"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// Delivery methods.
+const (
+	MethodKeys  = "keys"  // Typed with send-keys
+	MethodPaste = "paste" // Pasted from a tmux buffer and verified
+)
+
+// CheckMethod returns an error unless method is MethodKeys, MethodPaste, or
+// empty for the configured default.
+func CheckMethod(method string) error {
+	switch method {
+	case "", MethodKeys, MethodPaste:
+		return nil
+	}
+	return fmt.Errorf("unknown delivery method %q (want %s or %s)", method, MethodKeys, MethodPaste)
+}
+
+// Outcome is the result of delivering a prompt to one pane.
+type Outcome string
+
+const (
+	// OutcomeDelivered means the agent accepted the prompt.
+	OutcomeDelivered Outcome = "delivered"
+	// OutcomeUndelivered means the prompt was sent but the agent showed no
+	// sign of receiving it.
+	OutcomeUndelivered Outcome = "undelivered"
+	// OutcomeSent means the prompt was sent without verification.
+	OutcomeSent Outcome = "sent"
+	// OutcomeFailed means the prompt could not be sent.
+	OutcomeFailed Outcome = "failed"
+)
+
+// Result records how a prompt was delivered to a pane.
+type Result struct {
+	Pane     string  `json:"pane"`
+	Method   string  `json:"method"`
+	Outcome  Outcome `json:"outcome"`
+	Attempts int     `json:"attempts,omitempty"`
+	// Evidence is what showed the agent accepted the prompt: "working",
+	// "echoed" or "agent_error:<type>".
+	Evidence string `json:"evidence,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// OK reports whether the prompt reached the pane.
+func (r Result) OK() bool {
+	return r.Outcome == OutcomeDelivered || r.Outcome == OutcomeSent
+}
+
+// Pane is the tmux pane a prompt is pasted into.
+type Pane interface {
+	// Paste pastes text with bracketed paste without submitting it.
+	Paste(text string) error
+	// Enter presses Enter.
+	Enter() error
+	// Capture returns the pane's visible output.
+	Capture() (string, error)
+}
+
+// Options control paste delivery.
+type Options struct {
+	// AgentType selects the status patterns used for verification.
+	AgentType string
+	// Attempts is how many times Enter is pressed before giving up (default 3).
+	Attempts int
+	// SubmitDelay separates the paste from the first Enter (default 500ms).
+	SubmitDelay time.Duration
+	// Timeout is how long each attempt waits for the agent (default 5s).
+	Timeout time.Duration
+	// PollInterval is how often the pane is captured (default 250ms).
+	PollInterval time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.Attempts <= 0 {
+		o.Attempts = 3
+	}
+	if o.SubmitDelay <= 0 {
+		o.SubmitDelay = 500 * time.Millisecond
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 250 * time.Millisecond
+	}
+	return o
+}
+
+// Deliver pastes prompt into pane, submits it and waits for the agent to
+// accept it, pressing Enter again on each retry.
+func Deliver(ctx context.Context, pane Pane, prompt string, opts Options) Result {
+	opts = opts.withDefaults()
+	res := Result{Method: MethodPaste}
+
+	if err := pane.Paste(prompt); err != nil {
+		res.Outcome, res.Error = OutcomeFailed, err.Error()
+		return res
+	}
+	if err := sleep(ctx, opts.SubmitDelay); err != nil {
+		res.Outcome, res.Error = OutcomeUndelivered, err.Error()
+		return res
+	}
+
+	snippet := promptSnippet(prompt)
+	detector := status.NewDetector()
+	for res.Attempts < opts.Attempts {
+		baseline, err := pane.Capture()
+		if err != nil {
+			res.Outcome, res.Error = OutcomeFailed, fmt.Sprintf("capture pane: %v", err)
+			return res
+		}
+		res.Attempts++
+		if err := pane.Enter(); err != nil {
+			res.Outcome, res.Error = OutcomeFailed, err.Error()
+			return res
+		}
+
+		evidence, err := await(ctx, pane, detector, baseline, snippet, opts)
+		if evidence != "" {
+			res.Outcome, res.Evidence = OutcomeDelivered, evidence
+			return res
+		}
+		if err != nil {
+			res.Outcome, res.Error = OutcomeUndelivered, err.Error()
+			return res
+		}
+	}
+
+	res.Outcome = OutcomeUndelivered
+	res.Error = fmt.Sprintf("no response from agent after %d attempts", res.Attempts)
+	return res
+}
+
+// await polls pane until it shows the agent accepted the prompt or the
+// attempt times out.
+func await(ctx context.Context, pane Pane, detector *status.UnifiedDetector, baseline, snippet string, opts Options) (string, error) {
+	// The baseline is judged as a pane at rest: judged as just changed, any
+	// pane not showing a bare prompt, e.g. one holding the pasted prompt,
+	// would count as working.
+	before := detector.Analyze("", "", opts.AgentType, baseline, time.Time{})
+	// A long prompt still in the input box already reaches above it, so
+	// only echoes the baseline did not show count.
+	echoedBefore := echoes(status.StripANSI(baseline), snippet)
+	last, changes := baseline, 0
+	deadline := time.Now().Add(opts.Timeout)
+	for time.Now().Before(deadline) {
+		if err := sleep(ctx, opts.PollInterval); err != nil {
+			return "", err
+		}
+		output, err := pane.Capture()
+		if err != nil || output == last {
+			continue
+		}
+		last = output
+		changes++
+		after := detector.Analyze("", "", opts.AgentType, output, time.Now())
+		if evidence := acceptance(before, after, changes, echoedBefore, output, snippet); evidence != "" {
+			return evidence, nil
+		}
+	}
+	return "", nil
+}
+
+// acceptance returns the evidence in output, captured after Enter, that
+// the agent accepted the prompt, or "" if there is none yet. before is the
+// pane's status just before Enter, after its status in output, changes
+// how many times the pane has changed since Enter and echoedBefore how
+// often the prompt appeared above the input area just before Enter.
+func acceptance(before, after status.AgentStatus, changes, echoedBefore int, output, snippet string) string {
+	// Any change to the pane, such as the TUI finishing drawing the paste,
+	// makes the detector report working once. An agent that took the
+	// prompt goes from not working to working and keeps changing the pane.
+	switch after.State {
+	case status.StateWorking:
+		if before.State != status.StateWorking && changes >= 2 {
+			return "working"
+		}
+	case status.StateError:
+		if before.State != status.StateError || before.ErrorType != after.ErrorType {
+			return "agent_error:" + string(after.ErrorType)
+		}
+	}
+	if echoes(status.StripANSI(output), snippet) > echoedBefore {
+		return "echoed"
+	}
+	return ""
+}
+
+// inputLines is how many trailing lines of a pane count as the agent's
+// input area.
+const inputLines = 3
+
+// echoes counts how often snippet appears in output above the input area,
+// i.e. in the agent's transcript.
+func echoes(output, snippet string) int {
+	if snippet == "" {
+		return 0
+	}
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) <= inputLines {
+		return 0
+	}
+	return strings.Count(strings.Join(lines[:len(lines)-inputLines], "\n"), snippet)
+}
+
+// maxSnippetRunes keeps the snippet short enough not to wrap in a pane.
+const maxSnippetRunes = 40
+
+// promptSnippet returns the start of prompt's first non-blank line, used to
+// find the prompt in the agent's transcript.
+func promptSnippet(prompt string) string {
+	for _, line := range strings.Split(prompt, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if utf8.RuneCountInString(line) > maxSnippetRunes {
+			line = string([]rune(line)[:maxSnippetRunes])
+		}
+		return line
+	}
+	return ""
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// captureLines is how much of a pane is compared between polls.
+const captureLines = 60
+
+// TmuxPane is a Pane backed by a tmux pane ID.
+type TmuxPane string
+
+// Paste implements Pane with load-buffer and paste-buffer -p.
+func (p TmuxPane) Paste(text string) error {
+	return tmux.SendBuffer(string(p), text, false)
+}
+
+// Enter implements Pane.
+func (p TmuxPane) Enter() error {
+	return tmux.SendKeys(string(p), "", true)
+}
+
+// Capture implements Pane.
+func (p TmuxPane) Capture() (string, error) {
+	return tmux.CapturePaneOutput(string(p), captureLines)
+}
+
+// ToPane delivers prompt to the tmux pane paneID and records the pane in
+// the result.
+func ToPane(ctx context.Context, paneID string, agentType tmux.AgentType, prompt string, opts Options) Result {
+	opts.AgentType = string(agentType)
+	res := Deliver(ctx, TmuxPane(paneID), prompt, opts)
+	res.Pane = paneID
+	return res
+}
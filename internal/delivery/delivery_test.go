@@ -0,0 +1,139 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePane shows outputs[n] after Enter has been pressed n times. A %d in
+// an output is replaced by how many times the pane has been captured, like
+// an agent's elapsed-time counter.
+type fakePane struct {
+	mu       sync.Mutex
+	pasted   string
+	enters   int
+	captures int
+	outputs  []string
+	pasteErr error
+}
+
+func (p *fakePane) Paste(text string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pasted = text
+	return p.pasteErr
+}
+
+func (p *fakePane) Enter() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.enters++
+	return nil
+}
+
+func (p *fakePane) Capture() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.captures++
+	out := p.outputs[min(p.enters, len(p.outputs)-1)]
+	if strings.Contains(out, "%d") {
+		out = fmt.Sprintf(out, p.captures)
+	}
+	return out, nil
+}
+
+var fastOptions = Options{
+	AgentType:    "cc",
+	SubmitDelay:  time.Millisecond,
+	Timeout:      30 * time.Millisecond,
+	PollInterval: 5 * time.Millisecond,
+}
+
+func TestDeliver(t *testing.T) {
+	t.Parallel()
+	const prompt = "Refactor the parser\nand add tests"
+	idle := "Welcome\n\n> Refactor the parser and add tests\n"
+	working := "Welcome\n\n> Refactor the parser and add tests\n\n· Pondering… %ds (esc to interrupt)\n"
+	// The TUI finishes drawing the paste after Enter; nothing else happens.
+	empty := "Welcome\n\n> \n"
+	drawn := "Welcome\n\n> Refactor the parser\nand add tests\n"
+	// The prompt is submitted into the transcript.
+	echoed := "Welcome\n\n> Refactor the parser and add tests\n\nSure.\n\n\n> \n"
+	// A prompt taller than the input area reaches above it before Enter; the
+	// TUI then redraws its hint line while Enter was swallowed.
+	tall := "Welcome\n\n> Refactor the parser\nand add tests\nfor the lexer\nand the printer\n"
+	redrawn := tall + "  ? for shortcuts\n"
+
+	tests := []struct {
+		name     string
+		outputs  []string
+		outcome  Outcome
+		attempts int
+		evidence string
+	}{
+		{"accepted", []string{idle, working}, OutcomeDelivered, 1, "working"},
+		{"first enter swallowed", []string{idle, idle, working}, OutcomeDelivered, 2, "working"},
+		{"never accepted", []string{idle}, OutcomeUndelivered, 3, ""},
+		{"paste drawn late", []string{empty, drawn}, OutcomeUndelivered, 3, ""},
+		{"echoed", []string{idle, echoed}, OutcomeDelivered, 1, "echoed"},
+		{"tall prompt redrawn", []string{tall, redrawn}, OutcomeUndelivered, 3, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pane := &fakePane{outputs: tt.outputs}
+			res := Deliver(context.Background(), pane, prompt, fastOptions)
+			if res.Outcome != tt.outcome || res.Attempts != tt.attempts || res.Evidence != tt.evidence {
+				t.Errorf("Deliver = %+v, want %s after %d attempts (%q)", res, tt.outcome, tt.attempts, tt.evidence)
+			}
+			if pane.pasted != prompt {
+				t.Errorf("pasted %q, want the prompt", pane.pasted)
+			}
+			if res.Method != MethodPaste {
+				t.Errorf("Method = %q", res.Method)
+			}
+		})
+	}
+}
+
+func TestDeliver_PasteError(t *testing.T) {
+	t.Parallel()
+	pane := &fakePane{outputs: []string{""}, pasteErr: errors.New("no such pane")}
+	res := Deliver(context.Background(), pane, "hi", fastOptions)
+	if res.Outcome != OutcomeFailed || res.Error != "no such pane" || res.OK() {
+		t.Errorf("Deliver = %+v, want failed", res)
+	}
+}
+
+func TestEchoes(t *testing.T) {
+	t.Parallel()
+	snippet := promptSnippet("\n  Summarize the open issues in this repository and rank them by impact\n")
+	if snippet != "Summarize the open issues in this reposi" {
+		t.Fatalf("promptSnippet = %q", snippet)
+	}
+	inInput := "history\n\n> Summarize the open issues in this reposi\n"
+	submitted := "> Summarize the open issues in this reposi\n\nThere are three issues.\n\n\n>\n"
+	if echoes(inInput, snippet) != 0 {
+		t.Error("prompt still in the input area counted as echoed")
+	}
+	if echoes(submitted, snippet) != 1 {
+		t.Error("submitted prompt not counted as echoed")
+	}
+}
+
+func TestCheckMethod(t *testing.T) {
+	t.Parallel()
+	for _, m := range []string{"", MethodKeys, MethodPaste} {
+		if err := CheckMethod(m); err != nil {
+			t.Errorf("CheckMethod(%q) = %v", m, err)
+		}
+	}
+	if err := CheckMethod("pigeon"); err == nil || !strings.Contains(err.Error(), "pigeon") {
+		t.Errorf("CheckMethod(pigeon) = %v, want an error naming it", err)
+	}
+}
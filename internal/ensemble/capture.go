@@ -55,6 +55,20 @@ func (c *OutputCapture) SetMaxLines(lines int) {
 
 // CaptureAll captures output from all assignments in the session.
 func (c *OutputCapture) CaptureAll(session *EnsembleSession) ([]CapturedOutput, error) {
+	return c.captureAll(session, "")
+}
+
+// CaptureAfter captures output from all assignments in the session, parsing
+// only what each pane printed after the last occurrence of marker. Panes
+// that do not show the marker yield no parsed output.
+func (c *OutputCapture) CaptureAfter(session *EnsembleSession, marker string) ([]CapturedOutput, error) {
+	if marker == "" {
+		return nil, errors.New("marker is required")
+	}
+	return c.captureAll(session, marker)
+}
+
+func (c *OutputCapture) captureAll(session *EnsembleSession, marker string) ([]CapturedOutput, error) {
 	if c == nil {
 		return nil, errors.New("output capture is nil")
 	}
@@ -115,6 +129,14 @@ func (c *OutputCapture) CaptureAll(session *EnsembleSession) ([]CapturedOutput,
 
 		captured.LineCount = countLines(raw)
 		clean := status.StripANSI(raw)
+		if marker != "" {
+			idx := strings.LastIndex(clean, marker)
+			if idx < 0 {
+				clean = ""
+			} else {
+				clean = clean[idx+len(marker):]
+			}
+		}
 
 		if yamlBlock, ok := c.extractYAML(clean); ok && strings.TrimSpace(yamlBlock) != "" {
 			parsed, validationErrs, parseErr := c.validator.ParseNormalizeAndValidate(yamlBlock, assignment.ModeID)
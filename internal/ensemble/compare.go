@@ -32,6 +32,10 @@ type ComparisonResult struct {
 	// ContributionDiff shows changes in mode contribution scores.
 	ContributionDiff ContributionDiff `json:"contribution_diff"`
 
+	// Deliberation shows how positions moved between deliberation rounds
+	// (only set when comparing rounds of one deliberation).
+	Deliberation *DeliberationDiff `json:"deliberation,omitempty"`
+
 	// Summary provides an overall description of the diff.
 	Summary string `json:"summary"`
 }
@@ -262,6 +266,37 @@ func Compare(runA, runB CompareInput) *ComparisonResult {
 	return result
 }
 
+// CompareRounds compares two rounds of a deliberation, reporting the usual
+// finding and conclusion diffs along with how each mode's position moved.
+func CompareRounds(record *DeliberationRecord, from, to int) (*ComparisonResult, error) {
+	if record == nil {
+		return nil, fmt.Errorf("deliberation record is nil")
+	}
+	movement, err := record.Movement(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	input := func(n int) CompareInput {
+		round := record.Round(n)
+		modeIDs := make([]string, 0, len(round.Outputs))
+		for _, o := range round.Outputs {
+			modeIDs = append(modeIDs, o.ModeID)
+		}
+		sort.Strings(modeIDs)
+		return CompareInput{
+			RunID:   fmt.Sprintf("%s@round%d", record.SessionName, n),
+			ModeIDs: modeIDs,
+			Outputs: round.Outputs,
+		}
+	}
+
+	result := Compare(input(from), input(to))
+	result.Deliberation = movement
+	result.Summary = generateComparisonSummary(result)
+	return result, nil
+}
+
 // compareModes computes the diff between two mode lists.
 func compareModes(modesA, modesB []string) ModeDiff {
 	setA := make(map[string]bool, len(modesA))
@@ -570,6 +605,11 @@ func generateComparisonSummary(result *ComparisonResult) string {
 		parts = append(parts, fmt.Sprintf("%d rank changes", len(result.ContributionDiff.RankChanges)))
 	}
 
+	// Deliberation movement
+	if d := result.Deliberation; d != nil && len(d.ResolvedConflicts) > 0 {
+		parts = append(parts, fmt.Sprintf("%d conflicts resolved", len(d.ResolvedConflicts)))
+	}
+
 	if len(parts) == 0 {
 		return "No differences found"
 	}
@@ -666,6 +706,11 @@ func FormatComparison(result *ComparisonResult) string {
 		b.WriteString("\n")
 	}
 
+	// Deliberation movement
+	if d := result.Deliberation; d != nil {
+		formatDeliberationDiff(&b, d)
+	}
+
 	// Synthesis diff
 	if result.ConclusionDiff.SynthesisChanged {
 		b.WriteString("Synthesis Output: Changed\n")
@@ -674,6 +719,35 @@ func FormatComparison(result *ComparisonResult) string {
 	return b.String()
 }
 
+// formatDeliberationDiff writes the position movement section of a report.
+func formatDeliberationDiff(b *strings.Builder, d *DeliberationDiff) {
+	fmt.Fprintf(b, "Position Movement (round %d → %d of %d):\n", d.FromRound, d.ToRound, d.Rounds)
+	if d.StopReason != "" {
+		state := "not converged"
+		if d.Converged {
+			state = "converged"
+		}
+		fmt.Fprintf(b, "  Stopped: %s (%s)\n", d.StopReason, state)
+	}
+	fmt.Fprintf(b, "  Agreement: %.2f → %.2f\n", d.SimilarityFrom, d.SimilarityTo)
+	if len(d.ResolvedConflicts) > 0 {
+		fmt.Fprintf(b, "  Resolved conflicts: %s\n", strings.Join(d.ResolvedConflicts, ", "))
+	}
+	if len(d.OpenConflicts) > 0 {
+		fmt.Fprintf(b, "  Open conflicts: %s\n", strings.Join(d.OpenConflicts, ", "))
+	}
+	for _, p := range d.Positions {
+		fmt.Fprintf(b, "  %s: agreement %.2f → %.2f, confidence %s → %s, findings =%d +%d -%d\n",
+			p.ModeID, p.AgreementFrom, p.AgreementTo, p.ConfidenceFrom, p.ConfidenceTo,
+			p.FindingsKept, p.FindingsAdded, p.FindingsWithdrawn)
+		if p.ThesisChanged {
+			fmt.Fprintf(b, "    thesis: %s\n", truncateForDiff(p.ThesisFrom, 70))
+			fmt.Fprintf(b, "         → %s\n", truncateForDiff(p.ThesisTo, 70))
+		}
+	}
+	b.WriteString("\n")
+}
+
 // absInt returns the absolute value of an integer.
 func absInt(x int) int {
 	if x < 0 {
@@ -0,0 +1,589 @@
+package ensemble
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrStaleDeliberation is returned by LoadDeliberationRecord for a record
+// left behind by an earlier ensemble in the same tmux session.
+var ErrStaleDeliberation = errors.New("deliberation record belongs to an earlier ensemble")
+
+const (
+	// defaultDeliberationRounds is the default cap on deliberation rounds.
+	defaultDeliberationRounds = 3
+
+	// defaultDeliberationSimilarity is the pairwise output similarity above
+	// which agents are considered to have converged.
+	defaultDeliberationSimilarity = 0.5
+
+	// deliberationStallSimilarity is the similarity between a mode's
+	// consecutive outputs above which its position is considered unmoved.
+	deliberationStallSimilarity = 0.9
+)
+
+// Deliberation stop reasons, in addition to the EarlyStopDetector reasons
+// ("similarity", "findings_rate", "findings_rate_and_similarity").
+const (
+	DeliberationStopNoConflicts = "no_conflicts"
+	DeliberationStopBudget      = "budget"
+	DeliberationStopMaxRounds   = "max_rounds"
+	DeliberationStopNoMovement  = "no_movement"
+)
+
+// DeliberationConfig controls multi-round deliberation, where agents revise
+// or defend their analyses after reading each other's outputs.
+type DeliberationConfig struct {
+	// MaxRounds caps the number of rounds, including the initial one.
+	MaxRounds int `json:"max_rounds" toml:"max_rounds" yaml:"max_rounds"`
+
+	// Convergence decides when the agents agree closely enough to stop.
+	// WindowSize and MinAgentsBeforeStop are set to the mode count.
+	Convergence EarlyStopConfig `json:"convergence" toml:"convergence" yaml:"convergence"`
+
+	// Budget caps the tokens spent across all rounds.
+	Budget BudgetConfig `json:"budget" toml:"budget" yaml:"budget"`
+}
+
+// DefaultDeliberationConfig returns the default deliberation settings.
+func DefaultDeliberationConfig() DeliberationConfig {
+	budget := DefaultBudgetConfig()
+	budget.MaxTokensPerMode *= defaultDeliberationRounds
+	return DeliberationConfig{
+		MaxRounds: defaultDeliberationRounds,
+		Convergence: EarlyStopConfig{
+			Enabled:             true,
+			SimilarityThreshold: defaultDeliberationSimilarity,
+		},
+		Budget: budget,
+	}
+}
+
+// DeliberationRound is one round of mode outputs and the conflicts among them.
+type DeliberationRound struct {
+	Round      int                `json:"round"`
+	Outputs    []ModeOutput       `json:"outputs"`
+	Conflicts  []DetailedConflict `json:"conflicts,omitempty"`
+	Missing    []string           `json:"missing,omitempty"`
+	Tokens     int                `json:"tokens"`
+	Similarity float64            `json:"similarity"`
+	RecordedAt time.Time          `json:"recorded_at"`
+}
+
+// Output returns the round's output for a mode.
+func (r *DeliberationRound) Output(modeID string) (ModeOutput, bool) {
+	for _, o := range r.Outputs {
+		if o.ModeID == modeID {
+			return o, true
+		}
+	}
+	return ModeOutput{}, false
+}
+
+// DeliberationRecord is the persisted history of a deliberation.
+type DeliberationRecord struct {
+	SessionName string              `json:"session_name"`
+	Question    string              `json:"question"`
+	ModeIDs     []string            `json:"mode_ids"`
+	Rounds      []DeliberationRound `json:"rounds"`
+	StopReason  string              `json:"stop_reason,omitempty"`
+	Converged   bool                `json:"converged"`
+	TokensSpent int                 `json:"tokens_spent"`
+	StartedAt   time.Time           `json:"started_at"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty"`
+}
+
+// Round returns round n (1-based), or nil if it was not recorded.
+func (r *DeliberationRecord) Round(n int) *DeliberationRound {
+	if r == nil || n < 1 || n > len(r.Rounds) {
+		return nil
+	}
+	return &r.Rounds[n-1]
+}
+
+// FinalOutputs returns the outputs of the last recorded round.
+func (r *DeliberationRecord) FinalOutputs() []ModeOutput {
+	if r == nil || len(r.Rounds) == 0 {
+		return nil
+	}
+	return r.Rounds[len(r.Rounds)-1].Outputs
+}
+
+// Provenance replays the recorded rounds into a provenance tracker.
+func (r *DeliberationRecord) Provenance() *ProvenanceTracker {
+	if r == nil {
+		return nil
+	}
+	tracker := NewProvenanceTracker(r.Question, r.ModeIDs)
+	for _, round := range r.Rounds {
+		for _, o := range round.Outputs {
+			if sliceContains(round.Missing, o.ModeID) {
+				continue
+			}
+			tracker.RecordRound(round.Round, o.ModeID, o.TopFindings)
+		}
+	}
+	return tracker
+}
+
+// Deliberation drives the multi-round protocol. After each round the
+// DisagreementAuditor identifies conflicts, the EarlyStopDetector checks for
+// convergence and the BudgetTracker checks the token limit; when another
+// round is warranted, Prompt builds each mode's revision prompt.
+type Deliberation struct {
+	Config     DeliberationConfig
+	Record     *DeliberationRecord
+	Provenance *ProvenanceTracker
+
+	detector *EarlyStopDetector
+	budget   *BudgetTracker
+	labels   map[string]string
+	logger   *slog.Logger
+}
+
+// NewDeliberation starts a deliberation among modeIDs.
+func NewDeliberation(sessionName, question string, modeIDs []string, cfg DeliberationConfig, logger *slog.Logger) *Deliberation {
+	defaults := DefaultDeliberationConfig()
+	if cfg.MaxRounds <= 0 {
+		cfg.MaxRounds = defaults.MaxRounds
+	}
+	if cfg.Budget.MaxTokensPerMode == 0 {
+		cfg.Budget.MaxTokensPerMode = DefaultBudgetConfig().MaxTokensPerMode * cfg.MaxRounds
+	}
+	cfg.Convergence.WindowSize = len(modeIDs)
+	cfg.Convergence.MinAgentsBeforeStop = len(modeIDs)
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	labels := make(map[string]string, len(modeIDs))
+	for i, id := range modeIDs {
+		labels[id] = agentLabel(i)
+	}
+
+	detector := NewEarlyStopDetector(cfg.Convergence)
+	detector.Logger = logger
+
+	return &Deliberation{
+		Config: cfg,
+		Record: &DeliberationRecord{
+			SessionName: sessionName,
+			Question:    question,
+			ModeIDs:     append([]string(nil), modeIDs...),
+			StartedAt:   time.Now().UTC(),
+		},
+		Provenance: NewProvenanceTracker(question, modeIDs),
+		detector:   detector,
+		budget:     NewBudgetTracker(cfg.Budget, logger),
+		labels:     labels,
+		logger:     logger,
+	}
+}
+
+// agentLabel returns the anonymous label for the i-th mode.
+func agentLabel(i int) string {
+	label := ""
+	for i >= 0 {
+		label = string(rune('A'+i%26)) + label
+		i = i/26 - 1
+	}
+	return "Agent " + label
+}
+
+// AddRound records the next round's outputs and decides whether another
+// round should follow. Modes without an output this round keep their
+// previous one and are listed in the round's Missing modes.
+func (d *Deliberation) AddRound(outputs []ModeOutput) StopDecision {
+	rec := d.Record
+	round := DeliberationRound{
+		Round:      len(rec.Rounds) + 1,
+		RecordedAt: time.Now().UTC(),
+	}
+	prev := rec.Round(len(rec.Rounds))
+
+	byMode := make(map[string]ModeOutput, len(outputs))
+	for _, o := range outputs {
+		byMode[o.ModeID] = o
+	}
+	for _, id := range rec.ModeIDs {
+		o, ok := byMode[id]
+		if !ok {
+			if prev == nil {
+				continue
+			}
+			if o, ok = prev.Output(id); !ok {
+				continue
+			}
+			round.Missing = append(round.Missing, id)
+			round.Outputs = append(round.Outputs, o)
+			continue
+		}
+		round.Outputs = append(round.Outputs, o)
+	}
+
+	spent := SpendResult{Allowed: true}
+	for _, o := range round.Outputs {
+		if sliceContains(round.Missing, o.ModeID) {
+			continue
+		}
+		tokens := EstimateModeOutputTokens(&o)
+		round.Tokens += tokens
+		if res := d.budget.RecordSpend(o.ModeID, tokens); !res.Allowed {
+			spent = res
+		}
+		d.Provenance.RecordRound(round.Round, o.ModeID, o.TopFindings)
+		d.detector.RecordOutput(o, tokens)
+	}
+	round.Conflicts = NewDisagreementAuditor(round.Outputs, nil).IdentifyConflicts()
+	rec.TokensSpent += round.Tokens
+
+	decision := d.detector.ShouldStop()
+	decision.AgentsRun = len(round.Outputs)
+	round.Similarity = decision.SimilarityScore
+	rec.Rounds = append(rec.Rounds, round)
+
+	switch {
+	case len(round.Outputs) >= 2 && len(round.Conflicts) == 0:
+		decision.ShouldStop, decision.Reason = true, DeliberationStopNoConflicts
+	case decision.ShouldStop:
+		// Converged according to the detector; keep its reason.
+	case !spent.Allowed || d.budget.IsOverBudget():
+		decision.ShouldStop, decision.Reason = true, DeliberationStopBudget
+	case round.Round >= d.Config.MaxRounds:
+		decision.ShouldStop, decision.Reason = true, DeliberationStopMaxRounds
+	case d.budget.TotalRemaining() < round.Tokens:
+		decision.ShouldStop, decision.Reason = true, DeliberationStopBudget
+	case prev != nil && !positionsMoved(*prev, round):
+		decision.ShouldStop, decision.Reason = true, DeliberationStopNoMovement
+	default:
+		decision.ShouldStop, decision.Reason = false, "continue"
+	}
+
+	if decision.ShouldStop {
+		now := time.Now().UTC()
+		rec.StopReason = decision.Reason
+		rec.Converged = deliberationConverged(decision.Reason)
+		rec.CompletedAt = &now
+	}
+
+	d.logger.Info("ensemble deliberation round recorded",
+		"session", rec.SessionName,
+		"round", round.Round,
+		"outputs", len(round.Outputs),
+		"missing", len(round.Missing),
+		"conflicts", len(round.Conflicts),
+		"tokens", round.Tokens,
+		"similarity", round.Similarity,
+		"stop", decision.ShouldStop,
+		"reason", decision.Reason,
+	)
+	return decision
+}
+
+// deliberationConverged reports whether a stop reason means the agents
+// reached agreement rather than running out of rounds or budget.
+func deliberationConverged(reason string) bool {
+	switch reason {
+	case DeliberationStopBudget, DeliberationStopMaxRounds, DeliberationStopNoMovement, "continue", "":
+		return false
+	}
+	return true
+}
+
+// positionsMoved reports whether any mode's output changed materially
+// between two rounds.
+func positionsMoved(prev, cur DeliberationRound) bool {
+	for _, o := range cur.Outputs {
+		if sliceContains(cur.Missing, o.ModeID) {
+			continue
+		}
+		before, ok := prev.Output(o.ModeID)
+		if !ok || outputSimilarity(before, o) < deliberationStallSimilarity {
+			return true
+		}
+	}
+	return false
+}
+
+func outputSimilarity(a, b ModeOutput) float64 {
+	return jaccardSimilarity(
+		tokenize(normalizeText(outputSignature(a))),
+		tokenize(normalizeText(outputSignature(b))),
+	)
+}
+
+// DeliberationMarker is the line that ends the round's revision prompt.
+// Output captured after its last occurrence in a pane is the mode's answer
+// for that round.
+func DeliberationMarker(round int) string {
+	return fmt.Sprintf("[ntm deliberation round %d]", round)
+}
+
+// Prompt builds the revision prompt for modeID's next round: the other
+// modes' latest outputs under anonymous labels and the open conflicts.
+func (d *Deliberation) Prompt(modeID string) string {
+	rec := d.Record
+	last := rec.Round(len(rec.Rounds))
+	if last == nil {
+		return ""
+	}
+	next := last.Round + 1
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "DELIBERATION ROUND %d of up to %d\n\n", next, d.Config.MaxRounds)
+	b.WriteString("You are one of several agents analyzing the same question with different reasoning modes:\n\n")
+	fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(rec.Question))
+	b.WriteString("## Other Agents' Latest Analyses\n")
+	b.WriteString("Identities are withheld. Judge each point on its evidence, not its author.\n")
+	for _, o := range last.Outputs {
+		if o.ModeID == modeID {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n", d.labels[o.ModeID])
+		writeAnonymizedOutput(&b, o)
+	}
+
+	b.WriteString("\n## Open Conflicts\n")
+	if len(last.Conflicts) == 0 {
+		b.WriteString("No conflicts were detected.\n")
+	}
+	for i, c := range last.Conflicts {
+		fmt.Fprintf(&b, "%d. %s (severity: %s)\n", i+1, c.Topic, c.Severity)
+		for _, p := range c.Positions {
+			who := d.labels[p.ModeID]
+			if p.ModeID == modeID {
+				who = "You"
+			}
+			fmt.Fprintf(&b, "   - %s: %s\n", who, truncateText(p.Position, 240))
+		}
+		if c.EvidenceNeeded != "" {
+			fmt.Fprintf(&b, "   Evidence needed: %s\n", c.EvidenceNeeded)
+		}
+	}
+
+	fmt.Fprintf(&b, `
+## Your Task
+Submit a revision or a rebuttal of your previous analysis:
+- Keep findings you still hold, and defend them with evidence where others disagree.
+- Adopt points from other agents that you now find convincing.
+- Withdraw findings you can no longer support.
+- Adjust your confidence to reflect the discussion.
+
+Respond with your complete analysis as a single YAML code block in the same
+schema as before (mode_id: %s, thesis, top_findings, risks, recommendations,
+questions_for_user, failure_modes_to_watch, confidence).
+
+%s
+`, modeID, DeliberationMarker(next))
+	return b.String()
+}
+
+// writeAnonymizedOutput renders a mode output as prose without its mode ID.
+func writeAnonymizedOutput(b *strings.Builder, o ModeOutput) {
+	fmt.Fprintf(b, "Thesis: %s\n", strings.TrimSpace(o.Thesis))
+	fmt.Fprintf(b, "Confidence: %s\n", o.Confidence)
+	if len(o.TopFindings) > 0 {
+		b.WriteString("Findings:\n")
+		for _, f := range o.TopFindings {
+			fmt.Fprintf(b, "- [%s, %s] %s", f.Impact, f.Confidence, f.Finding)
+			if f.EvidencePointer != "" {
+				fmt.Fprintf(b, " (evidence: %s)", f.EvidencePointer)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(o.Risks) > 0 {
+		b.WriteString("Risks:\n")
+		for _, r := range o.Risks {
+			fmt.Fprintf(b, "- [%s] %s\n", r.Impact, r.Risk)
+		}
+	}
+	if len(o.Recommendations) > 0 {
+		b.WriteString("Recommendations:\n")
+		for _, r := range o.Recommendations {
+			fmt.Fprintf(b, "- [%s] %s\n", r.Priority, r.Recommendation)
+		}
+	}
+}
+
+// PositionMovement describes how one mode's position changed between two
+// deliberation rounds.
+type PositionMovement struct {
+	ModeID            string     `json:"mode_id"`
+	ThesisFrom        string     `json:"thesis_from"`
+	ThesisTo          string     `json:"thesis_to"`
+	ThesisChanged     bool       `json:"thesis_changed"`
+	ConfidenceFrom    Confidence `json:"confidence_from"`
+	ConfidenceTo      Confidence `json:"confidence_to"`
+	FindingsKept      int        `json:"findings_kept"`
+	FindingsAdded     int        `json:"findings_added"`
+	FindingsWithdrawn int        `json:"findings_withdrawn"`
+	// AgreementFrom and AgreementTo are the mode's average similarity to
+	// the other modes' outputs in each round.
+	AgreementFrom float64 `json:"agreement_from"`
+	AgreementTo   float64 `json:"agreement_to"`
+}
+
+// DeliberationDiff summarizes how positions moved between two rounds.
+type DeliberationDiff struct {
+	FromRound         int                `json:"from_round"`
+	ToRound           int                `json:"to_round"`
+	Rounds            int                `json:"rounds"`
+	StopReason        string             `json:"stop_reason,omitempty"`
+	Converged         bool               `json:"converged"`
+	SimilarityFrom    float64            `json:"similarity_from"`
+	SimilarityTo      float64            `json:"similarity_to"`
+	ResolvedConflicts []string           `json:"resolved_conflicts,omitempty"`
+	OpenConflicts     []string           `json:"open_conflicts,omitempty"`
+	Positions         []PositionMovement `json:"positions"`
+}
+
+// Movement computes how positions moved from round from to round to.
+func (r *DeliberationRecord) Movement(from, to int) (*DeliberationDiff, error) {
+	a, b := r.Round(from), r.Round(to)
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("rounds %d and %d must be between 1 and %d", from, to, len(r.Rounds))
+	}
+
+	diff := &DeliberationDiff{
+		FromRound:      from,
+		ToRound:        to,
+		Rounds:         len(r.Rounds),
+		StopReason:     r.StopReason,
+		Converged:      r.Converged,
+		SimilarityFrom: a.Similarity,
+		SimilarityTo:   b.Similarity,
+		Positions:      make([]PositionMovement, 0, len(r.ModeIDs)),
+	}
+	open := make(map[string]bool, len(b.Conflicts))
+	for _, c := range b.Conflicts {
+		open[c.Topic] = true
+		diff.OpenConflicts = append(diff.OpenConflicts, c.Topic)
+	}
+	for _, c := range a.Conflicts {
+		if !open[c.Topic] {
+			diff.ResolvedConflicts = append(diff.ResolvedConflicts, c.Topic)
+		}
+	}
+
+	for _, id := range r.ModeIDs {
+		before, okA := a.Output(id)
+		after, okB := b.Output(id)
+		if !okA || !okB {
+			continue
+		}
+		m := PositionMovement{
+			ModeID:         id,
+			ThesisFrom:     before.Thesis,
+			ThesisTo:       after.Thesis,
+			ThesisChanged:  normalizeText(before.Thesis) != normalizeText(after.Thesis),
+			ConfidenceFrom: before.Confidence,
+			ConfidenceTo:   after.Confidence,
+			AgreementFrom:  peerAgreement(*a, before),
+			AgreementTo:    peerAgreement(*b, after),
+		}
+		kept := make(map[string]bool, len(before.TopFindings))
+		for _, f := range before.TopFindings {
+			kept[normalizeText(f.Finding)] = false
+		}
+		for _, f := range after.TopFindings {
+			key := normalizeText(f.Finding)
+			if _, ok := kept[key]; ok {
+				kept[key] = true
+				m.FindingsKept++
+			} else {
+				m.FindingsAdded++
+			}
+		}
+		for _, stayed := range kept {
+			if !stayed {
+				m.FindingsWithdrawn++
+			}
+		}
+		diff.Positions = append(diff.Positions, m)
+	}
+	return diff, nil
+}
+
+// peerAgreement is o's average similarity to the other outputs of round.
+func peerAgreement(round DeliberationRound, o ModeOutput) float64 {
+	var total float64
+	var n int
+	for _, peer := range round.Outputs {
+		if peer.ModeID == o.ModeID {
+			continue
+		}
+		total += outputSimilarity(o, peer)
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return total / float64(n)
+}
+
+// SaveDeliberationRecord persists a deliberation record with its ensemble
+// session in the state store.
+func SaveDeliberationRecord(rec *DeliberationRecord) error {
+	store, err := defaultSQLiteStore()
+	if err != nil {
+		return err
+	}
+	return store.SaveDeliberation(rec)
+}
+
+// LoadDeliberationRecord loads the deliberation record of the ensemble in
+// session. It returns os.ErrNotExist when the ensemble never deliberated and
+// ErrStaleDeliberation when the record was made by an earlier ensemble in
+// the same tmux session.
+func LoadDeliberationRecord(session *EnsembleSession) (*DeliberationRecord, error) {
+	if session == nil {
+		return nil, errors.New("ensemble session is nil")
+	}
+	store, err := defaultSQLiteStore()
+	if err != nil {
+		return nil, err
+	}
+	rec, err := store.LoadDeliberation(session.SessionName)
+	if err != nil {
+		return nil, err
+	}
+	if err := rec.CheckSession(session); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// CheckSession returns ErrStaleDeliberation unless r was recorded by the
+// ensemble in session: it must have the same question and modes, and have
+// started after the ensemble was created.
+func (r *DeliberationRecord) CheckSession(session *EnsembleSession) error {
+	if r == nil || session == nil {
+		return ErrStaleDeliberation
+	}
+	if r.SessionName != session.SessionName {
+		return fmt.Errorf("%w: recorded for session %q", ErrStaleDeliberation, r.SessionName)
+	}
+	if r.Question != session.Question {
+		return fmt.Errorf("%w: question differs", ErrStaleDeliberation)
+	}
+	modeIDs := make([]string, 0, len(session.Assignments))
+	for _, a := range session.Assignments {
+		modeIDs = append(modeIDs, a.ModeID)
+	}
+	recorded := append([]string(nil), r.ModeIDs...)
+	sort.Strings(modeIDs)
+	sort.Strings(recorded)
+	if !slices.Equal(modeIDs, recorded) {
+		return fmt.Errorf("%w: modes %v, ensemble has %v", ErrStaleDeliberation, recorded, modeIDs)
+	}
+	if !session.CreatedAt.IsZero() && r.StartedAt.Before(session.CreatedAt.Truncate(time.Second)) {
+		return fmt.Errorf("%w: started %s, before the ensemble was created", ErrStaleDeliberation, r.StartedAt.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package ensemble
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func deliberationOutput(modeID, thesis string, findings ...string) ModeOutput {
+	out := ModeOutput{ModeID: modeID, Thesis: thesis, Confidence: 0.6}
+	for _, f := range findings {
+		out.TopFindings = append(out.TopFindings, Finding{Finding: f, Impact: ImpactMedium, Confidence: 0.7})
+	}
+	return out
+}
+
+func divergentRound() []ModeOutput {
+	return []ModeOutput{
+		deliberationOutput("deductive", "The cache layer causes the latency regression", "cache misses doubled after the refactor"),
+		deliberationOutput("bayesian", "Database connection pool exhaustion explains slow requests", "pool saturates under peak load"),
+		deliberationOutput("systems-thinking", "Retry storms from the client amplify every outage", "clients retry without backoff"),
+	}
+}
+
+func newTestDeliberation(cfg DeliberationConfig) *Deliberation {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewDeliberation("delib", "Why are requests slow?", []string{"deductive", "bayesian", "systems-thinking"}, cfg, logger)
+}
+
+func TestDeliberation_ConvergesWhenConflictsResolve(t *testing.T) {
+	d := newTestDeliberation(DefaultDeliberationConfig())
+
+	decision := d.AddRound(divergentRound())
+	if decision.ShouldStop {
+		t.Fatalf("round 1 stopped early: %s", decision.Reason)
+	}
+	if got := len(d.Record.Rounds[0].Conflicts); got == 0 {
+		t.Fatal("expected conflicts among divergent outputs")
+	}
+
+	agreed := "Retry storms from clients amplify cache misses and exhaust the pool"
+	decision = d.AddRound([]ModeOutput{
+		deliberationOutput("deductive", agreed, "clients retry without backoff"),
+		deliberationOutput("bayesian", agreed, "clients retry without backoff"),
+		deliberationOutput("systems-thinking", agreed, "clients retry without backoff"),
+	})
+	if !decision.ShouldStop || decision.Reason != DeliberationStopNoConflicts {
+		t.Fatalf("decision = %+v, want stop with %s", decision, DeliberationStopNoConflicts)
+	}
+	if !d.Record.Converged || d.Record.CompletedAt == nil {
+		t.Errorf("record = %+v, want converged and completed", d.Record)
+	}
+	if d.Record.TokensSpent <= 0 {
+		t.Errorf("TokensSpent = %d, want > 0", d.Record.TokensSpent)
+	}
+
+	chain, ok := d.Provenance.GetChain(GenerateFindingID("deductive", "cache misses doubled after the refactor"))
+	if !ok || chain.WithdrawnInRound != 2 {
+		t.Fatalf("withdrawn finding chain = %+v, want withdrawn in round 2", chain)
+	}
+	chain, ok = d.Provenance.GetChain(GenerateFindingID("deductive", "clients retry without backoff"))
+	if !ok || chain.Steps[0].Action != "introduced" || chain.Steps[0].Round != 2 {
+		t.Fatalf("adopted finding chain = %+v, want introduced in round 2", chain)
+	}
+}
+
+func TestDeliberation_StopsOnLimits(t *testing.T) {
+	t.Run("max rounds", func(t *testing.T) {
+		cfg := DefaultDeliberationConfig()
+		cfg.MaxRounds = 1
+		d := newTestDeliberation(cfg)
+		if decision := d.AddRound(divergentRound()); decision.Reason != DeliberationStopMaxRounds {
+			t.Errorf("reason = %q, want %q", decision.Reason, DeliberationStopMaxRounds)
+		}
+		if d.Record.Converged {
+			t.Error("hitting max rounds must not count as converged")
+		}
+	})
+
+	t.Run("budget", func(t *testing.T) {
+		cfg := DefaultDeliberationConfig()
+		cfg.Budget.MaxTotalTokens = 5
+		d := newTestDeliberation(cfg)
+		if decision := d.AddRound(divergentRound()); decision.Reason != DeliberationStopBudget {
+			t.Errorf("reason = %q, want %q", decision.Reason, DeliberationStopBudget)
+		}
+	})
+
+	t.Run("no movement", func(t *testing.T) {
+		d := newTestDeliberation(DefaultDeliberationConfig())
+		d.AddRound(divergentRound())
+		decision := d.AddRound(divergentRound())
+		if decision.Reason != DeliberationStopNoMovement {
+			t.Errorf("reason = %q, want %q", decision.Reason, DeliberationStopNoMovement)
+		}
+	})
+}
+
+func TestDeliberation_MissingRevisionsCarryOver(t *testing.T) {
+	d := newTestDeliberation(DefaultDeliberationConfig())
+	d.AddRound(divergentRound())
+	d.AddRound([]ModeOutput{
+		deliberationOutput("deductive", "Cache misses and retries together cause the regression", "cache misses doubled after the refactor", "clients retry without backoff"),
+	})
+
+	round := d.Record.Round(2)
+	if len(round.Outputs) != 3 {
+		t.Fatalf("round 2 has %d outputs, want 3", len(round.Outputs))
+	}
+	if strings.Join(round.Missing, ",") != "bayesian,systems-thinking" {
+		t.Errorf("Missing = %v, want bayesian and systems-thinking", round.Missing)
+	}
+	if out, _ := round.Output("bayesian"); out.Thesis != divergentRound()[1].Thesis {
+		t.Errorf("bayesian output not carried over: %q", out.Thesis)
+	}
+}
+
+func TestDeliberation_PromptIsAnonymized(t *testing.T) {
+	d := newTestDeliberation(DefaultDeliberationConfig())
+	d.AddRound(divergentRound())
+
+	prompt := d.Prompt("deductive")
+	for _, want := range []string{
+		"DELIBERATION ROUND 2 of up to 3",
+		"### Agent B",
+		"### Agent C",
+		"Database connection pool exhaustion",
+		"- You: The cache layer causes the latency regression",
+		"mode_id: deductive",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+	for _, leak := range []string{"bayesian", "systems-thinking", "### Agent A"} {
+		if strings.Contains(prompt, leak) {
+			t.Errorf("prompt leaks %q", leak)
+		}
+	}
+	if !strings.HasSuffix(strings.TrimSpace(prompt), DeliberationMarker(2)) {
+		t.Error("prompt must end with the round marker")
+	}
+}
+
+func TestDeliberationRecord_MovementAndPersistence(t *testing.T) {
+	d := newTestDeliberation(DefaultDeliberationConfig())
+	d.AddRound(divergentRound())
+	agreed := "Retry storms from clients amplify cache misses and exhaust the pool"
+	d.AddRound([]ModeOutput{
+		deliberationOutput("deductive", agreed, "clients retry without backoff", "cache misses doubled after the refactor"),
+		deliberationOutput("bayesian", agreed, "clients retry without backoff"),
+		deliberationOutput("systems-thinking", agreed, "clients retry without backoff"),
+	})
+
+	store, err := NewStateStore(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewStateStore: %v", err)
+	}
+	defer store.Close()
+	session := &EnsembleSession{
+		SessionName: "delib",
+		Question:    "Why are requests slow?",
+		Assignments: []ModeAssignment{
+			{ModeID: "systems-thinking", PaneName: "delib__cc_1", AgentType: "cc", Status: AssignmentActive},
+			{ModeID: "deductive", PaneName: "delib__cc_2", AgentType: "cc", Status: AssignmentActive},
+			{ModeID: "bayesian", PaneName: "delib__cc_3", AgentType: "cc", Status: AssignmentActive},
+		},
+		Status:    EnsembleActive,
+		CreatedAt: d.Record.StartedAt.Add(-time.Minute),
+	}
+	if err := store.Save(session); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := store.SaveDeliberation(d.Record); err != nil {
+		t.Fatalf("SaveDeliberation: %v", err)
+	}
+	rec, err := store.LoadDeliberation("delib")
+	if err != nil {
+		t.Fatalf("LoadDeliberation: %v", err)
+	}
+	if err := rec.CheckSession(session); err != nil {
+		t.Errorf("CheckSession: %v", err)
+	}
+	if _, err := store.LoadDeliberation("other"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing record error = %v, want os.ErrNotExist", err)
+	}
+
+	// A later ensemble in the same tmux session does not inherit the record.
+	later := *session
+	later.CreatedAt = d.Record.StartedAt.Add(time.Minute)
+	if err := store.Save(&later); err != nil {
+		t.Fatalf("Save(later ensemble): %v", err)
+	}
+	reloaded, err := store.Load("delib")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := rec.CheckSession(reloaded); !errors.Is(err, ErrStaleDeliberation) {
+		t.Errorf("CheckSession(later ensemble) = %v, want ErrStaleDeliberation", err)
+	}
+	other := *session
+	other.Question = "Why is the cache cold?"
+	if err := rec.CheckSession(&other); !errors.Is(err, ErrStaleDeliberation) {
+		t.Errorf("CheckSession(other question) = %v, want ErrStaleDeliberation", err)
+	}
+	other = *session
+	other.Assignments = session.Assignments[:2]
+	if err := rec.CheckSession(&other); !errors.Is(err, ErrStaleDeliberation) {
+		t.Errorf("CheckSession(other modes) = %v, want ErrStaleDeliberation", err)
+	}
+	if err := store.Delete("delib"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.LoadDeliberation("delib"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("record after ensemble deleted: error = %v, want os.ErrNotExist", err)
+	}
+
+	diff, err := rec.Movement(1, 2)
+	if err != nil {
+		t.Fatalf("Movement: %v", err)
+	}
+	if len(diff.ResolvedConflicts) == 0 || len(diff.OpenConflicts) != 0 {
+		t.Errorf("conflicts resolved=%v open=%v, want all resolved", diff.ResolvedConflicts, diff.OpenConflicts)
+	}
+	if len(diff.Positions) != 3 {
+		t.Fatalf("positions = %d, want 3", len(diff.Positions))
+	}
+	deductive := diff.Positions[0]
+	if !deductive.ThesisChanged || deductive.FindingsKept != 1 || deductive.FindingsAdded != 1 || deductive.FindingsWithdrawn != 0 {
+		t.Errorf("deductive movement = %+v", deductive)
+	}
+	if bayes := diff.Positions[1]; bayes.FindingsWithdrawn != 1 || bayes.AgreementTo <= bayes.AgreementFrom {
+		t.Errorf("bayesian movement = %+v, want one withdrawal and rising agreement", bayes)
+	}
+	if _, err := rec.Movement(1, 3); err == nil {
+		t.Error("expected error for unrecorded round")
+	}
+
+	result, err := CompareRounds(rec, 1, 2)
+	if err != nil {
+		t.Fatalf("CompareRounds: %v", err)
+	}
+	if result.RunA != "delib@round1" || result.Deliberation == nil {
+		t.Fatalf("result = %+v", result)
+	}
+	report := FormatComparison(result)
+	if !strings.Contains(report, "Position Movement (round 1 → 2 of 2)") || !strings.Contains(report, "conflicts resolved") {
+		t.Errorf("report missing position movement:\n%s", report)
+	}
+
+	tracker := rec.Provenance()
+	if stats := tracker.Stats(); stats.WithdrawnCount != 1 {
+		t.Errorf("WithdrawnCount = %d, want 1", stats.WithdrawnCount)
+	}
+}
@@ -28,6 +28,9 @@ type ProvenanceStep struct {
 
 	// RelatedIDs lists other finding IDs involved (e.g., for merges).
 	RelatedIDs []string `json:"related_ids,omitempty"`
+
+	// Round is the deliberation round the step belongs to (0 outside deliberation).
+	Round int `json:"round,omitempty"`
 }
 
 // ProvenanceChain tracks the full lifecycle of a finding.
@@ -70,6 +73,10 @@ type ProvenanceChain struct {
 
 	// SynthesisCitations tracks where this finding was cited in synthesis.
 	SynthesisCitations []string `json:"synthesis_citations,omitempty"`
+
+	// WithdrawnInRound is the deliberation round in which the source mode
+	// dropped this finding (0 if it was never withdrawn or was reinstated).
+	WithdrawnInRound int `json:"withdrawn_in_round,omitempty"`
 }
 
 // AddStep appends a transformation step to the chain.
@@ -85,6 +92,11 @@ func (p *ProvenanceChain) AddStep(stage, action, details string, relatedIDs ...s
 	p.UpdatedAt = step.Timestamp
 }
 
+func (p *ProvenanceChain) addRoundStep(round int, action, details string) {
+	p.AddStep("deliberation", action, details)
+	p.Steps[len(p.Steps)-1].Round = round
+}
+
 // IsActive returns true if this finding wasn't merged into another.
 func (p *ProvenanceChain) IsActive() bool {
 	return p.MergedInto == ""
@@ -95,6 +107,10 @@ type ProvenanceTracker struct {
 	mu          sync.RWMutex
 	chains      map[string]*ProvenanceChain
 	contextHash string
+
+	// roundFindings holds each mode's finding IDs from its latest
+	// deliberation round, used to detect withdrawn findings.
+	roundFindings map[string][]string
 }
 
 // NewProvenanceTracker creates a tracker for an ensemble run.
@@ -108,8 +124,9 @@ func NewProvenanceTracker(question string, modeIDs []string) *ProvenanceTracker
 	contextHash := hex.EncodeToString(h.Sum(nil))[:16]
 
 	return &ProvenanceTracker{
-		chains:      make(map[string]*ProvenanceChain),
-		contextHash: contextHash,
+		chains:        make(map[string]*ProvenanceChain),
+		contextHash:   contextHash,
+		roundFindings: make(map[string][]string),
 	}
 }
 
@@ -122,12 +139,17 @@ func GenerateFindingID(modeID, findingText string) string {
 	return hex.EncodeToString(h.Sum(nil))[:12]
 }
 
-// RecordDiscovery tracks a finding being discovered by a mode.
+// RecordDiscovery tracks a finding being discovered by a mode. A finding
+// that is already tracked keeps its existing chain.
 func (t *ProvenanceTracker) RecordDiscovery(modeID string, finding Finding) string {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	findingID := GenerateFindingID(modeID, finding.Finding)
+	if _, ok := t.chains[findingID]; ok {
+		// Already tracked, e.g. across deliberation rounds; keep its history.
+		return findingID
+	}
 
 	chain := &ProvenanceChain{
 		FindingID:    findingID,
@@ -218,6 +240,78 @@ func (t *ProvenanceTracker) RecordTextChange(findingID, newText, reason string)
 	return nil
 }
 
+// RecordRound tracks a mode's findings in a deliberation round. Findings seen
+// for the first time are discovered; findings the mode reported in its
+// previous round are retained, and those it no longer reports are withdrawn.
+// It returns the IDs of the round's findings.
+func (t *ProvenanceTracker) RecordRound(round int, modeID string, findings []Finding) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ids := make([]string, 0, len(findings))
+	current := make(map[string]bool, len(findings))
+	previous := make(map[string]bool)
+	for _, id := range t.roundFindings[modeID] {
+		previous[id] = true
+	}
+
+	for _, finding := range findings {
+		findingID := GenerateFindingID(modeID, finding.Finding)
+		if current[findingID] {
+			continue
+		}
+		current[findingID] = true
+		ids = append(ids, findingID)
+
+		chain, ok := t.chains[findingID]
+		switch {
+		case !ok:
+			now := time.Now()
+			chain = &ProvenanceChain{
+				FindingID:    findingID,
+				SourceMode:   modeID,
+				ContextHash:  t.contextHash,
+				OriginalText: finding.Finding,
+				CurrentText:  finding.Finding,
+				Impact:       finding.Impact,
+				Confidence:   finding.Confidence,
+				Steps:        make([]ProvenanceStep, 0, 4),
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			}
+			t.chains[findingID] = chain
+			if round <= 1 {
+				chain.addRoundStep(round, "discovered", fmt.Sprintf("Found by mode %s with confidence %.2f", modeID, finding.Confidence))
+			} else {
+				chain.addRoundStep(round, "introduced", fmt.Sprintf("Added by mode %s in round %d with confidence %.2f", modeID, round, finding.Confidence))
+			}
+		case chain.WithdrawnInRound > 0:
+			chain.WithdrawnInRound = 0
+			chain.addRoundStep(round, "reinstated", fmt.Sprintf("Reported again by mode %s in round %d", modeID, round))
+		case previous[findingID]:
+			details := fmt.Sprintf("Kept by mode %s in round %d", modeID, round)
+			if chain.Confidence != finding.Confidence {
+				details = fmt.Sprintf("Kept by mode %s in round %d, confidence %.2f -> %.2f", modeID, round, chain.Confidence, finding.Confidence)
+			}
+			chain.addRoundStep(round, "retained", details)
+		}
+		chain.Impact = finding.Impact
+		chain.Confidence = finding.Confidence
+	}
+
+	for _, id := range t.roundFindings[modeID] {
+		if current[id] {
+			continue
+		}
+		if chain, ok := t.chains[id]; ok && chain.WithdrawnInRound == 0 {
+			chain.WithdrawnInRound = round
+			chain.addRoundStep(round, "withdrawn", fmt.Sprintf("Dropped by mode %s in round %d", modeID, round))
+		}
+	}
+	t.roundFindings[modeID] = ids
+	return ids
+}
+
 // GetChain retrieves the provenance chain for a finding.
 func (t *ProvenanceTracker) GetChain(findingID string) (*ProvenanceChain, bool) {
 	t.mu.RLock()
@@ -335,6 +429,7 @@ type ProvenanceStats struct {
 	TotalFindings   int            `json:"total_findings"`
 	ActiveFindings  int            `json:"active_findings"`
 	MergedFindings  int            `json:"merged_findings"`
+	WithdrawnCount  int            `json:"withdrawn_count,omitempty"`
 	FilteredCount   int            `json:"filtered_count"`
 	CitedCount      int            `json:"cited_count"`
 	ModeBreakdown   map[string]int `json:"mode_breakdown"`
@@ -366,6 +461,10 @@ func (t *ProvenanceTracker) computeStatsLocked() ProvenanceStats {
 			stats.CitedCount++
 		}
 
+		if chain.WithdrawnInRound > 0 {
+			stats.WithdrawnCount++
+		}
+
 		// Count filtered findings
 		for _, step := range chain.Steps {
 			if step.Action == "filtered" {
@@ -403,6 +502,8 @@ func FormatProvenance(chain *ProvenanceChain) string {
 	// Status
 	if chain.MergedInto != "" {
 		fmt.Fprintf(&b, "Status: Merged into %s\n\n", chain.MergedInto)
+	} else if chain.WithdrawnInRound > 0 {
+		fmt.Fprintf(&b, "Status: Withdrawn in round %d\n\n", chain.WithdrawnInRound)
 	} else if len(chain.MergedFrom) > 0 {
 		fmt.Fprintf(&b, "Status: Active (merged %d findings)\n\n", len(chain.MergedFrom))
 	} else {
@@ -416,10 +517,14 @@ func FormatProvenance(chain *ProvenanceChain) string {
 		if i == len(chain.Steps)-1 {
 			marker = "└─"
 		}
+		stage := step.Stage
+		if step.Round > 0 {
+			stage = fmt.Sprintf("%s (round %d)", step.Stage, step.Round)
+		}
 		fmt.Fprintf(&b, "  %s [%s] %s: %s\n",
 			marker,
 			step.Timestamp.Format("15:04:05"),
-			step.Stage,
+			stage,
 			step.Action,
 		)
 		if step.Details != "" {
@@ -1,6 +1,7 @@
 package ensemble
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -81,6 +82,42 @@ func (s *StateStore) Load(sessionName string) (*EnsembleSession, error) {
 	return fromStateSession(session), nil
 }
 
+// SaveDeliberation persists the deliberation record of an ensemble session.
+// The session must already be saved.
+func (s *StateStore) SaveDeliberation(rec *DeliberationRecord) error {
+	if s == nil || s.ensembles == nil {
+		return errors.New("ensemble state store is nil")
+	}
+	if rec == nil {
+		return errors.New("deliberation record is nil")
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal deliberation: %w", err)
+	}
+	return s.ensembles.SaveDeliberation(rec.SessionName, data)
+}
+
+// LoadDeliberation fetches the deliberation record of the ensemble in
+// sessionName. It returns os.ErrNotExist when the ensemble never deliberated.
+func (s *StateStore) LoadDeliberation(sessionName string) (*DeliberationRecord, error) {
+	if s == nil || s.ensembles == nil {
+		return nil, errors.New("ensemble state store is nil")
+	}
+	data, err := s.ensembles.GetDeliberation(sessionName)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, os.ErrNotExist
+	}
+	var rec DeliberationRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("unmarshal deliberation: %w", err)
+	}
+	return &rec, nil
+}
+
 // UpdateStatus updates the overall status of an ensemble session.
 func (s *StateStore) UpdateStatus(sessionName string, status EnsembleStatus) error {
 	if s == nil || s.ensembles == nil {
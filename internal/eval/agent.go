@@ -0,0 +1,181 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/reply"
+	"github.com/Dicklesworthstone/ntm/internal/status"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+	"github.com/Dicklesworthstone/ntm/internal/tokens"
+)
+
+// Agent attempts one task in a prepared workspace.
+type Agent interface {
+	Run(ctx context.Context, req Request) (*Transcript, error)
+}
+
+// Request is one attempt at a task.
+type Request struct {
+	ID        string // Unique per attempt; safe for session names
+	Candidate Candidate
+	Task      Task
+	Prompt    string
+	Dir       string // Workspace the agent works in
+}
+
+// Transcript is what an agent produced for a request.
+type Transcript struct {
+	Output       string
+	InputTokens  int
+	OutputTokens int
+}
+
+// Env returns the NTM_EVAL_* variables describing req, for stub commands
+// and verify scripts.
+func (req Request) Env() []string {
+	return []string{
+		"NTM_EVAL_ID=" + req.ID,
+		"NTM_EVAL_TASK=" + req.Task.ID,
+		"NTM_EVAL_TASK_TYPE=" + req.Task.Type,
+		"NTM_EVAL_CANDIDATE=" + req.Candidate.Name,
+		"NTM_EVAL_MODEL=" + req.Candidate.Model,
+		"NTM_EVAL_WORKSPACE=" + req.Dir,
+	}
+}
+
+// CommandAgent runs a shell command headless in the workspace with the
+// prompt on stdin. Its combined output is the transcript.
+type CommandAgent struct {
+	Command string
+}
+
+// Run implements Agent.
+func (a *CommandAgent) Run(ctx context.Context, req Request) (*Transcript, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", a.Command)
+	cmd.Dir = req.Dir
+	cmd.Env = append(append(os.Environ(), req.Env()...), "NTM_EVAL_PROMPT="+req.Prompt)
+	cmd.Stdin = strings.NewReader(req.Prompt)
+	cmd.WaitDelay = 5 * time.Second
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	t := &Transcript{
+		Output:       out.String(),
+		InputTokens:  tokens.EstimateTokens(req.Prompt),
+		OutputTokens: tokens.EstimateTokens(out.String()),
+	}
+	if ctx.Err() != nil {
+		return t, ctx.Err()
+	}
+	if err != nil {
+		return t, fmt.Errorf("agent command: %w", err)
+	}
+	return t, nil
+}
+
+// TmuxAgent launches an interactive agent in a detached tmux session rooted
+// at the workspace, sends it the prompt, and waits until it has been idle
+// for Quiet before capturing its reply and killing the session.
+type TmuxAgent struct {
+	Command   string // Fully rendered agent launch command
+	AgentType tmux.AgentType
+
+	Interval time.Duration // Poll interval (default reply.DefaultInterval)
+	Quiet    time.Duration // Idle time that ends a turn (default 15s)
+	Startup  time.Duration // Minimum wait for the agent to boot (default 5s)
+}
+
+// Run implements Agent.
+func (a *TmuxAgent) Run(ctx context.Context, req Request) (*Transcript, error) {
+	session := "ntm-eval-" + req.ID
+	if err := tmux.CreateSession(session, req.Dir); err != nil {
+		return nil, fmt.Errorf("creating eval session: %w", err)
+	}
+	defer func() { _ = tmux.KillSession(session) }()
+
+	panes, err := tmux.GetPanes(session)
+	if err != nil || len(panes) == 0 {
+		return nil, fmt.Errorf("finding eval pane: %w", err)
+	}
+	pane := panes[0].ID
+	_ = tmux.SetPaneTitle(pane, tmux.FormatPaneName(session, string(a.AgentType), 1, req.Candidate.Model))
+
+	launch, err := tmux.BuildPaneCommand(req.Dir, a.Command)
+	if err != nil {
+		return nil, fmt.Errorf("building agent command: %w", err)
+	}
+	if err := tmux.SendKeys(pane, launch, true); err != nil {
+		return nil, fmt.Errorf("launching agent: %w", err)
+	}
+
+	startup := a.Startup
+	if startup <= 0 {
+		startup = 5 * time.Second
+	}
+	if err := a.waitIdle(ctx, pane, time.Now(), startup); err != nil {
+		return nil, fmt.Errorf("waiting for agent to start: %w", err)
+	}
+
+	line, err := tmux.PaneCursorLine(pane)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmux.SendKeysForAgent(pane, req.Prompt, true, a.AgentType); err != nil {
+		return nil, fmt.Errorf("sending prompt: %w", err)
+	}
+	waitErr := a.waitIdle(ctx, pane, time.Now(), a.quiet())
+
+	raw, err := reply.CaptureSince(pane, line, req.Prompt)
+	if err != nil {
+		return nil, err
+	}
+	text := reply.Clean(raw, string(a.AgentType), req.Prompt)
+	return &Transcript{
+		Output:       text,
+		InputTokens:  tokens.EstimateTokens(req.Prompt),
+		OutputTokens: tokens.EstimateTokens(text),
+	}, waitErr
+}
+
+// waitIdle polls pane until it is idle, quiet for the configured period,
+// and at least atLeast has passed since start.
+func (a *TmuxAgent) waitIdle(ctx context.Context, pane string, start time.Time, atLeast time.Duration) error {
+	interval := a.Interval
+	if interval <= 0 {
+		interval = reply.DefaultInterval
+	}
+	detector := status.NewDetector()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		now := time.Now()
+		if now.Sub(start) < atLeast {
+			continue
+		}
+		st, err := detector.Detect(pane)
+		if err != nil {
+			return err
+		}
+		if st.State == status.StateIdle && now.Sub(st.LastActive) >= a.quiet() {
+			return nil
+		}
+	}
+}
+
+func (a *TmuxAgent) quiet() time.Duration {
+	if a.Quiet > 0 {
+		return a.Quiet
+	}
+	return 15 * time.Second
+}
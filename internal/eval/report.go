@@ -0,0 +1,129 @@
+package eval
+
+import (
+	"sort"
+	"time"
+)
+
+// CandidateSummary aggregates one candidate's results across tasks.
+type CandidateSummary struct {
+	Candidate string `json:"candidate"`
+	Agent     string `json:"agent"`
+	Model     string `json:"model,omitempty"`
+	Persona   string `json:"persona,omitempty"`
+
+	Attempts      int     `json:"attempts"`
+	Passed        int     `json:"passed"`
+	PassRate      float64 `json:"pass_rate"`
+	AvgScore      float64 `json:"avg_score"`
+	AvgDurationMs int64   `json:"avg_duration_ms"`
+	AvgTokens     int     `json:"avg_tokens"`
+	TotalCostUSD  float64 `json:"total_cost_usd"`
+}
+
+// TaskCell is one candidate's record on one task.
+type TaskCell struct {
+	Attempts      int   `json:"attempts"`
+	Passed        int   `json:"passed"`
+	AvgDurationMs int64 `json:"avg_duration_ms"`
+}
+
+// TaskRow compares the candidates on one task.
+type TaskRow struct {
+	Task       string              `json:"task"`
+	TaskType   string              `json:"task_type"`
+	Candidates map[string]TaskCell `json:"candidates"`
+}
+
+// Report compares candidates across one or more runs.
+type Report struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Suites      []string           `json:"suites"`
+	Runs        []string           `json:"runs"`
+	Candidates  []CandidateSummary `json:"candidates"` // Best first
+	Tasks       []TaskRow          `json:"tasks"`
+}
+
+// BuildReport aggregates the results of runs. Candidates are ranked by
+// pass rate, then average score, then cost.
+func BuildReport(runs []*Run) *Report {
+	rep := &Report{GeneratedAt: time.Now().UTC()}
+
+	type acc struct {
+		sum      CandidateSummary
+		score    float64
+		duration int64
+		tokens   int
+	}
+	byCandidate := make(map[string]*acc)
+	byTask := make(map[string]*TaskRow)
+	taskDur := make(map[string]map[string]int64)
+	var taskOrder []string
+	suites := make(map[string]bool)
+
+	for _, run := range runs {
+		rep.Runs = append(rep.Runs, run.ID)
+		if !suites[run.Suite] {
+			suites[run.Suite] = true
+			rep.Suites = append(rep.Suites, run.Suite)
+		}
+		for _, r := range run.Results {
+			a := byCandidate[r.Candidate]
+			if a == nil {
+				a = &acc{sum: CandidateSummary{Candidate: r.Candidate, Agent: r.Agent, Model: r.Model, Persona: r.Persona}}
+				byCandidate[r.Candidate] = a
+			}
+			a.sum.Attempts++
+			if r.Passed {
+				a.sum.Passed++
+			}
+			a.score += r.Score(run.ID).Metrics.Overall
+			a.duration += r.DurationMs
+			a.tokens += r.Tokens()
+			a.sum.TotalCostUSD += r.CostUSD
+
+			row := byTask[r.Task]
+			if row == nil {
+				row = &TaskRow{Task: r.Task, TaskType: r.TaskType, Candidates: make(map[string]TaskCell)}
+				byTask[r.Task] = row
+				taskDur[r.Task] = make(map[string]int64)
+				taskOrder = append(taskOrder, r.Task)
+			}
+			cell := row.Candidates[r.Candidate]
+			cell.Attempts++
+			if r.Passed {
+				cell.Passed++
+			}
+			taskDur[r.Task][r.Candidate] += r.DurationMs
+			cell.AvgDurationMs = taskDur[r.Task][r.Candidate] / int64(cell.Attempts)
+			row.Candidates[r.Candidate] = cell
+		}
+	}
+
+	for _, a := range byCandidate {
+		n := a.sum.Attempts
+		a.sum.PassRate = float64(a.sum.Passed) / float64(n)
+		a.sum.AvgScore = a.score / float64(n)
+		a.sum.AvgDurationMs = a.duration / int64(n)
+		a.sum.AvgTokens = a.tokens / n
+		rep.Candidates = append(rep.Candidates, a.sum)
+	}
+	sort.Slice(rep.Candidates, func(i, j int) bool {
+		a, b := rep.Candidates[i], rep.Candidates[j]
+		if a.PassRate != b.PassRate {
+			return a.PassRate > b.PassRate
+		}
+		if a.AvgScore != b.AvgScore {
+			return a.AvgScore > b.AvgScore
+		}
+		if a.TotalCostUSD != b.TotalCostUSD {
+			return a.TotalCostUSD < b.TotalCostUSD
+		}
+		return a.Candidate < b.Candidate
+	})
+
+	for _, id := range taskOrder {
+		rep.Tasks = append(rep.Tasks, *byTask[id])
+	}
+	return rep
+}
@@ -0,0 +1,416 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/cost"
+	"github.com/Dicklesworthstone/ntm/internal/scoring"
+	"github.com/Dicklesworthstone/ntm/internal/util"
+)
+
+const (
+	// DefaultRunsDir is where run records are stored.
+	DefaultRunsDir = "~/.config/ntm/analytics/eval"
+
+	// ScoreSessionPrefix marks effectiveness scores recorded by evaluations.
+	ScoreSessionPrefix = "eval:"
+
+	// maxVerifyOutput caps the verify output kept per result.
+	maxVerifyOutput = 4000
+)
+
+// Result is the outcome of one candidate's attempt at one task.
+type Result struct {
+	Task      string `json:"task"`
+	TaskType  string `json:"task_type"`
+	Attempt   int    `json:"attempt"`
+	Candidate string `json:"candidate"`
+	Agent     string `json:"agent"`
+	Model     string `json:"model,omitempty"`
+	Persona   string `json:"persona,omitempty"`
+
+	Passed       bool    `json:"passed"`
+	DurationMs   int64   `json:"duration_ms"`
+	TimeoutMs    int64   `json:"timeout_ms"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+
+	VerifyOutput string `json:"verify_output,omitempty"`
+	Error        string `json:"error,omitempty"`
+	Workspace    string `json:"workspace,omitempty"` // Set when workspaces are kept
+}
+
+// Duration returns how long the agent worked on the task.
+func (r Result) Duration() time.Duration {
+	return time.Duration(r.DurationMs) * time.Millisecond
+}
+
+// Tokens returns the total tokens the attempt used.
+func (r Result) Tokens() int {
+	return r.InputTokens + r.OutputTokens
+}
+
+// Score converts r to an effectiveness score for the scoring store.
+// Completion is pass/fail; efficiency is the share of the timeout left.
+func (r Result) Score(runID string) *scoring.Score {
+	m := scoring.ScoreMetrics{
+		TokensUsed:      r.Tokens(),
+		PromptsUsed:     1,
+		DurationMinutes: int(r.Duration().Minutes()),
+	}
+	if r.Passed {
+		m.Completion = 1
+		m.Quality = 1
+		m.Efficiency = 0.1
+		if r.TimeoutMs > 0 {
+			m.Efficiency = max(0.1, 1-float64(r.DurationMs)/float64(r.TimeoutMs))
+		}
+	} else {
+		m.ErrorCount = 1
+	}
+	m.ComputeOverall()
+
+	return &scoring.Score{
+		Session:   ScoreSessionPrefix + runID,
+		AgentType: r.Agent,
+		AgentName: r.Candidate,
+		TaskType:  r.TaskType,
+		Metrics:   m,
+		Context: map[string]interface{}{
+			"eval_task":    r.Task,
+			"eval_attempt": r.Attempt,
+			"model":        r.Model,
+			"persona":      r.Persona,
+			"cost_usd":     r.CostUSD,
+		},
+	}
+}
+
+// Run is the record of one suite run.
+type Run struct {
+	ID          string     `json:"id"`
+	Suite       string     `json:"suite"`
+	SuitePath   string     `json:"suite_path,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Results     []Result   `json:"results"`
+}
+
+// Runner runs a suite.
+type Runner struct {
+	Suite *Suite
+
+	// NewAgent builds the agent for a candidate. Required.
+	NewAgent func(Candidate) (Agent, error)
+
+	// Tracker receives a score per result; nil records none.
+	Tracker *scoring.Tracker
+
+	// WorkDir holds the workspaces; empty uses a temporary directory.
+	WorkDir string
+
+	// Keep leaves workspaces in place for inspection.
+	Keep bool
+
+	// Repeat is how many attempts each candidate makes at each task
+	// (default 1).
+	Repeat int
+
+	// OnResult is called after every attempt, e.g. for progress output.
+	OnResult func(Result)
+}
+
+// Run attempts every task with every candidate and returns the run record.
+// Cancelling ctx stops after the current attempt; the partial run is
+// returned along with ctx's error.
+func (r *Runner) Run(ctx context.Context) (*Run, error) {
+	if r.NewAgent == nil {
+		return nil, errors.New("eval runner needs NewAgent")
+	}
+	run := &Run{
+		ID:        newRunID(r.Suite.Name),
+		Suite:     r.Suite.Name,
+		SuitePath: r.Suite.Path,
+		StartedAt: time.Now().UTC(),
+	}
+
+	workDir := r.WorkDir
+	if workDir == "" {
+		dir, err := os.MkdirTemp("", "ntm-eval-")
+		if err != nil {
+			return nil, fmt.Errorf("creating eval work dir: %w", err)
+		}
+		workDir = dir
+		if !r.Keep {
+			defer os.RemoveAll(dir)
+		}
+	}
+
+	agents := make(map[string]Agent, len(r.Suite.Candidates))
+	for _, c := range r.Suite.Candidates {
+		a, err := r.NewAgent(c)
+		if err != nil {
+			return nil, fmt.Errorf("candidate %s: %w", c.Name, err)
+		}
+		agents[c.Name] = a
+	}
+
+	repeat := max(r.Repeat, 1)
+	tag := run.ID[strings.LastIndex(run.ID, "-")+1:]
+	n := 0
+	for _, task := range r.Suite.Tasks {
+		prompt, err := r.Suite.PromptText(task)
+		if err != nil {
+			return run, err
+		}
+		for attempt := 1; attempt <= repeat; attempt++ {
+			for _, c := range r.Suite.Candidates {
+				if err := ctx.Err(); err != nil {
+					return run, err
+				}
+				n++
+				req := Request{
+					ID:        fmt.Sprintf("%s-%d", tag, n),
+					Candidate: c,
+					Task:      task,
+					Prompt:    prompt,
+					Dir:       filepath.Join(workDir, run.ID, fmt.Sprintf("%s-%s-%d", task.ID, c.Name, attempt)),
+				}
+				res := r.attempt(ctx, agents[c.Name], req, attempt)
+				run.Results = append(run.Results, res)
+				if r.Tracker != nil {
+					if err := r.Tracker.Record(res.Score(run.ID)); err != nil {
+						return run, fmt.Errorf("recording score: %w", err)
+					}
+				}
+				if r.OnResult != nil {
+					r.OnResult(res)
+				}
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	run.CompletedAt = &now
+	return run, nil
+}
+
+// attempt runs one request and verifies the workspace afterwards.
+func (r *Runner) attempt(ctx context.Context, agent Agent, req Request, attempt int) Result {
+	timeout := r.Suite.TaskTimeout(req.Task)
+	res := Result{
+		Task:      req.Task.ID,
+		TaskType:  string(req.Task.TaskType()),
+		Attempt:   attempt,
+		Candidate: req.Candidate.Name,
+		Agent:     string(req.Candidate.AgentType()),
+		Model:     req.Candidate.Model,
+		Persona:   req.Candidate.Persona,
+		TimeoutMs: timeout.Milliseconds(),
+	}
+
+	cleanup, err := prepareWorkspace(ctx, r.Suite.RepoPath(req.Task), req.Task.Ref, req.Dir)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if r.Keep {
+		res.Workspace = req.Dir
+	} else {
+		defer cleanup()
+	}
+
+	agentCtx, cancel := context.WithTimeout(ctx, timeout)
+	start := time.Now()
+	transcript, err := agent.Run(agentCtx, req)
+	res.DurationMs = time.Since(start).Milliseconds()
+	cancel()
+	if transcript != nil {
+		res.InputTokens = transcript.InputTokens
+		res.OutputTokens = transcript.OutputTokens
+	}
+	if res.Model != "" {
+		res.CostUSD = cost.GetModelPricing(res.Model).Price(cost.Usage{
+			Input:  res.InputTokens,
+			Output: res.OutputTokens,
+		}).Total()
+	}
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		res.Error = err.Error()
+	}
+	if ctx.Err() != nil {
+		return res
+	}
+
+	// Verify even after an agent error: a timed-out agent may still have
+	// finished the work.
+	verifyCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	out, err := runVerify(verifyCtx, req)
+	res.VerifyOutput = out
+	res.Passed = err == nil
+	return res
+}
+
+// runVerify runs the task's verify script in the workspace; a zero exit
+// status means the task passed.
+func runVerify(ctx context.Context, req Request) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", req.Task.Verify)
+	cmd.Dir = req.Dir
+	cmd.Env = append(os.Environ(), req.Env()...)
+	cmd.WaitDelay = 5 * time.Second
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+
+	text := strings.TrimSpace(out.String())
+	if len(text) > maxVerifyOutput {
+		text = "…" + text[len(text)-maxVerifyOutput:]
+	}
+	return text, err
+}
+
+// prepareWorkspace materialises repo at dest. A git repo gets a detached
+// worktree at ref; a plain directory is copied and committed so agents see
+// a clean git tree either way. The returned func removes the workspace.
+func prepareWorkspace(ctx context.Context, repo, ref, dest string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return nil, fmt.Errorf("creating workspace: %w", err)
+	}
+
+	if _, err := gitOutput(ctx, repo, "rev-parse", "--git-dir"); err == nil {
+		if ref == "" {
+			ref = "HEAD"
+		}
+		if out, err := gitOutput(ctx, repo, "worktree", "add", "--detach", dest, ref); err != nil {
+			return nil, fmt.Errorf("creating worktree of %s at %s: %s", repo, ref, out)
+		}
+		return func() {
+			_, _ = gitOutput(context.Background(), repo, "worktree", "remove", "--force", dest)
+			_ = os.RemoveAll(dest)
+			_, _ = gitOutput(context.Background(), repo, "worktree", "prune")
+		}, nil
+	}
+
+	if ref != "" {
+		return nil, fmt.Errorf("repo %s is not a git repository; ref %q cannot be used", repo, ref)
+	}
+	if err := os.CopyFS(dest, os.DirFS(repo)); err != nil {
+		return nil, fmt.Errorf("copying repo snapshot %s: %w", repo, err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=ntm-eval", "-c", "user.email=ntm-eval@localhost", "commit", "-q", "--allow-empty", "-m", "eval snapshot"},
+	} {
+		if out, err := gitOutput(ctx, dest, args...); err != nil {
+			_ = os.RemoveAll(dest)
+			return nil, fmt.Errorf("git %s in workspace: %s", args[0], out)
+		}
+	}
+	return func() { _ = os.RemoveAll(dest) }, nil
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+func newRunID(suite string) string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%s-%s", suite, time.Now().Format("20060102-150405"), hex.EncodeToString(b))
+}
+
+// SaveRun writes run to dir (DefaultRunsDir if empty) as <id>.json.
+func SaveRun(dir string, run *Run) error {
+	if dir == "" {
+		dir = util.ExpandPath(DefaultRunsDir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating eval runs directory: %w", err)
+	}
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling eval run: %w", err)
+	}
+	return util.AtomicWriteFile(filepath.Join(dir, run.ID+".json"), data, 0644)
+}
+
+// LoadRuns reads the runs in dir (DefaultRunsDir if empty), newest first.
+// Non-empty ids select runs by ID; otherwise suite, if set, filters by
+// suite name.
+func LoadRuns(dir, suite string, ids ...string) ([]*Run, error) {
+	if dir == "" {
+		dir = util.ExpandPath(DefaultRunsDir)
+	}
+	if len(ids) > 0 {
+		runs := make([]*Run, 0, len(ids))
+		for _, id := range ids {
+			run, err := loadRun(filepath.Join(dir, id+".json"))
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil, fmt.Errorf("eval run %q not found", id)
+				}
+				return nil, err
+			}
+			runs = append(runs, run)
+		}
+		return runs, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading eval runs: %w", err)
+	}
+	var runs []*Run
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		run, err := loadRun(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue // Skip unreadable records
+		}
+		if suite != "" && run.Suite != suite {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+	return runs, nil
+}
+
+func loadRun(path string) (*Run, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var run Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("parsing eval run %s: %w", path, err)
+	}
+	return &run, nil
+}
@@ -0,0 +1,182 @@
+package eval
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/scoring"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestRunnerWithStubAgents(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+
+	// A git repo snapshot and a plain directory snapshot.
+	gitRepo := filepath.Join(dir, "gitrepo")
+	if err := os.MkdirAll(gitRepo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(gitRepo, "main.txt"), []byte("buggy\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, gitRepo, "init", "-q")
+	runGit(t, gitRepo, "add", "-A")
+	runGit(t, gitRepo, "commit", "-q", "-m", "snapshot")
+
+	plain := filepath.Join(dir, "plain")
+	if err := os.MkdirAll(plain, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(plain, "README"), []byte("docs\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := writeSuite(t, dir, `
+name = "stubs"
+
+[[candidates]]
+name = "fixer"
+agent = "stub"
+command = "cat > prompt.txt && echo fixed > main.txt && echo done"
+
+[[candidates]]
+name = "idler"
+agent = "stub"
+command = "echo thinking"
+
+[[tasks]]
+id = "fix"
+type = "bug"
+repo = "gitrepo"
+prompt = "Replace buggy with fixed."
+verify = "grep -q fixed main.txt && test \"$NTM_EVAL_TASK\" = fix"
+
+[[tasks]]
+id = "copy"
+type = "docs"
+repo = "plain"
+prompt = "Nothing to do."
+verify = "test -f README && git rev-parse HEAD >/dev/null"
+`)
+	suite, err := LoadSuite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracker, err := scoring.NewTracker(scoring.TrackerOptions{Path: filepath.Join(dir, "scores.jsonl"), Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen []string
+	runner := &Runner{
+		Suite:   suite,
+		Tracker: tracker,
+		WorkDir: filepath.Join(dir, "work"),
+		NewAgent: func(c Candidate) (Agent, error) {
+			return &CommandAgent{Command: c.Command}, nil
+		},
+		OnResult: func(r Result) { seen = append(seen, r.Task+"/"+r.Candidate) },
+	}
+	run, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if run.CompletedAt == nil || len(run.Results) != 4 || len(seen) != 4 {
+		t.Fatalf("run = %+v, seen = %v", run, seen)
+	}
+
+	want := map[string]bool{"fix/fixer": true, "fix/idler": false, "copy/fixer": true, "copy/idler": true}
+	for _, r := range run.Results {
+		key := r.Task + "/" + r.Candidate
+		if r.Passed != want[key] {
+			t.Errorf("%s passed = %v, want %v (verify: %s, error: %s)", key, r.Passed, want[key], r.VerifyOutput, r.Error)
+		}
+		if r.Agent != AgentStub || r.InputTokens == 0 {
+			t.Errorf("%s agent=%q input tokens=%d", key, r.Agent, r.InputTokens)
+		}
+	}
+
+	// The source repo is untouched and its worktrees are cleaned up.
+	if data, _ := os.ReadFile(filepath.Join(gitRepo, "main.txt")); string(data) != "buggy\n" {
+		t.Errorf("source repo modified: %q", data)
+	}
+	out, _ := exec.Command("git", "-C", gitRepo, "worktree", "list").CombinedOutput()
+	if strings.Count(strings.TrimSpace(string(out)), "\n") != 0 {
+		t.Errorf("worktrees left behind:\n%s", out)
+	}
+
+	scores, err := tracker.QueryScores(scoring.Query{})
+	if err != nil || len(scores) != 4 {
+		t.Fatalf("QueryScores = %d scores, %v", len(scores), err)
+	}
+	if scores[0].Session != ScoreSessionPrefix+run.ID || scores[0].TaskType != "bug" || scores[0].Metrics.Completion != 1 {
+		t.Errorf("first score = %+v", scores[0])
+	}
+	if scores[1].Metrics.Overall != 0 {
+		t.Errorf("failed attempt overall = %v, want 0", scores[1].Metrics.Overall)
+	}
+
+	runsDir := filepath.Join(dir, "runs")
+	if err := SaveRun(runsDir, run); err != nil {
+		t.Fatalf("SaveRun: %v", err)
+	}
+	runs, err := LoadRuns(runsDir, "stubs")
+	if err != nil || len(runs) != 1 || runs[0].ID != run.ID {
+		t.Fatalf("LoadRuns = %v, %v", runs, err)
+	}
+	if runs, _ := LoadRuns(runsDir, "other"); len(runs) != 0 {
+		t.Errorf("LoadRuns(other suite) = %d runs", len(runs))
+	}
+	if _, err := LoadRuns(runsDir, "", "missing"); err == nil {
+		t.Error("LoadRuns accepted a missing run ID")
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	runs := []*Run{{
+		ID:    "r1",
+		Suite: "s",
+		Results: []Result{
+			{Task: "a", TaskType: "bug", Candidate: "codex", Agent: "cod", Passed: true, DurationMs: 60000, TimeoutMs: 600000, CostUSD: 0.5},
+			{Task: "a", TaskType: "bug", Candidate: "claude", Agent: "cc", Passed: false, DurationMs: 600000, TimeoutMs: 600000, CostUSD: 1},
+			{Task: "b", TaskType: "refactor", Candidate: "codex", Agent: "cod", Passed: true, DurationMs: 120000, TimeoutMs: 600000},
+			{Task: "b", TaskType: "refactor", Candidate: "claude", Agent: "cc", Passed: true, DurationMs: 60000, TimeoutMs: 600000},
+			{Task: "b", TaskType: "refactor", Candidate: "stub", Agent: AgentStub, Passed: true},
+		},
+	}}
+
+	rep := BuildReport(runs)
+	if len(rep.Candidates) != 3 || len(rep.Tasks) != 2 {
+		t.Fatalf("report = %+v", rep)
+	}
+	// codex and stub pass everything, codex with the better score; claude is last.
+	if rep.Candidates[2].Candidate != "claude" || rep.Candidates[2].PassRate != 0.5 {
+		t.Errorf("last candidate = %+v, want claude at 50%%", rep.Candidates[2])
+	}
+	codex := rep.Candidates[0]
+	if codex.Candidate != "codex" || codex.AvgDurationMs != 90000 || codex.TotalCostUSD != 0.5 {
+		t.Errorf("codex summary = %+v", codex)
+	}
+	if cell := rep.Tasks[0].Candidates["claude"]; cell.Attempts != 1 || cell.Passed != 0 {
+		t.Errorf("task a / claude = %+v", cell)
+	}
+	if _, ok := rep.Tasks[0].Candidates["stub"]; ok {
+		t.Error("stub has a cell for a task it did not attempt")
+	}
+
+}
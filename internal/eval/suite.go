@@ -0,0 +1,269 @@
+// Package eval runs offline evaluation suites that compare agents, models
+// and personas on the same tasks.
+//
+// A suite is a TOML file listing candidates (an agent type with an optional
+// model and persona) and tasks (a repo snapshot, a prompt and a verification
+// script). Each candidate attempts each task in its own throwaway worktree;
+// the verification script's exit status decides pass or fail. Results are
+// recorded as runs and as effectiveness scores, so live assignment can learn
+// from them.
+package eval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/Dicklesworthstone/ntm/internal/assign"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+const (
+	// AgentStub names a candidate that runs its command headless instead of
+	// launching an interactive agent, for offline runs and tests.
+	AgentStub = "stub"
+
+	// DefaultTaskTimeout bounds one agent attempt at a task when neither the
+	// task nor the suite sets a timeout.
+	DefaultTaskTimeout = 20 * time.Minute
+
+	// SuitesDir is where named suites live, relative to the project.
+	SuitesDir = ".ntm/eval"
+)
+
+var idRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// Suite is a set of tasks and the candidates to compare on them.
+type Suite struct {
+	Name        string      `toml:"name"`
+	Description string      `toml:"description,omitempty"`
+	Timeout     string      `toml:"timeout,omitempty"` // Default per-task timeout
+	Candidates  []Candidate `toml:"candidates"`
+	Tasks       []Task      `toml:"tasks"`
+
+	// Path is the file the suite was loaded from; relative task repos are
+	// resolved against its directory.
+	Path string `toml:"-"`
+}
+
+// Candidate is one agent configuration under evaluation.
+type Candidate struct {
+	Name    string `toml:"name"`
+	Agent   string `toml:"agent"`             // cc, cod, gmi or stub
+	Model   string `toml:"model,omitempty"`   // Model alias or full name
+	Persona string `toml:"persona,omitempty"` // Persona whose system prompt is injected
+
+	// Command replaces the configured agent command. Stub candidates require
+	// it: it runs in the workspace with the prompt on stdin.
+	Command string `toml:"command,omitempty"`
+}
+
+// AgentType returns c's agent normalised to cc, cod, gmi or stub.
+func (c Candidate) AgentType() tmux.AgentType {
+	return assign.ParseAgentType(c.Agent)
+}
+
+// Task is one unit of work every candidate attempts.
+type Task struct {
+	ID         string `toml:"id"`
+	Type       string `toml:"type,omitempty"` // Task type for scoring, e.g. refactor or bug
+	Repo       string `toml:"repo"`           // Git repo or plain directory to start from
+	Ref        string `toml:"ref,omitempty"`  // Git ref to check out (default HEAD)
+	Prompt     string `toml:"prompt,omitempty"`
+	PromptFile string `toml:"prompt_file,omitempty"`
+	Verify     string `toml:"verify"` // Shell script; exit 0 means the task passed
+	Timeout    string `toml:"timeout,omitempty"`
+}
+
+// LoadSuite reads and validates a suite file.
+func LoadSuite(path string) (*Suite, error) {
+	var s Suite
+	if _, err := toml.DecodeFile(path, &s); err != nil {
+		return nil, fmt.Errorf("parsing suite %s: %w", path, err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	s.Path = abs
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("suite %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// ResolveSuitePath maps a suite argument to a file: an existing path is used
+// as is, otherwise name is looked up as SuitesDir/<name>.toml under dir.
+func ResolveSuitePath(dir, name string) (string, error) {
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+	path := filepath.Join(dir, SuitesDir, name+".toml")
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("suite %q not found (looked for %s)", name, path)
+	}
+	return path, nil
+}
+
+// Validate checks that the suite can be run.
+func (s *Suite) Validate() error {
+	if !idRegex.MatchString(s.Name) {
+		return fmt.Errorf("name %q must contain only letters, digits, '.', '_' or '-'", s.Name)
+	}
+	if _, err := parseTimeout(s.Timeout, DefaultTaskTimeout); err != nil {
+		return fmt.Errorf("timeout: %w", err)
+	}
+	if len(s.Candidates) == 0 {
+		return fmt.Errorf("no candidates")
+	}
+	if len(s.Tasks) == 0 {
+		return fmt.Errorf("no tasks")
+	}
+
+	seen := make(map[string]bool)
+	for i, c := range s.Candidates {
+		if !idRegex.MatchString(c.Name) {
+			return fmt.Errorf("candidates[%d]: invalid name %q", i, c.Name)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("candidates[%d]: duplicate name %q", i, c.Name)
+		}
+		seen[c.Name] = true
+		switch strings.ToLower(c.Agent) {
+		case AgentStub:
+			if c.Command == "" {
+				return fmt.Errorf("candidate %s: stub agents need a command", c.Name)
+			}
+		case "cc", "claude", "cod", "codex", "gmi", "gemini":
+		default:
+			return fmt.Errorf("candidate %s: unknown agent %q (want cc, cod, gmi or stub)", c.Name, c.Agent)
+		}
+	}
+
+	seen = make(map[string]bool)
+	for i, t := range s.Tasks {
+		if !idRegex.MatchString(t.ID) {
+			return fmt.Errorf("tasks[%d]: invalid id %q", i, t.ID)
+		}
+		if seen[t.ID] {
+			return fmt.Errorf("tasks[%d]: duplicate id %q", i, t.ID)
+		}
+		seen[t.ID] = true
+		if t.Repo == "" {
+			return fmt.Errorf("task %s: repo is required", t.ID)
+		}
+		if (t.Prompt == "") == (t.PromptFile == "") {
+			return fmt.Errorf("task %s: set exactly one of prompt and prompt_file", t.ID)
+		}
+		if strings.TrimSpace(t.Verify) == "" {
+			return fmt.Errorf("task %s: verify is required", t.ID)
+		}
+		if _, err := parseTimeout(t.Timeout, 0); err != nil {
+			return fmt.Errorf("task %s: timeout: %w", t.ID, err)
+		}
+	}
+	return nil
+}
+
+// Candidate returns the candidate named name.
+func (s *Suite) Candidate(name string) (Candidate, bool) {
+	for _, c := range s.Candidates {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Candidate{}, false
+}
+
+// Filter narrows the suite to the named candidates and tasks; empty lists
+// keep everything.
+func (s *Suite) Filter(candidates, tasks []string) error {
+	if len(candidates) > 0 {
+		var kept []Candidate
+		for _, name := range candidates {
+			c, ok := s.Candidate(name)
+			if !ok {
+				return fmt.Errorf("unknown candidate %q", name)
+			}
+			kept = append(kept, c)
+		}
+		s.Candidates = kept
+	}
+	if len(tasks) > 0 {
+		var kept []Task
+		for _, id := range tasks {
+			found := false
+			for _, t := range s.Tasks {
+				if t.ID == id {
+					kept = append(kept, t)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("unknown task %q", id)
+			}
+		}
+		s.Tasks = kept
+	}
+	return nil
+}
+
+// resolve returns p relative to the suite file's directory.
+func (s *Suite) resolve(p string) string {
+	if p == "" || filepath.IsAbs(p) || s.Path == "" {
+		return p
+	}
+	return filepath.Join(filepath.Dir(s.Path), p)
+}
+
+// RepoPath returns the absolute location of t's repo snapshot.
+func (s *Suite) RepoPath(t Task) string {
+	return s.resolve(t.Repo)
+}
+
+// PromptText returns t's prompt, reading prompt_file if set.
+func (s *Suite) PromptText(t Task) (string, error) {
+	if t.PromptFile == "" {
+		return t.Prompt, nil
+	}
+	data, err := os.ReadFile(s.resolve(t.PromptFile))
+	if err != nil {
+		return "", fmt.Errorf("reading prompt for task %s: %w", t.ID, err)
+	}
+	return string(data), nil
+}
+
+// TaskTimeout returns how long an agent may spend on t.
+func (s *Suite) TaskTimeout(t Task) time.Duration {
+	def, _ := parseTimeout(s.Timeout, DefaultTaskTimeout)
+	d, _ := parseTimeout(t.Timeout, def)
+	return d
+}
+
+// TaskType returns t's task type in the assignment vocabulary.
+func (t Task) TaskType() assign.TaskType {
+	return assign.ParseTaskType(t.Type)
+}
+
+func parseTimeout(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return d, nil
+}
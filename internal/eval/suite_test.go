@@ -0,0 +1,146 @@
+package eval
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeSuite(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "suite.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSuite(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "prompt.md"), []byte("Fix the bug."), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := writeSuite(t, dir, `
+timeout = "10m"
+
+[[candidates]]
+name = "claude"
+agent = "claude"
+model = "opus"
+
+[[candidates]]
+name = "baseline"
+agent = "stub"
+command = "true"
+
+[[tasks]]
+id = "fix-bug"
+type = "bug"
+repo = "fixtures/repo"
+prompt_file = "prompt.md"
+verify = "test -f fixed"
+timeout = "2m"
+
+[[tasks]]
+id = "docs"
+repo = "/abs/repo"
+prompt = "Document it."
+verify = "true"
+`)
+
+	s, err := LoadSuite(path)
+	if err != nil {
+		t.Fatalf("LoadSuite: %v", err)
+	}
+	if s.Name != "suite" {
+		t.Errorf("Name = %q, want name derived from the file", s.Name)
+	}
+	if got := s.Candidates[0].AgentType(); got != "cc" {
+		t.Errorf("AgentType = %q, want cc", got)
+	}
+	if got := s.RepoPath(s.Tasks[0]); got != filepath.Join(dir, "fixtures/repo") {
+		t.Errorf("RepoPath = %q, want it relative to the suite file", got)
+	}
+	if got := s.RepoPath(s.Tasks[1]); got != "/abs/repo" {
+		t.Errorf("RepoPath = %q, want absolute path kept", got)
+	}
+	if prompt, err := s.PromptText(s.Tasks[0]); err != nil || prompt != "Fix the bug." {
+		t.Errorf("PromptText = %q, %v", prompt, err)
+	}
+	if got := s.TaskTimeout(s.Tasks[0]); got != 2*time.Minute {
+		t.Errorf("task timeout = %s, want 2m", got)
+	}
+	if got := s.TaskTimeout(s.Tasks[1]); got != 10*time.Minute {
+		t.Errorf("suite timeout = %s, want 10m", got)
+	}
+	if got := s.Tasks[1].TaskType(); got != "task" {
+		t.Errorf("default TaskType = %q, want task", got)
+	}
+
+	if err := s.Filter([]string{"baseline"}, []string{"docs"}); err != nil {
+		t.Fatalf("Filter: %v", err)
+	}
+	if len(s.Candidates) != 1 || len(s.Tasks) != 1 || s.Tasks[0].ID != "docs" {
+		t.Errorf("Filter kept %d candidates, %d tasks", len(s.Candidates), len(s.Tasks))
+	}
+	if err := s.Filter([]string{"nope"}, nil); err == nil {
+		t.Error("Filter accepted an unknown candidate")
+	}
+}
+
+func TestSuiteValidate(t *testing.T) {
+	base := `
+[[candidates]]
+name = "a"
+agent = "cc"
+`
+	task := `
+[[tasks]]
+id = "t"
+repo = "r"
+prompt = "p"
+verify = "true"
+`
+	tests := []struct {
+		name, content, want string
+	}{
+		{"no tasks", base, "no tasks"},
+		{"unknown agent", "[[candidates]]\nname = \"a\"\nagent = \"robot\"\n" + task, "unknown agent"},
+		{"stub without command", "[[candidates]]\nname = \"a\"\nagent = \"stub\"\n" + task, "need a command"},
+		{"duplicate candidate", base + base + task, "duplicate name"},
+		{"missing verify", base + "[[tasks]]\nid = \"t\"\nrepo = \"r\"\nprompt = \"p\"\n", "verify is required"},
+		{"both prompts", base + "[[tasks]]\nid = \"t\"\nrepo = \"r\"\nprompt = \"p\"\nprompt_file = \"f\"\nverify = \"true\"\n", "exactly one of prompt"},
+		{"bad timeout", "timeout = \"soon\"\n" + base + task, "timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSuite(writeSuite(t, t.TempDir(), tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadSuite error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveSuitePath(t *testing.T) {
+	dir := t.TempDir()
+	named := filepath.Join(dir, SuitesDir, "refactors.toml")
+	if err := os.MkdirAll(filepath.Dir(named), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(named, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := ResolveSuitePath(dir, "refactors"); err != nil || got != named {
+		t.Errorf("ResolveSuitePath(name) = %q, %v", got, err)
+	}
+	if got, err := ResolveSuitePath(dir, named); err != nil || got != named {
+		t.Errorf("ResolveSuitePath(path) = %q, %v", got, err)
+	}
+	if _, err := ResolveSuitePath(dir, "missing"); err == nil {
+		t.Error("ResolveSuitePath found a missing suite")
+	}
+}
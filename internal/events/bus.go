@@ -3,7 +3,9 @@ package events
 import (
 	"container/ring"
 	"encoding/json"
+	"errors"
 	"io"
+	"log/slog"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -19,6 +21,10 @@ type BusEvent interface {
 // EventHandler is a callback function for event subscriptions
 type EventHandler func(BusEvent)
 
+// OffsetHandler is a callback for durable subscriptions; offset is the
+// event's position in the bus's event log.
+type OffsetHandler func(offset uint64, event BusEvent)
+
 // UnsubscribeFunc is returned from Subscribe and can be called to unsubscribe
 type UnsubscribeFunc func()
 
@@ -40,6 +46,7 @@ type EventBus struct {
 	historySize int
 	historyMu   sync.RWMutex
 	handlerSem  chan struct{} // semaphore to limit concurrent handlers
+	log         atomic.Pointer[EventLog]
 }
 
 // NewEventBus creates a new event bus with the specified history size
@@ -90,8 +97,89 @@ func (b *EventBus) SubscribeAll(handler EventHandler) UnsubscribeFunc {
 	return b.Subscribe("*", handler)
 }
 
+// SetLog attaches a durable event log. Published events are appended to it
+// before dispatch, and SubscribeFrom/SubscribeGroup replay from it.
+// Pass nil to detach.
+func (b *EventBus) SetLog(l *EventLog) {
+	b.log.Store(l)
+}
+
+// Log returns the attached durable event log, or nil.
+func (b *EventBus) Log() *EventLog {
+	return b.log.Load()
+}
+
+// SubscribeFrom delivers every event with an offset >= offset, replaying
+// the durable log and then following new events, in order, from a single
+// goroutine. Events from privacy-mode sessions are not persisted and are
+// only seen by regular subscribers.
+func (b *EventBus) SubscribeFrom(offset uint64, handler OffsetHandler) (UnsubscribeFunc, error) {
+	l := b.Log()
+	if l == nil {
+		return nil, ErrNoEventLog
+	}
+
+	stop := make(chan struct{})
+	go l.Follow(offset, stop, handler)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stop) })
+	}, nil
+}
+
+// SubscribeGroup is SubscribeFrom for a named consumer group: delivery
+// resumes after the group's last acknowledged event, and each event is
+// acknowledged once handler returns. A new group starts with the next
+// published event.
+func (b *EventBus) SubscribeGroup(group string, handler OffsetHandler) (UnsubscribeFunc, error) {
+	l := b.Log()
+	if l == nil {
+		return nil, ErrNoEventLog
+	}
+
+	start, ok, err := l.ConsumerOffset(group)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if start, err = l.NextOffset(); err != nil {
+			return nil, err
+		}
+		l.Commit(group, start)
+	}
+
+	unsub, err := b.SubscribeFrom(start, func(offset uint64, e BusEvent) {
+		handler(offset, e)
+		l.Commit(group, offset+1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		unsub()
+		if err := l.Flush(); err != nil {
+			slog.Default().Debug("flushing consumer offsets failed", "group", group, "error", err)
+		}
+	}, nil
+}
+
+// persist appends event to the durable log, if one is attached.
+func (b *EventBus) persist(event BusEvent) {
+	l := b.Log()
+	if l == nil {
+		return
+	}
+	if _, err := l.Append(event); err != nil && !errors.Is(err, ErrEventNotPersisted) {
+		slog.Default().Debug("event log append failed", "type", event.EventType(), "error", err)
+	}
+}
+
 // Publish sends an event to all matching subscribers
 func (b *EventBus) Publish(event BusEvent) {
+	checkEventSchema(event)
+	b.persist(event)
+
 	// Add to history first
 	b.historyMu.Lock()
 	b.history.Value = event
@@ -128,6 +216,9 @@ func (b *EventBus) Publish(event BusEvent) {
 
 // PublishSync sends an event and waits for all handlers to complete
 func (b *EventBus) PublishSync(event BusEvent) {
+	checkEventSchema(event)
+	b.persist(event)
+
 	// Add to history first
 	b.historyMu.Lock()
 	b.history.Value = event
@@ -576,6 +667,40 @@ func NewAlertEvent(session, alertID, alertType, severity, message string) AlertE
 	}
 }
 
+// ----------------------------------------------------------------
+// Metrics Events
+// ----------------------------------------------------------------
+
+// AgentMetrics is one agent's readings in a MetricsSampleEvent
+type AgentMetrics struct {
+	Pane        string  `json:"pane"`
+	Agent       string  `json:"agent,omitempty"`
+	ContextPct  float64 `json:"context_pct"`
+	IdleMinutes float64 `json:"idle_minutes"`
+	CostUSD     float64 `json:"cost_usd,omitempty"` // cumulative
+}
+
+// MetricsSampleEvent is emitted periodically by the session monitor with the
+// metrics alert rules evaluate, so rules can be replayed over recorded history
+type MetricsSampleEvent struct {
+	BaseEvent
+	Agents        []AgentMetrics `json:"agents"`
+	FileConflicts *int           `json:"file_conflicts,omitempty"` // nil when unknown
+}
+
+// NewMetricsSampleEvent creates a new metrics sample event
+func NewMetricsSampleEvent(session string, agents []AgentMetrics, fileConflicts *int) MetricsSampleEvent {
+	return MetricsSampleEvent{
+		BaseEvent: BaseEvent{
+			Type:      "metrics_sample",
+			Timestamp: time.Now().UTC(),
+			Session:   session,
+		},
+		Agents:        agents,
+		FileConflicts: fileConflicts,
+	}
+}
+
 // ----------------------------------------------------------------
 // Global Functions (using DefaultBus)
 // ----------------------------------------------------------------
@@ -0,0 +1,799 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/privacy"
+)
+
+const (
+	// DefaultSegmentBytes is the size at which the active log segment is
+	// closed and a new one started.
+	DefaultSegmentBytes = 8 << 20
+
+	// DefaultMaxSegments is how many log segments are retained.
+	DefaultMaxSegments = 16
+
+	// eventLogPollInterval is how often followers check for events appended
+	// by other processes.
+	eventLogPollInterval = 250 * time.Millisecond
+
+	// consumerFlushInterval bounds how often consumer offsets hit disk.
+	consumerFlushInterval = time.Second
+
+	// recentCacheSize is how many typed events appended by this process are
+	// kept so local followers receive the original values, not replays.
+	recentCacheSize = 256
+
+	segmentSuffix     = ".log"
+	consumersFileName = "consumers.json"
+	lockFileName      = "lock"
+)
+
+var (
+	// ErrEventNotPersisted is returned by Append when the event's session is
+	// in privacy mode. Such events are only delivered to live subscribers.
+	ErrEventNotPersisted = errors.New("event not persisted: session is in privacy mode")
+
+	// ErrNoEventLog is returned when a replay is requested from a bus that
+	// has no durable log attached.
+	ErrNoEventLog = errors.New("event bus has no durable log")
+
+	// ErrEventLogClosed is returned by operations on a closed log.
+	ErrEventLogClosed = errors.New("event log is closed")
+)
+
+// EventLogOptions configures a durable event log.
+type EventLogOptions struct {
+	// SegmentBytes is the size at which a segment is rolled (default 8 MiB).
+	SegmentBytes int64
+	// MaxSegments is how many segments are kept; the oldest are deleted
+	// first (default 16).
+	MaxSegments int
+}
+
+// EventLog is an append-only, segmented on-disk log of bus events.
+//
+// Every appended event gets a monotonically increasing offset. Segments are
+// JSONL files named after the offset of their first record; each line is
+// "<offset> <record>", where the record is redacted and, when encryption is
+// configured, encrypted. Several processes may append to the same directory:
+// writes are serialized with a lock file and each writer re-reads the tail
+// before appending.
+//
+// Named consumer groups store the next offset they want to read, so a
+// restarted consumer resumes after its last acknowledged event.
+type EventLog struct {
+	dir  string
+	opts EventLogOptions
+
+	// mu serializes appends within the process and guards the tail state.
+	mu        sync.Mutex
+	tailKnown bool
+	tailBase  uint64
+	tailSize  int64
+	next      uint64
+
+	cacheMu    sync.RWMutex
+	cache      map[uint64]BusEvent
+	cacheOrder []uint64
+
+	notifyMu sync.Mutex
+	notify   chan struct{}
+
+	consumerMu    sync.Mutex
+	pending       map[string]uint64
+	lastFlush     time.Time
+	flushTimerSet bool
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// logRecord is the JSON form of one log line (after the offset prefix).
+type logRecord struct {
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`
+	Session   string          `json:"session,omitempty"`
+	Event     json.RawMessage `json:"event"`
+}
+
+// RecordedEvent is an event replayed from the durable log. It keeps the
+// envelope fields and the event's original JSON, which MarshalJSON returns.
+type RecordedEvent struct {
+	Offset    uint64
+	Type      string
+	Timestamp time.Time
+	Session   string
+	Payload   json.RawMessage
+}
+
+// EventType returns the event type
+func (e RecordedEvent) EventType() string { return e.Type }
+
+// EventTimestamp returns the event timestamp
+func (e RecordedEvent) EventTimestamp() time.Time { return e.Timestamp }
+
+// EventSession returns the session name
+func (e RecordedEvent) EventSession() string { return e.Session }
+
+// MarshalJSON returns the recorded payload so replayed events encode like
+// the originals.
+func (e RecordedEvent) MarshalJSON() ([]byte, error) {
+	if len(e.Payload) > 0 {
+		return e.Payload, nil
+	}
+	return json.Marshal(BaseEvent{Type: e.Type, Timestamp: e.Timestamp, Session: e.Session})
+}
+
+// DefaultEventLogDir returns the default durable event log directory,
+// alongside the other session data ($XDG_DATA_HOME/ntm/events, falling back
+// to ~/.local/share/ntm/events).
+func DefaultEventLogDir() (string, error) {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataDir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataDir, "ntm", "events"), nil
+}
+
+// OpenEventLog opens (creating if needed) the durable event log in dir.
+// A torn final line left by a crashed writer is truncated.
+func OpenEventLog(dir string, opts EventLogOptions) (*EventLog, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, errors.New("event log directory is required")
+	}
+	if opts.SegmentBytes <= 0 {
+		opts.SegmentBytes = DefaultSegmentBytes
+	}
+	if opts.MaxSegments <= 0 {
+		opts.MaxSegments = DefaultMaxSegments
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating event log directory: %w", err)
+	}
+
+	l := &EventLog{
+		dir:     dir,
+		opts:    opts,
+		cache:   make(map[uint64]BusEvent),
+		notify:  make(chan struct{}),
+		pending: make(map[string]uint64),
+		done:    make(chan struct{}),
+	}
+
+	unlock, err := l.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := l.syncTail(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Dir returns the log directory.
+func (l *EventLog) Dir() string { return l.dir }
+
+// Append writes event to the log and returns its offset. Events whose
+// session is in privacy mode are not written and return ErrEventNotPersisted.
+func (l *EventLog) Append(event BusEvent) (uint64, error) {
+	if l.isClosed() {
+		return 0, ErrEventLogClosed
+	}
+	if session := event.EventSession(); session != "" {
+		if err := privacy.GetDefaultManager().CanPersist(session, privacy.OpEventLog); err != nil {
+			return 0, ErrEventNotPersisted
+		}
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("marshaling event: %w", err)
+	}
+	// Redact the serialized event; if a replacement broke the JSON, keep
+	// the redacted text as a string rather than writing the raw secret.
+	redacted := []byte(redactString(string(payload)))
+	if !json.Valid(redacted) {
+		redacted, _ = json.Marshal(string(redacted))
+	}
+	data, err := json.Marshal(logRecord{
+		Type:      event.EventType(),
+		Timestamp: event.EventTimestamp(),
+		Session:   event.EventSession(),
+		Event:     redacted,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("marshaling record: %w", err)
+	}
+	data, err = encryptJSONLine(data)
+	if err != nil {
+		return 0, fmt.Errorf("encrypting event: %w", err)
+	}
+
+	unlock, err := l.lock()
+	if err != nil {
+		return 0, err
+	}
+	offset, err := l.appendLocked(event, data)
+	unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	l.wake()
+	return offset, nil
+}
+
+// appendLocked writes one record line. Callers hold the log lock.
+func (l *EventLog) appendLocked(event BusEvent, data []byte) (uint64, error) {
+	if err := l.syncTail(); err != nil {
+		return 0, err
+	}
+	if !l.tailKnown || l.tailSize >= l.opts.SegmentBytes {
+		l.tailBase = l.next
+		l.tailSize = 0
+		l.tailKnown = true
+		if err := l.applyRetention(); err != nil {
+			slog.Default().Debug("event log retention failed", "dir", l.dir, "error", err)
+		}
+	}
+
+	offset := l.next
+	line := make([]byte, 0, len(data)+24)
+	line = strconv.AppendUint(line, offset, 10)
+	line = append(line, ' ')
+	line = append(line, data...)
+	line = append(line, '\n')
+
+	// Cache before writing so local followers never see the record without
+	// its typed event.
+	l.remember(offset, event)
+
+	f, err := os.OpenFile(l.segmentPath(l.tailBase), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		l.forget(offset)
+		return 0, fmt.Errorf("opening event log segment: %w", err)
+	}
+	n, werr := f.Write(line)
+	cerr := f.Close()
+	if werr != nil {
+		l.forget(offset)
+		// Force a rescan so a partial write is truncated before the next append.
+		l.tailKnown = false
+		return 0, fmt.Errorf("writing event: %w", werr)
+	}
+	if cerr != nil {
+		return 0, fmt.Errorf("closing event log segment: %w", cerr)
+	}
+
+	l.tailSize += int64(n)
+	l.next = offset + 1
+	return offset, nil
+}
+
+// syncTail brings the cached tail state up to date with the files on disk,
+// picking up records appended by other processes and truncating a torn
+// final line. Callers hold the log lock.
+func (l *EventLog) syncTail() error {
+	bases, err := l.segments()
+	if err != nil {
+		return err
+	}
+	if len(bases) == 0 {
+		l.tailKnown = false
+		return nil
+	}
+
+	base := bases[len(bases)-1]
+	path := l.segmentPath(base)
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat event log segment: %w", err)
+	}
+	size := info.Size()
+	if l.tailKnown && l.tailBase == base && l.tailSize == size {
+		return nil
+	}
+
+	start := int64(0)
+	next := base
+	if l.tailKnown && l.tailBase == base && size > l.tailSize {
+		start = l.tailSize
+		next = l.next
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("opening event log segment: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return fmt.Errorf("seeking event log segment: %w", err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return fmt.Errorf("reading event log segment: %w", err)
+	}
+
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	if complete < len(data) {
+		// A writer died mid-line; drop the partial record.
+		if err := f.Truncate(start + int64(complete)); err != nil {
+			return fmt.Errorf("truncating torn event log record: %w", err)
+		}
+		size = start + int64(complete)
+	}
+	for _, line := range bytes.Split(data[:complete], []byte{'\n'}) {
+		if offset, _, ok := splitLogLine(line); ok && offset >= next {
+			next = offset + 1
+		}
+	}
+
+	l.tailKnown = true
+	l.tailBase = base
+	l.tailSize = size
+	l.next = next
+	return nil
+}
+
+// applyRetention deletes the oldest segments so that, counting the segment
+// about to be created, at most MaxSegments remain.
+func (l *EventLog) applyRetention() error {
+	bases, err := l.segments()
+	if err != nil {
+		return err
+	}
+	excess := len(bases) + 1 - l.opts.MaxSegments
+	for i := 0; i < excess && i < len(bases); i++ {
+		if err := os.Remove(l.segmentPath(bases[i])); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// NextOffset returns the offset the next appended event will receive.
+func (l *EventLog) NextOffset() (uint64, error) {
+	unlock, err := l.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	if err := l.syncTail(); err != nil {
+		return 0, err
+	}
+	return l.next, nil
+}
+
+// FirstOffset returns the oldest offset still retained on disk.
+func (l *EventLog) FirstOffset() (uint64, error) {
+	bases, err := l.segments()
+	if err != nil || len(bases) == 0 {
+		return 0, err
+	}
+	return bases[0], nil
+}
+
+// segments returns the base offsets of all segment files in ascending order.
+func (l *EventLog) segments() ([]uint64, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, fmt.Errorf("listing event log segments: %w", err)
+	}
+	var bases []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		base, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+	return bases, nil
+}
+
+func (l *EventLog) segmentPath(base uint64) string {
+	return filepath.Join(l.dir, fmt.Sprintf("%020d%s", base, segmentSuffix))
+}
+
+// splitLogLine splits "<offset> <record>" into its parts.
+func splitLogLine(line []byte) (uint64, []byte, bool) {
+	sp := bytes.IndexByte(line, ' ')
+	if sp <= 0 {
+		return 0, nil, false
+	}
+	offset, err := strconv.ParseUint(string(line[:sp]), 10, 64)
+	if err != nil {
+		return 0, nil, false
+	}
+	return offset, line[sp+1:], true
+}
+
+// decodeRecord turns a stored record into a RecordedEvent.
+func decodeRecord(offset uint64, data []byte) (RecordedEvent, error) {
+	plain, err := decryptJSONLine(data)
+	if err != nil {
+		return RecordedEvent{}, fmt.Errorf("decrypting event: %w", err)
+	}
+	var rec logRecord
+	if err := json.Unmarshal(plain, &rec); err != nil {
+		return RecordedEvent{}, fmt.Errorf("decoding event: %w", err)
+	}
+	return RecordedEvent{
+		Offset:    offset,
+		Type:      rec.Type,
+		Timestamp: rec.Timestamp,
+		Session:   rec.Session,
+		Payload:   rec.Event,
+	}, nil
+}
+
+// remember caches a typed event appended by this process.
+func (l *EventLog) remember(offset uint64, event BusEvent) {
+	l.cacheMu.Lock()
+	defer l.cacheMu.Unlock()
+	l.cache[offset] = event
+	l.cacheOrder = append(l.cacheOrder, offset)
+	if len(l.cacheOrder) > recentCacheSize {
+		delete(l.cache, l.cacheOrder[0])
+		l.cacheOrder = l.cacheOrder[1:]
+	}
+}
+
+// forget drops a cached event whose write failed.
+func (l *EventLog) forget(offset uint64) {
+	l.cacheMu.Lock()
+	defer l.cacheMu.Unlock()
+	delete(l.cache, offset)
+}
+
+func (l *EventLog) cached(offset uint64) (BusEvent, bool) {
+	l.cacheMu.RLock()
+	defer l.cacheMu.RUnlock()
+	e, ok := l.cache[offset]
+	return e, ok
+}
+
+// wake notifies followers that a record was appended.
+func (l *EventLog) wake() {
+	l.notifyMu.Lock()
+	close(l.notify)
+	l.notify = make(chan struct{})
+	l.notifyMu.Unlock()
+}
+
+func (l *EventLog) waitChan() <-chan struct{} {
+	l.notifyMu.Lock()
+	defer l.notifyMu.Unlock()
+	return l.notify
+}
+
+func (l *EventLog) isClosed() bool {
+	select {
+	case <-l.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Follow delivers every event with an offset >= from to handler, first
+// replaying what is on disk and then tailing new appends (including those
+// from other processes), until stop is closed or the log is closed.
+// Events are delivered in order from a single goroutine. If from is older
+// than the retained segments, delivery starts at the oldest retained event.
+func (l *EventLog) Follow(from uint64, stop <-chan struct{}, handler func(offset uint64, event BusEvent)) {
+	var (
+		base    uint64
+		pos     int64
+		located bool
+	)
+	for {
+		wait := l.waitChan()
+
+		if !located {
+			bases, err := l.segments()
+			if err == nil && len(bases) > 0 {
+				base, pos, located = bases[0], 0, true
+				for _, b := range bases {
+					if b <= from {
+						base = b
+					}
+				}
+			}
+		}
+
+		progressed := false
+		if located {
+			data, err := readFrom(l.segmentPath(base), pos)
+			switch {
+			case os.IsNotExist(err):
+				// Retention removed the segment under us; relocate.
+				located = false
+				continue
+			case err != nil:
+				slog.Default().Debug("event log read failed", "dir", l.dir, "error", err)
+			default:
+				complete := bytes.LastIndexByte(data, '\n') + 1
+				for _, line := range bytes.Split(data[:complete], []byte{'\n'}) {
+					offset, rec, ok := splitLogLine(line)
+					if !ok || offset < from {
+						continue
+					}
+					event, ok := l.cached(offset)
+					if !ok {
+						decoded, err := decodeRecord(offset, rec)
+						if err != nil {
+							slog.Default().Debug("skipping unreadable event", "offset", offset, "error", err)
+							from = offset + 1
+							continue
+						}
+						event = decoded
+					}
+					from = offset + 1
+					if !deliver(stop, l.done, func() { handler(offset, event) }) {
+						return
+					}
+				}
+				pos += int64(complete)
+				progressed = complete > 0
+			}
+
+			if !progressed {
+				// At the end of this segment: move on once a newer one exists.
+				// Writers only roll after finishing the previous append, so
+				// nothing more will land in this segment.
+				if bases, err := l.segments(); err == nil {
+					for _, b := range bases {
+						if b > base {
+							// Re-read once: lines may have landed since the read above.
+							if rest, err := readFrom(l.segmentPath(base), pos); err != nil || bytes.IndexByte(rest, '\n') < 0 {
+								base, pos = b, 0
+							}
+							progressed = true
+							break
+						}
+					}
+				}
+			}
+		}
+		if progressed {
+			continue
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-l.done:
+			return
+		case <-wait:
+		case <-time.After(eventLogPollInterval):
+		}
+	}
+}
+
+// Scan calls fn for each event on disk with an offset >= from, in order,
+// and returns at the end of the log instead of waiting for new appends.
+// Unreadable records are skipped. Scanning stops early when fn returns false.
+func (l *EventLog) Scan(from uint64, fn func(RecordedEvent) bool) error {
+	bases, err := l.segments()
+	if err != nil {
+		return err
+	}
+	for i, base := range bases {
+		if i+1 < len(bases) && bases[i+1] <= from {
+			continue
+		}
+		data, err := readFrom(l.segmentPath(base), 0)
+		if os.IsNotExist(err) {
+			continue // removed by retention
+		} else if err != nil {
+			return fmt.Errorf("reading event log segment: %w", err)
+		}
+		complete := bytes.LastIndexByte(data, '\n') + 1
+		for _, line := range bytes.Split(data[:complete], []byte{'\n'}) {
+			offset, rec, ok := splitLogLine(line)
+			if !ok || offset < from {
+				continue
+			}
+			event, err := decodeRecord(offset, rec)
+			if err != nil {
+				slog.Default().Debug("skipping unreadable event", "offset", offset, "error", err)
+				continue
+			}
+			if !fn(event) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// deliver runs fn unless the follower was stopped, recovering from panics
+// in handlers like the bus does.
+func deliver(stop, done <-chan struct{}, fn func()) bool {
+	select {
+	case <-stop:
+		return false
+	case <-done:
+		return false
+	default:
+	}
+	defer func() { _ = recover() }()
+	fn()
+	return true
+}
+
+// readFrom returns the contents of path starting at pos.
+func readFrom(path string, pos int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(pos, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(f)
+}
+
+// ConsumerOffset returns the next offset consumer group should read and
+// whether the group has committed before.
+func (l *EventLog) ConsumerOffset(group string) (uint64, bool, error) {
+	unlock, err := l.lock()
+	if err != nil {
+		return 0, false, err
+	}
+	stored, err := l.readConsumers()
+	unlock()
+	if err != nil {
+		return 0, false, err
+	}
+
+	next, ok := stored[group]
+	l.consumerMu.Lock()
+	if p, pok := l.pending[group]; pok && (!ok || p > next) {
+		next, ok = p, true
+	}
+	l.consumerMu.Unlock()
+	return next, ok, nil
+}
+
+// Commit records that group has handled every event before next. Offsets
+// only move forward; they are written to disk at most once per second and
+// on Flush or Close.
+func (l *EventLog) Commit(group string, next uint64) {
+	l.consumerMu.Lock()
+	if cur, ok := l.pending[group]; !ok || next > cur {
+		l.pending[group] = next
+	}
+	flushNow := time.Since(l.lastFlush) >= consumerFlushInterval
+	if !flushNow && !l.flushTimerSet {
+		l.flushTimerSet = true
+		time.AfterFunc(consumerFlushInterval, func() {
+			l.consumerMu.Lock()
+			l.flushTimerSet = false
+			l.consumerMu.Unlock()
+			if err := l.Flush(); err != nil {
+				slog.Default().Debug("flushing consumer offsets failed", "dir", l.dir, "error", err)
+			}
+		})
+	}
+	l.consumerMu.Unlock()
+
+	if flushNow {
+		if err := l.Flush(); err != nil {
+			slog.Default().Debug("flushing consumer offsets failed", "dir", l.dir, "error", err)
+		}
+	}
+}
+
+// Flush writes pending consumer offsets to disk.
+func (l *EventLog) Flush() error {
+	l.consumerMu.Lock()
+	if len(l.pending) == 0 {
+		l.consumerMu.Unlock()
+		return nil
+	}
+	pending := make(map[string]uint64, len(l.pending))
+	for k, v := range l.pending {
+		pending[k] = v
+	}
+	l.lastFlush = time.Now()
+	l.consumerMu.Unlock()
+
+	unlock, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	stored, err := l.readConsumers()
+	if err != nil {
+		return err
+	}
+	for group, next := range pending {
+		if cur, ok := stored[group]; !ok || next > cur {
+			stored[group] = next
+		}
+	}
+	if err := l.writeConsumers(stored); err != nil {
+		return err
+	}
+
+	l.consumerMu.Lock()
+	for group, next := range pending {
+		if l.pending[group] == next {
+			delete(l.pending, group)
+		}
+	}
+	l.consumerMu.Unlock()
+	return nil
+}
+
+// readConsumers loads stored consumer offsets. Callers hold the log lock.
+func (l *EventLog) readConsumers() (map[string]uint64, error) {
+	stored := make(map[string]uint64)
+	data, err := os.ReadFile(filepath.Join(l.dir, consumersFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return stored, nil
+		}
+		return nil, fmt.Errorf("reading consumer offsets: %w", err)
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("parsing consumer offsets: %w", err)
+	}
+	return stored, nil
+}
+
+// writeConsumers atomically replaces the consumer offsets file. Callers
+// hold the log lock.
+func (l *EventLog) writeConsumers(stored map[string]uint64) error {
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(l.dir, consumersFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing consumer offsets: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing consumer offsets: %w", err)
+	}
+	return nil
+}
+
+// Close flushes consumer offsets and stops all followers.
+func (l *EventLog) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		err = l.Flush()
+		close(l.done)
+	})
+	return err
+}
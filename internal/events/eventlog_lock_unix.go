@@ -0,0 +1,34 @@
+//go:build unix
+
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lock acquires both the in-process mutex and an exclusive flock on the log
+// directory's lock file, so appends from several ntm processes serialize.
+// Returns an unlock function to release both.
+func (l *EventLog) lock() (func(), error) {
+	l.mu.Lock()
+
+	f, err := os.OpenFile(filepath.Join(l.dir, lockFileName), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		l.mu.Unlock()
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		l.mu.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+		l.mu.Unlock()
+	}, nil
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package events
+
+// lock acquires the in-process mutex only on Windows.
+// File locking is not supported on Windows in this implementation.
+// Returns an unlock function to release the lock.
+func (l *EventLog) lock() (func(), error) {
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+	}, nil
+}
@@ -0,0 +1,353 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func openTestLog(t *testing.T, dir string, opts EventLogOptions) *EventLog {
+	t.Helper()
+	l, err := OpenEventLog(dir, opts)
+	if err != nil {
+		t.Fatalf("OpenEventLog() error: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func logEvent(typ string) BaseEvent {
+	return BaseEvent{Type: typ, Timestamp: time.Now().UTC(), Session: "proj"}
+}
+
+// collector gathers events delivered to an OffsetHandler.
+type collector struct {
+	mu      sync.Mutex
+	offsets []uint64
+	events  []BusEvent
+}
+
+func (c *collector) handle(offset uint64, e BusEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offsets = append(c.offsets, offset)
+	c.events = append(c.events, e)
+}
+
+func (c *collector) waitFor(t *testing.T, n int) ([]uint64, []BusEvent) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		if len(c.offsets) >= n {
+			offsets := append([]uint64(nil), c.offsets...)
+			events := append([]BusEvent(nil), c.events...)
+			c.mu.Unlock()
+			return offsets, events
+		}
+		c.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d events", n)
+	return nil, nil
+}
+
+func TestEventLog_AppendAndReopen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	l := openTestLog(t, dir, EventLogOptions{})
+	for i := 0; i < 3; i++ {
+		offset, err := l.Append(logEvent("agent_idle"))
+		if err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+		if offset != uint64(i) {
+			t.Errorf("Append() offset = %d, want %d", offset, i)
+		}
+	}
+	l.Close()
+	if _, err := l.Append(logEvent("x")); err != ErrEventLogClosed {
+		t.Errorf("Append() after Close = %v, want ErrEventLogClosed", err)
+	}
+
+	reopened := openTestLog(t, dir, EventLogOptions{})
+	if next, err := reopened.NextOffset(); err != nil || next != 3 {
+		t.Errorf("NextOffset() after reopen = %d, %v; want 3", next, err)
+	}
+}
+
+func TestEventLog_SharedDirectory(t *testing.T) {
+	t.Parallel()
+
+	// Two handles on one directory behave like two ntm processes.
+	dir := t.TempDir()
+	a := openTestLog(t, dir, EventLogOptions{})
+	b := openTestLog(t, dir, EventLogOptions{})
+
+	var c collector
+	stop := make(chan struct{})
+	defer close(stop)
+	go a.Follow(0, stop, c.handle)
+
+	for i := 0; i < 4; i++ {
+		w := a
+		if i%2 == 1 {
+			w = b
+		}
+		if _, err := w.Append(logEvent("tick")); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	offsets, _ := c.waitFor(t, 4)
+	for i, off := range offsets {
+		if off != uint64(i) {
+			t.Fatalf("offsets = %v, want 0..3 with no gaps", offsets)
+		}
+	}
+}
+
+func TestEventLog_RotationAndRetention(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	l := openTestLog(t, dir, EventLogOptions{SegmentBytes: 1, MaxSegments: 3})
+	for i := 0; i < 5; i++ {
+		if _, err := l.Append(logEvent("tick")); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	bases, err := l.segments()
+	if err != nil {
+		t.Fatalf("segments() error: %v", err)
+	}
+	if len(bases) != 3 || bases[0] != 2 {
+		t.Errorf("segments = %v, want [2 3 4]", bases)
+	}
+	if first, _ := l.FirstOffset(); first != 2 {
+		t.Errorf("FirstOffset() = %d, want 2", first)
+	}
+
+	// Replaying from a trimmed offset starts at the oldest retained event.
+	var c collector
+	stop := make(chan struct{})
+	defer close(stop)
+	go l.Follow(0, stop, c.handle)
+	offsets, _ := c.waitFor(t, 3)
+	if offsets[0] != 2 || offsets[2] != 4 {
+		t.Errorf("replayed offsets = %v, want [2 3 4]", offsets)
+	}
+}
+
+func TestEventLog_TruncatesTornRecord(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	l := openTestLog(t, dir, EventLogOptions{})
+	if _, err := l.Append(logEvent("first")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	l.Close()
+
+	seg := l.segmentPath(0)
+	f, err := os.OpenFile(seg, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`1 {"type":"torn","timest`)
+	f.Close()
+
+	reopened := openTestLog(t, dir, EventLogOptions{})
+	offset, err := reopened.Append(logEvent("second"))
+	if err != nil || offset != 1 {
+		t.Fatalf("Append() after torn write = %d, %v; want 1", offset, err)
+	}
+	data, _ := os.ReadFile(seg)
+	if bytes.Contains(data, []byte("torn")) || bytes.Count(data, []byte("\n")) != 2 {
+		t.Errorf("segment after recovery:\n%s", data)
+	}
+}
+
+func TestEventLog_ReplayDecodesRecords(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writer := openTestLog(t, dir, EventLogOptions{})
+	ev := NewProfileAssignedEvent("proj", "cc_1", "reviewer", "")
+	if _, err := writer.Append(ev); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	// A fresh handle has no typed cache and must decode from disk.
+	reader := openTestLog(t, dir, EventLogOptions{})
+	var c collector
+	stop := make(chan struct{})
+	defer close(stop)
+	go reader.Follow(0, stop, c.handle)
+	_, got := c.waitFor(t, 1)
+
+	rec, ok := got[0].(RecordedEvent)
+	if !ok {
+		t.Fatalf("replayed event is %T, want RecordedEvent", got[0])
+	}
+	if rec.EventType() != "profile_assigned" || rec.EventSession() != "proj" || !rec.EventTimestamp().Equal(ev.Timestamp) {
+		t.Errorf("replayed envelope = %+v", rec)
+	}
+	var decoded ProfileAssignedEvent
+	data, _ := json.Marshal(rec)
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Profile != "reviewer" {
+		t.Errorf("replayed payload = %s (%v)", data, err)
+	}
+}
+
+func TestEventLog_Scan(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	l := openTestLog(t, dir, EventLogOptions{SegmentBytes: 200})
+	for _, typ := range []string{"a", "b", "c", "d", "e"} {
+		if _, err := l.Append(logEvent(typ)); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+	if bases, _ := l.segments(); len(bases) < 2 {
+		t.Fatalf("want several segments, got %v", bases)
+	}
+
+	var types []string
+	if err := l.Scan(1, func(e RecordedEvent) bool {
+		types = append(types, e.Type)
+		return e.Type != "d"
+	}); err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if got := strings.Join(types, ","); got != "b,c,d" {
+		t.Errorf("Scan() types = %s, want b,c,d", got)
+	}
+}
+
+func TestEventLog_ConsumerOffsets(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	l := openTestLog(t, dir, EventLogOptions{})
+	if _, ok, err := l.ConsumerOffset("webhook"); err != nil || ok {
+		t.Fatalf("ConsumerOffset(new) = %v, %v", ok, err)
+	}
+
+	l.Commit("webhook", 5)
+	l.Commit("webhook", 3) // offsets never move backwards
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	other := openTestLog(t, dir, EventLogOptions{})
+	if next, ok, err := other.ConsumerOffset("webhook"); err != nil || !ok || next != 5 {
+		t.Errorf("ConsumerOffset() = %d, %v, %v; want 5", next, ok, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, consumersFileName)); err != nil {
+		t.Errorf("consumer offsets not written: %v", err)
+	}
+}
+
+func TestEventLog_Encrypted(t *testing.T) {
+	key := evtTestKey(t)
+	SetEncryptionConfig(&EncryptionConfig{Enabled: true, EncryptKey: key, DecryptKeys: [][]byte{key}})
+	defer SetEncryptionConfig(nil)
+
+	dir := t.TempDir()
+	l := openTestLog(t, dir, EventLogOptions{})
+	if _, err := l.Append(NewProfileAssignedEvent("proj", "cc_1", "secret-profile", "")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	data, err := os.ReadFile(l.segmentPath(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("secret-profile")) {
+		t.Error("encrypted segment contains plaintext")
+	}
+
+	offset, rec, ok := splitLogLine(bytes.TrimSpace(data))
+	if !ok || offset != 0 {
+		t.Fatalf("splitLogLine() = %d, %v", offset, ok)
+	}
+	decoded, err := decodeRecord(offset, rec)
+	if err != nil || !bytes.Contains(decoded.Payload, []byte("secret-profile")) {
+		t.Errorf("decodeRecord() = %+v, %v", decoded, err)
+	}
+}
+
+func TestEventBus_SubscribeFrom(t *testing.T) {
+	t.Parallel()
+
+	bus := NewEventBus(10)
+	if _, err := bus.SubscribeFrom(0, func(uint64, BusEvent) {}); err != ErrNoEventLog {
+		t.Fatalf("SubscribeFrom() without log = %v, want ErrNoEventLog", err)
+	}
+	bus.SetLog(openTestLog(t, t.TempDir(), EventLogOptions{}))
+
+	bus.PublishSync(logEvent("before_1"))
+	bus.PublishSync(logEvent("before_2"))
+
+	var c collector
+	unsub, err := bus.SubscribeFrom(1, c.handle)
+	if err != nil {
+		t.Fatalf("SubscribeFrom() error: %v", err)
+	}
+	defer unsub()
+	bus.Publish(logEvent("after"))
+
+	offsets, got := c.waitFor(t, 2)
+	if offsets[0] != 1 || offsets[1] != 2 {
+		t.Errorf("offsets = %v, want [1 2]", offsets)
+	}
+	// Events published by this process keep their concrete type.
+	if _, ok := got[1].(BaseEvent); !ok || got[1].EventType() != "after" {
+		t.Errorf("live event = %T %v", got[1], got[1])
+	}
+}
+
+func TestEventBus_SubscribeGroupResumes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	bus := NewEventBus(10)
+	bus.SetLog(openTestLog(t, dir, EventLogOptions{}))
+	bus.PublishSync(logEvent("before_group"))
+
+	// A new group starts at the end of the log.
+	var first collector
+	unsub, err := bus.SubscribeGroup("bridge", first.handle)
+	if err != nil {
+		t.Fatalf("SubscribeGroup() error: %v", err)
+	}
+	bus.PublishSync(logEvent("seen"))
+	if offsets, _ := first.waitFor(t, 1); offsets[0] != 1 {
+		t.Errorf("first delivery offset = %d, want 1", offsets[0])
+	}
+	unsub()
+
+	// Events published while the consumer is down are replayed on restart.
+	bus.PublishSync(logEvent("missed"))
+	restarted := NewEventBus(10)
+	restarted.SetLog(openTestLog(t, dir, EventLogOptions{}))
+	var second collector
+	unsub, err = restarted.SubscribeGroup("bridge", second.handle)
+	if err != nil {
+		t.Fatalf("SubscribeGroup() after restart error: %v", err)
+	}
+	defer unsub()
+	offsets, got := second.waitFor(t, 1)
+	if offsets[0] != 2 || got[0].EventType() != "missed" {
+		t.Errorf("resumed at %d (%s), want 2 (missed)", offsets[0], got[0].EventType())
+	}
+}
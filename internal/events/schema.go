@@ -0,0 +1,437 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// JSONSchemaDialect is the JSON Schema draft used for event schemas.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is the subset of JSON Schema used to describe bus events.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Const                interface{}            `json:"const,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	// Nullable marks a scalar that may also be null (a Go pointer). Objects
+	// and arrays always accept null, since nil maps and slices encode as null.
+	Nullable bool `json:"nullable,omitempty"`
+}
+
+// EventSchema describes one registered event type.
+type EventSchema struct {
+	Type        string      `json:"type"`
+	Version     int         `json:"version"`
+	GoType      string      `json:"go_type"`
+	Description string      `json:"description,omitempty"`
+	Schema      *JSONSchema `json:"schema"`
+}
+
+// schemaEntry is a registry record; schemas are generated lazily.
+type schemaEntry struct {
+	version     int
+	goType      reflect.Type
+	description string
+}
+
+var (
+	schemaMu       sync.RWMutex
+	schemaRegistry = make(map[string]schemaEntry)
+
+	schemaValidation atomic.Bool
+)
+
+// RegisterEventSchema registers the Go type published for eventType. The
+// JSON Schema is generated from prototype's type; bump version whenever a
+// change to the type alters its JSON shape. Registering the same type string
+// twice panics, since one event type must have one shape.
+func RegisterEventSchema(eventType string, version int, prototype BusEvent, description string) {
+	if eventType == "" || version < 1 || prototype == nil {
+		panic("events: RegisterEventSchema requires a type, a version >= 1 and a prototype")
+	}
+	t := reflect.TypeOf(prototype)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	if _, exists := schemaRegistry[eventType]; exists {
+		panic(fmt.Sprintf("events: schema for %q registered twice", eventType))
+	}
+	schemaRegistry[eventType] = schemaEntry{version: version, goType: t, description: description}
+}
+
+// LookupEventSchema returns the schema registered for eventType.
+func LookupEventSchema(eventType string) (*EventSchema, bool) {
+	schemaMu.RLock()
+	entry, ok := schemaRegistry[eventType]
+	schemaMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return buildEventSchema(eventType, entry), true
+}
+
+// EventSchemas returns every registered event schema, sorted by type.
+func EventSchemas() []*EventSchema {
+	schemaMu.RLock()
+	types := make([]string, 0, len(schemaRegistry))
+	for t := range schemaRegistry {
+		types = append(types, t)
+	}
+	entries := make(map[string]schemaEntry, len(schemaRegistry))
+	for t, e := range schemaRegistry {
+		entries[t] = e
+	}
+	schemaMu.RUnlock()
+
+	sort.Strings(types)
+	out := make([]*EventSchema, 0, len(types))
+	for _, t := range types {
+		out = append(out, buildEventSchema(t, entries[t]))
+	}
+	return out
+}
+
+// EventSchemaID returns the $id of a versioned event schema.
+func EventSchemaID(eventType string, version int) string {
+	return fmt.Sprintf("urn:ntm:event:%s:v%d", eventType, version)
+}
+
+func buildEventSchema(eventType string, entry schemaEntry) *EventSchema {
+	schema := structSchema(entry.goType)
+	schema.Schema = JSONSchemaDialect
+	schema.ID = EventSchemaID(eventType, entry.version)
+	schema.Title = eventType
+	schema.Description = entry.description
+	if typeProp, ok := schema.Properties["type"]; ok {
+		typeProp.Const = eventType
+	}
+	return &EventSchema{
+		Type:        eventType,
+		Version:     entry.version,
+		GoType:      entry.goType.String(),
+		Description: entry.description,
+		Schema:      schema,
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// structSchema generates an object schema from a struct type, flattening
+// embedded structs the way encoding/json does.
+func structSchema(t reflect.Type) *JSONSchema {
+	schema := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+	addStructFields(t, schema)
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func addStructFields(t reflect.Type, schema *JSONSchema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(ft, schema)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = typeSchema(field.Type)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func typeSchema(t reflect.Type) *JSONSchema {
+	if t.Kind() == reflect.Ptr {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		schema := typeSchema(t)
+		if schema.Type != "" && schema.Type != "object" && schema.Type != "array" {
+			schema.Nullable = true
+		}
+		return schema
+	}
+	if t == timeType {
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: typeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		// interface{} and anything else accepts any JSON value.
+		return &JSONSchema{}
+	}
+}
+
+// SetSchemaValidation turns publish-time schema validation on or off. It is
+// on by default in builds tagged ntm_debug, where a mismatch panics so that
+// schema drift fails tests.
+func SetSchemaValidation(enabled bool) {
+	schemaValidation.Store(enabled)
+}
+
+// SchemaValidationEnabled reports whether publish-time validation is on.
+func SchemaValidationEnabled() bool {
+	return schemaValidation.Load()
+}
+
+func init() {
+	schemaValidation.Store(debugSchemaValidation)
+}
+
+// checkEventSchema panics if validation is enabled and event does not match
+// its registered schema.
+func checkEventSchema(event BusEvent) {
+	if !schemaValidation.Load() {
+		return
+	}
+	if err := ValidateEvent(event); err != nil {
+		panic(fmt.Sprintf("events: schema drift: %v", err))
+	}
+}
+
+// ValidateEvent checks an event's JSON encoding against the schema
+// registered for its type. Unregistered types are not checked.
+func ValidateEvent(event BusEvent) error {
+	if event == nil {
+		return fmt.Errorf("nil event")
+	}
+	es, ok := LookupEventSchema(event.EventType())
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("%s: marshal: %w", event.EventType(), err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("%s: decode: %w", event.EventType(), err)
+	}
+	if err := validateValue(es.Schema, value, ""); err != nil {
+		return fmt.Errorf("%s (v%d, %T): %w", es.Type, es.Version, event, err)
+	}
+	return nil
+}
+
+// ValidateEventJSON checks a raw JSON event against its registered schema.
+func ValidateEventJSON(data []byte) error {
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	eventType, _ := value["type"].(string)
+	es, ok := LookupEventSchema(eventType)
+	if !ok {
+		return fmt.Errorf("no schema registered for event type %q", eventType)
+	}
+	return validateValue(es.Schema, value, "")
+}
+
+// validateValue checks a decoded JSON value against schema. Extra object
+// properties are allowed so producers can add optional fields compatibly.
+func validateValue(schema *JSONSchema, value interface{}, path string) error {
+	if schema == nil {
+		return nil
+	}
+	where := path
+	if where == "" {
+		where = "event"
+	}
+
+	if schema.Const != nil && value != schema.Const {
+		return fmt.Errorf("%s: got %v, want %v", where, value, schema.Const)
+	}
+
+	if value == nil && (schema.Nullable || schema.Type == "object" || schema.Type == "array") {
+		return nil
+	}
+
+	switch schema.Type {
+	case "":
+		return nil
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %s", where, jsonKind(value))
+		}
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", where, name)
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := schema.Properties[name]
+			if !ok {
+				prop = schema.AdditionalProperties
+			}
+			if err := validateValue(prop, obj[name], joinPath(path, name)); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %s", where, jsonKind(value))
+		}
+		for i, v := range arr {
+			if err := validateValue(schema.Items, v, fmt.Sprintf("%s[%d]", where, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string, got %s", where, jsonKind(value))
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				return fmt.Errorf("%s: invalid date-time %q", where, s)
+			}
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%s: expected integer, got %s", where, jsonKind(value))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected number, got %s", where, jsonKind(value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %s", where, jsonKind(value))
+		}
+	}
+	return nil
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// Built-in event schemas. Bump a version when the corresponding struct's
+// JSON shape changes.
+func init() {
+	RegisterEventSchema("profile_assigned", 1, ProfileAssignedEvent{}, "A persona profile was assigned to an agent.")
+	RegisterEventSchema("profile_switched", 1, ProfileSwitchedEvent{}, "An agent switched persona profiles.")
+	RegisterEventSchema("context_warning", 1, ContextWarningEvent{}, "An agent's context usage is approaching its threshold.")
+	RegisterEventSchema("rotation_started", 1, RotationStartedEvent{}, "Context rotation of an agent began.")
+	RegisterEventSchema("rotation_completed", 1, RotationCompletedEvent{}, "Context rotation of an agent finished.")
+	RegisterEventSchema("checkpoint_created", 1, CheckpointCreatedEvent{}, "A session checkpoint was created.")
+	RegisterEventSchema("checkpoint_restored", 1, CheckpointRestoredEvent{}, "A session checkpoint was restored.")
+	RegisterEventSchema("workflow_started", 1, WorkflowStartedEvent{}, "A workflow run started.")
+	RegisterEventSchema("stage_transition", 1, StageTransitionEvent{}, "A workflow run moved between stages.")
+	RegisterEventSchema("workflow_paused", 1, WorkflowPausedEvent{}, "A workflow run was paused.")
+	RegisterEventSchema("workflow_completed", 1, WorkflowCompletedEvent{}, "A workflow run finished.")
+	RegisterEventSchema("agent_stall", 1, AgentStallEvent{}, "An agent appears stalled.")
+	RegisterEventSchema("agent_error", 1, AgentErrorEvent{}, "An agent hit an error.")
+	RegisterEventSchema("alert", 1, AlertEvent{}, "An alert was raised.")
+	RegisterEventSchema("metrics_sample", 1, MetricsSampleEvent{}, "A periodic sample of agent metrics for alert rules.")
+
+	RegisterEventSchema("approval.requested", 1, BaseEvent{}, "An approval was requested.")
+	RegisterEventSchema("approval.approved", 1, BaseEvent{}, "An approval was granted.")
+	RegisterEventSchema("approval.denied", 1, BaseEvent{}, "An approval was denied.")
+	RegisterEventSchema("approval.expired", 1, BaseEvent{}, "An approval expired before a decision.")
+
+	for eventType, description := range map[string]string{
+		WebhookSessionCreated: "A session was created.",
+		WebhookSessionKilled:  "A session was killed.",
+		WebhookSessionEnded:   "A session ended (alias of session.killed).",
+		WebhookAgentStarted:   "An agent started.",
+		WebhookAgentStopped:   "An agent stopped.",
+		WebhookAgentCrashed:   "An agent process crashed.",
+		WebhookAgentRestarted: "An agent was restarted.",
+		WebhookAgentRateLimit: "An agent hit a provider rate limit.",
+		WebhookAgentCompleted: "An agent completed its task.",
+		WebhookRotationNeeded: "An agent needs context rotation.",
+		WebhookHealthDegraded: "Session health degraded.",
+		WebhookBeadAssigned:   "A bead was assigned to an agent.",
+		WebhookBeadCompleted:  "An assigned bead was completed.",
+		WebhookBeadFailed:     "An assigned bead failed.",
+	} {
+		RegisterEventSchema(eventType, 1, WebhookEvent{}, description)
+	}
+
+	for eventType, description := range map[string]string{
+		WebhookAgentError: "An agent entered an error state.",
+		WebhookAgentIdle:  "An agent became idle.",
+		WebhookAgentBusy:  "An agent started working.",
+		"agent.recovered": "An agent recovered from an error state.",
+	} {
+		RegisterEventSchema(eventType, 1, AgentStateEvent{}, description)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build ntm_debug
+
+package events
+
+// debugSchemaValidation enables publish-time schema validation in debug builds.
+const debugSchemaValidation = true
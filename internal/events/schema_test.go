@@ -0,0 +1,190 @@
+package events
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// schemaFingerprints pins the JSON shape of every registered Go type. If a
+// struct change breaks this test, bump the version of each event type that
+// uses the struct in schema.go, then update the entry here. Changes to the
+// schema generator that leave the encoded events as they were only need the
+// hash refreshed.
+var schemaFingerprints = map[string]string{
+	"events.AgentErrorEvent":         "v1:9d064597ced5",
+	"events.AgentStateEvent":         "v1:7a87ec8c4bf0",
+	"events.AgentStallEvent":         "v1:3dc3e26a0ce5",
+	"events.AlertEvent":              "v1:6142537d60b8",
+	"events.BaseEvent":               "v1:7dcb17061765",
+	"events.CheckpointCreatedEvent":  "v1:1f4daf3c0917",
+	"events.CheckpointRestoredEvent": "v1:7ed796e71196",
+	"events.ContextWarningEvent":     "v1:46e92992e719",
+	"events.MetricsSampleEvent":      "v1:2d69e261c5cb",
+	"events.ProfileAssignedEvent":    "v1:5a9cfb17213b",
+	"events.ProfileSwitchedEvent":    "v1:a072992890e0",
+	"events.RotationCompletedEvent":  "v1:6b67df13a468",
+	"events.RotationStartedEvent":    "v1:21d356857e07",
+	"events.StageTransitionEvent":    "v1:20666edb7270",
+	"events.WebhookEvent":            "v1:47e4414f596a",
+	"events.WorkflowCompletedEvent":  "v1:c2fe91c5b84f",
+	"events.WorkflowPausedEvent":     "v1:f8e69465da47",
+	"events.WorkflowStartedEvent":    "v1:b5e8e296f6f3",
+}
+
+func TestEventSchemas_NoDrift(t *testing.T) {
+	t.Parallel()
+
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	for eventType, entry := range schemaRegistry {
+		data, err := json.Marshal(structSchema(entry.goType))
+		if err != nil {
+			t.Fatalf("marshal schema for %s: %v", eventType, err)
+		}
+		sum := sha256.Sum256(data)
+		got := fmt.Sprintf("v%d:%s", entry.version, hex.EncodeToString(sum[:])[:12])
+		if want := schemaFingerprints[entry.goType.String()]; got != want {
+			t.Errorf("%s (%s) fingerprint = %q, want %q: bump its schema version and update schemaFingerprints",
+				eventType, entry.goType, got, want)
+		}
+	}
+}
+
+func TestEventSchemas_ConstructorsValidate(t *testing.T) {
+	t.Parallel()
+
+	samples := []BusEvent{
+		NewProfileAssignedEvent("s", "cc_1", "reviewer", ""),
+		NewProfileSwitchedEvent("s", "cc_1", "a", "b"),
+		NewContextWarningEvent("s", "cc_1", 81.5, 20000),
+		NewRotationStartedEvent("s", "cc_1", 90, ""),
+		NewRotationCompletedEvent("s", "cc_1", "cc_2", 1200, true, ""),
+		NewCheckpointCreatedEvent("s", "cp", "full", 1024, 3),
+		NewCheckpointRestoredEvent("s", "cp", 3),
+		NewWorkflowStartedEvent("s", "wf", "run", nil),
+		NewStageTransitionEvent("s", "wf", "run", "a", "b", ""),
+		NewWorkflowPausedEvent("s", "wf", "run", "gate"),
+		NewWorkflowCompletedEvent("s", "wf", "run", 30, 4, true, ""),
+		NewAgentStallEvent("s", "cc_1", 120, ""),
+		NewAgentErrorEvent("s", "cc_1", "crash", "boom"),
+		NewAlertEvent("s", "a1", "stall", "warning", "stalled"),
+		NewWebhookEvent(WebhookAgentIdle, "s", "%1", "claude", "idle", map[string]string{"k": "v"}),
+		BaseEvent{Type: "approval.requested", Timestamp: time.Now()},
+	}
+	for _, e := range samples {
+		if _, ok := LookupEventSchema(e.EventType()); !ok {
+			t.Errorf("%s has no registered schema", e.EventType())
+			continue
+		}
+		if err := ValidateEvent(e); err != nil {
+			t.Errorf("ValidateEvent(%s) error: %v", e.EventType(), err)
+		}
+	}
+}
+
+func TestEventSchema_Document(t *testing.T) {
+	t.Parallel()
+
+	es, ok := LookupEventSchema("agent_stall")
+	if !ok {
+		t.Fatal("agent_stall not registered")
+	}
+	if es.Version != 1 || es.GoType != "events.AgentStallEvent" {
+		t.Errorf("entry = %+v", es)
+	}
+	s := es.Schema
+	if s.ID != "urn:ntm:event:agent_stall:v1" || s.Schema != JSONSchemaDialect {
+		t.Errorf("$id/$schema = %q/%q", s.ID, s.Schema)
+	}
+	if s.Properties["type"].Const != "agent_stall" {
+		t.Errorf("type const = %v", s.Properties["type"].Const)
+	}
+	if s.Properties["timestamp"].Format != "date-time" || s.Properties["stall_duration_sec"].Type != "number" {
+		t.Errorf("properties = %+v", s.Properties)
+	}
+	// Embedded BaseEvent fields are flattened; omitempty fields are optional.
+	if strings.Join(s.Required, ",") != "agent_id,stall_duration_sec,timestamp,type" {
+		t.Errorf("required = %v", s.Required)
+	}
+
+	all := EventSchemas()
+	for i := 1; i < len(all); i++ {
+		if all[i-1].Type >= all[i].Type {
+			t.Fatalf("EventSchemas() not sorted at %q", all[i].Type)
+		}
+	}
+}
+
+// driftedEvent reuses a registered type string with the wrong shape.
+type driftedEvent struct {
+	BaseEvent
+	AgentID       int    `json:"agent_id"`
+	StallDuration string `json:"stall_duration_sec"`
+}
+
+func TestValidateEvent_DetectsDrift(t *testing.T) {
+	t.Parallel()
+
+	ev := driftedEvent{BaseEvent: BaseEvent{Type: "agent_stall", Timestamp: time.Now()}}
+	err := ValidateEvent(ev)
+	if err == nil || !strings.Contains(err.Error(), "agent_id: expected string") {
+		t.Errorf("ValidateEvent() = %v, want agent_id type error", err)
+	}
+
+	if err := ValidateEvent(BaseEvent{Type: "unregistered.custom", Timestamp: time.Now()}); err != nil {
+		t.Errorf("unregistered types should pass, got %v", err)
+	}
+
+	if err := ValidateEventJSON([]byte(`{"type":"alert","timestamp":"2026-01-02T03:04:05Z","alert_id":"a","alert_type":"t","severity":"info"}`)); err == nil ||
+		!strings.Contains(err.Error(), `"message"`) {
+		t.Errorf("ValidateEventJSON() = %v, want missing message", err)
+	}
+}
+
+// nullableEvent reuses a registered type string with nil map and slice
+// fields, which encode as JSON null.
+type nullableEvent struct {
+	BaseEvent
+	AgentID string         `json:"agent_id"`
+	Details map[string]any `json:"details"`
+	Agents  []string       `json:"agents"`
+}
+
+func TestPublish_AcceptsNullWhenValidating(t *testing.T) {
+	prev := SchemaValidationEnabled()
+	SetSchemaValidation(true)
+	defer SetSchemaValidation(prev)
+
+	bus := NewEventBus(10)
+	bus.Publish(nullableEvent{BaseEvent: BaseEvent{Type: WebhookAgentIdle, Timestamp: time.Now()}})
+	bus.Publish(NewWorkflowStartedEvent("s", "wf", "run", nil))
+	bus.Publish(MetricsSampleEvent{BaseEvent: BaseEvent{Type: "metrics_sample", Timestamp: time.Now()}})
+
+	if err := ValidateEventJSON([]byte(`{"type":"metrics_sample","timestamp":"2026-01-02T03:04:05Z","agents":null,"file_conflicts":null}`)); err != nil {
+		t.Errorf("ValidateEventJSON() = %v, want null accepted", err)
+	}
+	if err := ValidateEventJSON([]byte(`{"type":"agent.idle","timestamp":"2026-01-02T03:04:05Z","agent_id":null}`)); err == nil {
+		t.Error("ValidateEventJSON() accepted null for a non-nullable string")
+	}
+}
+
+func TestPublish_PanicsOnDriftWhenValidating(t *testing.T) {
+	prev := SchemaValidationEnabled()
+	SetSchemaValidation(true)
+	defer SetSchemaValidation(prev)
+
+	bus := NewEventBus(10)
+	bus.Publish(NewAgentStallEvent("s", "cc_1", 5, ""))
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "schema drift") {
+			t.Errorf("recover() = %v, want schema drift panic", r)
+		}
+	}()
+	bus.Publish(driftedEvent{BaseEvent: BaseEvent{Type: "agent_stall", Timestamp: time.Now()}})
+}
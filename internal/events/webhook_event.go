@@ -49,3 +49,19 @@ func NewWebhookEvent(eventType, session, pane, agent, message string, details ma
 		Details: details,
 	}
 }
+
+// AgentStateEvent is the schema of the agent state transitions (agent.idle,
+// agent.busy, agent.error, agent.recovered). They are published both as
+// WebhookEvents and by the session coordinator, whose details carry
+// non-string values such as the pane index, so details accept any JSON value.
+type AgentStateEvent struct {
+	BaseEvent
+
+	Pane       string         `json:"pane,omitempty"`
+	Agent      string         `json:"agent,omitempty"`
+	AgentID    string         `json:"agent_id,omitempty"`
+	Message    string         `json:"message,omitempty"`
+	Details    map[string]any `json:"details,omitempty"`
+	PrevStatus string         `json:"prev_status,omitempty"`
+	NewStatus  string         `json:"new_status,omitempty"`
+}
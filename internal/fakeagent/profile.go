@@ -0,0 +1,171 @@
+// Package fakeagent simulates the terminal UIs of the supported coding
+// agents. A simulated agent prints the same prompt glyphs, spinners, usage
+// screens, rate-limit banners and compaction messages as the real CLI, driven
+// by a scripted scenario, so ntm's detectors, quota fetcher, respawner and
+// handoff trigger can be tested end to end without network access.
+package fakeagent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dicklesworthstone/ntm/internal/adapter"
+	"github.com/Dicklesworthstone/ntm/internal/agent"
+)
+
+// Profile describes how one agent's TUI looks and which input quits it.
+// Strings may contain {model}, {dir}, {left} and {resets} placeholders.
+type Profile struct {
+	Type   agent.AgentType
+	Model  string   // Default model
+	Banner []string // Printed once the agent has started
+	Prompt string   // Input prompt, printed without a trailing newline
+
+	Spinner     []string // Spinner frames shown while working
+	WorkingText string   // Shown next to the spinner; {secs} is the elapsed time
+	ReplyPrefix string   // Marker in front of the first line of a reply
+
+	// ContextLine reports how much context is left after each turn. It is
+	// only shown once {left} drops to ContextBelow percent or less.
+	ContextLine  string
+	ContextBelow int
+
+	RateLimit   string // Banner shown when the usage limit is hit
+	Compacted   string // Shown after the context was compacted
+	Cleared     string // Shown after the conversation was cleared
+	Interrupted string // Shown when Ctrl+C stops a turn
+	ExitHint    string // Shown after a Ctrl+C that does not quit yet
+
+	// InterruptsToExit is how many Ctrl+Cs in a row quit the idle agent.
+	InterruptsToExit int
+	// ExitCommands quit the agent when typed at the prompt.
+	ExitCommands []string
+
+	// Quota and Compaction are the commands ntm sends for this agent type.
+	// They come from the agent's adapter so the simulator answers exactly
+	// what the quota fetcher and context rotation send.
+	Quota      adapter.Quota
+	Compaction adapter.Compaction
+	// ModelCommand switches models, e.g. "/model".
+	ModelCommand string
+}
+
+var profiles = map[agent.AgentType]Profile{
+	agent.AgentTypeClaudeCode: {
+		Model: "claude-sonnet-4-5",
+		Banner: []string{
+			"╭───────────────────────────────────────────╮",
+			"│ ✻ Welcome to Claude Code (simulated)      │",
+			"│                                           │",
+			"│   /help for help                          │",
+			"│   model: {model}",
+			"│   cwd: {dir}",
+			"╰───────────────────────────────────────────╯",
+		},
+		Prompt:           "> ",
+		Spinner:          []string{"·", "✢", "✳", "✶", "✻", "✽"},
+		WorkingText:      "Thinking… ({secs}s · esc to interrupt)",
+		ReplyPrefix:      "⏺ ",
+		ContextLine:      "Context left until auto-compact: {left}%",
+		ContextBelow:     20,
+		RateLimit:        "You've hit your limit · resets {resets}",
+		Compacted:        "✻ Conversation compacted · ctrl+o for history",
+		Cleared:          "✻ Conversation cleared",
+		Interrupted:      "  ⎿  Interrupted by user",
+		ExitHint:         "Press Ctrl-C again to exit",
+		InterruptsToExit: 2,
+		ExitCommands:     []string{"/exit", "/quit"},
+		ModelCommand:     "/model",
+	},
+	agent.AgentTypeCodex: {
+		Model: "gpt-5-codex",
+		Banner: []string{
+			">_ OpenAI Codex (simulated)",
+			"",
+			"   model:     {model}",
+			"   directory: {dir}",
+		},
+		Prompt:           "› ",
+		Spinner:          []string{"◦", "•"},
+		WorkingText:      "Working ({secs}s • esc to interrupt)",
+		ContextLine:      "  ⏎ send   ⌃J newline   ? for shortcuts   {left}% context left",
+		ContextBelow:     100,
+		RateLimit:        "■ You've reached your usage limit. Try again {resets}.",
+		Compacted:        "• Context limit reached: conversation truncated, continuing from summary",
+		Cleared:          "• History cleared",
+		Interrupted:      "■ Conversation interrupted",
+		ExitHint:         "Press Ctrl-C again to quit",
+		InterruptsToExit: 2,
+		ExitCommands:     []string{"/exit", "/quit"},
+		ModelCommand:     "/model",
+	},
+	agent.AgentTypeGemini: {
+		Model: "gemini-2.0-flash",
+		Banner: []string{
+			"Gemini CLI (simulated)",
+			"",
+			"Tips for getting started:",
+			"1. Ask questions, edit files, or run commands.",
+			"",
+			"{dir}   no sandbox   {model}",
+		},
+		Prompt:       "gemini> ",
+		Spinner:      []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+		WorkingText:  "Thinking… (esc to cancel, {secs}s)",
+		ReplyPrefix:  "✦ ",
+		ContextLine:  "{model} ({left}% context left)  |  212.4 MB",
+		ContextBelow: 100,
+		RateLimit:    "✕ API Error: 429 Quota exceeded (RESOURCE_EXHAUSTED). Please try again {resets}.",
+		Compacted:    "ℹ Chat history compressed: conversation reset to a summary",
+		Cleared:      "ℹ Chat history cleared",
+		Interrupted:  "ℹ Request cancelled.",
+		// The respawner quits Gemini with Escape and a single Ctrl+C.
+		InterruptsToExit: 1,
+		ExitCommands:     []string{"/exit", "/quit"},
+		ModelCommand:     "/model",
+	},
+}
+
+// compactFallback names the compact command of agents whose adapter has none.
+var compactFallback = map[agent.AgentType]string{
+	agent.AgentTypeCodex:  "/compact",
+	agent.AgentTypeGemini: "/compress",
+}
+
+// normalizeType maps agent names and aliases to the short agent types.
+func normalizeType(name string) agent.AgentType {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "cc", "claude", "claude-code":
+		return agent.AgentTypeClaudeCode
+	case "cod", "codex":
+		return agent.AgentTypeCodex
+	case "gmi", "gemini":
+		return agent.AgentTypeGemini
+	default:
+		return agent.AgentType(name)
+	}
+}
+
+// ProfileFor returns the profile for an agent type or alias ("cc", "claude",
+// "cod", "codex", "gmi", "gemini"). An empty name means Claude.
+func ProfileFor(name string) (Profile, error) {
+	t := normalizeType(name)
+	p, ok := profiles[t]
+	if !ok {
+		return Profile{}, fmt.Errorf("no simulator for agent type %q (supported: %s)", name, strings.Join(SupportedTypes(), ", "))
+	}
+	p.Type = t
+	if a, ok := adapter.Lookup(t); ok {
+		p.Quota = a.Quota()
+		p.Compaction = a.Compaction()
+	}
+	if p.Compaction.CompactCommand == "" {
+		p.Compaction.CompactCommand = compactFallback[t]
+	}
+	return p, nil
+}
+
+// SupportedTypes lists the agent types that can be simulated.
+func SupportedTypes() []string {
+	return []string{string(agent.AgentTypeClaudeCode), string(agent.AgentTypeCodex), string(agent.AgentTypeGemini)}
+}
@@ -0,0 +1,194 @@
+package fakeagent
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Step triggers.
+const (
+	OnPrompt = "prompt" // Runs when the agent receives a prompt (default)
+	OnStart  = "start"  // Runs as soon as the agent is ready
+)
+
+// Step events.
+const (
+	EventRateLimit = "rate_limit" // Show the rate-limit banner; later prompts are refused
+	EventCompact   = "compact"    // Compact the context
+	EventClear     = "clear"      // Clear the conversation
+	EventCrash     = "crash"      // Exit with status 1
+	EventExit      = "exit"       // Exit with status 0
+)
+
+// DefaultReply answers prompts once the scenario's steps have run out.
+const DefaultReply = "Done."
+
+// Scenario scripts a simulated agent session. Steps are consumed in order:
+// each prompt runs the next step, except that a step with a match pattern
+// waits for a prompt that matches it, and steps triggered on start run as
+// soon as the agent is ready.
+type Scenario struct {
+	Agent   string `toml:"agent,omitempty"`   // Agent to simulate unless --agent is given
+	Model   string `toml:"model,omitempty"`   // Model shown by the agent (default per agent)
+	Startup string `toml:"startup,omitempty"` // Delay before the banner, e.g. "500ms"
+	Work    string `toml:"work,omitempty"`    // Default time spent working on a prompt
+	Reply   string `toml:"reply,omitempty"`   // Reply once the steps run out
+
+	Account Account `toml:"account"`
+	Usage   Usage   `toml:"usage"`
+	Context Context `toml:"context"`
+	Steps   []Step  `toml:"steps"`
+
+	// Path is the file the scenario was loaded from.
+	Path string `toml:"-"`
+
+	startup time.Duration
+	work    time.Duration
+}
+
+// Account is what the agent's status screen reports.
+type Account struct {
+	Email        string `toml:"email,omitempty"`
+	Organization string `toml:"organization,omitempty"`
+	Plan         string `toml:"plan,omitempty"`
+}
+
+// Usage is what the agent's usage screen reports, in percent.
+type Usage struct {
+	Session float64 `toml:"session,omitempty"`
+	Weekly  float64 `toml:"weekly,omitempty"`
+	Period  float64 `toml:"period,omitempty"`
+	Sonnet  float64 `toml:"sonnet,omitempty"`
+	Resets  string  `toml:"resets,omitempty"` // e.g. "in 2 hours"
+}
+
+// merge replaces the fields of u that are set in o.
+func (u *Usage) merge(o Usage) {
+	if o.Session != 0 {
+		u.Session = o.Session
+	}
+	if o.Weekly != 0 {
+		u.Weekly = o.Weekly
+	}
+	if o.Period != 0 {
+		u.Period = o.Period
+	}
+	if o.Sonnet != 0 {
+		u.Sonnet = o.Sonnet
+	}
+	if o.Resets != "" {
+		u.Resets = o.Resets
+	}
+}
+
+// Context controls the simulated context window.
+type Context struct {
+	Window      int64   `toml:"window,omitempty"`       // Window size in tokens (default: the model's known limit)
+	Tokens      int64   `toml:"tokens,omitempty"`       // Tokens in context at startup
+	PerTurn     int64   `toml:"per_turn,omitempty"`     // Tokens a prompt adds unless its step says otherwise
+	AutoCompact float64 `toml:"auto_compact,omitempty"` // Percent used that compacts automatically (0 = never)
+	Compacted   int64   `toml:"compacted,omitempty"`    // Tokens left after compaction (default 10% of the window)
+}
+
+// Step is one scripted turn.
+type Step struct {
+	On     string   `toml:"on,omitempty"`     // OnPrompt or OnStart
+	Match  string   `toml:"match,omitempty"`  // Regexp the prompt must match
+	Work   string   `toml:"work,omitempty"`   // Time spent working, e.g. "2s"
+	Output []string `toml:"output,omitempty"` // Tool activity printed while working
+	Reply  string   `toml:"reply,omitempty"`
+	Tokens int64    `toml:"tokens,omitempty"` // Context tokens this turn adds
+	Usage  *Usage   `toml:"usage,omitempty"`  // Fields set here replace the current usage
+	Event  string   `toml:"event,omitempty"`  // One of the Event constants
+
+	match *regexp.Regexp
+	work  time.Duration
+}
+
+// Matches reports whether a prompt runs this step.
+func (s Step) Matches(prompt string) bool {
+	return s.match == nil || s.match.MatchString(prompt)
+}
+
+// LoadScenario reads and validates a scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	var s Scenario
+	if _, err := toml.DecodeFile(path, &s); err != nil {
+		return nil, fmt.Errorf("parsing scenario %s: %w", path, err)
+	}
+	s.Path = path
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Validate checks the scenario and compiles its durations and patterns.
+func (s *Scenario) Validate() error {
+	var err error
+	if s.startup, err = parseDuration(s.Startup); err != nil {
+		return fmt.Errorf("startup: %w", err)
+	}
+	if s.work, err = parseDuration(s.Work); err != nil {
+		return fmt.Errorf("work: %w", err)
+	}
+	if s.Agent != "" {
+		if _, err := ProfileFor(s.Agent); err != nil {
+			return err
+		}
+	}
+	if s.Context.Window < 0 || s.Context.Tokens < 0 || s.Context.PerTurn < 0 || s.Context.Compacted < 0 {
+		return fmt.Errorf("context token counts cannot be negative")
+	}
+	if s.Context.AutoCompact < 0 || s.Context.AutoCompact > 100 {
+		return fmt.Errorf("context.auto_compact must be a percentage")
+	}
+
+	for i := range s.Steps {
+		st := &s.Steps[i]
+		switch st.On {
+		case "":
+			st.On = OnPrompt
+		case OnPrompt, OnStart:
+		default:
+			return fmt.Errorf("step %d: on must be %q or %q", i+1, OnPrompt, OnStart)
+		}
+		switch st.Event {
+		case "", EventRateLimit, EventCompact, EventClear, EventCrash, EventExit:
+		default:
+			return fmt.Errorf("step %d: unknown event %q", i+1, st.Event)
+		}
+		if st.Match != "" {
+			if st.On == OnStart {
+				return fmt.Errorf("step %d: start steps cannot match prompts", i+1)
+			}
+			if st.match, err = regexp.Compile(st.Match); err != nil {
+				return fmt.Errorf("step %d: match: %w", i+1, err)
+			}
+		}
+		if st.work, err = parseDuration(st.Work); err != nil {
+			return fmt.Errorf("step %d: work: %w", i+1, err)
+		}
+		if st.Tokens < 0 {
+			return fmt.Errorf("step %d: tokens cannot be negative", i+1)
+		}
+	}
+	return nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s is negative", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,110 @@
+package fakeagent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeScenario(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scenario.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadScenario(t *testing.T) {
+	sc, err := LoadScenario(writeScenario(t, `
+agent = "codex"
+startup = "200ms"
+work = "1s"
+
+[usage]
+session = 40
+resets = "in 1 hour"
+
+[context]
+tokens = 5000
+auto_compact = 90
+
+[[steps]]
+on = "start"
+event = "rate_limit"
+
+[[steps]]
+match = "(?i)fix"
+work = "2s"
+output = ["Editing main.go"]
+reply = "Fixed."
+tokens = 12000
+usage = { session = 95 }
+`))
+	if err != nil {
+		t.Fatalf("LoadScenario: %v", err)
+	}
+	if sc.startup != 200*time.Millisecond || sc.work != time.Second {
+		t.Errorf("durations = %s, %s", sc.startup, sc.work)
+	}
+	if len(sc.Steps) != 2 || sc.Steps[0].On != OnStart || sc.Steps[1].On != OnPrompt {
+		t.Fatalf("steps = %+v", sc.Steps)
+	}
+	fix := sc.Steps[1]
+	if !fix.Matches("Please FIX it") || fix.Matches("refactor") || fix.work != 2*time.Second {
+		t.Errorf("fix step = %+v", fix)
+	}
+	if fix.Usage == nil || fix.Usage.Session != 95 {
+		t.Errorf("step usage = %+v", fix.Usage)
+	}
+
+	u := sc.Usage
+	u.merge(*fix.Usage)
+	if u.Session != 95 || u.Resets != "in 1 hour" {
+		t.Errorf("merged usage = %+v", u)
+	}
+}
+
+func TestScenarioValidate(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"unknown agent", `agent = "robot"`, "no simulator"},
+		{"bad duration", `work = "soon"`, "work"},
+		{"bad trigger", "[[steps]]\non = \"later\"", "on must be"},
+		{"unknown event", "[[steps]]\nevent = \"explode\"", "unknown event"},
+		{"start with match", "[[steps]]\non = \"start\"\nmatch = \"x\"", "cannot match"},
+		{"bad match", "[[steps]]\nmatch = \"(\"", "match"},
+		{"bad auto compact", "[context]\nauto_compact = 150.0", "percentage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadScenario(writeScenario(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadScenario error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileFor(t *testing.T) {
+	for alias, want := range map[string]string{"": "cc", "claude": "cc", "codex": "cod", "gmi": "gmi"} {
+		p, err := ProfileFor(alias)
+		if err != nil || string(p.Type) != want {
+			t.Errorf("ProfileFor(%q) = %q, %v", alias, p.Type, err)
+		}
+	}
+	cc, _ := ProfileFor("cc")
+	if cc.Quota.UsageCommand != "/usage" || cc.Compaction.CompactCommand != "/compact" {
+		t.Errorf("claude commands = %+v, %+v", cc.Quota, cc.Compaction)
+	}
+	gmi, _ := ProfileFor("gmi")
+	if gmi.Quota.UsageCommand != "/auth status" || gmi.Compaction.CompactCommand != "/compress" {
+		t.Errorf("gemini commands = %+v, %+v", gmi.Quota, gmi.Compaction)
+	}
+	if _, err := ProfileFor("aider"); err == nil {
+		t.Error("ProfileFor accepted an agent without a simulator")
+	}
+}
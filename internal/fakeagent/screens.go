@@ -0,0 +1,105 @@
+package fakeagent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// usageScreen renders the agent's usage command output in the layout the
+// quota parser for its provider reads.
+func (s *Simulator) usageScreen() []string {
+	u := s.usage
+	switch s.Profile.Quota.Provider {
+	case "claude":
+		lines := []string{
+			" Usage",
+			fmt.Sprintf(" Current session: %g%% used", u.Session),
+			fmt.Sprintf(" Weekly (all models): %g%% used", u.Weekly),
+		}
+		if u.Sonnet != 0 {
+			lines = append(lines, fmt.Sprintf(" Sonnet only: %g%% used", u.Sonnet))
+		}
+		if u.Period != 0 {
+			lines = append(lines, fmt.Sprintf(" 5-hour period: %g%% used", u.Period))
+		}
+		lines = append(lines, " Resets "+s.resets())
+		if s.limited {
+			lines = append(lines, " Rate limited until the reset")
+		}
+		return lines
+
+	case "codex":
+		lines := []string{
+			fmt.Sprintf(" Usage: %g%%", u.Session),
+			fmt.Sprintf(" Weekly limit: %g%%", u.Weekly),
+		}
+		if s.limited {
+			lines = append(lines, " Rate limited, resets "+s.resets())
+		}
+		return lines
+
+	case "gemini":
+		// Gemini answers usage and status with the same command.
+		lines := s.statusScreen()
+		lines = append(lines, fmt.Sprintf(" Daily usage: %g%%", u.Session))
+		if u.Weekly != 0 {
+			lines = append(lines, fmt.Sprintf(" Quota: %g%%", u.Weekly))
+		}
+		if s.limited {
+			lines = append(lines, " Rate limited, resets "+s.resets())
+		}
+		return lines
+	}
+	return []string{"Usage information is not available."}
+}
+
+// statusScreen renders the agent's account status output.
+func (s *Simulator) statusScreen() []string {
+	a := s.account()
+	switch s.Profile.Quota.Provider {
+	case "claude":
+		return []string{
+			" Account",
+			" Logged in as: " + a.Email,
+			" Organization: " + a.Organization,
+			" Login method: Claude " + a.Plan + " account",
+			" Plan: " + a.Plan,
+			" Model: " + s.model,
+		}
+	case "codex":
+		return []string{
+			" Account: " + a.Email,
+			" Workspace: " + a.Organization,
+			" Model: " + s.model,
+		}
+	case "gemini":
+		return []string{
+			" Account: " + a.Email,
+			" Project: " + a.Organization,
+			" Region: us-central1",
+		}
+	}
+	return []string{" Model: " + s.model}
+}
+
+// account fills in the scenario's account with defaults.
+func (s *Simulator) account() Account {
+	a := s.Scenario.Account
+	if a.Email == "" {
+		a.Email = "fake-agent@example.com"
+	}
+	if a.Organization == "" {
+		a.Organization = "Fake Org"
+	}
+	if a.Plan == "" {
+		a.Plan = "Pro"
+	}
+	return a
+}
+
+func (s *Simulator) resets() string {
+	if r := strings.TrimSpace(s.usage.Resets); r != "" {
+		return r
+	}
+	return "in 2 hours"
+}
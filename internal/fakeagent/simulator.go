@@ -0,0 +1,567 @@
+package fakeagent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	ntmcontext "github.com/Dicklesworthstone/ntm/internal/context"
+)
+
+// Timing defaults.
+const (
+	// DefaultSpinnerInterval is how often the working spinner advances.
+	DefaultSpinnerInterval = 100 * time.Millisecond
+	// interruptWindow is how soon a second Ctrl+C must follow the first.
+	interruptWindow = 2 * time.Second
+	// pasteWindow joins lines arriving this close together into one
+	// prompt, as the real TUIs do with pasted multi-line text.
+	pasteWindow = 20 * time.Millisecond
+)
+
+// Simulator runs one simulated agent session.
+type Simulator struct {
+	Profile  Profile
+	Scenario *Scenario
+
+	In  io.Reader // Typed input, one line per Enter
+	Out io.Writer // The agent's terminal
+
+	// Interrupts delivers Ctrl+C presses (SIGINT in a real terminal).
+	Interrupts <-chan struct{}
+
+	// Model overrides the scenario's and profile's model.
+	Model string
+	// Dir is the working directory shown in the banner.
+	Dir string
+	// StatePath persists how far through the scenario the agent got, so a
+	// respawned agent continues with the next step instead of starting over.
+	StatePath string
+	// TranscriptPath, when set, receives each turn's token usage in the
+	// agent's native transcript format.
+	TranscriptPath string
+	// SpinnerInterval overrides DefaultSpinnerInterval.
+	SpinnerInterval time.Duration
+
+	model      string
+	window     int64
+	tokens     int64
+	usage      Usage
+	limited    bool
+	step       int
+	interrupts int
+	lastIntr   time.Time
+	transcript *transcriptWriter
+	exitCode   int
+}
+
+// state is what StatePath persists between runs.
+type state struct {
+	Step     int `json:"step"`
+	Launches int `json:"launches"`
+}
+
+// errExit ends the session with the simulator's exit code.
+var errExit = errors.New("agent exited")
+
+// Run plays the session until the agent exits, input ends or ctx is done,
+// and returns the agent's exit status.
+func (s *Simulator) Run(ctx context.Context) (int, error) {
+	if s.Scenario == nil {
+		s.Scenario = &Scenario{}
+		if err := s.Scenario.Validate(); err != nil {
+			return 1, err
+		}
+	}
+	if err := s.init(); err != nil {
+		return 1, err
+	}
+
+	if !s.sleep(ctx, s.Scenario.startup) {
+		return 0, nil
+	}
+	s.banner()
+
+	// Buffered so lines typed ahead during a turn keep their arrival times.
+	lines := make(chan inputLine, 64)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(s.In)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			select {
+			case lines <- inputLine{text: scanner.Text(), at: time.Now()}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	err := s.loop(ctx, lines)
+	if errors.Is(err, errExit) {
+		return s.exitCode, nil
+	}
+	return s.exitCode, err
+}
+
+func (s *Simulator) init() error {
+	s.model = firstNonEmpty(s.Model, s.Scenario.Model, s.Profile.Model)
+	s.window = s.Scenario.Context.Window
+	if s.window == 0 {
+		s.window = ntmcontext.GetContextLimit(s.model)
+	}
+	s.tokens = s.Scenario.Context.Tokens
+	s.usage = s.Scenario.Usage
+	if s.SpinnerInterval <= 0 {
+		s.SpinnerInterval = DefaultSpinnerInterval
+	}
+	if s.Dir == "" {
+		s.Dir, _ = os.Getwd()
+	}
+
+	if s.StatePath != "" {
+		st, err := s.loadState()
+		if err != nil {
+			return err
+		}
+		s.step = st.Step
+		st.Launches++
+		if err := s.saveState(st); err != nil {
+			return err
+		}
+	}
+	if s.TranscriptPath != "" {
+		w, err := newTranscriptWriter(s.TranscriptPath, string(s.Profile.Type))
+		if err != nil {
+			return err
+		}
+		s.transcript = w
+	}
+	return nil
+}
+
+func (s *Simulator) loop(ctx context.Context, lines <-chan inputLine) error {
+	if err := s.runStartSteps(ctx); err != nil {
+		return err
+	}
+	s.prompt()
+
+	var next *inputLine
+	for {
+		var in inputLine
+		if next != nil {
+			in, next = *next, nil
+		} else {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-s.Interrupts:
+				if err := s.idleInterrupt(); err != nil {
+					return err
+				}
+				continue
+			case line, ok := <-lines:
+				if !ok {
+					return nil
+				}
+				in = line
+			}
+		}
+		var text string
+		text, next = collectPaste(in, lines)
+		if err := s.handleInput(ctx, text); err != nil {
+			return err
+		}
+		s.prompt()
+	}
+}
+
+// inputLine is a line read from the terminal and when it arrived.
+type inputLine struct {
+	text string
+	at   time.Time
+}
+
+// collectPaste joins the lines that arrived right behind first, as a paste
+// does. It returns the joined text and the first line that was typed
+// separately, if one was already read.
+func collectPaste(first inputLine, lines <-chan inputLine) (string, *inputLine) {
+	text, last := first.text, first.at
+	for {
+		select {
+		case next, ok := <-lines:
+			if !ok {
+				return text, nil
+			}
+			if next.at.Sub(last) > pasteWindow {
+				return text, &next
+			}
+			text += "\n" + next.text
+			last = next.at
+		case <-time.After(pasteWindow):
+			return text, nil
+		}
+	}
+}
+
+func (s *Simulator) runStartSteps(ctx context.Context) error {
+	for s.step < len(s.Scenario.Steps) && s.Scenario.Steps[s.step].On == OnStart {
+		st := s.Scenario.Steps[s.step]
+		if err := s.advance(); err != nil {
+			return err
+		}
+		if err := s.runTurn(ctx, st); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleInput answers one submitted line.
+func (s *Simulator) handleInput(ctx context.Context, line string) error {
+	line = strings.TrimSpace(cleanInput(line))
+	if line == "" {
+		return nil
+	}
+	s.interrupts = 0
+	s.println("")
+
+	if strings.HasPrefix(line, "/") {
+		return s.command(line)
+	}
+	if s.limited {
+		s.println(s.expand(s.Profile.RateLimit))
+		return nil
+	}
+
+	st := Step{Reply: firstNonEmpty(s.Scenario.Reply, DefaultReply), work: s.Scenario.work}
+	if s.step < len(s.Scenario.Steps) {
+		if next := s.Scenario.Steps[s.step]; next.On == OnPrompt && next.Matches(line) {
+			st = next
+			if next.Work == "" {
+				st.work = s.Scenario.work
+			}
+			if err := s.advance(); err != nil {
+				return err
+			}
+		}
+	}
+	return s.runTurn(ctx, st)
+}
+
+// command handles slash commands.
+func (s *Simulator) command(line string) error {
+	name, arg, _ := strings.Cut(line, " ")
+	p := s.Profile
+	switch {
+	case slices.Contains(p.ExitCommands, name):
+		return s.exit(0, "")
+	case line == p.Quota.UsageCommand:
+		s.block(s.usageScreen())
+	case line == p.Quota.StatusCommand:
+		s.block(s.statusScreen())
+	case line == p.Compaction.CompactCommand:
+		return s.compact()
+	case line == p.Compaction.ClearCommand:
+		s.tokens = 0
+		s.println(p.Cleared)
+	case name == p.ModelCommand && p.ModelCommand != "":
+		if arg = strings.TrimSpace(arg); arg == "" {
+			s.println("  ⎿  Current model: " + s.model)
+			return nil
+		}
+		s.model = arg
+		s.println("  ⎿  Set model to " + arg)
+	default:
+		s.println("Unknown command: " + name)
+	}
+	return nil
+}
+
+// runTurn plays a step: work, output, events, reply and context accounting.
+func (s *Simulator) runTurn(ctx context.Context, st Step) error {
+	if !s.work(ctx, st) {
+		return nil
+	}
+
+	if st.Usage != nil {
+		s.usage.merge(*st.Usage)
+	}
+	switch st.Event {
+	case EventCrash:
+		return s.exit(1, st.Reply)
+	case EventExit:
+		return s.exit(0, st.Reply)
+	}
+
+	if st.Reply != "" {
+		s.reply(st.Reply)
+	}
+	tokens := st.Tokens
+	if tokens == 0 && st.On != OnStart && st.Event == "" {
+		tokens = s.Scenario.Context.PerTurn
+	}
+	if tokens > 0 {
+		s.tokens += tokens
+		if err := s.recordTurn(st.Reply); err != nil {
+			return err
+		}
+	}
+
+	switch st.Event {
+	case EventRateLimit:
+		s.limited = true
+		s.println(s.expand(s.Profile.RateLimit))
+		return nil
+	case EventCompact:
+		return s.compact()
+	case EventClear:
+		s.tokens = 0
+		s.println(s.Profile.Cleared)
+		return nil
+	}
+
+	if auto := s.Scenario.Context.AutoCompact; auto > 0 && s.usedPercent() >= auto {
+		return s.compact()
+	}
+	s.contextLine()
+	return nil
+}
+
+// work shows the spinner and the step's activity. It returns false if the
+// turn was interrupted.
+func (s *Simulator) work(ctx context.Context, st Step) bool {
+	for _, line := range st.Output {
+		s.println("  " + line)
+	}
+	if st.work <= 0 {
+		return true
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(s.SpinnerInterval)
+	defer ticker.Stop()
+	timer := time.NewTimer(st.work)
+	defer timer.Stop()
+
+	frames := s.Profile.Spinner
+	if len(frames) == 0 {
+		frames = []string{"*"}
+	}
+	for i := 0; ; i++ {
+		secs := strconv.Itoa(int(time.Since(start).Seconds()))
+		fmt.Fprintf(s.Out, "\r\x1b[K%s %s", frames[i%len(frames)], strings.ReplaceAll(s.Profile.WorkingText, "{secs}", secs))
+		select {
+		case <-ctx.Done():
+			fmt.Fprint(s.Out, "\r\x1b[K")
+			return false
+		case <-s.Interrupts:
+			fmt.Fprint(s.Out, "\r\x1b[K")
+			s.println(s.Profile.Interrupted)
+			return false
+		case <-timer.C:
+			fmt.Fprint(s.Out, "\r\x1b[K")
+			return true
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Simulator) compact() error {
+	compacted := s.Scenario.Context.Compacted
+	if compacted == 0 {
+		compacted = s.window / 10
+	}
+	s.tokens = min(s.tokens, compacted)
+	s.println(s.Profile.Compacted)
+	if s.transcript != nil {
+		return s.recordTurn("")
+	}
+	return nil
+}
+
+// idleInterrupt handles Ctrl+C at the prompt.
+func (s *Simulator) idleInterrupt() error {
+	now := time.Now()
+	if now.Sub(s.lastIntr) > interruptWindow {
+		s.interrupts = 0
+	}
+	s.lastIntr = now
+	s.interrupts++
+	if s.interrupts >= s.Profile.InterruptsToExit {
+		return s.exit(0, "")
+	}
+	s.println("")
+	s.println(s.Profile.ExitHint)
+	s.prompt()
+	return nil
+}
+
+func (s *Simulator) exit(code int, message string) error {
+	if message != "" {
+		s.println(message)
+	}
+	if code == 0 && s.Profile.Type == "cod" && s.tokens > 0 {
+		s.println(fmt.Sprintf("Token usage: total=%d input=%d output=0", s.tokens, s.tokens))
+	}
+	s.exitCode = code
+	return errExit
+}
+
+// recordTurn writes the context usage after a turn that replied text.
+func (s *Simulator) recordTurn(text string) error {
+	if s.transcript == nil {
+		return nil
+	}
+	var outputTokens int64
+	if text != "" {
+		outputTokens = min(int64(len(text)/4+1), s.tokens)
+	}
+	if err := s.transcript.turn(s.model, s.window, s.tokens, outputTokens, text); err != nil {
+		return fmt.Errorf("writing transcript: %w", err)
+	}
+	return nil
+}
+
+// advance consumes the current step and persists the position.
+func (s *Simulator) advance() error {
+	s.step++
+	if s.StatePath == "" {
+		return nil
+	}
+	st, err := s.loadState()
+	if err != nil {
+		return err
+	}
+	st.Step = s.step
+	return s.saveState(st)
+}
+
+func (s *Simulator) loadState() (state, error) {
+	var st state
+	data, err := os.ReadFile(s.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("reading state: %w", err)
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, fmt.Errorf("parsing state %s: %w", s.StatePath, err)
+	}
+	return st, nil
+}
+
+func (s *Simulator) saveState(st state) error {
+	if err := os.MkdirAll(filepath.Dir(s.StatePath), 0o755); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.StatePath, data, 0o644); err != nil {
+		return fmt.Errorf("writing state: %w", err)
+	}
+	return nil
+}
+
+// sleep waits for d and reports false if ctx ended first.
+func (s *Simulator) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (s *Simulator) usedPercent() float64 {
+	if s.window <= 0 {
+		return 0
+	}
+	return float64(s.tokens) / float64(s.window) * 100
+}
+
+func (s *Simulator) contextLine() {
+	if s.Profile.ContextLine == "" {
+		return
+	}
+	left := max(0, 100-int(s.usedPercent()+0.5))
+	if left > s.Profile.ContextBelow {
+		return
+	}
+	s.println("")
+	s.println(strings.ReplaceAll(s.expand(s.Profile.ContextLine), "{left}", strconv.Itoa(left)))
+}
+
+func (s *Simulator) banner() {
+	for _, line := range s.Profile.Banner {
+		s.println(s.expand(line))
+	}
+	s.println("")
+}
+
+func (s *Simulator) reply(text string) {
+	for i, line := range strings.Split(text, "\n") {
+		if i == 0 {
+			line = s.Profile.ReplyPrefix + line
+		} else if s.Profile.ReplyPrefix != "" {
+			line = strings.Repeat(" ", len([]rune(s.Profile.ReplyPrefix))) + line
+		}
+		s.println(line)
+	}
+}
+
+// block prints command output.
+func (s *Simulator) block(lines []string) {
+	for _, line := range lines {
+		s.println(line)
+	}
+}
+
+func (s *Simulator) prompt() {
+	fmt.Fprint(s.Out, s.Profile.Prompt)
+}
+
+func (s *Simulator) println(line string) {
+	fmt.Fprintln(s.Out, line)
+}
+
+func (s *Simulator) expand(text string) string {
+	return strings.NewReplacer("{model}", s.model, "{dir}", s.Dir, "{resets}", s.resets()).Replace(text)
+}
+
+// cleanInput drops control characters, such as the Escape some agents are
+// sent before a command, that a cooked terminal passes through.
+func cleanInput(line string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, line)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
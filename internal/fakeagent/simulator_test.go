@@ -0,0 +1,335 @@
+package fakeagent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/agent"
+	ntmcontext "github.com/Dicklesworthstone/ntm/internal/context"
+	"github.com/Dicklesworthstone/ntm/internal/quota"
+	"github.com/Dicklesworthstone/ntm/internal/status"
+)
+
+// syncBuffer is an io.Writer safe to read while the simulator writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// session drives a simulator the way a terminal would.
+type session struct {
+	t    *testing.T
+	sim  *Simulator
+	in   *io.PipeWriter
+	out  *syncBuffer
+	intr chan struct{}
+	done chan struct{}
+	code int
+	err  error
+}
+
+func startSession(t *testing.T, agentType string, sc *Scenario, configure func(*Simulator)) *session {
+	t.Helper()
+	profile, err := ProfileFor(agentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc != nil {
+		if err := sc.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, w := io.Pipe()
+	s := &session{t: t, in: w, out: &syncBuffer{}, intr: make(chan struct{}), done: make(chan struct{})}
+	s.sim = &Simulator{Profile: profile, Scenario: sc, In: r, Out: s.out, Interrupts: s.intr, Dir: "/work", SpinnerInterval: 5 * time.Millisecond}
+	if configure != nil {
+		configure(s.sim)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer close(s.done)
+		s.code, s.err = s.sim.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		w.Close()
+		<-s.done
+	})
+	s.waitPrompts(1)
+	return s
+}
+
+func (s *session) prompts() int {
+	return strings.Count(s.out.String(), s.sim.Profile.Prompt)
+}
+
+func (s *session) waitPrompts(n int) {
+	s.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.prompts() < n {
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+		if time.Now().After(deadline) {
+			s.t.Fatalf("timed out waiting for prompt %d:\n%s", n, s.out.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// send submits a line and returns the output it produced.
+func (s *session) send(line string) string {
+	s.t.Helper()
+	before := len(s.out.String())
+	n := s.prompts()
+	fmt.Fprintln(s.in, line)
+	s.waitPrompts(n + 1)
+	return s.out.String()[before:]
+}
+
+func (s *session) interrupt() {
+	s.intr <- struct{}{}
+}
+
+func (s *session) wait() int {
+	s.t.Helper()
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		s.t.Fatalf("simulator did not exit:\n%s", s.out.String())
+	}
+	if s.err != nil {
+		s.t.Fatalf("Run: %v", s.err)
+	}
+	return s.code
+}
+
+func TestSimulatorClaudeScenario(t *testing.T) {
+	sc := &Scenario{
+		Usage:   Usage{Session: 42, Weekly: 71, Resets: "in 3 hours"},
+		Account: Account{Email: "dev@example.com", Plan: "Max"},
+		Context: Context{Window: 1000, Tokens: 100},
+		Steps: []Step{
+			{Match: "hello", Work: "30ms", Output: []string{"Reading main.go"}, Reply: "Hi there\nsecond line", Tokens: 600},
+			{Reply: "near the end", Tokens: 150},
+			{Event: EventRateLimit, Usage: &Usage{Session: 100}},
+		},
+	}
+	s := startSession(t, "claude", sc, nil)
+	if out := s.out.String(); !strings.Contains(out, "Welcome to Claude Code") || !strings.Contains(out, "/work") {
+		t.Errorf("banner = %q", out)
+	}
+
+	// A prompt that does not match the next step gets the default reply.
+	if out := s.send("unrelated"); !strings.Contains(out, "⏺ "+DefaultReply) {
+		t.Errorf("unmatched prompt output = %q", out)
+	}
+	out := s.send("hello")
+	for _, want := range []string{"Reading main.go", "esc to interrupt", "⏺ Hi there\n  second line"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("turn output missing %q: %q", want, out)
+		}
+	}
+	if strings.Contains(out, "Context left") {
+		t.Errorf("context line shown at 70%% used: %q", out)
+	}
+	if out := s.send("keep going"); !strings.Contains(out, "Context left until auto-compact: 15%") {
+		t.Errorf("context line missing at 85%% used: %q", out)
+	}
+
+	out = s.send("one more")
+	state, _ := agent.NewParser().ParseWithHint(out, agent.AgentTypeClaudeCode)
+	if !state.IsRateLimited {
+		t.Errorf("rate limit banner not detected: %q", out)
+	}
+	if out := s.send("are you there?"); !strings.Contains(out, "You've hit your limit · resets in 3 hours") {
+		t.Errorf("limited agent answered: %q", out)
+	}
+
+	q := quota.ParseClaudeUsageString(s.send("/usage") + s.send("/status"))
+	if q.SessionUsage != 100 || q.WeeklyUsage != 71 || !q.IsLimited || q.ResetTime != "in 3 hours" {
+		t.Errorf("usage screen parsed as %+v", q)
+	}
+	if q.AccountEmail != "dev@example.com" || q.Organization != "Fake Org" {
+		t.Errorf("status screen parsed as %+v", q)
+	}
+
+	if out := s.send("/compact"); !status.HasCompaction(out, "cc") {
+		t.Errorf("compaction not detected: %q", out)
+	}
+
+	// Claude quits on the second Ctrl+C in a row.
+	n := s.prompts()
+	s.interrupt()
+	s.waitPrompts(n + 1)
+	if !strings.Contains(s.out.String(), "Press Ctrl-C again to exit") {
+		t.Errorf("missing exit hint:\n%s", s.out.String())
+	}
+	s.interrupt()
+	if code := s.wait(); code != 0 {
+		t.Errorf("exit code = %d", code)
+	}
+}
+
+func TestSimulatorCodexAndGemini(t *testing.T) {
+	sc := &Scenario{
+		Context: Context{Window: 1000, PerTurn: 250, AutoCompact: 60, Compacted: 50},
+		Usage:   Usage{Session: 12, Weekly: 30},
+	}
+
+	cod := startSession(t, "cod", sc, nil)
+	out := cod.send("first")
+	state, _ := agent.NewParser().ParseWithHint(out, agent.AgentTypeCodex)
+	if state.ContextRemaining == nil || *state.ContextRemaining != 75 || !state.IsIdle {
+		t.Errorf("codex state = %+v from %q", state, out)
+	}
+	cod.send("second")
+	if out := cod.send("third"); !status.HasCompaction(out, "cod") {
+		t.Errorf("auto-compaction at 75%% not shown: %q", out)
+	}
+	if out := cod.send("/usage"); !strings.Contains(out, "Usage: 12%") || !strings.Contains(out, "Weekly limit: 30%") {
+		t.Errorf("codex usage = %q", out)
+	}
+	fmt.Fprintln(cod.in, "/exit")
+	if code := cod.wait(); code != 0 || !strings.Contains(cod.out.String(), "Token usage: total=") {
+		t.Errorf("codex exit = %d:\n%s", code, cod.out.String())
+	}
+
+	gmi := startSession(t, "gemini", sc, nil)
+	if out := gmi.send("/auth status"); !strings.Contains(out, "Account: fake-agent@example.com") || !strings.Contains(out, "Daily usage: 12%") {
+		t.Errorf("gemini auth status = %q", out)
+	}
+	if out := gmi.send("hi"); !strings.Contains(out, "✦ Done.") || !strings.Contains(out, "(75% context left)") {
+		t.Errorf("gemini turn = %q", out)
+	}
+	// The adapter's exit: Escape, then /exit.
+	fmt.Fprintln(gmi.in, "\x1b/exit")
+	if code := gmi.wait(); code != 0 {
+		t.Errorf("gemini exit = %d", code)
+	}
+}
+
+func TestSimulatorInterruptAndCrash(t *testing.T) {
+	sc := &Scenario{Steps: []Step{
+		{Work: "10s", Reply: "never shown"},
+		{Event: EventCrash, Reply: "panic: simulated crash"},
+	}}
+	s := startSession(t, "cc", sc, nil)
+	n := s.prompts()
+	fmt.Fprintln(s.in, "long task")
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(s.out.String(), "Thinking") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.interrupt()
+	s.waitPrompts(n + 1)
+	if out := s.out.String(); !strings.Contains(out, "Interrupted by user") || strings.Contains(out, "never shown") {
+		t.Errorf("interrupted turn output:\n%s", out)
+	}
+
+	fmt.Fprintln(s.in, "next")
+	if code := s.wait(); code != 1 || !strings.Contains(s.out.String(), "panic: simulated crash") {
+		t.Errorf("crash exit = %d:\n%s", code, s.out.String())
+	}
+}
+
+func TestSimulatorPasteAndTypeAhead(t *testing.T) {
+	sc := &Scenario{Steps: []Step{
+		{Match: "line one", Reply: "pasted"},
+		{Work: "200ms", Reply: "first"},
+		{Reply: "second"},
+		{Reply: "third"},
+	}}
+	s := startSession(t, "cc", sc, nil)
+
+	// Lines written together are one pasted prompt.
+	if out := s.send("line one\nline two"); !strings.Contains(out, "⏺ pasted") || strings.Contains(out, "second") {
+		t.Errorf("paste output = %q", out)
+	}
+
+	// Lines typed while the agent works are answered one by one afterwards.
+	n := s.prompts()
+	fmt.Fprintln(s.in, "start")
+	for _, line := range []string{"queued", "queued again"} {
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprintln(s.in, line)
+	}
+	s.waitPrompts(n + 3)
+	if out := s.out.String(); !strings.Contains(out, "⏺ second") || !strings.Contains(out, "⏺ third") {
+		t.Errorf("type-ahead output:\n%s", out)
+	}
+}
+
+func TestSimulatorStateAndTranscript(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.json")
+	sc := &Scenario{
+		Context: Context{Tokens: 1000},
+		Steps: []Step{
+			{On: OnStart, Event: EventRateLimit},
+			{Reply: "back to work", Tokens: 150000},
+		},
+	}
+
+	// The first launch starts rate limited and is respawned.
+	first := startSession(t, "cc", sc, func(sim *Simulator) { sim.StatePath = statePath })
+	if out := first.out.String(); !strings.Contains(out, "You've hit your limit") {
+		t.Fatalf("start step did not run:\n%s", out)
+	}
+	first.interrupt()
+	first.interrupt()
+	first.wait()
+
+	// The respawned agent skips the consumed step.
+	for _, tc := range []struct {
+		agentType string
+		file      string
+	}{
+		{"cc", "session.jsonl"},
+		{"cod", "rollout-fake.jsonl"},
+		{"gmi", "session-fake.json"},
+	} {
+		t.Run(tc.agentType, func(t *testing.T) {
+			if err := os.WriteFile(statePath, []byte(`{"step":1}`), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			transcript := filepath.Join(dir, tc.agentType, tc.file)
+			s := startSession(t, tc.agentType, sc, func(sim *Simulator) {
+				sim.StatePath = statePath
+				sim.TranscriptPath = transcript
+			})
+			if out := s.send("continue"); !strings.Contains(out, "back to work") {
+				t.Errorf("respawned agent output = %q", out)
+			}
+
+			usage, err := ntmcontext.NewTranscriptTailer(transcript, ntmcontext.TranscriptFormatFor(tc.agentType, transcript)).Poll()
+			if err != nil || usage == nil {
+				t.Fatalf("Poll = %v, %v", usage, err)
+			}
+			if usage.Turns != 1 || usage.ContextTokens != 151000 || usage.Model != s.sim.Profile.Model {
+				t.Errorf("transcript usage = %+v", usage)
+			}
+		})
+	}
+}
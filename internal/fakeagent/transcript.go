@@ -0,0 +1,118 @@
+package fakeagent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	ntmcontext "github.com/Dicklesworthstone/ntm/internal/context"
+)
+
+// transcriptWriter records each simulated turn's token usage in the agent's
+// native transcript format, so context monitoring reads exact usage from it
+// as it does for the real CLIs.
+type transcriptWriter struct {
+	path   string
+	format ntmcontext.TranscriptFormat
+	turns  int
+
+	codexInput, codexOutput int64 // Running totals Codex reports
+	gemini                  []map[string]any
+}
+
+func newTranscriptWriter(path, agentType string) (*transcriptWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating transcript directory: %w", err)
+	}
+	return &transcriptWriter{path: path, format: ntmcontext.TranscriptFormatFor(agentType, path)}, nil
+}
+
+// turn records a turn that left contextTokens in the context window, of
+// which outputTokens were the reply text.
+func (w *transcriptWriter) turn(model string, window, contextTokens, outputTokens int64, text string) error {
+	w.turns++
+	now := time.Now().UTC()
+	input := contextTokens - outputTokens
+
+	switch w.format {
+	case ntmcontext.TranscriptGemini:
+		w.gemini = append(w.gemini, map[string]any{
+			"type":      "gemini",
+			"model":     model,
+			"timestamp": now,
+			"tokens":    map[string]int64{"input": input, "output": outputTokens},
+		})
+		data, err := json.Marshal(map[string]any{"sessionId": "fake-agent", "messages": w.gemini})
+		if err != nil {
+			return err
+		}
+		// Gemini rewrites the whole file each turn.
+		tmp := w.path + ".tmp"
+		if err := os.WriteFile(tmp, data, 0o644); err != nil {
+			return err
+		}
+		return os.Rename(tmp, w.path)
+
+	case ntmcontext.TranscriptCodex:
+		var lines []any
+		if w.turns == 1 {
+			lines = append(lines, map[string]any{
+				"timestamp": now,
+				"type":      "turn_context",
+				"payload":   map[string]any{"model": model},
+			})
+		}
+		w.codexInput += input
+		w.codexOutput += outputTokens
+		lines = append(lines, map[string]any{
+			"timestamp": now,
+			"type":      "event_msg",
+			"payload": map[string]any{
+				"type": "token_count",
+				"info": map[string]any{
+					"total_token_usage":    map[string]int64{"input_tokens": w.codexInput, "output_tokens": w.codexOutput},
+					"last_token_usage":     map[string]int64{"input_tokens": input, "output_tokens": outputTokens},
+					"model_context_window": window,
+				},
+			},
+		})
+		return w.append(lines...)
+
+	default:
+		entry := map[string]any{
+			"type":      "assistant",
+			"timestamp": now,
+			"message": map[string]any{
+				"id":      fmt.Sprintf("msg_fake_%d", w.turns),
+				"role":    "assistant",
+				"model":   model,
+				"content": []map[string]string{{"type": "text", "text": text}},
+				"usage":   map[string]int64{"input_tokens": input, "output_tokens": outputTokens},
+			},
+		}
+		if text != "" {
+			// The handoff generator reads the reply from flattened entries.
+			entry["role"] = "assistant"
+			entry["content"] = text
+		}
+		return w.append(entry)
+	}
+}
+
+// append writes entries as JSON lines.
+func (w *transcriptWriter) append(entries ...any) error {
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
@@ -204,7 +204,7 @@ func checkAgent(ctx context.Context, pa tmux.PaneActivity) AgentHealth {
 	issues := detectErrors(output)
 
 	// Check for rate limit and parse wait time (including exit code detection)
-	detection := ratelimit.DetectRateLimit(output)
+	detection := ratelimit.DetectRateLimitForAgent(output, string(pa.Pane.Type))
 	if detection.RateLimited {
 		if !hasRateLimitIssue(issues) {
 			issues = append(issues, Issue{Type: "rate_limit", Message: "Rate limit detected"})
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"sync/atomic"
 	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/delivery"
 )
 
 // idCounter provides uniqueness fallback if crypto/rand fails
@@ -32,12 +34,15 @@ type HistoryEntry struct {
 	Success    bool      `json:"success"`               // Whether send succeeded
 	Error      string    `json:"error,omitempty"`       // Error message if failed
 	DurationMs int       `json:"duration_ms,omitempty"` // How long the operation took
+
+	// Delivery records how the prompt reached each target pane
+	Delivery []delivery.Result `json:"delivery,omitempty"`
 }
 
 // NewEntry creates a new history entry with generated ID and timestamp.
 func NewEntry(session string, targets []string, prompt string, source Source) *HistoryEntry {
 	return &HistoryEntry{
-		ID:        newID(),
+		ID:        NewID(),
 		Timestamp: time.Now().UTC(),
 		Session:   session,
 		Targets:   targets,
@@ -59,10 +64,11 @@ func (e *HistoryEntry) SetError(err error) {
 	}
 }
 
-// newID generates a unique, sortable ID.
+// NewID generates a unique, sortable entry ID, for callers that need an
+// entry's ID before creating it.
 // Format: timestamp (ms) + random suffix for uniqueness.
 // Falls back to an atomic counter if crypto/rand fails.
-func newID() string {
+func NewID() string {
 	ms := time.Now().UnixMilli()
 	b := make([]byte, 4)
 	if _, err := rand.Read(b); err != nil {
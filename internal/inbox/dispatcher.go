@@ -0,0 +1,324 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/adapter"
+	"github.com/Dicklesworthstone/ntm/internal/agent"
+	"github.com/Dicklesworthstone/ntm/internal/delivery"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/status"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+const (
+	// DefaultInterval is how often a dispatcher checks the queued panes.
+	DefaultInterval = 2 * time.Second
+
+	// DefaultQuiet is how long a pane must be idle without output before a
+	// queued prompt is delivered to it.
+	DefaultQuiet = 5 * time.Second
+
+	// DefaultMaxAttempts is how many times a failed delivery is tried.
+	DefaultMaxAttempts = 3
+
+	// staleClaimAge is how long a prompt may stay claimed before it is
+	// assumed its dispatcher exited mid-delivery.
+	staleClaimAge = 2 * time.Minute
+)
+
+// ErrUndelivered is returned by Send when the prompt reached the pane but the
+// agent showed no sign of accepting it. Such prompts are not retried, since
+// retrying would paste them a second time.
+var ErrUndelivered = errors.New("prompt not accepted by agent")
+
+// Dispatcher delivers queued prompts to idle agents.
+type Dispatcher struct {
+	Store *state.Store
+
+	Interval    time.Duration // Default DefaultInterval
+	Quiet       time.Duration // Default DefaultQuiet
+	MaxAttempts int           // Default DefaultMaxAttempts
+
+	// Delivery is delivery.MethodKeys (the default) or delivery.MethodPaste,
+	// as for ntm send --delivery. DeliveryAttempts is passed to paste delivery.
+	Delivery         string
+	DeliveryAttempts int
+
+	// Tests replace these; nil uses tmux and the status detector.
+	ListPanes func(session string) ([]tmux.Pane, error)
+	Detect    func(paneID string) (status.AgentStatus, error)
+	Send      func(ctx context.Context, pane tmux.Pane, prompt string) error
+	Now       func() time.Time
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time // Keyed by session and pane index
+}
+
+// NewDispatcher returns a dispatcher for the queues in store.
+func NewDispatcher(store *state.Store) *Dispatcher {
+	return &Dispatcher{Store: store}
+}
+
+// Run dispatches queued prompts every Interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	interval := d.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("prompt queue dispatch error", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce delivers the next prompt to each queued pane that is ready
+// for it and returns the prompts delivered.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) ([]state.QueuedPrompt, error) {
+	if _, err := d.Store.RequeueStalePrompts(d.now().Add(-staleClaimAge)); err != nil {
+		return nil, err
+	}
+	queued, err := d.Store.ListQueuedPrompts("", -1, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Panes with a prompt already being delivered are skipped by the store
+	// when the next prompt is claimed.
+	pending := make(map[string]map[int]bool)
+	var sessions []string
+	for _, p := range queued {
+		if pending[p.Session] == nil {
+			pending[p.Session] = make(map[int]bool)
+			sessions = append(sessions, p.Session)
+		}
+		if p.Status == state.QueuedPending {
+			pending[p.Session][p.Pane] = true
+		}
+	}
+
+	var delivered []state.QueuedPrompt
+	for _, session := range sessions {
+		panes, err := d.listPanes(session)
+		if err != nil {
+			// The session may come back; its prompts stay queued.
+			continue
+		}
+		for _, pane := range panes {
+			if ctx.Err() != nil {
+				return delivered, ctx.Err()
+			}
+			if !pending[session][pane.Index] {
+				continue
+			}
+			p, err := d.dispatchPane(ctx, session, pane)
+			if err != nil {
+				slog.Warn("queued prompt delivery failed", "session", session, "pane", pane.Index, "error", err)
+				continue
+			}
+			if p != nil {
+				delivered = append(delivered, *p)
+			}
+		}
+	}
+	return delivered, nil
+}
+
+// DispatchPane delivers the next prompt queued for pane in session if the
+// pane is ready for it. It returns the prompt delivered, or nil.
+func (d *Dispatcher) DispatchPane(ctx context.Context, session string, pane int) (*state.QueuedPrompt, error) {
+	panes, err := d.listPanes(session)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range panes {
+		if p.Index == pane {
+			return d.dispatchPane(ctx, session, p)
+		}
+	}
+	return nil, nil
+}
+
+func (d *Dispatcher) dispatchPane(ctx context.Context, session string, pane tmux.Pane) (*state.QueuedPrompt, error) {
+	next, err := d.Store.NextQueuedPrompt(session, pane.Index)
+	if err != nil || next == nil || !d.ready(session, pane) {
+		return nil, err
+	}
+	claimed, err := d.Store.ClaimQueuedPrompt(next.ID)
+	if err != nil || !claimed {
+		return nil, err
+	}
+	next.Attempts++
+
+	sendErr := d.send(ctx, pane, next.Prompt)
+	d.markSent(session, pane.Index)
+	if sendErr != nil {
+		outcome := state.QueuedPending
+		if next.Attempts >= d.maxAttempts() || errors.Is(sendErr, ErrUndelivered) {
+			outcome = state.QueuedFailed
+		}
+		if err := d.Store.FinishQueuedPrompt(next.ID, outcome, sendErr.Error()); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("deliver %s: %w", next.ID, sendErr)
+	}
+	if err := d.Store.FinishQueuedPrompt(next.ID, state.QueuedDelivered, ""); err != nil {
+		return nil, err
+	}
+	next.Status = state.QueuedDelivered
+	return next, nil
+}
+
+// ready reports whether pane's agent is idle and has been quiet long enough,
+// both in its own output and since the last prompt delivered to it.
+func (d *Dispatcher) ready(session string, pane tmux.Pane) bool {
+	quiet := d.Quiet
+	if quiet <= 0 {
+		quiet = DefaultQuiet
+	}
+	now := d.now()
+
+	d.mu.Lock()
+	last := d.lastSent[paneKey(session, pane.Index)]
+	d.mu.Unlock()
+	if now.Sub(last) < quiet {
+		return false
+	}
+
+	st, err := d.detect(pane.ID)
+	if err != nil || st.State != status.StateIdle {
+		return false
+	}
+	return now.Sub(st.LastActive) >= quiet
+}
+
+func (d *Dispatcher) markSent(session string, pane int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.lastSent == nil {
+		d.lastSent = make(map[string]time.Time)
+	}
+	d.lastSent[paneKey(session, pane)] = d.now()
+}
+
+// Wait blocks until the queued prompt id is delivered, fails or is
+// cancelled. While it waits it dispatches the prompt's pane itself, so it
+// does not depend on a dispatcher running elsewhere.
+func (d *Dispatcher) Wait(ctx context.Context, id string) (*state.QueuedPrompt, error) {
+	p, _, err := d.WaitCapture(ctx, id, nil)
+	return p, err
+}
+
+// WaitCapture is Wait for callers that need the pane's output from just
+// before the prompt reached it. capture is called on every poll, and the
+// last result taken while the prompt was still pending is returned along
+// with the prompt. Since a prompt is claimed before it is sent, that output
+// never includes the prompt or the agent's response to it, whichever
+// dispatcher delivers it.
+func (d *Dispatcher) WaitCapture(ctx context.Context, id string, capture func() string) (*state.QueuedPrompt, string, error) {
+	interval := d.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	var before string
+	for {
+		var snapshot string
+		if capture != nil {
+			snapshot = capture()
+		}
+		p, err := d.Store.GetQueuedPrompt(id)
+		if err != nil {
+			return nil, before, err
+		}
+		if p == nil {
+			return nil, before, fmt.Errorf("queued prompt not found: %s", id)
+		}
+		if p.Status.IsTerminal() {
+			return p, before, nil
+		}
+		if p.Status == state.QueuedPending {
+			before = snapshot
+			if _, err := d.DispatchPane(ctx, p.Session, p.Pane); err != nil {
+				slog.Warn("queued prompt delivery failed", "id", id, "error", err)
+			}
+			if p, err = d.Store.GetQueuedPrompt(id); err == nil && p != nil && p.Status.IsTerminal() {
+				return p, before, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, before, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (d *Dispatcher) maxAttempts() int {
+	if d.MaxAttempts > 0 {
+		return d.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
+
+func (d *Dispatcher) now() time.Time {
+	if d.Now != nil {
+		return d.Now()
+	}
+	return time.Now()
+}
+
+func (d *Dispatcher) listPanes(session string) ([]tmux.Pane, error) {
+	if d.ListPanes != nil {
+		return d.ListPanes(session)
+	}
+	return tmux.GetPanes(session)
+}
+
+func (d *Dispatcher) detect(paneID string) (status.AgentStatus, error) {
+	if d.Detect != nil {
+		return d.Detect(paneID)
+	}
+	return status.NewDetector().Detect(paneID)
+}
+
+// send delivers prompt with the configured method. User panes are always
+// typed into.
+func (d *Dispatcher) send(ctx context.Context, pane tmux.Pane, prompt string) error {
+	if d.Send != nil {
+		return d.Send(ctx, pane, prompt)
+	}
+	if pane.Type == tmux.AgentUser {
+		return tmux.PasteKeys(pane.ID, prompt, true)
+	}
+	if d.Delivery == delivery.MethodPaste {
+		res := delivery.ToPane(ctx, pane.ID, pane.Type, prompt, delivery.Options{Attempts: d.DeliveryAttempts})
+		switch res.Outcome {
+		case delivery.OutcomeDelivered:
+			return nil
+		case delivery.OutcomeUndelivered:
+			return fmt.Errorf("%w: %s", ErrUndelivered, res.Error)
+		default:
+			return errors.New(res.Error)
+		}
+	}
+	return adapter.Get(agent.AgentType(pane.Type)).SendPrompt(pane.ID, prompt)
+}
+
+func paneKey(session string, pane int) string {
+	return fmt.Sprintf("%s:%d", session, pane)
+}
@@ -0,0 +1,229 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/status"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+type fakeSession struct {
+	now    time.Time
+	states map[string]status.AgentState // Keyed by pane ID
+	sent   []string
+	err    error
+}
+
+func newTestDispatcher(t *testing.T, fs *fakeSession) *Dispatcher {
+	t.Helper()
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDispatcher(store)
+	d.Interval = time.Millisecond
+	d.Now = func() time.Time { return fs.now }
+	d.ListPanes = func(session string) ([]tmux.Pane, error) {
+		return []tmux.Pane{
+			{ID: "%1", Index: 1, Type: tmux.AgentClaude},
+			{ID: "%2", Index: 2, Type: tmux.AgentCodex},
+		}, nil
+	}
+	d.Detect = func(paneID string) (status.AgentStatus, error) {
+		return status.AgentStatus{PaneID: paneID, State: fs.states[paneID], LastActive: fs.now.Add(-time.Minute)}, nil
+	}
+	d.Send = func(ctx context.Context, pane tmux.Pane, prompt string) error {
+		if fs.err != nil {
+			return fs.err
+		}
+		fs.sent = append(fs.sent, pane.ID+" "+prompt)
+		return nil
+	}
+	return d
+}
+
+func TestDispatcher_DeliversWhenIdle(t *testing.T) {
+	fs := &fakeSession{
+		now:    time.Now(),
+		states: map[string]status.AgentState{"%1": status.StateWorking, "%2": status.StateIdle},
+	}
+	d := newTestDispatcher(t, fs)
+	ctx := context.Background()
+
+	for _, q := range []struct {
+		pane   int
+		prompt string
+	}{{1, "a"}, {2, "b"}, {2, "c"}} {
+		if _, err := Enqueue(d.Store, "proj", q.pane, q.prompt, SourceSend); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Enqueue(d.Store, "proj", 2, "  ", SourceSend); err == nil {
+		t.Error("Enqueue accepted an empty prompt")
+	}
+
+	delivered, err := d.DispatchOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(delivered) != 1 || len(fs.sent) != 1 || fs.sent[0] != "%2 b" {
+		t.Fatalf("first dispatch sent %v, delivered %+v", fs.sent, delivered)
+	}
+
+	// The idle pane gets its next prompt only after a quiet period, and the
+	// working pane keeps its prompt queued.
+	d.DispatchOnce(ctx)
+	if len(fs.sent) != 1 {
+		t.Fatalf("sent %v before the quiet period passed", fs.sent)
+	}
+	fs.now = fs.now.Add(DefaultQuiet)
+	d.DispatchOnce(ctx)
+	if len(fs.sent) != 2 || fs.sent[1] != "%2 c" {
+		t.Fatalf("sent %v, want c delivered to %%2", fs.sent)
+	}
+
+	fs.states["%1"] = status.StateIdle
+	fs.now = fs.now.Add(DefaultQuiet)
+	d.DispatchOnce(ctx)
+	if len(fs.sent) != 3 || fs.sent[2] != "%1 a" {
+		t.Fatalf("sent %v, want a delivered to %%1", fs.sent)
+	}
+	if left, _ := d.Store.ListQueuedPrompts("proj", -1, false); len(left) != 0 {
+		t.Errorf("prompts left queued: %+v", left)
+	}
+}
+
+func TestDispatcher_Retries(t *testing.T) {
+	fs := &fakeSession{
+		now:    time.Now(),
+		states: map[string]status.AgentState{"%2": status.StateIdle},
+		err:    errors.New("pane gone"),
+	}
+	d := newTestDispatcher(t, fs)
+	d.MaxAttempts = 2
+	ctx := context.Background()
+
+	q, err := Enqueue(d.Store, "proj", 2, "hello", SourceSend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.DispatchOnce(ctx)
+	if p, _ := d.Store.GetQueuedPrompt(q.ID); p.Status != state.QueuedPending || p.Attempts != 1 || p.Error != "pane gone" {
+		t.Fatalf("after first failure = %+v", p)
+	}
+	fs.now = fs.now.Add(DefaultQuiet)
+	d.DispatchOnce(ctx)
+	if p, _ := d.Store.GetQueuedPrompt(q.ID); p.Status != state.QueuedFailed || p.Attempts != 2 {
+		t.Fatalf("after last attempt = %+v", p)
+	}
+
+	// An undelivered paste is not retried.
+	fs.err = ErrUndelivered
+	q, _ = Enqueue(d.Store, "proj", 2, "again", SourceSend)
+	fs.now = fs.now.Add(DefaultQuiet)
+	d.DispatchOnce(ctx)
+	if p, _ := d.Store.GetQueuedPrompt(q.ID); p.Status != state.QueuedFailed || p.Attempts != 1 {
+		t.Errorf("undelivered prompt = %+v", p)
+	}
+}
+
+func TestDispatcher_Wait(t *testing.T) {
+	fs := &fakeSession{
+		now:    time.Now(),
+		states: map[string]status.AgentState{"%1": status.StateIdle},
+	}
+	d := newTestDispatcher(t, fs)
+
+	q, err := Enqueue(d.Store, "proj", 1, "step", SourcePipeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.Wait(context.Background(), q.ID)
+	if err != nil || p.Status != state.QueuedDelivered || len(fs.sent) != 1 {
+		t.Fatalf("Wait() = %+v, %v; sent %v", p, err, fs.sent)
+	}
+
+	// A cancelled prompt ends the wait without being sent.
+	q, _ = Enqueue(d.Store, "proj", 1, "never", SourcePipeline)
+	if err := d.Store.CancelQueuedPrompt(q.ID); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := d.Wait(context.Background(), q.ID); err != nil || p.Status != state.QueuedCancelled || len(fs.sent) != 1 {
+		t.Errorf("Wait(cancelled) = %+v, %v; sent %v", p, err, fs.sent)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	fs.states["%1"] = status.StateWorking
+	q, _ = Enqueue(d.Store, "proj", 1, "busy", SourcePipeline)
+	if _, err := d.Wait(ctx, q.ID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait(busy) error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestDispatcher_SkipsPaneBeingDelivered(t *testing.T) {
+	fs := &fakeSession{
+		now:    time.Now(),
+		states: map[string]status.AgentState{"%2": status.StateIdle},
+	}
+	d := newTestDispatcher(t, fs)
+	ctx := context.Background()
+
+	first, err := Enqueue(d.Store, "proj", 2, "first", SourceSend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Enqueue(d.Store, "proj", 2, "second", SourceSend); err != nil {
+		t.Fatal(err)
+	}
+	// Another dispatcher is delivering the first prompt.
+	if ok, err := d.Store.ClaimQueuedPrompt(first.ID); err != nil || !ok {
+		t.Fatalf("ClaimQueuedPrompt() = %v, %v", ok, err)
+	}
+
+	if p, err := d.DispatchPane(ctx, "proj", 2); err != nil || p != nil {
+		t.Errorf("DispatchPane() = %+v, %v; want nothing delivered", p, err)
+	}
+	if delivered, err := d.DispatchOnce(ctx); err != nil || len(delivered) != 0 {
+		t.Errorf("DispatchOnce() = %+v, %v; want nothing delivered", delivered, err)
+	}
+	if len(fs.sent) != 0 {
+		t.Errorf("sent %v while another delivery was in flight", fs.sent)
+	}
+}
+
+func TestDispatcher_WaitCaptureBaseline(t *testing.T) {
+	fs := &fakeSession{
+		now:    time.Now(),
+		states: map[string]status.AgentState{"%1": status.StateIdle},
+	}
+	d := newTestDispatcher(t, fs)
+	output := "earlier response"
+	send := d.Send
+	d.Send = func(ctx context.Context, pane tmux.Pane, prompt string) error {
+		output += "\n> " + prompt + "\nworking on it"
+		return send(ctx, pane, prompt)
+	}
+
+	q, err := Enqueue(d.Store, "proj", 1, "step", SourcePipeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, before, err := d.WaitCapture(context.Background(), q.ID, func() string { return output })
+	if err != nil || p.Status != state.QueuedDelivered {
+		t.Fatalf("WaitCapture() = %+v, %v", p, err)
+	}
+	if before != "earlier response" {
+		t.Errorf("baseline = %q, want the output from before the prompt was sent", before)
+	}
+}
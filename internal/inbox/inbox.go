@@ -0,0 +1,72 @@
+// Package inbox holds prompts for busy agents and delivers them once the
+// agent is idle.
+//
+// Sending to an agent that is still generating either interrupts it or the
+// text is lost, so each pane has a persistent queue in the state store.
+// ntm send --queue, pipeline steps with queue: true, bulk assignment and the
+// REST API add prompts to it. A Dispatcher, run by ntm serve or ntm queue
+// run, watches the panes with queued prompts and delivers the head of each
+// queue once the pane's agent has been idle and quiet for a while, one
+// prompt per pane at a time.
+package inbox
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+// Sources recorded on queued prompts.
+const (
+	SourceSend       = "send"
+	SourcePipeline   = "pipeline"
+	SourceBulkAssign = "bulk_assign"
+	SourceAPI        = "api"
+)
+
+// OpenStore opens the default state store and applies its migrations.
+func OpenStore() (*state.Store, error) {
+	store, err := state.Open("")
+	if err != nil {
+		return nil, fmt.Errorf("open state store: %w", err)
+	}
+	if err := store.Migrate(); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("apply migrations: %w", err)
+	}
+	return store, nil
+}
+
+// Enqueue adds prompt to the end of the queue for pane in session.
+func Enqueue(store *state.Store, session string, pane int, prompt, source string) (*state.QueuedPrompt, error) {
+	if session == "" {
+		return nil, fmt.Errorf("session is required")
+	}
+	if pane < 0 {
+		return nil, fmt.Errorf("invalid pane index %d", pane)
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return nil, fmt.Errorf("prompt is empty")
+	}
+	p := &state.QueuedPrompt{
+		ID:      newID(),
+		Session: session,
+		Pane:    pane,
+		Prompt:  prompt,
+		Source:  source,
+	}
+	if err := store.EnqueuePrompt(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newID returns a short random ID that is easy to type in ntm queue commands.
+func newID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return "q-" + hex.EncodeToString(b)
+}
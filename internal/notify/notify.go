@@ -38,6 +38,7 @@ const (
 	EventSessionCreated EventType = "session.created"  // New session spawned
 	EventSessionKilled  EventType = "session.killed"   // Session terminated
 	EventHealthDegraded EventType = "health.degraded"  // Overall health dropped
+	EventAlertRule      EventType = "alert.rule"       // User-defined alert rule fired
 )
 
 // Event represents a notification event
@@ -342,6 +343,30 @@ func (n *Notifier) Notify(event Event) error {
 	return nil
 }
 
+// NotifyChannels sends event to the given channels regardless of the
+// configured event filter and routing. Channels that are not enabled are
+// reported as errors.
+func (n *Notifier) NotifyChannels(event Event, channels []string) error {
+	if !n.config.Enabled {
+		return nil
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	event = n.sanitizeEvent(event)
+
+	var errs []error
+	for _, ch := range channels {
+		if err := n.sendToChannel(ChannelName(ch), event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("notification errors: %v", errs)
+	}
+	return nil
+}
+
 func (n *Notifier) sanitizeEvent(event Event) Event {
 	if n.redactionCfg == nil || n.redactionCfg.Mode == redaction.ModeOff {
 		return event
@@ -350,6 +350,31 @@ func TestRoutingRules(t *testing.T) {
 	}
 }
 
+func TestNotifyChannels(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "test.log")
+
+	// alert.rule is not in Events, and routing would send it elsewhere.
+	n := New(Config{
+		Enabled: true,
+		Events:  []string{"agent.error"},
+		Routing: map[string][]string{"alert.rule": {"filebox"}},
+		Log:     LogConfig{Enabled: true, Path: logPath},
+	})
+
+	if err := n.NotifyChannels(Event{Type: EventAlertRule, Message: "Rule fired"}, []string{"log"}); err != nil {
+		t.Fatalf("NotifyChannels failed: %v", err)
+	}
+	logContent, _ := os.ReadFile(logPath)
+	if !contains(string(logContent), "Rule fired") {
+		t.Error("Log should contain rule event")
+	}
+
+	if err := n.NotifyChannels(Event{Type: EventAlertRule, Message: "x"}, []string{"desktop"}); err == nil {
+		t.Error("NotifyChannels to a disabled channel should fail")
+	}
+}
+
 func TestPrimaryFallback(t *testing.T) {
 	tmpDir := t.TempDir()
 	inboxPath := filepath.Join(tmpDir, "inbox")
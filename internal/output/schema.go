@@ -159,13 +159,15 @@ type SendResponse struct {
 // ListResponse is the output format for list command
 type ListResponse struct {
 	TimestampedResponse
-	Sessions []SessionListItem `json:"sessions"`
-	Count    int               `json:"count"`
+	Sessions   []SessionListItem `json:"sessions"`
+	Count      int               `json:"count"`
+	HostErrors map[string]string `json:"host_errors,omitempty"` // Remote hosts that could not be listed
 }
 
 // SessionListItem is a single session in list output
 type SessionListItem struct {
 	Name             string               `json:"name"`
+	Host             string               `json:"host,omitempty"` // Remote host from the [hosts] inventory`
 	BaseProject      string               `json:"base_project"`
 	Label            string               `json:"label,omitempty"`
 	Windows          int                  `json:"windows"`
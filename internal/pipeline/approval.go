@@ -0,0 +1,310 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/approval"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+// DefaultApprovalAction is the approval action recorded for pipeline gates.
+const DefaultApprovalAction = "pipeline_gate"
+
+// approvalPollInterval is how often a gate re-checks its request. Decisions
+// made from another process (ntm approve) are only seen by polling.
+const approvalPollInterval = 2 * time.Second
+
+// SetApprovalEngine sets the engine used by approval steps. When unset, an
+// engine backed by the default state store is opened for each gate.
+func (e *Executor) SetApprovalEngine(engine *approval.Engine) {
+	e.approvals = engine
+}
+
+// approvalEngine returns the engine for a gate and a func that releases it.
+func (e *Executor) approvalEngine() (*approval.Engine, func(), error) {
+	if e.approvals != nil {
+		return e.approvals, func() {}, nil
+	}
+
+	store, err := state.Open("")
+	if err != nil {
+		return nil, nil, fmt.Errorf("open state store: %w", err)
+	}
+	if err := store.Migrate(); err != nil {
+		store.Close()
+		return nil, nil, fmt.Errorf("apply migrations: %w", err)
+	}
+	return approval.New(store, nil, nil, approval.DefaultConfig()), func() { store.Close() }, nil
+}
+
+// executeApprovalStep requests human sign-off and blocks until the request is
+// approved, denied, or expires. While waiting, the run is persisted as paused
+// so an interrupted run can be resumed against the same request.
+func (e *Executor) executeApprovalStep(ctx context.Context, step *Step, workflow *Workflow) StepResult {
+	result := StepResult{
+		StepID:    step.ID,
+		Status:    StatusRunning,
+		StartedAt: time.Now(),
+		AgentType: "human",
+	}
+
+	message := e.substituteVariables(step.Approval.Message)
+
+	if e.config.DryRun {
+		result.Status = StatusCompleted
+		result.Output = "[DRY RUN] Would request approval: " + truncatePrompt(message, 100)
+		result.FinishedAt = time.Now()
+		return result
+	}
+
+	engine, release, err := e.approvalEngine()
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = &StepError{
+			Type:      "approval",
+			Message:   fmt.Sprintf("approval engine unavailable: %v", err),
+			Timestamp: time.Now(),
+		}
+		result.FinishedAt = time.Now()
+		return result
+	}
+	defer release()
+
+	timeout := approval.DefaultExpiry
+	if step.Timeout.Duration > 0 {
+		timeout = step.Timeout.Duration
+	}
+
+	approvalID := e.takeResumeApproval(step.ID)
+	if approvalID == "" {
+		action := step.Approval.Action
+		if action == "" {
+			action = DefaultApprovalAction
+		}
+		resource := e.substituteVariables(step.Approval.Resource)
+		if resource == "" {
+			resource = workflow.Name + "/" + step.ID
+		}
+
+		appr, err := engine.Request(ctx, approval.RequestParams{
+			Action:        action,
+			Resource:      resource,
+			Reason:        message,
+			RequestedBy:   "pipeline:" + e.state.RunID,
+			CorrelationID: e.state.RunID,
+			RequiresSLB:   step.Approval.SLB,
+			ExpiresIn:     timeout,
+		})
+		if err != nil {
+			result.Status = StatusFailed
+			result.Error = &StepError{
+				Type:      "approval",
+				Message:   fmt.Sprintf("failed to request approval: %v", err),
+				Timestamp: time.Now(),
+			}
+			result.FinishedAt = time.Now()
+			return result
+		}
+		approvalID = appr.ID
+	}
+	result.ApprovalID = approvalID
+
+	// Persist the paused gate so status views and resume can find it.
+	paused := result
+	paused.Status = StatusPaused
+	paused.Output = message
+	e.stateMu.Lock()
+	e.state.Steps[step.ID] = paused
+	e.state.Status = StatusPaused
+	e.state.UpdatedAt = time.Now()
+	e.stateMu.Unlock()
+	e.persistState()
+
+	e.emitProgress("step_approval", step.ID,
+		fmt.Sprintf("Waiting for approval: %s (ntm approve %s)", truncatePrompt(message, 80), approvalID),
+		e.calculateProgress())
+
+	appr, err := e.waitForDecision(ctx, engine, approvalID)
+
+	e.stateMu.Lock()
+	e.resumeUnlessPausedLocked(step.ID)
+	e.state.UpdatedAt = time.Now()
+	e.stateMu.Unlock()
+
+	result.FinishedAt = time.Now()
+	if err != nil {
+		if ctx.Err() != nil {
+			// Leave the request pending; resume will wait on it again.
+			result.Status = StatusPaused
+			result.Output = message
+			return result
+		}
+		result.Status = StatusFailed
+		result.Error = &StepError{
+			Type:      "approval",
+			Message:   fmt.Sprintf("failed to check approval %s: %v", approvalID, err),
+			Timestamp: time.Now(),
+		}
+		return result
+	}
+
+	switch appr.Status {
+	case state.ApprovalApproved:
+		result.Status = StatusCompleted
+		result.Output = fmt.Sprintf("approved by %s", appr.ApprovedBy)
+	case state.ApprovalDenied:
+		msg := fmt.Sprintf("approval denied by %s", appr.ApprovedBy)
+		if appr.DeniedReason != "" {
+			msg += ": " + appr.DeniedReason
+		}
+		result.Status = StatusFailed
+		result.Error = &StepError{
+			Type:      "approval_denied",
+			Message:   msg,
+			Details:   message,
+			Timestamp: time.Now(),
+		}
+	default:
+		result.Status = StatusFailed
+		result.Error = &StepError{
+			Type:      "timeout",
+			Message:   fmt.Sprintf("approval %s not granted within %s", approvalID, timeout),
+			Details:   message,
+			Timestamp: time.Now(),
+		}
+	}
+	return result
+}
+
+// takeResumeApproval returns, once, the pending request of a gate from the
+// run being resumed.
+func (e *Executor) takeResumeApproval(stepID string) string {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	id := e.resumeApprovals[stepID]
+	delete(e.resumeApprovals, stepID)
+	return id
+}
+
+// resumeUnlessPausedLocked marks the run as running again unless a step
+// other than stepID is still waiting at a gate. Callers hold e.stateMu.
+func (e *Executor) resumeUnlessPausedLocked(stepID string) {
+	for id, r := range e.state.Steps {
+		if id != stepID && r.Status == StatusPaused {
+			return
+		}
+	}
+	e.state.Status = StatusRunning
+}
+
+// waitForDecision polls an approval until it leaves the pending state.
+func (e *Executor) waitForDecision(ctx context.Context, engine *approval.Engine, id string) (*state.Approval, error) {
+	interval := e.approvalPoll
+	if interval <= 0 {
+		interval = approvalPollInterval
+	}
+
+	for {
+		appr, err := engine.Check(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if appr.Status != state.ApprovalPending {
+			return appr, nil
+		}
+
+		// WaitForApproval returns early for decisions made through this engine;
+		// otherwise it times out and we poll the store again.
+		wait := interval
+		if remaining := time.Until(appr.ExpiresAt); remaining < wait {
+			wait = remaining + 10*time.Millisecond
+		}
+		if _, err := engine.WaitForApproval(ctx, id, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// PendingGate describes an approval step that is waiting for a decision.
+type PendingGate struct {
+	RunID       string    `json:"run_id"`
+	WorkflowID  string    `json:"workflow_id"`
+	Session     string    `json:"session,omitempty"`
+	StepID      string    `json:"step_id"`
+	ApprovalID  string    `json:"approval_id"`
+	Message     string    `json:"message"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// PendingGates returns the gates a run is currently paused on.
+func PendingGates(st *ExecutionState) []PendingGate {
+	if st == nil {
+		return nil
+	}
+
+	var gates []PendingGate
+	for id, result := range st.Steps {
+		// Gates inside a sub-workflow are reported against the parent run
+		if result.Status == StatusPaused && result.Child != nil {
+			for _, gate := range PendingGates(result.Child) {
+				gate.RunID = st.RunID
+				gate.WorkflowID = st.WorkflowID
+				gate.Session = st.Session
+				gate.StepID = id + "/" + gate.StepID
+				gates = append(gates, gate)
+			}
+			continue
+		}
+		if result.Status != StatusPaused || result.ApprovalID == "" {
+			continue
+		}
+		gates = append(gates, PendingGate{
+			RunID:       st.RunID,
+			WorkflowID:  st.WorkflowID,
+			Session:     st.Session,
+			StepID:      id,
+			ApprovalID:  result.ApprovalID,
+			Message:     result.Output,
+			RequestedAt: result.StartedAt,
+		})
+	}
+	sort.Slice(gates, func(i, j int) bool {
+		return gates[i].StepID < gates[j].StepID
+	})
+	return gates
+}
+
+// ListPendingGates scans persisted pipeline state under projectDir for paused
+// approval gates, oldest first.
+func ListPendingGates(projectDir string) ([]PendingGate, error) {
+	entries, err := os.ReadDir(pipelineStateDir(projectDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read pipeline state dir: %w", err)
+	}
+
+	var gates []PendingGate
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		st, err := LoadState(projectDir, strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil || st.Status != StatusPaused {
+			continue
+		}
+		gates = append(gates, PendingGates(st)...)
+	}
+	sort.SliceStable(gates, func(i, j int) bool {
+		return gates[i].RequestedAt.Before(gates[j].RequestedAt)
+	})
+	return gates, nil
+}
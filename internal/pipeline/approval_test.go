@@ -0,0 +1,335 @@
+package pipeline
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/approval"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+func newApprovalTestStore(t *testing.T) *state.Store {
+	t.Helper()
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("state.Open() error: %v", err)
+	}
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("Migrate() error: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func newApprovalTestExecutor(t *testing.T, store *state.Store, projectDir string) *Executor {
+	t.Helper()
+	e := newRunTestExecutor(t)
+	if projectDir != "" {
+		e.config.ProjectDir = projectDir
+	}
+	e.SetApprovalEngine(approval.New(store, nil, nil, approval.DefaultConfig()))
+	e.approvalPoll = 20 * time.Millisecond
+	return e
+}
+
+func gatedWorkflow(onError ErrorAction, timeout time.Duration) *Workflow {
+	return &Workflow{
+		SchemaVersion: SchemaVersion,
+		Name:          "gated",
+		Settings:      DefaultWorkflowSettings(),
+		Steps: []Step{
+			{ID: "build", Run: &RunConfig{Command: "echo built"}},
+			{
+				ID:        "gate",
+				DependsOn: []string{"build"},
+				Approval:  &ApprovalConfig{Message: "Push ${vars.branch}?"},
+				Timeout:   Duration{Duration: timeout},
+				OnError:   onError,
+			},
+			{ID: "push", DependsOn: []string{"gate"}, Run: &RunConfig{Command: "echo pushed"}},
+		},
+	}
+}
+
+// waitForGate polls persisted state until the run is paused at a gate.
+func waitForGate(t *testing.T, projectDir string) PendingGate {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		gates, err := ListPendingGates(projectDir)
+		if err != nil {
+			t.Fatalf("ListPendingGates() error: %v", err)
+		}
+		if len(gates) > 0 {
+			return gates[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for pending gate")
+	return PendingGate{}
+}
+
+func TestParseString_ApprovalStep(t *testing.T) {
+	t.Parallel()
+
+	w, err := ParseString(`
+schema_version: "2.0"
+name: gate-test
+steps:
+  - id: gate
+    approval:
+      message: Merge worktrees into main?
+      slb: true
+    timeout: 2h
+`, "yaml")
+	if err != nil {
+		t.Fatalf("ParseString() error: %v", err)
+	}
+	gate := w.Steps[0]
+	if gate.Approval == nil || gate.Approval.Message != "Merge worktrees into main?" || !gate.Approval.SLB {
+		t.Errorf("approval = %+v", gate.Approval)
+	}
+	if res := Validate(w); !res.Valid {
+		t.Errorf("Validate() errors: %+v", res.Errors)
+	}
+
+	tests := []struct {
+		name string
+		step Step
+	}{
+		{"missing message", Step{ID: "a", Approval: &ApprovalConfig{}}},
+		{"approval with run", Step{ID: "a", Approval: &ApprovalConfig{Message: "ok?"}, Run: &RunConfig{Command: "true"}}},
+	}
+	for _, tt := range tests {
+		w := &Workflow{SchemaVersion: SchemaVersion, Name: "w", Steps: []Step{tt.step}}
+		if Validate(w).Valid {
+			t.Errorf("%s: expected validation error", tt.name)
+		}
+	}
+}
+
+func TestExecutor_ApprovalStep_PausesUntilApproved(t *testing.T) {
+	t.Parallel()
+
+	store := newApprovalTestStore(t)
+	e := newApprovalTestExecutor(t, store, "")
+	projectDir := e.config.ProjectDir
+
+	type runResult struct {
+		state *ExecutionState
+		err   error
+	}
+	done := make(chan runResult, 1)
+	go func() {
+		st, err := e.Run(context.Background(), gatedWorkflow("", 0), map[string]interface{}{"branch": "main"}, nil)
+		done <- runResult{st, err}
+	}()
+
+	gate := waitForGate(t, projectDir)
+	if gate.StepID != "gate" || gate.Message != "Push main?" {
+		t.Errorf("pending gate = %+v", gate)
+	}
+	persisted, err := LoadState(projectDir, gate.RunID)
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	if persisted.Status != StatusPaused {
+		t.Errorf("persisted status = %v, want paused", persisted.Status)
+	}
+
+	// Approve from a separate engine, as "ntm approve" would from another process.
+	other := approval.New(store, nil, nil, approval.DefaultConfig())
+	if err := other.Approve(context.Background(), gate.ApprovalID, "alice"); err != nil {
+		t.Fatalf("Approve() error: %v", err)
+	}
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("Run() error: %v", res.err)
+	}
+	if res.state.Status != StatusCompleted {
+		t.Errorf("status = %v, want completed", res.state.Status)
+	}
+	if got := res.state.Steps["gate"]; got.Status != StatusCompleted || got.Output != "approved by alice" {
+		t.Errorf("gate result = %+v", got)
+	}
+	if res.state.Steps["push"].Status != StatusCompleted {
+		t.Errorf("push status = %v, want completed", res.state.Steps["push"].Status)
+	}
+}
+
+func TestExecutor_ApprovalStep_Denied(t *testing.T) {
+	t.Parallel()
+
+	store := newApprovalTestStore(t)
+	e := newApprovalTestExecutor(t, store, "")
+
+	done := make(chan *ExecutionState, 1)
+	go func() {
+		st, _ := e.Run(context.Background(), gatedWorkflow("", 0), nil, nil)
+		done <- st
+	}()
+
+	gate := waitForGate(t, e.config.ProjectDir)
+	if err := e.approvals.Deny(context.Background(), gate.ApprovalID, "bob", "not today"); err != nil {
+		t.Fatalf("Deny() error: %v", err)
+	}
+
+	st := <-done
+	if st.Status != StatusFailed {
+		t.Errorf("status = %v, want failed", st.Status)
+	}
+	gateResult := st.Steps["gate"]
+	if gateResult.Error == nil || gateResult.Error.Type != "approval_denied" || !strings.Contains(gateResult.Error.Message, "not today") {
+		t.Errorf("gate error = %+v", gateResult.Error)
+	}
+	if _, ran := st.Steps["push"]; ran {
+		t.Error("push should not run after a denied gate")
+	}
+}
+
+func TestExecutor_ApprovalStep_TimeoutUsesOnError(t *testing.T) {
+	t.Parallel()
+
+	store := newApprovalTestStore(t)
+	e := newApprovalTestExecutor(t, store, "")
+
+	st, err := e.Run(context.Background(), gatedWorkflow(ErrorActionContinue, 100*time.Millisecond), nil, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if st.Status != StatusCompleted {
+		t.Errorf("status = %v, want completed (on_error: continue)", st.Status)
+	}
+	if gateResult := st.Steps["gate"]; gateResult.Error == nil || gateResult.Error.Type != "timeout" {
+		t.Errorf("gate result = %+v, want timeout error", gateResult)
+	}
+	if st.Steps["push"].Status != StatusSkipped {
+		t.Errorf("push status = %v, want skipped", st.Steps["push"].Status)
+	}
+}
+
+func TestExecutor_ApprovalStep_ResumeReusesRequest(t *testing.T) {
+	t.Parallel()
+
+	store := newApprovalTestStore(t)
+	e := newApprovalTestExecutor(t, store, "")
+	projectDir := e.config.ProjectDir
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan *ExecutionState, 1)
+	go func() {
+		st, _ := e.Run(ctx, gatedWorkflow("", 0), nil, nil)
+		done <- st
+	}()
+
+	gate := waitForGate(t, projectDir)
+	cancel() // simulate the process going away while paused
+	if st := <-done; st.Status != StatusPaused {
+		t.Fatalf("interrupted status = %v, want paused", st.Status)
+	}
+
+	prior, err := LoadState(projectDir, gate.RunID)
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	if prior.Status != StatusPaused {
+		t.Errorf("persisted status = %v, want paused", prior.Status)
+	}
+
+	// Approve before resuming; the resumed run must see the original request.
+	if err := e.approvals.Approve(context.Background(), gate.ApprovalID, "carol"); err != nil {
+		t.Fatalf("Approve() error: %v", err)
+	}
+
+	resumed := newApprovalTestExecutor(t, store, projectDir)
+	resumed.config.RunID = gate.RunID
+	st, err := resumed.Resume(context.Background(), gatedWorkflow("", 0), prior, nil)
+	if err != nil {
+		t.Fatalf("Resume() error: %v", err)
+	}
+	if st.Status != StatusCompleted {
+		t.Errorf("resumed status = %v, want completed", st.Status)
+	}
+	if got := st.Steps["gate"].ApprovalID; got != gate.ApprovalID {
+		t.Errorf("resumed gate approval = %q, want original %q", got, gate.ApprovalID)
+	}
+	pending, err := resumed.approvals.ListPending(context.Background())
+	if err != nil {
+		t.Fatalf("ListPending() error: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("resume created %d new approval requests", len(pending))
+	}
+}
+
+func TestExecutor_ApprovalStep_ParallelGatesStayPaused(t *testing.T) {
+	t.Parallel()
+
+	store := newApprovalTestStore(t)
+	e := newApprovalTestExecutor(t, store, "")
+	projectDir := e.config.ProjectDir
+	workflow := &Workflow{
+		SchemaVersion: SchemaVersion,
+		Name:          "two-gates",
+		Settings:      DefaultWorkflowSettings(),
+		Steps: []Step{{
+			ID: "gates",
+			Parallel: []Step{
+				{ID: "legal", Approval: &ApprovalConfig{Message: "Legal ok?"}},
+				{ID: "security", Approval: &ApprovalConfig{Message: "Security ok?"}},
+			},
+		}},
+	}
+
+	done := make(chan *ExecutionState, 1)
+	go func() {
+		st, _ := e.Run(context.Background(), workflow, nil, nil)
+		done <- st
+	}()
+
+	gates := make(map[string]PendingGate)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(gates) < 2 && time.Now().Before(deadline) {
+		pending, err := ListPendingGates(projectDir)
+		if err != nil {
+			t.Fatalf("ListPendingGates() error: %v", err)
+		}
+		for _, g := range pending {
+			gates[g.StepID] = g
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(gates) != 2 {
+		t.Fatalf("pending gates = %+v, want legal and security", gates)
+	}
+
+	other := approval.New(store, nil, nil, approval.DefaultConfig())
+	if err := other.Approve(context.Background(), gates["legal"].ApprovalID, "alice"); err != nil {
+		t.Fatalf("Approve() error: %v", err)
+	}
+	// The run stays paused while security is still waiting.
+	deadline = time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		e.stateMu.Lock()
+		legal, status := e.state.Steps["legal"].Status, e.state.Status
+		e.stateMu.Unlock()
+		if legal == StatusCompleted {
+			if status != StatusPaused {
+				t.Errorf("status after first approval = %v, want paused", status)
+			}
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := other.Approve(context.Background(), gates["security"].ApprovalID, "bob"); err != nil {
+		t.Fatalf("Approve() error: %v", err)
+	}
+	if st := <-done; st.Status != StatusCompleted {
+		t.Errorf("status = %v, want completed", st.Status)
+	}
+}
@@ -13,6 +13,10 @@ type DependencyGraph struct {
 	inDegree map[string]int      // step ID -> number of dependencies
 	executed map[string]bool     // step ID -> has been executed
 	failed   map[string]bool     // step ID -> has failed (for CONTINUE mode)
+	parent   map[string]string   // nested step ID -> enclosing parallel/loop step ID
+
+	root       *Workflow // workflow the graph was built from, for uses resolution
+	projectDir string    // project dir for resolving uses names
 }
 
 // DependencyError represents an error in the dependency graph
@@ -43,14 +47,19 @@ func NewDependencyGraph(workflow *Workflow) *DependencyGraph {
 		inDegree: make(map[string]int),
 		executed: make(map[string]bool),
 		failed:   make(map[string]bool),
+		parent:   make(map[string]string),
+		root:     workflow,
 	}
 
 	// Add all steps including parallel sub-steps
-	var addSteps func(steps []Step)
-	addSteps = func(steps []Step) {
+	var addSteps func(steps []Step, parentID string)
+	addSteps = func(steps []Step, parentID string) {
 		for i := range steps {
 			step := &steps[i]
 			g.steps[step.ID] = step
+			if parentID != "" {
+				g.parent[step.ID] = parentID
+			}
 			g.edges[step.ID] = step.DependsOn
 			g.inDegree[step.ID] = len(step.DependsOn)
 
@@ -61,17 +70,18 @@ func NewDependencyGraph(workflow *Workflow) *DependencyGraph {
 
 			// Handle parallel sub-steps
 			if len(step.Parallel) > 0 {
-				addSteps(step.Parallel)
+				addSteps(step.Parallel, step.ID)
 			}
 
 			// Handle loop sub-steps
 			if step.Loop != nil {
-				addSteps(step.Loop.Steps)
+				addSteps(step.Loop.Steps, step.ID)
 			}
 		}
 	}
 
-	addSteps(workflow.Steps)
+	// Matrix steps are planned as the parallel groups they expand into
+	addSteps(expandMatrix(workflow.Steps), "")
 	return g
 }
 
@@ -115,6 +125,9 @@ func (g *DependencyGraph) Validate() []DependencyError {
 		}
 	}
 
+	// Check that included workflows load and don't include each other
+	errors = append(errors, g.checkIncludes()...)
+
 	return errors
 }
 
@@ -272,6 +285,11 @@ func (g *DependencyGraph) GetReadySteps() []string {
 			continue
 		}
 
+		// Nested steps are run by their enclosing step
+		if parentID, ok := g.parent[id]; ok && !g.executed[parentID] {
+			continue
+		}
+
 		allDepsExecuted := true
 		for _, dep := range g.edges[id] {
 			if !g.executed[dep] {
@@ -348,6 +366,12 @@ func (g *DependencyGraph) GetFailedDependencies(id string) []string {
 	return failed
 }
 
+// ParentOf returns the enclosing parallel or loop step for a nested step.
+func (g *DependencyGraph) ParentOf(id string) (string, bool) {
+	parentID, ok := g.parent[id]
+	return parentID, ok
+}
+
 // GetStep returns a step by ID
 func (g *DependencyGraph) GetStep(id string) (*Step, bool) {
 	step, exists := g.steps[id]
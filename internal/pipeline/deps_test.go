@@ -1,6 +1,7 @@
 package pipeline
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -379,6 +380,54 @@ func TestDependencyGraph_ParallelSubsteps(t *testing.T) {
 	}
 }
 
+func TestDependencyGraph_NestedStepsWaitForParent(t *testing.T) {
+	t.Parallel()
+
+	w := &Workflow{
+		Steps: []Step{
+			{
+				ID: "group",
+				Parallel: []Step{
+					{ID: "p1", Prompt: "parallel 1"},
+				},
+			},
+			{
+				ID: "retry",
+				Loop: &LoopConfig{
+					Times: 2,
+					Steps: []Step{{ID: "attempt", Prompt: "try"}},
+				},
+			},
+		},
+	}
+
+	g := NewDependencyGraph(w)
+	if parent, ok := g.ParentOf("attempt"); !ok || parent != "retry" {
+		t.Errorf("ParentOf(attempt) = %q, %v, want retry", parent, ok)
+	}
+	if _, ok := g.ParentOf("group"); ok {
+		t.Error("top-level step should have no parent")
+	}
+
+	// Nested steps are run by their enclosing step, not on their own
+	ready := g.GetReadySteps()
+	if len(ready) != 2 || !slices.Contains(ready, "group") || !slices.Contains(ready, "retry") {
+		t.Errorf("GetReadySteps() = %v, want [group retry]", ready)
+	}
+
+	if err := g.MarkExecuted("group"); err != nil {
+		t.Fatalf("MarkExecuted(group) error: %v", err)
+	}
+	if ready := g.GetReadySteps(); !slices.Contains(ready, "p1") || slices.Contains(ready, "attempt") {
+		t.Errorf("GetReadySteps() after group = %v, want p1 but not attempt", ready)
+	}
+
+	res := Validate(&Workflow{SchemaVersion: SchemaVersion, Name: "w", Steps: w.Steps[1:]})
+	if !res.Valid {
+		t.Errorf("Validate() of a times loop errors: %+v", res.Errors)
+	}
+}
+
 func TestResolveWorkflow(t *testing.T) {
 	t.Parallel()
 
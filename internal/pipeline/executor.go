@@ -15,6 +15,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Dicklesworthstone/ntm/internal/approval"
+	"github.com/Dicklesworthstone/ntm/internal/inbox"
+	"github.com/Dicklesworthstone/ntm/internal/policy"
 	"github.com/Dicklesworthstone/ntm/internal/robot"
 	"github.com/Dicklesworthstone/ntm/internal/status"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
@@ -23,17 +26,22 @@ import (
 
 // ExecutorConfig configures the executor behavior
 type ExecutorConfig struct {
-	Session          string        // Required: tmux session name
-	ProjectDir       string        // Optional: project root for .ntm state
-	WorkflowFile     string        // Optional: workflow file path for state persistence
-	DefaultTimeout   time.Duration // Default step timeout (default: 5m)
-	GlobalTimeout    time.Duration // Maximum workflow runtime (default: 30m)
-	ProgressInterval time.Duration // Interval for progress updates (default: 1s)
-	DryRun           bool          // If true, validate but don't execute
-	Verbose          bool          // Enable verbose logging
-	RunID            string        // Optional: pre-generated run ID (if empty, one is generated)
+	Session          string          // Required: tmux session name
+	ProjectDir       string          // Optional: project root for .ntm state
+	WorkflowFile     string          // Optional: workflow file path for state persistence
+	DefaultTimeout   time.Duration   // Default step timeout (default: 5m)
+	GlobalTimeout    time.Duration   // Maximum workflow runtime (default: 30m)
+	ProgressInterval time.Duration   // Interval for progress updates (default: 1s)
+	DryRun           bool            // If true, validate but don't execute
+	Verbose          bool            // Enable verbose logging
+	RunID            string          // Optional: pre-generated run ID (if empty, one is generated)
+	BudgetCheck      BudgetCheckFunc // Optional: consulted before each prompt is sent
 }
 
+// BudgetCheckFunc reports whether the session's spend budget allows another
+// prompt. A non-nil error fails the step without sending.
+type BudgetCheckFunc func(ctx context.Context, session string) error
+
 // MinProgressInterval is the minimum allowed progress interval to prevent ticker panics.
 // time.NewTicker requires a positive duration.
 const MinProgressInterval = 100 * time.Millisecond
@@ -59,6 +67,25 @@ type Executor struct {
 	notifier *Notifier
 	loopExec *LoopExecutor
 
+	// Command policy for run steps (loaded lazily)
+	policy     *policy.Policy
+	policyOnce sync.Once
+	policyErr  error
+
+	// Approval engine for gate steps, and requests still pending from a resumed run
+	approvals       *approval.Engine
+	resumeApprovals map[string]string
+	approvalPoll    time.Duration
+
+	// Prompt queue dispatcher for steps with queue: true
+	promptQueue *inbox.Dispatcher
+
+	// Sub-workflow runs: the executor a nested run reports its state to, and
+	// child states from a resumed run
+	parent         *Executor
+	parentStep     string
+	resumeChildren map[string]*ExecutionState
+
 	// Runtime state (reset per execution)
 	state    *ExecutionState
 	stateMu  sync.RWMutex // Protects state.Steps for concurrent access
@@ -91,6 +118,14 @@ func (e *Executor) SetNotifier(n *Notifier) {
 	e.notifier = n
 }
 
+// checkBudget consults the configured budget check, if any.
+func (e *Executor) checkBudget(ctx context.Context) error {
+	if e.config.BudgetCheck == nil {
+		return nil
+	}
+	return e.config.BudgetCheck(ctx, e.config.Session)
+}
+
 // Run executes a workflow with the given initial variables.
 // Returns the final execution state and any fatal error.
 // Progress events are sent to the provided channel if non-nil.
@@ -141,6 +176,7 @@ func (e *Executor) Run(ctx context.Context, workflow *Workflow, vars map[string]
 
 	// Build dependency graph
 	e.graph = NewDependencyGraph(workflow)
+	e.graph.projectDir = e.config.ProjectDir
 	if errors := e.graph.Validate(); len(errors) > 0 {
 		e.state.Status = StatusFailed
 		for _, err := range errors {
@@ -166,7 +202,10 @@ func (e *Executor) Run(ctx context.Context, workflow *Workflow, vars map[string]
 	e.state.UpdatedAt = time.Now()
 
 	if err != nil {
-		if ctx.Err() == context.Canceled {
+		if ctx.Err() == context.Canceled && len(PendingGates(e.state)) > 0 {
+			// Interrupted at an approval gate: stay resumable
+			e.state.Status = StatusPaused
+		} else if ctx.Err() == context.Canceled {
 			e.state.Status = StatusCancelled
 			e.sendNotification(ctx, workflow, NotifyCancelled)
 		} else if ctx.Err() == context.DeadlineExceeded {
@@ -248,6 +287,7 @@ func (e *Executor) Resume(ctx context.Context, workflow *Workflow, prior *Execut
 
 	// Build dependency graph
 	e.graph = NewDependencyGraph(workflow)
+	e.graph.projectDir = e.config.ProjectDir
 	if errors := e.graph.Validate(); len(errors) > 0 {
 		e.state.Status = StatusFailed
 		for _, err := range errors {
@@ -276,7 +316,10 @@ func (e *Executor) Resume(ctx context.Context, workflow *Workflow, prior *Execut
 	e.state.UpdatedAt = time.Now()
 
 	if err != nil {
-		if ctx.Err() == context.Canceled {
+		if ctx.Err() == context.Canceled && len(PendingGates(e.state)) > 0 {
+			// Interrupted at an approval gate: stay resumable
+			e.state.Status = StatusPaused
+		} else if ctx.Err() == context.Canceled {
 			e.state.Status = StatusCancelled
 			e.sendNotification(ctx, workflow, NotifyCancelled)
 		} else if ctx.Err() == context.DeadlineExceeded {
@@ -343,6 +386,14 @@ func (e *Executor) executeWorkflow(ctx context.Context, workflow *Workflow) erro
 				continue
 			}
 
+			// Nested steps already ran inside their parallel group or loop
+			if _, nested := e.graph.ParentOf(stepID); nested {
+				if err := e.graph.MarkExecuted(stepID); err != nil {
+					return fmt.Errorf("failed to mark step %s as executed: %w", stepID, err)
+				}
+				continue
+			}
+
 			e.state.CurrentStep = stepID
 			e.state.UpdatedAt = time.Now()
 
@@ -360,6 +411,9 @@ func (e *Executor) executeWorkflow(ctx context.Context, workflow *Workflow) erro
 				if result.ParsedData != nil {
 					e.state.Variables[step.OutputVar+"_parsed"] = result.ParsedData
 				}
+				if result.ExitCode != nil {
+					e.state.Variables[step.OutputVar+"_exit_code"] = *result.ExitCode
+				}
 				StoreStepOutput(e.state, stepID, result.Output, result.ParsedData)
 				e.varMu.Unlock()
 			}
@@ -493,6 +547,13 @@ func (e *Executor) executeStep(ctx context.Context, step *Step, workflow *Workfl
 			return result
 		}
 
+		// Interrupted (e.g. while waiting at an approval gate); don't retry
+		if stepResult.Status == StatusPaused || stepResult.Status == StatusCancelled {
+			result = stepResult
+			result.Attempts = attempt
+			return result
+		}
+
 		// Step failed
 		result.Error = stepResult.Error
 
@@ -525,6 +586,21 @@ func (e *Executor) executeStep(ctx context.Context, step *Step, workflow *Workfl
 
 // executeStepOnce executes a step once without retry logic
 func (e *Executor) executeStepOnce(ctx context.Context, step *Step, workflow *Workflow) StepResult {
+	// Shell steps run on the host, not in an agent pane
+	if step.Run != nil {
+		return e.executeRunStep(ctx, step)
+	}
+
+	// Approval gates wait for a human decision
+	if step.Approval != nil {
+		return e.executeApprovalStep(ctx, step, workflow)
+	}
+
+	// Sub-workflows run as a nested execution
+	if step.Uses != "" {
+		return e.executeUsesStep(ctx, step, workflow)
+	}
+
 	result := StepResult{
 		StepID:    step.ID,
 		Status:    StatusRunning,
@@ -568,11 +644,20 @@ func (e *Executor) executeStepOnce(ctx context.Context, step *Step, workflow *Wo
 		return result
 	}
 
-	// Capture state before sending
-	beforeOutput, _ := tmux.CapturePaneOutput(paneID, 2000)
+	if err := e.checkBudget(ctx); err != nil {
+		result.Status = StatusFailed
+		result.Error = &StepError{
+			Type:      "budget",
+			Message:   err.Error(),
+			Timestamp: time.Now(),
+		}
+		result.FinishedAt = time.Now()
+		return result
+	}
 
-	// Send prompt
-	if err := tmux.PasteKeys(paneID, prompt, true); err != nil {
+	// Send prompt, capturing state before it is received
+	beforeOutput, err := e.sendPrompt(ctx, step, paneID, prompt)
+	if err != nil {
 		result.Status = StatusFailed
 		result.Error = &StepError{
 			Type:      "send",
@@ -832,6 +917,11 @@ func (e *Executor) executeParallel(ctx context.Context, step *Step, workflow *Wo
 		result.Output = fmt.Sprintf("All %d parallel steps completed", len(results))
 	}
 
+	// Matrix groups aggregate their expansions for follow-up steps
+	if len(step.Matrix) > 0 && result.Status == StatusCompleted {
+		e.collectMatrix(step, results, &result)
+	}
+
 	return result
 }
 
@@ -919,6 +1009,26 @@ func (e *Executor) executeParallelStep(ctx context.Context, step *Step, workflow
 		}
 	}
 
+	// Shell, approval and uses steps don't occupy an agent pane; reuse the sequential retry path
+	if step.Run != nil || step.Approval != nil || step.Uses != "" {
+		result = e.executeStep(ctx, step, workflow)
+		if result.Status == StatusCompleted {
+			e.varMu.Lock()
+			if step.OutputVar != "" {
+				e.state.Variables[step.OutputVar] = result.Output
+				if result.ParsedData != nil {
+					e.state.Variables[step.OutputVar+"_parsed"] = result.ParsedData
+				}
+				if result.ExitCode != nil {
+					e.state.Variables[step.OutputVar+"_exit_code"] = *result.ExitCode
+				}
+			}
+			StoreStepOutput(e.state, step.ID, result.Output, result.ParsedData)
+			e.varMu.Unlock()
+		}
+		return result
+	}
+
 	// Select pane with coordination to avoid reusing agents
 	// We select once and reuse for retries to avoid "self-exclusion" issues
 	paneID, agentType, err := e.selectAndMarkPane(step, usedPanes, panesMu)
@@ -996,11 +1106,19 @@ func (e *Executor) executeParallelStep(ctx context.Context, step *Step, workflow
 			return result
 		}
 
-		// Capture state before sending
-		beforeOutput, _ = tmux.CapturePaneOutput(paneID, 2000)
+		if err := e.checkBudget(ctx); err != nil {
+			result.Status = StatusFailed
+			result.Error = &StepError{
+				Type:      "budget",
+				Message:   err.Error(),
+				Timestamp: time.Now(),
+			}
+			result.FinishedAt = time.Now()
+			return result
+		}
 
-		// Send prompt
-		if err := tmux.PasteKeys(paneID, prompt, true); err != nil {
+		// Send prompt, capturing state before it is received
+		if beforeOutput, err = e.sendPrompt(ctx, step, paneID, prompt); err != nil {
 			result.Status = StatusFailed
 			result.Error = &StepError{
 				Type:      "send",
@@ -1164,6 +1282,9 @@ func (e *Executor) selectAndMarkPane(step *Step, usedPanes map[string]bool, pane
 		agents = filtered
 	}
 
+	// Matrix expansions over models or personas only run on panes spawned with that variant
+	agents = filterAgentsByMatrix(agents, step.matrixValues)
+
 	// Begin atomic selection and marking
 	panesMu.Lock()
 	defer panesMu.Unlock()
@@ -1497,6 +1618,21 @@ func (e *Executor) applyResumeState() {
 
 	rerun := make(map[string]StepResult)
 	for stepID, result := range e.state.Steps {
+		// A gate that was still waiting picks its request back up instead of
+		// asking again.
+		if result.Status == StatusPaused && result.ApprovalID != "" {
+			if e.resumeApprovals == nil {
+				e.resumeApprovals = make(map[string]string)
+			}
+			e.resumeApprovals[stepID] = result.ApprovalID
+		}
+		// An unfinished sub-workflow resumes inside its nested run.
+		if result.Child != nil && shouldRerunStep(result) {
+			if e.resumeChildren == nil {
+				e.resumeChildren = make(map[string]*ExecutionState)
+			}
+			e.resumeChildren[stepID] = result.Child
+		}
 		if shouldRerunStep(result) {
 			rerun[stepID] = result
 			continue
@@ -1518,7 +1654,7 @@ func (e *Executor) applyResumeState() {
 
 func shouldRerunStep(result StepResult) bool {
 	switch result.Status {
-	case StatusFailed, StatusCancelled, StatusRunning, StatusPending:
+	case StatusFailed, StatusCancelled, StatusRunning, StatusPending, StatusPaused:
 		return true
 	case StatusSkipped:
 		if strings.HasPrefix(result.SkipReason, "dependency failed") {
@@ -1538,10 +1674,16 @@ func (e *Executor) clearStepVariables(stepID string) {
 
 	delete(e.state.Variables, "steps."+stepID+".output")
 	delete(e.state.Variables, "steps."+stepID+".data")
+	delete(e.state.Variables, "steps."+stepID+".exit_code")
+	delete(e.state.Variables, "steps."+stepID+".stdout")
+	delete(e.state.Variables, "steps."+stepID+".stderr")
+	delete(e.state.Variables, "steps."+stepID+".outputs")
+	delete(e.state.Variables, "steps."+stepID+".collect")
 
 	if step, ok := e.graph.GetStep(stepID); ok && step.OutputVar != "" {
 		delete(e.state.Variables, step.OutputVar)
 		delete(e.state.Variables, step.OutputVar+"_parsed")
+		delete(e.state.Variables, step.OutputVar+"_exit_code")
 	}
 }
 
@@ -1595,6 +1737,12 @@ func (e *Executor) persistState() {
 		return
 	}
 
+	// Nested runs are persisted inside their parent's state
+	if e.parent != nil {
+		e.parent.updateChild(e.parentStep, snapshot)
+		return
+	}
+
 	if err := SaveState(projectDir, snapshot); err != nil && e.config.Verbose {
 		log.Printf("pipeline: state persistence failed: %v", err)
 	}
@@ -495,6 +495,19 @@ func (le *LoopExecutor) executeIteration(ctx context.Context, step *Step, loop *
 		le.executor.state.Steps[iteratedStep.ID] = result
 		le.executor.stateMu.Unlock()
 
+		// Expose output variables so while conditions can observe this iteration
+		if nestedStep.OutputVar != "" && result.Status == StatusCompleted {
+			le.executor.varMu.Lock()
+			le.executor.state.Variables[nestedStep.OutputVar] = result.Output
+			if result.ParsedData != nil {
+				le.executor.state.Variables[nestedStep.OutputVar+"_parsed"] = result.ParsedData
+			}
+			if result.ExitCode != nil {
+				le.executor.state.Variables[nestedStep.OutputVar+"_exit_code"] = *result.ExitCode
+			}
+			le.executor.varMu.Unlock()
+		}
+
 		// Handle step failure based on error action
 		if result.Status == StatusFailed {
 			onError := nestedStep.OnError
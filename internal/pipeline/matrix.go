@@ -0,0 +1,327 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Dicklesworthstone/ntm/internal/robot"
+)
+
+// MaxMatrixExpansions caps how many sub-steps a single matrix step expands into.
+const MaxMatrixExpansions = 64
+
+// maxMatrixIDValueLen is the longest matrix value used verbatim in an
+// expansion's step ID; longer values (e.g. prompt variants) use their index.
+const maxMatrixIDValueLen = 24
+
+// matrixRefPattern matches ${matrix.key} references.
+var matrixRefPattern = regexp.MustCompile(`\$\{matrix\.([a-zA-Z0-9_-]+)\}`)
+
+// matrixCombination is one expansion of a matrix step.
+type matrixCombination struct {
+	suffix string
+	values map[string]string
+}
+
+// matrixCombinations returns the cartesian product of a step's matrix, with
+// dimensions ordered by name and values in declared order.
+func matrixCombinations(step *Step) []matrixCombination {
+	keys := sortedKeys(step.Matrix)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	combos := []matrixCombination{{values: map[string]string{}}}
+	for _, key := range keys {
+		parts := matrixIDParts(step.Matrix[key])
+		var next []matrixCombination
+		for _, c := range combos {
+			for i, v := range step.Matrix[key] {
+				values := make(map[string]string, len(c.values)+1)
+				for k, cv := range c.values {
+					values[k] = cv
+				}
+				values[key] = v
+				part := parts[i]
+				if c.suffix != "" {
+					part = c.suffix + "-" + part
+				}
+				next = append(next, matrixCombination{suffix: part, values: values})
+			}
+		}
+		combos = next
+	}
+	uniqueMatrixSuffixes(combos)
+	return combos
+}
+
+// uniqueMatrixSuffixes suffixes the 1-based combination index to suffixes
+// that collide, e.g. "x-y"+"z" and "x"+"y-z", so every expansion gets its
+// own step ID. Suffixes are compared as output variables name them, with
+// '-' and '_' alike.
+func uniqueMatrixSuffixes(combos []matrixCombination) {
+	key := func(suffix string) string { return strings.ReplaceAll(suffix, "-", "_") }
+	counts := make(map[string]int, len(combos))
+	for _, c := range combos {
+		counts[key(c.suffix)]++
+	}
+	used := make(map[string]bool, len(combos))
+	for _, c := range combos {
+		if counts[key(c.suffix)] == 1 {
+			used[key(c.suffix)] = true
+		}
+	}
+	for i := range combos {
+		if counts[key(combos[i].suffix)] == 1 {
+			continue
+		}
+		n := i + 1
+		suffix := fmt.Sprintf("%s-%d", combos[i].suffix, n)
+		for used[key(suffix)] {
+			n += len(combos)
+			suffix = fmt.Sprintf("%s-%d", combos[i].suffix, n)
+		}
+		used[key(suffix)] = true
+		combos[i].suffix = suffix
+	}
+}
+
+// matrixIDParts turns one dimension's values into step ID fragments. Values
+// that don't make a short, unique fragment fall back to their 1-based index.
+func matrixIDParts(values []string) []string {
+	parts := make([]string, len(values))
+	seen := make(map[string]bool, len(values))
+	for i, value := range values {
+		var b strings.Builder
+		for _, r := range strings.ToLower(value) {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+				b.WriteRune(r)
+			} else {
+				b.WriteRune('-')
+			}
+		}
+		part := strings.Trim(b.String(), "-")
+		if part == "" || len(part) > maxMatrixIDValueLen || seen[part] {
+			part = strconv.Itoa(i + 1)
+		}
+		seen[part] = true
+		parts[i] = part
+	}
+	return parts
+}
+
+// matrixSize returns how many expansions a step's matrix produces.
+func matrixSize(step *Step) int {
+	if len(step.Matrix) == 0 {
+		return 0
+	}
+	n := 1
+	for _, values := range step.Matrix {
+		n *= len(values)
+	}
+	return n
+}
+
+// expandMatrix returns a copy of steps in which every matrix step becomes a
+// parallel group with one sub-step per combination, so routing, dependency
+// planning and execution treat expansions like hand-written parallel steps.
+func expandMatrix(steps []Step) []Step {
+	if len(steps) == 0 {
+		return steps
+	}
+
+	out := make([]Step, len(steps))
+	for i, step := range steps {
+		if step.Loop != nil {
+			loop := *step.Loop
+			loop.Steps = expandMatrix(loop.Steps)
+			step.Loop = &loop
+		}
+		if len(step.Matrix) > 0 && len(step.Parallel) == 0 {
+			step = expandMatrixStep(step)
+		}
+		out[i] = step
+	}
+	return out
+}
+
+// expandMatrixStep builds the parallel group for a single matrix step.
+func expandMatrixStep(step Step) Step {
+	group := step
+	group.Prompt = ""
+	group.PromptFile = ""
+	group.Run = nil
+	group.Uses = ""
+	group.With = nil
+	group.Agent = ""
+
+	// A condition that varies per combination is evaluated per expansion;
+	// otherwise it gates the whole group once.
+	perExpansionWhen := matrixRefPattern.MatchString(step.When)
+	if perExpansionWhen {
+		group.When = ""
+	}
+
+	for _, combo := range matrixCombinations(&step) {
+		sub := step
+		sub.ID = step.ID + "-" + combo.suffix
+		if step.Name != "" {
+			sub.Name = fmt.Sprintf("%s (%s)", step.Name, formatMatrixValues(combo.values))
+		}
+		sub.DependsOn = nil
+		sub.Matrix = nil
+		sub.Collect = ""
+		sub.matrixValues = combo.values
+		if !perExpansionWhen {
+			sub.When = ""
+		}
+		if step.OutputVar != "" {
+			sub.OutputVar = step.OutputVar + "_" + strings.ReplaceAll(combo.suffix, "-", "_")
+		}
+		if agent, ok := combo.values["agent"]; ok {
+			sub.Agent = agent
+		}
+
+		sub.Prompt = substituteMatrix(step.Prompt, combo.values)
+		sub.PromptFile = substituteMatrix(step.PromptFile, combo.values)
+		sub.When = substituteMatrix(sub.When, combo.values)
+		if step.Run != nil {
+			run := *step.Run
+			run.Command = substituteMatrix(run.Command, combo.values)
+			run.Dir = substituteMatrix(run.Dir, combo.values)
+			if len(run.Env) > 0 {
+				run.Env = make(map[string]string, len(step.Run.Env))
+				for k, v := range step.Run.Env {
+					run.Env[k] = substituteMatrix(v, combo.values)
+				}
+			}
+			sub.Run = &run
+		}
+		if len(step.With) > 0 {
+			sub.With = make(map[string]interface{}, len(step.With))
+			for k, v := range step.With {
+				if s, ok := v.(string); ok {
+					v = substituteMatrix(s, combo.values)
+				}
+				sub.With[k] = v
+			}
+		}
+
+		group.Parallel = append(group.Parallel, sub)
+	}
+	return group
+}
+
+// substituteMatrix replaces ${matrix.key} references with a combination's
+// values. Unknown keys are left for the variable substitutor to report.
+func substituteMatrix(s string, values map[string]string) string {
+	if s == "" || !strings.Contains(s, "${matrix.") {
+		return s
+	}
+	return matrixRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		key := matrixRefPattern.FindStringSubmatch(ref)[1]
+		if v, ok := values[key]; ok {
+			return v
+		}
+		return ref
+	})
+}
+
+// formatMatrixValues renders a combination as "k1=v1, k2=v2".
+func formatMatrixValues(values map[string]string) string {
+	parts := make([]string, 0, len(values))
+	for _, k := range sortedKeys(values) {
+		v := values[k]
+		if len(v) > maxMatrixIDValueLen {
+			v = truncatePrompt(v, maxMatrixIDValueLen)
+		}
+		parts = append(parts, k+"="+v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// collectMatrix aggregates a matrix group's expansion results into the
+// group's output and ${steps.<id>.collect}.
+func (e *Executor) collectMatrix(step *Step, results []StepResult, result *StepResult) {
+	entries := make([]interface{}, 0, len(results))
+	var text strings.Builder
+	for i, r := range results {
+		matrix := make(map[string]interface{})
+		if i < len(step.Parallel) {
+			for k, v := range step.Parallel[i].matrixValues {
+				matrix[k] = v
+			}
+		}
+		entry := map[string]interface{}{
+			"id":     r.StepID,
+			"matrix": matrix,
+			"status": string(r.Status),
+			"output": r.Output,
+		}
+		if r.ParsedData != nil {
+			entry["data"] = r.ParsedData
+		}
+		if r.AgentType != "" {
+			entry["agent_type"] = r.AgentType
+		}
+		entries = append(entries, entry)
+
+		if r.Status != StatusCompleted {
+			continue
+		}
+		if text.Len() > 0 {
+			text.WriteString("\n")
+		}
+		label := r.StepID
+		if i < len(step.Parallel) {
+			label = formatMatrixValues(step.Parallel[i].matrixValues)
+		}
+		fmt.Fprintf(&text, "### %s\n%s\n", label, strings.TrimSpace(r.Output))
+	}
+
+	collected := text.String()
+	if step.Collect == MatrixCollectJSON {
+		if data, err := json.Marshal(entries); err == nil {
+			collected = string(data)
+		}
+	}
+
+	result.ParsedData = entries
+	result.Output = collected
+
+	e.varMu.Lock()
+	if e.state.Variables == nil {
+		e.state.Variables = make(map[string]interface{})
+	}
+	e.state.Variables["steps."+step.ID+".collect"] = collected
+	e.varMu.Unlock()
+}
+
+// variantMatrixKeys are the matrix keys whose values name a pane variant.
+var variantMatrixKeys = []string{"model", "persona"}
+
+// filterAgentsByMatrix keeps agents whose pane variant matches the model or
+// persona of a matrix expansion. Other steps keep every agent.
+func filterAgentsByMatrix(agents []robot.ScoredAgent, values map[string]string) []robot.ScoredAgent {
+	for _, key := range variantMatrixKeys {
+		if variant := values[key]; variant != "" {
+			agents = filterAgentsByVariant(agents, variant)
+		}
+	}
+	return agents
+}
+
+// filterAgentsByVariant keeps agents whose pane variant (model alias or
+// persona from the pane title) matches variant.
+func filterAgentsByVariant(agents []robot.ScoredAgent, variant string) []robot.ScoredAgent {
+	filtered := make([]robot.ScoredAgent, 0, len(agents))
+	for _, a := range agents {
+		if strings.EqualFold(a.Variant, variant) {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
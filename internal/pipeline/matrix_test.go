@@ -0,0 +1,273 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/robot"
+)
+
+func TestParseString_MatrixStep(t *testing.T) {
+	t.Parallel()
+
+	w, err := ParseString(`
+schema_version: "2.0"
+name: compare
+steps:
+  - id: solve
+    matrix:
+      agent: [claude, codex, gemini]
+      model: [opus, sonnet]
+    prompt: "As ${matrix.agent} on ${matrix.model}: fix the flaky test"
+    route: least-loaded
+    output_var: answer
+    collect: json
+  - id: judge
+    depends_on: [solve]
+    prompt: "Pick the best answer: ${steps.solve.collect}"
+`, "yaml")
+	if err != nil {
+		t.Fatalf("ParseString() error: %v", err)
+	}
+	solve := w.Steps[0]
+	if len(solve.Matrix["agent"]) != 3 || solve.Collect != MatrixCollectJSON {
+		t.Errorf("matrix step = %+v", solve)
+	}
+	if res := Validate(w); !res.Valid {
+		t.Errorf("Validate() errors: %+v", res.Errors)
+	}
+
+	tests := []struct {
+		name  string
+		steps []Step
+	}{
+		{"empty values", []Step{{ID: "a", Prompt: "x", Matrix: map[string][]string{"agent": {}}}}},
+		{"agent twice", []Step{{ID: "a", Prompt: "x", Agent: "claude", Matrix: map[string][]string{"agent": {"codex"}}}}},
+		{"with parallel", []Step{{ID: "a", Matrix: map[string][]string{"k": {"v"}}, Parallel: []Step{{ID: "b", Prompt: "x"}}}}},
+		{"bad collect", []Step{{ID: "a", Prompt: "x", Matrix: map[string][]string{"k": {"v"}}, Collect: "csv"}}},
+		{"collect without matrix", []Step{{ID: "a", Prompt: "x", Collect: MatrixCollectJSON}}},
+		{"expansion id clash", []Step{
+			{ID: "a", Prompt: "x", Matrix: map[string][]string{"k": {"v"}}},
+			{ID: "a-v", Prompt: "y"},
+		}},
+	}
+	for _, tt := range tests {
+		w := &Workflow{SchemaVersion: SchemaVersion, Name: "w", Steps: tt.steps}
+		if Validate(w).Valid {
+			t.Errorf("%s: expected validation error", tt.name)
+		}
+	}
+}
+
+func TestExpandMatrix(t *testing.T) {
+	t.Parallel()
+
+	steps := []Step{{
+		ID:        "solve",
+		Name:      "Solve",
+		DependsOn: []string{"setup"},
+		Matrix: map[string][]string{
+			"model": {"opus", "gpt-4.1"},
+			"agent": {"claude", "codex"},
+		},
+		Prompt:    "As ${matrix.agent}/${matrix.model}: ${vars.task}",
+		When:      "${matrix.agent} != codex",
+		Route:     RouteRoundRobin,
+		OutputVar: "answer",
+	}}
+
+	expanded := expandMatrix(steps)
+	if len(steps[0].Parallel) != 0 {
+		t.Fatal("expandMatrix modified its input")
+	}
+	group := expanded[0]
+	if group.Prompt != "" || group.When != "" || len(group.DependsOn) != 1 {
+		t.Errorf("group = %+v", group)
+	}
+	if len(group.Parallel) != 4 {
+		t.Fatalf("got %d expansions, want 4", len(group.Parallel))
+	}
+
+	first := group.Parallel[0]
+	if first.ID != "solve-claude-opus" || first.OutputVar != "answer_claude_opus" {
+		t.Errorf("first expansion id/output_var = %q/%q", first.ID, first.OutputVar)
+	}
+	if first.Agent != "claude" || first.Route != RouteRoundRobin || first.matrixValues["model"] != "opus" {
+		t.Errorf("first expansion routing = agent %q route %q values %v", first.Agent, first.Route, first.matrixValues)
+	}
+	if first.Prompt != "As claude/opus: ${vars.task}" || first.When != "claude != codex" {
+		t.Errorf("first expansion prompt/when = %q/%q", first.Prompt, first.When)
+	}
+	if first.Name != "Solve (agent=claude, model=opus)" || len(first.DependsOn) != 0 {
+		t.Errorf("first expansion name/deps = %q/%v", first.Name, first.DependsOn)
+	}
+	if got := group.Parallel[1].ID; got != "solve-claude-gpt-4-1" {
+		t.Errorf("second expansion id = %q", got)
+	}
+
+	graph := NewDependencyGraph(&Workflow{Steps: append([]Step{{ID: "setup", Prompt: "x"}}, steps...)})
+	if parent, ok := graph.ParentOf("solve-codex-opus"); !ok || parent != "solve" {
+		t.Errorf("ParentOf(solve-codex-opus) = %q, %v", parent, ok)
+	}
+}
+
+func TestMatrixIDParts(t *testing.T) {
+	t.Parallel()
+
+	got := matrixIDParts([]string{"Be terse.", "be-terse", "", strings.Repeat("long ", 10)})
+	want := []string{"be-terse", "2", "3", "4"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("matrixIDParts()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExecutor_MatrixStep_CollectsOutputs(t *testing.T) {
+	t.Parallel()
+
+	e := newRunTestExecutor(t)
+	w := &Workflow{
+		SchemaVersion: SchemaVersion,
+		Name:          "matrix-run",
+		Settings:      DefaultWorkflowSettings(),
+		Steps: []Step{
+			{
+				ID:        "lang",
+				Matrix:    map[string][]string{"lang": {"go", "rust"}},
+				Run:       &RunConfig{Command: "printf 'built ${matrix.lang}'"},
+				OutputVar: "build",
+				Collect:   MatrixCollectJSON,
+			},
+			{
+				ID:        "report",
+				DependsOn: []string{"lang"},
+				Run:       &RunConfig{Command: `printf '%s' "${vars.build_go} + ${vars.build_rust}"`},
+				OutputVar: "report",
+			},
+		},
+	}
+	if res := Validate(w); !res.Valid {
+		t.Fatalf("Validate() errors: %+v", res.Errors)
+	}
+
+	st, err := e.Run(context.Background(), w, nil, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if got := st.Variables["report"]; got != "built go + built rust" {
+		t.Errorf("report = %q", got)
+	}
+	for _, id := range []string{"lang-go", "lang-rust"} {
+		if st.Steps[id].Status != StatusCompleted {
+			t.Errorf("%s status = %v", id, st.Steps[id].Status)
+		}
+	}
+
+	var collected []map[string]interface{}
+	if err := json.Unmarshal([]byte(st.Variables["steps.lang.collect"].(string)), &collected); err != nil {
+		t.Fatalf("collect is not JSON: %v", err)
+	}
+	if len(collected) != 2 || collected[1]["output"] != "built rust" {
+		t.Errorf("collect = %+v", collected)
+	}
+	if m, _ := collected[0]["matrix"].(map[string]interface{}); m["lang"] != "go" {
+		t.Errorf("collect[0].matrix = %v", collected[0]["matrix"])
+	}
+	if got := st.Variables["build"]; got != st.Variables["steps.lang.collect"] {
+		t.Errorf("output_var = %q, want the collected aggregate", got)
+	}
+}
+
+func TestCollectMatrix_Concat(t *testing.T) {
+	t.Parallel()
+
+	e := newRunTestExecutor(t)
+	e.state = &ExecutionState{Variables: map[string]interface{}{}, Steps: map[string]StepResult{}}
+	group := expandMatrixStep(Step{ID: "ask", Prompt: "x", Matrix: map[string][]string{"agent": {"claude", "codex"}}})
+	results := []StepResult{
+		{StepID: "ask-claude", Status: StatusCompleted, Output: "answer one\n"},
+		{StepID: "ask-codex", Status: StatusFailed},
+	}
+
+	var result StepResult
+	e.collectMatrix(&group, results, &result)
+	if result.Output != "### agent=claude\nanswer one\n" {
+		t.Errorf("concat output = %q", result.Output)
+	}
+	if entries, ok := result.ParsedData.([]interface{}); !ok || len(entries) != 2 {
+		t.Errorf("parsed data = %v", result.ParsedData)
+	}
+}
+
+func TestFilterAgentsByVariant(t *testing.T) {
+	t.Parallel()
+
+	agents := []robot.ScoredAgent{
+		{PaneID: "%1", AgentType: "claude", Variant: "opus"},
+		{PaneID: "%2", AgentType: "claude", Variant: "sonnet"},
+		{PaneID: "%3", AgentType: "claude"},
+	}
+	got := filterAgentsByVariant(agents, "Opus")
+	if len(got) != 1 || got[0].PaneID != "%1" {
+		t.Errorf("filterAgentsByVariant() = %+v", got)
+	}
+}
+
+func TestFilterAgentsByMatrix_Personas(t *testing.T) {
+	t.Parallel()
+
+	agents := []robot.ScoredAgent{
+		{PaneID: "%1", AgentType: "claude", Variant: "architect"},
+		{PaneID: "%2", AgentType: "claude", Variant: "reviewer"},
+		{PaneID: "%3", AgentType: "claude"},
+	}
+	group := expandMatrixStep(Step{ID: "ask", Prompt: "as ${matrix.persona}", Matrix: map[string][]string{
+		"persona": {"architect", "reviewer"},
+	}})
+	if len(group.Parallel) != 2 {
+		t.Fatalf("expansions = %d, want 2", len(group.Parallel))
+	}
+
+	want := map[string]string{"ask-architect": "%1", "ask-reviewer": "%2"}
+	for _, sub := range group.Parallel {
+		got := filterAgentsByMatrix(agents, sub.matrixValues)
+		if len(got) != 1 || got[0].PaneID != want[sub.ID] {
+			t.Errorf("%s routed to %+v, want only pane %s", sub.ID, got, want[sub.ID])
+		}
+	}
+
+	if got := filterAgentsByMatrix(agents, map[string]string{"focus": "tests"}); len(got) != len(agents) {
+		t.Errorf("plain matrix key filtered agents: %+v", got)
+	}
+}
+
+func TestExpandMatrix_UniqueIDs(t *testing.T) {
+	t.Parallel()
+
+	steps := []Step{
+		{ID: "joined", Prompt: "x", OutputVar: "out", Matrix: map[string][]string{
+			"a": {"x-y", "x"},
+			"b": {"z", "y-z"},
+		}},
+		{ID: "fallback", Prompt: "x", OutputVar: "out", Matrix: map[string][]string{
+			"a": {"2", "!!", "a_b", "a-b"},
+		}},
+	}
+	for _, group := range expandMatrix(steps) {
+		ids := make(map[string]bool)
+		vars := make(map[string]bool)
+		for _, sub := range group.Parallel {
+			if ids[sub.ID] || vars[sub.OutputVar] {
+				t.Errorf("%s: duplicate expansion %q/%q", group.ID, sub.ID, sub.OutputVar)
+			}
+			ids[sub.ID] = true
+			vars[sub.OutputVar] = true
+		}
+		if len(ids) != matrixSize(&group) {
+			t.Errorf("%s: got %d unique IDs, want %d", group.ID, len(ids), matrixSize(&group))
+		}
+	}
+}
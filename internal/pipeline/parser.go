@@ -80,6 +80,11 @@ func ParseFile(path string) (*Workflow, error) {
 		}
 	}
 
+	if abs, err := filepath.Abs(path); err == nil {
+		workflow.source = abs
+	} else {
+		workflow.source = path
+	}
 	return &workflow, nil
 }
 
@@ -210,6 +215,9 @@ func validateStep(step *Step, stepField string, stepIDs map[string]bool, result
 	// Check for parallel vs prompt mutual exclusivity
 	hasPrompt := step.Prompt != "" || step.PromptFile != ""
 	hasParallel := len(step.Parallel) > 0
+	hasRun := step.Run != nil
+	hasApproval := step.Approval != nil
+	hasUses := step.Uses != ""
 
 	if hasPrompt && hasParallel {
 		result.addError(ParseError{
@@ -219,11 +227,67 @@ func validateStep(step *Step, stepField string, stepIDs map[string]bool, result
 		})
 	}
 
-	if !hasPrompt && !hasParallel && step.Loop == nil {
+	if hasRun && (hasPrompt || hasParallel || step.Loop != nil) {
+		result.addError(ParseError{
+			Field:   stepField,
+			Message: "step cannot combine run with prompt, parallel, or loop",
+			Hint:    "Split the shell command into its own step",
+		})
+	}
+
+	if hasApproval && (hasPrompt || hasParallel || hasRun || step.Loop != nil) {
+		result.addError(ParseError{
+			Field:   stepField,
+			Message: "step cannot combine approval with prompt, run, parallel, or loop",
+			Hint:    "Put the approval gate in its own step and depend on it",
+		})
+	}
+
+	if hasUses && (hasPrompt || hasParallel || hasRun || hasApproval || step.Loop != nil) {
+		result.addError(ParseError{
+			Field:   stepField,
+			Message: "step cannot combine uses with prompt, run, approval, parallel, or loop",
+			Hint:    "Invoke the sub-workflow from its own step",
+		})
+	}
+
+	if len(step.With) > 0 && !hasUses {
 		result.addError(ParseError{
+			Field:   stepField + ".with",
+			Message: "with is only valid on uses steps",
+			Hint:    "Add uses: <workflow> or remove with",
+		})
+	}
+
+	if step.Queue && !hasPrompt {
+		result.addWarning(ParseError{
+			Field:   stepField + ".queue",
+			Message: "queue is ignored for steps without a prompt",
+			Hint:    "Set queue: true on the prompt steps themselves",
+		})
+	}
+
+	if !hasPrompt && !hasParallel && !hasRun && !hasApproval && !hasUses && step.Loop == nil {
+		result.addError(ParseError{
+			Field:   stepField,
+			Message: "step must have prompt, prompt_file, run, approval, uses, parallel, or loop",
+			Hint:    "Add a prompt, shell command, approval gate, sub-workflow, parallel steps, or loop for this step",
+		})
+	}
+
+	if hasRun {
+		validateRunConfig(step, stepField, result)
+	}
+
+	if hasApproval {
+		validateApprovalConfig(step, stepField, result)
+	}
+
+	if hasUses && (step.Agent != "" || step.Pane > 0 || step.Route != "" || step.Wait != "") {
+		result.addWarning(ParseError{
 			Field:   stepField,
-			Message: "step must have prompt, prompt_file, parallel, or loop",
-			Hint:    "Add a prompt, parallel steps, or loop for this step",
+			Message: "agent, pane, route, and wait are ignored for uses steps",
+			Hint:    "Set agent selection on the sub-workflow's own steps",
 		})
 	}
 
@@ -298,6 +362,8 @@ func validateStep(step *Step, stepField string, stepIDs map[string]bool, result
 		})
 	}
 
+	validateMatrix(step, stepField, stepIDs, result)
+
 	// Validate parallel sub-steps
 	for j, pStep := range step.Parallel {
 		validateStep(&pStep, fmt.Sprintf("%s.parallel[%d]", stepField, j), stepIDs, result)
@@ -305,11 +371,11 @@ func validateStep(step *Step, stepField string, stepIDs map[string]bool, result
 
 	// Validate loop configuration
 	if step.Loop != nil {
-		if step.Loop.Items == "" {
+		if step.Loop.Items == "" && step.Loop.While == "" && step.Loop.Times <= 0 {
 			result.addError(ParseError{
 				Field:   stepField + ".loop.items",
 				Message: "loop items is required",
-				Hint:    "Specify the variable to iterate over",
+				Hint:    "Specify the variable to iterate over, or use while/times",
 			})
 		}
 		if step.Loop.MaxIterations < 0 {
@@ -324,6 +390,161 @@ func validateStep(step *Step, stepField string, stepIDs map[string]bool, result
 	}
 }
 
+// validateRunConfig checks the run block of a shell step
+func validateRunConfig(step *Step, stepField string, result *ValidationResult) {
+	if strings.TrimSpace(step.Run.Command) == "" {
+		result.addError(ParseError{
+			Field:   stepField + ".run.command",
+			Message: "run command is required",
+			Hint:    "Use run: \"go test ./...\" or run: {command: ...}",
+		})
+	}
+
+	if step.Run.Capture != "" && !isValidRunCapture(step.Run.Capture) {
+		result.addError(ParseError{
+			Field:   stepField + ".run.capture",
+			Message: fmt.Sprintf("invalid capture value: %s", step.Run.Capture),
+			Hint:    "Valid values: stdout, stderr, combined",
+		})
+	}
+
+	if step.Agent != "" || step.Pane > 0 || step.Route != "" {
+		result.addWarning(ParseError{
+			Field:   stepField,
+			Message: "agent, pane, and route are ignored for run steps",
+			Hint:    "Run steps execute on the host, not in an agent pane",
+		})
+	}
+
+	if step.Wait != "" {
+		result.addWarning(ParseError{
+			Field:   stepField + ".wait",
+			Message: "wait is ignored for run steps",
+			Hint:    "Run steps always wait for the command to exit; use timeout to bound it",
+		})
+	}
+}
+
+// validateApprovalConfig checks the approval block of a gate step
+func validateApprovalConfig(step *Step, stepField string, result *ValidationResult) {
+	if strings.TrimSpace(step.Approval.Message) == "" {
+		result.addError(ParseError{
+			Field:   stepField + ".approval.message",
+			Message: "approval message is required",
+			Hint:    "Tell the approver what they are signing off on",
+		})
+	}
+
+	if step.Agent != "" || step.Pane > 0 || step.Route != "" || step.Wait != "" {
+		result.addWarning(ParseError{
+			Field:   stepField,
+			Message: "agent, pane, route, and wait are ignored for approval steps",
+			Hint:    "Approval steps wait for a human decision; use timeout to bound it",
+		})
+	}
+}
+
+// validateMatrix checks a matrix step and reserves its expansions' step IDs
+func validateMatrix(step *Step, stepField string, stepIDs map[string]bool, result *ValidationResult) {
+	if len(step.Matrix) == 0 {
+		if step.Collect != "" {
+			result.addError(ParseError{
+				Field:   stepField + ".collect",
+				Message: "collect is only valid on matrix steps",
+			})
+		}
+		return
+	}
+
+	if len(step.Parallel) > 0 || step.Loop != nil || step.Approval != nil {
+		result.addError(ParseError{
+			Field:   stepField + ".matrix",
+			Message: "matrix cannot be combined with parallel, loop, or approval",
+			Hint:    "Put the prompt, run command, or uses reference on the matrix step itself",
+		})
+	}
+	if strings.Contains(stepField, ".parallel[") {
+		result.addError(ParseError{
+			Field:   stepField + ".matrix",
+			Message: "matrix steps cannot be nested in a parallel group",
+			Hint:    "A matrix step already runs its expansions in parallel",
+		})
+	}
+	if step.Pane > 0 {
+		result.addError(ParseError{
+			Field:   stepField + ".matrix",
+			Message: "matrix steps cannot target a fixed pane",
+			Hint:    "Use agent/route selection so expansions can run on different panes",
+		})
+	}
+
+	for _, key := range sortedKeys(step.Matrix) {
+		values := step.Matrix[key]
+		if !isValidID(key) {
+			result.addError(ParseError{
+				Field:   stepField + ".matrix." + key,
+				Message: fmt.Sprintf("invalid matrix key: %s", key),
+				Hint:    "Use alphanumeric characters, underscores, and hyphens only",
+			})
+		}
+		if len(values) == 0 {
+			result.addError(ParseError{
+				Field:   stepField + ".matrix." + key,
+				Message: fmt.Sprintf("matrix key %s has no values", key),
+			})
+		}
+		if key == "agent" {
+			if step.Agent != "" {
+				result.addError(ParseError{
+					Field:   stepField + ".agent",
+					Message: "step cannot set agent when its matrix varies agent",
+					Hint:    "Remove agent or drop the agent key from matrix",
+				})
+			}
+			for _, v := range values {
+				if !IsValidAgentType(v) {
+					result.addWarning(ParseError{
+						Field:   stepField + ".matrix.agent",
+						Message: fmt.Sprintf("unknown agent type: %s", v),
+						Hint:    "Valid types: claude, codex, gemini (and aliases)",
+					})
+				}
+			}
+		}
+	}
+
+	if n := matrixSize(step); n > MaxMatrixExpansions {
+		result.addError(ParseError{
+			Field:   stepField + ".matrix",
+			Message: fmt.Sprintf("matrix expands to %d steps (max %d)", n, MaxMatrixExpansions),
+			Hint:    "Split the comparison across several matrix steps",
+		})
+	}
+
+	if step.Collect != "" && step.Collect != MatrixCollectConcat && step.Collect != MatrixCollectJSON {
+		result.addError(ParseError{
+			Field:   stepField + ".collect",
+			Message: fmt.Sprintf("invalid collect value: %s", step.Collect),
+			Hint:    "Valid values: concat, json",
+		})
+	}
+
+	// Expansion IDs share the step ID namespace
+	if step.ID != "" {
+		for _, combo := range matrixCombinations(step) {
+			id := step.ID + "-" + combo.suffix
+			if stepIDs[id] {
+				result.addError(ParseError{
+					Field:   stepField + ".matrix",
+					Message: fmt.Sprintf("matrix expansion id %s duplicates another step id", id),
+					Hint:    "Rename the matrix step or the conflicting step",
+				})
+			}
+			stepIDs[id] = true
+		}
+	}
+}
+
 // detectCycles finds circular dependencies in steps
 func detectCycles(steps []Step) [][]string {
 	// Build dependency graph
@@ -423,7 +644,7 @@ func validateVariableRefs(w *Workflow, result *ValidationResult) {
 						Hint:    "Use ${steps.step_id.output}",
 					})
 				}
-			case "env", "session", "timestamp", "run_id", "workflow", "loop":
+			case "env", "session", "timestamp", "run_id", "workflow", "loop", "matrix":
 				// Valid built-in references
 			default:
 				result.addWarning(ParseError{
@@ -446,6 +667,17 @@ func validateVariableRefs(w *Workflow, result *ValidationResult) {
 			if step.When != "" {
 				checkString(step.When, stepField+".when")
 			}
+			if step.Run != nil {
+				checkString(step.Run.Command, stepField+".run.command")
+			}
+			if step.Approval != nil {
+				checkString(step.Approval.Message, stepField+".approval.message")
+			}
+			for _, k := range sortedKeys(step.With) {
+				if v, ok := step.With[k].(string); ok {
+					checkString(v, stepField+".with."+k)
+				}
+			}
 			// Check parallel sub-steps
 			if len(step.Parallel) > 0 {
 				checkSteps(step.Parallel, stepField+".parallel")
@@ -499,6 +731,14 @@ func isValidWaitCondition(w WaitCondition) bool {
 	return false
 }
 
+func isValidRunCapture(c RunCapture) bool {
+	switch c {
+	case RunCaptureStdout, RunCaptureStderr, RunCaptureCombined:
+		return true
+	}
+	return false
+}
+
 func isValidPath(p string) bool {
 	// Basic path validation - not empty, no null bytes
 	if p == "" {
@@ -581,6 +581,38 @@ func TestValidate_InvalidWaitCondition(t *testing.T) {
 	}
 }
 
+func TestValidate_QueueOnPromptSteps(t *testing.T) {
+	t.Parallel()
+
+	w, err := ParseString(`
+schema_version: "2.0"
+name: test
+steps:
+  - id: follow_up
+    pane: 2
+    queue: true
+    prompt: add tests
+  - id: lint
+    queue: true
+    run:
+      command: make lint
+`, "yaml")
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	if !w.Steps[0].Queue {
+		t.Error("queue: true not parsed")
+	}
+
+	result := Validate(w)
+	if !result.Valid {
+		t.Fatalf("expected validation to pass, got errors: %v", result.Errors)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Field != "steps[1].queue" {
+		t.Errorf("warnings = %v, want one for steps[1].queue", result.Warnings)
+	}
+}
+
 func TestValidate_LoopWithMissingItems(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Dicklesworthstone/ntm/internal/inbox"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// SetPromptQueue sets the dispatcher used by steps with queue: true. When
+// unset, one backed by the default state store is opened for each step.
+func (e *Executor) SetPromptQueue(d *inbox.Dispatcher) {
+	e.promptQueue = d
+}
+
+// promptDispatcher returns the dispatcher for a queued step and a func that
+// releases it.
+func (e *Executor) promptDispatcher() (*inbox.Dispatcher, func(), error) {
+	if e.promptQueue != nil {
+		return e.promptQueue, func() {}, nil
+	}
+	store, err := inbox.OpenStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	return inbox.NewDispatcher(store), func() { store.Close() }, nil
+}
+
+// sendPrompt sends prompt to paneID and returns the pane's output from just
+// before the agent received it. Steps with queue: true add the prompt to the
+// pane's queue and block until it has been delivered.
+func (e *Executor) sendPrompt(ctx context.Context, step *Step, paneID, prompt string) (string, error) {
+	if !step.Queue {
+		before, _ := tmux.CapturePaneOutput(paneID, 2000)
+		return before, tmux.PasteKeys(paneID, prompt, true)
+	}
+
+	panes, err := tmux.GetPanes(e.config.Session)
+	if err != nil {
+		return "", err
+	}
+	index := -1
+	for _, p := range panes {
+		if p.ID == paneID {
+			index = p.Index
+			break
+		}
+	}
+	if index < 0 {
+		return "", fmt.Errorf("pane %s not found in session %s", paneID, e.config.Session)
+	}
+
+	d, release, err := e.promptDispatcher()
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	queued, err := inbox.Enqueue(d.Store, e.config.Session, index, prompt, inbox.SourcePipeline)
+	if err != nil {
+		return "", err
+	}
+	// The baseline is retaken while the prompt waits, so output from prompts
+	// ahead of this one does not count towards the step, and is never taken
+	// after the prompt was claimed for delivery.
+	p, before, err := d.WaitCapture(ctx, queued.ID, func() string {
+		out, _ := tmux.CapturePaneOutput(paneID, 2000)
+		return out
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			_ = d.Store.CancelQueuedPrompt(queued.ID)
+		}
+		return "", err
+	}
+	if p.Status != state.QueuedDelivered {
+		return "", fmt.Errorf("queued prompt %s %s: %s", p.ID, p.Status, p.Error)
+	}
+	return before, nil
+}
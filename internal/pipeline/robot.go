@@ -94,6 +94,7 @@ type PipelineStep struct {
 	DurationMs  int64  `json:"duration_ms,omitempty"`
 	OutputLines int    `json:"output_lines,omitempty"`
 	Error       string `json:"error,omitempty"`
+	ApprovalID  string `json:"approval_id,omitempty"`
 }
 
 // PipelineRunOptions configures a pipeline run
@@ -103,6 +104,7 @@ type PipelineRunOptions struct {
 	Variables    map[string]interface{} // Runtime variables
 	DryRun       bool                   // Validate without executing
 	Background   bool                   // Run in background
+	BudgetCheck  BudgetCheckFunc        // Optional: consulted before each prompt is sent
 }
 
 // PipelineRunOutput is the response for --robot-pipeline-run
@@ -232,6 +234,7 @@ func PrintPipelineRun(opts PipelineRunOptions) int {
 		execCfg.ProjectDir = projectDir
 	}
 	execCfg.WorkflowFile = workflowPath
+	execCfg.BudgetCheck = opts.BudgetCheck
 	executor := NewExecutor(execCfg)
 
 	// Create context
@@ -540,7 +543,7 @@ func calculateProgress(state *ExecutionState) PipelineProgress {
 		switch result.Status {
 		case StatusCompleted:
 			progress.Completed++
-		case StatusRunning:
+		case StatusRunning, StatusPaused:
 			progress.Running++
 		case StatusFailed:
 			progress.Failed++
@@ -566,10 +569,11 @@ func convertSteps(state *ExecutionState) map[string]PipelineStep {
 
 	for id, result := range state.Steps {
 		step := PipelineStep{
-			ID:       id,
-			Status:   string(result.Status),
-			Agent:    result.AgentType,
-			PaneUsed: result.PaneUsed,
+			ID:         id,
+			Status:     string(result.Status),
+			Agent:      result.AgentType,
+			PaneUsed:   result.PaneUsed,
+			ApprovalID: result.ApprovalID,
 		}
 
 		if !result.StartedAt.IsZero() {
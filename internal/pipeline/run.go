@@ -0,0 +1,342 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/policy"
+)
+
+// maxRunOutputBytes caps how much of a run step's stdout/stderr is kept in
+// execution state. Longer output keeps its tail, where test and build
+// failures are usually reported.
+const maxRunOutputBytes = 256 * 1024
+
+// SetPolicy sets the command policy that run steps are checked against.
+// When unset, the policy is loaded with policy.LoadOrDefault on first use.
+func (e *Executor) SetPolicy(p *policy.Policy) {
+	e.policy = p
+}
+
+// commandPolicy returns the policy used for run steps, loading it lazily.
+func (e *Executor) commandPolicy() (*policy.Policy, error) {
+	e.policyOnce.Do(func() {
+		if e.policy != nil {
+			return
+		}
+		e.policy, e.policyErr = policy.LoadOrDefault()
+	})
+	return e.policy, e.policyErr
+}
+
+// executeRunStep runs a step's shell command once and records its exit code
+// and output streams.
+func (e *Executor) executeRunStep(ctx context.Context, step *Step) StepResult {
+	result := StepResult{
+		StepID:    step.ID,
+		Status:    StatusRunning,
+		StartedAt: time.Now(),
+		AgentType: "shell",
+	}
+
+	command, varEnv := e.substituteCommand(step.Run.Command)
+	if command == "" {
+		result.Status = StatusFailed
+		result.Error = &StepError{
+			Type:      "validation",
+			Message:   "run step has no command",
+			Timestamp: time.Now(),
+		}
+		result.FinishedAt = time.Now()
+		return result
+	}
+
+	// Run steps go through the same destructive-command policy as agents.
+	pol, err := e.commandPolicy()
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = &StepError{
+			Type:      "policy",
+			Message:   fmt.Sprintf("failed to load command policy: %v", err),
+			Timestamp: time.Now(),
+		}
+		result.FinishedAt = time.Now()
+		return result
+	}
+	if match := pol.Check(command); match != nil && match.Action != policy.ActionAllow {
+		msg := fmt.Sprintf("command blocked by policy: %s", match.Reason)
+		if match.Action == policy.ActionApprove {
+			msg = fmt.Sprintf("command requires approval: %s", match.Reason)
+		}
+		result.Status = StatusFailed
+		result.Error = &StepError{
+			Type:      "policy",
+			Message:   msg,
+			Details:   fmt.Sprintf("pattern: %s", match.Pattern),
+			Timestamp: time.Now(),
+		}
+		result.FinishedAt = time.Now()
+		return result
+	}
+
+	if e.config.DryRun {
+		result.Status = StatusCompleted
+		result.Output = "[DRY RUN] Would run: " + truncatePrompt(command, 100)
+		result.FinishedAt = time.Now()
+		return result
+	}
+
+	timeout := e.config.DefaultTimeout
+	if step.Timeout.Duration > 0 {
+		timeout = step.Timeout.Duration
+	}
+	runCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(runCtx, "/bin/sh", "-c", command)
+	cmd.Dir = e.resolveRunDir(e.substituteVariables(step.Run.Dir))
+	cmd.Env = os.Environ()
+	envKeys := make([]string, 0, len(step.Run.Env))
+	for k := range step.Run.Env {
+		envKeys = append(envKeys, k)
+	}
+	sort.Strings(envKeys)
+	for _, k := range envKeys {
+		cmd.Env = append(cmd.Env, k+"="+e.substituteVariables(step.Run.Env[k]))
+	}
+	cmd.Env = append(cmd.Env, varEnv...)
+	configureRunProcess(cmd)
+	// Don't hang on grandchildren that inherit the output pipes.
+	cmd.WaitDelay = 5 * time.Second
+
+	var stdout, stderr, combined bytes.Buffer
+	var outMu sync.Mutex
+	cmd.Stdout = &teeWriter{mu: &outMu, primary: &stdout, combined: &combined}
+	cmd.Stderr = &teeWriter{mu: &outMu, primary: &stderr, combined: &combined}
+
+	e.emitProgress("step_run", step.ID, fmt.Sprintf("Running: %s", truncatePrompt(command, 80)), e.calculateProgress())
+	runErr := cmd.Run()
+
+	exitCode := 0
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		} else {
+			exitCode = -1
+		}
+	}
+
+	stdoutStr := tailOutput(stdout.String())
+	stderrStr := tailOutput(stderr.String())
+	result.ExitCode = &exitCode
+
+	switch step.Run.Capture {
+	case RunCaptureStderr:
+		result.Output = stderrStr
+	case RunCaptureCombined:
+		result.Output = tailOutput(combined.String())
+	default:
+		result.Output = stdoutStr
+	}
+
+	e.varMu.Lock()
+	StoreRunOutput(e.state, step.ID, exitCode, stdoutStr, stderrStr)
+	e.varMu.Unlock()
+
+	result.FinishedAt = time.Now()
+
+	switch {
+	case runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+		result.Status = StatusFailed
+		result.Error = &StepError{
+			Type:      "timeout",
+			Message:   fmt.Sprintf("command timed out after %s", timeout),
+			Details:   tailLines(stderrStr, 50),
+			Timestamp: time.Now(),
+		}
+		return result
+	case ctx.Err() != nil:
+		result.Status = StatusCancelled
+		return result
+	case runErr != nil && exitCode == -1:
+		result.Status = StatusFailed
+		result.Error = &StepError{
+			Type:      "crash",
+			Message:   fmt.Sprintf("failed to run command: %v", runErr),
+			Timestamp: time.Now(),
+		}
+		return result
+	case exitCode != 0 && !step.Run.AllowFailure:
+		result.Status = StatusFailed
+		result.Error = &StepError{
+			Type:      "exit_code",
+			Message:   fmt.Sprintf("command exited with code %d", exitCode),
+			Details:   tailLines(stderrStr, 50),
+			Timestamp: time.Now(),
+		}
+		return result
+	}
+
+	if step.OutputParse.Type != "" && step.OutputParse.Type != "none" {
+		parsed, err := e.parseOutput(result.Output, step.OutputParse)
+		if err != nil {
+			e.emitProgress("step_warning", step.ID,
+				fmt.Sprintf("output parse warning: %v", err),
+				e.calculateProgress())
+		} else {
+			result.ParsedData = parsed
+		}
+	}
+
+	result.Status = StatusCompleted
+	return result
+}
+
+// runVarEnvPrefix names the environment variables that carry substituted
+// values into a run step's shell.
+const runVarEnvPrefix = "NTM_RUN_VAR_"
+
+// substituteCommand resolves the ${...} references in a run command. Values
+// often come from agent output, so they are never pasted into the script:
+// each reference becomes a quoted expansion of an environment variable and
+// the values are returned as environment entries for the shell. The policy
+// check therefore sees the command as written, and a value like "; rm -rf ~"
+// stays a plain argument. Unresolved references are left as written.
+func (e *Executor) substituteCommand(command string) (string, []string) {
+	e.varMu.RLock()
+	defer e.varMu.RUnlock()
+	sub := NewSubstitutor(e.state, e.config.Session, e.state.WorkflowID)
+
+	escaped := escapedPattern.ReplaceAllString(command, escapePlaceholder)
+	var out strings.Builder
+	var env []string
+	var quote byte // ' or " while inside a quoted shell string
+	last := 0
+	for _, loc := range varPattern.FindAllStringIndex(escaped, -1) {
+		quote = shellQuoteState(escaped[last:loc[0]], quote)
+		out.WriteString(escaped[last:loc[0]])
+		last = loc[1]
+
+		match := escaped[loc[0]:loc[1]]
+		value, err := sub.Substitute(match)
+		if err != nil {
+			out.WriteString(match)
+			continue
+		}
+		name := fmt.Sprintf("%s%d", runVarEnvPrefix, len(env)+1)
+		env = append(env, name+"="+value)
+		switch quote {
+		case '"':
+			out.WriteString("${" + name + "}")
+		case '\'':
+			out.WriteString(`'"${` + name + `}"'`)
+		default:
+			out.WriteString(`"${` + name + `}"`)
+		}
+	}
+	out.WriteString(escaped[last:])
+	return strings.ReplaceAll(out.String(), escapePlaceholder, "${"), env
+}
+
+// shellQuoteState returns the quoting in effect after s, given the quoting
+// in effect before it: 0, a single quote or a double quote.
+func shellQuoteState(s string, quote byte) byte {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			i++ // the next byte is escaped
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		}
+	}
+	return quote
+}
+
+// resolveRunDir resolves a run step's working directory against the project dir.
+func (e *Executor) resolveRunDir(dir string) string {
+	base := e.config.ProjectDir
+	if dir == "" {
+		return base
+	}
+	if filepath.IsAbs(dir) || base == "" {
+		return dir
+	}
+	return filepath.Join(base, dir)
+}
+
+// StoreRunOutput stores a run step's exit code and streams for variable access
+// as ${steps.<id>.exit_code}, ${steps.<id>.stdout} and ${steps.<id>.stderr}.
+// Note: Caller must hold any necessary locks on state.Variables if used concurrently.
+func StoreRunOutput(state *ExecutionState, stepID string, exitCode int, stdout, stderr string) {
+	if state.Variables == nil {
+		state.Variables = make(map[string]interface{})
+	}
+
+	state.Variables["steps."+stepID+".exit_code"] = exitCode
+	state.Variables["steps."+stepID+".stdout"] = stdout
+	state.Variables["steps."+stepID+".stderr"] = stderr
+}
+
+// teeWriter writes to a per-stream buffer and to a shared combined buffer.
+// exec copies stdout and stderr from separate goroutines, so writes share a lock.
+type teeWriter struct {
+	mu       *sync.Mutex
+	primary  *bytes.Buffer
+	combined *bytes.Buffer
+}
+
+func (w *teeWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.primary.Write(p)
+	w.combined.Write(p)
+	return len(p), nil
+}
+
+// tailOutput keeps the last maxRunOutputBytes of s.
+func tailOutput(s string) string {
+	if len(s) <= maxRunOutputBytes {
+		return s
+	}
+	return "[truncated]\n" + s[len(s)-maxRunOutputBytes:]
+}
+
+// tailLines returns the last n lines of s.
+func tailLines(s string, n int) string {
+	if n <= 0 || s == "" {
+		return ""
+	}
+	count := 0
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == '\n' && i != len(s)-1 {
+			count++
+			if count == n {
+				return s[i+1:]
+			}
+		}
+	}
+	return s
+}
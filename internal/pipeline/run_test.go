@@ -0,0 +1,339 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/policy"
+)
+
+func newRunTestExecutor(t *testing.T) *Executor {
+	t.Helper()
+	cfg := DefaultExecutorConfig("test-session")
+	cfg.ProjectDir = t.TempDir()
+	e := NewExecutor(cfg)
+	e.SetPolicy(policy.DefaultPolicy())
+	return e
+}
+
+func TestParseString_RunStep(t *testing.T) {
+	t.Parallel()
+
+	yamlContent := `
+schema_version: "2.0"
+name: run-test
+steps:
+  - id: short
+    run: go test ./...
+  - id: full
+    run:
+      command: make lint
+      dir: sub
+      env:
+        CI: "1"
+      capture: combined
+      allow_failure: true
+`
+	w, err := ParseString(yamlContent, "yaml")
+	if err != nil {
+		t.Fatalf("ParseString(yaml) error: %v", err)
+	}
+	if w.Steps[0].Run == nil || w.Steps[0].Run.Command != "go test ./..." {
+		t.Errorf("short form run = %+v, want command 'go test ./...'", w.Steps[0].Run)
+	}
+	full := w.Steps[1].Run
+	if full == nil || full.Command != "make lint" || full.Dir != "sub" || full.Env["CI"] != "1" ||
+		full.Capture != RunCaptureCombined || !full.AllowFailure {
+		t.Errorf("full form run = %+v", full)
+	}
+
+	tomlContent := `
+schema_version = "2.0"
+name = "run-test"
+
+[[steps]]
+id = "short"
+run = "go vet ./..."
+
+[[steps]]
+id = "full"
+[steps.run]
+command = "go build ./..."
+capture = "stderr"
+`
+	w, err = ParseString(tomlContent, "toml")
+	if err != nil {
+		t.Fatalf("ParseString(toml) error: %v", err)
+	}
+	if w.Steps[0].Run == nil || w.Steps[0].Run.Command != "go vet ./..." {
+		t.Errorf("toml short form run = %+v", w.Steps[0].Run)
+	}
+	if w.Steps[1].Run == nil || w.Steps[1].Run.Capture != RunCaptureStderr {
+		t.Errorf("toml full form run = %+v", w.Steps[1].Run)
+	}
+}
+
+func TestValidate_RunStep(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		step      Step
+		wantValid bool
+	}{
+		{"valid run", Step{ID: "a", Run: &RunConfig{Command: "true"}}, true},
+		{"empty command", Step{ID: "a", Run: &RunConfig{}}, false},
+		{"run with prompt", Step{ID: "a", Prompt: "hi", Run: &RunConfig{Command: "true"}}, false},
+		{"bad capture", Step{ID: "a", Run: &RunConfig{Command: "true", Capture: "both"}}, false},
+	}
+	for _, tt := range tests {
+		w := &Workflow{SchemaVersion: SchemaVersion, Name: "w", Steps: []Step{tt.step}}
+		if got := Validate(w).Valid; got != tt.wantValid {
+			t.Errorf("%s: Valid = %v, want %v (%+v)", tt.name, got, tt.wantValid, Validate(w).Errors)
+		}
+	}
+}
+
+func TestExecutor_RunStep_CapturesOutputAndExitCode(t *testing.T) {
+	t.Parallel()
+
+	e := newRunTestExecutor(t)
+	workflow := &Workflow{
+		SchemaVersion: SchemaVersion,
+		Name:          "run-workflow",
+		Settings:      DefaultWorkflowSettings(),
+		Steps: []Step{
+			{
+				ID:          "check",
+				Run:         &RunConfig{Command: `echo '{"ok": true}'; echo warn >&2; exit 3`, AllowFailure: true},
+				OutputVar:   "check_out",
+				OutputParse: OutputParse{Type: "json"},
+			},
+			{
+				ID:        "fix",
+				DependsOn: []string{"check"},
+				When:      "${steps.check.exit_code} != 0",
+				Run:       &RunConfig{Command: "printf fixed-$GREETING", Env: map[string]string{"GREETING": "${vars.who}"}},
+				OutputVar: "fix_out",
+			},
+			{
+				ID:        "skipped",
+				DependsOn: []string{"check"},
+				When:      "${vars.check_out_exit_code} == 0",
+				Run:       &RunConfig{Command: "echo never"},
+			},
+		},
+	}
+
+	state, err := e.Run(context.Background(), workflow, map[string]interface{}{"who": "world"}, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	check := state.Steps["check"]
+	if check.Status != StatusCompleted {
+		t.Fatalf("check status = %v, want completed (err=%+v)", check.Status, check.Error)
+	}
+	if check.ExitCode == nil || *check.ExitCode != 3 {
+		t.Errorf("check exit code = %v, want 3", check.ExitCode)
+	}
+	if parsed, ok := state.Variables["check_out_parsed"].(map[string]interface{}); !ok || parsed["ok"] != true {
+		t.Errorf("check_out_parsed = %v, want map with ok=true", state.Variables["check_out_parsed"])
+	}
+	if got := state.Variables["steps.check.stderr"]; got != "warn\n" {
+		t.Errorf("steps.check.stderr = %q, want %q", got, "warn\n")
+	}
+
+	if got := state.Variables["fix_out"]; got != "fixed-world" {
+		t.Errorf("fix_out = %q, want %q", got, "fixed-world")
+	}
+	if state.Steps["skipped"].Status != StatusSkipped {
+		t.Errorf("skipped status = %v, want skipped", state.Steps["skipped"].Status)
+	}
+}
+
+func TestExecutor_RunStep_NonZeroExitFails(t *testing.T) {
+	t.Parallel()
+
+	e := newRunTestExecutor(t)
+	step := &Step{ID: "fail", Run: &RunConfig{Command: "echo boom >&2; exit 1"}}
+	e.state = &ExecutionState{Variables: map[string]interface{}{}, Steps: map[string]StepResult{}}
+
+	result := e.executeRunStep(context.Background(), step)
+	if result.Status != StatusFailed {
+		t.Fatalf("status = %v, want failed", result.Status)
+	}
+	if result.Error == nil || result.Error.Type != "exit_code" || !strings.Contains(result.Error.Details, "boom") {
+		t.Errorf("error = %+v, want exit_code error with stderr details", result.Error)
+	}
+}
+
+func TestExecutor_RunStep_Timeout(t *testing.T) {
+	t.Parallel()
+
+	e := newRunTestExecutor(t)
+	step := &Step{ID: "slow", Run: &RunConfig{Command: "sleep 5"}, Timeout: Duration{Duration: 100 * time.Millisecond}}
+	e.state = &ExecutionState{Variables: map[string]interface{}{}, Steps: map[string]StepResult{}}
+
+	start := time.Now()
+	result := e.executeRunStep(context.Background(), step)
+	if result.Status != StatusFailed || result.Error == nil || result.Error.Type != "timeout" {
+		t.Fatalf("result = %+v, want timeout failure", result)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("timeout took %v, expected prompt kill", elapsed)
+	}
+}
+
+func TestExecutor_RunStep_PolicyBlocked(t *testing.T) {
+	t.Parallel()
+
+	e := newRunTestExecutor(t)
+	step := &Step{ID: "danger", Run: &RunConfig{Command: "git reset --hard HEAD~3"}}
+	e.state = &ExecutionState{Variables: map[string]interface{}{}, Steps: map[string]StepResult{}}
+
+	result := e.executeRunStep(context.Background(), step)
+	if result.Status != StatusFailed || result.Error == nil || result.Error.Type != "policy" {
+		t.Fatalf("result = %+v, want policy failure", result)
+	}
+	if result.ExitCode != nil {
+		t.Error("blocked command should never run")
+	}
+}
+
+func TestExecutor_RunStep_VariablesAreNotShell(t *testing.T) {
+	t.Parallel()
+
+	e := newRunTestExecutor(t)
+	marker := e.config.ProjectDir + "/pwned"
+	reply := `it's done; touch ` + marker + ` $(touch ` + marker + `) "` + "`touch " + marker + "`"
+	e.state = &ExecutionState{
+		Variables: map[string]interface{}{"reply": reply},
+		Steps:     map[string]StepResult{},
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{"unquoted", `printf '%s' ${vars.reply}`, reply},
+		{"double quoted", `printf '%s' "said: ${vars.reply}!"`, "said: " + reply + "!"},
+		{"single quoted", `printf '%s' 'said: ${vars.reply}!'`, "said: " + reply + "!"},
+	}
+	for _, tt := range tests {
+		step := &Step{ID: "echo", Run: &RunConfig{Command: tt.command, AllowFailure: true}}
+		result := e.executeRunStep(context.Background(), step)
+		if result.Status != StatusCompleted || result.Output != tt.want {
+			t.Errorf("%s: output = %q (status %v, err %+v), want %q", tt.name, result.Output, result.Status, result.Error, tt.want)
+		}
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("substituted value was run as shell")
+	}
+}
+
+func TestExecutor_RunStep_WorkingDir(t *testing.T) {
+	t.Parallel()
+
+	e := newRunTestExecutor(t)
+	step := &Step{ID: "pwd", Run: &RunConfig{Command: "mkdir -p sub && cd sub && pwd"}}
+	e.state = &ExecutionState{Variables: map[string]interface{}{}, Steps: map[string]StepResult{}}
+
+	result := e.executeRunStep(context.Background(), step)
+	if result.Status != StatusCompleted {
+		t.Fatalf("status = %v (%+v)", result.Status, result.Error)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(result.Output), e.config.ProjectDir) {
+		t.Errorf("output %q should be under project dir %q", result.Output, e.config.ProjectDir)
+	}
+
+	if got := e.resolveRunDir("sub"); got != e.config.ProjectDir+"/sub" {
+		t.Errorf("resolveRunDir(sub) = %q", got)
+	}
+	if got := e.resolveRunDir("/abs"); got != "/abs" {
+		t.Errorf("resolveRunDir(/abs) = %q", got)
+	}
+}
+
+func TestExecutor_RunStep_WhileLoop(t *testing.T) {
+	t.Parallel()
+
+	e := newRunTestExecutor(t)
+	counter := e.config.ProjectDir + "/count"
+	workflow := &Workflow{
+		SchemaVersion: SchemaVersion,
+		Name:          "retry-until-green",
+		Settings:      DefaultWorkflowSettings(),
+		Vars: map[string]VarDef{
+			"attempt_exit_code": {Default: 1},
+		},
+		Steps: []Step{
+			{
+				ID: "until-green",
+				Loop: &LoopConfig{
+					While:         "${vars.attempt_exit_code} != 0",
+					MaxIterations: 5,
+					Steps: []Step{
+						{
+							ID: "attempt",
+							// Fails twice, then succeeds.
+							Run:       &RunConfig{Command: "echo x >> " + counter + "; test $(wc -l < " + counter + ") -ge 3", AllowFailure: true},
+							OutputVar: "attempt",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if res := Validate(workflow); !res.Valid {
+		t.Fatalf("Validate() errors: %+v", res.Errors)
+	}
+
+	state, err := e.Run(context.Background(), workflow, nil, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if got := state.Variables["attempt_exit_code"]; got != 0 {
+		t.Errorf("attempt_exit_code = %v, want 0", got)
+	}
+	if got := state.Steps["until-green"].Output; !strings.Contains(got, "3 iterations") {
+		t.Errorf("loop output = %q, want 3 iterations", got)
+	}
+}
+
+func TestExecutor_RunStep_DryRunParallel(t *testing.T) {
+	t.Parallel()
+
+	e := newRunTestExecutor(t)
+	e.config.DryRun = true
+	workflow := &Workflow{
+		SchemaVersion: SchemaVersion,
+		Name:          "run-dry-parallel",
+		Settings:      DefaultWorkflowSettings(),
+		Steps: []Step{
+			{
+				ID: "checks",
+				Parallel: []Step{
+					{ID: "lint", Run: &RunConfig{Command: "make lint"}, OutputVar: "lint_out"},
+					{ID: "test", Run: &RunConfig{Command: "make test"}, OutputVar: "test_out"},
+				},
+			},
+		},
+	}
+
+	state, err := e.Run(context.Background(), workflow, nil, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if got, _ := state.Variables["lint_out"].(string); !strings.HasPrefix(got, "[DRY RUN]") {
+		t.Errorf("lint_out = %q, want dry-run output", got)
+	}
+	if _, ok := state.Variables["lint_out_exit_code"]; ok {
+		t.Error("lint_out_exit_code set for a dry-run step")
+	}
+}
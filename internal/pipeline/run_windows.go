@@ -0,0 +1,9 @@
+//go:build windows
+
+package pipeline
+
+import "os/exec"
+
+// configureRunProcess is a no-op on Windows; exec.CommandContext kills the
+// shell process directly.
+func configureRunProcess(cmd *exec.Cmd) {}
@@ -1,6 +1,7 @@
 package pipeline
 
 import (
+	"fmt"
 	"strings"
 	"time"
 )
@@ -24,6 +25,14 @@ type Workflow struct {
 
 	// Step definitions
 	Steps []Step `yaml:"steps" toml:"steps" json:"steps"`
+
+	// Outputs exposed to a parent workflow that includes this one via uses,
+	// as name -> template evaluated after the last step (e.g. ${steps.review.output})
+	Outputs map[string]string `yaml:"outputs,omitempty" toml:"outputs,omitempty" json:"outputs,omitempty"`
+
+	// source is the file the workflow was parsed from; relative uses paths
+	// resolve against its directory.
+	source string
 }
 
 // VarDef defines a workflow variable with optional default and type info
@@ -101,6 +110,9 @@ type Step struct {
 	Wait    WaitCondition `yaml:"wait,omitempty" toml:"wait,omitempty" json:"wait,omitempty"` // completion, idle, time, none
 	Timeout Duration      `yaml:"timeout,omitempty" toml:"timeout,omitempty" json:"timeout,omitempty"`
 
+	// Deliver through the pane's prompt queue once the agent is idle
+	Queue bool `yaml:"queue,omitempty" toml:"queue,omitempty" json:"queue,omitempty"`
+
 	// Dependencies
 	DependsOn []string `yaml:"depends_on,omitempty" toml:"depends_on,omitempty" json:"depends_on,omitempty"`
 
@@ -117,6 +129,17 @@ type Step struct {
 	OutputVar   string      `yaml:"output_var,omitempty" toml:"output_var,omitempty" json:"output_var,omitempty"`       // Store output in variable
 	OutputParse OutputParse `yaml:"output_parse,omitempty" toml:"output_parse,omitempty" json:"output_parse,omitempty"` // none, json, yaml, lines, first_line, regex
 
+	// Shell command execution (mutually exclusive with Prompt and Parallel)
+	Run *RunConfig `yaml:"run,omitempty" toml:"run,omitempty" json:"run,omitempty"`
+
+	// Human approval gate (mutually exclusive with Prompt, Run and Parallel)
+	Approval *ApprovalConfig `yaml:"approval,omitempty" toml:"approval,omitempty" json:"approval,omitempty"`
+
+	// Sub-workflow invocation: a workflow file path or a name resolved via the
+	// workflow search paths (mutually exclusive with Prompt, Run, Approval and Parallel)
+	Uses string                 `yaml:"uses,omitempty" toml:"uses,omitempty" json:"uses,omitempty"`
+	With map[string]interface{} `yaml:"with,omitempty" toml:"with,omitempty" json:"with,omitempty"` // Inputs mapped onto the sub-workflow's vars
+
 	// Parallel execution (mutually exclusive with Prompt)
 	Parallel []Step `yaml:"parallel,omitempty" toml:"parallel,omitempty" json:"parallel,omitempty"`
 
@@ -125,8 +148,24 @@ type Step struct {
 
 	// Loop control: break or continue (only valid inside loops)
 	LoopControl LoopControl `yaml:"loop_control,omitempty" toml:"loop_control,omitempty" json:"loop_control,omitempty"`
+
+	// Matrix fan-out: run the step once per combination of values as parallel
+	// sub-steps; agent, model and persona keys also steer routing
+	Matrix  map[string][]string `yaml:"matrix,omitempty" toml:"matrix,omitempty" json:"matrix,omitempty"`
+	Collect MatrixCollect       `yaml:"collect,omitempty" toml:"collect,omitempty" json:"collect,omitempty"` // concat (default), json
+
+	// matrixValues holds the combination an expanded matrix sub-step runs with
+	matrixValues map[string]string
 }
 
+// MatrixCollect defines how a matrix step aggregates its expansions' outputs
+type MatrixCollect string
+
+const (
+	MatrixCollectConcat MatrixCollect = "concat" // Outputs joined under per-expansion headings
+	MatrixCollectJSON   MatrixCollect = "json"   // JSON array of {id, matrix, status, output, data}
+)
+
 // RoutingStrategy defines how to select an agent for a step
 type RoutingStrategy string
 
@@ -158,6 +197,89 @@ func (o *OutputParse) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// RunConfig defines a shell command executed on the host instead of in an agent pane.
+// It can be written as a plain string (run: "go test ./...") or as a table.
+type RunConfig struct {
+	Command      string            `yaml:"command" toml:"command" json:"command"`                                                 // Executed via /bin/sh -c
+	Dir          string            `yaml:"dir,omitempty" toml:"dir,omitempty" json:"dir,omitempty"`                               // Working directory (relative to project dir)
+	Env          map[string]string `yaml:"env,omitempty" toml:"env,omitempty" json:"env,omitempty"`                               // Extra environment variables
+	Capture      RunCapture        `yaml:"capture,omitempty" toml:"capture,omitempty" json:"capture,omitempty"`                   // stdout, stderr, combined (default: stdout)
+	AllowFailure bool              `yaml:"allow_failure,omitempty" toml:"allow_failure,omitempty" json:"allow_failure,omitempty"` // Non-zero exit does not fail the step
+}
+
+// UnmarshalText allows RunConfig to be specified as a simple command string
+func (r *RunConfig) UnmarshalText(text []byte) error {
+	r.Command = string(text)
+	return nil
+}
+
+// UnmarshalTOML accepts both the string and table forms. The TOML decoder
+// would otherwise route tables through UnmarshalText and reject them.
+func (r *RunConfig) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case string:
+		r.Command = v
+		return nil
+	case map[string]interface{}:
+		for key, val := range v {
+			switch key {
+			case "command", "dir", "capture":
+				str, ok := val.(string)
+				if !ok {
+					return fmt.Errorf("run.%s must be a string", key)
+				}
+				switch key {
+				case "command":
+					r.Command = str
+				case "dir":
+					r.Dir = str
+				case "capture":
+					r.Capture = RunCapture(str)
+				}
+			case "allow_failure":
+				b, ok := val.(bool)
+				if !ok {
+					return fmt.Errorf("run.allow_failure must be a boolean")
+				}
+				r.AllowFailure = b
+			case "env":
+				env, ok := val.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("run.env must be a table")
+				}
+				r.Env = make(map[string]string, len(env))
+				for k, ev := range env {
+					r.Env[k] = fmt.Sprint(ev)
+				}
+			default:
+				return fmt.Errorf("unknown run field: %s", key)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("run must be a string or table, got %T", data)
+	}
+}
+
+// RunCapture selects which stream of a run step becomes the step output
+type RunCapture string
+
+const (
+	RunCaptureStdout   RunCapture = "stdout"
+	RunCaptureStderr   RunCapture = "stderr"
+	RunCaptureCombined RunCapture = "combined"
+)
+
+// ApprovalConfig defines a human approval gate. The step pauses the workflow
+// until the request is approved or denied (e.g. with "ntm approve <id>").
+// The step timeout bounds how long the gate waits for a decision.
+type ApprovalConfig struct {
+	Message  string `yaml:"message" toml:"message" json:"message"`                                  // Shown to the approver
+	Action   string `yaml:"action,omitempty" toml:"action,omitempty" json:"action,omitempty"`       // Approval action (default: pipeline_gate)
+	Resource string `yaml:"resource,omitempty" toml:"resource,omitempty" json:"resource,omitempty"` // Resource being acted on (default: <workflow>/<step>)
+	SLB      bool   `yaml:"slb,omitempty" toml:"slb,omitempty" json:"slb,omitempty"`                // Require a second person to approve
+}
+
 // LoopConfig defines loop iteration settings for for-each, while, and times loops
 type LoopConfig struct {
 	// For-each loop: iterate over array
@@ -229,11 +351,14 @@ type StepResult struct {
 	Error      *StepError      `json:"error,omitempty"`
 	SkipReason string          `json:"skip_reason,omitempty"` // If skipped due to 'when' condition
 	Attempts   int             `json:"attempts,omitempty"`    // Number of retry attempts
+	ExitCode   *int            `json:"exit_code,omitempty"`   // Exit status of a run step
+	ApprovalID string          `json:"approval_id,omitempty"` // Approval request of a gate step
+	Child      *ExecutionState `json:"child,omitempty"`       // Nested run of a uses step
 }
 
 // StepError contains detailed error information for a failed step
 type StepError struct {
-	Type       string    `json:"type"` // timeout, agent_error, crash, validation, routing, send, capture
+	Type       string    `json:"type"` // timeout, agent_error, crash, validation, routing, send, capture, policy, exit_code, approval_denied
 	Message    string    `json:"message"`
 	Details    string    `json:"details,omitempty"`     // Full error output
 	PaneOutput string    `json:"pane_output,omitempty"` // Last N lines from pane for debugging
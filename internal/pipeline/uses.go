@@ -0,0 +1,436 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/workflow"
+)
+
+// workflowExtensions are tried, in order, when resolving a uses name.
+var workflowExtensions = []string{".yaml", ".yml", ".toml"}
+
+// ResolveUses resolves a step's uses reference to a workflow file.
+// References that look like paths (containing a separator or ending in a
+// workflow extension) resolve against baseDir; bare names are looked up as
+// <name>.yaml, <name>.yml or <name>.toml in the workflow.Loader search paths
+// for projectDir.
+func ResolveUses(ref, baseDir, projectDir string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", fmt.Errorf("empty uses reference")
+	}
+
+	ext := strings.ToLower(filepath.Ext(ref))
+	isPath := strings.ContainsRune(ref, '/') || strings.ContainsRune(ref, filepath.Separator)
+	for _, known := range workflowExtensions {
+		if ext == known {
+			isPath = true
+		}
+	}
+
+	if isPath {
+		path := ref
+		if !filepath.IsAbs(path) {
+			if baseDir == "" {
+				baseDir = projectDir
+			}
+			path = filepath.Join(baseDir, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("workflow file not found: %s", path)
+		}
+		return filepath.Abs(path)
+	}
+
+	loader := workflow.NewLoader()
+	if projectDir != "" {
+		loader.ProjectDir = projectDir
+	}
+	dirs := loader.SearchDirs()
+	for _, dir := range dirs {
+		for _, ext := range workflowExtensions {
+			path := filepath.Join(dir, ref+ext)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return filepath.Abs(path)
+			}
+		}
+	}
+	return "", fmt.Errorf("workflow %q not found in %s", ref, strings.Join(dirs, ", "))
+}
+
+// workflowBaseDir returns the directory relative uses paths resolve against.
+func workflowBaseDir(w *Workflow, projectDir string) string {
+	if w != nil && w.source != "" {
+		return filepath.Dir(w.source)
+	}
+	return projectDir
+}
+
+// checkIncludes loads every workflow reachable through uses steps and reports
+// references that cannot be loaded and include cycles.
+func (g *DependencyGraph) checkIncludes() []DependencyError {
+	if g.root == nil {
+		return nil
+	}
+
+	var errs []DependencyError
+	var stack []string
+	onStack := make(map[string]bool)
+	if g.root.source != "" {
+		stack = append(stack, g.root.source)
+		onStack[g.root.source] = true
+	}
+
+	var walk func(w *Workflow)
+	walk = func(w *Workflow) {
+		for _, step := range usesSteps(w.Steps) {
+			path, err := ResolveUses(step.Uses, workflowBaseDir(w, g.projectDir), g.projectDir)
+			if err != nil {
+				errs = append(errs, DependencyError{
+					Type:    "missing_workflow",
+					Steps:   []string{step.ID},
+					Message: fmt.Sprintf("step %q uses %q: %v", step.ID, step.Uses, err),
+				})
+				continue
+			}
+
+			if onStack[path] {
+				start := 0
+				for i, p := range stack {
+					if p == path {
+						start = i
+						break
+					}
+				}
+				cycle := append(append([]string{}, stack[start:]...), path)
+				errs = append(errs, DependencyError{
+					Type:    "cycle",
+					Steps:   []string{step.ID},
+					Message: fmt.Sprintf("circular uses: %s", strings.Join(cycle, " -> ")),
+				})
+				continue
+			}
+
+			child, err := ParseFile(path)
+			if err != nil {
+				errs = append(errs, DependencyError{
+					Type:    "missing_workflow",
+					Steps:   []string{step.ID},
+					Message: fmt.Sprintf("step %q uses %q: %v", step.ID, step.Uses, err),
+				})
+				continue
+			}
+
+			stack = append(stack, path)
+			onStack[path] = true
+			walk(child)
+			stack = stack[:len(stack)-1]
+			delete(onStack, path)
+		}
+	}
+	walk(g.root)
+
+	return errs
+}
+
+// usesSteps returns the uses steps in steps, including nested parallel and
+// loop sub-steps.
+func usesSteps(steps []Step) []*Step {
+	var out []*Step
+	for i := range steps {
+		step := &steps[i]
+		if step.Uses != "" {
+			out = append(out, step)
+		}
+		out = append(out, usesSteps(step.Parallel)...)
+		if step.Loop != nil {
+			out = append(out, usesSteps(step.Loop.Steps)...)
+		}
+	}
+	return out
+}
+
+// executeUsesStep runs another workflow as a nested run of this one. The
+// child's state is kept on the step result so an interrupted run resumes
+// inside the sub-workflow instead of starting it over.
+func (e *Executor) executeUsesStep(ctx context.Context, step *Step, parent *Workflow) StepResult {
+	result := StepResult{
+		StepID:    step.ID,
+		Status:    StatusRunning,
+		StartedAt: time.Now(),
+		AgentType: "workflow",
+	}
+	fail := func(errType, msg string) StepResult {
+		result.Status = StatusFailed
+		result.Error = &StepError{
+			Type:      errType,
+			Message:   msg,
+			Timestamp: time.Now(),
+		}
+		result.FinishedAt = time.Now()
+		return result
+	}
+
+	path, err := ResolveUses(step.Uses, workflowBaseDir(parent, e.config.ProjectDir), e.config.ProjectDir)
+	if err != nil {
+		return fail("uses", err.Error())
+	}
+	child, err := ParseFile(path)
+	if err != nil {
+		return fail("uses", fmt.Sprintf("failed to load %s: %v", path, err))
+	}
+	if res := Validate(child); !res.Valid {
+		return fail("validation", fmt.Sprintf("sub-workflow %s is invalid: %s", child.Name, res.Errors[0].Message))
+	}
+
+	inputs, err := e.usesInputs(step, child)
+	if err != nil {
+		return fail("validation", err.Error())
+	}
+
+	if e.config.DryRun {
+		result.Status = StatusCompleted
+		result.Output = fmt.Sprintf("[DRY RUN] Would run workflow %s (%s)", child.Name, path)
+		result.FinishedAt = time.Now()
+		return result
+	}
+
+	cfg := e.config
+	cfg.RunID = e.state.RunID + "." + step.ID
+	cfg.WorkflowFile = path
+	if step.Timeout.Duration > 0 {
+		cfg.GlobalTimeout = step.Timeout.Duration
+	}
+	sub := NewExecutor(cfg)
+	sub.policy = e.policy
+	sub.approvals = e.approvals
+	sub.promptQueue = e.promptQueue
+	sub.approvalPoll = e.approvalPoll
+	sub.parent = e
+	sub.parentStep = step.ID
+
+	e.emitProgress("step_uses", step.ID, fmt.Sprintf("Running workflow %s", child.Name), e.calculateProgress())
+
+	var childState *ExecutionState
+	var runErr error
+	if prior := e.takeResumeChild(step.ID); prior != nil {
+		childState, runErr = sub.Resume(ctx, child, prior, nil)
+	} else {
+		childState, runErr = sub.Run(ctx, child, inputs, nil)
+	}
+
+	result.Child = childState
+	result.FinishedAt = time.Now()
+
+	switch childState.Status {
+	case StatusCompleted:
+		outputs := sub.evaluateOutputs(child)
+		result.Status = StatusCompleted
+		if len(outputs) > 0 {
+			result.ParsedData = outputs
+			if data, err := json.Marshal(outputs); err == nil {
+				result.Output = string(data)
+			}
+		}
+		e.varMu.Lock()
+		StoreUsesOutputs(e.state, step.ID, outputs)
+		e.varMu.Unlock()
+	case StatusPaused:
+		result.Status = StatusPaused
+	case StatusCancelled:
+		result.Status = StatusCancelled
+	default:
+		msg := fmt.Sprintf("sub-workflow %s failed", child.Name)
+		if runErr != nil {
+			msg += ": " + runErr.Error()
+		}
+		return fail("workflow", msg)
+	}
+	return result
+}
+
+// usesInputs maps a uses step's with values onto the child workflow's vars,
+// checking names, required inputs and declared types.
+func (e *Executor) usesInputs(step *Step, child *Workflow) (map[string]interface{}, error) {
+	inputs := make(map[string]interface{}, len(step.With))
+	for _, name := range sortedKeys(step.With) {
+		def, ok := child.Vars[name]
+		if !ok {
+			return nil, fmt.Errorf("workflow %s has no input %q", child.Name, name)
+		}
+		value, err := coerceVar(e.substituteValue(step.With[name]), def.Type)
+		if err != nil {
+			return nil, fmt.Errorf("input %q: %w", name, err)
+		}
+		inputs[name] = value
+	}
+
+	for _, name := range sortedKeys(child.Vars) {
+		def := child.Vars[name]
+		if _, ok := inputs[name]; !ok && def.Required && def.Default == nil {
+			return nil, fmt.Errorf("workflow %s requires input %q", child.Name, name)
+		}
+	}
+	return inputs, nil
+}
+
+// substituteValue substitutes variables in every string within v.
+func (e *Executor) substituteValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return e.substituteVariables(val)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = e.substituteValue(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = e.substituteValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// coerceVar converts v to the declared variable type. Strings produced by
+// substitution are parsed; an empty type accepts any value.
+func coerceVar(v interface{}, typ VarType) (interface{}, error) {
+	switch typ {
+	case "":
+		return v, nil
+	case VarTypeString:
+		switch val := v.(type) {
+		case string:
+			return val, nil
+		case []interface{}, map[string]interface{}:
+			return nil, fmt.Errorf("expected string, got %T", v)
+		default:
+			return fmt.Sprint(val), nil
+		}
+	case VarTypeNumber:
+		switch val := v.(type) {
+		case int, int64, float64:
+			return val, nil
+		case string:
+			s := strings.TrimSpace(val)
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return int(n), nil
+			}
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return f, nil
+			}
+			return nil, fmt.Errorf("expected number, got %q", val)
+		default:
+			return nil, fmt.Errorf("expected number, got %T", v)
+		}
+	case VarTypeBoolean:
+		switch val := v.(type) {
+		case bool:
+			return val, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(val))
+			if err != nil {
+				return nil, fmt.Errorf("expected boolean, got %q", val)
+			}
+			return b, nil
+		default:
+			return nil, fmt.Errorf("expected boolean, got %T", v)
+		}
+	case VarTypeArray:
+		switch val := v.(type) {
+		case []interface{}:
+			return val, nil
+		case string:
+			var arr []interface{}
+			if err := json.Unmarshal([]byte(val), &arr); err != nil {
+				return nil, fmt.Errorf("expected array, got %q", val)
+			}
+			return arr, nil
+		default:
+			return nil, fmt.Errorf("expected array, got %T", v)
+		}
+	default:
+		return nil, fmt.Errorf("unknown variable type %q", typ)
+	}
+}
+
+// evaluateOutputs evaluates a finished workflow's declared outputs against its
+// own state.
+func (e *Executor) evaluateOutputs(w *Workflow) map[string]interface{} {
+	outputs := make(map[string]interface{}, len(w.Outputs))
+	for _, name := range sortedKeys(w.Outputs) {
+		outputs[name] = e.substituteVariables(w.Outputs[name])
+	}
+	return outputs
+}
+
+// StoreUsesOutputs stores a uses step's outputs for variable access as
+// ${steps.<id>.outputs.<name>}.
+// Note: Caller must hold any necessary locks on state.Variables if used concurrently.
+func StoreUsesOutputs(state *ExecutionState, stepID string, outputs map[string]interface{}) {
+	if state.Variables == nil {
+		state.Variables = make(map[string]interface{})
+	}
+
+	state.Variables["steps."+stepID+".outputs"] = outputs
+}
+
+// takeResumeChild returns, once, the nested state of a uses step from the run
+// being resumed.
+func (e *Executor) takeResumeChild(stepID string) *ExecutionState {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	prior := e.resumeChildren[stepID]
+	delete(e.resumeChildren, stepID)
+	return prior
+}
+
+// updateChild records a running sub-workflow's state on its uses step and
+// persists the parent run. A paused child (waiting at an approval gate)
+// pauses the parent too.
+func (e *Executor) updateChild(stepID string, child *ExecutionState) {
+	e.stateMu.Lock()
+	result := e.state.Steps[stepID]
+	result.StepID = stepID
+	result.AgentType = "workflow"
+	if result.StartedAt.IsZero() {
+		result.StartedAt = child.StartedAt
+	}
+	result.Child = child
+	if child.Status == StatusPaused {
+		result.Status = StatusPaused
+		e.state.Status = StatusPaused
+	} else {
+		result.Status = StatusRunning
+		if e.state.Status == StatusPaused {
+			e.resumeUnlessPausedLocked(stepID)
+		}
+	}
+	e.state.Steps[stepID] = result
+	e.state.UpdatedAt = time.Now()
+	e.stateMu.Unlock()
+
+	e.persistState()
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
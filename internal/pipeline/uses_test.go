@@ -0,0 +1,326 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeWorkflowFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+}
+
+const greetWorkflow = `
+schema_version: "2.0"
+name: greet
+vars:
+  who:
+    type: string
+    required: true
+  times:
+    type: number
+    default: 1
+steps:
+  - id: say
+    run: printf "hello ${vars.who} x${vars.times}"
+outputs:
+  greeting: ${steps.say.stdout}
+`
+
+func TestParseString_UsesStep(t *testing.T) {
+	t.Parallel()
+
+	w, err := ParseString(`
+schema_version: "2.0"
+name: parent
+steps:
+  - id: sub
+    uses: ./lint.yaml
+    with:
+      strict: true
+      paths: [a, b]
+`, "yaml")
+	if err != nil {
+		t.Fatalf("ParseString() error: %v", err)
+	}
+	sub := w.Steps[0]
+	if sub.Uses != "./lint.yaml" || sub.With["strict"] != true {
+		t.Errorf("uses step = %+v", sub)
+	}
+	if res := Validate(w); !res.Valid {
+		t.Errorf("Validate() errors: %+v", res.Errors)
+	}
+
+	tests := []struct {
+		name string
+		step Step
+	}{
+		{"uses with run", Step{ID: "a", Uses: "lint", Run: &RunConfig{Command: "true"}}},
+		{"with without uses", Step{ID: "a", Run: &RunConfig{Command: "true"}, With: map[string]interface{}{"x": 1}}},
+	}
+	for _, tt := range tests {
+		w := &Workflow{SchemaVersion: SchemaVersion, Name: "w", Steps: []Step{tt.step}}
+		if Validate(w).Valid {
+			t.Errorf("%s: expected validation error", tt.name)
+		}
+	}
+}
+
+func TestResolveUses(t *testing.T) {
+	t.Parallel()
+
+	projectDir := t.TempDir()
+	named := filepath.Join(projectDir, ".ntm", "workflows", "greet.yaml")
+	writeWorkflowFile(t, named, greetWorkflow)
+	local := filepath.Join(projectDir, "flows", "local.toml")
+	writeWorkflowFile(t, local, "")
+
+	if got, err := ResolveUses("greet", "", projectDir); err != nil || got != named {
+		t.Errorf("ResolveUses(greet) = %q, %v; want %q", got, err, named)
+	}
+	if got, err := ResolveUses("local.toml", filepath.Join(projectDir, "flows"), projectDir); err != nil || got != local {
+		t.Errorf("ResolveUses(local.toml) = %q, %v; want %q", got, err, local)
+	}
+	if got, err := ResolveUses("flows/local.toml", "", projectDir); err != nil || got != local {
+		t.Errorf("ResolveUses(flows/local.toml) = %q, %v; want %q", got, err, local)
+	}
+	if _, err := ResolveUses("nope", "", projectDir); err == nil {
+		t.Error("ResolveUses(nope) should fail")
+	}
+}
+
+func TestCoerceVar(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value   interface{}
+		typ     VarType
+		want    interface{}
+		wantErr bool
+	}{
+		{"3", VarTypeNumber, 3, false},
+		{"2.5", VarTypeNumber, 2.5, false},
+		{"abc", VarTypeNumber, nil, true},
+		{"true", VarTypeBoolean, true, false},
+		{"maybe", VarTypeBoolean, nil, true},
+		{7, VarTypeString, "7", false},
+		{"x", "", "x", false},
+	}
+	for _, tt := range tests {
+		got, err := coerceVar(tt.value, tt.typ)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("coerceVar(%v, %s) error = %v, wantErr %v", tt.value, tt.typ, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("coerceVar(%v, %s) = %v (%T), want %v", tt.value, tt.typ, got, got, tt.want)
+		}
+	}
+
+	arr, err := coerceVar(`["a", "b"]`, VarTypeArray)
+	if err != nil || len(arr.([]interface{})) != 2 {
+		t.Errorf("coerceVar(array) = %v, %v", arr, err)
+	}
+}
+
+func TestExecutor_UsesStep_InputsAndOutputs(t *testing.T) {
+	t.Parallel()
+
+	e := newRunTestExecutor(t)
+	writeWorkflowFile(t, filepath.Join(e.config.ProjectDir, ".ntm", "workflows", "greet.yaml"), greetWorkflow)
+
+	w := &Workflow{
+		SchemaVersion: SchemaVersion,
+		Name:          "parent",
+		Settings:      DefaultWorkflowSettings(),
+		Steps: []Step{
+			{ID: "count", Run: &RunConfig{Command: "printf 2"}, OutputVar: "count"},
+			{
+				ID:        "hello",
+				DependsOn: []string{"count"},
+				Uses:      "greet",
+				With:      map[string]interface{}{"who": "${vars.name}", "times": "${vars.count}"},
+			},
+			{
+				ID:        "shout",
+				DependsOn: []string{"hello"},
+				Run:       &RunConfig{Command: `printf "%s!" "${steps.hello.outputs.greeting}"`},
+				OutputVar: "shout",
+			},
+		},
+	}
+
+	st, err := e.Run(context.Background(), w, map[string]interface{}{"name": "world"}, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	hello := st.Steps["hello"]
+	if hello.Status != StatusCompleted {
+		t.Fatalf("hello status = %v (%+v)", hello.Status, hello.Error)
+	}
+	if hello.Child == nil || hello.Child.WorkflowID != "greet" || hello.Child.Variables["times"] != 2 {
+		t.Errorf("nested state = %+v", hello.Child)
+	}
+	if got := st.Variables["shout"]; got != "hello world x2!" {
+		t.Errorf("shout = %q, want %q", got, "hello world x2!")
+	}
+
+	// The child run is persisted inside the parent, not as its own run.
+	entries, err := os.ReadDir(pipelineStateDir(e.config.ProjectDir))
+	if err != nil {
+		t.Fatalf("ReadDir() error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("persisted %d runs, want 1", len(entries))
+	}
+}
+
+func TestExecutor_UsesStep_InputErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		with map[string]interface{}
+		want string
+	}{
+		{"missing required", map[string]interface{}{}, `requires input "who"`},
+		{"unknown input", map[string]interface{}{"who": "x", "extra": 1}, `no input "extra"`},
+		{"bad type", map[string]interface{}{"who": "x", "times": "many"}, "expected number"},
+	}
+	for _, tt := range tests {
+		e := newRunTestExecutor(t)
+		writeWorkflowFile(t, filepath.Join(e.config.ProjectDir, "greet.yaml"), greetWorkflow)
+		w := &Workflow{
+			SchemaVersion: SchemaVersion,
+			Name:          "parent",
+			Settings:      DefaultWorkflowSettings(),
+			Steps:         []Step{{ID: "hello", Uses: "./greet.yaml", With: tt.with}},
+		}
+
+		st, _ := e.Run(context.Background(), w, nil, nil)
+		res := st.Steps["hello"]
+		if res.Status != StatusFailed || res.Error == nil || !strings.Contains(res.Error.Message, tt.want) {
+			t.Errorf("%s: result = %+v, want error containing %q", tt.name, res.Error, tt.want)
+		}
+	}
+}
+
+func TestDependencyGraph_UsesCycle(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeWorkflowFile(t, filepath.Join(dir, "a.yaml"), `
+schema_version: "2.0"
+name: a
+steps:
+  - id: to-b
+    uses: b.yaml
+`)
+	writeWorkflowFile(t, filepath.Join(dir, "b.yaml"), `
+schema_version: "2.0"
+name: b
+steps:
+  - id: to-a
+    uses: ./a.yaml
+`)
+
+	w, err := ParseFile(filepath.Join(dir, "a.yaml"))
+	if err != nil {
+		t.Fatalf("ParseFile() error: %v", err)
+	}
+	errs := NewDependencyGraph(w).Validate()
+	if len(errs) != 1 || errs[0].Type != "cycle" || !strings.Contains(errs[0].Message, "b.yaml -> ") {
+		t.Fatalf("Validate() = %+v, want one include cycle", errs)
+	}
+
+	e := newRunTestExecutor(t)
+	st, err := e.Run(context.Background(), w, nil, nil)
+	if err == nil || st.Status != StatusFailed {
+		t.Errorf("Run() = %v, %v; want dependency failure", st.Status, err)
+	}
+}
+
+func TestExecutor_UsesStep_ResumesNestedRun(t *testing.T) {
+	t.Parallel()
+
+	store := newApprovalTestStore(t)
+	e := newApprovalTestExecutor(t, store, "")
+	projectDir := e.config.ProjectDir
+	writeWorkflowFile(t, filepath.Join(projectDir, "release.yaml"), `
+schema_version: "2.0"
+name: release
+steps:
+  - id: build
+    run: echo built
+  - id: gate
+    depends_on: [build]
+    approval:
+      message: Ship it?
+  - id: tag
+    depends_on: [gate]
+    run: printf v1
+outputs:
+  tag: ${steps.tag.stdout}
+`)
+	parent := func() *Workflow {
+		return &Workflow{
+			SchemaVersion: SchemaVersion,
+			Name:          "parent",
+			Settings:      DefaultWorkflowSettings(),
+			Steps:         []Step{{ID: "release", Uses: "release.yaml"}},
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan *ExecutionState, 1)
+	go func() {
+		st, _ := e.Run(ctx, parent(), nil, nil)
+		done <- st
+	}()
+
+	gate := waitForGate(t, projectDir)
+	if gate.StepID != "release/gate" || gate.Message != "Ship it?" {
+		t.Errorf("pending gate = %+v", gate)
+	}
+	cancel()
+	if st := <-done; st.Status != StatusPaused {
+		t.Fatalf("interrupted status = %v, want paused", st.Status)
+	}
+
+	prior, err := LoadState(projectDir, gate.RunID)
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	child := prior.Steps["release"].Child
+	if child == nil || child.Steps["build"].Status != StatusCompleted {
+		t.Fatalf("persisted nested state = %+v", child)
+	}
+	if err := e.approvals.Approve(context.Background(), gate.ApprovalID, "dana"); err != nil {
+		t.Fatalf("Approve() error: %v", err)
+	}
+
+	resumed := newApprovalTestExecutor(t, store, projectDir)
+	st, err := resumed.Resume(context.Background(), parent(), prior, nil)
+	if err != nil {
+		t.Fatalf("Resume() error: %v", err)
+	}
+	release := st.Steps["release"]
+	if release.Status != StatusCompleted || release.Child.RunID != child.RunID {
+		t.Fatalf("resumed release = %+v", release)
+	}
+	if got := release.Child.Steps["build"].StartedAt; !got.Equal(child.Steps["build"].StartedAt) {
+		t.Error("resume re-ran the completed build step")
+	}
+	if outputs, _ := st.Variables["steps.release.outputs"].(map[string]interface{}); outputs["tag"] != "v1" {
+		t.Errorf("outputs = %v, want tag=v1", st.Variables["steps.release.outputs"])
+	}
+}
@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -8,6 +9,9 @@ import (
 	"strings"
 
 	"github.com/BurntSushi/toml"
+
+	"github.com/Dicklesworthstone/ntm/internal/adapter"
+	"github.com/Dicklesworthstone/ntm/internal/agent"
 )
 
 // pluginNameRegex enforces allowed characters for plugin names (must match tmux pane regex)
@@ -23,6 +27,85 @@ type AgentPlugin struct {
 	Defaults    struct {
 		Tags []string `toml:"tags"`
 	} `toml:"defaults"`
+
+	// Patterns describe the agent's pane output for status detection
+	Patterns AgentPatterns `toml:"patterns"`
+	// ContextLimit is the agent's context window in tokens (0 = unknown)
+	ContextLimit int `toml:"context_limit"`
+	// ExitSequence lists the keys sent, in order, to quit the agent. Each
+	// entry is passed to tmux send-keys: key names such as "C-c", "Escape"
+	// and "Enter" are pressed, anything else is typed.
+	ExitSequence []string `toml:"exit_sequence"`
+	// CompactCommand and ClearCommand shrink or reset the agent's context
+	CompactCommand string `toml:"compact_command"`
+	ClearCommand   string `toml:"clear_command"`
+	// ModelCommand switches models, with {model} replaced by the model name
+	ModelCommand string `toml:"model_command"`
+}
+
+// AgentPatterns are regular expressions matched against a plugin agent's
+// pane output, so status, rate-limit handling and pipeline waits work for it
+// as they do for the built-in agents.
+type AgentPatterns struct {
+	Prompt     []string `toml:"prompt"`     // A single line showing the agent at its input prompt
+	Idle       []string `toml:"idle"`       // The last few lines while waiting for input
+	Working    []string `toml:"working"`    // Recent output while the agent is busy
+	Error      []string `toml:"error"`      // Recent output after an error
+	RateLimit  []string `toml:"rate_limit"` // Recent output after hitting a usage limit
+	Compaction []string `toml:"compaction"` // Output when the context was compacted or reset
+}
+
+// PatternSet compiles the plugin's patterns for the agent package. Prompt
+// patterns also count as idle patterns, matched per line.
+func (p AgentPlugin) PatternSet() (*agent.PatternSet, error) {
+	ps := &agent.PatternSet{ContextLimit: p.ContextLimit}
+	var err error
+	compile := func(field string, patterns []string, prefix string) []*regexp.Regexp {
+		var out []*regexp.Regexp
+		for _, pattern := range patterns {
+			re, cerr := regexp.Compile(prefix + pattern)
+			if cerr != nil {
+				if err == nil {
+					err = fmt.Errorf("patterns.%s: %w", field, cerr)
+				}
+				continue
+			}
+			out = append(out, re)
+		}
+		return out
+	}
+
+	ps.IdlePatterns = append(compile("prompt", p.Patterns.Prompt, "(?m)"), compile("idle", p.Patterns.Idle, "")...)
+	ps.WorkingRegexes = compile("working", p.Patterns.Working, "")
+	ps.ErrorRegexes = compile("error", p.Patterns.Error, "")
+	ps.RateLimitRegexes = compile("rate_limit", p.Patterns.RateLimit, "")
+	ps.CompactionRegexes = compile("compaction", p.Patterns.Compaction, "")
+	if err != nil {
+		return nil, err
+	}
+	if p.ContextLimit < 0 {
+		return nil, fmt.Errorf("context_limit must be non-negative")
+	}
+	return ps, nil
+}
+
+// Adapter returns the plugin's agent adapter. The binary is the first word
+// of its command.
+func (p AgentPlugin) Adapter() *adapter.Base {
+	var binary string
+	if fields := strings.Fields(p.Command); len(fields) > 0 {
+		binary = fields[0]
+	}
+	return &adapter.Base{
+		AgentType: agent.AgentType(p.Name),
+		Binary:    binary,
+		ExitKeys:  p.ExitSequence,
+		CompactionCommands: adapter.Compaction{
+			CompactCommand: p.CompactCommand,
+			ClearCommand:   p.ClearCommand,
+		},
+		ModelCommand: p.ModelCommand,
+	}
 }
 
 type agentConfigFile struct {
@@ -65,6 +148,11 @@ func LoadAgentPlugins(dir string) ([]AgentPlugin, error) {
 				continue
 			}
 
+			if _, err := cfg.Agent.PatternSet(); err != nil {
+				log.Printf("plugins: plugin %s has invalid patterns: %v", cfg.Agent.Name, err)
+				continue
+			}
+
 			plugins = append(plugins, cfg.Agent)
 		}
 	}
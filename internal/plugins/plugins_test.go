@@ -578,3 +578,64 @@ func TestPluginNameRegex(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadAgentPlugins_Patterns(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	content := `[agent]
+name = "goose"
+command = "goose session"
+context_limit = 200000
+exit_sequence = ["Escape", "/exit", "Enter"]
+compact_command = "/compact"
+model_command = "/model {model}"
+
+[agent.patterns]
+prompt = ['^goose>\s*$']
+working = ['Thinking']
+rate_limit = ['(?i)quota exceeded']
+compaction = ['(?i)context compacted']
+`
+	if err := os.WriteFile(filepath.Join(dir, "goose.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bad := "[agent]\nname = \"bad\"\ncommand = \"bad\"\n[agent.patterns]\nerror = ['(unclosed']\n"
+	if err := os.WriteFile(filepath.Join(dir, "bad.toml"), []byte(bad), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins, err := LoadAgentPlugins(dir)
+	if err != nil {
+		t.Fatalf("LoadAgentPlugins failed: %v", err)
+	}
+	if len(plugins) != 1 || plugins[0].Name != "goose" {
+		t.Fatalf("expected only the goose plugin (invalid pattern skipped), got: %+v", plugins)
+	}
+
+	p := plugins[0]
+	if p.ContextLimit != 200000 || strings.Join(p.ExitSequence, ",") != "Escape,/exit,Enter" {
+		t.Errorf("context_limit/exit_sequence = %d/%v", p.ContextLimit, p.ExitSequence)
+	}
+	ps, err := p.PatternSet()
+	if err != nil {
+		t.Fatalf("PatternSet: %v", err)
+	}
+	if !ps.MatchesIdle("working on it\ngoose> ") {
+		t.Error("prompt pattern should match the last line as idle")
+	}
+	if !ps.MatchesWorking("Thinking...") || !ps.MatchesRateLimit("Error: Quota exceeded") || !ps.MatchesCompaction("Context compacted") {
+		t.Errorf("patterns not compiled: %+v", ps)
+	}
+	if ps.ContextLimit != 200000 {
+		t.Errorf("ContextLimit = %d, want 200000", ps.ContextLimit)
+	}
+
+	a := p.Adapter()
+	if a.Type() != "goose" || a.Launch().Binary != "goose" || a.Compaction().CompactCommand != "/compact" {
+		t.Errorf("adapter = %+v", a)
+	}
+	if len(a.ExitKeys) != 3 || a.ModelCommand != "/model {model}" {
+		t.Errorf("adapter exit keys/model command = %v/%q", a.ExitKeys, a.ModelCommand)
+	}
+}
@@ -132,6 +132,13 @@ func (m *Manager) CanPersist(session string, operation PersistOperation) error {
 				Message:   "scrollback capture is disabled in privacy mode",
 			}
 		}
+	case OpSearchIndex:
+		// Indexed output is queryable long after the session ends.
+		return &PrivacyError{
+			Operation: operation,
+			Session:   session,
+			Message:   "search indexing is disabled in privacy mode",
+		}
 	case OpExport, OpArchive:
 		if m.globalConfig.RequireExplicitPersist {
 			return &PrivacyError{
@@ -161,6 +168,8 @@ const (
 	OpExport PersistOperation = "export"
 	// OpArchive is an archive creation operation.
 	OpArchive PersistOperation = "archive"
+	// OpSearchIndex is a full-text search index write or read.
+	OpSearchIndex PersistOperation = "search_index"
 )
 
 // PrivacyError is returned when an operation is blocked by privacy mode.
@@ -76,7 +76,7 @@ func TestCanPersist_PrivacyDisabled(t *testing.T) {
 	// All operations should be allowed
 	ops := []PersistOperation{
 		OpCheckpoint, OpEventLog, OpPromptHistory,
-		OpScrollback, OpExport, OpArchive,
+		OpScrollback, OpExport, OpArchive, OpSearchIndex,
 	}
 
 	for _, op := range ops {
@@ -110,6 +110,7 @@ func TestCanPersist_PrivacyEnabled(t *testing.T) {
 		{OpScrollback, true},
 		{OpExport, true},
 		{OpArchive, true},
+		{OpSearchIndex, true},
 	}
 
 	for _, tt := range tests {
@@ -140,7 +141,7 @@ func TestCanPersist_AllowPersist(t *testing.T) {
 	// All operations should be allowed with AllowPersist
 	ops := []PersistOperation{
 		OpCheckpoint, OpEventLog, OpPromptHistory,
-		OpScrollback, OpExport, OpArchive,
+		OpScrollback, OpExport, OpArchive, OpSearchIndex,
 	}
 
 	for _, op := range ops {
@@ -178,6 +179,11 @@ func TestCanPersist_PartialConfig(t *testing.T) {
 	if err := m.CanPersist("test-session", OpExport); err != nil {
 		t.Errorf("Export should be allowed: %v", err)
 	}
+
+	// Search indexing is always blocked in privacy mode
+	if err := m.CanPersist("test-session", OpSearchIndex); err == nil {
+		t.Error("Search indexing should be blocked")
+	}
 }
 
 func TestPrivacyError(t *testing.T) {
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/ntm/internal/adapter"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
@@ -17,23 +18,21 @@ type PTYFetcher struct {
 	CaptureLines int
 }
 
-// providerCommands maps providers to their quota commands
-var providerCommands = map[Provider]struct {
-	UsageCmd  string
-	StatusCmd string
-}{
-	ProviderClaude: {
-		UsageCmd:  "/usage",
-		StatusCmd: "/status",
-	},
-	ProviderCodex: {
-		UsageCmd:  "/usage", // May need adjustment after research
-		StatusCmd: "/status",
-	},
-	ProviderGemini: {
-		UsageCmd:  "/auth status", // Gemini uses different commands
-		StatusCmd: "/auth status",
-	},
+// providerCommands returns the quota commands of the agent whose adapter
+// declares provider.
+func providerCommands(provider Provider) (adapter.Quota, bool) {
+	for _, a := range adapter.All() {
+		if q := a.Quota(); q.Provider == string(provider) && q.UsageCommand != "" {
+			return q, true
+		}
+	}
+	return adapter.Quota{}, false
+}
+
+// ProviderFor returns the quota provider of an agent type, or "" if the
+// agent has no quota commands.
+func ProviderFor(agentType tmux.AgentType) Provider {
+	return Provider(adapter.Get(agentType).Quota().Provider)
 }
 
 // FetchQuota sends quota commands to a pane and parses the output
@@ -48,7 +47,7 @@ func (f *PTYFetcher) FetchQuota(ctx context.Context, paneID string, provider Pro
 		captureLines = 100
 	}
 
-	cmds, ok := providerCommands[provider]
+	cmds, ok := providerCommands(provider)
 	if !ok {
 		return nil, fmt.Errorf("unknown provider: %s", provider)
 	}
@@ -66,7 +65,7 @@ func (f *PTYFetcher) FetchQuota(ctx context.Context, paneID string, provider Pro
 	}
 
 	// Send /usage command
-	if err := tmux.SendKeys(paneID, cmds.UsageCmd, true); err != nil {
+	if err := tmux.SendKeys(paneID, cmds.UsageCommand, true); err != nil {
 		info.Error = fmt.Sprintf("failed to send usage command: %v", err)
 		return info, nil
 	}
@@ -108,8 +107,8 @@ func (f *PTYFetcher) FetchQuota(ctx context.Context, paneID string, provider Pro
 				// Attempt to slice content after the command echo to avoid stale data
 				relevantOutput := output
 				// Use LastIndex to find the most recent command execution
-				if idx := strings.LastIndex(output, cmds.UsageCmd); idx != -1 {
-					relevantOutput = output[idx+len(cmds.UsageCmd):]
+				if idx := strings.LastIndex(output, cmds.UsageCommand); idx != -1 {
+					relevantOutput = output[idx+len(cmds.UsageCommand):]
 				}
 
 				found, err := parseUsageOutput(info, relevantOutput, provider)
@@ -119,7 +118,7 @@ func (f *PTYFetcher) FetchQuota(ctx context.Context, paneID string, provider Pro
 				if found {
 					info.RawOutput = relevantOutput
 					// Optionally fetch status for additional info
-					statusOutput, err := f.fetchStatus(ctx, paneID, cmds.StatusCmd, captureLines, 2*time.Second)
+					statusOutput, err := f.fetchStatus(ctx, paneID, cmds.StatusCommand, captureLines, 2*time.Second)
 					if err == nil && statusOutput != "" {
 						parseStatusOutput(info, statusOutput, provider)
 						info.RawOutput += "\n---\n" + statusOutput
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/Dicklesworthstone/ntm/internal/status"
+)
+
+// Rate-limit patterns for agent types defined by plugins, keyed by agent type.
+var (
+	agentPatternsMu sync.RWMutex
+	agentPatterns   = make(map[string][]*regexp.Regexp)
+)
+
+// AddAgentPattern allows adding a rate-limit pattern for an agent type at
+// runtime. DetectRateLimitForAgent checks it for panes of that type.
+func AddAgentPattern(agentType, pattern string) error {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	agentPatternsMu.Lock()
+	defer agentPatternsMu.Unlock()
+	agentPatterns[agentType] = append(agentPatterns[agentType], regex)
+	return nil
+}
+
+// MatchAgentPattern returns the first text in output that matches a pattern
+// added for agentType, or "" if none does.
+func MatchAgentPattern(output, agentType string) string {
+	agentPatternsMu.RLock()
+	patterns := agentPatterns[agentType]
+	agentPatternsMu.RUnlock()
+	if len(patterns) == 0 || output == "" {
+		return ""
+	}
+
+	cleaned := status.StripANSI(output)
+	for _, pat := range patterns {
+		if m := pat.FindString(cleaned); m != "" {
+			return m
+		}
+	}
+	return ""
+}
@@ -0,0 +1,31 @@
+package ratelimit
+
+import "testing"
+
+func TestDetectRateLimitForAgent_PluginPatterns(t *testing.T) {
+	t.Parallel()
+
+	const agentType = "goose-ratelimit-test"
+	output := "goose: daily allowance used up, come back tomorrow"
+	if DetectRateLimitForAgent(output, agentType).RateLimited {
+		t.Fatal("output should not be detected before the pattern is added")
+	}
+
+	if err := AddAgentPattern(agentType, `(?i)allowance used up`); err != nil {
+		t.Fatalf("AddAgentPattern: %v", err)
+	}
+	if err := AddAgentPattern(agentType, `(unclosed`); err == nil {
+		t.Error("AddAgentPattern should fail with invalid regex")
+	}
+
+	detection := DetectRateLimitForAgent(output, agentType)
+	if !detection.RateLimited || detection.Source != detectionSourceOutput {
+		t.Errorf("detection = %+v, want rate limited from output", detection)
+	}
+	if got := MatchAgentPattern(output, agentType); got != "allowance used up" {
+		t.Errorf("MatchAgentPattern = %q", got)
+	}
+	if DetectRateLimitForAgent(output, "cc").RateLimited {
+		t.Error("plugin patterns must not apply to other agent types")
+	}
+}
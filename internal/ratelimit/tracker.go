@@ -531,7 +531,8 @@ func DetectRateLimit(output string) RateLimitDetection {
 }
 
 // DetectRateLimitForAgent inspects output for rate limit signals with agent type context.
-// When agentType is "cod", additional Codex-specific patterns are checked.
+// When agentType is "cod", additional Codex-specific patterns are checked, as
+// are any patterns added for agentType with AddAgentPattern.
 func DetectRateLimitForAgent(output string, agentType string) RateLimitDetection {
     detection := DetectRateLimit(output)
     detection.AgentType = agentType
@@ -548,6 +549,13 @@ func DetectRateLimitForAgent(output string, agentType string) RateLimitDetection
         return detection
     }
 
+    // Patterns declared by agent plugins
+    if MatchAgentPattern(output, agentType) != "" {
+        detection.RateLimited = true
+        detection.Source = detectionSourceOutput
+        return detection
+    }
+
     return detection
 }
 
@@ -0,0 +1,206 @@
+package reply
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/status"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+const (
+	// DefaultInterval is how often a capturer checks pending responses.
+	DefaultInterval = 3 * time.Second
+
+	// DefaultQuiet is how long an agent must be idle without output before
+	// its reply is considered complete.
+	DefaultQuiet = 5 * time.Second
+
+	// DefaultTimeout is how long a capturer waits for an agent to finish
+	// before storing what it has printed so far.
+	DefaultTimeout = 30 * time.Minute
+)
+
+// Capturer stores the replies of pending responses once their agents are
+// idle again.
+type Capturer struct {
+	Store *state.Store
+
+	Interval time.Duration // Default DefaultInterval
+	Quiet    time.Duration // Default DefaultQuiet
+	Timeout  time.Duration // Default DefaultTimeout
+
+	// Tests replace these; nil uses tmux and the status detector.
+	Detect  func(paneID string) (status.AgentStatus, error)
+	Capture func(paneID string, line int) (string, error)
+	Exists  func(paneID string) bool
+	Now     func() time.Time
+}
+
+// NewCapturer returns a capturer for the responses in store.
+func NewCapturer(store *state.Store) *Capturer {
+	return &Capturer{Store: store}
+}
+
+// Run captures pending responses every Interval until ctx is cancelled.
+func (c *Capturer) Run(ctx context.Context) error {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.CaptureOnce(ctx, state.ResponseFilter{Pane: -1}); err != nil && ctx.Err() == nil {
+			slog.Warn("response capture error", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// CaptureOnce tries to capture every pending response matching f and
+// returns those captured.
+func (c *Capturer) CaptureOnce(ctx context.Context, f state.ResponseFilter) ([]state.AgentResponse, error) {
+	f.Status = state.ResponsePending
+	pending, err := c.Store.ListResponses(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var captured []state.AgentResponse
+	for i := range pending {
+		if ctx.Err() != nil {
+			return captured, ctx.Err()
+		}
+		done, err := c.CaptureResponse(&pending[i])
+		if err != nil {
+			slog.Warn("response capture failed", "id", pending[i].ID, "pane", pending[i].PaneID, "error", err)
+			continue
+		}
+		if done {
+			captured = append(captured, pending[i])
+		}
+	}
+	return captured, nil
+}
+
+// CaptureResponse captures r if its agent has finished replying, or if it
+// has timed out, updating r in place. It reports whether r was captured.
+func (c *Capturer) CaptureResponse(r *state.AgentResponse) (bool, error) {
+	if r.Status != state.ResponsePending {
+		return false, nil
+	}
+	now := c.now()
+
+	if !c.exists(r.PaneID) {
+		return c.finish(r, state.ResponseLost, "", "pane no longer exists")
+	}
+
+	outcome := state.ResponseCaptured
+	if now.Sub(r.CreatedAt) >= c.timeout() {
+		outcome = state.ResponseTimeout
+	} else if !c.finished(r, now) {
+		return false, nil
+	}
+
+	raw, err := c.capture(r)
+	if err != nil {
+		return false, err
+	}
+	return c.finish(r, outcome, Clean(raw, r.AgentType, r.Prompt), "")
+}
+
+// Wait captures the pending responses matching f as their agents finish,
+// until none are left or ctx is done.
+func (c *Capturer) Wait(ctx context.Context, f state.ResponseFilter) error {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	for {
+		if _, err := c.CaptureOnce(ctx, f); err != nil {
+			return err
+		}
+		f.Status = state.ResponsePending
+		left, err := c.Store.ListResponses(f)
+		if err != nil || len(left) == 0 {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// finished reports whether r's agent is idle and has been quiet long
+// enough, and has had time to start on the prompt at all.
+func (c *Capturer) finished(r *state.AgentResponse, now time.Time) bool {
+	quiet := c.Quiet
+	if quiet <= 0 {
+		quiet = DefaultQuiet
+	}
+	if now.Sub(r.CreatedAt) < quiet {
+		return false
+	}
+	st, err := c.detect(r.PaneID)
+	if err != nil || st.State != status.StateIdle {
+		return false
+	}
+	return now.Sub(st.LastActive) >= quiet
+}
+
+func (c *Capturer) finish(r *state.AgentResponse, outcome state.ResponseStatus, text, errMsg string) (bool, error) {
+	blocks := CodeBlocks(text)
+	ok, err := c.Store.FinishResponse(r.ID, outcome, text, blocks, errMsg)
+	if err != nil || !ok {
+		return false, err
+	}
+	now := c.now()
+	r.Status, r.Text, r.CodeBlocks, r.Error, r.CapturedAt = outcome, text, blocks, errMsg, &now
+	return true, nil
+}
+
+func (c *Capturer) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return DefaultTimeout
+}
+
+func (c *Capturer) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+func (c *Capturer) detect(paneID string) (status.AgentStatus, error) {
+	if c.Detect != nil {
+		return c.Detect(paneID)
+	}
+	return status.NewDetector().Detect(paneID)
+}
+
+func (c *Capturer) capture(r *state.AgentResponse) (string, error) {
+	if c.Capture != nil {
+		return c.Capture(r.PaneID, r.StartLine)
+	}
+	return CaptureSince(r.PaneID, r.StartLine, r.Prompt)
+}
+
+func (c *Capturer) exists(paneID string) bool {
+	if c.Exists != nil {
+		return c.Exists(paneID)
+	}
+	_, err := tmux.PaneCursorLine(paneID)
+	return err == nil
+}
@@ -0,0 +1,117 @@
+package reply
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/status"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+type fakePanes struct {
+	now    time.Time
+	states map[string]status.AgentState // Keyed by pane ID
+	output map[string]string
+	gone   map[string]bool
+}
+
+func newTestCapturer(t *testing.T, fp *fakePanes) *Capturer {
+	t.Helper()
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCapturer(store)
+	c.Interval = time.Millisecond
+	c.Now = func() time.Time { return fp.now }
+	c.Detect = func(paneID string) (status.AgentStatus, error) {
+		return status.AgentStatus{PaneID: paneID, State: fp.states[paneID], LastActive: fp.now.Add(-time.Minute)}, nil
+	}
+	c.Capture = func(paneID string, line int) (string, error) { return fp.output[paneID], nil }
+	c.Exists = func(paneID string) bool { return !fp.gone[paneID] }
+	return c
+}
+
+func TestCapturer(t *testing.T) {
+	fp := &fakePanes{
+		now:    time.Now().Add(time.Minute),
+		states: map[string]status.AgentState{"%1": status.StateIdle, "%2": status.StateWorking},
+		output: map[string]string{
+			"%1": "> fix it\n\n⏺ Fixed in main.go.\n\n```go\nreturn nil\n```\n>",
+			"%2": "› fix it\n\n• Still looking",
+		},
+		gone: map[string]bool{"%3": true},
+	}
+	c := newTestCapturer(t, fp)
+	ctx := context.Background()
+
+	marks := []Mark{
+		{Pane: tmux.Pane{ID: "%1", Index: 1, Type: tmux.AgentClaude}, Line: 10},
+		{Pane: tmux.Pane{ID: "%2", Index: 2, Type: tmux.AgentCodex}, Line: 4},
+		{Pane: tmux.Pane{ID: "%3", Index: 3, Type: tmux.AgentGemini}, Line: 0},
+	}
+	recorded, err := Record(c.Store, "h1", "proj", "fix it", marks)
+	if err != nil || len(recorded) != 3 {
+		t.Fatalf("Record() = %+v, %v", recorded, err)
+	}
+
+	captured, err := c.CaptureOnce(ctx, state.ResponseFilter{HistoryID: "h1", Pane: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captured) != 2 {
+		t.Fatalf("captured %+v, want panes 1 and 3", captured)
+	}
+	r, _ := c.Store.GetResponse(recorded[0].ID)
+	if r.Status != state.ResponseCaptured || r.Text != "Fixed in main.go.\n\n```go\nreturn nil\n```" {
+		t.Errorf("pane 1 response = %+v", r)
+	}
+	if len(r.CodeBlocks) != 1 || r.CodeBlocks[0].Language != "go" {
+		t.Errorf("pane 1 code blocks = %+v", r.CodeBlocks)
+	}
+	if r, _ := c.Store.GetResponse(recorded[2].ID); r.Status != state.ResponseLost {
+		t.Errorf("pane 3 response = %+v, want lost", r)
+	}
+
+	// The working agent's reply is stored as is once it times out.
+	if r, _ := c.Store.GetResponse(recorded[1].ID); r.Status != state.ResponsePending {
+		t.Fatalf("pane 2 response = %+v, want pending", r)
+	}
+	fp.now = fp.now.Add(DefaultTimeout)
+	if err := c.Wait(ctx, state.ResponseFilter{HistoryID: "h1", Pane: -1}); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ := c.Store.GetResponse(recorded[1].ID); r.Status != state.ResponseTimeout || r.Text != "• Still looking" {
+		t.Errorf("pane 2 response = %+v, want timeout", r)
+	}
+}
+
+func TestCapturer_WaitsForQuiet(t *testing.T) {
+	fp := &fakePanes{
+		now:    time.Now(),
+		states: map[string]status.AgentState{"%1": status.StateIdle},
+		output: map[string]string{"%1": "done"},
+	}
+	c := newTestCapturer(t, fp)
+
+	// Right after sending the agent may not have started yet.
+	recorded, err := Record(c.Store, "h1", "proj", "go", []Mark{{Pane: tmux.Pane{ID: "%1", Index: 1, Type: tmux.AgentClaude}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := c.CaptureResponse(&recorded[0]); ok || err != nil {
+		t.Fatalf("CaptureResponse() right after send = %v, %v", ok, err)
+	}
+	fp.now = fp.now.Add(DefaultQuiet + time.Second)
+	if ok, err := c.CaptureResponse(&recorded[0]); !ok || err != nil || recorded[0].Text != "done" {
+		t.Errorf("CaptureResponse() = %v, %v; response %+v", ok, err, recorded[0])
+	}
+}
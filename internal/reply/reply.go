@@ -0,0 +1,238 @@
+// Package reply captures what an agent printed in response to a prompt.
+//
+// When ntm send delivers a prompt it records the pane's cursor line as a
+// pending response linked to the prompt history entry. Once the agent is
+// idle again, a Capturer reads the pane from that line down, strips the
+// agent's TUI chrome (input box, status bar, spinners, the echoed prompt)
+// and stores the remaining text with its code blocks pre-extracted.
+package reply
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/Dicklesworthstone/ntm/internal/codeblock"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/status"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// Mark is where a pane's output stood just before a prompt was sent.
+type Mark struct {
+	Pane tmux.Pane
+	Line int
+}
+
+// MarkPane records the cursor line of p. Call it before sending. The line
+// only disambiguates the prompt's echo, which CaptureSince anchors on.
+func MarkPane(p tmux.Pane) (Mark, error) {
+	line, err := tmux.PaneCursorLine(p.ID)
+	if err != nil {
+		return Mark{}, err
+	}
+	return Mark{Pane: p, Line: line}, nil
+}
+
+// Record stores a pending response for each mark, linked to the prompt
+// history entry historyID.
+func Record(store *state.Store, historyID, session, prompt string, marks []Mark) ([]state.AgentResponse, error) {
+	responses := make([]state.AgentResponse, 0, len(marks))
+	for _, m := range marks {
+		r := &state.AgentResponse{
+			ID:        newID(),
+			HistoryID: historyID,
+			Session:   session,
+			Pane:      m.Pane.Index,
+			PaneID:    m.Pane.ID,
+			AgentType: string(m.Pane.Type),
+			StartLine: m.Line,
+			Prompt:    prompt,
+		}
+		if err := store.CreateResponse(r); err != nil {
+			return responses, err
+		}
+		responses = append(responses, *r)
+	}
+	return responses, nil
+}
+
+// CaptureSince captures paneID and returns its output from the echo of
+// prompt, sent after line was marked with MarkPane, to the bottom.
+func CaptureSince(paneID string, line int, prompt string) (string, error) {
+	raw, alternate, err := tmux.CapturePaneHistory(paneID)
+	if err != nil {
+		return "", err
+	}
+	if alternate {
+		// Full-screen TUIs redraw in place, so the mark says nothing about
+		// where on the screen the echo is.
+		line = -1
+	}
+	return SinceMark(raw, line, prompt), nil
+}
+
+// SinceMark returns the part of a pane capture that starts at the echo of
+// prompt. line, the absolute line marked just before sending, picks the
+// right echo when the prompt was sent before: it is the first echo at or
+// after line. Once tmux has dropped lines from the top of a full history
+// everything has moved up, so failing that it is the last echo before line.
+// A negative line means the position is unknown and the last echo is used.
+// Without an echo the capture is returned from line.
+func SinceMark(raw string, line int, prompt string) string {
+	lines := strings.Split(raw, "\n")
+	last := echoLine(prompt)
+
+	start := -1
+	if last != "" {
+		for i := range lines {
+			if !isEcho(lines[i], last) {
+				continue
+			}
+			if line >= 0 && i >= line {
+				start = i
+				break
+			}
+			start = i
+		}
+	}
+	if start < 0 {
+		start = 0
+		if line >= 0 && line < len(lines) {
+			start = line
+		}
+	}
+	return strings.Join(lines[start:], "\n")
+}
+
+// Chrome lines shown by every agent TUI: box borders, key hints, spinners
+// and token counters.
+var commonChrome = []*regexp.Regexp{
+	regexp.MustCompile(`^[\s─━═│┃╭╮╰╯┌┐└┘├┤┬┴┼╔╗╚╝║▔▁]*$`),
+	regexp.MustCompile(`(?i)\b(esc|ctrl\+c) to (interrupt|cancel)\b`),
+	regexp.MustCompile(`(?i)\?\s+for shortcuts`),
+	regexp.MustCompile(`(?i)\d+% context left`),
+	regexp.MustCompile(`^\s*[⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏✻✽✶✳✢·*]\s+\w+(ing|ed)?…`),
+}
+
+// Chrome specific to one agent type, keyed by tmux.AgentType.
+var agentChrome = map[string][]*regexp.Regexp{
+	string(tmux.AgentClaude): {
+		regexp.MustCompile(`(?i)^\s*⏵⏵ .*(accept|bypass|plan mode)`),
+		regexp.MustCompile(`(?i)auto-accept edits on|bypass permissions on`),
+		regexp.MustCompile(`^\s*[│┃]\s*>\s`),
+	},
+	string(tmux.AgentCodex): {
+		regexp.MustCompile(`(?i)⏎ send|ctrl\+j newline|shift\+⏎`),
+		regexp.MustCompile(`^\s*›\s`),
+	},
+	string(tmux.AgentGemini): {
+		regexp.MustCompile(`(?i)^\s*using:? \d+ `),
+		regexp.MustCompile(`(?i)\(esc to cancel`),
+		regexp.MustCompile(`(?i)no sandbox|gemini-[\d.]+-(pro|flash)`),
+	},
+}
+
+// Markers some agents put in front of the first line of each message.
+var messageMarker = regexp.MustCompile(`^(\s*)[⏺●✦]\s`)
+
+// Clean turns a raw pane capture taken from a mark into the agent's reply:
+// ANSI codes, TUI chrome, the echoed prompt and the idle prompt at the
+// bottom are removed, and runs of blank lines are collapsed.
+func Clean(raw, agentType, prompt string) string {
+	lines := strings.Split(status.StripANSI(strings.ReplaceAll(raw, "\r", "")), "\n")
+	lines = dropEcho(lines, prompt)
+
+	patterns := append(append([]*regexp.Regexp{}, commonChrome...), agentChrome[agentType]...)
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if isChrome(line, patterns) {
+			continue
+		}
+		line = messageMarker.ReplaceAllString(line, "$1")
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+
+	// The agent's empty input prompt sits below its reply.
+	for len(kept) > 0 {
+		last := strings.TrimSpace(kept[len(kept)-1])
+		if last != "" && !status.IsPromptLine(last, agentType) {
+			break
+		}
+		kept = kept[:len(kept)-1]
+	}
+
+	var out []string
+	blank := true // Also drops leading blank lines
+	for _, line := range kept {
+		if line == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		out = append(out, line)
+	}
+	return strings.TrimRight(strings.Join(out, "\n"), "\n")
+}
+
+// CodeBlocks extracts the fenced code blocks of a cleaned reply.
+func CodeBlocks(text string) []codeblock.CodeBlock {
+	return codeblock.NewParser().Parse(text)
+}
+
+func isChrome(line string, patterns []*regexp.Regexp) bool {
+	if strings.TrimSpace(line) == "" {
+		return false
+	}
+	for _, re := range patterns {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropEcho removes the prompt as echoed back by the agent from the top of
+// lines. Only the first few lines are searched, since the echo comes first.
+func dropEcho(lines []string, prompt string) []string {
+	last := echoLine(prompt)
+	if last == "" {
+		return lines
+	}
+	limit := min(len(lines), strings.Count(strings.TrimSpace(prompt), "\n")+9)
+	for i := limit - 1; i >= 0; i-- {
+		if isEcho(lines[i], last) {
+			return lines[i+1:]
+		}
+	}
+	return lines
+}
+
+// echoLine returns the last line of prompt, which ends its echo.
+func echoLine(prompt string) string {
+	promptLines := strings.Split(strings.TrimSpace(prompt), "\n")
+	return strings.TrimSpace(promptLines[len(promptLines)-1])
+}
+
+// isEcho reports whether line ends the echo of a prompt whose last line is
+// last.
+func isEcho(line, last string) bool {
+	line = strings.TrimSpace(strings.Trim(status.StripANSI(line), "│┃ "))
+	if line == "" {
+		return false
+	}
+	// The agent may have wrapped the echo, leaving only the prompt's tail
+	// on the last line.
+	return strings.HasSuffix(line, last) || (len(line) >= min(len(last), 8) && strings.HasSuffix(last, line))
+}
+
+// newID returns a short random response ID.
+func newID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return "r-" + hex.EncodeToString(b)
+}
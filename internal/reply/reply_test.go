@@ -0,0 +1,153 @@
+package reply
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name      string
+		agentType string
+		prompt    string
+		raw       string
+		want      string
+	}{
+		{
+			name:      "claude",
+			agentType: "cc",
+			prompt:    "how do I reverse a slice?",
+			raw: "> how do I reverse a slice?\n\n" +
+				"\x1b[1m⏺\x1b[0m Use slices.Reverse:\n\n" +
+				"```go\nslices.Reverse(s)\n```\n\n\n" +
+				"✻ Pondering… (3s · ↑ 120 tokens · esc to interrupt)\n" +
+				"╭──────────────────────────╮\n" +
+				"│ >                        │\n" +
+				"╰──────────────────────────╯\n" +
+				"  ⏵⏵ auto-accept edits on (shift+tab to cycle)\n" +
+				">",
+			want: "Use slices.Reverse:\n\n```go\nslices.Reverse(s)\n```",
+		},
+		{
+			name:      "codex",
+			agentType: "cod",
+			prompt:    "list the tests\nthat fail",
+			raw: "› list the tests\n  that fail\n\n" +
+				"• Two tests fail:\n  - TestA\n  - TestB\n\n" +
+				"› \n\n  ⏎ send   Ctrl+J newline   Ctrl+C quit   47% context left",
+			want: "• Two tests fail:\n  - TestA\n  - TestB",
+		},
+		{
+			name:      "no echo found",
+			agentType: "gmi",
+			prompt:    "something else",
+			raw:       "✦ All done.\n\nUsing: 1 GEMINI.md file\ngemini>",
+			want:      "All done.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Clean(tt.raw, tt.agentType, tt.prompt); got != tt.want {
+				t.Errorf("Clean() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeBlocks(t *testing.T) {
+	blocks := CodeBlocks("Run:\n\n```bash\ngo test ./...\n```\n")
+	if len(blocks) != 1 || blocks[0].Language != "bash" || blocks[0].Content != "go test ./..." {
+		t.Errorf("CodeBlocks() = %+v", blocks)
+	}
+}
+
+func TestSinceMark(t *testing.T) {
+	history := strings.Join([]string{
+		"$ run the tests", // earlier, identical prompt
+		"ok",
+		"$ run the tests", // marked at line 2
+		"FAIL TestA",
+		"$ ",
+	}, "\n")
+
+	tests := []struct {
+		name   string
+		raw    string
+		line   int
+		prompt string
+		want   string
+	}{
+		{"echo at mark", history, 2, "run the tests", "$ run the tests\nFAIL TestA\n$ "},
+		{"history trimmed past the mark", strings.Join(strings.Split(history, "\n")[2:], "\n"), 3, "run the tests", "$ run the tests\nFAIL TestA\n$ "},
+		{"alternate screen", history, -1, "run the tests", "$ run the tests\nFAIL TestA\n$ "},
+		{"no echo", history, 3, "something else", "FAIL TestA\n$ "},
+		{"no echo, mark gone", history, 40, "something else", history},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SinceMark(tt.raw, tt.line, tt.prompt); got != tt.want {
+				t.Errorf("SinceMark() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCaptureSince_HistoryOverflow sends a prompt to a pane whose history
+// is at history-limit, so tmux drops lines from the top while the reply is
+// printed and the marked line no longer points at the prompt.
+func TestCaptureSince_HistoryOverflow(t *testing.T) {
+	if !tmux.IsInstalled() {
+		t.Skip("tmux not installed")
+	}
+	session := fmt.Sprintf("ntm-reply-test-%d", time.Now().UnixNano())
+	if err := exec.Command("tmux", "new-session", "-d", "-s", session, "-x", "80", "-y", "10", "sh").Run(); err != nil {
+		t.Skipf("cannot start tmux session: %v", err)
+	}
+	defer exec.Command("tmux", "kill-session", "-t", session).Run()
+	// history-limit applies to panes created after it is set.
+	if err := exec.Command("tmux", "set-option", "-t", session, "history-limit", "100").Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := exec.Command("tmux", "new-window", "-t", session, "-n", "reply", "sh").Run(); err != nil {
+		t.Fatal(err)
+	}
+	pane := session + ":reply.0"
+	run := func(cmd, done string) {
+		t.Helper()
+		if err := tmux.SendKeys(pane, cmd, true); err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(10 * time.Second)
+		for time.Now().Before(deadline) {
+			out, _ := tmux.CapturePaneOutput(pane, 20)
+			if strings.Contains(out, done) {
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %q", done)
+	}
+
+	run("seq 1001 1150; echo filled", "\nfilled")
+	line, err := tmux.PaneCursorLine(pane)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt := "seq 1 40; echo replied"
+	run(prompt, "\nreplied")
+
+	got, err := CaptureSince(pane, line, prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(got, "\n")
+	if len(lines) < 42 || !strings.HasSuffix(lines[0], prompt) || lines[1] != "1" || lines[41] != "replied" {
+		t.Errorf("CaptureSince() = %q, want the echo followed by the whole reply", got)
+	}
+}
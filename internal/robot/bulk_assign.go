@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/Dicklesworthstone/ntm/internal/bv"
+	"github.com/Dicklesworthstone/ntm/internal/inbox"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
@@ -29,6 +30,7 @@ type BulkAssignOptions struct {
 	Stagger            time.Duration
 	SkipPanes          []int
 	PromptTemplatePath string
+	Queue              bool // Add prompts to each pane's prompt queue instead of typing them now
 	Deps               *BulkAssignDependencies
 }
 
@@ -38,6 +40,7 @@ type BulkAssignDependencies struct {
 	FetchInProgress  func(dir string, limit int) ([]bv.BeadInProgress, error)
 	ListPanes        func(session string) ([]tmux.Pane, error)
 	SendKeys         func(paneID, message string, enter bool) error
+	Enqueue          func(session string, pane int, prompt string) (string, error)
 	ReadFile         func(path string) ([]byte, error)
 	FetchBeadTitle   func(dir, beadID string) (string, error)
 	FetchBeadDetails func(dir, beadID string) (BeadDetails, error)
@@ -75,6 +78,7 @@ type BulkAssignAssignment struct {
 	AgentType  string `json:"agent_type"`
 	Status     string `json:"status"`
 	PromptSent bool   `json:"prompt_sent"`
+	QueueID    string `json:"queue_id,omitempty"`
 	Error      string `json:"error,omitempty"`
 }
 
@@ -82,6 +86,7 @@ type BulkAssignAssignment struct {
 type BulkAssignSummary struct {
 	TotalPanes int `json:"total_panes"`
 	Assigned   int `json:"assigned"`
+	Queued     int `json:"queued,omitempty"`
 	Skipped    int `json:"skipped"`
 	Failed     int `json:"failed"`
 }
@@ -220,6 +225,7 @@ func bulkAssignDeps(custom *BulkAssignDependencies) BulkAssignDependencies {
 		FetchInProgress:  func(dir string, limit int) ([]bv.BeadInProgress, error) { return bv.GetInProgressList(dir, limit), nil },
 		ListPanes:        tmux.GetPanes,
 		SendKeys:         tmux.SendKeys,
+		Enqueue:          enqueueBulkPrompt,
 		ReadFile:         os.ReadFile,
 		FetchBeadTitle:   fetchBeadTitle,
 		FetchBeadDetails: fetchBeadDetails,
@@ -242,6 +248,9 @@ func bulkAssignDeps(custom *BulkAssignDependencies) BulkAssignDependencies {
 	if custom.SendKeys != nil {
 		deps.SendKeys = custom.SendKeys
 	}
+	if custom.Enqueue != nil {
+		deps.Enqueue = custom.Enqueue
+	}
 	if custom.ReadFile != nil {
 		deps.ReadFile = custom.ReadFile
 	}
@@ -600,8 +609,7 @@ func applyBulkAssignPlan(opts BulkAssignOptions, deps BulkAssignDependencies, ou
 					return
 				}
 
-				paneID := fmt.Sprintf("%s:%d", output.Session, a.Pane)
-				if err := deps.SendKeys(paneID, prompt, true); err != nil {
+				if err := sendBulkAssignPrompt(opts, deps, output.Session, a, prompt); err != nil {
 					a.Status = "failed"
 					a.Error = err.Error()
 					a.PromptSent = false
@@ -610,9 +618,6 @@ func applyBulkAssignPlan(opts BulkAssignOptions, deps BulkAssignDependencies, ou
 					mu.Unlock()
 					return
 				}
-
-				a.Status = "assigned"
-				a.PromptSent = true
 			}(assignment)
 		}
 		wg.Wait()
@@ -634,16 +639,13 @@ func applyBulkAssignPlan(opts BulkAssignOptions, deps BulkAssignDependencies, ou
 				assignment.Status = "planned"
 				assignment.PromptSent = false
 			} else {
-				paneID := fmt.Sprintf("%s:%d", output.Session, assignment.Pane)
-				if err := deps.SendKeys(paneID, prompt, true); err != nil {
+				if err := sendBulkAssignPrompt(opts, deps, output.Session, assignment, prompt); err != nil {
 					assignment.Status = "failed"
 					assignment.Error = err.Error()
 					assignment.PromptSent = false
 					plan.failed++
 					continue
 				}
-				assignment.Status = "assigned"
-				assignment.PromptSent = true
 			}
 
 			if opts.Stagger > 0 && i < len(plan.Assignments)-1 {
@@ -657,11 +659,15 @@ func applyBulkAssignPlan(opts BulkAssignOptions, deps BulkAssignDependencies, ou
 	output.UnassignedPanes = append(output.UnassignedPanes, plan.UnassignedPanes...)
 
 	assigned := 0
+	queued := 0
 	failed := 0
 	for _, assignment := range output.Assignments {
 		switch assignment.Status {
 		case "assigned":
 			assigned++
+		case "queued":
+			assigned++
+			queued++
 		case "failed":
 			failed++
 		}
@@ -670,11 +676,48 @@ func applyBulkAssignPlan(opts BulkAssignOptions, deps BulkAssignDependencies, ou
 	output.Summary = BulkAssignSummary{
 		TotalPanes: len(output.Assignments) + len(output.UnassignedPanes),
 		Assigned:   assigned,
+		Queued:     queued,
 		Skipped:    0,
 		Failed:     failed,
 	}
 }
 
+// sendBulkAssignPrompt types prompt into the assignment's pane, or with
+// opts.Queue adds it to the pane's prompt queue, and records the outcome.
+func sendBulkAssignPrompt(opts BulkAssignOptions, deps BulkAssignDependencies, session string, a *BulkAssignAssignment, prompt string) error {
+	if opts.Queue {
+		id, err := deps.Enqueue(session, a.Pane, prompt)
+		if err != nil {
+			return err
+		}
+		a.Status = "queued"
+		a.QueueID = id
+		return nil
+	}
+
+	paneID := fmt.Sprintf("%s:%d", session, a.Pane)
+	if err := deps.SendKeys(paneID, prompt, true); err != nil {
+		return err
+	}
+	a.Status = "assigned"
+	a.PromptSent = true
+	return nil
+}
+
+// enqueueBulkPrompt adds prompt to the pane's queue in the default state store.
+func enqueueBulkPrompt(session string, pane int, prompt string) (string, error) {
+	store, err := inbox.OpenStore()
+	if err != nil {
+		return "", err
+	}
+	defer store.Close()
+	p, err := inbox.Enqueue(store, session, pane, prompt, inbox.SourceBulkAssign)
+	if err != nil {
+		return "", err
+	}
+	return p.ID, nil
+}
+
 func buildBulkAssignPrompt(template string, deps BulkAssignDependencies, assignment *BulkAssignAssignment, session string, needsDetails bool) (string, error) {
 	beadType := ""
 	var beadDeps []string
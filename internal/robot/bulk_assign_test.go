@@ -300,6 +300,41 @@ func TestBulkAssignDryRunSkipsPromptSend(t *testing.T) {
 	t.Logf("dry-run output=%+v", output)
 }
 
+func TestBulkAssignQueueEnqueuesInsteadOfSending(t *testing.T) {
+	panes := mockPanes("proj", []int{1, 2})
+	beads := []bulkBead{{ID: "bd-1", Title: "Title1"}, {ID: "bd-2", Title: "Title2"}}
+	plan := allocateBulkAssignBeads(panes, beads)
+
+	var queued []int
+	deps := BulkAssignDependencies{
+		SendKeys: func(paneID, message string, enter bool) error {
+			t.Errorf("unexpected send to %s", paneID)
+			return nil
+		},
+		Enqueue: func(session string, pane int, prompt string) (string, error) {
+			if pane == 2 {
+				return "", errors.New("store locked")
+			}
+			queued = append(queued, pane)
+			return "q-0001", nil
+		},
+		ReadFile: func(path string) ([]byte, error) { return []byte(defaultBulkAssignTemplate), nil },
+	}
+	output := BulkAssignOutput{Session: "proj"}
+	applyBulkAssignPlan(BulkAssignOptions{Queue: true}, bulkAssignDeps(&deps), &output, plan)
+
+	if !reflect.DeepEqual(queued, []int{1}) {
+		t.Fatalf("queued panes = %v, want [1]", queued)
+	}
+	a := output.Assignments[0]
+	if a.Status != "queued" || a.QueueID != "q-0001" || a.PromptSent {
+		t.Errorf("queued assignment = %+v", a)
+	}
+	if output.Summary.Assigned != 1 || output.Summary.Queued != 1 || output.Summary.Failed != 1 {
+		t.Errorf("summary = %+v", output.Summary)
+	}
+}
+
 func TestBulkAssignAllocationParsing(t *testing.T) {
 	allocation := `{"1":"bd-1","2":"bd-2"}`
 	parsed, err := parseBulkAssignAllocation(allocation)
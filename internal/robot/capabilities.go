@@ -1162,6 +1162,34 @@ func buildCommandRegistry() []RobotCommandInfo {
 			},
 			Examples: []string{"ntm --robot-history=myproject --history-last=10"},
 		},
+		{
+			Name:        "responses",
+			Flag:        "--robot-responses",
+			Category:    "utility",
+			Description: "Get each agent's captured reply to a prompt sent with ntm send.",
+			Parameters: []RobotParameter{
+				{Name: "history-id", Flag: "--robot-responses", Type: "string", Required: true, Description: "History entry ID"},
+				{Name: "responses-wait", Flag: "--responses-wait", Type: "duration", Required: false, Description: "Wait up to this long for agents still replying"},
+			},
+			Examples: []string{"ntm --robot-responses=1735830245123-a1b2c3d4 --responses-wait=5m"},
+		},
+		{
+			Name:        "merge-queue",
+			Flag:        "--robot-merge-queue",
+			Category:    "control",
+			Description: "Rebase each agent worktree branch onto the integration branch in a scratch worktree, run the verification command and fast-forward on success. Agents are told about conflicts and failures.",
+			Parameters: []RobotParameter{
+				{Name: "session", Flag: "--robot-merge-queue", Type: "string", Required: true, Description: "Session whose ntm/<session>/<agent> branches to merge"},
+				{Name: "merge-queue-status", Flag: "--merge-queue-status", Type: "bool", Required: false, Description: "Report the last run instead of starting one"},
+				{Name: "merge-queue-target", Flag: "--merge-queue-target", Type: "string", Required: false, Description: "Integration branch (default ntm/<session>/integration)"},
+				{Name: "merge-queue-verify", Flag: "--merge-queue-verify", Type: "string", Required: false, Description: "Command that must pass before a branch is merged"},
+				{Name: "merge-queue-agents", Flag: "--merge-queue-agents", Type: "string", Required: false, Description: "Comma-separated agents to merge, in order"},
+			},
+			Examples: []string{
+				"ntm --robot-merge-queue=myproject --merge-queue-verify='go test ./...'",
+				"ntm --robot-merge-queue=myproject --merge-queue-status",
+			},
+		},
 		{
 			Name:        "replay",
 			Flag:        "--robot-replay",
@@ -5,8 +5,9 @@ package robot
 import (
 	"os/exec"
 	"strconv"
-	"time"
 
+	"github.com/Dicklesworthstone/ntm/internal/adapter"
+	"github.com/Dicklesworthstone/ntm/internal/agent"
 	"github.com/Dicklesworthstone/ntm/internal/process"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
@@ -15,97 +16,39 @@ import (
 // Agent Exit Sequences (bd-2c7f4)
 // =============================================================================
 //
-// Each AI coding agent has different exit methods:
+// Each AI coding agent has a different exit method, defined by its adapter
+// in internal/adapter:
 // - Claude Code (cc): Double Ctrl+C with CRITICAL 0.1s timing
 // - Codex (cod): /exit command
 // - Gemini (gmi): Escape (exit shell mode if active) then /exit
+// - Plugin agents: the exit_sequence declared in their plugin definition
 // - Unknown: Try Ctrl+C as fallback
 
-// exitAgent exits the current agent using the appropriate method.
-func exitAgent(session string, pane int, agentType string, seq *RestartSequence) error {
-	switch agentType {
-	case "cc":
-		return exitClaudeCode(session, pane, seq)
-	case "cod":
-		return exitCodex(session, pane, seq)
-	case "gmi":
-		return exitGemini(session, pane, seq)
-	default:
-		return exitUnknown(session, pane, seq)
-	}
-}
-
-// exitClaudeCode exits Claude Code with double Ctrl+C.
-// CRITICAL: The 0.1s timing between Ctrl+Cs is essential!
-func exitClaudeCode(session string, pane int, seq *RestartSequence) error {
-	seq.ExitMethod = "double_ctrl_c"
-
-	// First Ctrl+C
-	if err := sendCtrlC(session, pane); err != nil {
-		return wrapError("first ctrl-c failed", err)
-	}
-
-	// CRITICAL: 100ms pause between Ctrl+Cs
-	time.Sleep(100 * time.Millisecond)
-
-	// Second Ctrl+C
-	if err := sendCtrlC(session, pane); err != nil {
-		return wrapError("second ctrl-c failed", err)
-	}
-
-	return nil
-}
-
-// exitCodex exits Codex CLI with /exit command.
-func exitCodex(session string, pane int, seq *RestartSequence) error {
-	seq.ExitMethod = "exit_command"
-
-	if err := sendKeys(session, pane, "/exit\n"); err != nil {
-		return wrapError("exit command failed", err)
-	}
-
-	return nil
-}
-
-// exitGemini exits Gemini CLI with Escape (to exit shell mode) then /exit.
-func exitGemini(session string, pane int, seq *RestartSequence) error {
-	seq.ExitMethod = "escape_then_exit"
-
-	// Send Escape to exit shell mode if active
-	if err := sendEscape(session, pane); err != nil {
-		return wrapError("escape failed", err)
-	}
-
-	// Brief pause
-	time.Sleep(100 * time.Millisecond)
-
-	// Send /exit command
-	if err := sendKeys(session, pane, "/exit\n"); err != nil {
-		return wrapError("exit failed", err)
-	}
-
-	return nil
-}
-
-// exitUnknown tries Ctrl+C as a fallback for unknown agent types.
-func exitUnknown(session string, pane int, seq *RestartSequence) error {
-	seq.ExitMethod = "ctrl_c_fallback"
-
-	if err := sendCtrlC(session, pane); err != nil {
-		return wrapError("ctrl-c failed", err)
+// RegisterExitSequence sets the keys sent, in order, to quit agents of
+// agentType. Each entry is passed to tmux send-keys, so key names such as
+// "C-c", "Escape" and "Enter" are pressed and other text is typed. It updates
+// the exit keys of the type's declarative adapter, or registers a generic
+// one; built-in agents keep their own exit methods.
+func RegisterExitSequence(agentType string, keys []string) {
+	t := agent.AgentType(agentType)
+	base := &adapter.Base{AgentType: t}
+	if a, ok := adapter.Lookup(t); ok {
+		existing, ok := a.(*adapter.Base)
+		if !ok {
+			return
+		}
+		copied := *existing
+		base = &copied
 	}
-
-	return nil
-}
-
-// sendCtrlC sends Ctrl+C to a tmux pane.
-func sendCtrlC(session string, pane int) error {
-	return runTmuxCommand("send-keys", "-t", formatTarget(session, pane), "C-c")
+	base.ExitKeys = append([]string(nil), keys...)
+	_ = adapter.Register(base)
 }
 
-// sendEscape sends Escape key to a tmux pane.
-func sendEscape(session string, pane int) error {
-	return runTmuxCommand("send-keys", "-t", formatTarget(session, pane), "Escape")
+// exitAgent exits the current agent using its adapter's exit method.
+func exitAgent(session string, pane int, agentType string, seq *RestartSequence) error {
+	method, err := adapter.Get(agent.AgentType(agentType)).Exit(formatTarget(session, pane))
+	seq.ExitMethod = method
+	return err
 }
 
 // sendKeys sends literal keys to a tmux pane.
@@ -0,0 +1,158 @@
+package robot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/delivery"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+	"github.com/Dicklesworthstone/ntm/internal/worktrees"
+)
+
+// MergeQueueOptions configures --robot-merge-queue.
+type MergeQueueOptions struct {
+	Session    string
+	ProjectDir string        // "" uses the current directory
+	Target     string        // integration branch; "" for ntm/<session>/integration
+	Verify     string        // verification command; "" merges without verifying
+	Timeout    time.Duration // verification timeout; 0 for the default
+	Agents     []string      // agent branches in merge order; empty for all
+	Status     bool          // report the last run instead of starting one
+	NoNotify   bool          // don't message agents about conflicts and failures
+}
+
+// MergeQueueOutput is the structured output for --robot-merge-queue.
+type MergeQueueOutput struct {
+	RobotResponse
+	Session    string                 `json:"session"`
+	Report     *worktrees.QueueReport `json:"report"`
+	AgentHints *MergeQueueAgentHints  `json:"_agent_hints,omitempty"`
+}
+
+// MergeQueueAgentHints provides actionable suggestions for AI agents
+type MergeQueueAgentHints struct {
+	Summary           string   `json:"summary,omitempty"`
+	SuggestedCommands []string `json:"suggested_commands,omitempty"`
+}
+
+// GetMergeQueue runs the session's merge queue, or with Status set returns
+// the report of its last run.
+// This function returns the data struct directly, enabling CLI/REST parity.
+func GetMergeQueue(opts MergeQueueOptions) (*MergeQueueOutput, error) {
+	output := &MergeQueueOutput{
+		RobotResponse: NewRobotResponse(true),
+		Session:       opts.Session,
+	}
+	if opts.Session == "" {
+		output.RobotResponse = NewErrorResponse(
+			fmt.Errorf("session is required"),
+			ErrCodeInvalidFlag,
+			"Example: ntm --robot-merge-queue=myproject",
+		)
+		return output, nil
+	}
+
+	dir, err := resolveMergeQueueDir(opts.ProjectDir)
+	if err != nil {
+		output.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "")
+		return output, nil
+	}
+
+	if opts.Status {
+		report, err := worktrees.LoadQueueReport(dir, opts.Session)
+		if err != nil {
+			output.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "")
+			return output, nil
+		}
+		output.Report = report
+		output.AgentHints = mergeQueueHints(opts.Session, report)
+		return output, nil
+	}
+
+	q := worktrees.NewMergeQueue(dir, opts.Session)
+	q.Target = opts.Target
+	q.Verify = opts.Verify
+	if opts.Timeout > 0 {
+		q.VerifyTimeout = opts.Timeout
+	}
+	if !opts.NoNotify {
+		q.Notify = NotifyMergeAgent(opts.Session)
+	}
+
+	report, err := q.Run(context.Background(), opts.Agents)
+	output.Report = report
+	switch {
+	case errors.Is(err, worktrees.ErrQueueBusy):
+		output.RobotResponse = NewErrorResponse(err, ErrCodeResourceBusy,
+			fmt.Sprintf("Follow the running queue with: ntm --robot-merge-queue=%s --merge-queue-status", opts.Session))
+	case err != nil:
+		output.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "")
+	default:
+		output.AgentHints = mergeQueueHints(opts.Session, report)
+	}
+	return output, nil
+}
+
+// PrintMergeQueue outputs a merge queue run or its last report as JSON.
+func PrintMergeQueue(opts MergeQueueOptions) error {
+	output, err := GetMergeQueue(opts)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(output)
+}
+
+func mergeQueueHints(session string, report *worktrees.QueueReport) *MergeQueueAgentHints {
+	if report == nil {
+		return &MergeQueueAgentHints{
+			Summary:           "the merge queue has not run for this session",
+			SuggestedCommands: []string{fmt.Sprintf("ntm --robot-merge-queue=%s", session)},
+		}
+	}
+	hints := &MergeQueueAgentHints{
+		Summary: fmt.Sprintf("%d merged, %d conflict, %d failed verification, %d up to date into %s",
+			report.Count(worktrees.MergeMerged), report.Count(worktrees.MergeConflict),
+			report.Count(worktrees.MergeFailed), report.Count(worktrees.MergeUpToDate), report.Target),
+	}
+	if report.Count(worktrees.MergeConflict)+report.Count(worktrees.MergeFailed)+report.Count(worktrees.MergeError) > 0 {
+		hints.SuggestedCommands = []string{fmt.Sprintf("ntm --robot-merge-queue=%s", session)}
+	}
+	return hints
+}
+
+// NotifyMergeAgent returns a merge queue notifier that pastes the entry's
+// message into the pane of the agent that owns the branch.
+func NotifyMergeAgent(session string) func(worktrees.MergeEntry, string) error {
+	return func(e worktrees.MergeEntry, target string) error {
+		panes, err := tmux.GetPanes(session)
+		if err != nil {
+			return err
+		}
+		for _, p := range panes {
+			if strings.ToLower(string(p.Type))+"_"+strconv.Itoa(p.NTMIndex) != e.Agent {
+				continue
+			}
+			res := delivery.ToPane(context.Background(), p.ID, p.Type, e.Message(target), delivery.Options{})
+			if !res.OK() && res.Outcome != delivery.OutcomeUndelivered {
+				return fmt.Errorf("notify %s: %s", e.Agent, res.Error)
+			}
+			return nil
+		}
+		return fmt.Errorf("no pane for agent %s in session %s", e.Agent, session)
+	}
+}
+
+// resolveMergeQueueDir returns the absolute project directory, defaulting to
+// the current one.
+func resolveMergeQueueDir(dir string) (string, error) {
+	if dir == "" {
+		return os.Getwd()
+	}
+	return filepath.Abs(dir)
+}
@@ -4,9 +4,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Dicklesworthstone/ntm/internal/adapter"
+	"github.com/Dicklesworthstone/ntm/internal/agent"
 	"github.com/Dicklesworthstone/ntm/internal/agentmail"
+	"github.com/Dicklesworthstone/ntm/internal/budget"
+	"github.com/Dicklesworthstone/ntm/internal/config"
 	"github.com/Dicklesworthstone/ntm/internal/ensemble"
 	"github.com/Dicklesworthstone/ntm/internal/integrations/pt"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
 func TestParseJFPIDs(t *testing.T) {
@@ -741,3 +746,49 @@ func TestUpdatePTSummary_UnrecognizedClassification(t *testing.T) {
 		t.Errorf("Unknown = %d, want 1 for unrecognized classification", summary.Unknown)
 	}
 }
+
+func TestBudgetAlerts(t *testing.T) {
+	t.Parallel()
+	status := &budget.Status{Session: "proj", Scopes: []budget.ScopeStatus{
+		budget.Evaluate(budget.ScopeSession, "proj", budget.Spend{USD: 12}, config.BudgetLimits{HardUSD: 10}),
+		budget.Evaluate(budget.ScopeDaily, "2026-03-01", budget.Spend{USD: 6}, config.BudgetLimits{SoftUSD: 5}),
+		budget.Evaluate(budget.ScopeProject, "/work/proj", budget.Spend{USD: 1}, config.BudgetLimits{SoftUSD: 5}),
+	}}
+	alerts := budgetAlerts(status)
+	if len(alerts) != 2 {
+		t.Fatalf("got %d alerts, want 2: %+v", len(alerts), alerts)
+	}
+	if alerts[0].Type != "budget_exceeded" || alerts[0].Severity != "critical" || alerts[0].Session != "proj" {
+		t.Errorf("hard alert = %+v", alerts[0])
+	}
+	if alerts[1].Type != "budget_warning" || alerts[1].Severity != "warning" || alerts[1].Message == "" {
+		t.Errorf("soft alert = %+v", alerts[1])
+	}
+}
+
+func TestPluginAgentTypes(t *testing.T) {
+	const goose = tmux.AgentType("goose-robot-test")
+	if got := agentTypeString(goose); got != "unknown" {
+		t.Fatalf("unregistered type = %q, want unknown", got)
+	}
+	if err := agent.RegisterPatternSet(goose, &agent.PatternSet{ContextLimit: 64000}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { agent.UnregisterPatternSet(goose) })
+
+	if got := agentTypeString(goose); got != string(goose) {
+		t.Errorf("agentTypeString = %q, want %q", got, goose)
+	}
+	if got := modelNameForPane(tmux.Pane{Type: goose}, nil); got != string(goose) {
+		t.Errorf("modelNameForPane = %q, want %q", got, goose)
+	}
+	if got := pluginContextLimit(string(goose)); got != 64000 {
+		t.Errorf("pluginContextLimit = %d, want 64000", got)
+	}
+
+	RegisterExitSequence(string(goose), []string{"Escape", "/exit", "Enter"})
+	t.Cleanup(func() { adapter.Unregister(goose) })
+	if got := adapter.Get(goose).(*adapter.Base).ExitKeys; len(got) != 3 || got[1] != "/exit" {
+		t.Errorf("registered exit keys = %v", got)
+	}
+}
@@ -0,0 +1,131 @@
+package robot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/reply"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+// ResponsesOptions configures --robot-responses.
+type ResponsesOptions struct {
+	HistoryID string        // prompt history entry ID
+	Session   string        // list recent responses in a session instead
+	Pane      int           // negative for every pane
+	Limit     int           // max responses when listing a session
+	Wait      time.Duration // wait this long for agents still replying
+	Store     *state.Store  // nil opens the default state store
+}
+
+// ResponsesOutput is the structured output for --robot-responses.
+type ResponsesOutput struct {
+	RobotResponse
+	HistoryID  string                `json:"history_id,omitempty"`
+	Session    string                `json:"session,omitempty"`
+	Responses  []state.AgentResponse `json:"responses"`
+	Pending    int                   `json:"pending"`
+	AgentHints *ResponsesAgentHints  `json:"_agent_hints,omitempty"`
+}
+
+// ResponsesAgentHints provides actionable suggestions for AI agents
+type ResponsesAgentHints struct {
+	Summary           string   `json:"summary,omitempty"`
+	SuggestedCommands []string `json:"suggested_commands,omitempty"`
+}
+
+// GetResponses returns the agents' replies to a prompt sent with ntm send,
+// capturing any that have finished since.
+// This function returns the data struct directly, enabling CLI/REST parity.
+func GetResponses(opts ResponsesOptions) (*ResponsesOutput, error) {
+	output := &ResponsesOutput{
+		RobotResponse: NewRobotResponse(true),
+		HistoryID:     opts.HistoryID,
+		Session:       opts.Session,
+		Responses:     []state.AgentResponse{},
+	}
+
+	if opts.HistoryID == "" && opts.Session == "" {
+		output.RobotResponse = NewErrorResponse(
+			fmt.Errorf("history entry ID or session is required"),
+			ErrCodeInvalidFlag,
+			"Provide the ID printed by ntm send: ntm --robot-responses=<id>",
+		)
+		return output, nil
+	}
+
+	store := opts.Store
+	if store == nil {
+		var err error
+		if store, err = state.Open(""); err != nil {
+			output.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "Check the ntm state directory")
+			return output, nil
+		}
+		defer store.Close()
+		if err := store.Migrate(); err != nil {
+			output.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "Check the ntm state directory")
+			return output, nil
+		}
+	}
+
+	filter := state.ResponseFilter{HistoryID: opts.HistoryID, Session: opts.Session, Pane: opts.Pane}
+	if opts.HistoryID == "" {
+		filter.Limit = opts.Limit
+	}
+
+	capturer := reply.NewCapturer(store)
+	if opts.Wait > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), opts.Wait)
+		err := capturer.Wait(ctx, filter)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			output.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "")
+			return output, nil
+		}
+	} else if _, err := capturer.CaptureOnce(context.Background(), filter); err != nil {
+		output.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "")
+		return output, nil
+	}
+
+	responses, err := store.ListResponses(filter)
+	if err != nil {
+		output.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "")
+		return output, nil
+	}
+	if opts.HistoryID != "" && len(responses) == 0 {
+		output.RobotResponse = NewErrorResponse(
+			fmt.Errorf("no responses recorded for history entry %s", opts.HistoryID),
+			ErrCodeInvalidFlag,
+			"Responses are recorded for prompts sent with ntm send; use --robot-history to find entry IDs",
+		)
+		return output, nil
+	}
+	if responses != nil {
+		output.Responses = responses
+	}
+
+	for _, r := range output.Responses {
+		if r.Status == state.ResponsePending {
+			output.Pending++
+		}
+	}
+	output.AgentHints = &ResponsesAgentHints{
+		Summary: fmt.Sprintf("%d response(s), %d still pending", len(output.Responses), output.Pending),
+	}
+	if output.Pending > 0 && opts.HistoryID != "" {
+		output.AgentHints.SuggestedCommands = []string{
+			fmt.Sprintf("ntm --robot-responses=%s --responses-wait=5m", opts.HistoryID),
+		}
+	}
+	return output, nil
+}
+
+// PrintResponses outputs the replies to a prompt as JSON.
+func PrintResponses(opts ResponsesOptions) error {
+	output, err := GetResponses(opts)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(output)
+}
@@ -13,12 +13,15 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/ntm/internal/agent"
 	"github.com/Dicklesworthstone/ntm/internal/agentmail"
 	"github.com/Dicklesworthstone/ntm/internal/alerts"
+	"github.com/Dicklesworthstone/ntm/internal/budget"
 	"github.com/Dicklesworthstone/ntm/internal/bv"
 	"github.com/Dicklesworthstone/ntm/internal/cass"
 	"github.com/Dicklesworthstone/ntm/internal/config"
 	ntmctx "github.com/Dicklesworthstone/ntm/internal/context"
+	"github.com/Dicklesworthstone/ntm/internal/delivery"
 	"github.com/Dicklesworthstone/ntm/internal/git"
 	"github.com/Dicklesworthstone/ntm/internal/handoff"
 	"github.com/Dicklesworthstone/ntm/internal/health"
@@ -1512,6 +1515,8 @@ type SessionInfo struct {
 	CreatedAt   *time.Time `json:"created_at,omitempty"`
 	Agents      []Agent    `json:"agents,omitempty"`
 	PrivacyMode bool       `json:"privacy_mode,omitempty"` // True if privacy mode is enabled
+	// Budget is the session's spend against configured budgets, if any.
+	Budget *budget.Status `json:"budget,omitempty"`
 }
 
 // Agent represents an AI agent in a session
@@ -1660,6 +1665,7 @@ type StatusAlert struct {
 	UsagePercent float64 `json:"usage_percent,omitempty"`
 	ContextModel string  `json:"context_model,omitempty"`
 	Severity     string  `json:"severity,omitempty"`
+	Message      string  `json:"message,omitempty"`
 }
 
 // GraphMetrics provides bv graph analysis metrics for status output
@@ -1840,6 +1846,8 @@ Tool Bridges:
 --robot-slb-deny=ID          Deny SLB request by ID (--reason="...")
 --robot-tokens               Token usage stats (--days=30, --group-by=agent)
 --robot-history=SESSION      Command history (--last=10)
+--robot-responses=ID         Agents' replies to a sent prompt (--responses-wait=5m)
+--robot-merge-queue=SESSION  Rebase, verify and merge agent branches (--merge-queue-status)
 
 Bead Management:
 ----------------
@@ -2007,6 +2015,14 @@ func GetStatusWithOptions(opts PaginationOptions) (*StatusOutput, error) {
 			}
 		}
 
+		// Spend budgets (best-effort; only when configured)
+		if cfg.Budget.Enabled() {
+			if status, err := budget.Observe(cfg, sess.Name); err == nil && status != nil {
+				info.Budget = status
+				output.Alerts = append(output.Alerts, budgetAlerts(status)...)
+			}
+		}
+
 		output.Sessions = append(output.Sessions, info)
 		output.Summary.TotalSessions++
 		if sess.Attached {
@@ -2059,6 +2075,20 @@ func GetStatusWithOptions(opts PaginationOptions) (*StatusOutput, error) {
 	return output, nil
 }
 
+// budgetAlerts returns an alert for each budget scope past a limit.
+func budgetAlerts(status *budget.Status) []StatusAlert {
+	var alerts []StatusAlert
+	for _, sc := range status.Scopes {
+		switch sc.Level {
+		case budget.LevelSoft:
+			alerts = append(alerts, StatusAlert{Type: "budget_warning", Session: status.Session, Severity: "warning", Message: sc.Describe()})
+		case budget.LevelHard:
+			alerts = append(alerts, StatusAlert{Type: "budget_exceeded", Session: status.Session, Severity: "critical", Message: sc.Describe()})
+		}
+	}
+	return alerts
+}
+
 // PrintStatus outputs machine-readable status.
 // This is a thin wrapper around GetStatus() for CLI output.
 func PrintStatus() error {
@@ -3958,6 +3988,9 @@ func agentTypeString(t tmux.AgentType) string {
 	case tmux.AgentUser:
 		return "user"
 	default:
+		if agent.IsRegistered(t) {
+			return string(t) // Plugin-defined agent
+		}
 		return "unknown"
 	}
 }
@@ -3990,6 +4023,10 @@ func modelNameForPane(pane tmux.Pane, cfg *config.Config) string {
 	case tmux.AgentGemini:
 		return "gemini-2.0-flash"
 	default:
+		// Plugin agents with a context limit are tracked under their type
+		if ps, ok := agent.RegisteredPatternSet(pane.Type); ok && ps.ContextLimit > 0 {
+			return string(pane.Type)
+		}
 		return ""
 	}
 }
@@ -4008,6 +4045,8 @@ type SendOutput struct {
 	MessagePreview string             `json:"message_preview"`
 	DryRun         bool               `json:"dry_run,omitempty"`
 	WouldSendTo    []string           `json:"would_send_to,omitempty"`
+	Deliveries     []delivery.Result  `json:"deliveries,omitempty"`
+	Undelivered    []string           `json:"undelivered,omitempty"`
 	CASSInjection  *CASSInjectionInfo `json:"cass_injection,omitempty"`
 	AgentHints     *SendAgentHints    `json:"_agent_hints,omitempty"`
 }
@@ -4104,6 +4143,11 @@ type SendOptions struct {
 	DryRun     bool     // If true, show what would be sent without actually sending
 	Enter      *bool    // If set, override Enter behavior after paste
 
+	// Delivery is "paste" to paste from a tmux buffer and verify the agent
+	// accepted the message, or "keys" (default) to type it with send-keys.
+	Delivery         string
+	DeliveryAttempts int // Enter presses before a paste is reported undelivered (0 = default)
+
 	// CASS injection options
 	WithCASS     bool          // Enable CASS context injection
 	CASSConfig   *CASSConfig   // CASS query configuration (optional)
@@ -4258,6 +4302,21 @@ func GetSend(opts SendOptions) (*SendOutput, error) {
 		}, nil
 	}
 
+	if err := delivery.CheckMethod(opts.Delivery); err != nil {
+		return &SendOutput{
+			RobotResponse:  NewErrorResponse(err, ErrCodeInvalidFlag, "Use --delivery=keys or --delivery=paste"),
+			Session:        opts.Session,
+			SentAt:         time.Now().UTC(),
+			Blocked:        false,
+			Redaction:      initialSummary,
+			Warnings:       initialWarnings,
+			Targets:        []string{},
+			Successful:     []string{},
+			Failed:         []SendError{{Pane: "delivery", Error: err.Error()}},
+			MessagePreview: initialPreview,
+		}, nil
+	}
+
 	if !tmux.SessionExists(opts.Session) {
 		return &SendOutput{
 			RobotResponse:  NewErrorResponse(fmt.Errorf("session '%s' not found", opts.Session), ErrCodeSessionNotFound, "Use 'ntm list' to see available sessions"),
@@ -4463,6 +4522,23 @@ func GetSend(opts SendOptions) (*SendOutput, error) {
 			enterDelay = tmux.ShellEnterDelay
 		}
 
+		// Paste delivery needs Enter to verify the agent accepted the message
+		if opts.Delivery == delivery.MethodPaste && sendEnter && pane.Type != tmux.AgentUser {
+			res := delivery.ToPane(context.Background(), pane.ID, pane.Type, messageToSend, delivery.Options{Attempts: opts.DeliveryAttempts})
+			res.Pane = paneKey
+			output.Deliveries = append(output.Deliveries, res)
+			switch {
+			case res.Outcome == delivery.OutcomeUndelivered:
+				output.Undelivered = append(output.Undelivered, paneKey)
+				output.Failed = append(output.Failed, SendError{Pane: paneKey, Error: "undelivered: " + res.Error})
+			case !res.OK():
+				output.Failed = append(output.Failed, SendError{Pane: paneKey, Error: res.Error})
+			default:
+				output.Successful = append(output.Successful, paneKey)
+			}
+			continue
+		}
+
 		// Use agent-aware send method which handles Gemini's multi-line quirks
 		// by using buffer-based paste instead of send-keys when content has newlines
 		err := tmux.SendKeysForAgentWithDelay(pane.ID, messageToSend, sendEnter, enterDelay, pane.Type)
@@ -4518,6 +4594,9 @@ func generateSendHints(output SendOutput) *SendAgentHints {
 		summary = "No target panes matched the filter criteria"
 		suggestions = append(suggestions, "Check --all, --panes, or --agent-types flags")
 	}
+	if len(output.Undelivered) > 0 {
+		suggestions = append(suggestions, fmt.Sprintf("Pane(s) %s did not accept the message; check them with --robot-tail before resending", strings.Join(output.Undelivered, ", ")))
+	}
 
 	if summary == "" {
 		return nil
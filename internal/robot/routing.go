@@ -106,6 +106,7 @@ type ScoredAgent struct {
 	PaneID    string `json:"pane_id"`
 	AgentType string `json:"agent_type"` // cc, cod, gmi
 	PaneIndex int    `json:"pane_index"`
+	Variant   string `json:"variant,omitempty"` // Model alias or persona from the pane title
 
 	// Current state
 	State      AgentState `json:"state"`
@@ -545,6 +546,7 @@ func (s *AgentScorer) ScoreAgents(session string, prompt string) ([]ScoredAgent,
 			PaneID:       pane.ID,
 			AgentType:    agentType,
 			PaneIndex:    pane.Index,
+			Variant:      pane.Variant,
 			State:        activity.State,
 			Confidence:   activity.Confidence,
 			Velocity:     activity.Velocity,
@@ -642,3 +642,20 @@ func simulateSendDryRun(opts SendOptions, panes []mockPane) SendOutput {
 		WouldSendTo:    targets,
 	}
 }
+
+func TestGenerateSendHints_Undelivered(t *testing.T) {
+	output := SendOutput{
+		Targets:     []string{"1", "2"},
+		Successful:  []string{"1"},
+		Failed:      []SendError{{Pane: "2", Error: "undelivered: no response from agent after 3 attempts"}},
+		Undelivered: []string{"2"},
+	}
+	hints := generateSendHints(output)
+	if hints == nil || !strings.Contains(hints.Summary, "Partial success") {
+		t.Fatalf("hints = %+v", hints)
+	}
+	last := hints.Suggestions[len(hints.Suggestions)-1]
+	if !strings.Contains(last, "Pane(s) 2 did not accept the message") {
+		t.Errorf("missing undelivered suggestion: %v", hints.Suggestions)
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"sync"
 	"time"
 
+	agentpkg "github.com/Dicklesworthstone/ntm/internal/agent"
 	"github.com/Dicklesworthstone/ntm/internal/process"
+	"github.com/Dicklesworthstone/ntm/internal/ratelimit"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 	"github.com/Dicklesworthstone/ntm/internal/tokens"
 )
@@ -77,6 +79,12 @@ func enrichAgentStatus(agent *Agent, sessionName, modelName string) {
 	if err == nil {
 		// Rate limit
 		detected, match := detectRateLimit(content)
+		if !detected {
+			// Patterns declared by agent plugins
+			if m := ratelimit.MatchAgentPattern(content, agent.Type); m != "" {
+				detected, match = true, m
+			}
+		}
 		agent.RateLimitDetected = detected
 		agent.RateLimitMatch = match
 
@@ -92,6 +100,10 @@ func enrichAgentStatus(agent *Agent, sessionName, modelName string) {
 		if modelName != "" {
 			usage := tokens.GetUsageInfo(content, modelName)
 			if usage != nil {
+				if limit := pluginContextLimit(agent.Type); limit > 0 {
+					usage.ContextLimit = limit
+					usage.UsagePercent = float64(usage.EstimatedTokens) * 100.0 / float64(limit)
+				}
 				agent.ContextTokens = usage.EstimatedTokens
 				agent.ContextLimit = usage.ContextLimit
 				agent.ContextPercent = usage.UsagePercent
@@ -101,6 +113,15 @@ func enrichAgentStatus(agent *Agent, sessionName, modelName string) {
 	}
 }
 
+// pluginContextLimit returns the context limit declared by the plugin that
+// defines agentType, or 0 if there is none.
+func pluginContextLimit(agentType string) int {
+	if ps, ok := agentpkg.RegisteredPatternSet(agentpkg.AgentType(agentType)); ok {
+		return ps.ContextLimit
+	}
+	return 0
+}
+
 func getProcessMemoryMB(pid int) (int, error) {
 	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
 	if err != nil {
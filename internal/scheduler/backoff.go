@@ -257,6 +257,9 @@ type BackoffController struct {
 	// scheduler is a reference to pause/resume the queue.
 	scheduler *Scheduler
 
+	// coord publishes global backoff windows to other ntm processes.
+	coord Coordinator
+
 	// hooks for events.
 	onBackoffStart   func(delay time.Duration, reason ResourceErrorType)
 	onBackoffEnd     func(totalDuration time.Duration)
@@ -289,6 +292,14 @@ func (bc *BackoffController) SetScheduler(s *Scheduler) {
 	bc.scheduler = s
 }
 
+// SetCoordinator shares global backoff windows through c. Pass nil to
+// keep backoff local to this process.
+func (bc *BackoffController) SetCoordinator(c Coordinator) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.coord = c
+}
+
 // SetHooks sets event callbacks.
 func (bc *BackoffController) SetHooks(
 	onStart func(time.Duration, ResourceErrorType),
@@ -310,6 +321,15 @@ func (bc *BackoffController) HandleError(job *SpawnJob, resErr *ResourceError) (
 		return false, 0
 	}
 
+	// A global window is shared with other processes after bc.mu is
+	// released, since publishing it writes to the state database.
+	var share func()
+	defer func() {
+		if share != nil {
+			share()
+		}
+	}()
+
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 
@@ -349,8 +369,10 @@ func (bc *BackoffController) HandleError(job *SpawnJob, resErr *ResourceError) (
 	)
 
 	// Check if we should trigger global backoff
-	if bc.consecutiveFailures >= bc.config.ConsecutiveFailuresThreshold {
-		bc.triggerGlobalBackoff(delay, resErr.Type)
+	if bc.consecutiveFailures >= bc.config.ConsecutiveFailuresThreshold &&
+		bc.triggerGlobalBackoff(delay, resErr.Type) && bc.coord != nil {
+		coord, until, reason := bc.coord, bc.globalBackoffUntil, resErr.Type
+		share = func() { publishGlobalBackoff(coord, until, reason) }
 	}
 
 	bc.stats.TotalBackoffs++
@@ -388,16 +410,17 @@ func (bc *BackoffController) calculateDelay() time.Duration {
 	return delayWithJitter
 }
 
-// triggerGlobalBackoff pauses the scheduler queue during backoff.
-func (bc *BackoffController) triggerGlobalBackoff(delay time.Duration, reason ResourceErrorType) {
+// triggerGlobalBackoff pauses the scheduler queue during backoff. It
+// reports whether the global window was started or extended.
+func (bc *BackoffController) triggerGlobalBackoff(delay time.Duration, reason ResourceErrorType) bool {
 	if !bc.config.PauseQueueOnBackoff {
-		return
+		return false
 	}
 
 	if bc.globalBackoffActive.Load() {
 		// Already in global backoff, extend it
 		bc.globalBackoffUntil = time.Now().Add(delay)
-		return
+		return true
 	}
 
 	bc.globalBackoffActive.Store(true)
@@ -426,6 +449,14 @@ func (bc *BackoffController) triggerGlobalBackoff(delay time.Duration, reason Re
 		}
 		bc.endGlobalBackoff()
 	}(bc.globalBackoffUntil)
+	return true
+}
+
+// publishGlobalBackoff shares a global backoff window with other processes.
+func publishGlobalBackoff(coord Coordinator, until time.Time, reason ResourceErrorType) {
+	if err := coord.SetCooldown(CooldownGlobal, until, string(reason)); err != nil {
+		slog.Warn("failed to share global backoff", "error", err)
+	}
 }
 
 // endGlobalBackoff resumes normal operation.
@@ -116,6 +116,10 @@ type AgentCaps struct {
 	// codexThrottle provides AIMD-based rate-limit throttling for cod agents.
 	// When non-nil, TryAcquire/Acquire for "cod" agents check the throttle first.
 	codexThrottle *ratelimit.CodexThrottle
+
+	// coord shares caps and rate-limit cooldowns with other ntm processes.
+	// When non-nil, a slot also needs a machine-wide lease.
+	coord Coordinator
 }
 
 // agentCapState tracks the state of caps for one agent type.
@@ -212,9 +216,50 @@ func (ac *AgentCaps) getCapState(agentType string) *agentCapState {
 // For "cod" agents, the Codex throttle is checked first; if throttled,
 // the acquire is rejected without affecting other agent types.
 func (ac *AgentCaps) TryAcquire(agentType string) bool {
+	ac.mu.Lock()
+	state, ok := ac.reserveLocked(agentType)
+	coord, globalMax := ac.coord, ac.config.GlobalMax
+	var agentCap int
+	if ok {
+		agentCap = state.currentCap
+	}
+	ac.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	// Check machine-wide caps and cooldowns without holding ac.mu, since
+	// the coordinator reads and writes the state database. The local slot
+	// is held meanwhile and given back if the shared one is refused.
+	if coord != nil && !acquireShared(coord, agentType, agentCap, globalMax) {
+		ac.mu.Lock()
+		ac.running[agentType]--
+		ac.stats.TotalRunning--
+		ac.mu.Unlock()
+		return false
+	}
+
 	ac.mu.Lock()
 	defer ac.mu.Unlock()
 
+	// Start ramp-up timer if this is the first instance
+	if state.startedAt.IsZero() {
+		state.startedAt = time.Now()
+		state.lastRampUp = time.Now()
+	}
+
+	slog.Debug("agent cap acquired",
+		"agent_type", agentType,
+		"running", ac.running[agentType],
+		"cap", state.currentCap,
+	)
+
+	return true
+}
+
+// reserveLocked takes a local slot for agentType if the throttle and the
+// per-agent and global caps allow it. Callers hold ac.mu.
+func (ac *AgentCaps) reserveLocked(agentType string) (*agentCapState, bool) {
 	// Codex rate-limit throttle gate (bd-3qoly): only affects cod agents.
 	if agentType == "cod" && ac.codexThrottle != nil {
 		if !ac.codexThrottle.MayLaunch(ac.running["cod"]) {
@@ -222,7 +267,7 @@ func (ac *AgentCaps) TryAcquire(agentType string) bool {
 				"agent_type", agentType,
 				"running", ac.running["cod"],
 			)
-			return false
+			return nil, false
 		}
 	}
 
@@ -231,7 +276,7 @@ func (ac *AgentCaps) TryAcquire(agentType string) bool {
 
 	// Check if at capacity
 	if ac.running[agentType] >= state.currentCap {
-		return false
+		return nil, false
 	}
 
 	// Check global cap
@@ -241,26 +286,13 @@ func (ac *AgentCaps) TryAcquire(agentType string) bool {
 			total += count
 		}
 		if total >= ac.config.GlobalMax {
-			return false
+			return nil, false
 		}
 	}
 
 	ac.running[agentType]++
 	ac.stats.TotalRunning++
-
-	// Start ramp-up timer if this is the first instance
-	if state.startedAt.IsZero() {
-		state.startedAt = time.Now()
-		state.lastRampUp = time.Now()
-	}
-
-	slog.Debug("agent cap acquired",
-		"agent_type", agentType,
-		"running", ac.running[agentType],
-		"cap", state.currentCap,
-	)
-
-	return true
+	return state, true
 }
 
 // Acquire blocks until a slot is available or context is cancelled.
@@ -270,6 +302,14 @@ func (ac *AgentCaps) Acquire(ctx context.Context, agentType string) error {
 		return nil
 	}
 
+	// Slots freed by other processes produce no local notification
+	ac.mu.Lock()
+	coordinated := ac.coord != nil
+	ac.mu.Unlock()
+	if coordinated {
+		return ac.pollAcquire(ctx, agentType)
+	}
+
 	// Need to wait
 	ac.mu.Lock()
 
@@ -314,6 +354,56 @@ func (ac *AgentCaps) Acquire(ctx context.Context, agentType string) error {
 	}
 }
 
+// sharedPollInterval is how often Acquire retries when coordinated.
+const sharedPollInterval = 100 * time.Millisecond
+
+// pollAcquire retries TryAcquire until it succeeds or ctx is cancelled.
+func (ac *AgentCaps) pollAcquire(ctx context.Context, agentType string) error {
+	ac.mu.Lock()
+	ac.stats.TotalWaiting++
+	ac.mu.Unlock()
+	defer func() {
+		ac.mu.Lock()
+		ac.stats.TotalWaiting--
+		ac.mu.Unlock()
+	}()
+
+	ticker := time.NewTicker(sharedPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if ac.TryAcquire(agentType) {
+				return nil
+			}
+		}
+	}
+}
+
+// acquireShared takes a machine-wide lease for agentType. Coordination
+// errors fail open, so a broken state database never blocks spawning.
+func acquireShared(coord Coordinator, agentType string, agentCap, globalCap int) bool {
+	if until, err := coord.Cooldown(agentType); err == nil && time.Now().Before(until) {
+		slog.Debug("agent type in shared cooldown",
+			"agent_type", agentType,
+			"until", until,
+		)
+		return false
+	}
+
+	ok, err := coord.Acquire(agentType, agentCap, globalCap)
+	if err != nil {
+		slog.Warn("spawn coordination unavailable, using local caps",
+			"agent_type", agentType,
+			"error", err,
+		)
+		return true
+	}
+	return ok
+}
+
 // globalCapExceeded checks if global cap is exceeded.
 func (ac *AgentCaps) globalCapExceeded() bool {
 	if ac.config.GlobalMax <= 0 {
@@ -339,12 +429,28 @@ func (ac *AgentCaps) removeWaiter(agentType string, ch chan struct{}) {
 
 // Release releases a slot for an agent type.
 func (ac *AgentCaps) Release(agentType string) {
+	// The shared slot is returned after ac.mu is released, since the
+	// coordinator writes to the state database.
+	var coord Coordinator
+	defer func() {
+		if coord == nil {
+			return
+		}
+		if err := coord.Release(agentType); err != nil {
+			slog.Warn("failed to release shared agent slot",
+				"agent_type", agentType,
+				"error", err,
+			)
+		}
+	}()
+
 	ac.mu.Lock()
 	defer ac.mu.Unlock()
 
 	if ac.running[agentType] > 0 {
 		ac.running[agentType]--
 		ac.stats.TotalRunning--
+		coord = ac.coord
 	}
 
 	slog.Debug("agent cap released",
@@ -656,16 +762,35 @@ func (ac *AgentCaps) SetCodexThrottle(ct *ratelimit.CodexThrottle) {
 	ac.codexThrottle = ct
 }
 
+// SetCoordinator shares caps and rate-limit cooldowns through c. Pass nil
+// to keep caps local to this process.
+func (ac *AgentCaps) SetCoordinator(c Coordinator) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	ac.coord = c
+}
+
 // RecordCodexRateLimit notifies the Codex throttle of a rate-limit event.
-// It also calls RecordFailure for the "cod" agent type to trigger cap cooldown.
-// This is a no-op if no throttle is configured.
+// It also calls RecordFailure for the "cod" agent type to trigger cap cooldown,
+// and publishes the wait to other processes when coordinated.
 func (ac *AgentCaps) RecordCodexRateLimit(paneID string, waitSeconds int) {
 	ac.mu.Lock()
 	if ac.codexThrottle != nil {
 		ac.codexThrottle.RecordRateLimit(paneID, waitSeconds)
 	}
+	coord := ac.coord
 	ac.mu.Unlock()
 
+	if coord != nil {
+		wait := ratelimit.DefaultCooldownWindow
+		if waitSeconds > 0 {
+			wait = time.Duration(waitSeconds) * time.Second
+		}
+		if err := coord.SetCooldown("cod", time.Now().Add(wait), "rate_limit"); err != nil {
+			slog.Warn("failed to share codex cooldown", "error", err)
+		}
+	}
+
 	// Also trigger the existing cap cooldown mechanism
 	ac.RecordFailure("cod")
 }
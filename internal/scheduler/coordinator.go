@@ -0,0 +1,252 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+// CooldownGlobal is the cooldown scope that pauses all spawning.
+const CooldownGlobal = "global"
+
+// Coordinator shares spawn limits with other ntm processes on the machine,
+// so that two terminals running `ntm spawn`, or `ntm serve` alongside a CLI
+// run, do not each assume they own the full agent caps.
+type Coordinator interface {
+	// Acquire takes a machine-wide slot for agentType. agentCap and
+	// globalCap bound the slots held by all processes; zero is unlimited.
+	Acquire(agentType string, agentCap, globalCap int) (bool, error)
+
+	// Release returns a slot taken by Acquire.
+	Release(agentType string) error
+
+	// SetCooldown publishes a backoff window for scope, which is
+	// CooldownGlobal or an agent type. Longer existing windows are kept.
+	SetCooldown(scope string, until time.Time, reason string) error
+
+	// Cooldown returns when the window for scope ends, or the zero time.
+	Cooldown(scope string) (time.Time, error)
+
+	// Close releases every slot held by this process.
+	Close() error
+}
+
+// CoordinationConfig configures machine-wide spawn coordination.
+type CoordinationConfig struct {
+	// Enabled turns on coordination through the shared state database.
+	Enabled bool `json:"enabled"`
+
+	// StatePath is the state database path (default ~/.config/ntm/state.db).
+	StatePath string `json:"state_path,omitempty"`
+
+	// LeaseTTL is how long a slot survives without a heartbeat, which
+	// bounds how long a crashed process can hold capacity.
+	LeaseTTL time.Duration `json:"lease_ttl"`
+}
+
+// DefaultCoordinationConfig returns sensible defaults. Coordination is off
+// for standalone schedulers; the process-wide Global scheduler enables it.
+func DefaultCoordinationConfig() CoordinationConfig {
+	return CoordinationConfig{
+		LeaseTTL: 30 * time.Second,
+	}
+}
+
+// cooldownCacheTTL limits how often workers hit the database for cooldowns.
+const cooldownCacheTTL = 250 * time.Millisecond
+
+// StoreCoordinator implements Coordinator with a lease table in state.Store.
+// Each running spawn holds a lease that a heartbeat keeps alive; leases
+// from processes that exit without releasing them expire after LeaseTTL.
+type StoreCoordinator struct {
+	store     *state.Store
+	ownsStore bool
+	holder    string
+	ttl       time.Duration
+
+	mu        sync.Mutex
+	held      map[string][]string // agent type -> lease IDs
+	cooldowns map[string]cachedCooldown
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+type cachedCooldown struct {
+	until   time.Time
+	fetched time.Time
+}
+
+// OpenStoreCoordinator opens the state database at path and coordinates
+// through it. An empty path uses the default state database.
+func OpenStoreCoordinator(path string, cfg CoordinationConfig) (*StoreCoordinator, error) {
+	store, err := state.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// Another process may be applying the same migration; retry once.
+	if err := store.Migrate(); err != nil {
+		if err := store.Migrate(); err != nil {
+			_ = store.Close()
+			return nil, fmt.Errorf("migrate state store: %w", err)
+		}
+	}
+	c := NewStoreCoordinator(store, cfg)
+	c.ownsStore = true
+	return c, nil
+}
+
+// NewStoreCoordinator coordinates through an already-migrated store.
+func NewStoreCoordinator(store *state.Store, cfg CoordinationConfig) *StoreCoordinator {
+	ttl := cfg.LeaseTTL
+	if ttl <= 0 {
+		ttl = DefaultCoordinationConfig().LeaseTTL
+	}
+	host, _ := os.Hostname()
+	c := &StoreCoordinator{
+		store:     store,
+		holder:    fmt.Sprintf("%s:%d:%s", host, os.Getpid(), generateID()[:8]),
+		ttl:       ttl,
+		held:      make(map[string][]string),
+		cooldowns: make(map[string]cachedCooldown),
+		stop:      make(chan struct{}),
+	}
+	c.wg.Add(1)
+	go c.heartbeat()
+	return c
+}
+
+// Holder returns the identifier this process uses for its leases.
+func (c *StoreCoordinator) Holder() string {
+	return c.holder
+}
+
+// Acquire implements Coordinator.
+func (c *StoreCoordinator) Acquire(agentType string, agentCap, globalCap int) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lease := &state.SchedulerLease{
+		ID:        c.holder + ":" + generateID()[:12],
+		Holder:    c.holder,
+		AgentType: agentType,
+		ExpiresAt: time.Now().Add(c.ttl),
+	}
+	ok, err := c.store.AcquireSchedulerLease(lease, agentCap, globalCap)
+	if err != nil || !ok {
+		return false, err
+	}
+	c.held[agentType] = append(c.held[agentType], lease.ID)
+	return true, nil
+}
+
+// Release implements Coordinator.
+func (c *StoreCoordinator) Release(agentType string) error {
+	c.mu.Lock()
+	ids := c.held[agentType]
+	if len(ids) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	id := ids[len(ids)-1]
+	c.held[agentType] = ids[:len(ids)-1]
+	c.mu.Unlock()
+
+	return c.store.ReleaseSchedulerLease(id)
+}
+
+// HeldCount returns the number of slots this process holds.
+func (c *StoreCoordinator) HeldCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, ids := range c.held {
+		n += len(ids)
+	}
+	return n
+}
+
+// SetCooldown implements Coordinator.
+func (c *StoreCoordinator) SetCooldown(scope string, until time.Time, reason string) error {
+	c.mu.Lock()
+	if cached, ok := c.cooldowns[scope]; ok && until.After(cached.until) {
+		c.cooldowns[scope] = cachedCooldown{until: until, fetched: cached.fetched}
+	}
+	c.mu.Unlock()
+
+	return c.store.ExtendSchedulerCooldown(&state.SchedulerCooldown{
+		Scope:  scope,
+		Until:  until,
+		Reason: reason,
+		Holder: c.holder,
+	})
+}
+
+// Cooldown implements Coordinator.
+func (c *StoreCoordinator) Cooldown(scope string) (time.Time, error) {
+	c.mu.Lock()
+	cached, ok := c.cooldowns[scope]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetched) < cooldownCacheTTL {
+		return cached.until, nil
+	}
+
+	cd, err := c.store.GetSchedulerCooldown(scope)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var until time.Time
+	if cd != nil {
+		until = cd.Until
+	}
+
+	c.mu.Lock()
+	c.cooldowns[scope] = cachedCooldown{until: until, fetched: time.Now()}
+	c.mu.Unlock()
+	return until, nil
+}
+
+// Close implements Coordinator.
+func (c *StoreCoordinator) Close() error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	c.wg.Wait()
+
+	c.mu.Lock()
+	c.held = make(map[string][]string)
+	c.mu.Unlock()
+
+	err := c.store.ReleaseSchedulerLeasesByHolder(c.holder)
+	if c.ownsStore {
+		if cerr := c.store.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// heartbeat renews this process's leases and clears expired ones.
+func (c *StoreCoordinator) heartbeat() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			if _, err := c.store.RenewSchedulerLeases(c.holder, now.Add(c.ttl)); err != nil {
+				slog.Warn("scheduler lease renewal failed", "holder", c.holder, "error", err)
+			}
+			if _, err := c.store.PruneSchedulerLeases(now); err != nil {
+				slog.Debug("scheduler lease prune failed", "error", err)
+			}
+		}
+	}
+}
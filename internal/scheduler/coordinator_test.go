@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openCoordinators returns two coordinators on one state database, standing
+// in for two ntm processes.
+func openCoordinators(t *testing.T) (*StoreCoordinator, *StoreCoordinator) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "state.db")
+	cfg := DefaultCoordinationConfig()
+
+	a, err := OpenStoreCoordinator(path, cfg)
+	if err != nil {
+		t.Fatalf("OpenStoreCoordinator() error: %v", err)
+	}
+	b, err := OpenStoreCoordinator(path, cfg)
+	if err != nil {
+		a.Close()
+		t.Fatalf("OpenStoreCoordinator() error: %v", err)
+	}
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+	return a, b
+}
+
+func coordinatedCaps(c Coordinator) *AgentCaps {
+	capCfg := DefaultAgentCapConfig()
+	capCfg.MaxConcurrent = 2
+	ac := NewAgentCaps(AgentCapsConfig{
+		Default:  capCfg,
+		PerAgent: map[string]AgentCapConfig{"cod": capCfg},
+	})
+	ac.SetCoordinator(c)
+	return ac
+}
+
+func TestStoreCoordinator_SharesCaps(t *testing.T) {
+	coordA, coordB := openCoordinators(t)
+	capsA, capsB := coordinatedCaps(coordA), coordinatedCaps(coordB)
+
+	if !capsA.TryAcquire("cc") || !capsA.TryAcquire("cc") {
+		t.Fatal("first process could not fill its cap")
+	}
+	if capsB.TryAcquire("cc") {
+		t.Fatal("second process exceeded the machine-wide cap")
+	}
+	if !capsB.TryAcquire("gmi") {
+		t.Error("cap on one agent type blocked another")
+	}
+
+	capsA.Release("cc")
+	if !capsB.TryAcquire("cc") {
+		t.Error("released slot not available to the other process")
+	}
+	if coordA.HeldCount() != 1 || coordB.HeldCount() != 2 {
+		t.Errorf("held leases = %d, %d", coordA.HeldCount(), coordB.HeldCount())
+	}
+
+	// Closing a coordinator frees everything its process held.
+	if err := coordA.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if !capsB.TryAcquire("cc") {
+		t.Error("slot held by a closed coordinator was not freed")
+	}
+}
+
+func TestStoreCoordinator_SharesCooldowns(t *testing.T) {
+	coordA, coordB := openCoordinators(t)
+	capsA, capsB := coordinatedCaps(coordA), coordinatedCaps(coordB)
+
+	// A Codex rate limit in one process pauses cod launches in the other.
+	capsA.RecordCodexRateLimit("%1", 60)
+	if capsB.TryAcquire("cod") {
+		t.Error("cod launched during a rate-limit cooldown from another process")
+	}
+	if !capsB.TryAcquire("cc") {
+		t.Error("cod cooldown blocked another agent type")
+	}
+
+	// Global backoff in one process blocks dispatch in the other.
+	cfg := DefaultBackoffConfig()
+	cfg.ConsecutiveFailuresThreshold = 1
+	bc := NewBackoffController(cfg)
+	bc.SetCoordinator(coordA)
+	job := NewSpawnJob("j1", JobTypeAgentLaunch, "proj")
+	bc.HandleError(job, &ResourceError{Original: errors.New("429"), Type: ResourceErrorRateLimit, Retryable: true})
+
+	s := New(DefaultConfig())
+	s.SetCoordinator(coordB)
+	st := s.Stats()
+	if !st.Coordinated || st.SharedBackoff <= 0 {
+		t.Errorf("stats coordinated=%v shared_backoff=%v, want an active shared backoff", st.Coordinated, st.SharedBackoff)
+	}
+}
+
+func TestStoreCoordinator_AcquireWaitsForOtherProcess(t *testing.T) {
+	coordA, coordB := openCoordinators(t)
+	capsA, capsB := coordinatedCaps(coordA), coordinatedCaps(coordB)
+
+	capsA.TryAcquire("cc")
+	capsA.TryAcquire("cc")
+	time.AfterFunc(150*time.Millisecond, func() { capsA.Release("cc") })
+
+	ctx := t.Context()
+	if err := capsB.Acquire(ctx, "cc"); err != nil {
+		t.Fatalf("Acquire() error: %v", err)
+	}
+	if capsB.GetRunning("cc") != 1 {
+		t.Errorf("running = %d, want 1", capsB.GetRunning("cc"))
+	}
+}
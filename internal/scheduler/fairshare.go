@@ -0,0 +1,190 @@
+package scheduler
+
+import (
+	"strings"
+	"time"
+)
+
+// Weighted fair queuing across tenants.
+//
+// Queued jobs are served strictly by priority. Within a priority level the
+// scheduler shares dispatch slots between projects, and then between the
+// sessions of each project, using start-time fair queuing: every tenant
+// carries a virtual finish tag, the tenant with the smallest start tag is
+// served next, and serving one of its jobs advances its tag by 1/weight. A
+// tenant that goes idle restarts at the current virtual clock, so it cannot
+// bank credit while it has nothing queued.
+
+// defaultTenantWeight is used for projects and sessions without a configured weight.
+const defaultTenantWeight = 1.0
+
+// jobProject returns the project a job is accounted to.
+func jobProject(job *SpawnJob) string {
+	if job.Project != "" {
+		return job.Project
+	}
+	return job.SessionName
+}
+
+// sessionTagKey identifies a session within its project.
+func sessionTagKey(project, session string) string {
+	return project + "\x00" + session
+}
+
+// fairShare holds virtual-time state for two-level weighted fair queuing.
+type fairShare struct {
+	projectWeights map[string]float64
+	sessionWeights map[string]float64
+
+	// clock is the project-level virtual time.
+	clock float64
+
+	// projectTags are finish tags per project.
+	projectTags map[string]float64
+
+	// sessionClocks are the session-level virtual times per project.
+	sessionClocks map[string]float64
+
+	// sessionTags are finish tags per (project, session).
+	sessionTags map[string]float64
+}
+
+func newFairShare(cfg FairSchedulerConfig) *fairShare {
+	return &fairShare{
+		projectWeights: cfg.ProjectWeights,
+		sessionWeights: cfg.SessionWeights,
+		projectTags:    make(map[string]float64),
+		sessionClocks:  make(map[string]float64),
+		sessionTags:    make(map[string]float64),
+	}
+}
+
+// clone returns an independent copy for simulating dispatch order.
+func (fs *fairShare) clone() *fairShare {
+	c := &fairShare{
+		projectWeights: fs.projectWeights,
+		sessionWeights: fs.sessionWeights,
+		clock:          fs.clock,
+		projectTags:    make(map[string]float64, len(fs.projectTags)),
+		sessionClocks:  make(map[string]float64, len(fs.sessionClocks)),
+		sessionTags:    make(map[string]float64, len(fs.sessionTags)),
+	}
+	for k, v := range fs.projectTags {
+		c.projectTags[k] = v
+	}
+	for k, v := range fs.sessionClocks {
+		c.sessionClocks[k] = v
+	}
+	for k, v := range fs.sessionTags {
+		c.sessionTags[k] = v
+	}
+	return c
+}
+
+func weightOf(weights map[string]float64, key string) float64 {
+	if w, ok := weights[key]; ok && w > 0 {
+		return w
+	}
+	return defaultTenantWeight
+}
+
+// projectWeight returns the fair-share weight of a project.
+func (fs *fairShare) projectWeight(project string) float64 {
+	return weightOf(fs.projectWeights, project)
+}
+
+// sessionWeight returns the fair-share weight of a session.
+func (fs *fairShare) sessionWeight(session string) float64 {
+	return weightOf(fs.sessionWeights, session)
+}
+
+// startTags returns the virtual start tags a job would be served at.
+func (fs *fairShare) startTags(job *SpawnJob) (project, session float64) {
+	p := jobProject(job)
+	project = max(fs.projectTags[p], fs.clock)
+	session = max(fs.sessionTags[sessionTagKey(p, job.SessionName)], fs.sessionClocks[p])
+	return project, session
+}
+
+// charge accounts one dispatched job to its tenants.
+func (fs *fairShare) charge(job *SpawnJob) {
+	p := jobProject(job)
+	pStart, sStart := fs.startTags(job)
+
+	fs.clock = pStart
+	fs.projectTags[p] = pStart + 1/fs.projectWeight(p)
+	fs.sessionClocks[p] = sStart
+	fs.sessionTags[sessionTagKey(p, job.SessionName)] = sStart + 1/fs.sessionWeight(job.SessionName)
+}
+
+// prune drops state for tenants with nothing queued whose tags are at or
+// behind their clock; such tenants would restart at the clock anyway.
+func (fs *fairShare) prune(queued []*SpawnJob) {
+	projects := make(map[string]bool)
+	sessions := make(map[string]bool)
+	for _, job := range queued {
+		p := jobProject(job)
+		projects[p] = true
+		sessions[sessionTagKey(p, job.SessionName)] = true
+	}
+	for p, tag := range fs.projectTags {
+		if !projects[p] && tag <= fs.clock {
+			delete(fs.projectTags, p)
+		}
+	}
+	for k, tag := range fs.sessionTags {
+		p := keyProject(k)
+		if sessions[k] {
+			continue
+		}
+		if _, active := fs.projectTags[p]; !active || tag <= fs.sessionClocks[p] {
+			delete(fs.sessionTags, k)
+		}
+	}
+	for p := range fs.sessionClocks {
+		if _, active := fs.projectTags[p]; !active && !projects[p] {
+			delete(fs.sessionClocks, p)
+		}
+	}
+}
+
+// keyProject extracts the project from a session tag key.
+func keyProject(key string) string {
+	project, _, _ := strings.Cut(key, "\x00")
+	return project
+}
+
+// pick returns the index of the job to serve next, or -1 if none is
+// eligible. A nil eligible function accepts every job.
+func (fs *fairShare) pick(jobs []*SpawnJob, eligible func(*SpawnJob) bool) int {
+	best := -1
+	var bestP, bestS float64
+	var bestCreated time.Time
+	var bestPriority JobPriority
+	for i, job := range jobs {
+		if eligible != nil && !eligible(job) {
+			continue
+		}
+		p, s := fs.startTags(job)
+		if best >= 0 {
+			switch {
+			case job.Priority != bestPriority:
+				if job.Priority > bestPriority {
+					continue
+				}
+			case p != bestP:
+				if p > bestP {
+					continue
+				}
+			case s != bestS:
+				if s > bestS {
+					continue
+				}
+			case !job.CreatedAt.Before(bestCreated):
+				continue
+			}
+		}
+		best, bestP, bestS, bestCreated, bestPriority = i, p, s, job.CreatedAt, job.Priority
+	}
+	return best
+}
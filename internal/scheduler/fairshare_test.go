@@ -0,0 +1,291 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+)
+
+var fairShareEpoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// enqueueJobs queues n jobs for a session with strictly increasing CreatedAt.
+func enqueueJobs(f *FairScheduler, seq *int, project, session string, n int) {
+	for i := 0; i < n; i++ {
+		job := NewSpawnJob(fmt.Sprintf("%s-%d", session, i), JobTypeAgentLaunch, session)
+		job.Project = project
+		job.CreatedAt = fairShareEpoch.Add(time.Duration(*seq) * time.Millisecond)
+		*seq++
+		f.Enqueue(job)
+	}
+}
+
+// sessionsOf returns the session of each job, joined for easy comparison.
+func sessionsOf(jobs []*SpawnJob) string {
+	names := make([]string, len(jobs))
+	for i, j := range jobs {
+		names[i] = j.SessionName
+	}
+	return strings.Join(names, ",")
+}
+
+func TestFairScheduler_InterleavesSessions(t *testing.T) {
+	t.Parallel()
+	f := NewFairScheduler(FairSchedulerConfig{})
+	var seq int
+	enqueueJobs(f, &seq, "", "big", 5)
+	enqueueJobs(f, &seq, "", "small", 2)
+
+	// A later, smaller spawn is not stuck behind the whole first batch.
+	if got := sessionsOf(f.DispatchOrder()); got != "big,small,big,small,big,big,big" {
+		t.Errorf("dispatch order = %s", got)
+	}
+}
+
+func TestFairScheduler_Weights(t *testing.T) {
+	t.Parallel()
+	f := NewFairScheduler(FairSchedulerConfig{ProjectWeights: map[string]float64{"heavy": 2}})
+	var seq int
+	enqueueJobs(f, &seq, "heavy", "h", 6)
+	enqueueJobs(f, &seq, "light", "l", 6)
+
+	order := f.DispatchOrder()[:6]
+	if got := sessionsOf(order); strings.Count(got, "h") != 4 {
+		t.Errorf("first six dispatches = %s, want 4 from the weight-2 project", got)
+	}
+	if pw, sw := f.Weights("heavy", "h"); pw != 2 || sw != 1 {
+		t.Errorf("Weights() = %v, %v", pw, sw)
+	}
+}
+
+func TestFairScheduler_ProjectsThenSessions(t *testing.T) {
+	t.Parallel()
+	f := NewFairScheduler(FairSchedulerConfig{})
+	var seq int
+	enqueueJobs(f, &seq, "p", "p1", 3)
+	enqueueJobs(f, &seq, "p", "p2", 3)
+	enqueueJobs(f, &seq, "q", "q1", 3)
+
+	// Project q gets half the slots even though p has two sessions, and
+	// p's sessions alternate within p's half.
+	if got := sessionsOf(f.DispatchOrder()[:6]); got != "p1,q1,p2,q1,p1,q1" {
+		t.Errorf("dispatch order = %s", got)
+	}
+}
+
+func TestFairScheduler_PriorityBeforeFairness(t *testing.T) {
+	t.Parallel()
+	f := NewFairScheduler(FairSchedulerConfig{})
+	var seq int
+	enqueueJobs(f, &seq, "", "a", 2)
+	urgent := NewSpawnJob("respawn", JobTypeAgentLaunch, "a")
+	urgent.Priority = PriorityUrgent
+	urgent.CreatedAt = fairShareEpoch.Add(time.Hour)
+	f.Enqueue(urgent)
+	enqueueJobs(f, &seq, "", "b", 1)
+
+	order := f.DispatchOrder()
+	if order[0].ID != "respawn" {
+		t.Errorf("first dispatch = %s, want the urgent job", order[0].ID)
+	}
+}
+
+func TestFairScheduler_IdleTenantCannotBankCredit(t *testing.T) {
+	t.Parallel()
+	f := NewFairScheduler(FairSchedulerConfig{})
+	var seq int
+	enqueueJobs(f, &seq, "", "a", 4)
+	for i := 0; i < 4; i++ {
+		f.MarkComplete(f.TryDequeue())
+	}
+
+	enqueueJobs(f, &seq, "", "b", 3)
+	enqueueJobs(f, &seq, "", "c", 3)
+	var got []*SpawnJob
+	for i := 0; i < 4; i++ {
+		job := f.TryDequeue()
+		got = append(got, job)
+		f.MarkComplete(job)
+	}
+	if s := sessionsOf(got); s != "b,c,b,c" {
+		t.Errorf("dispatch after idle period = %s", s)
+	}
+}
+
+func TestFairScheduler_ClaimRejectionKeepsPlace(t *testing.T) {
+	t.Parallel()
+	f := NewFairScheduler(FairSchedulerConfig{})
+	var seq int
+	enqueueJobs(f, &seq, "", "capped", 1)
+	enqueueJobs(f, &seq, "", "free", 1)
+
+	job := f.TryDequeueFunc(func(j *SpawnJob) bool { return j.SessionName != "capped" }, nil)
+	if job == nil || job.SessionName != "free" {
+		t.Fatalf("TryDequeueFunc() = %v, want the uncapped job", job)
+	}
+	if f.Queue().Get("capped-0") == nil {
+		t.Fatal("rejected job left the queue")
+	}
+	if f.RunningCount("capped") != 0 {
+		t.Error("rejected job counted as running")
+	}
+}
+
+func TestFairScheduler_CancelDuringClaim(t *testing.T) {
+	t.Parallel()
+	f := NewFairScheduler(FairSchedulerConfig{})
+	var seq int
+	enqueueJobs(f, &seq, "", "a", 1)
+
+	var released []string
+	job := f.TryDequeueFunc(func(j *SpawnJob) bool {
+		// Cancel must still find the job while it is being claimed
+		if f.Queue().Remove(j.ID) == nil {
+			t.Error("job being claimed was not in the queue")
+		}
+		return true
+	}, func(j *SpawnJob) { released = append(released, j.ID) })
+
+	if job != nil {
+		t.Fatalf("TryDequeueFunc() = %v, want nil for a cancelled job", job)
+	}
+	if len(released) != 1 || released[0] != "a-0" {
+		t.Errorf("released = %v, want [a-0]", released)
+	}
+	if f.Queue().Len() != 0 || f.RunningCount("a") != 0 {
+		t.Errorf("queue len %d, running %d; cancelled job should be gone", f.Queue().Len(), f.RunningCount("a"))
+	}
+}
+
+func TestFairScheduler_RequeueKeepsStats(t *testing.T) {
+	t.Parallel()
+	f := NewFairScheduler(FairSchedulerConfig{})
+	var seq int
+	enqueueJobs(f, &seq, "", "a", 2)
+
+	f.TryDequeueFunc(func(j *SpawnJob) bool { return false }, nil)
+	f.Requeue(f.TryDequeue())
+
+	stats := f.Queue().Stats()
+	if stats.TotalEnqueued != 2 || stats.CurrentSize != 2 {
+		t.Errorf("TotalEnqueued = %d, CurrentSize = %d, want 2 and 2", stats.TotalEnqueued, stats.CurrentSize)
+	}
+	if n := stats.ByPriority[PriorityNormal]; n != 2 {
+		t.Errorf("ByPriority[normal] = %d, want 2", n)
+	}
+}
+
+func newPausedScheduler(t *testing.T, cfg Config) *Scheduler {
+	t.Helper()
+	cfg.Headroom.Enabled = false
+	s := New(cfg)
+	s.SetExecutor(func(ctx context.Context, job *SpawnJob) error { return nil })
+	s.Pause()
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func TestScheduler_PreemptsQueuedJobs(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxQueueSize = 2
+	s := newPausedScheduler(t, cfg)
+
+	var preempted, by string
+	s.SetHooks(Hooks{OnJobPreempted: func(job, byJob *SpawnJob) {
+		preempted, by = job.ID, byJob.ID
+	}})
+
+	submit := func(id string, p JobPriority) error {
+		job := NewSpawnJob(id, JobTypeAgentLaunch, "proj")
+		job.Priority = p
+		return s.Submit(job)
+	}
+	if err := submit("low", PriorityLow); err != nil {
+		t.Fatal(err)
+	}
+	if err := submit("normal", PriorityNormal); err != nil {
+		t.Fatal(err)
+	}
+	if err := submit("high", PriorityHigh); err != nil {
+		t.Fatalf("Submit(high) error: %v", err)
+	}
+	if preempted != "low" || by != "high" {
+		t.Errorf("preempted %q by %q, want low by high", preempted, by)
+	}
+	if job := s.GetJob("low"); job == nil || job.Status != StatusPreempted || !strings.Contains(job.Error, "high") {
+		t.Errorf("preempted job = %+v", job)
+	}
+
+	// An equal or lower priority job cannot displace anything.
+	if err := submit("another", PriorityNormal); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Submit() on full queue = %v, want ErrQueueFull", err)
+	}
+	if st := s.Stats(); st.TotalPreempted != 1 || st.CurrentQueueSize != 2 {
+		t.Errorf("stats preempted=%d queued=%d", st.TotalPreempted, st.CurrentQueueSize)
+	}
+}
+
+func TestScheduler_ProjectFromDirectory(t *testing.T) {
+	pacing := config.DefaultSpawnPacingConfig()
+	pacing.MaxQueueSize = 10
+	pacing.ProjectWeights = map[string]float64{"/src/shop/": 2}
+	cfg := ConfigFromPacing(pacing)
+	if cfg.MaxQueueSize != 10 || cfg.FairScheduler.ProjectWeights["/src/shop"] != 2 {
+		t.Fatalf("ConfigFromPacing() = queue %d, weights %v", cfg.MaxQueueSize, cfg.FairScheduler.ProjectWeights)
+	}
+
+	s := newPausedScheduler(t, cfg)
+	job := NewSpawnJob("a0", JobTypeAgentLaunch, "alpha")
+	job.Directory = "/src/shop/"
+	if err := s.Submit(job); err != nil {
+		t.Fatal(err)
+	}
+	if job.Project != "/src/shop" {
+		t.Errorf("Project = %q, want the spawn directory", job.Project)
+	}
+	if pw, _ := s.queue.Weights(job.Project, job.SessionName); pw != 2 {
+		t.Errorf("project weight = %v, want 2", pw)
+	}
+}
+
+func TestScheduler_ProgressReportsTenants(t *testing.T) {
+	s := newPausedScheduler(t, DefaultConfig())
+	for i := 0; i < 3; i++ {
+		job := NewSpawnJob(fmt.Sprintf("a%d", i), JobTypeAgentLaunch, "alpha")
+		job.Project = "shop"
+		if err := s.Submit(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Submit(NewSpawnJob("b0", JobTypeAgentLaunch, "beta")); err != nil {
+		t.Fatal(err)
+	}
+
+	p := s.GetProgress()
+	if len(p.Tenants) != 2 {
+		t.Fatalf("tenants = %+v", p.Tenants)
+	}
+	alpha, beta := p.Tenants[0], p.Tenants[1]
+	if alpha.SessionName != "alpha" || alpha.Project != "shop" || alpha.QueueDepth != 3 || alpha.NextPosition != 1 {
+		t.Errorf("alpha = %+v", alpha)
+	}
+	if beta.Project != "beta" || beta.QueueDepth != 1 || beta.NextPosition != 2 {
+		t.Errorf("beta = %+v", beta)
+	}
+	if alpha.DrainETASeconds < beta.NextETASeconds {
+		t.Errorf("alpha drains at %.2fs before beta starts at %.2fs", alpha.DrainETASeconds, beta.NextETASeconds)
+	}
+	if p.Queued[1].ID != "b0" || p.Queued[1].Position != 2 {
+		t.Errorf("queued[1] = %+v", p.Queued[1])
+	}
+	if eta, err := s.EstimateETA("a2"); err != nil || eta <= 0 {
+		t.Errorf("EstimateETA(a2) = %v, %v", eta, err)
+	}
+}
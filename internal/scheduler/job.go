@@ -39,6 +39,7 @@ const (
 	StatusFailed    JobStatus = "failed"    // Failed with error
 	StatusCancelled JobStatus = "cancelled" // Cancelled by user/system
 	StatusRetrying  JobStatus = "retrying"  // Failed but will retry
+	StatusPreempted JobStatus = "preempted" // Evicted from a full queue by a higher-priority job
 )
 
 // SpawnJob represents a single spawn operation in the queue.
@@ -55,6 +56,11 @@ type SpawnJob struct {
 	// SessionName is the target session.
 	SessionName string `json:"session_name"`
 
+	// Project groups sessions for fair-share scheduling. Submit sets it to
+	// Directory when empty; jobs with neither are treated as a project of
+	// their own session.
+	Project string `json:"project,omitempty"`
+
 	// AgentType is the type of agent (cc, cod, gmi) if applicable.
 	AgentType string `json:"agent_type,omitempty"`
 
@@ -184,7 +190,19 @@ func (j *SpawnJob) IsCancelled() bool {
 func (j *SpawnJob) IsTerminal() bool {
 	j.mu.RLock()
 	defer j.mu.RUnlock()
-	return j.Status == StatusCompleted || j.Status == StatusFailed || j.Status == StatusCancelled
+	return j.Status == StatusCompleted || j.Status == StatusFailed ||
+		j.Status == StatusCancelled || j.Status == StatusPreempted
+}
+
+// Preempt marks a queued job as evicted and cancels its context.
+func (j *SpawnJob) Preempt() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.cancel != nil {
+		j.cancel()
+	}
+	j.Status = StatusPreempted
+	j.CompletedAt = time.Now()
 }
 
 // SetStatus updates the job status with proper timestamps.
@@ -198,7 +216,7 @@ func (j *SpawnJob) SetStatus(status JobStatus) {
 		j.ScheduledAt = now
 	case StatusRunning:
 		j.StartedAt = now
-	case StatusCompleted, StatusFailed, StatusCancelled:
+	case StatusCompleted, StatusFailed, StatusCancelled, StatusPreempted:
 		j.CompletedAt = now
 	}
 }
@@ -279,6 +297,7 @@ func (j *SpawnJob) Clone() *SpawnJob {
 		Type:        j.Type,
 		Priority:    j.Priority,
 		SessionName: j.SessionName,
+		Project:     j.Project,
 		AgentType:   j.AgentType,
 		PaneIndex:   j.PaneIndex,
 		Directory:   j.Directory,
@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"encoding/json"
+	"sort"
 	"time"
 )
 
@@ -42,6 +43,13 @@ type Progress struct {
 
 	// BySession contains progress grouped by session.
 	BySession map[string]*SessionProgress `json:"by_session,omitempty"`
+
+	// Tenants reports each session's share of the queue, ordered by when
+	// its next job is expected to dispatch.
+	Tenants []TenantProgress `json:"tenants,omitempty"`
+
+	// PreemptedCount is the number of queued jobs evicted by higher-priority jobs.
+	PreemptedCount int `json:"preempted_count,omitempty"`
 }
 
 // RateLimitInfo contains rate limiter state for display.
@@ -76,6 +84,9 @@ type JobProgress struct {
 	// SessionName is the target session.
 	SessionName string `json:"session_name"`
 
+	// Project is the fair-share project, if set.
+	Project string `json:"project,omitempty"`
+
 	// AgentType is the agent type if applicable.
 	AgentType string `json:"agent_type,omitempty"`
 
@@ -99,6 +110,40 @@ type JobProgress struct {
 
 	// EstimatedETASeconds is estimated time until this job starts.
 	EstimatedETASeconds float64 `json:"estimated_eta_seconds,omitempty"`
+
+	// Position is the 1-based place of a queued job in dispatch order.
+	Position int `json:"position,omitempty"`
+}
+
+// TenantProgress reports one session's position in the fair-share queue.
+type TenantProgress struct {
+	// Project is the project the session is accounted to.
+	Project string `json:"project"`
+
+	// SessionName is the session name.
+	SessionName string `json:"session_name"`
+
+	// ProjectWeight is the project's fair-share weight.
+	ProjectWeight float64 `json:"project_weight"`
+
+	// SessionWeight is the session's fair-share weight within its project.
+	SessionWeight float64 `json:"session_weight"`
+
+	// QueueDepth is the number of queued jobs for this session.
+	QueueDepth int `json:"queue_depth"`
+
+	// RunningCount is the number of running jobs for this session.
+	RunningCount int `json:"running_count"`
+
+	// NextPosition is the 1-based dispatch position of the session's next
+	// queued job, or 0 when nothing is queued.
+	NextPosition int `json:"next_position,omitempty"`
+
+	// NextETASeconds is the estimated time until the next job starts.
+	NextETASeconds float64 `json:"next_eta_seconds,omitempty"`
+
+	// DrainETASeconds is the estimated time until the last queued job starts.
+	DrainETASeconds float64 `json:"drain_eta_seconds,omitempty"`
 }
 
 // SessionProgress groups progress by session.
@@ -146,6 +191,7 @@ func (s *Scheduler) GetProgress() *Progress {
 		RunningCount:   stats.CurrentRunning,
 		CompletedCount: int(stats.TotalCompleted),
 		FailedCount:    int(stats.TotalFailed),
+		PreemptedCount: int(stats.TotalPreempted),
 		RateLimitInfo: RateLimitInfo{
 			AvailableTokens: stats.LimiterStats.CurrentTokens,
 			Rate:            s.globalLimiter.rate,
@@ -166,27 +212,52 @@ func (s *Scheduler) GetProgress() *Progress {
 		progress.EstimatedETASeconds = etaSeconds
 	}
 
-	// Collect queued jobs
-	for _, job := range s.GetQueuedJobs() {
-		jp := JobProgress{
-			ID:          job.ID,
-			Type:        job.Type,
-			Status:      job.Status,
-			SessionName: job.SessionName,
-			AgentType:   job.AgentType,
-			PaneIndex:   job.PaneIndex,
-			Priority:    job.Priority,
-			QueuedFor:   job.QueueDuration(),
-			RetryCount:  job.RetryCount,
+	// Collect queued jobs in dispatch order
+	tenants := make(map[string]*TenantProgress)
+	tenant := func(job *SpawnJob) *TenantProgress {
+		project := jobProject(job)
+		key := sessionTagKey(project, job.SessionName)
+		tp := tenants[key]
+		if tp == nil {
+			pw, sw := s.queue.Weights(project, job.SessionName)
+			tp = &TenantProgress{
+				Project:       project,
+				SessionName:   job.SessionName,
+				ProjectWeight: pw,
+				SessionWeight: sw,
+			}
+			tenants[key] = tp
 		}
+		return tp
+	}
 
-		eta, err := s.EstimateETA(job.ID)
-		if err == nil {
-			jp.EstimatedETASeconds = eta.Seconds()
+	for i, queued := range s.queue.DispatchOrder() {
+		job := queued.Clone()
+		eta := s.etaAfter(i).Seconds()
+		jp := JobProgress{
+			ID:                  job.ID,
+			Type:                job.Type,
+			Status:              job.Status,
+			SessionName:         job.SessionName,
+			Project:             job.Project,
+			AgentType:           job.AgentType,
+			PaneIndex:           job.PaneIndex,
+			Priority:            job.Priority,
+			QueuedFor:           job.QueueDuration(),
+			RetryCount:          job.RetryCount,
+			EstimatedETASeconds: eta,
+			Position:            i + 1,
 		}
-
 		progress.Queued = append(progress.Queued, jp)
 
+		tp := tenant(job)
+		tp.QueueDepth++
+		if tp.NextPosition == 0 {
+			tp.NextPosition = i + 1
+			tp.NextETASeconds = eta
+		}
+		tp.DrainETASeconds = eta
+
 		// Update session progress
 		sp := progress.BySession[job.SessionName]
 		if sp == nil {
@@ -204,6 +275,7 @@ func (s *Scheduler) GetProgress() *Progress {
 			Type:        job.Type,
 			Status:      job.Status,
 			SessionName: job.SessionName,
+			Project:     job.Project,
 			AgentType:   job.AgentType,
 			PaneIndex:   job.PaneIndex,
 			Priority:    job.Priority,
@@ -211,6 +283,7 @@ func (s *Scheduler) GetProgress() *Progress {
 			RetryCount:  job.RetryCount,
 		}
 		progress.Running = append(progress.Running, jp)
+		tenant(job).RunningCount++
 
 		sp := progress.BySession[job.SessionName]
 		if sp == nil {
@@ -250,6 +323,20 @@ func (s *Scheduler) GetProgress() *Progress {
 		sp.TotalPanes++
 	}
 
+	for _, tp := range tenants {
+		progress.Tenants = append(progress.Tenants, *tp)
+	}
+	sort.Slice(progress.Tenants, func(i, j int) bool {
+		a, b := progress.Tenants[i], progress.Tenants[j]
+		if (a.NextPosition == 0) != (b.NextPosition == 0) {
+			return a.NextPosition != 0
+		}
+		if a.NextPosition != b.NextPosition {
+			return a.NextPosition < b.NextPosition
+		}
+		return a.Project+"/"+a.SessionName < b.Project+"/"+b.SessionName
+	})
+
 	// Calculate progress percentages
 	for _, sp := range progress.BySession {
 		if sp.TotalPanes > 0 {
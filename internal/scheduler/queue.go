@@ -2,10 +2,15 @@ package scheduler
 
 import (
 	"container/heap"
+	"errors"
 	"sync"
 	"time"
 )
 
+// ErrQueueFull is returned when a job is submitted to a full queue and does
+// not outrank any queued job.
+var ErrQueueFull = errors.New("spawn queue is full")
+
 // JobQueue is a priority queue for spawn jobs with fairness tracking.
 type JobQueue struct {
 	mu sync.RWMutex
@@ -100,6 +105,34 @@ func (q *JobQueue) Enqueue(job *SpawnJob) {
 	q.stats.ByType[job.Type]++
 }
 
+// reinsert puts back a job taken out with Remove, for example one that was
+// dequeued but could not start. Unlike Enqueue it is not counted as a new
+// submission; the per-priority and per-type counts Remove lowered are
+// restored.
+func (q *JobQueue) reinsert(job *SpawnJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, exists := q.byID[job.ID]; exists {
+		return
+	}
+
+	heap.Push(&q.jobs, job)
+	q.byID[job.ID] = job
+
+	if job.BatchID != "" {
+		q.batchCounts[job.BatchID]++
+	}
+	q.sessionCounts[job.SessionName]++
+
+	q.stats.CurrentSize = len(q.jobs)
+	if q.stats.CurrentSize > q.stats.MaxSize {
+		q.stats.MaxSize = q.stats.CurrentSize
+	}
+	q.stats.ByPriority[job.Priority]++
+	q.stats.ByType[job.Type]++
+}
+
 // Dequeue removes and returns the highest priority job.
 // Returns nil if the queue is empty.
 func (q *JobQueue) Dequeue() *SpawnJob {
@@ -381,6 +414,22 @@ func (q *JobQueue) CancelBatch(batchID string) []*SpawnJob {
 	return cancelled
 }
 
+// lowest returns the queued job that would be dispatched last by priority:
+// the lowest priority, and the newest within it.
+func (q *JobQueue) lowest() *SpawnJob {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	var lowest *SpawnJob
+	for _, job := range q.jobs {
+		if lowest == nil || job.Priority > lowest.Priority ||
+			(job.Priority == lowest.Priority && job.CreatedAt.After(lowest.CreatedAt)) {
+			lowest = job
+		}
+	}
+	return lowest
+}
+
 // jobHeap implements heap.Interface for SpawnJobs.
 type jobHeap []*SpawnJob
 
@@ -411,7 +460,8 @@ func (h *jobHeap) Pop() interface{} {
 	return item
 }
 
-// FairScheduler wraps JobQueue with fairness guarantees.
+// FairScheduler wraps JobQueue with fairness guarantees. Jobs are served by
+// priority, then by weighted fair share across projects and sessions.
 type FairScheduler struct {
 	queue *JobQueue
 
@@ -424,7 +474,13 @@ type FairScheduler struct {
 	// running tracks currently running jobs by session.
 	running map[string]int
 
-	// mu protects running map.
+	// claiming holds queued jobs whose claim is in progress.
+	claiming map[string]bool
+
+	// share holds weighted fair queuing state.
+	share *fairShare
+
+	// mu protects running, claiming and share.
 	mu sync.RWMutex
 }
 
@@ -432,6 +488,15 @@ type FairScheduler struct {
 type FairSchedulerConfig struct {
 	MaxPerSession int `json:"max_per_session"`
 	MaxPerBatch   int `json:"max_per_batch"`
+
+	// ProjectWeights sets the fair-share weight of each project. A project
+	// with weight 2 is dispatched twice as often as one with weight 1 when
+	// both have jobs queued at the same priority. Unlisted projects get 1.
+	ProjectWeights map[string]float64 `json:"project_weights,omitempty"`
+
+	// SessionWeights sets the fair-share weight of each session within its
+	// project. Unlisted sessions get 1.
+	SessionWeights map[string]float64 `json:"session_weights,omitempty"`
 }
 
 // DefaultFairSchedulerConfig returns sensible defaults.
@@ -449,6 +514,8 @@ func NewFairScheduler(cfg FairSchedulerConfig) *FairScheduler {
 		maxPerSession: cfg.MaxPerSession,
 		maxPerBatch:   cfg.MaxPerBatch,
 		running:       make(map[string]int),
+		claiming:      make(map[string]bool),
+		share:         newFairShare(cfg),
 	}
 }
 
@@ -457,42 +524,129 @@ func (f *FairScheduler) Enqueue(job *SpawnJob) {
 	f.queue.Enqueue(job)
 }
 
+// Admit enqueues a new job, keeping the queue within maxQueued jobs when
+// maxQueued is positive. If the queue is full, the lowest-priority queued job
+// is preempted and returned, provided the new job outranks it; otherwise the
+// new job is rejected with ErrQueueFull. Running jobs are never preempted.
+func (f *FairScheduler) Admit(job *SpawnJob, maxQueued int) (*SpawnJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var victim *SpawnJob
+	if maxQueued > 0 && f.queue.Len() >= maxQueued {
+		lowest := f.queue.lowest()
+		if lowest == nil || lowest.Priority <= job.Priority {
+			return nil, ErrQueueFull
+		}
+		victim = f.queue.Remove(lowest.ID)
+	}
+	f.queue.Enqueue(job)
+	return victim, nil
+}
+
 // TryDequeue returns the next job that can run without violating fairness.
 // Returns nil if no eligible job is available.
 func (f *FairScheduler) TryDequeue() *SpawnJob {
+	return f.TryDequeueFunc(nil, nil)
+}
+
+// TryDequeueFunc is like TryDequeue but calls claim on the chosen job before
+// handing it out. If claim returns false, for example because the job's agent
+// type is at its concurrency cap, the job keeps its place in the queue and the
+// next candidate is tried. Tenants are only charged for claimed jobs.
+//
+// claim runs without f.mu held, as it may consult the shared state database.
+// Meanwhile the job stays queued, so it can still be cancelled or preempted,
+// and its session slot is reserved so concurrent callers cannot exceed the
+// per-session limit. If the job left the queue before the claim finished,
+// release is called to undo the claim and the next candidate is tried.
+func (f *FairScheduler) TryDequeueFunc(claim func(*SpawnJob) bool, release func(*SpawnJob)) *SpawnJob {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	// Iterate through jobs to find one that can run
-	for _, job := range f.queue.ListAll() {
-		// Check per-session limit
-		if f.maxPerSession > 0 && f.running[job.SessionName] >= f.maxPerSession {
-			continue
+	skipped := make(map[string]bool)
+	for {
+		jobs := f.queue.ListAll()
+		idx := f.share.pick(jobs, func(job *SpawnJob) bool {
+			return !skipped[job.ID] && !f.claiming[job.ID] && f.canRunLocked(job, jobs)
+		})
+		if idx < 0 {
+			return nil
 		}
 
-		// Check per-batch limit
-		if f.maxPerBatch > 0 && job.BatchID != "" {
-			// Count running jobs in this batch
-			batchRunning := 0
-			for _, j := range f.queue.ListAll() {
-				if j.BatchID == job.BatchID && j.GetStatus() == StatusRunning {
-					batchRunning++
-				}
-			}
-			if batchRunning >= f.maxPerBatch {
+		job := jobs[idx]
+		skipped[job.ID] = true
+		f.running[job.SessionName]++
+
+		if claim != nil {
+			f.claiming[job.ID] = true
+			var claimed bool
+			f.unlocked(func() { claimed = claim(job) })
+			delete(f.claiming, job.ID)
+			if !claimed {
+				f.releaseSessionLocked(job.SessionName)
 				continue
 			}
 		}
 
-		// This job can run, remove and return it
-		removed := f.queue.Remove(job.ID)
-		if removed != nil {
-			f.running[job.SessionName]++
+		if f.queue.Remove(job.ID) == nil {
+			// Cancelled or preempted concurrently
+			f.releaseSessionLocked(job.SessionName)
+			if claim != nil && release != nil {
+				f.unlocked(func() { release(job) })
+			}
+			continue
+		}
+
+		f.share.charge(job)
+		f.share.prune(f.queue.ListAll())
+		return job
+	}
+}
+
+// unlocked calls fn with f.mu released. Callers hold f.mu.
+func (f *FairScheduler) unlocked(fn func()) {
+	f.mu.Unlock()
+	defer f.mu.Lock()
+	fn()
+}
+
+// releaseSessionLocked gives back a session slot. Callers hold f.mu.
+func (f *FairScheduler) releaseSessionLocked(session string) {
+	f.running[session]--
+	if f.running[session] <= 0 {
+		delete(f.running, session)
+	}
+}
+
+// canRunLocked reports whether a job fits the per-session and per-batch limits.
+func (f *FairScheduler) canRunLocked(job *SpawnJob, queued []*SpawnJob) bool {
+	// Check per-session limit
+	if f.maxPerSession > 0 && f.running[job.SessionName] >= f.maxPerSession {
+		return false
+	}
+
+	// Check per-batch limit
+	if f.maxPerBatch > 0 && job.BatchID != "" {
+		// Count running jobs in this batch
+		batchRunning := 0
+		for _, j := range queued {
+			if j.BatchID == job.BatchID && j.GetStatus() == StatusRunning {
+				batchRunning++
+			}
+		}
+		if batchRunning >= f.maxPerBatch {
+			return false
 		}
-		return removed
 	}
+	return true
+}
 
-	return nil
+// Requeue returns a dequeued job to the queue without running it, for
+// example when its agent cap was taken by another worker.
+func (f *FairScheduler) Requeue(job *SpawnJob) {
+	f.MarkComplete(job)
+	f.queue.reinsert(job)
 }
 
 // MarkComplete marks a job as complete for fairness tracking.
@@ -500,10 +654,32 @@ func (f *FairScheduler) MarkComplete(job *SpawnJob) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	f.running[job.SessionName]--
-	if f.running[job.SessionName] <= 0 {
-		delete(f.running, job.SessionName)
+	f.releaseSessionLocked(job.SessionName)
+}
+
+// DispatchOrder returns queued jobs in the order they would be dispatched
+// if capacity were unlimited. Per-session, per-batch and agent caps can
+// reorder jobs at run time, so this is an estimate for progress reporting.
+func (f *FairScheduler) DispatchOrder() []*SpawnJob {
+	f.mu.RLock()
+	jobs := f.queue.ListAll()
+	share := f.share.clone()
+	f.mu.RUnlock()
+
+	order := make([]*SpawnJob, 0, len(jobs))
+	for len(jobs) > 0 {
+		idx := share.pick(jobs, nil)
+		job := jobs[idx]
+		share.charge(job)
+		order = append(order, job)
+		jobs = append(jobs[:idx], jobs[idx+1:]...)
 	}
+	return order
+}
+
+// Weights returns the fair-share weights of a project and session.
+func (f *FairScheduler) Weights(project, session string) (projectWeight, sessionWeight float64) {
+	return f.share.projectWeight(project), f.share.sessionWeight(session)
 }
 
 // Queue returns the underlying queue for direct access.
@@ -6,9 +6,12 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
 )
 
 // Scheduler is the global spawn scheduler that serializes and paces
@@ -55,6 +58,10 @@ type Scheduler struct {
 	// headroom is the pre-spawn resource headroom guard.
 	headroom *HeadroomGuard
 
+	// coord shares caps and backoff windows with other ntm processes.
+	coord     Coordinator
+	ownsCoord bool
+
 	// running state
 	started   atomic.Bool
 	ctx       context.Context
@@ -94,6 +101,10 @@ type Hooks struct {
 
 	// OnGuardrailTriggered is called when a guardrail blocks a spawn.
 	OnGuardrailTriggered func(job *SpawnJob, reason string)
+
+	// OnJobPreempted is called when a queued job is evicted to make room
+	// for a higher-priority job.
+	OnJobPreempted func(job *SpawnJob, by *SpawnJob)
 }
 
 // Config configures the scheduler.
@@ -128,8 +139,17 @@ type Config struct {
 	// BackpressureThreshold is the queue size that triggers backpressure alerts.
 	BackpressureThreshold int `json:"backpressure_threshold"`
 
+	// MaxQueueSize bounds the number of queued jobs (0 = unbounded). When the
+	// queue is full, a new job preempts the lowest-priority queued job if it
+	// outranks it, and is rejected with ErrQueueFull otherwise.
+	MaxQueueSize int `json:"max_queue_size"`
+
 	// Headroom is the pre-spawn resource headroom configuration.
 	Headroom HeadroomConfig `json:"headroom"`
+
+	// Coordination shares caps and backoff windows with other ntm
+	// processes on this machine.
+	Coordination CoordinationConfig `json:"coordination"`
 }
 
 // DefaultConfig returns sensible default configuration.
@@ -146,7 +166,36 @@ func DefaultConfig() Config {
 		DefaultRetryDelay:     time.Second,
 		BackpressureThreshold: 50,
 		Headroom:              DefaultHeadroomConfig(),
+		Coordination:          DefaultCoordinationConfig(),
+	}
+}
+
+// ConfigFromPacing returns the default configuration with the concurrency,
+// retry, queue and fair-share settings of the [spawn_pacing] config section
+// applied.
+func ConfigFromPacing(p config.SpawnPacingConfig) Config {
+	cfg := DefaultConfig()
+	if p.MaxConcurrentSpawns > 0 {
+		cfg.MaxConcurrent = p.MaxConcurrentSpawns
+	}
+	if p.MaxSpawnsPerSecond > 0 {
+		cfg.GlobalRateLimit.Rate = p.MaxSpawnsPerSecond
+	}
+	if p.BurstSize > 0 {
+		cfg.GlobalRateLimit.Capacity = float64(p.BurstSize)
+	}
+	cfg.DefaultRetries = p.DefaultRetries
+	cfg.DefaultRetryDelay = p.RetryDelay()
+	if p.BackpressureThreshold > 0 {
+		cfg.BackpressureThreshold = p.BackpressureThreshold
+	}
+	cfg.MaxQueueSize = p.MaxQueueSize
+	cfg.FairScheduler.ProjectWeights = make(map[string]float64, len(p.ProjectWeights))
+	for project, w := range p.ProjectWeights {
+		cfg.FairScheduler.ProjectWeights[filepath.Clean(project)] = w
 	}
+	cfg.FairScheduler.SessionWeights = p.SessionWeights
+	return cfg
 }
 
 // Stats contains scheduler statistics.
@@ -163,6 +212,9 @@ type Stats struct {
 	// TotalRetried is the total number of retry attempts.
 	TotalRetried int64 `json:"total_retried"`
 
+	// TotalPreempted is queued jobs evicted by higher-priority jobs.
+	TotalPreempted int64 `json:"total_preempted"`
+
 	// CurrentQueueSize is the current queue size.
 	CurrentQueueSize int `json:"current_queue_size"`
 
@@ -202,6 +254,12 @@ type Stats struct {
 	// RemainingBackoff is the remaining backoff duration if in global backoff.
 	RemainingBackoff time.Duration `json:"remaining_backoff,omitempty"`
 
+	// Coordinated indicates caps and backoff are shared with other processes.
+	Coordinated bool `json:"coordinated"`
+
+	// SharedBackoff is the remaining global backoff published by any process.
+	SharedBackoff time.Duration `json:"shared_backoff,omitempty"`
+
 	// HeadroomStatus contains resource headroom status.
 	HeadroomStatus HeadroomStatus `json:"headroom_status"`
 }
@@ -264,6 +322,19 @@ func (s *Scheduler) SetExecutor(executor SpawnExecutor) {
 	s.executor = executor
 }
 
+// SetCoordinator shares caps, global backoff and rate-limit cooldowns
+// through c. Pass nil to keep them local to this process. A coordinator set
+// here is not closed by Stop.
+func (s *Scheduler) SetCoordinator(c Coordinator) {
+	s.mu.Lock()
+	s.coord = c
+	s.ownsCoord = false
+	s.mu.Unlock()
+
+	s.agentCaps.SetCoordinator(c)
+	s.backoff.SetCoordinator(c)
+}
+
 // SetHooks sets the lifecycle hooks.
 func (s *Scheduler) SetHooks(hooks Hooks) {
 	s.mu.Lock()
@@ -283,8 +354,21 @@ func (s *Scheduler) Start() error {
 		return fmt.Errorf("executor not set")
 	}
 	s.stats.StartedAt = time.Now()
+	needCoord := s.coord == nil && s.config.Coordination.Enabled
 	s.mu.Unlock()
 
+	if needCoord {
+		c, err := OpenStoreCoordinator(s.config.Coordination.StatePath, s.config.Coordination)
+		if err != nil {
+			slog.Warn("spawn coordination disabled", "error", err)
+		} else {
+			s.SetCoordinator(c)
+			s.mu.Lock()
+			s.ownsCoord = true
+			s.mu.Unlock()
+		}
+	}
+
 	s.started.Store(true)
 
 	// Start worker goroutines
@@ -312,6 +396,17 @@ func (s *Scheduler) Stop() {
 		s.headroom.Stop()
 	}
 
+	// Release shared slots held by this process
+	s.mu.Lock()
+	coord, owns := s.coord, s.ownsCoord
+	s.mu.Unlock()
+	if owns {
+		s.SetCoordinator(nil)
+		if err := coord.Close(); err != nil {
+			slog.Warn("failed to close spawn coordinator", "error", err)
+		}
+	}
+
 	slog.Info("scheduler stopped")
 }
 
@@ -330,11 +425,20 @@ func (s *Scheduler) Submit(job *SpawnJob) error {
 	if job.RetryDelay == 0 {
 		job.RetryDelay = s.config.DefaultRetryDelay
 	}
+	if job.Project == "" && job.Directory != "" {
+		job.Project = filepath.Clean(job.Directory)
+	}
 
 	job.SetStatus(StatusPending)
-	s.queue.Enqueue(job)
+	victim, err := s.queue.Admit(job, s.config.MaxQueueSize)
+	if err != nil {
+		return err
+	}
 
 	atomic.AddInt64(&s.stats.TotalSubmitted, 1)
+	if victim != nil {
+		s.preempt(victim, job)
+	}
 
 	// Check for backpressure
 	queueSize := s.queue.Queue().Len()
@@ -377,6 +481,51 @@ func (s *Scheduler) SubmitBatch(jobs []*SpawnJob) (string, error) {
 	return batchID, nil
 }
 
+// preempt finalizes a queued job that was evicted by a higher-priority job.
+func (s *Scheduler) preempt(victim, by *SpawnJob) {
+	victim.Preempt()
+	victim.SetError(fmt.Errorf("preempted by %s job %s", priorityName(by.Priority), by.ID))
+	atomic.AddInt64(&s.stats.TotalPreempted, 1)
+
+	slog.Info("spawn job preempted",
+		"job_id", victim.ID,
+		"session", victim.SessionName,
+		"priority", victim.Priority,
+		"by_job_id", by.ID,
+		"by_priority", by.Priority,
+	)
+
+	if s.hooks.OnJobPreempted != nil {
+		s.hooks.OnJobPreempted(victim, by)
+	}
+
+	s.mu.Lock()
+	s.completed = append(s.completed, victim.Clone())
+	if len(s.completed) > s.maxCompleted {
+		s.completed = s.completed[len(s.completed)-s.maxCompleted:]
+	}
+	s.mu.Unlock()
+
+	if victim.Callback != nil {
+		victim.Callback(victim)
+	}
+}
+
+// priorityName returns a readable name for a job priority.
+func priorityName(p JobPriority) string {
+	switch p {
+	case PriorityUrgent:
+		return "urgent"
+	case PriorityHigh:
+		return "high"
+	case PriorityNormal:
+		return "normal"
+	case PriorityLow:
+		return "low"
+	}
+	return fmt.Sprintf("priority-%d", p)
+}
+
 // Cancel cancels a job by ID.
 func (s *Scheduler) Cancel(jobID string) bool {
 	// Check queue first
@@ -537,6 +686,8 @@ func (s *Scheduler) Stats() Stats {
 	stats.CapsStats = s.agentCaps.Stats()
 	stats.InGlobalBackoff = s.backoff.IsInGlobalBackoff()
 	stats.RemainingBackoff = s.backoff.RemainingBackoff()
+	stats.Coordinated = s.coord != nil
+	stats.SharedBackoff = remainingCooldown(s.coord, CooldownGlobal)
 	if s.headroom != nil {
 		stats.HeadroomStatus = s.headroom.Status()
 	}
@@ -546,32 +697,23 @@ func (s *Scheduler) Stats() Stats {
 
 // EstimateETA estimates when a queued job will start.
 func (s *Scheduler) EstimateETA(jobID string) (time.Duration, error) {
-	job := s.queue.Queue().Get(jobID)
-	if job == nil {
-		return 0, fmt.Errorf("job not found in queue")
-	}
-
-	// Count jobs ahead in queue
-	jobsAhead := 0
-	for _, j := range s.queue.Queue().ListAll() {
-		if j.Priority < job.Priority {
-			jobsAhead++
-		} else if j.Priority == job.Priority && j.CreatedAt.Before(job.CreatedAt) {
-			jobsAhead++
+	for ahead, job := range s.queue.DispatchOrder() {
+		if job.ID == jobID {
+			return s.etaAfter(ahead), nil
 		}
 	}
+	return 0, fmt.Errorf("job not found in queue")
+}
 
-	// Estimate based on rate limit and concurrency
-	tokensNeeded := float64(jobsAhead) / float64(s.workers)
-	etaSeconds := tokensNeeded / s.globalLimiter.Stats().CurrentTokens
-	if etaSeconds < 0 {
-		etaSeconds = 0
+// etaAfter estimates how long until a job with the given number of jobs
+// ahead of it in dispatch order can start.
+func (s *Scheduler) etaAfter(ahead int) time.Duration {
+	eta := s.globalLimiter.TimeUntilNextToken()
+	if ahead <= 0 || s.workers <= 0 || s.globalLimiter.rate <= 0 {
+		return eta
 	}
-
-	// Add time until next token
-	eta := time.Duration(etaSeconds*1000)*time.Millisecond + s.globalLimiter.TimeUntilNextToken()
-
-	return eta, nil
+	tokensNeeded := float64(ahead) / float64(s.workers)
+	return eta + time.Duration(tokensNeeded/s.globalLimiter.rate*float64(time.Second))
 }
 
 // worker is a goroutine that processes jobs from the queue.
@@ -619,42 +761,35 @@ func (s *Scheduler) processJobs(workerID int) {
 			}
 		}
 
-		// Try to get a job
-		job := s.queue.TryDequeue()
-		if job == nil {
-			return // No jobs available
+		// Honor global backoff triggered by other processes
+		if remaining := s.sharedBackoff(); remaining > 0 {
+			slog.Debug("job processing blocked by shared backoff",
+				"worker_id", workerID,
+				"remaining", remaining,
+			)
+			return
 		}
 
-		// Check agent concurrency cap (non-blocking check first)
-		if job.AgentType != "" {
-			if !s.agentCaps.TryAcquire(job.AgentType) {
-				// At cap, put job back and try another
-				s.queue.Enqueue(job)
-
-				// Try to find a job for a different agent type
-				job = s.findJobWithAvailableCap()
-				if job == nil {
-					return // No jobs with available caps
-				}
-			}
+		// Take the next fair-share job whose agent type has capacity
+		job := s.queue.TryDequeueFunc(s.acquireCap, s.releaseCap)
+		if job == nil {
+			return // No jobs available
 		}
 
 		// Wait for rate limit
 		if job.AgentType != "" {
 			if err := s.agentLimiters.Wait(s.ctx, job.AgentType); err != nil {
 				// Context cancelled, put job back and release cap
-				s.agentCaps.Release(job.AgentType)
-				s.queue.Enqueue(job)
+				s.releaseCap(job)
+				s.queue.Requeue(job)
 				return
 			}
 		}
 
 		if err := s.globalLimiter.Wait(s.ctx); err != nil {
 			// Context cancelled, put job back and release cap
-			if job.AgentType != "" {
-				s.agentCaps.Release(job.AgentType)
-			}
-			s.queue.Enqueue(job)
+			s.releaseCap(job)
+			s.queue.Requeue(job)
 			return
 		}
 
@@ -663,23 +798,50 @@ func (s *Scheduler) processJobs(workerID int) {
 	}
 }
 
-// findJobWithAvailableCap tries to find a job whose agent type has available capacity.
-func (s *Scheduler) findJobWithAvailableCap() *SpawnJob {
-	// Get all queued jobs and try to find one with available cap
-	jobs := s.queue.Queue().ListAll()
-	for _, job := range jobs {
-		if job.AgentType == "" || s.agentCaps.TryAcquire(job.AgentType) {
-			// Remove from queue if we got the cap
-			if s.queue.Queue().Remove(job.ID) != nil {
-				return job
-			}
-			// If removal failed, release the cap we just acquired
-			if job.AgentType != "" {
-				s.agentCaps.Release(job.AgentType)
-			}
-		}
+// sharedBackoff returns the remaining machine-wide global backoff.
+func (s *Scheduler) sharedBackoff() time.Duration {
+	s.mu.RLock()
+	coord := s.coord
+	s.mu.RUnlock()
+	return remainingCooldown(coord, CooldownGlobal)
+}
+
+// remainingCooldown returns how long the shared window for scope lasts.
+func remainingCooldown(coord Coordinator, scope string) time.Duration {
+	if coord == nil {
+		return 0
+	}
+	until, err := coord.Cooldown(scope)
+	if err != nil {
+		return 0
+	}
+	return max(time.Until(until), 0)
+}
+
+// shareRateLimit publishes a rate-limit cooldown for an agent type so that
+// other processes stop launching it too.
+func (s *Scheduler) shareRateLimit(agentType string, delay time.Duration) {
+	s.mu.RLock()
+	coord := s.coord
+	s.mu.RUnlock()
+	if coord == nil || agentType == "" || delay <= 0 {
+		return
+	}
+	if err := coord.SetCooldown(agentType, time.Now().Add(delay), string(ResourceErrorRateLimit)); err != nil {
+		slog.Warn("failed to share rate-limit cooldown", "agent_type", agentType, "error", err)
+	}
+}
+
+// acquireCap takes a concurrency slot for a job's agent type, if it has one.
+func (s *Scheduler) acquireCap(job *SpawnJob) bool {
+	return job.AgentType == "" || s.agentCaps.TryAcquire(job.AgentType)
+}
+
+// releaseCap gives back the agent cap taken by acquireCap.
+func (s *Scheduler) releaseCap(job *SpawnJob) {
+	if job.AgentType != "" {
+		s.agentCaps.Release(job.AgentType)
 	}
-	return nil
 }
 
 // executeJob executes a single job.
@@ -742,6 +904,9 @@ func (s *Scheduler) executeJob(workerID int, job *SpawnJob) {
 
 			// Use backoff controller for resource errors
 			shouldRetry, backoffDelay := s.backoff.HandleError(job, resErr)
+			if resErr != nil && resErr.Type == ResourceErrorRateLimit {
+				s.shareRateLimit(job.AgentType, backoffDelay)
+			}
 
 			// Release agent cap before retry delay
 			if job.AgentType != "" {
@@ -854,7 +1019,11 @@ var globalSchedulerOnce sync.Once
 // Global returns the global scheduler instance, creating it if necessary.
 func Global() *Scheduler {
 	globalSchedulerOnce.Do(func() {
-		globalScheduler = New(DefaultConfig())
+		// The process-wide scheduler shares its limits with other ntm
+		// processes; coordination is set up when it starts.
+		cfg := DefaultConfig()
+		cfg.Coordination.Enabled = true
+		globalScheduler = New(cfg)
 	})
 	return globalScheduler
 }
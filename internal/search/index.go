@@ -0,0 +1,316 @@
+// Package search provides a full-text index over archived agent output.
+//
+// The index lives in its own SQLite database next to the state store. It
+// uses FTS5 when the SQLite build includes it (build with -tags sqlite_fts5)
+// and falls back to FTS4, which every build of the driver provides.
+package search
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Dicklesworthstone/ntm/internal/archive"
+	"github.com/Dicklesworthstone/ntm/internal/privacy"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+)
+
+// Engine identifies the SQLite full-text module backing an index.
+type Engine string
+
+const (
+	EngineFTS5 Engine = "fts5"
+	EngineFTS4 Engine = "fts4"
+)
+
+// DefaultPath returns the default index location, ~/.config/ntm/search.db.
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(home, ".config", "ntm", "search.db"), nil
+}
+
+// Index is a full-text index of archive records.
+type Index struct {
+	db     *sql.DB
+	mu     sync.Mutex // serializes writes
+	path   string
+	engine Engine
+
+	// privacy decides which sessions may be indexed and returned.
+	// Nil uses privacy.GetDefaultManager().
+	privacy *privacy.Manager
+}
+
+// Open opens or creates the index at path. An empty path uses DefaultPath.
+func Open(path string) (*Index, error) {
+	if path == "" {
+		p, err := DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create index dir: %w", err)
+	}
+
+	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=5000", path)
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open index: %w", err)
+	}
+	db.SetMaxOpenConns(4)
+
+	idx := &Index{db: db, path: path}
+	if err := idx.init(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Close closes the index.
+func (idx *Index) Close() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.db.Close()
+}
+
+// Path returns the index database path.
+func (idx *Index) Path() string { return idx.path }
+
+// Engine returns the full-text module in use.
+func (idx *Index) Engine() Engine { return idx.engine }
+
+// SetPrivacyManager overrides the privacy manager used to exclude sessions.
+func (idx *Index) SetPrivacyManager(m *privacy.Manager) {
+	idx.privacy = m
+}
+
+func (idx *Index) privacyManager() *privacy.Manager {
+	if idx.privacy != nil {
+		return idx.privacy
+	}
+	return privacy.GetDefaultManager()
+}
+
+// canIndex reports whether a session's output may be indexed or returned.
+func (idx *Index) canIndex(session string) bool {
+	return idx.privacyManager().CanPersist(session, privacy.OpSearchIndex) == nil
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS search_docs (
+	id         INTEGER PRIMARY KEY,
+	session    TEXT NOT NULL,
+	pane       TEXT NOT NULL,
+	pane_index INTEGER NOT NULL,
+	agent      TEXT NOT NULL,
+	model      TEXT NOT NULL DEFAULT '',
+	ts         INTEGER NOT NULL,
+	seq        INTEGER NOT NULL,
+	lines      INTEGER NOT NULL,
+	content    TEXT NOT NULL,
+	UNIQUE (session, pane, ts, seq)
+);
+CREATE INDEX IF NOT EXISTS idx_search_docs_session_ts ON search_docs(session, ts);
+CREATE INDEX IF NOT EXISTS idx_search_docs_pane_ts ON search_docs(session, pane, ts);
+
+CREATE TABLE IF NOT EXISTS search_sources (
+	path   TEXT PRIMARY KEY,
+	offset INTEGER NOT NULL
+);`
+
+// init creates the schema and the full-text table, preferring FTS5.
+func (idx *Index) init() error {
+	if _, err := idx.db.Exec(schema); err != nil {
+		return fmt.Errorf("create index schema: %w", err)
+	}
+
+	var existing string
+	err := idx.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'search_fts'`).Scan(&existing)
+	switch {
+	case err == nil:
+		idx.engine = EngineFTS4
+		if strings.Contains(strings.ToLower(existing), "fts5") {
+			idx.engine = EngineFTS5
+		}
+		return nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("inspect index schema: %w", err)
+	}
+
+	_, err = idx.db.Exec(`CREATE VIRTUAL TABLE search_fts USING fts5(
+		content, content='search_docs', content_rowid='id', tokenize='unicode61')`)
+	if err == nil {
+		idx.engine = EngineFTS5
+		return nil
+	}
+	if !strings.Contains(err.Error(), "no such module") {
+		return fmt.Errorf("create fts5 table: %w", err)
+	}
+	if _, err := idx.db.Exec(`CREATE VIRTUAL TABLE search_fts USING fts4(
+		content, content="search_docs", tokenize=unicode61)`); err != nil {
+		return fmt.Errorf("create fts4 table: %w", err)
+	}
+	idx.engine = EngineFTS4
+	return nil
+}
+
+// IndexRecord adds an archive record to the index. Records from sessions in
+// privacy mode are skipped, and records already indexed are ignored, so the
+// archiver and IngestDir may both feed the same record.
+func (idx *Index) IndexRecord(rec *archive.ArchiveRecord) error {
+	if rec == nil || strings.TrimSpace(rec.Content) == "" || !idx.canIndex(rec.Session) {
+		return nil
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	tx, err := idx.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin index tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertRecord(tx, rec); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit index tx: %w", err)
+	}
+	return nil
+}
+
+func insertRecord(tx *sql.Tx, rec *archive.ArchiveRecord) error {
+	res, err := tx.Exec(`
+		INSERT OR IGNORE INTO search_docs (session, pane, pane_index, agent, model, ts, seq, lines, content)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.Session, rec.Pane, rec.PaneIndex, rec.Agent, rec.Model,
+		rec.Timestamp.UTC().UnixNano(), rec.Sequence, rec.Lines, rec.Content,
+	)
+	if err != nil {
+		return fmt.Errorf("insert search doc: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("insert search doc: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO search_fts (rowid, content) VALUES (?, ?)`, id, rec.Content); err != nil {
+		return fmt.Errorf("insert search text: %w", err)
+	}
+	return nil
+}
+
+// IngestStats summarizes an IngestDir run.
+type IngestStats struct {
+	Files   int `json:"files"`
+	Records int `json:"records"`
+	Skipped int `json:"skipped"` // Malformed records or privacy-mode sessions
+}
+
+// IngestDir indexes archive JSONL files in dir, resuming each file where the
+// previous run stopped. This backfills archives written before the index
+// existed or while it was unavailable.
+func (idx *Index) IngestDir(dir string) (IngestStats, error) {
+	var stats IngestStats
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return stats, fmt.Errorf("list archives: %w", err)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		n, skipped, err := idx.ingestFile(path)
+		if err != nil {
+			return stats, err
+		}
+		if n > 0 || skipped > 0 {
+			stats.Files++
+		}
+		stats.Records += n
+		stats.Skipped += skipped
+	}
+	return stats, nil
+}
+
+func (idx *Index) ingestFile(path string) (indexed, skipped int, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("stat archive: %w", err)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	var offset int64
+	err = idx.db.QueryRow(`SELECT offset FROM search_sources WHERE path = ?`, path).Scan(&offset)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, fmt.Errorf("get archive offset: %w", err)
+	}
+	if offset > info.Size() {
+		offset = 0 // Archive was replaced
+	}
+	if offset == info.Size() {
+		return 0, 0, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, 0); err != nil {
+		return 0, 0, fmt.Errorf("seek archive: %w", err)
+	}
+
+	tx, err := idx.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("begin index tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, readErr := r.ReadBytes('\n')
+		if readErr != nil {
+			break // EOF or a partial trailing line, picked up next time
+		}
+		offset += int64(len(line))
+
+		var rec archive.ArchiveRecord
+		if json.Unmarshal(line, &rec) != nil || strings.TrimSpace(rec.Content) == "" || !idx.canIndex(rec.Session) {
+			skipped++
+			continue
+		}
+		if err := insertRecord(tx, &rec); err != nil {
+			return 0, 0, err
+		}
+		indexed++
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO search_sources (path, offset) VALUES (?, ?)
+		ON CONFLICT(path) DO UPDATE SET offset = excluded.offset`, path, offset); err != nil {
+		return 0, 0, fmt.Errorf("save archive offset: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("commit index tx: %w", err)
+	}
+	return indexed, skipped, nil
+}
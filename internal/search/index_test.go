@@ -0,0 +1,243 @@
+package search
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/archive"
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/privacy"
+)
+
+var base = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func openIndex(t *testing.T) *Index {
+	t.Helper()
+	idx, err := Open(filepath.Join(t.TempDir(), "search.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	idx.SetPrivacyManager(privacy.New(config.PrivacyConfig{}))
+	t.Cleanup(func() { idx.Close() })
+	return idx
+}
+
+func record(session, agent string, paneIndex, seq int, at time.Time, content string) *archive.ArchiveRecord {
+	return &archive.ArchiveRecord{
+		Session:   session,
+		Pane:      agent + "_" + string(rune('0'+paneIndex)),
+		PaneIndex: paneIndex,
+		Agent:     agent,
+		Timestamp: at,
+		Content:   content,
+		Lines:     strings.Count(content, "\n") + 1,
+		Sequence:  seq,
+	}
+}
+
+func mustIndex(t *testing.T, idx *Index, recs ...*archive.ArchiveRecord) {
+	t.Helper()
+	for _, r := range recs {
+		if err := idx.IndexRecord(r); err != nil {
+			t.Fatalf("IndexRecord() error: %v", err)
+		}
+	}
+}
+
+func TestSearch_RankingFiltersAndSnippets(t *testing.T) {
+	t.Parallel()
+	idx := openIndex(t)
+	mustIndex(t, idx,
+		record("proj", "cod", 2, 1, base, "running tests\npanic: runtime error: invalid memory address or nil pointer dereference\n[signal SIGSEGV]"),
+		record("proj", "cc", 3, 1, base.Add(time.Minute), "checked for nil before the pointer was used; nil pointer nil pointer"),
+		record("proj", "cod", 2, 2, base.Add(48*time.Hour), "build ok, nothing about pointers here"),
+		record("other", "cod", 2, 1, base.Add(time.Hour), "nil pointer in another session"),
+	)
+
+	hits, err := idx.Search(Query{Text: "nil pointer"})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(hits) != 3 {
+		t.Fatalf("got %d hits, want 3", len(hits))
+	}
+	for i := 1; i < len(hits); i++ {
+		if hits[i].Score > hits[i-1].Score {
+			t.Errorf("hits not ranked: %v then %v", hits[i-1].Score, hits[i].Score)
+		}
+	}
+	if hits[0].Agent != "cc" {
+		t.Errorf("top hit agent = %q, want the record repeating the terms", hits[0].Agent)
+	}
+	if !strings.Contains(hits[0].Snippet, HighlightStart+"nil"+HighlightEnd) {
+		t.Errorf("snippet not highlighted: %q", hits[0].Snippet)
+	}
+	if page, _ := idx.Search(Query{Text: "nil pointer", Limit: 1, Offset: 1}); len(page) != 1 || page[0].ID != hits[1].ID {
+		t.Errorf("second page = %+v, want %+v", page, hits[1])
+	}
+
+	hits, err = idx.Search(Query{Text: "nil pointer", Session: "proj", Agent: "cod", Since: base.Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Pane != "cod_2" || !hits[0].Timestamp.Equal(base) {
+		t.Errorf("filtered hits = %+v", hits)
+	}
+
+	// Punctuation is matched literally rather than parsed as syntax.
+	if hits, err := idx.Search(Query{Text: "runtime error:"}); err != nil || len(hits) != 1 {
+		t.Errorf("Search(punctuation) = %d hits, %v", len(hits), err)
+	}
+	if hits, err := idx.Search(Query{Text: `"pointer dereference"`}); err != nil || len(hits) != 1 {
+		t.Errorf("Search(phrase) = %d hits, %v", len(hits), err)
+	}
+	if hits, err := idx.Search(Query{Text: "deref*"}); err != nil || len(hits) != 1 {
+		t.Errorf("Search(prefix) = %d hits, %v", len(hits), err)
+	}
+	if _, err := idx.Search(Query{Text: "  "}); err == nil {
+		t.Error("empty query should fail")
+	}
+}
+
+func TestSearch_PrivacySessionsExcluded(t *testing.T) {
+	t.Parallel()
+	idx := openIndex(t)
+	pm := privacy.New(config.PrivacyConfig{})
+	idx.SetPrivacyManager(pm)
+
+	pm.RegisterSession("secret", true, false)
+	mustIndex(t, idx,
+		record("secret", "cc", 2, 1, base, "api key rotation failed"),
+		record("open", "cc", 2, 1, base, "api key rotation failed"),
+	)
+	if st, _ := idx.Stats(); st.Records != 1 {
+		t.Errorf("indexed %d records, want 1 (privacy session skipped)", st.Records)
+	}
+
+	// A session that enters privacy mode later disappears from results.
+	pm.RegisterSession("open", true, false)
+	hits, err := idx.Search(Query{Text: "rotation"})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(hits) != 0 {
+		t.Errorf("privacy-mode session returned: %+v", hits)
+	}
+	if recs, _ := idx.At("open", "", base, 1, 1); len(recs) != 0 {
+		t.Errorf("At() returned privacy-mode output")
+	}
+}
+
+func TestAround_JumpsToTimestamp(t *testing.T) {
+	t.Parallel()
+	idx := openIndex(t)
+	for i := 0; i < 6; i++ {
+		mustIndex(t, idx, record("proj", "cod", 2, i+1, base.Add(time.Duration(i)*time.Minute), "step "+string(rune('a'+i))))
+	}
+	mustIndex(t, idx, record("proj", "cc", 3, 1, base.Add(2*time.Minute), "other pane"))
+
+	hits, err := idx.Search(Query{Text: "step", Pane: "cod_2", Limit: 10})
+	if err != nil || len(hits) != 6 {
+		t.Fatalf("Search() = %d hits, %v", len(hits), err)
+	}
+	var target Hit
+	for _, h := range hits {
+		if h.Timestamp.Equal(base.Add(3 * time.Minute)) {
+			target = h
+		}
+	}
+
+	recs, err := idx.Around(target.ID, 2, 1)
+	if err != nil {
+		t.Fatalf("Around() error: %v", err)
+	}
+	var got []string
+	for _, r := range recs {
+		got = append(got, r.Content)
+	}
+	if strings.Join(got, ",") != "step b,step c,step d,step e" {
+		t.Errorf("Around() = %v", got)
+	}
+
+	recs, _ = idx.At("proj", "", base.Add(2*time.Minute+time.Second), 0, 0)
+	if len(recs) != 1 || recs[0].Content != "other pane" {
+		t.Errorf("At() across panes = %+v", recs)
+	}
+}
+
+func TestIngestDir_ResumesAndDeduplicates(t *testing.T) {
+	t.Parallel()
+	idx := openIndex(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "proj_2026-03-01.jsonl")
+
+	writeRecords := func(recs ...*archive.ArchiveRecord) {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		enc := json.NewEncoder(f)
+		for _, r := range recs {
+			if err := enc.Encode(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	first := record("proj", "cod", 2, 1, base, "segfault in parser")
+	writeRecords(first, record("proj", "cod", 2, 2, base.Add(time.Minute), "fixed the segfault"))
+	// The archiver already indexed the first record live.
+	mustIndex(t, idx, first)
+
+	stats, err := idx.IngestDir(dir)
+	if err != nil {
+		t.Fatalf("IngestDir() error: %v", err)
+	}
+	if stats.Files != 1 || stats.Records != 2 {
+		t.Errorf("first ingest = %+v", stats)
+	}
+	if st, _ := idx.Stats(); st.Records != 2 {
+		t.Errorf("index holds %d records, want 2 (no duplicates)", st.Records)
+	}
+
+	writeRecords(record("proj", "cod", 2, 3, base.Add(2*time.Minute), "segfault is back"))
+	if f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644); err == nil {
+		f.WriteString(`{"session":"proj","content":"partial`) // still being written
+		f.Close()
+	}
+	stats, err = idx.IngestDir(dir)
+	if err != nil || stats.Records != 1 {
+		t.Errorf("second ingest = %+v, %v", stats, err)
+	}
+	if hits, _ := idx.Search(Query{Text: "segfault"}); len(hits) != 3 {
+		t.Errorf("got %d hits after resume, want 3", len(hits))
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in     string
+		engine Engine
+		want   string
+	}{
+		{"nil pointer", EngineFTS5, `"nil" "pointer"`},
+		{`"nil pointer" panic`, EngineFTS5, `"nil pointer" "panic"`},
+		{`say "hi`, EngineFTS4, `"say" "hi"`},
+		{`a"b`, EngineFTS5, `"a" "b"`},
+		{"conn*", EngineFTS5, `"conn"*`},
+		{"conn*", EngineFTS4, `"conn*"`},
+		{"error: x-y", EngineFTS4, `"error:" "x-y"`},
+	}
+	for _, tt := range tests {
+		got, err := MatchExpression(tt.in, tt.engine)
+		if err != nil || got != tt.want {
+			t.Errorf("MatchExpression(%q, %s) = %q, %v; want %q", tt.in, tt.engine, got, err, tt.want)
+		}
+	}
+}
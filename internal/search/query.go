@@ -0,0 +1,400 @@
+package search
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Snippet highlight markers around matched terms.
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// DefaultLimit is the number of hits returned when Query.Limit is unset.
+const DefaultLimit = 20
+
+// fts4CandidateLimit bounds how many matches are ranked in Go on FTS4,
+// which has no built-in ranking function.
+const fts4CandidateLimit = 5000
+
+// Query describes a search.
+type Query struct {
+	Text    string    // Terms, AND-ed; "double quotes" group a phrase, a trailing * matches a prefix
+	Session string    // Exact session name
+	Agent   string    // Agent type (cc, cod, gmi, ...)
+	Pane    string    // Pane name as archived, e.g. "cod_2"
+	Since   time.Time // Inclusive lower bound
+	Until   time.Time // Exclusive upper bound
+	Limit   int
+	Offset  int // Hits to skip, for pagination
+}
+
+// Hit is a ranked match.
+type Hit struct {
+	ID        int64     `json:"id"`
+	Session   string    `json:"session"`
+	Pane      string    `json:"pane"`
+	PaneIndex int       `json:"pane_index"`
+	Agent     string    `json:"agent"`
+	Model     string    `json:"model,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Snippet   string    `json:"snippet"`
+	Score     float64   `json:"score"` // Higher is more relevant
+}
+
+// Record is an archived output chunk, used for surrounding context.
+type Record struct {
+	ID        int64     `json:"id"`
+	Session   string    `json:"session"`
+	Pane      string    `json:"pane"`
+	PaneIndex int       `json:"pane_index"`
+	Agent     string    `json:"agent"`
+	Model     string    `json:"model,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Lines     int       `json:"lines"`
+	Content   string    `json:"content"`
+}
+
+// MatchExpression converts user search text into a full-text MATCH
+// expression. Every term is quoted so punctuation in error messages and
+// paths cannot be misread as query syntax.
+func MatchExpression(text string, engine Engine) (string, error) {
+	var terms []string
+	var cur strings.Builder
+	inQuote := false
+
+	flush := func() {
+		term := cur.String()
+		cur.Reset()
+		prefix := false
+		if !inQuote && strings.HasSuffix(term, "*") {
+			term = strings.TrimRight(term, "*")
+			prefix = true
+		}
+		if strings.TrimSpace(term) == "" {
+			return
+		}
+		quoted := strings.ReplaceAll(term, `"`, `""`)
+		switch {
+		case prefix && engine == EngineFTS5:
+			terms = append(terms, `"`+quoted+`"*`)
+		case prefix:
+			terms = append(terms, `"`+quoted+`*"`)
+		default:
+			terms = append(terms, `"`+quoted+`"`)
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case r == '"':
+			flush()
+			inQuote = !inQuote
+		case unicode.IsSpace(r) && !inQuote:
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+
+	if len(terms) == 0 {
+		return "", fmt.Errorf("empty search query")
+	}
+	return strings.Join(terms, " "), nil
+}
+
+// Search returns the best matches for q, most relevant first. Sessions in
+// privacy mode are never returned, even if they were indexed earlier.
+func (idx *Index) Search(q Query) ([]Hit, error) {
+	match, err := MatchExpression(q.Text, idx.engine)
+	if err != nil {
+		return nil, err
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	offset := max(q.Offset, 0)
+
+	where, args, err := idx.filters(q)
+	if err != nil {
+		return nil, err
+	}
+	args = append([]interface{}{match}, args...)
+
+	if idx.engine == EngineFTS5 {
+		return idx.searchFTS5(where, args, limit, offset)
+	}
+	return idx.searchFTS4(where, args, limit, offset)
+}
+
+// filters builds the WHERE clause shared by both engines.
+func (idx *Index) filters(q Query) (string, []interface{}, error) {
+	clauses := []string{"search_fts MATCH ?"}
+	var args []interface{}
+
+	if q.Session != "" {
+		clauses = append(clauses, "d.session = ?")
+		args = append(args, q.Session)
+	}
+	if q.Agent != "" {
+		clauses = append(clauses, "d.agent = ?")
+		args = append(args, q.Agent)
+	}
+	if q.Pane != "" {
+		clauses = append(clauses, "d.pane = ?")
+		args = append(args, q.Pane)
+	}
+	if !q.Since.IsZero() {
+		clauses = append(clauses, "d.ts >= ?")
+		args = append(args, q.Since.UTC().UnixNano())
+	}
+	if !q.Until.IsZero() {
+		clauses = append(clauses, "d.ts < ?")
+		args = append(args, q.Until.UTC().UnixNano())
+	}
+
+	excluded, err := idx.excludedSessions()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(excluded) > 0 {
+		clauses = append(clauses, "d.session NOT IN (?"+strings.Repeat(", ?", len(excluded)-1)+")")
+		for _, s := range excluded {
+			args = append(args, s)
+		}
+	}
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+// excludedSessions lists indexed sessions that privacy mode now hides.
+func (idx *Index) excludedSessions() ([]string, error) {
+	rows, err := idx.db.Query(`SELECT DISTINCT session FROM search_docs`)
+	if err != nil {
+		return nil, fmt.Errorf("list indexed sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var excluded []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, fmt.Errorf("scan indexed session: %w", err)
+		}
+		if !idx.canIndex(s) {
+			excluded = append(excluded, s)
+		}
+	}
+	return excluded, rows.Err()
+}
+
+const hitColumns = `d.id, d.session, d.pane, d.pane_index, d.agent, d.model, d.ts`
+
+func (idx *Index) searchFTS5(where string, args []interface{}, limit, offset int) ([]Hit, error) {
+	query := fmt.Sprintf(`
+		SELECT %s, snippet(search_fts, 0, ?, ?, '…', 16), bm25(search_fts)
+		FROM search_fts JOIN search_docs d ON d.id = search_fts.rowid
+		WHERE %s
+		ORDER BY bm25(search_fts), d.ts DESC
+		LIMIT ? OFFSET ?`, hitColumns, where)
+	args = append([]interface{}{HighlightStart, HighlightEnd}, args...)
+	args = append(args, limit, offset)
+
+	rows, err := idx.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []Hit
+	for rows.Next() {
+		var h Hit
+		var ts int64
+		var bm25 float64
+		if err := rows.Scan(&h.ID, &h.Session, &h.Pane, &h.PaneIndex, &h.Agent, &h.Model, &ts, &h.Snippet, &bm25); err != nil {
+			return nil, fmt.Errorf("scan hit: %w", err)
+		}
+		h.Timestamp = time.Unix(0, ts).UTC()
+		h.Score = -bm25 // FTS5 scores better matches lower
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
+
+func (idx *Index) searchFTS4(where string, args []interface{}, limit, offset int) ([]Hit, error) {
+	query := fmt.Sprintf(`
+		SELECT %s, snippet(search_fts, ?, ?, '…', -1, 16), matchinfo(search_fts, 'pcnalx')
+		FROM search_fts JOIN search_docs d ON d.id = search_fts.docid
+		WHERE %s
+		ORDER BY d.ts DESC
+		LIMIT ?`, hitColumns, where)
+	args = append([]interface{}{HighlightStart, HighlightEnd}, args...)
+	args = append(args, fts4CandidateLimit)
+
+	rows, err := idx.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []Hit
+	for rows.Next() {
+		var h Hit
+		var ts int64
+		var info []byte
+		if err := rows.Scan(&h.ID, &h.Session, &h.Pane, &h.PaneIndex, &h.Agent, &h.Model, &ts, &h.Snippet, &info); err != nil {
+			return nil, fmt.Errorf("scan hit: %w", err)
+		}
+		h.Timestamp = time.Unix(0, ts).UTC()
+		h.Score = bm25FromMatchinfo(info)
+		hits = append(hits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if offset >= len(hits) {
+		return nil, nil
+	}
+	hits = hits[offset:]
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// bm25FromMatchinfo scores a row from FTS4 matchinfo(..., 'pcnalx') output
+// with Okapi BM25 (k1=1.2, b=0.75), matching FTS5's bm25() up to sign.
+func bm25FromMatchinfo(info []byte) float64 {
+	if len(info) < 8 || len(info)%4 != 0 {
+		return 0
+	}
+	v := make([]uint32, len(info)/4)
+	for i := range v {
+		v[i] = binary.NativeEndian.Uint32(info[i*4:])
+	}
+
+	const k1, b = 1.2, 0.75
+	p, c := int(v[0]), int(v[1])
+	if len(v) < 3+2*c+3*p*c {
+		return 0
+	}
+	n := float64(v[2])
+	avgLen := v[3 : 3+c]
+	docLen := v[3+c : 3+2*c]
+	x := v[3+2*c:]
+
+	var score float64
+	for phrase := 0; phrase < p; phrase++ {
+		for col := 0; col < c; col++ {
+			base := 3 * (phrase*c + col)
+			tf := float64(x[base])
+			docsWithHit := float64(x[base+2])
+			if tf == 0 {
+				continue
+			}
+			idf := math.Log((n - docsWithHit + 0.5) / (docsWithHit + 0.5))
+			if idf < 1e-6 {
+				idf = 1e-6
+			}
+			avg := math.Max(float64(avgLen[col]), 1)
+			norm := 1 - b + b*float64(docLen[col])/avg
+			score += idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+	}
+	return score
+}
+
+// Around returns the hit's record plus up to before/after neighbouring
+// records from the same pane, in time order.
+func (idx *Index) Around(id int64, before, after int) ([]Record, error) {
+	var session, pane string
+	var ts int64
+	err := idx.db.QueryRow(`SELECT session, pane, ts FROM search_docs WHERE id = ?`, id).Scan(&session, &pane, &ts)
+	if err != nil {
+		return nil, fmt.Errorf("get search doc %d: %w", id, err)
+	}
+	return idx.At(session, pane, time.Unix(0, ts), before, after)
+}
+
+// At returns archived output around a point in time: the last record at or
+// before at plus before earlier and after later records. An empty pane
+// covers every pane in the session.
+func (idx *Index) At(session, pane string, at time.Time, before, after int) ([]Record, error) {
+	if !idx.canIndex(session) {
+		return nil, nil
+	}
+	paneClause := ""
+	args := []interface{}{session}
+	if pane != "" {
+		paneClause = " AND pane = ?"
+		args = append(args, pane)
+	}
+	ts := at.UTC().UnixNano()
+
+	earlier, err := idx.records(fmt.Sprintf(`
+		SELECT id, session, pane, pane_index, agent, model, ts, lines, content
+		FROM search_docs WHERE session = ?%s AND ts <= ?
+		ORDER BY ts DESC, id DESC LIMIT ?`, paneClause), append(args, ts, before+1)...)
+	if err != nil {
+		return nil, err
+	}
+	later, err := idx.records(fmt.Sprintf(`
+		SELECT id, session, pane, pane_index, agent, model, ts, lines, content
+		FROM search_docs WHERE session = ?%s AND ts > ?
+		ORDER BY ts, id LIMIT ?`, paneClause), append(args, ts, after)...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Record, 0, len(earlier)+len(later))
+	for i := len(earlier) - 1; i >= 0; i-- {
+		out = append(out, earlier[i])
+	}
+	return append(out, later...), nil
+}
+
+func (idx *Index) records(query string, args ...interface{}) ([]Record, error) {
+	rows, err := idx.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query search docs: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Record
+	for rows.Next() {
+		var r Record
+		var ts int64
+		if err := rows.Scan(&r.ID, &r.Session, &r.Pane, &r.PaneIndex, &r.Agent, &r.Model, &ts, &r.Lines, &r.Content); err != nil {
+			return nil, fmt.Errorf("scan search doc: %w", err)
+		}
+		r.Timestamp = time.Unix(0, ts).UTC()
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// IndexStats describes the index contents.
+type IndexStats struct {
+	Engine   Engine `json:"engine"`
+	Records  int    `json:"records"`
+	Sessions int    `json:"sessions"`
+}
+
+// Stats returns index statistics.
+func (idx *Index) Stats() (IndexStats, error) {
+	st := IndexStats{Engine: idx.engine}
+	err := idx.db.QueryRow(`SELECT COUNT(*), COUNT(DISTINCT session) FROM search_docs`).Scan(&st.Records, &st.Sessions)
+	if err != nil {
+		return st, fmt.Errorf("index stats: %w", err)
+	}
+	return st, nil
+}
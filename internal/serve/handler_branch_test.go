@@ -8553,6 +8553,23 @@ func TestHandleListPipelines_Empty(t *testing.T) {
 	}
 }
 
+func TestHandleListPipelineGates_Empty(t *testing.T) {
+	t.Parallel()
+	s, _ := setupTestServer(t)
+
+	req := httptest.NewRequest("GET", "/api/v1/pipelines/gates", nil)
+	rec := httptest.NewRecorder()
+
+	s.handleListPipelineGates(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"gates":[]`) {
+		t.Errorf("expected empty gates array, got %s", rec.Body.String())
+	}
+}
+
 
 // --- publishApprovalEvent with nil wsHub (exercises nil guard) ---
 
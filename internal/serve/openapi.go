@@ -8,6 +8,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/Dicklesworthstone/ntm/internal/events"
 	"github.com/Dicklesworthstone/ntm/internal/kernel"
 )
 
@@ -98,6 +99,7 @@ type ExampleRef struct {
 
 // Schema describes a JSON Schema.
 type Schema struct {
+	Title                string             `json:"title,omitempty"`
 	Type                 string             `json:"type,omitempty"`
 	Format               string             `json:"format,omitempty"`
 	Description          string             `json:"description,omitempty"`
@@ -106,6 +108,8 @@ type Schema struct {
 	Items                *Schema            `json:"items,omitempty"`
 	Ref                  string             `json:"$ref,omitempty"`
 	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Const                any                `json:"const,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
 }
 
 // OpenAPIComponents holds reusable components.
@@ -174,6 +178,8 @@ func GenerateOpenAPISpec(version, serverURL string) *OpenAPISpec {
 		},
 	}
 
+	addEventSchemas(spec.Components.Schemas)
+
 	// Collect tags from categories
 	tagSet := make(map[string]bool)
 
@@ -305,6 +311,48 @@ func GenerateOpenAPISpec(version, serverURL string) *OpenAPISpec {
 	return spec
 }
 
+// addEventSchemas publishes the event schema registry as components so /events
+// and /ws consumers can generate types. Each event type becomes Event.<type>,
+// and BusEvent is the union of all of them.
+func addEventSchemas(schemas map[string]*Schema) {
+	union := &Schema{
+		Description: "Any event published on the NTM event bus, discriminated by type.",
+	}
+	for _, es := range events.EventSchemas() {
+		name := "Event." + es.Type
+		s := convertEventSchema(es.Schema)
+		s.Title = fmt.Sprintf("%s (v%d)", es.Type, es.Version)
+		schemas[name] = s
+		union.OneOf = append(union.OneOf, &Schema{Ref: "#/components/schemas/" + name})
+	}
+	schemas["BusEvent"] = union
+}
+
+// convertEventSchema maps an events.JSONSchema onto the OpenAPI schema type.
+func convertEventSchema(js *events.JSONSchema) *Schema {
+	if js == nil {
+		return nil
+	}
+	s := &Schema{
+		Type:        js.Type,
+		Format:      js.Format,
+		Description: js.Description,
+		Const:       js.Const,
+		Required:    js.Required,
+		Items:       convertEventSchema(js.Items),
+	}
+	if js.AdditionalProperties != nil {
+		s.AdditionalProperties = convertEventSchema(js.AdditionalProperties)
+	}
+	if len(js.Properties) > 0 {
+		s.Properties = make(map[string]*Schema, len(js.Properties))
+		for name, prop := range js.Properties {
+			s.Properties[name] = convertEventSchema(prop)
+		}
+	}
+	return s
+}
+
 // normalizePathForOpenAPI converts paths like /sessions/{sessionId} to OpenAPI format.
 func normalizePathForOpenAPI(path string) string {
 	// Ensure path starts with /api/v1 prefix if not already present
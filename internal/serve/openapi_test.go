@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/Dicklesworthstone/ntm/internal/events"
 	"github.com/Dicklesworthstone/ntm/internal/kernel"
 )
 
@@ -336,6 +337,36 @@ func TestSecuritySchemes(t *testing.T) {
 	}
 }
 
+func TestEventSchemasInSpec(t *testing.T) {
+	spec := GenerateOpenAPISpec("dev", "http://localhost:8080")
+	schemas := spec.Components.Schemas
+
+	stall, ok := schemas["Event.agent_stall"]
+	if !ok {
+		t.Fatal("expected Event.agent_stall schema")
+	}
+	if stall.Title != "agent_stall (v1)" || stall.Properties["type"].Const != "agent_stall" {
+		t.Errorf("Event.agent_stall title/type = %q/%v", stall.Title, stall.Properties["type"].Const)
+	}
+	if stall.Properties["stall_duration_sec"].Type != "number" {
+		t.Errorf("stall_duration_sec type = %q", stall.Properties["stall_duration_sec"].Type)
+	}
+
+	union, ok := schemas["BusEvent"]
+	if !ok {
+		t.Fatal("expected BusEvent schema")
+	}
+	if len(union.OneOf) != len(events.EventSchemas()) {
+		t.Errorf("BusEvent oneOf has %d entries, want %d", len(union.OneOf), len(events.EventSchemas()))
+	}
+	for _, ref := range union.OneOf {
+		name := strings.TrimPrefix(ref.Ref, "#/components/schemas/")
+		if _, ok := schemas[name]; !ok {
+			t.Errorf("BusEvent references missing schema %q", ref.Ref)
+		}
+	}
+}
+
 func TestPathItemMethodsAreExclusive(t *testing.T) {
 	spec := GenerateOpenAPISpec("1.0.0", "http://localhost:8080")
 
@@ -80,6 +80,9 @@ func (s *Server) registerPipelineRoutes(r chi.Router) {
 		// List available workflow templates (read permission)
 		r.With(s.RequirePermission(PermReadPipelines)).Get("/templates", s.handleListPipelineTemplates)
 
+		// List approval gates that paused pipelines are waiting on (read permission)
+		r.With(s.RequirePermission(PermReadPipelines)).Get("/gates", s.handleListPipelineGates)
+
 		// Cleanup old pipeline state files (dangerous operation - admin only)
 		r.With(s.RequirePermission(PermDangerousOps)).Post("/cleanup", s.handleCleanupPipelines)
 
@@ -163,6 +166,7 @@ func (s *Server) handleRunPipeline(w http.ResponseWriter, r *http.Request) {
 		Variables:    req.Variables,
 		DryRun:       req.DryRun,
 		Background:   req.Background,
+		BudgetCheck:  s.pipelineBudgetCheck,
 	}
 
 	// Use the pipeline robot API which handles everything
@@ -460,6 +464,32 @@ func (s *Server) handleListPipelineTemplates(w http.ResponseWriter, r *http.Requ
 	}, reqID)
 }
 
+// handleListPipelineGates handles GET /api/v1/pipelines/gates
+func (s *Server) handleListPipelineGates(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+
+	slog.Info("pipeline gates list", "request_id", reqID)
+
+	projectDir, _ := os.Getwd()
+	gates, err := pipeline.ListPendingGates(projectDir)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to list pending gates", map[string]interface{}{
+			"error": err.Error(),
+		}, reqID)
+		return
+	}
+
+	// Ensure never null
+	if gates == nil {
+		gates = []pipeline.PendingGate{}
+	}
+
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"gates": gates,
+		"count": len(gates),
+	}, reqID)
+}
+
 // handleCleanupPipelines handles POST /api/v1/pipelines/cleanup
 func (s *Server) handleCleanupPipelines(w http.ResponseWriter, r *http.Request) {
 	reqID := requestIDFromContext(r.Context())
@@ -514,6 +544,8 @@ func pipelineEventTypeFromProgressType(progressType string) (string, bool) {
 		return "pipeline.step_completed", true
 	case "step_error":
 		return "pipeline.step_failed", true
+	case "step_approval":
+		return "pipeline.approval_pending", true
 	case "workflow_complete":
 		return "pipeline.complete", true
 	case "workflow_error":
@@ -559,8 +591,12 @@ func (s *Server) runPipelineWithResult(ctx context.Context, opts pipeline.Pipeli
 	config.ProjectDir = projectDir
 	config.WorkflowFile = workflowPath
 	config.RunID = pipeline.GenerateRunID()
+	config.BudgetCheck = opts.BudgetCheck
 
 	executor := pipeline.NewExecutor(config)
+	if s.promptQueue != nil {
+		executor.SetPromptQueue(s.promptQueue)
+	}
 
 	if opts.DryRun {
 		validation := executor.Validate(workflow)
@@ -681,8 +717,12 @@ func (s *Server) execPipelineInline(ctx context.Context, workflow *pipeline.Work
 	projectDir, _ := os.Getwd()
 	config.ProjectDir = projectDir
 	config.RunID = pipeline.GenerateRunID()
+	config.BudgetCheck = s.pipelineBudgetCheck
 
 	executor := pipeline.NewExecutor(config)
+	if s.promptQueue != nil {
+		executor.SetPromptQueue(s.promptQueue)
+	}
 
 	output.RobotResponse = pipeline.NewRobotResponse(true)
 	output.RunID = config.RunID
@@ -802,8 +842,12 @@ func (s *Server) resumePipelineWithResult(ctx context.Context, runID, session st
 	config.ProjectDir = projectDir
 	config.WorkflowFile = state.WorkflowFile
 	config.RunID = runID
+	config.BudgetCheck = s.pipelineBudgetCheck
 
 	executor := pipeline.NewExecutor(config)
+	if s.promptQueue != nil {
+		executor.SetPromptQueue(s.promptQueue)
+	}
 
 	// Merge variables
 	vars := state.Variables
@@ -0,0 +1,169 @@
+// queue.go implements the /api/v1/queue endpoints over the per-pane prompt queue.
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Dicklesworthstone/ntm/internal/inbox"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/go-chi/chi/v5"
+)
+
+// QueueEnqueueRequest is the request body for POST /api/v1/queue.
+type QueueEnqueueRequest struct {
+	Session string `json:"session"`
+	Pane    int    `json:"pane"`
+	Prompt  string `json:"prompt"`
+}
+
+// QueueMoveRequest is the request body for PATCH /api/v1/queue/{id}.
+type QueueMoveRequest struct {
+	Position int `json:"position"`
+}
+
+// registerQueueRoutes registers prompt queue routes.
+func (s *Server) registerQueueRoutes(r chi.Router) {
+	r.Route("/queue", func(r chi.Router) {
+		r.With(s.RequirePermission(PermReadAgents)).Get("/", s.handleQueueListV1)
+		r.With(s.RequirePermission(PermWriteAgents)).Post("/", s.handleQueueEnqueueV1)
+		r.With(s.RequirePermission(PermReadAgents)).Get("/{id}", s.handleQueueGetV1)
+		r.With(s.RequirePermission(PermWriteAgents)).Patch("/{id}", s.handleQueueMoveV1)
+		r.With(s.RequirePermission(PermWriteAgents)).Delete("/{id}", s.handleQueueCancelV1)
+	})
+}
+
+// queueStore returns the state store, writing an error if there is none.
+func (s *Server) queueStore(w http.ResponseWriter, reqID string) *state.Store {
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+	}
+	return s.stateStore
+}
+
+// handleQueueListV1 handles GET /api/v1/queue.
+//
+// Query parameters: session, pane and all (include finished prompts).
+func (s *Server) handleQueueListV1(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	params := r.URL.Query()
+
+	pane := -1
+	if v := params.Get("pane"); v != "" {
+		var err error
+		if pane, err = strconv.Atoi(v); err != nil || pane < 0 {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid pane", nil, reqID)
+			return
+		}
+	}
+	all, _ := strconv.ParseBool(params.Get("all"))
+
+	store := s.queueStore(w, reqID)
+	if store == nil {
+		return
+	}
+	prompts, err := store.ListQueuedPrompts(params.Get("session"), pane, all)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
+		return
+	}
+	if prompts == nil {
+		prompts = []state.QueuedPrompt{}
+	}
+
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"count":   len(prompts),
+		"prompts": prompts,
+	}, reqID)
+}
+
+// handleQueueEnqueueV1 handles POST /api/v1/queue.
+func (s *Server) handleQueueEnqueueV1(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+
+	var req QueueEnqueueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid request body", nil, reqID)
+		return
+	}
+
+	store := s.queueStore(w, reqID)
+	if store == nil {
+		return
+	}
+	p, err := inbox.Enqueue(store, req.Session, req.Pane, req.Prompt, inbox.SourceAPI)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, err.Error(), nil, reqID)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, map[string]interface{}{"prompt": p}, reqID)
+}
+
+// handleQueueGetV1 handles GET /api/v1/queue/{id}.
+func (s *Server) handleQueueGetV1(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+
+	store := s.queueStore(w, reqID)
+	if store == nil {
+		return
+	}
+	p, err := store.GetQueuedPrompt(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
+		return
+	}
+	if p == nil {
+		writeErrorResponse(w, http.StatusNotFound, ErrCodeNotFound, "queued prompt not found", nil, reqID)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{"prompt": p}, reqID)
+}
+
+// handleQueueMoveV1 handles PATCH /api/v1/queue/{id}, moving a pending
+// prompt to a new position in its pane's queue.
+func (s *Server) handleQueueMoveV1(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	var req QueueMoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Position < 1 {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "position must be a positive integer", nil, reqID)
+		return
+	}
+
+	store := s.queueStore(w, reqID)
+	if store == nil {
+		return
+	}
+	if err := store.MoveQueuedPrompt(id, req.Position); err != nil {
+		writeErrorResponse(w, http.StatusConflict, ErrCodeConflict, err.Error(), nil, reqID)
+		return
+	}
+	p, err := store.GetQueuedPrompt(id)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{"prompt": p}, reqID)
+}
+
+// handleQueueCancelV1 handles DELETE /api/v1/queue/{id}.
+func (s *Server) handleQueueCancelV1(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	store := s.queueStore(w, reqID)
+	if store == nil {
+		return
+	}
+	if err := store.CancelQueuedPrompt(id); err != nil {
+		writeErrorResponse(w, http.StatusConflict, ErrCodeConflict, err.Error(), nil, reqID)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{"id": id, "status": state.QueuedCancelled}, reqID)
+}
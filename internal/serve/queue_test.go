@@ -0,0 +1,77 @@
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupQueueRouter(t *testing.T, srv *Server) http.Handler {
+	t.Helper()
+	srv.auth = AuthConfig{Mode: AuthModeLocal}
+	r := chi.NewRouter()
+	r.Use(srv.requestIDMiddlewareFunc)
+	r.Use(srv.rbacMiddleware)
+	r.Route("/api/v1", func(r chi.Router) {
+		srv.registerQueueRoutes(r)
+	})
+	return r
+}
+
+func doQueueRequest(t *testing.T, h http.Handler, method, url, body string, want int) map[string]interface{} {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, url, strings.NewReader(body)))
+	if rec.Code != want {
+		t.Fatalf("%s %s: status = %d, want %d; body: %s", method, url, rec.Code, want, rec.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return resp
+}
+
+func TestQueueRoutes(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	h := setupQueueRouter(t, srv)
+
+	var ids []string
+	for _, prompt := range []string{"first", "second"} {
+		resp := doQueueRequest(t, h, http.MethodPost, "/api/v1/queue", `{"session":"proj","pane":2,"prompt":"`+prompt+`"}`, http.StatusCreated)
+		ids = append(ids, resp["prompt"].(map[string]interface{})["id"].(string))
+	}
+	doQueueRequest(t, h, http.MethodPost, "/api/v1/queue", `{"session":"proj","pane":2,"prompt":""}`, http.StatusBadRequest)
+
+	resp := doQueueRequest(t, h, http.MethodPatch, "/api/v1/queue/"+ids[1], `{"position":1}`, http.StatusOK)
+	if pos := resp["prompt"].(map[string]interface{})["position"].(float64); pos != 1 {
+		t.Errorf("moved position = %v, want 1", pos)
+	}
+
+	doQueueRequest(t, h, http.MethodDelete, "/api/v1/queue/"+ids[0], "", http.StatusOK)
+	doQueueRequest(t, h, http.MethodDelete, "/api/v1/queue/"+ids[0], "", http.StatusConflict)
+
+	resp = doQueueRequest(t, h, http.MethodGet, "/api/v1/queue?session=proj&pane=2", "", http.StatusOK)
+	if resp["count"].(float64) != 1 {
+		t.Errorf("pending count = %v, want 1", resp["count"])
+	}
+	resp = doQueueRequest(t, h, http.MethodGet, "/api/v1/queue?session=proj&all=true", "", http.StatusOK)
+	if resp["count"].(float64) != 2 {
+		t.Errorf("all count = %v, want 2", resp["count"])
+	}
+
+	resp = doQueueRequest(t, h, http.MethodGet, "/api/v1/queue/"+ids[0], "", http.StatusOK)
+	if st := resp["prompt"].(map[string]interface{})["status"]; st != "cancelled" {
+		t.Errorf("status = %v, want cancelled", st)
+	}
+	doQueueRequest(t, h, http.MethodGet, "/api/v1/queue/q-missing", "", http.StatusNotFound)
+}
+
+func TestQueueRoutes_NoStateStore(t *testing.T) {
+	h := setupQueueRouter(t, &Server{wsHub: NewWSHub()})
+	doQueueRequest(t, h, http.MethodGet, "/api/v1/queue", "", http.StatusServiceUnavailable)
+}
@@ -0,0 +1,100 @@
+// responses.go implements the /api/v1/responses endpoints over captured agent replies.
+package serve
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/robot"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxResponsesWait bounds the ?wait= parameter of the responses endpoints.
+const maxResponsesWait = 10 * time.Minute
+
+// registerResponsesRoutes registers agent reply routes.
+func (s *Server) registerResponsesRoutes(r chi.Router) {
+	r.Route("/responses", func(r chi.Router) {
+		r.With(s.RequirePermission(PermReadSessions)).Get("/", s.handleResponsesListV1)
+		r.With(s.RequirePermission(PermReadSessions)).Get("/{historyId}", s.handleResponsesV1)
+	})
+}
+
+// handleResponsesListV1 handles GET /api/v1/responses.
+//
+// Query parameters: session (required), pane and limit.
+func (s *Server) handleResponsesListV1(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+
+	session := r.URL.Query().Get("session")
+	if session == "" {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "session parameter required", nil, reqID)
+		return
+	}
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	s.writeResponses(w, r, robot.ResponsesOptions{Session: session, Limit: limit})
+}
+
+// handleResponsesV1 handles GET /api/v1/responses/{historyId}.
+//
+// Query parameters: pane, and wait (a duration to wait for agents still
+// replying, at most 10m).
+func (s *Server) handleResponsesV1(w http.ResponseWriter, r *http.Request) {
+	s.writeResponses(w, r, robot.ResponsesOptions{HistoryID: chi.URLParam(r, "historyId")})
+}
+
+func (s *Server) writeResponses(w http.ResponseWriter, r *http.Request, opts robot.ResponsesOptions) {
+	reqID := requestIDFromContext(r.Context())
+	params := r.URL.Query()
+
+	opts.Pane = -1
+	if v := params.Get("pane"); v != "" {
+		pane, err := strconv.Atoi(v)
+		if err != nil || pane < 0 {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid pane", nil, reqID)
+			return
+		}
+		opts.Pane = pane
+	}
+	if v := params.Get("wait"); v != "" {
+		wait, err := time.ParseDuration(v)
+		if err != nil || wait < 0 {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid wait duration", nil, reqID)
+			return
+		}
+		opts.Wait = min(wait, maxResponsesWait)
+	}
+
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return
+	}
+	opts.Store = s.stateStore
+
+	result, err := robot.GetResponses(opts)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
+		return
+	}
+	if !result.Success {
+		if result.ErrorCode == robot.ErrCodeInvalidFlag {
+			writeErrorResponse(w, http.StatusNotFound, ErrCodeNotFound, result.Error, nil, reqID)
+		} else {
+			writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, result.Error, nil, reqID)
+		}
+		return
+	}
+
+	data, err := toJSONMap(result)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to serialize response", nil, reqID)
+		return
+	}
+	writeSuccessResponse(w, http.StatusOK, data, reqID)
+}
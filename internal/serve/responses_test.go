@@ -0,0 +1,61 @@
+package serve
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+func setupResponsesRouter(t *testing.T, srv *Server) http.Handler {
+	t.Helper()
+	srv.auth = AuthConfig{Mode: AuthModeLocal}
+	r := chi.NewRouter()
+	r.Use(srv.requestIDMiddlewareFunc)
+	r.Use(srv.rbacMiddleware)
+	r.Route("/api/v1", func(r chi.Router) {
+		srv.registerResponsesRoutes(r)
+	})
+	return r
+}
+
+func TestResponsesRoutes(t *testing.T) {
+	srv, store := setupTestServer(t)
+	h := setupResponsesRouter(t, srv)
+
+	err := store.CreateResponse(&state.AgentResponse{
+		ID: "r-1", HistoryID: "h1", Session: "proj", Pane: 1, PaneID: "%999999",
+		AgentType: "cc", Prompt: "fix it",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.FinishResponse("r-1", state.ResponseCaptured, "Fixed.", nil, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := doQueueRequest(t, h, http.MethodGet, "/api/v1/responses/h1", "", http.StatusOK)
+	responses := resp["responses"].([]interface{})
+	if len(responses) != 1 {
+		t.Fatalf("responses = %v, want 1", responses)
+	}
+	if r := responses[0].(map[string]interface{}); r["text"] != "Fixed." || r["status"] != "captured" {
+		t.Errorf("response = %v", r)
+	}
+
+	resp = doQueueRequest(t, h, http.MethodGet, "/api/v1/responses?session=proj", "", http.StatusOK)
+	if len(resp["responses"].([]interface{})) != 1 {
+		t.Errorf("session responses = %v, want 1", resp["responses"])
+	}
+
+	doQueueRequest(t, h, http.MethodGet, "/api/v1/responses/h-missing", "", http.StatusNotFound)
+	doQueueRequest(t, h, http.MethodGet, "/api/v1/responses/h1?pane=x", "", http.StatusBadRequest)
+	doQueueRequest(t, h, http.MethodGet, "/api/v1/responses", "", http.StatusBadRequest)
+}
+
+func TestResponsesRoutes_NoStateStore(t *testing.T) {
+	h := setupResponsesRouter(t, &Server{wsHub: NewWSHub()})
+	doQueueRequest(t, h, http.MethodGet, "/api/v1/responses/h1", "", http.StatusServiceUnavailable)
+}
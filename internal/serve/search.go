@@ -0,0 +1,203 @@
+// search.go implements the /api/v1/search endpoints over archived pane output.
+package serve
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/archive"
+	"github.com/Dicklesworthstone/ntm/internal/search"
+	"github.com/Dicklesworthstone/ntm/internal/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxSearchContext caps the records returned on either side of a hit.
+const maxSearchContext = 50
+
+// registerSearchRoutes registers archive search routes.
+func (s *Server) registerSearchRoutes(r chi.Router) {
+	r.Route("/search", func(r chi.Router) {
+		r.With(s.RequirePermission(PermReadSessions)).Get("/", s.handleSearchV1)
+		r.With(s.RequirePermission(PermReadSessions)).Get("/context", s.handleSearchContextV1)
+	})
+}
+
+// getSearchIndex returns the archive search index, opening the default one
+// on first use and catching it up on archive files written since.
+func (s *Server) getSearchIndex() (*search.Index, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.searchIndex == nil {
+		idx, err := search.Open("")
+		if err != nil {
+			return nil, err
+		}
+		s.searchIndex = idx
+		s.searchIngestDir = util.ExpandPath(archive.DefaultOutputDir)
+	}
+	if s.searchIngestDir != "" {
+		if _, err := s.searchIndex.IngestDir(s.searchIngestDir); err != nil {
+			log.Printf("REST: search ingest failed dir=%s error=%v", s.searchIngestDir, err)
+		}
+	}
+	return s.searchIndex, nil
+}
+
+// handleSearchV1 handles GET /api/v1/search.
+//
+// Query parameters: q (required), session, agent, pane, since and until
+// (RFC3339 or a duration like 2h), limit and offset.
+func (s *Server) handleSearchV1(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	params := r.URL.Query()
+
+	q := search.Query{
+		Text:    params.Get("q"),
+		Session: params.Get("session"),
+		Agent:   params.Get("agent"),
+		Pane:    params.Get("pane"),
+	}
+	if q.Text == "" {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "q parameter required", nil, reqID)
+		return
+	}
+	var err error
+	if q.Since, err = parseSearchTimeParam(params.Get("since")); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid since: "+err.Error(), nil, reqID)
+		return
+	}
+	if q.Until, err = parseSearchTimeParam(params.Get("until")); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid until: "+err.Error(), nil, reqID)
+		return
+	}
+	if v := params.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit < 1 || q.Limit > 1000 {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "limit must be 1-1000", nil, reqID)
+			return
+		}
+	}
+	if v := params.Get("offset"); v != "" {
+		if q.Offset, err = strconv.Atoi(v); err != nil || q.Offset < 0 {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid offset", nil, reqID)
+			return
+		}
+	}
+
+	idx, err := s.getSearchIndex()
+	if err != nil {
+		log.Printf("REST: search index open failed error=%v request_id=%s", err, reqID)
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeSearchFailed, "search index unavailable", nil, reqID)
+		return
+	}
+	hits, err := idx.Search(q)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeSearchFailed, err.Error(), nil, reqID)
+		return
+	}
+	if hits == nil {
+		hits = []search.Hit{}
+	}
+
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"query":           q.Text,
+		"engine":          idx.Engine(),
+		"count":           len(hits),
+		"hits":            hits,
+		"highlight_start": search.HighlightStart,
+		"highlight_end":   search.HighlightEnd,
+	}, reqID)
+}
+
+// handleSearchContextV1 handles GET /api/v1/search/context, returning the
+// archived output around a hit (id) or a point in time (session and at).
+// before and after default to 3 records.
+func (s *Server) handleSearchContextV1(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	params := r.URL.Query()
+
+	before, err := searchContextParam(params.Get("before"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid before", nil, reqID)
+		return
+	}
+	after, err := searchContextParam(params.Get("after"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid after", nil, reqID)
+		return
+	}
+
+	var id int64
+	var at time.Time
+	session := params.Get("session")
+	switch {
+	case params.Get("id") != "":
+		if id, err = strconv.ParseInt(params.Get("id"), 10, 64); err != nil || id <= 0 {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid id", nil, reqID)
+			return
+		}
+	case session != "" && params.Get("at") != "":
+		if at, err = parseSearchTimeParam(params.Get("at")); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid at: "+err.Error(), nil, reqID)
+			return
+		}
+	default:
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "id, or session and at, required", nil, reqID)
+		return
+	}
+
+	idx, err := s.getSearchIndex()
+	if err != nil {
+		log.Printf("REST: search index open failed error=%v request_id=%s", err, reqID)
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeSearchFailed, "search index unavailable", nil, reqID)
+		return
+	}
+
+	var records []search.Record
+	if id > 0 {
+		records, err = idx.Around(id, before, after)
+	} else {
+		records, err = idx.At(session, params.Get("pane"), at, before, after)
+	}
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, ErrCodeNotFound, err.Error(), nil, reqID)
+		return
+	}
+	if records == nil {
+		records = []search.Record{}
+	}
+
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"count":   len(records),
+		"records": records,
+	}, reqID)
+}
+
+// parseSearchTimeParam parses an RFC3339 time or a duration before now.
+// An empty value yields the zero time.
+func parseSearchTimeParam(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	d, err := util.ParseDuration(v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(-d), nil
+}
+
+func searchContextParam(v string) (int, error) {
+	if v == "" {
+		return 3, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, strconv.ErrSyntax
+	}
+	return min(n, maxSearchContext), nil
+}
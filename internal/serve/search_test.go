@@ -0,0 +1,121 @@
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/archive"
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/privacy"
+	"github.com/Dicklesworthstone/ntm/internal/search"
+)
+
+func setupSearchServer(t *testing.T) (*Server, *privacy.Manager) {
+	t.Helper()
+	idx, err := search.Open(filepath.Join(t.TempDir(), "search.db"))
+	if err != nil {
+		t.Fatalf("search.Open: %v", err)
+	}
+	t.Cleanup(func() { idx.Close() })
+	pm := privacy.New(config.PrivacyConfig{})
+	idx.SetPrivacyManager(pm)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, content := range []string{"go test ./...", "panic: nil pointer dereference", "fixed the nil check"} {
+		rec := &archive.ArchiveRecord{
+			Session: "proj", Pane: "cod_2", PaneIndex: 2, Agent: "cod",
+			Timestamp: base.Add(time.Duration(i) * time.Minute), Content: content, Lines: 1, Sequence: i + 1,
+		}
+		if err := idx.IndexRecord(rec); err != nil {
+			t.Fatalf("IndexRecord: %v", err)
+		}
+	}
+	return New(Config{SearchIndex: idx}), pm
+}
+
+func decodeSearchResponse(t *testing.T, rec *httptest.ResponseRecorder, want int) map[string]interface{} {
+	t.Helper()
+	if rec.Code != want {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, want, rec.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return resp
+}
+
+func TestHandleSearchV1(t *testing.T) {
+	t.Parallel()
+	srv, pm := setupSearchServer(t)
+
+	rec := httptest.NewRecorder()
+	srv.handleSearchV1(rec, httptest.NewRequest(http.MethodGet, "/api/v1/search?q=nil+pointer&agent=cod&since=2026-03-01T00:00:00Z", nil))
+	resp := decodeSearchResponse(t, rec, http.StatusOK)
+	hits, _ := resp["hits"].([]interface{})
+	if len(hits) != 1 {
+		t.Fatalf("hits = %v, want 1", resp["hits"])
+	}
+	hit := hits[0].(map[string]interface{})
+	if hit["session"] != "proj" || hit["snippet"] == "" {
+		t.Errorf("hit = %v", hit)
+	}
+
+	for _, url := range []string{"/api/v1/search", "/api/v1/search?q=x&since=bogus", "/api/v1/search?q=x&limit=0"} {
+		rec := httptest.NewRecorder()
+		srv.handleSearchV1(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", url, rec.Code)
+		}
+	}
+
+	pm.RegisterSession("proj", true, false)
+	rec = httptest.NewRecorder()
+	srv.handleSearchV1(rec, httptest.NewRequest(http.MethodGet, "/api/v1/search?q=nil", nil))
+	if resp := decodeSearchResponse(t, rec, http.StatusOK); resp["count"].(float64) != 0 {
+		t.Errorf("privacy-mode session returned: %v", resp["hits"])
+	}
+}
+
+func TestHandleSearchContextV1(t *testing.T) {
+	t.Parallel()
+	srv, _ := setupSearchServer(t)
+
+	rec := httptest.NewRecorder()
+	srv.handleSearchV1(rec, httptest.NewRequest(http.MethodGet, "/api/v1/search?q=panic", nil))
+	hit := decodeSearchResponse(t, rec, http.StatusOK)["hits"].([]interface{})[0].(map[string]interface{})
+	id := strconv.FormatInt(int64(hit["id"].(float64)), 10)
+
+	rec = httptest.NewRecorder()
+	srv.handleSearchContextV1(rec, httptest.NewRequest(http.MethodGet, "/api/v1/search/context?id="+id+"&before=1&after=0", nil))
+	records := decodeSearchResponse(t, rec, http.StatusOK)["records"].([]interface{})
+	if len(records) != 2 || records[1].(map[string]interface{})["content"] != "panic: nil pointer dereference" {
+		t.Errorf("records = %v", records)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleSearchContextV1(rec, httptest.NewRequest(http.MethodGet, "/api/v1/search/context?session=proj&at=2026-03-01T12:02:30Z&before=0&after=0", nil))
+	records = decodeSearchResponse(t, rec, http.StatusOK)["records"].([]interface{})
+	if len(records) != 1 || records[0].(map[string]interface{})["content"] != "fixed the nil check" {
+		t.Errorf("records at time = %v", records)
+	}
+
+	for _, url := range []string{"/api/v1/search/context", "/api/v1/search/context?id=abc", "/api/v1/search/context?session=proj"} {
+		rec := httptest.NewRecorder()
+		srv.handleSearchContextV1(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", url, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleSearchContextV1(rec, httptest.NewRequest(http.MethodGet, "/api/v1/search/context?id=999", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown id: status = %d, want 404", rec.Code)
+	}
+}
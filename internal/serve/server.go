@@ -34,10 +34,14 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/config"
 	"github.com/Dicklesworthstone/ntm/internal/ensemble"
 	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/inbox"
 	"github.com/Dicklesworthstone/ntm/internal/kernel"
 	"github.com/Dicklesworthstone/ntm/internal/metrics"
+	"github.com/Dicklesworthstone/ntm/internal/pipeline"
 	"github.com/Dicklesworthstone/ntm/internal/redaction"
+	"github.com/Dicklesworthstone/ntm/internal/reply"
 	"github.com/Dicklesworthstone/ntm/internal/robot"
+	"github.com/Dicklesworthstone/ntm/internal/search"
 	"github.com/Dicklesworthstone/ntm/internal/state"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 	"github.com/go-chi/chi/v5"
@@ -84,6 +88,22 @@ type Server struct {
 
 	// Redaction configuration for REST API
 	redactionCfg *RedactionConfig
+
+	// Archive search index (lazy-init unless configured)
+	searchIndex     *search.Index
+	searchIngestDir string
+
+	// Spend budget check run before each pipeline step
+	pipelineBudgetCheck pipeline.BudgetCheckFunc
+
+	// Delivers queued prompts while the server runs
+	promptQueue *inbox.Dispatcher
+
+	// Captures agent replies to sent prompts while the server runs
+	responseCapture *reply.Capturer
+
+	// Target and verification command for API-started merge queue runs
+	mergeQueue config.MergeQueueConfig
 }
 
 // AuthMode configures authentication for the server.
@@ -131,6 +151,23 @@ type Config struct {
 	Auth          AuthConfig
 	// AllowedOrigins controls CORS origin allowlist. Empty means default localhost only.
 	AllowedOrigins []string
+	// SearchIndex serves /api/v1/search. Nil opens the default index on first use.
+	SearchIndex *search.Index
+	// PipelineBudgetCheck, if set, runs before each pipeline step and fails
+	// the step when it returns an error.
+	PipelineBudgetCheck pipeline.BudgetCheckFunc
+	// PromptQueue, if set, delivers queued prompts to idle agents for as
+	// long as the server runs.
+	PromptQueue *inbox.Dispatcher
+	// ResponseCapture, if set, captures agents' replies to prompts sent with
+	// ntm send for as long as the server runs.
+	ResponseCapture *reply.Capturer
+	// MergeQueue holds the target and verification command for merge queue
+	// runs started over the API.
+	MergeQueue config.MergeQueueConfig
+	// TmuxControlMode streams pane output over tmux control-mode
+	// connections instead of pipe-pane.
+	TmuxControlMode bool
 }
 
 const (
@@ -767,10 +804,17 @@ func New(cfg Config) *Server {
 		idempotencyStore:   NewIdempotencyStore(24 * time.Hour),
 		jobStore:           NewJobStore(),
 		wsHub:              NewWSHub(),
+		searchIndex:        cfg.SearchIndex,
+
+		pipelineBudgetCheck: cfg.PipelineBudgetCheck,
+		promptQueue:         cfg.PromptQueue,
+		responseCapture:     cfg.ResponseCapture,
+		mergeQueue:          cfg.MergeQueue,
 	}
 
 	// Initialize pane output streaming
 	streamCfg := tmux.DefaultPaneStreamerConfig()
+	streamCfg.ControlMode = cfg.TmuxControlMode
 	s.streamManager = tmux.NewStreamManager(tmux.DefaultClient, func(event tmux.StreamEvent) {
 		// Publish pane output to WebSocket subscribers
 		// Topic format: panes:session:pane_idx
@@ -913,6 +957,18 @@ func (s *Server) buildRouter() chi.Router {
 		// Checkpoint and Rollback API
 		s.registerCheckpointRoutes(r)
 
+		// Archive Search API - full-text search over archived pane output
+		s.registerSearchRoutes(r)
+
+		// Prompt Queue API - prompts held until agents are idle
+		s.registerQueueRoutes(r)
+
+		// Responses API - agents' captured replies to sent prompts
+		s.registerResponsesRoutes(r)
+
+		// Worktrees API - test-gated merge queue for agent branches
+		s.registerWorktreesRoutes(r)
+
 		// Metrics API - performance and analytics data
 		r.Route("/metrics", func(r chi.Router) {
 			r.With(s.RequirePermission(PermReadHealth)).Get("/", s.handleMetricsV1)
@@ -1015,6 +1071,20 @@ func (s *Server) Start(ctx context.Context) error {
 		defer unsubscribe()
 	}
 
+	// Deliver queued prompts until shutdown
+	if s.promptQueue != nil {
+		queueCtx, stopQueue := context.WithCancel(ctx)
+		defer stopQueue()
+		go s.promptQueue.Run(queueCtx)
+	}
+
+	// Capture agent replies until shutdown
+	if s.responseCapture != nil {
+		captureCtx, stopCapture := context.WithCancel(ctx)
+		defer stopCapture()
+		go s.responseCapture.Run(captureCtx)
+	}
+
 	s.server = &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", s.host, s.port),
 		Handler:      s.router,
@@ -1632,12 +1702,24 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleEventStream handles SSE event streaming at /events.
+//
+// When the event bus has a durable log, each event carries its log offset as
+// the SSE id and clients can resume: the Last-Event-ID header (sent by
+// EventSource on reconnect) or ?since=<offset> replays from that point, and
+// ?consumer=<name> resumes a named consumer group and acknowledges each
+// event once written.
 func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	var eventLog *events.EventLog
+	if s.eventBus != nil {
+		eventLog = s.eventBus.Log()
+	}
+	if eventLog != nil {
+		s.streamLoggedEvents(w, r, eventLog)
+		return
+	}
+
 	// Set SSE headers
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+	setSSEHeaders(w)
 
 	// Create client channel
 	clientCh := make(chan events.BusEvent, 100)
@@ -1652,8 +1734,7 @@ func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Send initial connection event
-	fmt.Fprintf(w, "event: connected\ndata: {\"status\":\"connected\",\"time\":\"%s\"}\n\n",
-		time.Now().UTC().Format(time.RFC3339))
+	writeSSEConnected(w)
 	flusher.Flush()
 
 	// Stream events
@@ -1663,20 +1744,144 @@ func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
 		case <-ctx.Done():
 			return
 		case event := <-clientCh:
-			data, err := json.Marshal(map[string]interface{}{
-				"type":      event.EventType(),
-				"timestamp": event.EventTimestamp().Format(time.RFC3339),
-				"session":   event.EventSession(),
-			})
-			if err != nil {
+			if writeSSEEvent(w, "", event) {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// loggedEvent is an event read from the durable log with its offset.
+type loggedEvent struct {
+	offset uint64
+	event  events.BusEvent
+}
+
+// streamLoggedEvents serves /events from the durable event log.
+func (s *Server) streamLoggedEvents(w http.ResponseWriter, r *http.Request, eventLog *events.EventLog) {
+	start, group, err := eventStreamStart(r, eventLog)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	setSSEHeaders(w)
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	// Block the follower rather than dropping events for a slow client; the
+	// log keeps its place.
+	ctx := r.Context()
+	ch := make(chan loggedEvent, 100)
+	unsubscribe, err := s.eventBus.SubscribeFrom(start, func(offset uint64, e events.BusEvent) {
+		select {
+		case ch <- loggedEvent{offset: offset, event: e}:
+		case <-ctx.Done():
+		}
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer unsubscribe()
+
+	writeSSEConnected(w)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if group != "" {
+				_ = eventLog.Flush()
+			}
+			return
+		case le := <-ch:
+			if !writeSSEEvent(w, strconv.FormatUint(le.offset, 10), le.event) {
 				continue
 			}
-			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.EventType(), data)
 			flusher.Flush()
+			if group != "" {
+				eventLog.Commit(group, le.offset+1)
+			}
 		}
 	}
 }
 
+// eventStreamStart picks the offset an SSE client resumes from and the
+// consumer group to acknowledge to, if any. Without a resume point the
+// stream starts with the next published event.
+func eventStreamStart(r *http.Request, eventLog *events.EventLog) (uint64, string, error) {
+	group := strings.TrimSpace(r.URL.Query().Get("consumer"))
+	if group != "" {
+		group = "sse:" + group
+	}
+
+	if id := strings.TrimSpace(r.Header.Get("Last-Event-ID")); id != "" {
+		last, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid Last-Event-ID %q", id)
+		}
+		return last + 1, group, nil
+	}
+	if since := strings.TrimSpace(r.URL.Query().Get("since")); since != "" {
+		offset, err := strconv.ParseUint(since, 10, 64)
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid since offset %q", since)
+		}
+		return offset, group, nil
+	}
+	if group != "" {
+		offset, ok, err := eventLog.ConsumerOffset(group)
+		if err != nil {
+			return 0, "", err
+		}
+		if ok {
+			return offset, group, nil
+		}
+	}
+
+	offset, err := eventLog.NextOffset()
+	if err != nil {
+		return 0, "", err
+	}
+	if group != "" {
+		eventLog.Commit(group, offset)
+	}
+	return offset, group, nil
+}
+
+func setSSEHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+}
+
+func writeSSEConnected(w http.ResponseWriter) {
+	fmt.Fprintf(w, "event: connected\ndata: {\"status\":\"connected\",\"time\":\"%s\"}\n\n",
+		time.Now().UTC().Format(time.RFC3339))
+}
+
+// writeSSEEvent writes one event frame, with an id line when id is set.
+func writeSSEEvent(w http.ResponseWriter, id string, event events.BusEvent) bool {
+	data, err := json.Marshal(map[string]interface{}{
+		"type":      event.EventType(),
+		"timestamp": event.EventTimestamp().Format(time.RFC3339),
+		"session":   event.EventSession(),
+	})
+	if err != nil {
+		return false
+	}
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.EventType(), data)
+	return true
+}
+
 // addSSEClient adds a client to the SSE broadcast list.
 func (s *Server) addSSEClient(ch chan events.BusEvent) {
 	s.sseClientsMu.Lock()
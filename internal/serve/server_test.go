@@ -1443,6 +1443,7 @@ func TestPipelineEventTypeFromProgressType(t *testing.T) {
 		{progressType: "workflow_start", want: "pipeline.started", wantOK: true},
 		{progressType: "step_complete", want: "pipeline.step_completed", wantOK: true},
 		{progressType: "step_error", want: "pipeline.step_failed", wantOK: true},
+		{progressType: "step_approval", want: "pipeline.approval_pending", wantOK: true},
 		{progressType: "workflow_complete", want: "pipeline.complete", wantOK: true},
 		{progressType: "workflow_error", want: "pipeline.complete", wantOK: true},
 		{progressType: "step_start", want: "", wantOK: false},
@@ -3191,3 +3192,65 @@ func TestLoggingMiddleware_WithoutRequestID(t *testing.T) {
 		t.Error("next handler should be called")
 	}
 }
+
+func TestHandleEventStream_ResumesFromEventLog(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	eventLog, err := events.OpenEventLog(t.TempDir(), events.EventLogOptions{})
+	if err != nil {
+		t.Fatalf("OpenEventLog: %v", err)
+	}
+	t.Cleanup(func() { eventLog.Close() })
+	srv.eventBus.SetLog(eventLog)
+
+	for _, typ := range []string{"first.event", "second.event", "third.event"} {
+		srv.eventBus.PublishSync(events.BaseEvent{Type: typ, Timestamp: time.Now(), Session: "s"})
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(srv.handleEventStream))
+	t.Cleanup(ts.Close)
+
+	readFrames := func(req *http.Request, n int) string {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+		if err != nil {
+			t.Fatalf("GET /events: %v", err)
+		}
+		defer resp.Body.Close()
+
+		// Read until n event frames after the connected frame arrive.
+		var body strings.Builder
+		buf := make([]byte, 4096)
+		for strings.Count(body.String(), "id: ") < n {
+			k, err := resp.Body.Read(buf)
+			body.Write(buf[:k])
+			if err != nil {
+				t.Fatalf("reading stream: %v (got %q)", err, body.String())
+			}
+		}
+		return body.String()
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set("Last-Event-ID", "0")
+	body := readFrames(req, 2)
+	if strings.Contains(body, "first.event") || !strings.Contains(body, "id: 1\nevent: second.event") {
+		t.Errorf("Last-Event-ID resume body = %q", body)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, ts.URL+"?since=2", nil)
+	if body := readFrames(req, 1); !strings.Contains(body, "id: 2\nevent: third.event") {
+		t.Errorf("since resume body = %q", body)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, ts.URL+"?since=oops", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid since status = %d, want 400", resp.StatusCode)
+	}
+}
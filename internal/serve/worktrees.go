@@ -0,0 +1,125 @@
+// worktrees.go implements the /api/v1/worktrees endpoints over the agent branch merge queue.
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Dicklesworthstone/ntm/internal/robot"
+	"github.com/go-chi/chi/v5"
+)
+
+// MergeQueueRequest is the request body for POST /api/v1/worktrees/queue.
+// The verification command comes from the server's [merge_queue] config and
+// cannot be set by clients.
+type MergeQueueRequest struct {
+	Session    string   `json:"session"`
+	ProjectDir string   `json:"project_dir,omitempty"`
+	Target     string   `json:"target,omitempty"`
+	Agents     []string `json:"agents,omitempty"`
+	NoNotify   bool     `json:"no_notify,omitempty"`
+}
+
+// registerWorktreesRoutes registers worktree merge queue routes.
+func (s *Server) registerWorktreesRoutes(r chi.Router) {
+	r.Route("/worktrees", func(r chi.Router) {
+		r.With(s.RequirePermission(PermReadSessions)).Get("/queue", s.handleMergeQueueStatusV1)
+		r.With(s.RequirePermission(PermWriteAgents)).Post("/queue", s.handleMergeQueueRunV1)
+	})
+}
+
+// mergeQueueProjectDir returns the project directory a request refers to.
+func (s *Server) mergeQueueProjectDir(dir string) string {
+	if dir == "" {
+		return s.projectDir
+	}
+	return dir
+}
+
+// handleMergeQueueStatusV1 handles GET /api/v1/worktrees/queue.
+//
+// Query parameters: session (required) and project_dir.
+func (s *Server) handleMergeQueueStatusV1(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+
+	session := r.URL.Query().Get("session")
+	if session == "" {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "session parameter required", nil, reqID)
+		return
+	}
+	result, err := robot.GetMergeQueue(robot.MergeQueueOptions{
+		Session:    session,
+		ProjectDir: s.mergeQueueProjectDir(r.URL.Query().Get("project_dir")),
+		Status:     true,
+	})
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
+		return
+	}
+	if !result.Success {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, result.Error, nil, reqID)
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"session": session,
+		"report":  result.Report,
+	}, reqID)
+}
+
+// handleMergeQueueRunV1 handles POST /api/v1/worktrees/queue. The queue runs
+// as a background job; follow it with GET /api/v1/worktrees/queue or the job.
+// A run started while another is in progress fails its job.
+func (s *Server) handleMergeQueueRunV1(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+
+	var req MergeQueueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid request body", nil, reqID)
+		return
+	}
+	if req.Session == "" {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "session required", nil, reqID)
+		return
+	}
+
+	opts := robot.MergeQueueOptions{
+		Session:    req.Session,
+		ProjectDir: s.mergeQueueProjectDir(req.ProjectDir),
+		Target:     s.mergeQueue.Target,
+		Verify:     s.mergeQueue.Verify,
+		Timeout:    s.mergeQueue.VerifyTimeout(),
+		Agents:     req.Agents,
+		NoNotify:   req.NoNotify,
+	}
+	if req.Target != "" {
+		opts.Target = req.Target
+	}
+
+	job := *s.jobStore.Create("merge_queue")
+	go s.runMergeQueueJob(job.ID, opts)
+
+	writeSuccessResponse(w, http.StatusAccepted, map[string]interface{}{
+		"session": req.Session,
+		"job":     job,
+	}, reqID)
+}
+
+// runMergeQueueJob runs a merge queue and records its report on the job.
+func (s *Server) runMergeQueueJob(jobID string, opts robot.MergeQueueOptions) {
+	s.jobStore.Update(jobID, JobStatusRunning, 0, nil, "")
+
+	out, err := robot.GetMergeQueue(opts)
+	if err == nil && !out.Success {
+		err = errors.New(out.Error)
+	}
+	result, _ := toJSONMap(out)
+	if err != nil {
+		slog.Warn("merge queue failed", "session", opts.Session, "error", err)
+		s.jobStore.Update(jobID, JobStatusFailed, 100, result, err.Error())
+		return
+	}
+	s.jobStore.Update(jobID, JobStatusCompleted, 100, result, "")
+}
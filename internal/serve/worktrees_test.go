@@ -0,0 +1,80 @@
+package serve
+
+import (
+	"net/http"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupWorktreesRouter(t *testing.T, srv *Server) http.Handler {
+	t.Helper()
+	srv.auth = AuthConfig{Mode: AuthModeLocal}
+	r := chi.NewRouter()
+	r.Use(srv.requestIDMiddlewareFunc)
+	r.Use(srv.rbacMiddleware)
+	r.Route("/api/v1", func(r chi.Router) {
+		srv.registerWorktreesRoutes(r)
+	})
+	return r
+}
+
+func TestMergeQueueRoutes(t *testing.T) {
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.email", "test@test.com"},
+		{"config", "user.name", "Test"},
+		{"commit", "-q", "--allow-empty", "-m", "init"},
+		{"branch", "ntm/proj/cc_1"},
+		{"checkout", "-q", "-b", "ntm/proj/cc_2"},
+		{"commit", "-q", "--allow-empty", "-m", "cc_2 work"},
+		{"checkout", "-q", "main"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	srv, _ := setupTestServer(t)
+	srv.mergeQueue.Verify = "true"
+	h := setupWorktreesRouter(t, srv)
+
+	doQueueRequest(t, h, http.MethodPost, "/api/v1/worktrees/queue", `{}`, http.StatusBadRequest)
+	resp := doQueueRequest(t, h, http.MethodGet, "/api/v1/worktrees/queue?session=proj&project_dir="+repo, "", http.StatusOK)
+	if resp["report"] != nil {
+		t.Errorf("report before any run = %v", resp["report"])
+	}
+
+	resp = doQueueRequest(t, h, http.MethodPost, "/api/v1/worktrees/queue",
+		`{"session":"proj","project_dir":"`+repo+`","no_notify":true}`, http.StatusAccepted)
+	if resp["job"].(map[string]interface{})["type"] != "merge_queue" {
+		t.Errorf("job = %v", resp["job"])
+	}
+
+	var report map[string]interface{}
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		resp = doQueueRequest(t, h, http.MethodGet, "/api/v1/worktrees/queue?session=proj&project_dir="+repo, "", http.StatusOK)
+		report, _ = resp["report"].(map[string]interface{})
+		if report != nil && report["running"] == false {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("merge queue did not finish: %v", resp)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	entries := report["entries"].([]interface{})
+	if len(entries) != 2 || report["verify"] != "true" {
+		t.Fatalf("report = %v", report)
+	}
+	for i, want := range []string{"up_to_date", "merged"} {
+		if got := entries[i].(map[string]interface{})["status"]; got != want {
+			t.Errorf("entries[%d].status = %v, want %s", i, got, want)
+		}
+	}
+}
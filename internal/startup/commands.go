@@ -25,6 +25,7 @@ var CommandClassification = map[string]CommandRequirement{
 	"init":       RequirePhase1Only,
 	"completion": RequirePhase1Only,
 	"upgrade":    RequirePhase1Only,
+	"fake-agent": RequirePhase1Only,
 
 	// Config-only commands
 	"config":   RequireConfig,
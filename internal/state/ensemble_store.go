@@ -79,9 +79,9 @@ func (s *EnsembleStore) SaveEnsemble(e *EnsembleSession) error {
 	if err := func() error {
 		result, err := tx.Exec(`
 			UPDATE ensemble_sessions
-			SET question = ?, preset_used = ?, status = ?, synthesis_strategy = ?, synthesized_at = ?, synthesis_output = ?, error = ?
+			SET question = ?, preset_used = ?, status = ?, synthesis_strategy = ?, created_at = ?, synthesized_at = ?, synthesis_output = ?, error = ?
 			WHERE session_name = ?`,
-			e.Question, e.PresetUsed, e.Status, e.SynthesisStrategy, e.SynthesizedAt, e.SynthesisOutput, e.Error, e.SessionName,
+			e.Question, e.PresetUsed, e.Status, e.SynthesisStrategy, e.CreatedAt, e.SynthesizedAt, e.SynthesisOutput, e.Error, e.SessionName,
 		)
 		if err != nil {
 			return fmt.Errorf("update ensemble session: %w", err)
@@ -364,6 +364,9 @@ func (s *EnsembleStore) DeleteEnsemble(sessionName string) error {
 		if _, err := tx.Exec(`DELETE FROM mode_assignments WHERE ensemble_id = ?`, ensembleID); err != nil {
 			return fmt.Errorf("delete assignments: %w", err)
 		}
+		if _, err := tx.Exec(`DELETE FROM ensemble_deliberations WHERE ensemble_id = ?`, ensembleID); err != nil {
+			return fmt.Errorf("delete deliberation: %w", err)
+		}
 
 		result, err := tx.Exec(`DELETE FROM ensemble_sessions WHERE id = ?`, ensembleID)
 		if err != nil {
@@ -383,6 +386,60 @@ func (s *EnsembleStore) DeleteEnsemble(sessionName string) error {
 	return tx.Commit()
 }
 
+// SaveDeliberation stores the JSON deliberation record of the ensemble in
+// sessionName, replacing any earlier one.
+func (s *EnsembleStore) SaveDeliberation(sessionName string, record []byte) error {
+	if s == nil || s.store == nil {
+		return errors.New("ensemble store is nil")
+	}
+	if sessionName == "" {
+		return errors.New("session name is required")
+	}
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	result, err := s.store.db.Exec(`
+		INSERT INTO ensemble_deliberations (ensemble_id, record, updated_at)
+		SELECT id, ?, ? FROM ensemble_sessions WHERE session_name = ?
+		ON CONFLICT(ensemble_id) DO UPDATE SET record = excluded.record, updated_at = excluded.updated_at`,
+		string(record), time.Now().UTC(), sessionName)
+	if err != nil {
+		return fmt.Errorf("save deliberation: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("ensemble session not found: %s", sessionName)
+	}
+	return nil
+}
+
+// GetDeliberation returns the JSON deliberation record of the ensemble in
+// sessionName, or nil if it has none.
+func (s *EnsembleStore) GetDeliberation(sessionName string) ([]byte, error) {
+	if s == nil || s.store == nil {
+		return nil, errors.New("ensemble store is nil")
+	}
+	if sessionName == "" {
+		return nil, errors.New("session name is required")
+	}
+
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	var record string
+	err := s.store.db.QueryRow(`
+		SELECT d.record FROM ensemble_deliberations d
+		JOIN ensemble_sessions e ON e.id = d.ensemble_id
+		WHERE e.session_name = ?`, sessionName).Scan(&record)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get deliberation: %w", err)
+	}
+	return []byte(record), nil
+}
+
 func (s *EnsembleStore) fetchAssignments(ensembleID int64) ([]ModeAssignment, error) {
 	rows, err := s.store.db.Query(`
 		SELECT id, ensemble_id, mode_id, pane_name, agent_type, status, COALESCE(output_path, ''),
@@ -0,0 +1,201 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SchedulerLease is a machine-wide spawn slot held by one ntm process.
+type SchedulerLease struct {
+	ID         string    `json:"id"`
+	Holder     string    `json:"holder"`
+	AgentType  string    `json:"agent_type"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// SchedulerCooldown is a shared backoff window. Scope is "global" or an
+// agent type.
+type SchedulerCooldown struct {
+	Scope     string    `json:"scope"`
+	Until     time.Time `json:"until"`
+	Reason    string    `json:"reason,omitempty"`
+	Holder    string    `json:"holder,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ========================
+// Scheduler Lease Operations
+// ========================
+
+// AcquireSchedulerLease inserts the lease if fewer than agentCap unexpired
+// leases exist for its agent type and fewer than globalCap exist in total.
+// A cap of zero or less is unlimited. The check and insert run as a single
+// statement, so concurrent processes cannot both take the last slot.
+func (s *Store) AcquireSchedulerLease(lease *SchedulerLease, agentCap, globalCap int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	if lease.AcquiredAt.IsZero() {
+		lease.AcquiredAt = now
+	}
+	result, err := s.db.Exec(`
+		INSERT INTO scheduler_leases (id, holder, agent_type, acquired_at, expires_at)
+		SELECT ?, ?, ?, ?, ?
+		WHERE (? <= 0 OR (SELECT COUNT(*) FROM scheduler_leases WHERE agent_type = ? AND expires_at > ?) < ?)
+		  AND (? <= 0 OR (SELECT COUNT(*) FROM scheduler_leases WHERE expires_at > ?) < ?)`,
+		lease.ID, lease.Holder, lease.AgentType, lease.AcquiredAt.UTC(), lease.ExpiresAt.UTC(),
+		agentCap, lease.AgentType, now, agentCap,
+		globalCap, now, globalCap,
+	)
+	if err != nil {
+		return false, fmt.Errorf("acquire scheduler lease: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows == 1, nil
+}
+
+// RenewSchedulerLeases extends every lease held by holder.
+func (s *Store) RenewSchedulerLeases(holder string, expiresAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`UPDATE scheduler_leases SET expires_at = ? WHERE holder = ?`,
+		expiresAt.UTC(), holder)
+	if err != nil {
+		return 0, fmt.Errorf("renew scheduler leases: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ReleaseSchedulerLease deletes a lease.
+func (s *Store) ReleaseSchedulerLease(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec(`DELETE FROM scheduler_leases WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("release scheduler lease: %w", err)
+	}
+	return nil
+}
+
+// ReleaseSchedulerLeasesByHolder deletes every lease held by holder.
+func (s *Store) ReleaseSchedulerLeasesByHolder(holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec(`DELETE FROM scheduler_leases WHERE holder = ?`, holder); err != nil {
+		return fmt.Errorf("release scheduler leases: %w", err)
+	}
+	return nil
+}
+
+// PruneSchedulerLeases deletes leases that expired before now, typically
+// left behind by processes that exited without releasing them.
+func (s *Store) PruneSchedulerLeases(now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`DELETE FROM scheduler_leases WHERE expires_at <= ?`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("prune scheduler leases: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ListSchedulerLeases returns unexpired leases, oldest first.
+func (s *Store) ListSchedulerLeases() ([]SchedulerLease, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT id, holder, agent_type, acquired_at, expires_at
+		FROM scheduler_leases WHERE expires_at > ? ORDER BY acquired_at`, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("list scheduler leases: %w", err)
+	}
+	defer rows.Close()
+
+	var leases []SchedulerLease
+	for rows.Next() {
+		var l SchedulerLease
+		if err := rows.Scan(&l.ID, &l.Holder, &l.AgentType, &l.AcquiredAt, &l.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("scan scheduler lease: %w", err)
+		}
+		leases = append(leases, l)
+	}
+	return leases, rows.Err()
+}
+
+// ========================
+// Scheduler Cooldown Operations
+// ========================
+
+// ExtendSchedulerCooldown records a backoff window for scope. A window that
+// already ends later is kept.
+func (s *Store) ExtendSchedulerCooldown(cd *SchedulerCooldown) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	_, err := s.db.Exec(`
+		INSERT INTO scheduler_cooldowns (scope, until, reason, holder, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(scope) DO UPDATE SET
+			until = excluded.until,
+			reason = excluded.reason,
+			holder = excluded.holder,
+			updated_at = excluded.updated_at
+		WHERE excluded.until > scheduler_cooldowns.until`,
+		cd.Scope, cd.Until.UTC(), cd.Reason, cd.Holder, now,
+	)
+	if err != nil {
+		return fmt.Errorf("extend scheduler cooldown: %w", err)
+	}
+	return nil
+}
+
+// GetSchedulerCooldown returns the window for scope, or nil if none is recorded.
+func (s *Store) GetSchedulerCooldown(scope string) (*SchedulerCooldown, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cd := &SchedulerCooldown{}
+	err := s.db.QueryRow(`
+		SELECT scope, until, COALESCE(reason, ''), COALESCE(holder, ''), updated_at
+		FROM scheduler_cooldowns WHERE scope = ?`, scope,
+	).Scan(&cd.Scope, &cd.Until, &cd.Reason, &cd.Holder, &cd.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get scheduler cooldown: %w", err)
+	}
+	return cd, nil
+}
+
+// ListActiveSchedulerCooldowns returns windows that have not ended yet.
+func (s *Store) ListActiveSchedulerCooldowns() ([]SchedulerCooldown, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT scope, until, COALESCE(reason, ''), COALESCE(holder, ''), updated_at
+		FROM scheduler_cooldowns WHERE until > ? ORDER BY scope`, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("list scheduler cooldowns: %w", err)
+	}
+	defer rows.Close()
+
+	var cooldowns []SchedulerCooldown
+	for rows.Next() {
+		var cd SchedulerCooldown
+		if err := rows.Scan(&cd.Scope, &cd.Until, &cd.Reason, &cd.Holder, &cd.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan scheduler cooldown: %w", err)
+		}
+		cooldowns = append(cooldowns, cd)
+	}
+	return cooldowns, rows.Err()
+}
@@ -0,0 +1,147 @@
+package state
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openSharedStores opens two handles on one database file, like two ntm processes.
+func openSharedStores(t *testing.T) (*Store, *Store) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "state.db")
+	var stores [2]*Store
+	for i := range stores {
+		s, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open() error: %v", err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		if err := s.Migrate(); err != nil {
+			t.Fatalf("Migrate() error: %v", err)
+		}
+		stores[i] = s
+	}
+	return stores[0], stores[1]
+}
+
+func TestSchedulerLeases_SharedCaps(t *testing.T) {
+	a, b := openSharedStores(t)
+	expires := time.Now().Add(time.Minute)
+
+	acquire := func(s *Store, id, holder, agentType string, agentCap, globalCap int) bool {
+		t.Helper()
+		ok, err := s.AcquireSchedulerLease(&SchedulerLease{ID: id, Holder: holder, AgentType: agentType, ExpiresAt: expires}, agentCap, globalCap)
+		if err != nil {
+			t.Fatalf("AcquireSchedulerLease(%s) error: %v", id, err)
+		}
+		return ok
+	}
+
+	if !acquire(a, "a1", "proc-a", "cc", 2, 3) || !acquire(b, "b1", "proc-b", "cc", 2, 3) {
+		t.Fatal("first two cc leases should succeed")
+	}
+	if acquire(a, "a2", "proc-a", "cc", 2, 3) {
+		t.Error("third cc lease exceeded the per-agent cap")
+	}
+	if !acquire(b, "b2", "proc-b", "cod", 2, 3) {
+		t.Error("cod lease should succeed under the global cap")
+	}
+	if acquire(a, "a3", "proc-a", "gmi", 0, 3) {
+		t.Error("lease exceeded the global cap")
+	}
+
+	if err := b.ReleaseSchedulerLease("b1"); err != nil {
+		t.Fatal(err)
+	}
+	if !acquire(a, "a2", "proc-a", "cc", 2, 3) {
+		t.Error("cc lease should succeed after release")
+	}
+
+	// A holder that stops renewing loses its slots when the leases expire.
+	if _, err := b.RenewSchedulerLeases("proc-b", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := a.PruneSchedulerLeases(time.Now()); err != nil || n != 1 {
+		t.Errorf("PruneSchedulerLeases() = %d, %v; want 1", n, err)
+	}
+	leases, err := b.ListSchedulerLeases()
+	if err != nil || len(leases) != 2 {
+		t.Fatalf("ListSchedulerLeases() = %+v, %v", leases, err)
+	}
+	for _, l := range leases {
+		if l.Holder != "proc-a" {
+			t.Errorf("unexpected lease %+v", l)
+		}
+	}
+
+	if err := a.ReleaseSchedulerLeasesByHolder("proc-a"); err != nil {
+		t.Fatal(err)
+	}
+	if leases, _ := b.ListSchedulerLeases(); len(leases) != 0 {
+		t.Errorf("leases after holder release = %+v", leases)
+	}
+}
+
+func TestSchedulerLeases_ConcurrentAcquire(t *testing.T) {
+	a, b := openSharedStores(t)
+	expires := time.Now().Add(time.Minute)
+
+	results := make(chan bool, 20)
+	for i := 0; i < 20; i++ {
+		s := a
+		if i%2 == 1 {
+			s = b
+		}
+		go func(i int, s *Store) {
+			ok, err := s.AcquireSchedulerLease(&SchedulerLease{ID: fmt.Sprintf("l%d", i), Holder: "h", AgentType: "cc", ExpiresAt: expires}, 5, 0)
+			if err != nil {
+				t.Errorf("AcquireSchedulerLease() error: %v", err)
+			}
+			results <- ok
+		}(i, s)
+	}
+	granted := 0
+	for i := 0; i < 20; i++ {
+		if <-results {
+			granted++
+		}
+	}
+	if granted != 5 {
+		t.Errorf("granted %d leases, want exactly 5", granted)
+	}
+}
+
+func TestSchedulerCooldowns(t *testing.T) {
+	a, b := openSharedStores(t)
+
+	if cd, err := a.GetSchedulerCooldown("global"); err != nil || cd != nil {
+		t.Fatalf("GetSchedulerCooldown(empty) = %+v, %v", cd, err)
+	}
+
+	later := time.Now().Add(time.Minute).UTC().Truncate(time.Millisecond)
+	if err := a.ExtendSchedulerCooldown(&SchedulerCooldown{Scope: "global", Until: later, Reason: "EAGAIN", Holder: "proc-a"}); err != nil {
+		t.Fatal(err)
+	}
+	// A shorter window does not cut an existing one short.
+	if err := b.ExtendSchedulerCooldown(&SchedulerCooldown{Scope: "global", Until: time.Now().Add(time.Second), Reason: "RATE_LIMIT"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cd, err := b.GetSchedulerCooldown("global")
+	if err != nil || cd == nil {
+		t.Fatalf("GetSchedulerCooldown() = %+v, %v", cd, err)
+	}
+	if !cd.Until.Equal(later) || cd.Reason != "EAGAIN" || cd.Holder != "proc-a" {
+		t.Errorf("cooldown = %+v, want until %v from proc-a", cd, later)
+	}
+
+	if err := b.ExtendSchedulerCooldown(&SchedulerCooldown{Scope: "cod", Until: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	active, err := a.ListActiveSchedulerCooldowns()
+	if err != nil || len(active) != 1 || active[0].Scope != "global" {
+		t.Errorf("ListActiveSchedulerCooldowns() = %+v, %v", active, err)
+	}
+}
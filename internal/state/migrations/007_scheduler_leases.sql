@@ -0,0 +1,23 @@
+-- Machine-wide spawn coordination between ntm processes
+-- Leases share agent concurrency caps; cooldowns share backoff windows
+
+-- One row per running spawn, held by a live process
+CREATE TABLE IF NOT EXISTS scheduler_leases (
+    id TEXT PRIMARY KEY,
+    holder TEXT NOT NULL,      -- host:pid:nonce of the owning process
+    agent_type TEXT NOT NULL,
+    acquired_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL  -- renewed by the holder's heartbeat
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduler_leases_agent ON scheduler_leases(agent_type, expires_at);
+CREATE INDEX IF NOT EXISTS idx_scheduler_leases_holder ON scheduler_leases(holder);
+
+-- Backoff and rate-limit windows, keyed by scope ("global" or an agent type)
+CREATE TABLE IF NOT EXISTS scheduler_cooldowns (
+    scope TEXT PRIMARY KEY,
+    until TIMESTAMP NOT NULL,
+    reason TEXT,
+    holder TEXT,               -- process that set the window
+    updated_at TIMESTAMP NOT NULL
+);
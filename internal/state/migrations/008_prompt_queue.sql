@@ -0,0 +1,20 @@
+-- Per-pane prompt queue
+-- Prompts wait here until their agent is idle, then a dispatcher delivers them
+
+CREATE TABLE IF NOT EXISTS prompt_queue (
+    id TEXT PRIMARY KEY,
+    session TEXT NOT NULL,
+    pane INTEGER NOT NULL,          -- tmux pane index
+    position INTEGER NOT NULL,      -- delivery order among the pane's pending prompts
+    prompt TEXT NOT NULL,
+    source TEXT,                    -- send, pipeline, bulk_assign, api
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, delivering, delivered, failed, cancelled
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_prompt_queue_pane ON prompt_queue(session, pane, status, position);
+CREATE INDEX IF NOT EXISTS idx_prompt_queue_status ON prompt_queue(status, updated_at);
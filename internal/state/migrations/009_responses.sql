@@ -0,0 +1,24 @@
+-- Agent responses to prompts sent with ntm send
+-- Each row marks where a pane's output started when a prompt was sent and,
+-- once the agent is idle again, holds the cleaned reply
+
+CREATE TABLE IF NOT EXISTS responses (
+    id TEXT PRIMARY KEY,
+    history_id TEXT NOT NULL,       -- prompt history entry the reply answers
+    session TEXT NOT NULL,
+    pane INTEGER NOT NULL,          -- tmux pane index
+    pane_id TEXT NOT NULL,          -- tmux pane ID (%N), stable while the pane lives
+    agent_type TEXT,
+    start_line INTEGER NOT NULL,    -- absolute scrollback line the reply starts after
+    prompt TEXT,
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, captured, timeout, lost
+    text TEXT,
+    code_blocks TEXT,               -- JSON array of extracted code blocks
+    error TEXT,
+    created_at TIMESTAMP NOT NULL,
+    captured_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_responses_history ON responses(history_id, pane);
+CREATE INDEX IF NOT EXISTS idx_responses_status ON responses(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_responses_session ON responses(session, created_at);
@@ -0,0 +1,10 @@
+-- NTM State Store: Ensemble Deliberations
+-- Version: 010
+-- Description: Stores the deliberation record of an ensemble with the ensemble itself
+
+CREATE TABLE IF NOT EXISTS ensemble_deliberations (
+    ensemble_id INTEGER PRIMARY KEY,
+    record TEXT NOT NULL,           -- JSON deliberation record
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (ensemble_id) REFERENCES ensemble_sessions(id) ON DELETE CASCADE
+);
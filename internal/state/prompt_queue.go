@@ -0,0 +1,308 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// QueuedPromptStatus is where a queued prompt is in its delivery.
+type QueuedPromptStatus string
+
+const (
+	QueuedPending    QueuedPromptStatus = "pending"
+	QueuedDelivering QueuedPromptStatus = "delivering"
+	QueuedDelivered  QueuedPromptStatus = "delivered"
+	QueuedFailed     QueuedPromptStatus = "failed"
+	QueuedCancelled  QueuedPromptStatus = "cancelled"
+)
+
+// IsTerminal reports whether the prompt will not be delivered again.
+func (s QueuedPromptStatus) IsTerminal() bool {
+	return s == QueuedDelivered || s == QueuedFailed || s == QueuedCancelled
+}
+
+// QueuedPrompt is a prompt waiting in a pane's queue for its agent to be idle.
+type QueuedPrompt struct {
+	ID          string             `json:"id"`
+	Session     string             `json:"session"`
+	Pane        int                `json:"pane"`
+	Position    int                `json:"position"` // 1 is delivered next
+	Prompt      string             `json:"prompt"`
+	Source      string             `json:"source,omitempty"`
+	Status      QueuedPromptStatus `json:"status"`
+	Attempts    int                `json:"attempts"`
+	Error       string             `json:"error,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	DeliveredAt *time.Time         `json:"delivered_at,omitempty"`
+}
+
+const queuedPromptColumns = `id, session, pane, position, prompt, COALESCE(source, ''), status, attempts, COALESCE(error, ''), created_at, updated_at, delivered_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanQueuedPrompt(row rowScanner) (*QueuedPrompt, error) {
+	var p QueuedPrompt
+	var delivered sql.NullTime
+	if err := row.Scan(&p.ID, &p.Session, &p.Pane, &p.Position, &p.Prompt, &p.Source, &p.Status, &p.Attempts, &p.Error, &p.CreatedAt, &p.UpdatedAt, &delivered); err != nil {
+		return nil, err
+	}
+	if delivered.Valid {
+		t := delivered.Time
+		p.DeliveredAt = &t
+	}
+	return &p, nil
+}
+
+// ========================
+// Prompt Queue Operations
+// ========================
+
+// EnqueuePrompt appends p to the end of its pane's queue, setting its
+// position, status and timestamps.
+func (s *Store) EnqueuePrompt(p *QueuedPrompt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	p.Status = QueuedPending
+	p.CreatedAt, p.UpdatedAt = now, now
+	_, err := s.db.Exec(`
+		INSERT INTO prompt_queue (id, session, pane, position, prompt, source, status, created_at, updated_at)
+		SELECT ?, ?, ?, COALESCE(MAX(position), 0) + 1, ?, ?, ?, ?, ?
+		FROM prompt_queue WHERE session = ? AND pane = ? AND status IN ('pending', 'delivering')`,
+		p.ID, p.Session, p.Pane, p.Prompt, nullString(p.Source), p.Status, now, now,
+		p.Session, p.Pane,
+	)
+	if err != nil {
+		return fmt.Errorf("enqueue prompt: %w", err)
+	}
+	if err := s.db.QueryRow(`SELECT position FROM prompt_queue WHERE id = ?`, p.ID).Scan(&p.Position); err != nil {
+		return fmt.Errorf("enqueue prompt: %w", err)
+	}
+	return nil
+}
+
+// GetQueuedPrompt returns a queued prompt, or nil if there is none with id.
+func (s *Store) GetQueuedPrompt(id string) (*QueuedPrompt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, err := scanQueuedPrompt(s.db.QueryRow(`SELECT `+queuedPromptColumns+` FROM prompt_queue WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get queued prompt: %w", err)
+	}
+	return p, nil
+}
+
+// ListQueuedPrompts returns queued prompts in delivery order. An empty
+// session matches every session and a negative pane every pane. Unless all
+// is set, only prompts still waiting or being delivered are returned.
+func (s *Store) ListQueuedPrompts(session string, pane int, all bool) ([]QueuedPrompt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT `+queuedPromptColumns+` FROM prompt_queue
+		WHERE (? = '' OR session = ?) AND (? < 0 OR pane = ?)
+		  AND (? OR status IN ('pending', 'delivering'))
+		ORDER BY session, pane, status NOT IN ('pending', 'delivering'), position, created_at`,
+		session, session, pane, pane, all,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list queued prompts: %w", err)
+	}
+	defer rows.Close()
+
+	var prompts []QueuedPrompt
+	for rows.Next() {
+		p, err := scanQueuedPrompt(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan queued prompt: %w", err)
+		}
+		prompts = append(prompts, *p)
+	}
+	return prompts, rows.Err()
+}
+
+// NextQueuedPrompt returns the pane's next pending prompt, or nil if its
+// queue is empty or one of its prompts is already being delivered.
+func (s *Store) NextQueuedPrompt(session string, pane int) (*QueuedPrompt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, err := scanQueuedPrompt(s.db.QueryRow(`
+		SELECT `+queuedPromptColumns+` FROM prompt_queue
+		WHERE session = ? AND pane = ? AND status = 'pending'
+		  AND NOT EXISTS (
+			SELECT 1 FROM prompt_queue WHERE session = ? AND pane = ? AND status = 'delivering')
+		ORDER BY position, created_at LIMIT 1`, session, pane, session, pane))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("next queued prompt: %w", err)
+	}
+	return p, nil
+}
+
+// ClaimQueuedPrompt marks a pending prompt as being delivered and counts the
+// attempt. It reports false if the prompt is no longer pending, e.g. because
+// another dispatcher claimed it first, or if another prompt for the same
+// pane is already being delivered. The check and the claim are one
+// statement, so dispatchers in different processes cannot both deliver to
+// a pane.
+func (s *Store) ClaimQueuedPrompt(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`
+		UPDATE prompt_queue SET status = 'delivering', attempts = attempts + 1, updated_at = ?
+		WHERE id = ? AND status = 'pending'
+		  AND NOT EXISTS (
+			SELECT 1 FROM prompt_queue AS busy
+			WHERE busy.session = prompt_queue.session AND busy.pane = prompt_queue.pane
+			  AND busy.status = 'delivering')`, time.Now().UTC(), id)
+	if err != nil {
+		return false, fmt.Errorf("claim queued prompt: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows == 1, nil
+}
+
+// FinishQueuedPrompt records the outcome of a delivery claimed with
+// ClaimQueuedPrompt. Status QueuedPending puts the prompt back at the head
+// of its queue to be retried.
+func (s *Store) FinishQueuedPrompt(id string, status QueuedPromptStatus, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	var delivered sql.NullTime
+	if status == QueuedDelivered {
+		delivered = sql.NullTime{Time: now, Valid: true}
+	}
+	result, err := s.db.Exec(`
+		UPDATE prompt_queue SET status = ?, error = ?, updated_at = ?, delivered_at = ?
+		WHERE id = ? AND status = 'delivering'`,
+		status, nullString(errMsg), now, delivered, id)
+	if err != nil {
+		return fmt.Errorf("finish queued prompt: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("queued prompt %s is not being delivered", id)
+	}
+	return nil
+}
+
+// CancelQueuedPrompt cancels a pending prompt.
+func (s *Store) CancelQueuedPrompt(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`
+		UPDATE prompt_queue SET status = 'cancelled', updated_at = ?
+		WHERE id = ? AND status = 'pending'`, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("cancel queued prompt: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("no pending queued prompt %s", id)
+	}
+	return nil
+}
+
+// CancelQueuedPrompts cancels every pending prompt for session, or for one
+// of its panes if pane is not negative, and returns how many were cancelled.
+func (s *Store) CancelQueuedPrompts(session string, pane int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`
+		UPDATE prompt_queue SET status = 'cancelled', updated_at = ?
+		WHERE session = ? AND (? < 0 OR pane = ?) AND status = 'pending'`,
+		time.Now().UTC(), session, pane, pane)
+	if err != nil {
+		return 0, fmt.Errorf("cancel queued prompts: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// MoveQueuedPrompt moves a pending prompt to position in its pane's queue,
+// where 1 is delivered next. Positions past the end move it to the end.
+func (s *Store) MoveQueuedPrompt(id string, position int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var session string
+	var pane int
+	var status QueuedPromptStatus
+	err = tx.QueryRow(`SELECT session, pane, status FROM prompt_queue WHERE id = ?`, id).Scan(&session, &pane, &status)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("queued prompt not found: %s", id)
+	}
+	if err != nil {
+		return fmt.Errorf("move queued prompt: %w", err)
+	}
+	if status != QueuedPending {
+		return fmt.Errorf("queued prompt %s is %s; only pending prompts can be moved", id, status)
+	}
+
+	rows, err := tx.Query(`
+		SELECT id FROM prompt_queue WHERE session = ? AND pane = ? AND status = 'pending' AND id != ?
+		ORDER BY position, created_at`, session, pane, id)
+	if err != nil {
+		return fmt.Errorf("move queued prompt: %w", err)
+	}
+	var order []string
+	for rows.Next() {
+		var other string
+		if err := rows.Scan(&other); err != nil {
+			rows.Close()
+			return fmt.Errorf("move queued prompt: %w", err)
+		}
+		order = append(order, other)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("move queued prompt: %w", err)
+	}
+
+	at := min(max(position, 1), len(order)+1) - 1
+	order = append(order[:at], append([]string{id}, order[at:]...)...)
+	now := time.Now().UTC()
+	for i, qid := range order {
+		if _, err := tx.Exec(`UPDATE prompt_queue SET position = ?, updated_at = ? WHERE id = ?`, i+1, now, qid); err != nil {
+			return fmt.Errorf("move queued prompt: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// RequeueStalePrompts returns prompts whose delivery started before before
+// to the pending state, typically left behind by a dispatcher that exited
+// mid-delivery.
+func (s *Store) RequeueStalePrompts(before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`
+		UPDATE prompt_queue SET status = 'pending', updated_at = ?
+		WHERE status = 'delivering' AND updated_at < ?`, time.Now().UTC(), before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("requeue stale prompts: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,159 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func queueOrder(t *testing.T, s *Store, session string, pane int) []string {
+	t.Helper()
+	prompts, err := s.ListQueuedPrompts(session, pane, false)
+	if err != nil {
+		t.Fatalf("ListQueuedPrompts() error: %v", err)
+	}
+	var ids []string
+	for _, p := range prompts {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPromptQueue_Order(t *testing.T) {
+	a, b := openSharedStores(t)
+
+	for i, id := range []string{"q1", "q2", "q3"} {
+		p := &QueuedPrompt{ID: id, Session: "proj", Pane: 2, Prompt: "prompt " + id, Source: "send"}
+		if err := a.EnqueuePrompt(p); err != nil {
+			t.Fatalf("EnqueuePrompt(%s) error: %v", id, err)
+		}
+		if p.Position != i+1 || p.Status != QueuedPending {
+			t.Errorf("EnqueuePrompt(%s) = position %d status %s", id, p.Position, p.Status)
+		}
+	}
+	if err := a.EnqueuePrompt(&QueuedPrompt{ID: "other", Session: "proj", Pane: 3, Prompt: "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := queueOrder(t, b, "proj", 2); !equalIDs(got, []string{"q1", "q2", "q3"}) {
+		t.Errorf("queue = %v", got)
+	}
+	if got := queueOrder(t, b, "", -1); len(got) != 4 {
+		t.Errorf("all queues = %v", got)
+	}
+
+	if err := b.MoveQueuedPrompt("q3", 1); err != nil {
+		t.Fatalf("MoveQueuedPrompt() error: %v", err)
+	}
+	if got := queueOrder(t, a, "proj", 2); !equalIDs(got, []string{"q3", "q1", "q2"}) {
+		t.Errorf("queue after move to front = %v", got)
+	}
+	if err := b.MoveQueuedPrompt("q3", 99); err != nil {
+		t.Fatal(err)
+	}
+	if got := queueOrder(t, a, "proj", 2); !equalIDs(got, []string{"q1", "q2", "q3"}) {
+		t.Errorf("queue after move to end = %v", got)
+	}
+
+	if err := a.CancelQueuedPrompt("q2"); err != nil {
+		t.Fatalf("CancelQueuedPrompt() error: %v", err)
+	}
+	if err := a.CancelQueuedPrompt("q2"); err == nil {
+		t.Error("cancelling a cancelled prompt should fail")
+	}
+	if err := a.MoveQueuedPrompt("q2", 1); err == nil {
+		t.Error("moving a cancelled prompt should fail")
+	}
+	if next, err := b.NextQueuedPrompt("proj", 2); err != nil || next == nil || next.ID != "q1" {
+		t.Errorf("NextQueuedPrompt() = %+v, %v", next, err)
+	}
+	all, err := b.ListQueuedPrompts("proj", 2, true)
+	if err != nil || len(all) != 3 || all[2].Status != QueuedCancelled {
+		t.Errorf("ListQueuedPrompts(all) = %+v, %v", all, err)
+	}
+
+	if n, err := a.CancelQueuedPrompts("proj", -1); err != nil || n != 3 {
+		t.Errorf("CancelQueuedPrompts() = %d, %v; want 3", n, err)
+	}
+	if next, _ := b.NextQueuedPrompt("proj", 2); next != nil {
+		t.Errorf("NextQueuedPrompt() after clear = %+v", next)
+	}
+}
+
+func TestPromptQueue_Delivery(t *testing.T) {
+	a, b := openSharedStores(t)
+
+	if err := a.EnqueuePrompt(&QueuedPrompt{ID: "q1", Session: "proj", Pane: 2, Prompt: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only one of two dispatchers wins the claim.
+	okA, errA := a.ClaimQueuedPrompt("q1")
+	okB, errB := b.ClaimQueuedPrompt("q1")
+	if errA != nil || errB != nil || !okA || okB {
+		t.Fatalf("claims = %v/%v, %v/%v; want exactly the first to win", okA, errA, okB, errB)
+	}
+	if err := a.CancelQueuedPrompt("q1"); err == nil {
+		t.Error("cancelling a prompt being delivered should fail")
+	}
+
+	// A failed attempt goes back to the head of the queue.
+	if err := a.FinishQueuedPrompt("q1", QueuedPending, "paste failed"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := b.ClaimQueuedPrompt("q1"); !ok {
+		t.Fatal("retry claim failed")
+	}
+	if err := b.FinishQueuedPrompt("q1", QueuedDelivered, ""); err != nil {
+		t.Fatal(err)
+	}
+	p, err := a.GetQueuedPrompt("q1")
+	if err != nil || p == nil {
+		t.Fatalf("GetQueuedPrompt() = %+v, %v", p, err)
+	}
+	if p.Status != QueuedDelivered || p.Attempts != 2 || p.DeliveredAt == nil || !p.Status.IsTerminal() {
+		t.Errorf("delivered prompt = %+v", p)
+	}
+	if err := b.FinishQueuedPrompt("q1", QueuedFailed, ""); err == nil {
+		t.Error("finishing a delivered prompt should fail")
+	}
+
+	// A claim abandoned by an exited dispatcher is requeued.
+	if err := a.EnqueuePrompt(&QueuedPrompt{ID: "q2", Session: "proj", Pane: 2, Prompt: "again"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.EnqueuePrompt(&QueuedPrompt{ID: "q3", Session: "proj", Pane: 2, Prompt: "later"}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := a.ClaimQueuedPrompt("q2"); !ok {
+		t.Fatal("claim failed")
+	}
+
+	// Nothing else is handed out for a pane while it is being delivered to.
+	if ok, err := b.ClaimQueuedPrompt("q3"); err != nil || ok {
+		t.Errorf("ClaimQueuedPrompt(q3) while q2 delivers = %v, %v; want false", ok, err)
+	}
+	if next, err := b.NextQueuedPrompt("proj", 2); err != nil || next != nil {
+		t.Errorf("NextQueuedPrompt() while q2 delivers = %+v, %v; want nil", next, err)
+	}
+	if n, err := b.RequeueStalePrompts(time.Now().Add(time.Minute)); err != nil || n != 1 {
+		t.Errorf("RequeueStalePrompts() = %d, %v; want 1", n, err)
+	}
+	if next, _ := a.NextQueuedPrompt("proj", 2); next == nil || next.ID != "q2" {
+		t.Errorf("NextQueuedPrompt() after requeue = %+v", next)
+	}
+	if p, err := a.GetQueuedPrompt("missing"); err != nil || p != nil {
+		t.Errorf("GetQueuedPrompt(missing) = %+v, %v", p, err)
+	}
+}
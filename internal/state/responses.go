@@ -0,0 +1,170 @@
+package state
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/codeblock"
+)
+
+// ResponseStatus is whether an agent's reply has been captured yet.
+type ResponseStatus string
+
+const (
+	ResponsePending  ResponseStatus = "pending"  // Agent not yet idle again
+	ResponseCaptured ResponseStatus = "captured" // Agent went idle; text is its full reply
+	ResponseTimeout  ResponseStatus = "timeout"  // Agent never went idle; text is what it printed so far
+	ResponseLost     ResponseStatus = "lost"     // Pane went away before the reply was captured
+)
+
+// AgentResponse is one agent's reply to a prompt from the prompt history.
+type AgentResponse struct {
+	ID         string                `json:"id"`
+	HistoryID  string                `json:"history_id"`
+	Session    string                `json:"session"`
+	Pane       int                   `json:"pane"`
+	PaneID     string                `json:"pane_id"`
+	AgentType  string                `json:"agent_type,omitempty"`
+	StartLine  int                   `json:"start_line"`
+	Prompt     string                `json:"prompt,omitempty"`
+	Status     ResponseStatus        `json:"status"`
+	Text       string                `json:"text,omitempty"`
+	CodeBlocks []codeblock.CodeBlock `json:"code_blocks,omitempty"`
+	Error      string                `json:"error,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+	CapturedAt *time.Time            `json:"captured_at,omitempty"`
+}
+
+// ResponseFilter selects responses. Zero fields match everything, except
+// Pane, where any negative value matches every pane.
+type ResponseFilter struct {
+	HistoryID string
+	Session   string
+	Pane      int
+	Status    ResponseStatus
+	Limit     int
+}
+
+const responseColumns = `id, history_id, session, pane, pane_id, COALESCE(agent_type, ''), start_line, COALESCE(prompt, ''), status, COALESCE(text, ''), COALESCE(code_blocks, ''), COALESCE(error, ''), created_at, captured_at`
+
+func scanResponse(row rowScanner) (*AgentResponse, error) {
+	var r AgentResponse
+	var blocks string
+	var captured sql.NullTime
+	if err := row.Scan(&r.ID, &r.HistoryID, &r.Session, &r.Pane, &r.PaneID, &r.AgentType, &r.StartLine, &r.Prompt, &r.Status, &r.Text, &blocks, &r.Error, &r.CreatedAt, &captured); err != nil {
+		return nil, err
+	}
+	if blocks != "" {
+		if err := json.Unmarshal([]byte(blocks), &r.CodeBlocks); err != nil {
+			return nil, fmt.Errorf("decode code blocks: %w", err)
+		}
+	}
+	if captured.Valid {
+		t := captured.Time
+		r.CapturedAt = &t
+	}
+	return &r, nil
+}
+
+// ========================
+// Response Operations
+// ========================
+
+// CreateResponse records a pending response. Its status and creation time
+// are set here.
+func (s *Store) CreateResponse(r *AgentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.Status = ResponsePending
+	r.CreatedAt = time.Now().UTC()
+	_, err := s.db.Exec(`
+		INSERT INTO responses (id, history_id, session, pane, pane_id, agent_type, start_line, prompt, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.ID, r.HistoryID, r.Session, r.Pane, r.PaneID, nullString(r.AgentType), r.StartLine, nullString(r.Prompt), r.Status, r.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create response: %w", err)
+	}
+	return nil
+}
+
+// GetResponse returns a response, or nil if there is none with id.
+func (s *Store) GetResponse(id string) (*AgentResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, err := scanResponse(s.db.QueryRow(`SELECT `+responseColumns+` FROM responses WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get response: %w", err)
+	}
+	return r, nil
+}
+
+// ListResponses returns the responses matching f, grouped by prompt with
+// the newest prompt first (history IDs sort by time) and in pane order.
+func (s *Store) ListResponses(f ResponseFilter) ([]AgentResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query(`
+		SELECT `+responseColumns+` FROM responses
+		WHERE (? = '' OR history_id = ?) AND (? = '' OR session = ?)
+		  AND (? < 0 OR pane = ?) AND (? = '' OR status = ?)
+		ORDER BY history_id DESC, pane, created_at DESC
+		LIMIT ?`,
+		f.HistoryID, f.HistoryID, f.Session, f.Session, f.Pane, f.Pane, f.Status, f.Status, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list responses: %w", err)
+	}
+	defer rows.Close()
+
+	var responses []AgentResponse
+	for rows.Next() {
+		r, err := scanResponse(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan response: %w", err)
+		}
+		responses = append(responses, *r)
+	}
+	return responses, rows.Err()
+}
+
+// FinishResponse stores the captured reply of a pending response. It
+// returns false if the response was no longer pending, for example because
+// another process captured it first.
+func (s *Store) FinishResponse(id string, status ResponseStatus, text string, blocks []codeblock.CodeBlock, errMsg string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var encoded sql.NullString
+	if len(blocks) > 0 {
+		data, err := json.Marshal(blocks)
+		if err != nil {
+			return false, fmt.Errorf("encode code blocks: %w", err)
+		}
+		encoded = sql.NullString{String: string(data), Valid: true}
+	}
+	res, err := s.db.Exec(`
+		UPDATE responses SET status = ?, text = ?, code_blocks = ?, error = ?, captured_at = ?
+		WHERE id = ? AND status = 'pending'`,
+		status, nullString(text), encoded, nullString(errMsg), time.Now().UTC(), id)
+	if err != nil {
+		return false, fmt.Errorf("finish response: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("finish response: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,60 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/codeblock"
+)
+
+func TestResponses_Lifecycle(t *testing.T) {
+	a, b := openSharedStores(t)
+
+	for _, r := range []*AgentResponse{
+		{ID: "r1", HistoryID: "h1", Session: "proj", Pane: 1, PaneID: "%1", AgentType: "cc", StartLine: 40, Prompt: "explain"},
+		{ID: "r2", HistoryID: "h1", Session: "proj", Pane: 2, PaneID: "%2", AgentType: "cod", StartLine: 12, Prompt: "explain"},
+		{ID: "r3", HistoryID: "h2", Session: "other", Pane: 1, PaneID: "%5", StartLine: 0},
+	} {
+		if err := a.CreateResponse(r); err != nil {
+			t.Fatalf("CreateResponse(%s) error: %v", r.ID, err)
+		}
+		if r.Status != ResponsePending || r.CreatedAt.IsZero() {
+			t.Errorf("CreateResponse(%s) = %+v", r.ID, r)
+		}
+	}
+
+	got, err := b.ListResponses(ResponseFilter{HistoryID: "h1", Pane: -1})
+	if err != nil {
+		t.Fatalf("ListResponses() error: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "r1" || got[1].ID != "r2" {
+		t.Fatalf("ListResponses(h1) = %+v", got)
+	}
+
+	blocks := []codeblock.CodeBlock{{Language: "go", Content: "package main", StartLine: 2, EndLine: 4}}
+	ok, err := b.FinishResponse("r1", ResponseCaptured, "Here you go", blocks, "")
+	if err != nil || !ok {
+		t.Fatalf("FinishResponse() = %v, %v", ok, err)
+	}
+	if ok, _ := a.FinishResponse("r1", ResponseTimeout, "late", nil, ""); ok {
+		t.Error("FinishResponse() replaced an already captured response")
+	}
+
+	r, err := a.GetResponse("r1")
+	if err != nil || r == nil {
+		t.Fatalf("GetResponse() = %v, %v", r, err)
+	}
+	if r.Status != ResponseCaptured || r.Text != "Here you go" || r.CapturedAt == nil {
+		t.Errorf("captured response = %+v", r)
+	}
+	if len(r.CodeBlocks) != 1 || r.CodeBlocks[0].Content != "package main" {
+		t.Errorf("code blocks = %+v", r.CodeBlocks)
+	}
+
+	pending, err := a.ListResponses(ResponseFilter{Pane: -1, Status: ResponsePending})
+	if err != nil || len(pending) != 2 {
+		t.Errorf("pending responses = %+v, %v", pending, err)
+	}
+	if r, err := a.GetResponse("missing"); r != nil || err != nil {
+		t.Errorf("GetResponse(missing) = %v, %v", r, err)
+	}
+}
@@ -658,6 +658,33 @@ func (s *Store) ListPendingApprovals() ([]Approval, error) {
 	return approvals, rows.Err()
 }
 
+// ListApprovalsFor returns the approvals for an action on a resource, newest
+// first, whatever their status.
+func (s *Store) ListApprovalsFor(action, resource string) ([]Approval, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT id, action, resource, COALESCE(reason, ''), requested_by, COALESCE(correlation_id, ''), requires_slb, created_at, expires_at, status, COALESCE(approved_by, ''), approved_at, COALESCE(denied_reason, '')
+		FROM approvals WHERE action = ? AND resource = ?
+		ORDER BY created_at DESC`, action, resource)
+
+	if err != nil {
+		return nil, fmt.Errorf("list approvals: %w", err)
+	}
+	defer rows.Close()
+
+	var approvals []Approval
+	for rows.Next() {
+		var appr Approval
+		if err := rows.Scan(&appr.ID, &appr.Action, &appr.Resource, &appr.Reason, &appr.RequestedBy, &appr.CorrelationID, &appr.RequiresSLB, &appr.CreatedAt, &appr.ExpiresAt, &appr.Status, &appr.ApprovedBy, &appr.ApprovedAt, &appr.DeniedReason); err != nil {
+			return nil, fmt.Errorf("scan approval: %w", err)
+		}
+		approvals = append(approvals, appr)
+	}
+	return approvals, rows.Err()
+}
+
 // ListExpiredPendingApprovals returns pending approvals that have expired.
 func (s *Store) ListExpiredPendingApprovals() ([]Approval, error) {
 	s.mu.RLock()
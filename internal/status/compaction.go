@@ -22,8 +22,9 @@ type CompactionEvent struct {
 }
 
 var (
-	compactionPatterns []CompactionPattern
-	patternsOnce       sync.Once
+	compactionPatterns   []CompactionPattern
+	compactionPatternsMu sync.RWMutex
+	patternsOnce         sync.Once
 )
 
 // Claude Code compaction patterns - these are the CRITICAL ones
@@ -113,6 +114,9 @@ func compilePatterns(patterns []string) []*regexp.Regexp {
 func DetectCompaction(output string, agentType string) *CompactionEvent {
 	initPatterns()
 
+	compactionPatternsMu.RLock()
+	defer compactionPatternsMu.RUnlock()
+
 	// Check agent-specific patterns and generic patterns (cp.Agent == "*")
 	// The condition includes patterns where cp.Agent matches agentType OR is "*"
 	for _, cp := range compactionPatterns {
@@ -140,6 +144,8 @@ func DetectCompaction(output string, agentType string) *CompactionEvent {
 					if i < len(genericPatterns) {
 						patternStr = genericPatterns[i]
 					}
+				default:
+					patternStr = pattern.String()
 				}
 
 				return &CompactionEvent{
@@ -164,6 +170,31 @@ func DetectCompactionWithPaneID(output, agentType, paneID string) *CompactionEve
 	return event
 }
 
+// AddCompactionPattern allows adding compaction patterns for an agent type at
+// runtime. It is thread-safe and can be called concurrently with detection.
+func AddCompactionPattern(agentType string, pattern string) error {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	initPatterns()
+
+	compactionPatternsMu.Lock()
+	defer compactionPatternsMu.Unlock()
+
+	for i := range compactionPatterns {
+		if compactionPatterns[i].Agent == agentType {
+			compactionPatterns[i].Patterns = append(compactionPatterns[i].Patterns, regex)
+			return nil
+		}
+	}
+	compactionPatterns = append(compactionPatterns, CompactionPattern{
+		Agent:    agentType,
+		Patterns: []*regexp.Regexp{regex},
+	})
+	return nil
+}
+
 // HasCompaction is a simple boolean check for compaction
 func HasCompaction(output string, agentType string) bool {
 	return DetectCompaction(output, agentType) != nil
@@ -245,3 +245,23 @@ func TestCompactionEvent_Fields(t *testing.T) {
 		t.Error("Pattern should be set")
 	}
 }
+
+func TestAddCompactionPattern(t *testing.T) {
+	const goose = "goose-compaction-test"
+	if HasCompaction("memory squashed to fit", goose) {
+		t.Fatal("pattern should not match before it is added")
+	}
+	if err := AddCompactionPattern(goose, `(?i)memory squashed`); err != nil {
+		t.Fatalf("AddCompactionPattern: %v", err)
+	}
+	event := DetectCompaction("Memory squashed to fit", goose)
+	if event == nil || event.Pattern != `(?i)memory squashed` {
+		t.Fatalf("DetectCompaction = %+v", event)
+	}
+	if HasCompaction("Memory squashed to fit", "cc") {
+		t.Error("plugin pattern must not apply to other agent types")
+	}
+	if err := AddCompactionPattern(goose, `(unclosed`); err == nil {
+		t.Error("AddCompactionPattern should fail with invalid regex")
+	}
+}
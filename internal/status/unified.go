@@ -83,8 +83,18 @@ func (d *UnifiedDetector) determineState(output, agentType string, lastActivity
 	threshold := time.Duration(d.config.ActivityThreshold) * time.Second
 	isLowVelocity := time.Since(lastActivity) >= threshold
 
+	// Plugin agents may declare their own idle, working and error output
+	plugin, isPlugin := agent.RegisteredPatternSet(agent.AgentType(agentType))
+	var recent string
+	if isPlugin {
+		recent = recentLines(StripANSI(output), 20)
+	}
+
 	// Check if at prompt (idle) - prioritize this when velocity is low
 	isAtPrompt := DetectIdleFromOutput(output, agentType)
+	if isPlugin && !isAtPrompt {
+		isAtPrompt = plugin.MatchesIdle(recentLines(recent, 5))
+	}
 	if isAtPrompt && isLowVelocity {
 		// Agent is at prompt and not actively outputting - clearly idle
 		return StateIdle, ErrorNone
@@ -94,6 +104,14 @@ func (d *UnifiedDetector) determineState(output, agentType string, lastActivity
 	if errType := DetectErrorInOutput(output); errType != ErrorNone {
 		return StateError, errType
 	}
+	if isPlugin {
+		if plugin.MatchesRateLimit(recent) {
+			return StateError, ErrorRateLimit
+		}
+		if plugin.MatchesError(recent) {
+			return StateError, ErrorGeneric
+		}
+	}
 
 	// Check if at prompt (for cases with recent activity - might still be processing)
 	if isAtPrompt {
@@ -110,6 +128,9 @@ func (d *UnifiedDetector) determineState(output, agentType string, lastActivity
 	if !isLowVelocity {
 		return StateWorking, ErrorNone
 	}
+	if isPlugin && plugin.MatchesWorking(recentLines(recent, 5)) {
+		return StateWorking, ErrorNone
+	}
 
 	// Heuristic: if no recent activity and output suggests agent is waiting,
 	// prefer idle over unknown. This catches cases where:
@@ -134,7 +155,8 @@ func (d *UnifiedDetector) determineState(output, agentType string, lastActivity
 }
 
 // isKnownAgentType returns true for AI agent types that have predictable
-// working/idle behavior (cc=Claude Code, cod=Codex, gmi=Gemini).
+// working/idle behavior (cc=Claude Code, cod=Codex, gmi=Gemini), including
+// agent types registered by plugins.
 func isKnownAgentType(agentType string) bool {
 	switch agentType {
 	case string(agent.AgentTypeClaudeCode),
@@ -143,8 +165,17 @@ func isKnownAgentType(agentType string) bool {
 		"cursor", "windsurf", "aider":
 		return true
 	default:
-		return false
+		return agent.IsRegistered(agent.AgentType(agentType))
+	}
+}
+
+// recentLines returns the last n lines of output.
+func recentLines(output string, n int) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) <= n {
+		return strings.Join(lines, "\n")
 	}
+	return strings.Join(lines[len(lines)-n:], "\n")
 }
 
 // looksLikeIdle applies heuristics to detect likely idle state when
@@ -2,10 +2,12 @@ package status
 
 import (
 	"os/exec"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/Dicklesworthstone/ntm/internal/agent"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
@@ -941,3 +943,41 @@ func TestIsKnownAgentType(t *testing.T) {
 		})
 	}
 }
+
+func TestDetermineState_PluginAgent(t *testing.T) {
+	const goose = "goose-status-test"
+	ps := &agent.PatternSet{
+		WorkingRegexes:   []*regexp.Regexp{regexp.MustCompile(`Thinking`)},
+		RateLimitRegexes: []*regexp.Regexp{regexp.MustCompile(`(?i)allowance used up`)},
+		ErrorRegexes:     []*regexp.Regexp{regexp.MustCompile(`^oops:`)},
+	}
+	if err := agent.RegisterPatternSet(goose, ps); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { agent.UnregisterPatternSet(goose) })
+	if err := AddPromptPattern(goose, `^goose>\s*$`, "Goose prompt"); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDetector()
+	idleSince := time.Now().Add(-time.Minute)
+	tests := []struct {
+		output    string
+		wantState AgentState
+		wantErr   ErrorType
+	}{
+		{"answer\ngoose> ", StateIdle, ErrorNone},
+		{"reading files\nThinking about the change and what to do next", StateWorking, ErrorNone},
+		{"daily allowance used up", StateError, ErrorRateLimit},
+		{"oops: the tool crashed while doing the thing", StateError, ErrorGeneric},
+	}
+	for _, tt := range tests {
+		state, errType := d.determineState(tt.output, goose, idleSince)
+		if state != tt.wantState || errType != tt.wantErr {
+			t.Errorf("determineState(%q) = %s/%s, want %s/%s", tt.output, state, errType, tt.wantState, tt.wantErr)
+		}
+	}
+	if !isKnownAgentType(goose) {
+		t.Error("registered plugin type should count as a known agent type")
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/ntm/internal/adapter"
+	"github.com/Dicklesworthstone/ntm/internal/agent"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
@@ -399,29 +401,35 @@ func (l *AgentLauncher) LaunchAgentWithContext(session string, pane int, agentTy
 	return l.LaunchAgent(session, pane, agentType)
 }
 
-// ValidateAgentType checks if the given agent type is valid.
+// ValidateAgentType checks that the given agent type has a registered adapter.
 func ValidateAgentType(agentType string) error {
-	switch agentType {
-	case AgentCC, AgentCOD, AgentGMI:
+	if _, ok := adapter.Lookup(agent.AgentType(agentType)); ok {
 		return nil
-	default:
-		return fmt.Errorf("invalid agent type %q: must be one of %s, %s, %s",
-			agentType, AgentCC, AgentCOD, AgentGMI)
 	}
+	var types []string
+	for _, a := range adapter.All() {
+		types = append(types, string(a.Type()))
+	}
+	return fmt.Errorf("invalid agent type %q: must be one of %s",
+		agentType, strings.Join(types, ", "))
 }
 
-// DefaultAgentCommands maps agent types to their default binary names.
-var DefaultAgentCommands = map[string]string{
-	"cc":  "claude", // Claude Code CLI
-	"cod": "codex",  // OpenAI Codex CLI
-	"gmi": "gemini", // Google Gemini CLI
-}
+// DefaultAgentCommands maps agent types to their default binary names, as
+// declared by their adapters.
+var DefaultAgentCommands = map[string]string{}
+
+// DefaultAgentArgs provides default arguments per agent type, as declared by
+// their adapters.
+var DefaultAgentArgs = map[string][]string{}
 
-// DefaultAgentArgs provides default arguments per agent type.
-var DefaultAgentArgs = map[string][]string{
-	"cc":  {"--dangerously-skip-permissions"},
-	"cod": {"--quiet", "--auto-approve"},
-	"gmi": {"--non-interactive"},
+func init() {
+	for _, a := range adapter.All() {
+		launch := a.Launch()
+		DefaultAgentCommands[string(a.Type())] = launch.Binary
+		if len(launch.Args) > 0 {
+			DefaultAgentArgs[string(a.Type())] = launch.Args
+		}
+	}
 }
 
 // LaunchCommand represents a complete agent launch specification.
@@ -534,6 +542,8 @@ func (b *LaunchCommandBuilder) BuildLaunchCommand(spec PaneSpec, workDir string)
 			binary = customPath
 		} else if defaultCmd, ok := DefaultAgentCommands[agentType]; ok {
 			binary = defaultCmd
+		} else if a, ok := adapter.Lookup(agent.AgentType(agentType)); ok {
+			binary = a.Launch().Binary // Registered after startup, e.g. a plugin
 		} else {
 			binary = agentType // Fallback to agent type as command
 		}
@@ -548,6 +558,8 @@ func (b *LaunchCommandBuilder) BuildLaunchCommand(spec PaneSpec, workDir string)
 		args = customArgs
 	} else if defaultArgs, ok := DefaultAgentArgs[agentType]; ok {
 		args = defaultArgs
+	} else if a, ok := adapter.Lookup(agent.AgentType(agentType)); ok {
+		args = a.Launch().Args
 	}
 
 	// Build environment variables
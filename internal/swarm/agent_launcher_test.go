@@ -299,6 +299,8 @@ func TestValidateAgentType(t *testing.T) {
 		{AgentCC, false},
 		{AgentCOD, false},
 		{AgentGMI, false},
+		{"aider", false},
+		{"ollama", false},
 		{"invalid", true},
 		{"", true},
 		{"CC", true}, // Case sensitive
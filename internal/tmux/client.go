@@ -3,17 +3,28 @@ package tmux
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Client handles tmux operations, optionally on a remote host
 type Client struct {
 	Remote string // "user@host" or empty for local
+
+	// Host is the inventory name of the remote host, for display.
+	Host string
+	// SSHOptions are extra ssh arguments, such as -p or -i.
+	SSHOptions []string
+
+	// control, when set, multiplexes commands over a persistent tmux -C
+	// connection instead of forking a process per command.
+	control atomic.Pointer[ControlMode]
 }
 
 // NewClient creates a new tmux client
@@ -86,14 +97,20 @@ func (c *Client) RunContext(ctx context.Context, args ...string) (string, error)
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if cm := c.control.Load(); cm != nil && controlModeCompatible(args) {
+		out, err := cm.Run(ctx, args...)
+		if !errors.Is(err, ErrControlClosed) {
+			return strings.TrimSpace(out), err
+		}
+		// Connection dropped: fall through to a one-shot process.
+	}
 	if c.Remote == "" {
 		return runLocalContext(ctx, args...)
 	}
 
 	// Remote execution via ssh
 	remoteCmd := buildRemoteShellCommand("tmux", args...)
-	// Use "--" to prevent Remote from being parsed as an ssh option.
-	return runSSHContext(ctx, "--", c.Remote, remoteCmd)
+	return runSSHContext(ctx, c.sshArgs(false, remoteCmd)...)
 }
 
 // ShellQuote returns a POSIX-shell-safe single-quoted string.
@@ -147,10 +164,8 @@ func runSSHContext(ctx context.Context, args ...string) (string, error) {
 	// produces a single string like "tmux 'arg1' 'arg2'".
 	// We want: ssh host /bin/sh -c "tmux 'arg1' 'arg2'"
 	//
-	// args[0] is flags like "-t"
-	// args[1] is "--"
-	// args[2] is remote host
-	// args[3] is the command string
+	// args are ssh options, then "--", the remote host and, last, the
+	// command string (see sshArgs).
 
 	if len(args) > 0 {
 		commandIndex := len(args) - 1
@@ -0,0 +1,687 @@
+package tmux
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============== Control Mode Backend ==============
+//
+// Every Client.Run call normally forks a tmux (or ssh + tmux) process. The
+// dashboard, robot status, health checks and pane streamers issue dozens of
+// these per second, so a long-lived `tmux -C` control-mode connection can be
+// attached to a Client instead. Commands are written to the control client's
+// stdin and their replies are read back from %begin/%end (or %error) blocks in
+// the order they were sent. Everything outside a block is a notification and
+// is published as a ControlEvent.
+
+// ErrControlClosed is returned when a command is issued on a control-mode
+// connection that has exited. Client.RunContext treats it as a signal to fall
+// back to a one-shot tmux process.
+var ErrControlClosed = errors.New("tmux control mode connection closed")
+
+// DefaultControlStartTimeout bounds how long StartControlMode waits for the
+// control client to attach.
+const DefaultControlStartTimeout = 10 * time.Second
+
+// ControlEventType identifies a control-mode notification.
+type ControlEventType string
+
+// Notification types surfaced by ControlMode. Names match the tmux
+// notification without the leading '%'. ControlEventPaneExited is synthesized
+// by ntm: tmux has no per-pane exit notification, so the pane list is
+// re-read after layout changes and panes that disappeared are reported.
+const (
+	ControlEventOutput              ControlEventType = "output"
+	ControlEventExtendedOutput      ControlEventType = "extended-output"
+	ControlEventWindowAdd           ControlEventType = "window-add"
+	ControlEventWindowClose         ControlEventType = "window-close"
+	ControlEventWindowRenamed       ControlEventType = "window-renamed"
+	ControlEventUnlinkedWindowAdd   ControlEventType = "unlinked-window-add"
+	ControlEventUnlinkedWindowClose ControlEventType = "unlinked-window-close"
+	ControlEventWindowPaneChanged   ControlEventType = "window-pane-changed"
+	ControlEventLayoutChange        ControlEventType = "layout-change"
+	ControlEventSessionChanged      ControlEventType = "session-changed"
+	ControlEventSessionsChanged     ControlEventType = "sessions-changed"
+	ControlEventSessionRenamed      ControlEventType = "session-renamed"
+	ControlEventPaneModeChanged     ControlEventType = "pane-mode-changed"
+	ControlEventPaneExited          ControlEventType = "pane-exited"
+	ControlEventExit                ControlEventType = "exit"
+)
+
+// ControlEvent is a notification received from a control-mode connection.
+type ControlEvent struct {
+	Type      ControlEventType `json:"type"`
+	PaneID    string           `json:"pane_id,omitempty"`    // e.g. "%3"
+	WindowID  string           `json:"window_id,omitempty"`  // e.g. "@1"
+	SessionID string           `json:"session_id,omitempty"` // e.g. "$0"
+	Data      string           `json:"data,omitempty"`       // decoded %output payload
+	Args      []string         `json:"args,omitempty"`       // remaining raw arguments
+	Timestamp time.Time        `json:"timestamp"`
+}
+
+// controlResult carries the reply block for one command.
+type controlResult struct {
+	output string
+	err    error
+}
+
+// ControlMode is a persistent `tmux -C` connection that multiplexes commands
+// and streams notifications.
+type ControlMode struct {
+	remote  string
+	session string
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	// writeMu keeps the pending queue in the same order as the bytes written
+	// to stdin, since tmux replies strictly in submission order.
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending []chan controlResult
+	subs    map[int]chan ControlEvent
+	nextSub int
+	panes   map[string]struct{}
+	closed  bool
+	err     error
+
+	ready     chan struct{}
+	readyOnce sync.Once
+	done      chan struct{}
+	refresh   chan struct{}
+}
+
+// StartControlMode starts a control-mode client attached to session. When
+// remote is non-empty the client runs over ssh. tmux only emits %output for
+// panes in the attached session, but commands may target any session.
+func StartControlMode(ctx context.Context, remote, session string) (*ControlMode, error) {
+	return NewClient(remote).StartControlMode(ctx, session)
+}
+
+// StartControlMode starts a control-mode client attached to session on the
+// client's host, connecting with the client's ssh options when remote.
+func (c *Client) StartControlMode(ctx context.Context, session string) (*ControlMode, error) {
+	remote := c.Remote
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if session == "" {
+		return nil, errors.New("control mode requires a session to attach to")
+	}
+
+	args := []string{"-C", "attach-session", "-t", session}
+	var cmd *exec.Cmd
+	if remote == "" {
+		cmd = exec.Command(BinaryPath(), args...)
+	} else {
+		remoteCmd := buildRemoteShellCommand("tmux", args...)
+		cmd = exec.Command("ssh", c.sshArgs(false, fmt.Sprintf("/bin/sh -c %s", ShellQuote(remoteCmd)))...)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("control mode stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("control mode stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start tmux control mode: %w", err)
+	}
+
+	cm := &ControlMode{
+		remote:  remote,
+		session: session,
+		cmd:     cmd,
+		stdin:   stdin,
+		subs:    make(map[int]chan ControlEvent),
+		ready:   make(chan struct{}),
+		done:    make(chan struct{}),
+		refresh: make(chan struct{}, 1),
+	}
+	go cm.readLoop(stdout)
+
+	timer := time.NewTimer(DefaultControlStartTimeout)
+	defer timer.Stop()
+	select {
+	case <-cm.ready:
+	case <-cm.done:
+		return nil, fmt.Errorf("tmux control mode attach to %s: %w", session, cm.Err())
+	case <-ctx.Done():
+		_ = cm.Close()
+		return nil, ctx.Err()
+	case <-timer.C:
+		_ = cm.Close()
+		return nil, fmt.Errorf("tmux control mode attach to %s: timed out", session)
+	}
+
+	go cm.paneWatcher()
+	cm.requestPaneRefresh()
+	return cm, nil
+}
+
+// Session returns the session the control client is attached to.
+func (cm *ControlMode) Session() string {
+	return cm.session
+}
+
+// Remote returns the ssh target, or "" for a local connection.
+func (cm *ControlMode) Remote() string {
+	return cm.remote
+}
+
+// Done is closed when the connection exits.
+func (cm *ControlMode) Done() <-chan struct{} {
+	return cm.done
+}
+
+// Alive reports whether the connection can still accept commands.
+func (cm *ControlMode) Alive() bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return !cm.closed
+}
+
+// Err returns the reason the connection closed, if any.
+func (cm *ControlMode) Err() error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.err != nil {
+		return cm.err
+	}
+	if cm.closed {
+		return ErrControlClosed
+	}
+	return nil
+}
+
+// Run sends a single tmux command and waits for its reply block.
+func (cm *ControlMode) Run(ctx context.Context, args ...string) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(args) == 0 {
+		return "", errors.New("control mode: empty command")
+	}
+
+	line := encodeControlCommand(args)
+	ch := make(chan controlResult, 1)
+
+	cm.writeMu.Lock()
+	cm.mu.Lock()
+	if cm.closed {
+		cm.mu.Unlock()
+		cm.writeMu.Unlock()
+		return "", ErrControlClosed
+	}
+	cm.pending = append(cm.pending, ch)
+	cm.mu.Unlock()
+	_, err := io.WriteString(cm.stdin, line+"\n")
+	cm.writeMu.Unlock()
+	if err != nil {
+		// The reader will fail the queued request once stdout closes.
+		return "", fmt.Errorf("%w: %v", ErrControlClosed, err)
+	}
+
+	select {
+	case res := <-ch:
+		if res.err != nil && !errors.Is(res.err, ErrControlClosed) {
+			return "", fmt.Errorf("tmux %s: %w", strings.Join(args, " "), res.err)
+		}
+		return res.output, res.err
+	case <-ctx.Done():
+		// The reply will still arrive and be dropped into the buffered channel.
+		return "", ctx.Err()
+	}
+}
+
+// Subscribe registers for notifications. Events are delivered without
+// blocking the reader; if the buffer is full the event is dropped for that
+// subscriber. The returned function unsubscribes and closes the channel.
+func (cm *ControlMode) Subscribe(buffer int) (<-chan ControlEvent, func()) {
+	if buffer <= 0 {
+		buffer = 256
+	}
+	ch := make(chan ControlEvent, buffer)
+
+	cm.mu.Lock()
+	if cm.closed {
+		cm.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	id := cm.nextSub
+	cm.nextSub++
+	cm.subs[id] = ch
+	cm.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			cm.mu.Lock()
+			if sub, ok := cm.subs[id]; ok {
+				delete(cm.subs, id)
+				close(sub)
+			}
+			cm.mu.Unlock()
+		})
+	}
+}
+
+// Close detaches the control client and waits for it to exit.
+func (cm *ControlMode) Close() error {
+	cm.writeMu.Lock()
+	_ = cm.stdin.Close()
+	cm.writeMu.Unlock()
+
+	select {
+	case <-cm.done:
+	case <-time.After(2 * time.Second):
+		if cm.cmd.Process != nil {
+			_ = cm.cmd.Process.Kill()
+		}
+		<-cm.done
+	}
+	return nil
+}
+
+// readLoop parses stdout until the control client exits.
+func (cm *ControlMode) readLoop(stdout io.Reader) {
+	reader := bufio.NewReaderSize(stdout, 64*1024)
+
+	var (
+		inBlock    bool
+		clientCmd  bool
+		blockLines []string
+		exitReason string
+	)
+
+	for {
+		raw, err := reader.ReadString('\n')
+		if err != nil && raw == "" {
+			break
+		}
+		line := strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r")
+
+		if inBlock {
+			if kind, _, flags, ok := parseControlGuard(line); ok && kind != "begin" {
+				inBlock = false
+				if clientCmd {
+					res := controlResult{output: strings.Join(blockLines, "\n")}
+					if kind == "error" {
+						res = controlResult{err: errors.New(strings.TrimSpace(res.output))}
+					}
+					cm.deliver(res)
+				} else if flags == 0 {
+					cm.markReady()
+				}
+				blockLines = nil
+				continue
+			}
+			blockLines = append(blockLines, line)
+			continue
+		}
+
+		if kind, _, flags, ok := parseControlGuard(line); ok && kind == "begin" {
+			inBlock = true
+			clientCmd = flags&1 == 1
+			blockLines = nil
+			continue
+		}
+
+		ev, ok := ParseControlNotification(line)
+		if !ok {
+			continue
+		}
+		switch ev.Type {
+		case ControlEventExit:
+			if len(ev.Args) > 0 {
+				exitReason = strings.Join(ev.Args, " ")
+			}
+		case ControlEventSessionChanged:
+			cm.markReady()
+		case ControlEventLayoutChange, ControlEventWindowClose, ControlEventUnlinkedWindowClose,
+			ControlEventWindowAdd, ControlEventUnlinkedWindowAdd, ControlEventSessionsChanged:
+			cm.requestPaneRefresh()
+		}
+		cm.publish(ev)
+	}
+
+	waitErr := cm.cmd.Wait()
+
+	cm.mu.Lock()
+	cm.closed = true
+	switch {
+	case exitReason != "":
+		cm.err = fmt.Errorf("%w: %s", ErrControlClosed, exitReason)
+	case waitErr != nil:
+		cm.err = fmt.Errorf("%w: %v", ErrControlClosed, waitErr)
+	default:
+		cm.err = ErrControlClosed
+	}
+	pending := cm.pending
+	cm.pending = nil
+	subs := cm.subs
+	cm.subs = make(map[int]chan ControlEvent)
+	cm.mu.Unlock()
+
+	for _, ch := range pending {
+		ch <- controlResult{err: ErrControlClosed}
+	}
+	for _, ch := range subs {
+		close(ch)
+	}
+	close(cm.done)
+}
+
+func (cm *ControlMode) markReady() {
+	cm.readyOnce.Do(func() { close(cm.ready) })
+}
+
+// deliver hands a reply block to the oldest outstanding command.
+func (cm *ControlMode) deliver(res controlResult) {
+	cm.mu.Lock()
+	if len(cm.pending) == 0 {
+		cm.mu.Unlock()
+		return
+	}
+	ch := cm.pending[0]
+	cm.pending = cm.pending[1:]
+	cm.mu.Unlock()
+	ch <- res
+}
+
+func (cm *ControlMode) publish(ev ControlEvent) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	for _, ch := range cm.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func (cm *ControlMode) requestPaneRefresh() {
+	select {
+	case cm.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// paneWatcher re-lists panes after structural changes and synthesizes
+// ControlEventPaneExited for panes that no longer exist.
+func (cm *ControlMode) paneWatcher() {
+	for {
+		select {
+		case <-cm.done:
+			return
+		case <-cm.refresh:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultCommandTimeout)
+		out, err := cm.Run(ctx, "list-panes", "-a", "-F", "#{pane_id}")
+		cancel()
+		if err != nil {
+			continue
+		}
+
+		current := make(map[string]struct{})
+		for _, id := range strings.Split(out, "\n") {
+			if id = strings.TrimSpace(id); id != "" {
+				current[id] = struct{}{}
+			}
+		}
+
+		cm.mu.Lock()
+		previous := cm.panes
+		cm.panes = current
+		cm.mu.Unlock()
+
+		if previous == nil {
+			continue
+		}
+		for id := range previous {
+			if _, ok := current[id]; !ok {
+				cm.publish(ControlEvent{Type: ControlEventPaneExited, PaneID: id, Timestamp: time.Now()})
+			}
+		}
+	}
+}
+
+// parseControlGuard recognizes %begin, %end and %error lines and returns the
+// guard kind, command number and flags.
+func parseControlGuard(line string) (kind string, number int64, flags int, ok bool) {
+	if !strings.HasPrefix(line, "%") {
+		return "", 0, 0, false
+	}
+	fields := strings.Fields(line)
+	if len(fields) != 4 {
+		return "", 0, 0, false
+	}
+	switch fields[0] {
+	case "%begin", "%end", "%error":
+	default:
+		return "", 0, 0, false
+	}
+	n, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return "", 0, 0, false
+	}
+	f, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return "", 0, 0, false
+	}
+	return fields[0][1:], n, f, true
+}
+
+// ParseControlNotification parses a control-mode notification line (one that
+// appears outside a %begin/%end block).
+func ParseControlNotification(line string) (ControlEvent, bool) {
+	if !strings.HasPrefix(line, "%") || len(line) < 2 {
+		return ControlEvent{}, false
+	}
+	name, rest, _ := strings.Cut(line[1:], " ")
+	ev := ControlEvent{Type: ControlEventType(name), Timestamp: time.Now()}
+
+	switch ev.Type {
+	case ControlEventOutput:
+		pane, data, _ := strings.Cut(rest, " ")
+		ev.PaneID = pane
+		ev.Data = UnescapeControlOutput(data)
+	case ControlEventExtendedOutput:
+		// %extended-output %pane age ... : value
+		head, data, _ := strings.Cut(rest, " : ")
+		fields := strings.Fields(head)
+		if len(fields) > 0 {
+			ev.PaneID = fields[0]
+			ev.Args = fields[1:]
+		}
+		ev.Data = UnescapeControlOutput(data)
+	case ControlEventWindowAdd, ControlEventWindowClose, ControlEventUnlinkedWindowAdd,
+		ControlEventUnlinkedWindowClose, ControlEventLayoutChange:
+		fields := strings.Fields(rest)
+		if len(fields) > 0 {
+			ev.WindowID = fields[0]
+			ev.Args = fields[1:]
+		}
+	case ControlEventWindowRenamed:
+		window, name, _ := strings.Cut(rest, " ")
+		ev.WindowID = window
+		ev.Data = name
+	case ControlEventWindowPaneChanged:
+		fields := strings.Fields(rest)
+		if len(fields) > 0 {
+			ev.WindowID = fields[0]
+		}
+		if len(fields) > 1 {
+			ev.PaneID = fields[1]
+		}
+	case ControlEventPaneModeChanged:
+		ev.PaneID = strings.TrimSpace(rest)
+	case ControlEventSessionChanged, ControlEventSessionRenamed:
+		session, name, _ := strings.Cut(rest, " ")
+		ev.SessionID = session
+		ev.Data = name
+	default:
+		if rest != "" {
+			ev.Args = strings.Fields(rest)
+		}
+	}
+	return ev, true
+}
+
+// UnescapeControlOutput decodes the octal escapes tmux uses for %output
+// payloads (characters below ASCII 32 and backslash are sent as \ooo).
+func UnescapeControlOutput(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && isOctal(s[i+1]) && isOctal(s[i+2]) && isOctal(s[i+3]) {
+			b.WriteByte((s[i+1]-'0')<<6 | (s[i+2]-'0')<<3 | (s[i+3] - '0'))
+			i += 3
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+// encodeControlCommand renders args as a single tmux command line. Each
+// argument is double-quoted so the tmux parser treats it as one word; quote,
+// backslash, '$' and control characters are escaped so multi-line content
+// cannot terminate the command early.
+func encodeControlCommand(args []string) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		if i == 0 {
+			parts[i] = arg
+			continue
+		}
+		parts[i] = quoteControlArg(arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+func quoteControlArg(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\' || c == '$':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, `\%03o`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// controlModeCompatible reports whether a command can be routed through a
+// control-mode connection. Server flags (e.g. -V), commands that act on the
+// calling client, and commands that read stdin must run as their own process.
+func controlModeCompatible(args []string) bool {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return false
+	}
+	switch args[0] {
+	case "attach", "attach-session", "switch-client", "switchc", "detach-client", "detach", "kill-server":
+		return false
+	case "display-message", "display":
+		// Without an explicit target tmux resolves the control client's
+		// session rather than the user's.
+		hasTarget := false
+		for _, a := range args[1:] {
+			if a == "-t" {
+				hasTarget = true
+				break
+			}
+		}
+		if !hasTarget {
+			return false
+		}
+	}
+	for _, a := range args[1:] {
+		if a == "-" {
+			return false
+		}
+	}
+	return true
+}
+
+// NewControlClient returns a Client whose commands are multiplexed over a
+// control-mode connection attached to session. Commands fall back to
+// one-shot processes if the connection drops. Call Close when finished.
+func NewControlClient(ctx context.Context, remote, session string) (*Client, error) {
+	c := NewClient(remote)
+	if err := c.AttachControlMode(ctx, session); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// AttachControlMode routes the client's commands over a control-mode
+// connection attached to session. It does nothing if a live connection is
+// already attached. On error the client keeps forking a process per command.
+func (c *Client) AttachControlMode(ctx context.Context, session string) error {
+	if cm := c.control.Load(); cm != nil && cm.Alive() {
+		return nil
+	}
+	cm, err := c.StartControlMode(ctx, session)
+	if err != nil {
+		return err
+	}
+	if old := c.control.Swap(cm); old != nil {
+		_ = old.Close()
+	}
+	return nil
+}
+
+// Control returns the control-mode connection backing the client, or nil.
+func (c *Client) Control() *ControlMode {
+	if c == nil {
+		return nil
+	}
+	return c.control.Load()
+}
+
+// Close shuts down the control-mode connection, if any. The client keeps
+// working with a process per command.
+func (c *Client) Close() error {
+	if c == nil {
+		return nil
+	}
+	cm := c.control.Swap(nil)
+	if cm == nil {
+		return nil
+	}
+	return cm.Close()
+}
@@ -0,0 +1,278 @@
+package tmux
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseControlGuard(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantKind  string
+		wantFlags int
+		wantOK    bool
+	}{
+		{"%begin 1792149263 268 1", "begin", 1, true},
+		{"%end 1792149263 268 1", "end", 1, true},
+		{"%error 1792149263 270 1", "error", 1, true},
+		{"%begin 1792149263 263 0", "begin", 0, true},
+		{"%output %0 hello", "", 0, false},
+		{"%end", "", 0, false},
+		{"plain output", "", 0, false},
+	}
+	for _, tt := range tests {
+		kind, _, flags, ok := parseControlGuard(tt.line)
+		if ok != tt.wantOK || kind != tt.wantKind || flags != tt.wantFlags {
+			t.Errorf("parseControlGuard(%q) = (%q, %d, %v), want (%q, %d, %v)",
+				tt.line, kind, flags, ok, tt.wantKind, tt.wantFlags, tt.wantOK)
+		}
+	}
+}
+
+func TestParseControlNotification(t *testing.T) {
+	ev, ok := ParseControlNotification(`%output %3 hello\015\012world`)
+	if !ok {
+		t.Fatal("expected notification")
+	}
+	if ev.Type != ControlEventOutput || ev.PaneID != "%3" || ev.Data != "hello\r\nworld" {
+		t.Errorf("unexpected output event: %+v", ev)
+	}
+
+	ev, _ = ParseControlNotification("%window-add @4")
+	if ev.Type != ControlEventWindowAdd || ev.WindowID != "@4" {
+		t.Errorf("unexpected window-add event: %+v", ev)
+	}
+
+	ev, _ = ParseControlNotification("%window-pane-changed @0 %1")
+	if ev.WindowID != "@0" || ev.PaneID != "%1" {
+		t.Errorf("unexpected window-pane-changed event: %+v", ev)
+	}
+
+	ev, _ = ParseControlNotification("%session-changed $0 myproj")
+	if ev.SessionID != "$0" || ev.Data != "myproj" {
+		t.Errorf("unexpected session-changed event: %+v", ev)
+	}
+
+	ev, _ = ParseControlNotification("%exit detached")
+	if ev.Type != ControlEventExit || len(ev.Args) != 1 || ev.Args[0] != "detached" {
+		t.Errorf("unexpected exit event: %+v", ev)
+	}
+
+	if _, ok := ParseControlNotification("not a notification"); ok {
+		t.Error("expected non-% line to be rejected")
+	}
+}
+
+func TestUnescapeControlOutput(t *testing.T) {
+	tests := map[string]string{
+		"plain":            "plain",
+		`a\134b`:           `a\b`,
+		`\033[K\015`:       "\x1b[K\r",
+		`trailing\01`:      `trailing\01`,
+		`not\9octal`:       `not\9octal`,
+		`\342\224\200 box`: "─ box",
+	}
+	for in, want := range tests {
+		if got := UnescapeControlOutput(in); got != want {
+			t.Errorf("UnescapeControlOutput(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestEncodeControlCommand(t *testing.T) {
+	got := encodeControlCommand([]string{"send-keys", "-t", "proj:1.2", "-l", "echo \"$HOME\" \\ done\nnext\x03"})
+	want := `send-keys "-t" "proj:1.2" "-l" "echo \"\$HOME\" \\ done\nnext\003"`
+	if got != want {
+		t.Errorf("encodeControlCommand() =\n  %s\nwant\n  %s", got, want)
+	}
+	if strings.Contains(got, "\n") {
+		t.Error("encoded command must be a single line")
+	}
+}
+
+func TestControlModeCompatible(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"capture-pane", "-t", "s:1", "-p"}, true},
+		{[]string{"list-sessions"}, true},
+		{[]string{"display-message", "-p", "-t", "s", "#{pane_id}"}, true},
+		{[]string{"display-message", "-p", "#{session_name}"}, false},
+		{[]string{"-V"}, false},
+		{[]string{"attach", "-t", "s"}, false},
+		{[]string{"switch-client", "-t", "s"}, false},
+		{[]string{"load-buffer", "-b", "x", "-"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := controlModeCompatible(tt.args); got != tt.want {
+			t.Errorf("controlModeCompatible(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestControlModeRunAndEvents(t *testing.T) {
+	session := createTestSession(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	client, err := NewControlClient(ctx, "", session)
+	if err != nil {
+		t.Skipf("control mode unavailable: %v", err)
+	}
+	defer client.Close()
+
+	events, unsubscribe := client.Control().Subscribe(64)
+	defer unsubscribe()
+
+	// Concurrent commands must each get their own reply.
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			msg := strings.Repeat("x", i+1)
+			out, err := client.Run("display-message", "-p", "-t", session, msg)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if out != msg {
+				errs <- &mismatchError{got: out, want: msg}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// Errors come back as %error blocks.
+	if _, err := client.Run("has-session", "-t", session+"_missing"); err == nil {
+		t.Error("expected error for missing session")
+	}
+
+	// Multi-line content survives the control-mode encoding.
+	content := "line one\nline \"two\" $HOME\n"
+	if err := client.RunSilent("set-buffer", "-b", "ntm-control-test", "--", content); err != nil {
+		t.Fatalf("set-buffer: %v", err)
+	}
+	// show-buffer vis-escapes '$', so compare the saved bytes instead.
+	saved := filepath.Join(t.TempDir(), "buffer.txt")
+	if err := client.RunSilent("save-buffer", "-b", "ntm-control-test", saved); err != nil {
+		t.Fatalf("save-buffer: %v", err)
+	}
+	got, err := os.ReadFile(saved)
+	if err != nil {
+		t.Fatalf("read saved buffer: %v", err)
+	}
+	if string(got) != content {
+		t.Errorf("buffer = %q, want %q", got, content)
+	}
+	_ = client.RunSilent("delete-buffer", "-b", "ntm-control-test")
+
+	// New panes are announced and exited panes are synthesized.
+	paneID, err := client.Run("split-window", "-t", session, "-P", "-F", "#{pane_id}", "sleep 0.3")
+	if err != nil {
+		t.Fatalf("split-window: %v", err)
+	}
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == ControlEventPaneExited && ev.PaneID == paneID {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for pane-exited event for %s", paneID)
+		}
+	}
+}
+
+type mismatchError struct{ got, want string }
+
+func (e *mismatchError) Error() string { return "got " + e.got + ", want " + e.want }
+
+func TestPaneStreamerUsesControlMode(t *testing.T) {
+	session := createTestSession(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	client, err := NewControlClient(ctx, "", session)
+	if err != nil {
+		t.Skipf("control mode unavailable: %v", err)
+	}
+	defer client.Close()
+
+	target := session + ":0.0"
+	var mu sync.Mutex
+	var lines []string
+	cfg := DefaultPaneStreamerConfig()
+	cfg.FIFODir = t.TempDir()
+	ps := NewPaneStreamer(client, target, func(ev StreamEvent) {
+		mu.Lock()
+		lines = append(lines, ev.Lines...)
+		mu.Unlock()
+	}, cfg)
+	if err := ps.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ps.Stop()
+
+	if !ps.UsingControlMode() {
+		t.Fatal("expected streamer to use control mode")
+	}
+
+	if err := client.SendKeys(target, "echo ntm_control_marker", true); err != nil {
+		t.Fatalf("SendKeys: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		joined := strings.Join(lines, "\n")
+		mu.Unlock()
+		if strings.Count(joined, "ntm_control_marker") >= 2 {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("marker not streamed; got %q", strings.Join(lines, "\n"))
+}
+
+func TestPaneStreamerAttachesControlMode(t *testing.T) {
+	session := createTestSession(t)
+
+	client := NewClient("")
+	defer client.Close()
+
+	cfg := DefaultPaneStreamerConfig()
+	cfg.FIFODir = t.TempDir()
+	cfg.ControlMode = true
+	ps := NewPaneStreamer(client, session+":0.0", func(StreamEvent) {}, cfg)
+	if err := ps.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ps.Stop()
+
+	if cm := client.Control(); cm == nil || cm.Session() != session {
+		t.Skip("control mode unavailable")
+	}
+	if !ps.UsingControlMode() {
+		t.Error("expected streamer to use the attached control-mode connection")
+	}
+	// Other commands share the connection.
+	if out, err := client.CaptureForStatusDetection(session + ":0.0"); err != nil {
+		t.Errorf("CaptureForStatusDetection: %q, %v", out, err)
+	}
+}
@@ -43,6 +43,10 @@ type PaneStreamerConfig struct {
 
 	// FallbackPollLines is the number of lines to capture in polling mode (default: 50)
 	FallbackPollLines int
+
+	// ControlMode attaches the client to the pane's session in control mode
+	// when it has no connection, so output arrives as %output notifications
+	ControlMode bool
 }
 
 // DefaultPaneStreamerConfig returns sensible defaults.
@@ -70,6 +74,8 @@ type PaneStreamer struct {
 	fifoPath    string
 	seq         int64
 	useFallback atomic.Bool
+	useControl  atomic.Bool
+	unsubscribe func()
 
 	mu       sync.Mutex
 	running  bool
@@ -117,8 +123,10 @@ func (ps *PaneStreamer) Start(ctx context.Context) (err error) {
 	ps.ctx = ctx
 	ps.stopCh = make(chan struct{})
 	ps.useFallback.Store(false)
+	ps.useControl.Store(false)
 	ps.lastHash = ""
 	ps.fifoPath = ""
+	ps.unsubscribe = nil
 	ps.mu.Unlock()
 
 	defer func() {
@@ -138,6 +146,22 @@ func (ps *PaneStreamer) Start(ctx context.Context) (err error) {
 		}
 	}()
 
+	if ps.config.ControlMode {
+		session, _, _ := strings.Cut(ps.target, ":")
+		if cerr := ps.client.AttachControlMode(ctx, session); cerr != nil {
+			log.Printf("control-mode: cannot attach to %s, using pipe-pane: %v", session, cerr)
+		}
+	}
+
+	// Prefer %output notifications when the client has a control-mode connection.
+	if cm := ps.client.Control(); cm != nil && cm.Alive() {
+		cerr := ps.startControlStreaming(cm)
+		if cerr == nil {
+			return nil
+		}
+		log.Printf("control-mode: cannot stream %s, using pipe-pane: %v", ps.target, cerr)
+	}
+
 	// Ensure FIFO directory exists
 	if err = os.MkdirAll(ps.config.FIFODir, 0755); err != nil {
 		return fmt.Errorf("create fifo dir: %w", err)
@@ -169,6 +193,11 @@ func (ps *PaneStreamer) Stop() {
 		close(stopCh)
 	}
 
+	if ps.unsubscribe != nil {
+		ps.unsubscribe()
+		ps.unsubscribe = nil
+	}
+
 	// Stop pipe-pane
 	if ps.fifoPath != "" {
 		_ = ps.client.RunSilent("pipe-pane", "-t", ps.target)
@@ -193,6 +222,12 @@ func (ps *PaneStreamer) UsingFallback() bool {
 	return ps.useFallback.Load()
 }
 
+// UsingControlMode returns true if output arrives via control-mode %output
+// notifications.
+func (ps *PaneStreamer) UsingControlMode() bool {
+	return ps.useControl.Load()
+}
+
 // nextSeq returns the next sequence number.
 func (ps *PaneStreamer) nextSeq() int64 {
 	return atomic.AddInt64(&ps.seq, 1)
@@ -320,6 +355,103 @@ func (ps *PaneStreamer) runFIFOReader() {
 	}
 }
 
+// startControlStreaming subscribes to %output notifications for the target
+// pane. tmux only reports output for panes in the control client's attached
+// session, so other targets return an error and use pipe-pane instead.
+func (ps *PaneStreamer) startControlStreaming(cm *ControlMode) error {
+	ctx, cancel := context.WithTimeout(ps.ctx, 2*time.Second)
+	defer cancel()
+	info, err := ps.client.RunContext(ctx, "display-message", "-p", "-t", ps.target, "#{pane_id} #{session_name}")
+	if err != nil {
+		return fmt.Errorf("resolve pane: %w", err)
+	}
+	paneID, session, _ := strings.Cut(info, " ")
+	if session != cm.Session() {
+		return fmt.Errorf("pane %s is in session %q, control client is attached to %q", paneID, session, cm.Session())
+	}
+
+	events, unsubscribe := cm.Subscribe(1024)
+	ps.unsubscribe = unsubscribe
+	ps.useControl.Store(true)
+	log.Printf("control-mode: streaming %s (%s)", ps.target, paneID)
+
+	ps.wg.Add(1)
+	go ps.runControlReader(paneID, events)
+	return nil
+}
+
+// runControlReader turns %output notifications for one pane into line events.
+func (ps *PaneStreamer) runControlReader(paneID string, events <-chan ControlEvent) {
+	defer ps.wg.Done()
+
+	ctx := ps.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	stopCh := ps.stopCh
+
+	var partial strings.Builder
+	var lineBuf []string
+	flushTicker := time.NewTicker(ps.config.FlushInterval)
+	defer flushTicker.Stop()
+
+	flushLines := func() {
+		if len(lineBuf) == 0 {
+			return
+		}
+		ps.callback(StreamEvent{
+			Target:    ps.target,
+			Lines:     lineBuf,
+			Seq:       ps.nextSeq(),
+			Timestamp: time.Now(),
+			IsFull:    false,
+		})
+		lineBuf = nil
+	}
+
+	for {
+		select {
+		case <-stopCh:
+			flushLines()
+			return
+		case <-ctx.Done():
+			flushLines()
+			return
+		case <-flushTicker.C:
+			flushLines()
+		case ev, ok := <-events:
+			if !ok {
+				// Connection closed: keep the stream alive by polling.
+				flushLines()
+				log.Printf("control-mode: connection closed while streaming %s, switching to fallback", ps.target)
+				ps.useControl.Store(false)
+				ps.useFallback.Store(true)
+				ps.wg.Add(1)
+				go ps.runPollingLoop()
+				return
+			}
+			if ev.Type == ControlEventPaneExited && ev.PaneID == paneID {
+				flushLines()
+				return
+			}
+			if ev.Type != ControlEventOutput || ev.PaneID != paneID {
+				continue
+			}
+			partial.WriteString(ev.Data)
+			buffered := partial.String()
+			lines := strings.Split(buffered, "\n")
+			partial.Reset()
+			partial.WriteString(lines[len(lines)-1])
+			for _, line := range lines[:len(lines)-1] {
+				lineBuf = append(lineBuf, strings.TrimSuffix(line, "\r"))
+				if len(lineBuf) >= ps.config.MaxLinesPerEvent {
+					flushLines()
+				}
+			}
+		}
+	}
+}
+
 // runPollingLoop polls capture-pane as a fallback.
 func (ps *PaneStreamer) runPollingLoop() {
 	defer ps.wg.Done()
@@ -482,10 +614,13 @@ func (sm *StreamManager) Stats() map[string]interface{} {
 	active := len(sm.streamers)
 	pipePaneCount := 0
 	fallbackCount := 0
+	controlCount := 0
 
 	for _, s := range sm.streamers {
 		if s.UsingFallback() {
 			fallbackCount++
+		} else if s.UsingControlMode() {
+			controlCount++
 		} else {
 			pipePaneCount++
 		}
@@ -495,6 +630,7 @@ func (sm *StreamManager) Stats() map[string]interface{} {
 		"active_streams":    active,
 		"pipe_pane_count":   pipePaneCount,
 		"fallback_count":    fallbackCount,
+		"control_count":     controlCount,
 		"fifo_dir":          sm.config.FIFODir,
 		"flush_interval_ms": sm.config.FlushInterval.Milliseconds(),
 	}
@@ -883,7 +883,13 @@ func (c *Client) SendBufferWithDelay(target, content string, enter bool, enterDe
 
 	// Load content into a tmux buffer
 	// We use 'load-buffer' with stdin to handle arbitrary content including special characters
-	if c.Remote == "" {
+	if cm := c.control.Load(); cm != nil && cm.Alive() {
+		// Control mode escapes arbitrary content itself, so set-buffer avoids
+		// spawning a load-buffer process.
+		if err := c.RunSilent("set-buffer", "-b", bufferName, "--", content); err != nil {
+			return fmt.Errorf("set buffer: %w", err)
+		}
+	} else if c.Remote == "" {
 		// Local: use load-buffer with a pipe
 		if err := c.loadBufferLocal(bufferName, content); err != nil {
 			return fmt.Errorf("load buffer: %w", err)
@@ -930,9 +936,7 @@ func (c *Client) loadBufferRemote(bufferName, content string) error {
 	// Use printf with escaped content to avoid shell interpretation issues
 	quotedContent := ShellQuote(content)
 	remoteCmd := fmt.Sprintf("printf %%s %s | tmux load-buffer -b %s -", quotedContent, ShellQuote(bufferName))
-	sshArgs := []string{"--", c.Remote, "/bin/sh", "-c", ShellQuote(remoteCmd)}
-
-	cmd := exec.Command("ssh", sshArgs...)
+	cmd := exec.Command("ssh", c.sshArgs(false, "/bin/sh", "-c", ShellQuote(remoteCmd))...)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
@@ -1073,9 +1077,7 @@ func (c *Client) AttachOrSwitch(session string) error {
 	// Remote attach
 	// ssh -t user@host tmux attach -t session
 	remoteCmd := buildRemoteShellCommand("tmux", "attach", "-t", session)
-	// Use "--" to prevent Remote from being parsed as an ssh option.
-	sshArgs := []string{"-t", "--", c.Remote, remoteCmd}
-	cmd := exec.Command("ssh", sshArgs...)
+	cmd := exec.Command("ssh", c.sshArgs(true, remoteCmd)...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -1185,6 +1187,50 @@ func CapturePaneOutputContext(ctx context.Context, target string, lines int) (st
 	return DefaultClient.CapturePaneOutputContext(ctx, target, lines)
 }
 
+// PaneCursorLine returns the absolute scrollback line of the cursor in
+// target: the lines in the pane's history plus the cursor row. It is only a
+// position hint: once the history reaches history-limit tmux drops its
+// oldest lines, which moves every later line up.
+func (c *Client) PaneCursorLine(target string) (int, error) {
+	out, err := c.Run("display-message", "-p", "-t", target, "#{history_size} #{cursor_y}")
+	if err != nil {
+		return 0, err
+	}
+	var history, cursor int
+	if _, err := fmt.Sscanf(out, "%d %d", &history, &cursor); err != nil {
+		return 0, fmt.Errorf("parse cursor position %q: %w", out, err)
+	}
+	return history + cursor, nil
+}
+
+// PaneCursorLine returns the absolute line of the cursor in target (default client)
+func PaneCursorLine(target string) (int, error) {
+	return DefaultClient.PaneCursorLine(target)
+}
+
+// CapturePaneHistory captures target's whole history and visible screen,
+// joining wrapped lines, so line i of the result is roughly absolute line i
+// as returned by PaneCursorLine (blank lines at the very top are trimmed).
+// When the pane shows the alternate screen, as full-screen TUIs do, only
+// that screen is captured and alternate is true.
+func (c *Client) CapturePaneHistory(target string) (text string, alternate bool, err error) {
+	out, err := c.Run("display-message", "-p", "-t", target, "#{alternate_on}")
+	if err != nil {
+		return "", false, err
+	}
+	if strings.TrimSpace(out) == "1" {
+		text, err = c.Run("capture-pane", "-t", target, "-p", "-J")
+		return text, true, err
+	}
+	text, err = c.Run("capture-pane", "-t", target, "-p", "-J", "-S", "-")
+	return text, false, err
+}
+
+// CapturePaneHistory captures target's whole history and screen (default client)
+func CapturePaneHistory(target string) (string, bool, error) {
+	return DefaultClient.CapturePaneHistory(target)
+}
+
 // GetCurrentSession returns the current session name (if in tmux)
 func (c *Client) GetCurrentSession() string {
 	if c.Remote == "" {
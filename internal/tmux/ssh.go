@@ -0,0 +1,86 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SSHControlPersist is how long a shared ssh connection stays open after its
+// last command exits.
+var SSHControlPersist = "10m"
+
+// sshControlDir returns the directory holding ssh ControlMaster sockets, or
+// "" when connection sharing is disabled (NTM_SSH_CONTROL_DIR=off) or the
+// directory cannot be created.
+func sshControlDir() string {
+	dir := os.Getenv("NTM_SSH_CONTROL_DIR")
+	switch dir {
+	case "off", "none":
+		return ""
+	case "":
+		if runtime := os.Getenv("XDG_RUNTIME_DIR"); runtime != "" {
+			dir = filepath.Join(runtime, "ntm-ssh")
+		} else {
+			dir = filepath.Join(os.TempDir(), fmt.Sprintf("ntm-ssh-%d", os.Getuid()))
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return ""
+	}
+	return dir
+}
+
+// sshArgs builds the ssh argument list for running command on remote. Every
+// command to the same host reuses one ControlMaster connection, so only the
+// first pays for the handshake. tty requests a terminal for interactive use.
+func sshArgs(remote string, options []string, tty bool, command ...string) []string {
+	var args []string
+	if tty {
+		args = append(args, "-t")
+	}
+	if dir := sshControlDir(); dir != "" {
+		args = append(args,
+			"-o", "ControlMaster=auto",
+			"-o", "ControlPath="+filepath.Join(dir, "%C"),
+			"-o", "ControlPersist="+SSHControlPersist,
+		)
+	}
+	args = append(args, options...)
+	// Use "--" to prevent remote from being parsed as an ssh option.
+	args = append(args, "--", remote)
+	return append(args, command...)
+}
+
+// sshArgs builds the ssh argument list for running command on the client's host.
+func (c *Client) sshArgs(tty bool, command ...string) []string {
+	return sshArgs(c.Remote, c.SSHOptions, tty, command...)
+}
+
+// MakeDir creates dir on the client's host if needed and returns its absolute
+// path. On a remote host a leading "~/" is relative to the remote home.
+func (c *Client) MakeDir(ctx context.Context, dir string) (string, error) {
+	if c.Remote == "" {
+		if strings.HasPrefix(dir, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(home, dir[2:])
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+		return filepath.Abs(dir)
+	}
+
+	quoted := ShellQuote(dir)
+	if dir == "~" {
+		quoted = `"$HOME"`
+	} else if strings.HasPrefix(dir, "~/") {
+		quoted = `"$HOME"/` + ShellQuote(dir[2:])
+	}
+	return runSSHContext(ctx, c.sshArgs(false, fmt.Sprintf("mkdir -p %s && cd %s && pwd", quoted, quoted))...)
+}
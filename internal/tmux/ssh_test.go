@@ -0,0 +1,134 @@
+package tmux
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSSH puts an ssh shim first on PATH. It records its arguments, one per
+// line, and prints output as the remote command's result. It returns the
+// path of the argument log.
+func fakeSSH(t *testing.T, output string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("ssh shim needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "args.log")
+	outPath := filepath.Join(dir, "output")
+	if err := os.WriteFile(outPath, []byte(output), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + ShellQuote(logPath) + "\ncat " + ShellQuote(outPath) + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logPath
+}
+
+func readSSHArgs(t *testing.T, logPath string) []string {
+	t.Helper()
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestRemoteClient_SharesSSHConnection(t *testing.T) {
+	controlDir := t.TempDir()
+	t.Setenv("NTM_SSH_CONTROL_DIR", controlDir)
+	logPath := fakeSSH(t, "api"+FieldSeparator+"2"+FieldSeparator+"0"+FieldSeparator+"Mon Jan 1\n")
+
+	c := &Client{Remote: "ubuntu@build-box", SSHOptions: []string{"-p", "2222"}}
+	sessions, err := c.ListSessions()
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Name != "api" || sessions[0].Windows != 2 {
+		t.Errorf("ListSessions() = %+v", sessions)
+	}
+
+	args := readSSHArgs(t, logPath)
+	for _, want := range []string{"ControlMaster=auto", "ControlPath=" + filepath.Join(controlDir, "%C"), "2222", "ubuntu@build-box"} {
+		if !slices.Contains(args, want) {
+			t.Errorf("ssh args %q missing %q", args, want)
+		}
+	}
+	if i := slices.Index(args, "--"); i < 0 || args[i+1] != "ubuntu@build-box" {
+		t.Errorf("ssh args %q: host must follow --", args)
+	}
+	if last := args[len(args)-1]; !strings.HasPrefix(last, "/bin/sh -c ") || !strings.Contains(last, "list-sessions") {
+		t.Errorf("remote command = %q", last)
+	}
+}
+
+func TestRemoteClient_NoControlMaster(t *testing.T) {
+	t.Setenv("NTM_SSH_CONTROL_DIR", "off")
+	logPath := fakeSSH(t, "")
+
+	if _, err := NewClient("box").ListSessions(); err != nil {
+		t.Fatal(err)
+	}
+	for _, arg := range readSSHArgs(t, logPath) {
+		if strings.HasPrefix(arg, "Control") {
+			t.Errorf("unexpected ssh option %q", arg)
+		}
+	}
+}
+
+func TestRemoteClient_MakeDir(t *testing.T) {
+	t.Setenv("NTM_SSH_CONTROL_DIR", "off")
+	logPath := fakeSSH(t, "/home/ubuntu/projects/api\n")
+
+	got, err := NewClient("box").MakeDir(context.Background(), "~/projects/api")
+	if err != nil || got != "/home/ubuntu/projects/api" {
+		t.Fatalf("MakeDir() = %q, %v", got, err)
+	}
+	args := readSSHArgs(t, logPath)
+	if last := args[len(args)-1]; !strings.Contains(last, "mkdir -p") || !strings.Contains(last, "$HOME") {
+		t.Errorf("remote command = %q", last)
+	}
+}
+
+func TestLocalClient_MakeDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "a", "b")
+	got, err := NewClient("").MakeDir(context.Background(), dir)
+	if err != nil || got != dir {
+		t.Fatalf("MakeDir() = %q, %v", got, err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("directory not created: %v", err)
+	}
+}
+
+func TestRemoteClient_ControlModeUsesSSHOptions(t *testing.T) {
+	t.Setenv("NTM_SSH_CONTROL_DIR", "off")
+	logPath := fakeSSH(t, "")
+
+	c := &Client{Remote: "ubuntu@build-box", SSHOptions: []string{"-p", "2222", "-i", "/keys/box"}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// The shim exits without speaking the control protocol, so attaching fails.
+	if cm, err := c.StartControlMode(ctx, "api"); err == nil {
+		cm.Close()
+		t.Fatal("StartControlMode() succeeded against a fake ssh")
+	}
+
+	args := readSSHArgs(t, logPath)
+	for _, want := range []string{"2222", "/keys/box", "ubuntu@build-box"} {
+		if !slices.Contains(args, want) {
+			t.Errorf("ssh args %q missing %q", args, want)
+		}
+	}
+	if last := args[len(args)-1]; !strings.Contains(last, "attach-session") {
+		t.Errorf("remote command = %q", last)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/handoff"
 	"github.com/Dicklesworthstone/ntm/internal/history"
 	"github.com/Dicklesworthstone/ntm/internal/integrations/pt"
+	"github.com/Dicklesworthstone/ntm/internal/pipeline"
 	"github.com/Dicklesworthstone/ntm/internal/robot"
 	"github.com/Dicklesworthstone/ntm/internal/state"
 	"github.com/Dicklesworthstone/ntm/internal/tracker"
@@ -42,6 +43,8 @@ func (m *Model) fetchBeadsCmd() tea.Cmd {
 func (m *Model) fetchAlertsCmd() tea.Cmd {
 	gen := m.nextGen(refreshAlerts)
 	cfg := m.cfg
+	projectDir := m.projectDir
+	session := m.session
 	return func() tea.Msg {
 		var alertCfg alerts.Config
 		if cfg != nil {
@@ -61,10 +64,47 @@ func (m *Model) fetchAlertsCmd() tea.Cmd {
 		// Use GenerateAndTrack to benefit from lifecycle management and error handling
 		tracker := alerts.GenerateAndTrack(alertCfg)
 		activeAlerts := tracker.GetActive()
+		activeAlerts = append(activeAlerts, pipelineGateAlerts(projectDir, session)...)
 		return AlertsUpdateMsg{Alerts: activeAlerts, Gen: gen}
 	}
 }
 
+// pipelineGateAlerts surfaces pipelines paused at an approval gate so the
+// operator can see what is waiting on them.
+func pipelineGateAlerts(projectDir, session string) []alerts.Alert {
+	if projectDir == "" {
+		return nil
+	}
+	gates, err := pipeline.ListPendingGates(projectDir)
+	if err != nil {
+		return nil
+	}
+
+	var result []alerts.Alert
+	for _, g := range gates {
+		if session != "" && g.Session != "" && g.Session != session {
+			continue
+		}
+		result = append(result, alerts.Alert{
+			ID:       "gate-" + g.ApprovalID,
+			Type:     alerts.AlertApprovalPending,
+			Severity: alerts.SeverityWarning,
+			Source:   "pipeline",
+			Message:  fmt.Sprintf("%s/%s awaiting approval: %s (ntm approve %s)", g.WorkflowID, g.StepID, g.Message, g.ApprovalID),
+			Session:  g.Session,
+			Context: map[string]interface{}{
+				"run_id":      g.RunID,
+				"step_id":     g.StepID,
+				"approval_id": g.ApprovalID,
+			},
+			CreatedAt:  g.RequestedAt,
+			LastSeenAt: time.Now(),
+			Count:      1,
+		})
+	}
+	return result
+}
+
 // fetchMetricsCmd refreshes observability metrics.
 func (m *Model) fetchMetricsCmd() tea.Cmd {
 	gen := m.nextGen(refreshMetrics)
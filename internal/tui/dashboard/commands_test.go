@@ -13,11 +13,52 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/config"
 	"github.com/Dicklesworthstone/ntm/internal/ensemble"
 	"github.com/Dicklesworthstone/ntm/internal/history"
+	"github.com/Dicklesworthstone/ntm/internal/pipeline"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 	"github.com/Dicklesworthstone/ntm/internal/tracker"
 	"github.com/Dicklesworthstone/ntm/internal/tui/dashboard/panels"
 )
 
+func TestPipelineGateAlerts(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	paused := &pipeline.ExecutionState{
+		RunID:      "run-gated",
+		WorkflowID: "release",
+		Session:    "proj",
+		Status:     pipeline.StatusPaused,
+		Steps: map[string]pipeline.StepResult{
+			"push": {StepID: "push", Status: pipeline.StatusPaused, ApprovalID: "appr-1", Output: "Push main?"},
+		},
+	}
+	other := &pipeline.ExecutionState{
+		RunID:   "run-other",
+		Session: "elsewhere",
+		Status:  pipeline.StatusPaused,
+		Steps: map[string]pipeline.StepResult{
+			"gate": {StepID: "gate", Status: pipeline.StatusPaused, ApprovalID: "appr-2"},
+		},
+	}
+	for _, st := range []*pipeline.ExecutionState{paused, other} {
+		if err := pipeline.SaveState(dir, st); err != nil {
+			t.Fatalf("SaveState() error: %v", err)
+		}
+	}
+
+	got := pipelineGateAlerts(dir, "proj")
+	if len(got) != 1 {
+		t.Fatalf("got %d gate alerts, want 1: %+v", len(got), got)
+	}
+	if got[0].Type != alerts.AlertApprovalPending || got[0].Context["approval_id"] != "appr-1" {
+		t.Errorf("alert = %+v", got[0])
+	}
+
+	if got := pipelineGateAlerts("", "proj"); got != nil {
+		t.Errorf("expected no alerts without a project dir, got %+v", got)
+	}
+}
+
 func TestFetchBeadsCmd_NoBv(t *testing.T) {
 	t.Parallel()
 
@@ -1,6 +1,11 @@
 package dashboard
 
-import "testing"
+import (
+	"testing"
+	"time"
+
+	ctxmon "github.com/Dicklesworthstone/ntm/internal/context"
+)
 
 func TestTailDelta(t *testing.T) {
 	tests := []struct {
@@ -50,3 +55,34 @@ func TestTailDelta(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyTranscriptUsage(t *testing.T) {
+	m := newTestModel(120)
+	m.costOutputTokens["1"] = 50 // estimate from pane output
+
+	m.applyTranscriptUsage(map[string]*ctxmon.TranscriptUsage{
+		"1": {
+			Model:           "gpt-5-codex",
+			InputTokens:     3000,
+			CacheReadTokens: 6000,
+			OutputTokens:    700,
+			ContextTokens:   68000,
+			ContextWindow:   272000,
+			Turns:           4,
+		},
+	})
+
+	ps := m.paneStatus[1]
+	if !ps.ContextExact || ps.ContextTokens != 68000 || ps.ContextLimit != 272000 || ps.ContextPercent != 25 {
+		t.Fatalf("pane status = %+v", ps)
+	}
+
+	m.refreshCostPanel(time.Now())
+	if len(m.costData.Agents) != 1 {
+		t.Fatalf("cost rows = %d", len(m.costData.Agents))
+	}
+	row := m.costData.Agents[0]
+	if row.InputTokens != 9000 || row.OutputTokens != 700 || row.Model != "gpt-5-codex" {
+		t.Errorf("cost row = %+v, want transcript figures", row)
+	}
+}
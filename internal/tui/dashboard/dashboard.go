@@ -21,6 +21,7 @@ import (
 
 	"github.com/Dicklesworthstone/ntm/internal/agentmail"
 	"github.com/Dicklesworthstone/ntm/internal/alerts"
+	"github.com/Dicklesworthstone/ntm/internal/budget"
 	"github.com/Dicklesworthstone/ntm/internal/bv"
 	"github.com/Dicklesworthstone/ntm/internal/cass"
 	"github.com/Dicklesworthstone/ntm/internal/checkpoint"
@@ -525,6 +526,11 @@ type Model struct {
 	costSnapshots           []costSnapshot     // rolling window for last-hour computations
 	costDailyBudgetUSD      float64            // 0 disables budget display
 
+	// Exact usage read from agent transcripts; preferred over the estimates above
+	transcripts *ctxmon.TranscriptEstimator
+	costUsage   map[string]*ctxmon.TranscriptUsage // paneID -> transcript usage
+	costBudget  *budget.Status                     // Configured spend budgets, if any
+
 	// Process triage health states (from pt.HealthMonitor)
 	healthStates map[string]*pt.AgentState // pane -> health state
 
@@ -617,6 +623,11 @@ type PaneStatus struct {
 	ContextPercent float64 // Usage percentage (0-100+)
 	ContextModel   string  // Model name for context limit lookup
 
+	// Set when the figures above come from the agent's own transcript
+	ContextExact      bool
+	ContextCacheRead  int // Cumulative cache-read tokens
+	ContextCacheWrite int // Cumulative cache-write tokens
+
 	// Agent Mail inbox tracking
 	MailUnread int
 	MailUrgent int
@@ -774,6 +785,7 @@ func New(session, projectDir string) Model {
 		costOutputTokens:           make(map[string]int),
 		costModels:                 make(map[string]string),
 		costLastCosts:              make(map[string]float64),
+		transcripts:                ctxmon.NewTranscriptEstimator(ctxmon.DefaultTranscriptLocator()),
 		refreshInterval:            DefaultRefreshInterval,
 		paneRefreshInterval:        PaneRefreshInterval,
 		contextRefreshInterval:     ContextRefreshInterval,
@@ -1775,6 +1787,8 @@ type PaneOutputData struct {
 type SessionDataWithOutputMsg struct {
 	Panes             []tmux.Pane
 	Outputs           []PaneOutputData
+	Usage             map[string]*ctxmon.TranscriptUsage // paneID -> exact transcript usage
+	Budget            *budget.Status                     // Nil when no budget is configured
 	Duration          time.Duration
 	NextCaptureCursor int
 	Err               error
@@ -1996,6 +2010,9 @@ func (m *Model) fetchSessionDataWithOutputsCtx(ctx context.Context) tea.Cmd {
 	}
 
 	session := m.session
+	projectDir := m.projectDir
+	transcripts := m.transcripts
+	cfg := m.cfg
 
 	return func() tea.Msg {
 		start := time.Now()
@@ -2060,9 +2077,12 @@ func (m *Model) fetchSessionDataWithOutputsCtx(ctx context.Context) tea.Cmd {
 			return SessionDataWithOutputMsg{Err: err, Duration: time.Since(start), Gen: gen}
 		}
 
+		usage := readTranscriptUsage(transcripts, panes, projectDir)
 		return SessionDataWithOutputMsg{
 			Panes:             panes,
 			Outputs:           outputs,
+			Usage:             usage,
+			Budget:            observeBudget(cfg, session, projectDir, panes, usage),
 			Duration:          time.Since(start),
 			NextCaptureCursor: plan.NextCursor,
 			Gen:               gen,
@@ -2842,8 +2862,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					timelineUpdated = true
 				}
 
-				// Calculate context usage
-				if data.Output != "" && modelName != "" {
+				// Calculate context usage (transcript figures are applied below)
+				if data.Output != "" && modelName != "" && !ps.ContextExact {
 					contextInfo := tokens.GetUsageInfo(data.Output, modelName)
 					ps.ContextTokens = contextInfo.EstimatedTokens
 					ps.ContextLimit = contextInfo.ContextLimit
@@ -2866,6 +2886,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if timelineUpdated {
 				m.refreshTimelinePanel()
 			}
+			m.applyTranscriptUsage(msg.Usage)
+			m.costBudget = msg.Budget
 
 			// Refresh cost panel from prompt history + accumulated output deltas.
 			now := time.Now()
@@ -4505,6 +4527,8 @@ func (m Model) renderHeaderContextLine(width int) string {
 	remote := strings.TrimSpace(tmux.DefaultClient.Remote)
 	if remote == "" {
 		parts = append(parts, "local")
+	} else if host := tmux.DefaultClient.Host; host != "" {
+		parts = append(parts, "host "+host)
 	} else {
 		parts = append(parts, "ssh "+remote)
 	}
@@ -5394,6 +5418,7 @@ func (m *Model) refreshCostPanel(now time.Time) {
 
 	var rows []panels.CostAgentRow
 	var total float64
+	var breakdown cost.CostBreakdown
 
 	for _, p := range m.panes {
 		if p.Type == tmux.AgentUser {
@@ -5411,9 +5436,20 @@ func (m *Model) refreshCostPanel(now time.Time) {
 		inputTokens := m.costInputTokens[p.ID]
 		outputTokens := m.costOutputTokens[p.ID]
 
-		pricing := cost.GetModelPricing(modelName)
-		costUSD := (float64(inputTokens)/1000.0)*pricing.InputPer1K + (float64(outputTokens)/1000.0)*pricing.OutputPer1K
+		var agentBreakdown cost.CostBreakdown
+		if u := m.costUsage[p.ID]; u != nil {
+			exact := u.AgentCost(modelName)
+			modelName = exact.Model
+			inputTokens = int(u.TotalInputTokens())
+			outputTokens = exact.OutputTokens
+			agentBreakdown = exact.Breakdown()
+		} else {
+			estimate := cost.AgentCost{InputTokens: inputTokens, OutputTokens: outputTokens, Model: modelName}
+			agentBreakdown = estimate.Breakdown()
+		}
+		costUSD := agentBreakdown.Billed()
 		total += costUSD
+		breakdown.Add(agentBreakdown)
 
 		prevCost := m.costLastCosts[p.ID]
 		delta := costUSD - prevCost
@@ -5439,10 +5475,12 @@ func (m *Model) refreshCostPanel(now time.Time) {
 
 	data := panels.CostPanelData{
 		Agents:          rows,
+		Breakdown:       breakdown,
 		SessionTotalUSD: total,
 		LastHourUSD:     lastHour,
 		DailyBudgetUSD:  m.costDailyBudgetUSD,
 		BudgetUsedUSD:   total,
+		Budget:          m.costBudget,
 	}
 
 	m.costData = data
@@ -6363,6 +6401,12 @@ func (m Model) renderPaneDetail(width int) string {
 			"  %d / %d tokens (%.1f%%)",
 			ps.ContextTokens, ps.ContextLimit, ps.ContextPercent,
 		)))
+		if ps.ContextExact {
+			lines = append(lines, statsStyle.Render(fmt.Sprintf(
+				"  from transcript · cache %d read / %d write",
+				ps.ContextCacheRead, ps.ContextCacheWrite,
+			)))
+		}
 		lines = append(lines, "")
 
 		// Legend for thresholds (kept compact and ASCII-safe)
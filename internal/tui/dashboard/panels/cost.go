@@ -9,6 +9,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/Dicklesworthstone/ntm/internal/budget"
 	"github.com/Dicklesworthstone/ntm/internal/cost"
 	"github.com/Dicklesworthstone/ntm/internal/tui/components"
 	"github.com/Dicklesworthstone/ntm/internal/tui/layout"
@@ -45,11 +46,15 @@ type CostAgentRow struct {
 
 type CostPanelData struct {
 	Agents          []CostAgentRow
+	Breakdown       cost.CostBreakdown // Session cost by token category
 	SessionTotalUSD float64
 	LastHourUSD     float64
 
 	DailyBudgetUSD float64
 	BudgetUsedUSD  float64
+
+	// Budget is the session's spend against configured [budget] limits.
+	Budget *budget.Status
 }
 
 type CostPanel struct {
@@ -114,6 +119,9 @@ func (c *CostPanel) HasData() bool {
 	if c.data.DailyBudgetUSD > 0 {
 		return true
 	}
+	if c.data.Budget != nil && len(c.data.Budget.Scopes) > 0 {
+		return true
+	}
 	return false
 }
 
@@ -136,6 +144,16 @@ func (c *CostPanel) View() string {
 			borderColor = t.Yellow
 		}
 	}
+	if c.data.Budget != nil {
+		switch c.data.Budget.Level {
+		case budget.LevelHard:
+			borderColor = t.Red
+		case budget.LevelSoft:
+			if borderColor != t.Red {
+				borderColor = t.Yellow
+			}
+		}
+	}
 
 	boxStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
@@ -277,6 +295,14 @@ func (c *CostPanel) View() string {
 	}
 	content.WriteString(lipgloss.NewStyle().Foreground(t.Text).Bold(true).Render(totalLine) + "\n")
 
+	if line := formatCostCategories(c.data.Breakdown); line != "" && h >= 12 {
+		content.WriteString(lipgloss.NewStyle().Foreground(t.Subtext).Render(layout.TruncateWidthDefault(line, w-4)) + "\n")
+	}
+	if c.data.Breakdown.Covered > 0 {
+		planLine := fmt.Sprintf("Plan-covered: %s (API price)", cost.FormatCost(c.data.Breakdown.Covered))
+		content.WriteString(lipgloss.NewStyle().Foreground(t.Green).Render(planLine) + "\n")
+	}
+
 	if c.data.DailyBudgetUSD > 0 {
 		remaining := c.data.DailyBudgetUSD - c.data.BudgetUsedUSD
 		remainingStr := cost.FormatCost(remaining)
@@ -297,6 +323,20 @@ func (c *CostPanel) View() string {
 		content.WriteString(lipgloss.NewStyle().Foreground(budgetColor).Bold(true).Render(budgetLine) + "\n")
 	}
 
+	if c.data.Budget != nil {
+		for _, sc := range c.data.Budget.Scopes {
+			budgetColor := t.Green
+			switch sc.Level {
+			case budget.LevelHard:
+				budgetColor = t.Red
+			case budget.LevelSoft:
+				budgetColor = t.Yellow
+			}
+			line := layout.TruncateWidthDefault(formatBudgetScope(sc), w-4)
+			content.WriteString(lipgloss.NewStyle().Foreground(budgetColor).Render(line) + "\n")
+		}
+	}
+
 	if footer := components.RenderFreshnessFooter(components.FreshnessOptions{
 		LastUpdate:      c.LastUpdate(),
 		RefreshInterval: c.Config().RefreshInterval,
@@ -308,6 +348,37 @@ func (c *CostPanel) View() string {
 	return boxStyle.Render(FitToHeight(content.String(), h-4))
 }
 
+// formatBudgetScope renders one budget scope against the next limit it can
+// reach, e.g. "Session budget: $4.20 / $10.00 hard".
+func formatBudgetScope(sc budget.ScopeStatus) string {
+	name := strings.ToUpper(sc.Scope[:1]) + sc.Scope[1:]
+	l := sc.Limits
+	useTokens := strings.HasSuffix(sc.Limit, "_tokens") || (sc.Limit == "" && l.SoftUSD == 0 && l.HardUSD == 0)
+
+	soft, hard := l.SoftUSD, l.HardUSD
+	format := cost.FormatCost
+	spent := sc.Spent.USD
+	if useTokens {
+		soft, hard = float64(l.SoftTokens), float64(l.HardTokens)
+		format = func(v float64) string { return formatTokenShort(int(v)) }
+		spent = float64(sc.Spent.Tokens)
+	}
+	limit, kind := hard, "hard"
+	if hard == 0 || (sc.Level == budget.LevelOK && soft > 0) {
+		limit, kind = soft, "soft"
+	}
+
+	line := fmt.Sprintf("%s budget: %s / %s %s", name, format(spent), format(limit), kind)
+	if sc.Level == budget.LevelHard {
+		if sc.Override != "" {
+			line += " (overridden)"
+		} else {
+			line += " (blocked)"
+		}
+	}
+	return line
+}
+
 func formatTokenShort(tokens int) string {
 	if tokens <= 0 {
 		return "0"
@@ -340,3 +411,29 @@ func padLeft(s string, width int) string {
 	}
 	return strings.Repeat(" ", width-len(s)) + s
 }
+
+// formatCostCategories summarizes a breakdown as "in $x · out $y · ...",
+// omitting empty categories.
+func formatCostCategories(b cost.CostBreakdown) string {
+	categories := []struct {
+		label string
+		usd   float64
+	}{
+		{"in", b.Input},
+		{"out", b.Output},
+		{"cache rd", b.CacheRead},
+		{"cache wr", b.CacheWrite},
+		{"reason", b.Reasoning},
+		{">200K", b.LongContext},
+	}
+	var parts []string
+	for _, c := range categories {
+		if c.usd > 0 {
+			parts = append(parts, c.label+" "+cost.FormatCost(c.usd))
+		}
+	}
+	if len(parts) < 2 {
+		return ""
+	}
+	return strings.Join(parts, " · ")
+}
@@ -1,6 +1,13 @@
 package panels
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/budget"
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/cost"
+)
 
 func TestNewCostPanel(t *testing.T) {
 	panel := NewCostPanel()
@@ -82,3 +89,45 @@ func TestCostPanel_HasData(t *testing.T) {
 		t.Fatal("expected HasData=true when budget is set")
 	}
 }
+
+func TestCostPanel_ShowsCategories(t *testing.T) {
+	panel := NewCostPanel()
+	panel.SetSize(80, 16)
+	panel.SetData(CostPanelData{
+		Agents:          []CostAgentRow{{PaneTitle: "proj__cc_1", CostUSD: 0}},
+		Breakdown:       cost.CostBreakdown{Input: 0.5, Output: 1.25, CacheRead: 0.2, Covered: 1.95},
+		SessionTotalUSD: 0,
+	}, nil)
+
+	view := panel.View()
+	for _, want := range []string{"in $0.500", "out $1.25", "cache rd $0.200", "Plan-covered: $1.95"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q", want)
+		}
+	}
+	if got := formatCostCategories(cost.CostBreakdown{Input: 1}); got != "" {
+		t.Errorf("single category line = %q, want none", got)
+	}
+}
+
+func TestCostPanel_ShowsBudgets(t *testing.T) {
+	status := &budget.Status{Session: "proj", Level: budget.LevelHard, Scopes: []budget.ScopeStatus{
+		budget.Evaluate(budget.ScopeSession, "proj", budget.Spend{USD: 12}, config.BudgetLimits{SoftUSD: 5, HardUSD: 10}),
+		budget.Evaluate(budget.ScopeDaily, "2026-03-01", budget.Spend{Tokens: 1500}, config.BudgetLimits{SoftTokens: 2000}),
+	}}
+
+	panel := NewCostPanel()
+	panel.SetSize(80, 20)
+	panel.SetData(CostPanelData{
+		Agents:          []CostAgentRow{{PaneTitle: "proj__cc_1", CostUSD: 12}},
+		SessionTotalUSD: 12,
+		Budget:          status,
+	}, nil)
+
+	view := panel.View()
+	for _, want := range []string{"Session budget: $12.00 / $10.00 hard (blocked)", "Daily budget: 1.5K / 2.0K soft"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package dashboard
+
+import (
+	"github.com/Dicklesworthstone/ntm/internal/budget"
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	ctxmon "github.com/Dicklesworthstone/ntm/internal/context"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// readTranscriptUsage reads exact token usage from the native transcripts of
+// the agent panes. Panes whose agent has not written a transcript (or whose
+// CLI does not write one) are omitted and keep output-based estimates.
+func readTranscriptUsage(transcripts *ctxmon.TranscriptEstimator, panes []tmux.Pane, projectDir string) map[string]*ctxmon.TranscriptUsage {
+	if transcripts == nil || projectDir == "" {
+		return nil
+	}
+	var usage map[string]*ctxmon.TranscriptUsage
+	for _, p := range panes {
+		switch p.Type {
+		case tmux.AgentClaude, tmux.AgentCodex, tmux.AgentGemini:
+		default:
+			continue
+		}
+		u := transcripts.PaneUsage(p.ID, string(p.Type), projectDir)
+		if u == nil {
+			continue
+		}
+		if usage == nil {
+			usage = make(map[string]*ctxmon.TranscriptUsage)
+		}
+		usage[p.ID] = u
+	}
+	return usage
+}
+
+// observeBudget records the session's transcript spend in the budget ledger
+// and returns its budget status, or nil when no budget is configured.
+func observeBudget(cfg *config.Config, session, projectDir string, panes []tmux.Pane, usage map[string]*ctxmon.TranscriptUsage) *budget.Status {
+	if cfg == nil || !cfg.Budget.Enabled() {
+		return nil
+	}
+	status, err := budget.NewTracker(cfg.Budget).Observe(session, projectDir, budget.SpendFromUsage(cfg, panes, usage))
+	if err != nil {
+		return nil
+	}
+	return status
+}
+
+// applyTranscriptUsage replaces estimated context and cost figures with the
+// exact ones read from transcripts.
+func (m *Model) applyTranscriptUsage(usage map[string]*ctxmon.TranscriptUsage) {
+	if len(usage) == 0 {
+		return
+	}
+	if m.costUsage == nil {
+		m.costUsage = make(map[string]*ctxmon.TranscriptUsage)
+	}
+	for _, p := range m.panes {
+		u, ok := usage[p.ID]
+		if !ok {
+			continue
+		}
+		m.costUsage[p.ID] = u
+
+		ps := m.paneStatus[p.Index]
+		limit := u.ContextLimit(m.resolveCostModelForPane(p))
+		ps.ContextTokens = int(u.ContextTokens)
+		ps.ContextLimit = int(limit)
+		ps.ContextPercent = float64(u.ContextTokens) / float64(limit) * 100
+		if u.Model != "" {
+			ps.ContextModel = u.Model
+		}
+		ps.ContextExact = true
+		ps.ContextCacheRead = int(u.CacheReadTokens)
+		ps.ContextCacheWrite = int(u.CacheCreationTokens)
+		m.paneStatus[p.Index] = ps
+	}
+}
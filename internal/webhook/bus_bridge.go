@@ -20,9 +20,22 @@ type BusBridge struct {
 	unsubscribe events.UnsubscribeFunc
 }
 
+// BridgeConsumerGroup returns the event log consumer group used by the
+// webhook bridge for session.
+func BridgeConsumerGroup(session string) string {
+	if strings.TrimSpace(session) == "" {
+		return "webhook"
+	}
+	return "webhook:" + session
+}
+
 // StartBridgeFromProjectConfig loads .ntm.yaml/.ntm.yml webhooks from projectDir,
 // starts a WebhookManager, and subscribes it to the provided event bus.
 //
+// If the bus has a durable event log, the bridge consumes it as the
+// BridgeConsumerGroup(session) group and resumes after the last event it
+// dispatched, including events published while no bridge was running.
+//
 // If no webhooks are configured for the project, it returns (nil, nil).
 func StartBridgeFromProjectConfig(projectDir, session string, bus *events.EventBus, redactionCfg *redaction.Config) (*BusBridge, error) {
 	if strings.TrimSpace(projectDir) == "" {
@@ -65,7 +78,7 @@ func StartBridgeFromProjectConfig(projectDir, session string, bus *events.EventB
 		return nil, err
 	}
 
-	unsub := bus.SubscribeAll(func(e events.BusEvent) {
+	dispatch := func(e events.BusEvent) {
 		if strings.TrimSpace(session) != "" && e.EventSession() != session {
 			return
 		}
@@ -77,7 +90,22 @@ func StartBridgeFromProjectConfig(projectDir, session string, bus *events.EventB
 
 		// Dispatch is already non-blocking; ignore errors for best-effort delivery.
 		_ = mgr.Dispatch(ev)
-	})
+	}
+
+	// With a durable event log, consume as a named group so a restarted
+	// bridge picks up after the last event it handed to the manager.
+	var unsub events.UnsubscribeFunc
+	if bus.Log() != nil {
+		unsub, err = bus.SubscribeGroup(BridgeConsumerGroup(session), func(_ uint64, e events.BusEvent) {
+			dispatch(e)
+		})
+		if err != nil {
+			_ = mgr.Stop()
+			return nil, fmt.Errorf("subscribing webhook bridge: %w", err)
+		}
+	} else {
+		unsub = bus.SubscribeAll(dispatch)
+	}
 
 	return &BusBridge{
 		session:     session,
@@ -76,6 +76,70 @@ webhooks:
 	}
 }
 
+func TestBusBridge_ResumesFromEventLog(t *testing.T) {
+	t.Parallel()
+
+	recv := make(chan map[string]any, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		recv <- payload
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, ".ntm.yaml"), []byte(`
+webhooks:
+  - name: test
+    url: `+srv.URL+`
+    events: ["agent.error"]
+    formatter: json
+`), 0o644); err != nil {
+		t.Fatalf("write .ntm.yaml: %v", err)
+	}
+
+	logDir := t.TempDir()
+	newBus := func() *events.EventBus {
+		l, err := events.OpenEventLog(logDir, events.EventLogOptions{})
+		if err != nil {
+			t.Fatalf("OpenEventLog: %v", err)
+		}
+		t.Cleanup(func() { _ = l.Close() })
+		bus := events.NewEventBus(10)
+		bus.SetLog(l)
+		return bus
+	}
+
+	// The first bridge registers the consumer group, then goes away.
+	bus := newBus()
+	bridge, err := StartBridgeFromProjectConfig(projectDir, "mysession", bus, nil)
+	if err != nil || bridge == nil {
+		t.Fatalf("StartBridgeFromProjectConfig: %v, %v", bridge, err)
+	}
+	_ = bridge.Close()
+
+	// An event emitted while no bridge runs is delivered by the next one.
+	bus.PublishSync(events.NewWebhookEvent(events.WebhookAgentError, "mysession", "%1", "codex", "while down", nil))
+
+	restarted := newBus()
+	bridge, err = StartBridgeFromProjectConfig(projectDir, "mysession", restarted, nil)
+	if err != nil || bridge == nil {
+		t.Fatalf("StartBridgeFromProjectConfig after restart: %v, %v", bridge, err)
+	}
+	t.Cleanup(func() { _ = bridge.Close() })
+
+	select {
+	case payload := <-recv:
+		if payload["message"] != "while down" {
+			t.Fatalf("message=%v, want %q", payload["message"], "while down")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for replayed webhook delivery")
+	}
+}
+
 // =============================================================================
 // toWebhookEvent — all branches (bd-1ced7)
 // =============================================================================
@@ -42,6 +42,16 @@ func defaultUserConfigDir() string {
 	return filepath.Join(home, ".config", "ntm")
 }
 
+// SearchDirs returns the directories searched for user and project workflow
+// files, highest precedence first: <project>/.ntm/workflows, then
+// <user config>/workflows.
+func (l *Loader) SearchDirs() []string {
+	return []string{
+		filepath.Join(l.ProjectDir, ".ntm", "workflows"),
+		filepath.Join(l.UserConfigDir, "workflows"),
+	}
+}
+
 // builtinWorkflows returns the built-in workflow templates.
 func builtinWorkflows() ([]WorkflowTemplate, error) {
 	entries, err := builtinFS.ReadDir("builtins")
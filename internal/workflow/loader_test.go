@@ -20,6 +20,20 @@ func TestNewLoader(t *testing.T) {
 	}
 }
 
+func TestLoader_SearchDirs(t *testing.T) {
+	loader := &Loader{UserConfigDir: "/home/u/.config/ntm", ProjectDir: "/src/proj"}
+	dirs := loader.SearchDirs()
+	want := []string{"/src/proj/.ntm/workflows", "/home/u/.config/ntm/workflows"}
+	if len(dirs) != len(want) {
+		t.Fatalf("SearchDirs() = %v, want %v", dirs, want)
+	}
+	for i := range want {
+		if dirs[i] != want[i] {
+			t.Errorf("SearchDirs()[%d] = %q, want %q", i, dirs[i], want[i])
+		}
+	}
+}
+
 func TestBuiltinWorkflows(t *testing.T) {
 	workflows, err := builtinWorkflows()
 	if err != nil {
@@ -0,0 +1,483 @@
+package worktrees
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MergeStatus is the outcome of one branch in a merge queue run.
+type MergeStatus string
+
+const (
+	MergeQueued   MergeStatus = "queued"
+	MergeUpToDate MergeStatus = "up_to_date" // nothing to integrate
+	MergeMerged   MergeStatus = "merged"     // rebased, verified and fast-forwarded
+	MergeConflict MergeStatus = "conflict"   // rebase stopped on conflicts
+	MergeFailed   MergeStatus = "failed"     // verification command failed
+	MergeError    MergeStatus = "error"      // git or setup failure
+)
+
+// DefaultVerifyTimeout bounds a verification command when none is configured.
+const DefaultVerifyTimeout = 20 * time.Minute
+
+// maxVerifyOutput is how much of a verification command's output is kept,
+// from the end.
+const maxVerifyOutput = 4000
+
+// maxConflictHunks is how much conflict text is kept per file.
+const maxConflictHunks = 3000
+
+// ConflictFile is a file the rebase could not apply cleanly.
+type ConflictFile struct {
+	Path  string `json:"path"`
+	Hunks string `json:"hunks"` // the conflict-marked regions
+}
+
+// MergeEntry is one agent branch in a merge queue run.
+type MergeEntry struct {
+	Agent      string         `json:"agent"`
+	Branch     string         `json:"branch"`
+	Status     MergeStatus    `json:"status"`
+	Commits    int            `json:"commits,omitempty"` // commits integrated, or waiting to be
+	Head       string         `json:"head,omitempty"`    // integration branch tip after merging
+	Conflicts  []ConflictFile `json:"conflicts,omitempty"`
+	Output     string         `json:"output,omitempty"` // tail of the verification output
+	Error      string         `json:"error,omitempty"`
+	Notified   bool           `json:"notified,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+}
+
+// QueueReport is the state of a merge queue run. It is saved after every
+// branch so other processes can follow the run.
+type QueueReport struct {
+	Session    string       `json:"session"`
+	Target     string       `json:"target"`
+	Verify     string       `json:"verify,omitempty"`
+	Running    bool         `json:"running"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+	Entries    []MergeEntry `json:"entries"`
+}
+
+// Count returns how many entries have status.
+func (r *QueueReport) Count(status MergeStatus) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// ErrQueueBusy is returned when another merge queue run holds the session's lock.
+var ErrQueueBusy = errors.New("merge queue is already running for this session")
+
+// MergeQueue integrates agent worktree branches one at a time. Each branch is
+// rebased onto the integration branch in a scratch worktree, the
+// verification command runs there, and on success the integration branch is
+// fast-forwarded. The user's working tree and checked-out branch are never
+// touched, and a target branch checked out in any worktree is refused.
+type MergeQueue struct {
+	ProjectPath string
+	Session     string
+	// Target is the integration branch (default ntm/<session>/integration,
+	// created from main or master).
+	Target string
+	// Verify is a shell command run in the rebased tree; empty skips it.
+	Verify        string
+	VerifyTimeout time.Duration
+	// Notify, if set, tells the owning agent about a conflict or failed
+	// verification.
+	Notify func(entry MergeEntry, target string) error
+}
+
+// NewMergeQueue returns a merge queue for session's agent branches.
+func NewMergeQueue(projectPath, session string) *MergeQueue {
+	return &MergeQueue{
+		ProjectPath:   projectPath,
+		Session:       session,
+		VerifyTimeout: DefaultVerifyTimeout,
+	}
+}
+
+func (q *MergeQueue) queueDir() string {
+	return filepath.Join(q.ProjectPath, ".ntm", "merge-queue")
+}
+
+// scratchDir returns where the session's scratch worktree is checked out.
+// It lives outside the project so tools scanning the project tree never see
+// a second copy of it; the path is stable per project so a run can remove
+// the leftover of a crashed one.
+func (q *MergeQueue) scratchDir() string {
+	project := q.ProjectPath
+	if abs, err := filepath.Abs(project); err == nil {
+		project = abs
+	}
+	sum := sha256.Sum256([]byte(project))
+	return filepath.Join(os.TempDir(), "ntm-merge-queue", hex.EncodeToString(sum[:6])+"-"+q.Session+"-scratch")
+}
+
+// ReportPath returns where the session's latest run report is saved.
+func ReportPath(projectPath, session string) string {
+	return filepath.Join(projectPath, ".ntm", "merge-queue", session+".json")
+}
+
+// LoadQueueReport returns the session's latest run report, or nil if the
+// queue has never run.
+func LoadQueueReport(projectPath, session string) (*QueueReport, error) {
+	data, err := os.ReadFile(ReportPath(projectPath, session))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read merge queue report: %w", err)
+	}
+	var report QueueReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("parse merge queue report: %w", err)
+	}
+	return &report, nil
+}
+
+func (q *MergeQueue) save(report *QueueReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := ReportPath(q.ProjectPath, q.Session)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write merge queue report: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Branches returns the agent names with a branch in the session, in order.
+func (q *MergeQueue) Branches(ctx context.Context) ([]string, error) {
+	prefix := fmt.Sprintf("refs/heads/ntm/%s/", q.Session)
+	out, err := q.git(ctx, q.ProjectPath, "for-each-ref", "--format=%(refname)", prefix)
+	if err != nil {
+		return nil, err
+	}
+	var agents []string
+	for _, ref := range strings.Fields(out) {
+		agent := strings.TrimPrefix(ref, prefix)
+		if "refs/heads/"+q.target() != ref && !strings.Contains(agent, "/") {
+			agents = append(agents, agent)
+		}
+	}
+	sort.Strings(agents)
+	return agents, nil
+}
+
+func (q *MergeQueue) target() string {
+	if q.Target != "" {
+		return q.Target
+	}
+	return fmt.Sprintf("ntm/%s/integration", q.Session)
+}
+
+// Run integrates the branches of agents in order, or every agent branch in
+// the session when agents is empty. Each branch is rebased onto the
+// integration branch as left by the ones before it. Conflicts and failures
+// are recorded in the report; the returned error is for runs that could not
+// start or were cancelled.
+func (q *MergeQueue) Run(ctx context.Context, agents []string) (*QueueReport, error) {
+	if err := os.MkdirAll(q.queueDir(), 0755); err != nil {
+		return nil, fmt.Errorf("create merge queue directory: %w", err)
+	}
+	unlock, err := lockQueue(filepath.Join(q.queueDir(), q.Session+".lock"))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	target := q.target()
+	if err := q.ensureTarget(ctx, target); err != nil {
+		return nil, err
+	}
+	if path, err := q.checkedOutIn(ctx, target); err != nil {
+		return nil, err
+	} else if path != "" {
+		return nil, fmt.Errorf("target branch %s is checked out in %s; the merge queue only updates branches nobody has checked out", target, path)
+	}
+
+	if len(agents) == 0 {
+		if agents, err = q.Branches(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &QueueReport{
+		Session:   q.Session,
+		Target:    target,
+		Verify:    q.Verify,
+		Running:   true,
+		StartedAt: time.Now().UTC(),
+		Entries:   make([]MergeEntry, len(agents)),
+	}
+	for i, agent := range agents {
+		report.Entries[i] = MergeEntry{
+			Agent:  agent,
+			Branch: fmt.Sprintf("ntm/%s/%s", q.Session, agent),
+			Status: MergeQueued,
+		}
+	}
+	if err := q.save(report); err != nil {
+		return nil, err
+	}
+
+	scratch := q.scratchDir()
+	if err := os.MkdirAll(filepath.Dir(scratch), 0755); err != nil {
+		return nil, fmt.Errorf("create scratch directory: %w", err)
+	}
+	_, _ = q.git(ctx, q.ProjectPath, "worktree", "remove", "--force", scratch) // leftover from a crashed run
+	if _, err := q.git(ctx, q.ProjectPath, "worktree", "add", "--detach", scratch, target); err != nil {
+		return nil, fmt.Errorf("create scratch worktree: %w", err)
+	}
+
+	for i := range report.Entries {
+		if ctx.Err() != nil {
+			break
+		}
+		e := &report.Entries[i]
+		q.integrate(ctx, scratch, target, e)
+		if (e.Status == MergeConflict || e.Status == MergeFailed) && q.Notify != nil {
+			e.Notified = q.Notify(*e, target) == nil
+		}
+		now := time.Now().UTC()
+		e.FinishedAt = &now
+		_ = q.save(report)
+	}
+	_, _ = q.git(context.Background(), q.ProjectPath, "worktree", "remove", "--force", scratch)
+
+	now := time.Now().UTC()
+	report.Running = false
+	report.FinishedAt = &now
+	if err := q.save(report); err != nil {
+		return report, err
+	}
+	return report, ctx.Err()
+}
+
+// integrate rebases, verifies and fast-forwards one branch.
+func (q *MergeQueue) integrate(ctx context.Context, scratch, target string, e *MergeEntry) {
+	fail := func(status MergeStatus, err error) {
+		e.Status = status
+		e.Error = err.Error()
+	}
+
+	base, err := q.git(ctx, q.ProjectPath, "rev-parse", "--verify", "refs/heads/"+target)
+	if err != nil {
+		fail(MergeError, err)
+		return
+	}
+	tip, err := q.git(ctx, q.ProjectPath, "rev-parse", "--verify", "refs/heads/"+e.Branch)
+	if err != nil {
+		fail(MergeError, fmt.Errorf("branch %s not found", e.Branch))
+		return
+	}
+	ahead, err := q.git(ctx, q.ProjectPath, "rev-list", "--count", base+".."+tip)
+	if err != nil {
+		fail(MergeError, err)
+		return
+	}
+	if ahead == "0" {
+		e.Status = MergeUpToDate
+		return
+	}
+	e.Commits, _ = strconv.Atoi(ahead)
+
+	if _, err := q.git(ctx, scratch, "checkout", "--force", "--detach", tip); err != nil {
+		fail(MergeError, err)
+		return
+	}
+	if _, err := q.git(ctx, scratch, "rebase", base); err != nil {
+		e.Conflicts = q.conflicts(ctx, scratch)
+		_, _ = q.git(context.Background(), scratch, "rebase", "--abort")
+		if len(e.Conflicts) > 0 {
+			fail(MergeConflict, fmt.Errorf("rebase onto %s conflicts in %d file(s)", target, len(e.Conflicts)))
+		} else {
+			fail(MergeError, err)
+		}
+		return
+	}
+	head, err := q.git(ctx, scratch, "rev-parse", "HEAD")
+	if err != nil {
+		fail(MergeError, err)
+		return
+	}
+	if n, err := q.git(ctx, scratch, "rev-list", "--count", base+"..HEAD"); err == nil {
+		e.Commits, _ = strconv.Atoi(n)
+	}
+	if e.Commits == 0 {
+		// Every commit was already upstream.
+		e.Status = MergeUpToDate
+		return
+	}
+
+	if q.Verify != "" {
+		out, err := q.runVerify(ctx, scratch)
+		e.Output = out
+		if err != nil {
+			fail(MergeFailed, fmt.Errorf("verification failed: %w", err))
+			return
+		}
+	}
+
+	// Compare-and-swap so a concurrent update to the target is not lost.
+	if _, err := q.git(ctx, q.ProjectPath, "update-ref", "-m", "ntm merge queue: "+e.Branch, "refs/heads/"+target, head, base); err != nil {
+		fail(MergeError, err)
+		return
+	}
+	e.Status = MergeMerged
+	e.Head = head
+}
+
+// runVerify runs the verification command in dir and returns the tail of
+// its combined output.
+func (q *MergeQueue) runVerify(ctx context.Context, dir string) (string, error) {
+	timeout := q.VerifyTimeout
+	if timeout <= 0 {
+		timeout = DefaultVerifyTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", q.Verify)
+	cmd.Dir = dir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	text := strings.TrimSpace(out.String())
+	if len(text) > maxVerifyOutput {
+		text = "…" + text[len(text)-maxVerifyOutput:]
+	}
+	return text, err
+}
+
+// conflicts returns the unmerged files of a stopped rebase with their
+// conflict-marked regions.
+func (q *MergeQueue) conflicts(ctx context.Context, dir string) []ConflictFile {
+	out, err := q.git(ctx, dir, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil
+	}
+	var files []ConflictFile
+	for _, path := range strings.Fields(out) {
+		data, _ := os.ReadFile(filepath.Join(dir, path))
+		files = append(files, ConflictFile{Path: path, Hunks: ConflictHunks(string(data))})
+	}
+	return files
+}
+
+// ConflictHunks extracts the regions between conflict markers, with their
+// line numbers, truncated to a readable size.
+func ConflictHunks(content string) string {
+	var b strings.Builder
+	in := false
+	for i, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "<<<<<<< ") {
+			in = true
+			fmt.Fprintf(&b, "@@ line %d\n", i+1)
+		}
+		if in {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+		if strings.HasPrefix(line, ">>>>>>> ") {
+			in = false
+		}
+		if b.Len() > maxConflictHunks {
+			return b.String()[:maxConflictHunks] + "\n…"
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// checkedOutIn returns the worktree path where branch is checked out, if any.
+func (q *MergeQueue) checkedOutIn(ctx context.Context, branch string) (string, error) {
+	out, err := q.git(ctx, q.ProjectPath, "worktree", "list", "--porcelain")
+	if err != nil {
+		return "", err
+	}
+	var path string
+	for _, line := range strings.Split(out, "\n") {
+		if p, ok := strings.CutPrefix(line, "worktree "); ok {
+			path = p
+		}
+		if line == "branch refs/heads/"+branch {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// ensureTarget creates the default integration branch from main or master.
+func (q *MergeQueue) ensureTarget(ctx context.Context, target string) error {
+	if _, err := q.git(ctx, q.ProjectPath, "rev-parse", "--verify", "refs/heads/"+target); err == nil {
+		return nil
+	}
+	if q.Target != "" {
+		return fmt.Errorf("target branch %s does not exist", target)
+	}
+	for _, base := range []string{"main", "master"} {
+		if _, err := q.git(ctx, q.ProjectPath, "rev-parse", "--verify", "refs/heads/"+base); err == nil {
+			_, err := q.git(ctx, q.ProjectPath, "branch", target, base)
+			return err
+		}
+	}
+	return fmt.Errorf("cannot create %s: no main or master branch", target)
+}
+
+func (q *MergeQueue) git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Message is the note sent to the owning agent when its branch could not
+// be integrated.
+func (e MergeEntry) Message(target string) string {
+	var b strings.Builder
+	switch e.Status {
+	case MergeConflict:
+		paths := make([]string, len(e.Conflicts))
+		for i, c := range e.Conflicts {
+			paths[i] = c.Path
+		}
+		fmt.Fprintf(&b, "The merge queue could not rebase your branch %s onto %s: conflicts in %s.\n", e.Branch, target, strings.Join(paths, ", "))
+		fmt.Fprintf(&b, "Rebase onto %s, resolve the conflicts, commit, and the queue will retry.\n", target)
+		for _, c := range e.Conflicts {
+			fmt.Fprintf(&b, "\n%s:\n%s\n", c.Path, c.Hunks)
+		}
+	case MergeFailed:
+		fmt.Fprintf(&b, "The merge queue rebased your branch %s onto %s, but %s.\n", e.Branch, target, e.Error)
+		b.WriteString("Fix the failure on your branch and commit; the queue will retry.\n")
+		if e.Output != "" {
+			fmt.Fprintf(&b, "\nOutput:\n%s\n", e.Output)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
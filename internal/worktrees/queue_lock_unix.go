@@ -0,0 +1,29 @@
+//go:build unix
+
+package worktrees
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockQueue takes an exclusive, non-blocking flock on path so only one merge
+// queue run per session proceeds. Returns an unlock function.
+func lockQueue(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrQueueBusy
+		}
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package worktrees
+
+import (
+	"errors"
+	"os"
+)
+
+// lockQueue creates path exclusively so only one merge queue run per session
+// proceeds. A lock left by a crashed run must be removed by hand. Returns an
+// unlock function.
+func lockQueue(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, ErrQueueBusy
+		}
+		return nil, err
+	}
+	f.Close()
+	return func() { _ = os.Remove(path) }, nil
+}
@@ -0,0 +1,183 @@
+package worktrees
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitRun runs git in dir and returns its trimmed output.
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitFile writes content to name on a new commit of branch, which is
+// created from start when it does not exist yet. The user's checkout is
+// restored afterwards.
+func commitFile(t *testing.T, repo, branch, start, name, content string) {
+	t.Helper()
+	orig := gitRun(t, repo, "rev-parse", "--abbrev-ref", "HEAD")
+	if start != "" {
+		gitRun(t, repo, "checkout", "-q", "-b", branch, start)
+	} else {
+		gitRun(t, repo, "checkout", "-q", branch)
+	}
+	if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, repo, "add", name)
+	gitRun(t, repo, "commit", "-q", "-m", "edit "+name)
+	gitRun(t, repo, "checkout", "-q", orig)
+}
+
+func TestMergeQueue_Run(t *testing.T) {
+	t.Parallel()
+
+	repo := setupWorktreeGitRepo(t)
+	main := gitRun(t, repo, "rev-parse", "--abbrev-ref", "HEAD")
+	commitFile(t, repo, main, "", "shared.txt", "one\ntwo\nthree\n")
+	mainTip := gitRun(t, repo, "rev-parse", "HEAD")
+
+	commitFile(t, repo, "ntm/s/cc_1", main, "a.txt", "a\n")
+	commitFile(t, repo, "ntm/s/cc_2", main, "shared.txt", "one\nTWO from cc_2\nthree\n")
+	commitFile(t, repo, "ntm/s/cc_3", main, "shared.txt", "one\nTWO from cc_3\nthree\n")
+	commitFile(t, repo, "ntm/s/cod_1", main, "broken", "x\n")
+	gitRun(t, repo, "branch", "ntm/s/gmi_1", main)
+
+	var notified []string
+	q := NewMergeQueue(repo, "s")
+	q.Verify = "test ! -f broken || { echo broken build; exit 1; }"
+	q.Notify = func(e MergeEntry, target string) error {
+		notified = append(notified, e.Agent)
+		if msg := e.Message(target); !strings.Contains(msg, e.Branch) {
+			t.Errorf("Message() = %q, missing branch", msg)
+		}
+		return nil
+	}
+
+	agents, err := q.Branches(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(agents, ",") != "cc_1,cc_2,cc_3,cod_1,gmi_1" {
+		t.Fatalf("Branches() = %v", agents)
+	}
+
+	report, err := q.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := map[string]MergeStatus{
+		"cc_1":  MergeMerged,
+		"cc_2":  MergeMerged,
+		"cc_3":  MergeConflict,
+		"cod_1": MergeFailed,
+		"gmi_1": MergeUpToDate,
+	}
+	for _, e := range report.Entries {
+		if e.Status != want[e.Agent] {
+			t.Errorf("%s: status = %s (%s), want %s", e.Agent, e.Status, e.Error, want[e.Agent])
+		}
+	}
+	if len(report.Entries) != len(want) || report.Running || report.Target != "ntm/s/integration" {
+		t.Errorf("report = %+v", report)
+	}
+
+	conflict := report.Entries[2]
+	if len(conflict.Conflicts) != 1 || conflict.Conflicts[0].Path != "shared.txt" ||
+		!strings.Contains(conflict.Conflicts[0].Hunks, "TWO from cc_3") || !conflict.Notified {
+		t.Errorf("conflict entry = %+v", conflict)
+	}
+	if failed := report.Entries[3]; !strings.Contains(failed.Output, "broken build") || !failed.Notified {
+		t.Errorf("failed entry = %+v", failed)
+	}
+	if strings.Join(notified, ",") != "cc_3,cod_1" {
+		t.Errorf("notified = %v", notified)
+	}
+
+	// The integration branch has both merged changes; the user's branch and
+	// working tree are untouched.
+	if got := gitRun(t, repo, "show", "ntm/s/integration:shared.txt"); !strings.Contains(got, "TWO from cc_2") {
+		t.Errorf("integration shared.txt = %q", got)
+	}
+	gitRun(t, repo, "cat-file", "-e", "ntm/s/integration:a.txt")
+	if got := gitRun(t, repo, "rev-parse", "HEAD"); got != mainTip {
+		t.Errorf("user HEAD moved to %s", got)
+	}
+	if got := gitRun(t, repo, "rev-parse", "--abbrev-ref", "HEAD"); got != main {
+		t.Errorf("user branch = %s, want %s", got, main)
+	}
+	if strings.Contains(gitRun(t, repo, "worktree", "list"), "scratch") {
+		t.Error("scratch worktree left behind")
+	}
+	if _, err := os.Stat(filepath.Join(repo, ".ntm", "merge-queue", "s-scratch")); !os.IsNotExist(err) {
+		t.Errorf("scratch worktree created inside the project: %v", err)
+	}
+	if scratch := NewMergeQueue(repo, "s").scratchDir(); strings.HasPrefix(scratch, repo) {
+		t.Errorf("scratch dir %s is inside the project", scratch)
+	}
+
+	saved, err := LoadQueueReport(repo, "s")
+	if err != nil || saved == nil || saved.Count(MergeMerged) != 2 {
+		t.Errorf("LoadQueueReport() = %+v, %v", saved, err)
+	}
+}
+
+func TestMergeQueue_RefusesCheckedOutTarget(t *testing.T) {
+	t.Parallel()
+
+	repo := setupWorktreeGitRepo(t)
+	main := gitRun(t, repo, "rev-parse", "--abbrev-ref", "HEAD")
+	commitFile(t, repo, "ntm/s/cc_1", main, "a.txt", "a\n")
+
+	q := NewMergeQueue(repo, "s")
+	q.Target = main
+	if _, err := q.Run(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "checked out") {
+		t.Fatalf("Run() error = %v, want checked-out refusal", err)
+	}
+
+	q.Target = "missing"
+	if _, err := q.Run(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Run() error = %v, want missing target", err)
+	}
+}
+
+func TestMergeQueue_Busy(t *testing.T) {
+	t.Parallel()
+
+	repo := setupWorktreeGitRepo(t)
+	q := NewMergeQueue(repo, "s")
+	if err := os.MkdirAll(q.queueDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := lockQueue(filepath.Join(q.queueDir(), "s.lock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	if _, err := q.Run(context.Background(), nil); !errors.Is(err, ErrQueueBusy) {
+		t.Errorf("Run() error = %v, want ErrQueueBusy", err)
+	}
+}
+
+func TestConflictHunks(t *testing.T) {
+	t.Parallel()
+
+	content := "a\n<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> cc_1\nb\n"
+	want := "@@ line 2\n<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> cc_1"
+	if got := ConflictHunks(content); got != want {
+		t.Errorf("ConflictHunks() = %q, want %q", got, want)
+	}
+}